@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/mcp"
+	"github.com/spf13/cobra"
+)
+
+// attachCmd represents the attach command
+var attachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Bridge stdio to a running MCP daemon",
+	Long: `Connect this process's stdin and stdout to a daemon started with
+'mcp-code-api server --daemon'.
+
+Configure attach as the MCP server command in each editor: the editors then
+share the daemon's provider connections, rate limits, cache and metrics
+instead of each starting its own server.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return err
+		}
+		if socket, _ := cmd.Flags().GetString("socket"); socket != "" {
+			cfg.Server.Socket = socket
+		}
+		socket := mcp.DaemonSocket(cfg)
+
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return fmt.Errorf("no daemon listening on %s (start one with 'mcp-code-api server --daemon'): %w", socket, err)
+		}
+		defer conn.Close()
+
+		// Forward requests until the editor closes stdin, then let the
+		// daemon finish the responses still in flight
+		go func() {
+			_, _ = io.Copy(conn, os.Stdin)
+			if unixConn, ok := conn.(*net.UnixConn); ok {
+				_ = unixConn.CloseWrite()
+			}
+		}()
+
+		if _, err := io.Copy(os.Stdout, conn); err != nil {
+			return fmt.Errorf("daemon connection lost: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(attachCmd)
+
+	attachCmd.Flags().String("socket", "", "daemon socket path (default server.socket, then ~/.mcp-code-api/daemon.sock)")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditSince    string
+	auditUntil    string
+	auditFile     string
+	auditProvider string
+	auditLimit    int
+	auditFormat   string
+)
+
+// auditCmd queries the write-tool audit log
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Query the audit log of files written by the server",
+	Long: `Query the append-only audit log of file modifications made by the write
+tool. Each entry records when a file was written, which provider and model
+produced it, a SHA-256 of the prompt, a diff summary and the calling client.
+
+Auditing is enabled with audit.enabled in the configuration (or required by
+a managed policy bundle). Entries are stored as JSONL, one file per day,
+under ~/.mcp-code-api/audit/ unless audit.dir is set.`,
+	Example: `  # Everything written in the last day
+  mcp-code-api audit --since 24h
+
+  # Changes to a file by one provider, as JSON lines
+  mcp-code-api audit --file internal/api --provider cerebras --format json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return err
+		}
+
+		filter := audit.Filter{File: auditFile, Provider: auditProvider, Limit: auditLimit}
+		if filter.Since, err = parseAuditTime(auditSince); err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		if filter.Until, err = parseAuditTime(auditUntil); err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+
+		entries, err := audit.Query(audit.Dir(cfg.Audit), filter)
+		if err != nil {
+			return err
+		}
+
+		switch auditFormat {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			for _, entry := range entries {
+				if err := encoder.Encode(entry); err != nil {
+					return err
+				}
+			}
+		case "text":
+			if len(entries) == 0 {
+				fmt.Println("No audit entries found")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tOPERATION\tFILE\tPROVIDER/MODEL\tCHANGES\tPROMPT\tCALLER")
+			for _, e := range entries {
+				source := "-"
+				if e.Provider != "" {
+					source = e.Provider + "/" + e.Model
+					if e.Cached {
+						source += " (cached)"
+					}
+				}
+				prompt := "-"
+				if len(e.PromptSHA256) >= 12 {
+					prompt = e.PromptSHA256[:12]
+				}
+				caller := strings.TrimSpace(strings.Join([]string{e.User, e.Caller}, " "))
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t+%d -%d\t%s\t%s\n",
+					e.Time.Local().Format("2006-01-02 15:04:05"), e.Operation, e.FilePath, source,
+					e.Diff.LinesAdded, e.Diff.LinesRemoved, prompt, caller)
+			}
+			return w.Flush()
+		default:
+			return fmt.Errorf("unknown format %q (expected text or json)", auditFormat)
+		}
+		return nil
+	},
+}
+
+// parseAuditTime accepts a duration before now ("24h"), a date or an RFC 3339 time
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a duration, date (2006-01-02) or RFC 3339 time", value)
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "only entries after this time (duration like 24h, date or RFC 3339)")
+	auditCmd.Flags().StringVar(&auditUntil, "until", "", "only entries before this time (duration like 1h, date or RFC 3339)")
+	auditCmd.Flags().StringVar(&auditFile, "file", "", "only entries whose file path contains this string")
+	auditCmd.Flags().StringVar(&auditProvider, "provider", "", "only entries generated by this provider")
+	auditCmd.Flags().IntVar(&auditLimit, "limit", 0, "show only the most recent N entries (0 = all)")
+	auditCmd.Flags().StringVar(&auditFormat, "format", "text", "output format: text or json")
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/bench"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchSuite   string
+	benchTargets []string
+	benchRuns    int
+	benchFormat  string
+	benchOutput  string
+	benchJudge   string
+	benchHistory string
+	benchNoSave  bool
+	benchFailReg bool
+)
+
+// benchCmd compares configured models on a suite of prompts
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Compare configured models on a suite of prompts",
+	Long: `Run a suite of code generation prompts against every configured
+provider:model pair and report time to first token, total latency, output
+speed and the quality of the generated code: how often it passes validation
+on the first try and the case's tests, its cyclomatic complexity, and with
+--judge a judge model's 1-10 rubric score.
+
+Targets are each enabled provider with its configured model, plus the models
+named in preferred_order, fallback chains, racing and OpenRouter's model
+list; --target picks them instead. Requests run one at a time, with the
+response cache and hedging off, so targets are measured alone.
+
+A suite is a YAML or JSON file of cases:
+
+  cases:
+    - name: lru-cache
+      file: lru.go          # sets the language; never written
+      prompt: "Write a generic LRU cache..."
+      validate: true        # default
+      test:                 # optional; run next to the generated file
+        file: lru_test.go
+        content: |
+          package lru
+          ...
+        command: ["go", "test", "./..."]   # default by language
+
+Without --suite a built-in suite of Go, Python and TypeScript tasks is run.
+
+Each run's summaries are appended to a history (~/.mcp-code-api/bench/
+history.jsonl by default) and compared with the previous run of the same
+suite: falling pass rates or judge scores, slower responses and a different
+model answering are reported as regressions.`,
+	Example: `  mcp-code-api bench
+  mcp-code-api bench --target cerebras --target openrouter:qwen/qwen3-coder --runs 3
+  mcp-code-api bench --suite bench.yaml --format json --output bench.json
+  mcp-code-api bench --judge anthropic --fail-on-regression`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if benchFormat != "markdown" && benchFormat != "json" {
+			return fmt.Errorf("unknown format %q (expected markdown or json)", benchFormat)
+		}
+		suite := bench.DefaultSuite
+		if benchSuite != "" {
+			var err error
+			if suite, err = bench.LoadSuite(benchSuite); err != nil {
+				return err
+			}
+		}
+
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return err
+		}
+		// Measure every request against the provider itself
+		cfg.Cache.Enabled = false
+		cfg.Providers.Hedging = nil
+
+		targets := benchTargets
+		if len(targets) == 0 {
+			targets = bench.Targets(cfg)
+		}
+		// Accept provider/model too, as the write tool's model argument does
+		for i, target := range targets {
+			if !strings.Contains(target, ":") {
+				targets[i] = strings.Replace(target, "/", ":", 1)
+			}
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("no providers enabled; run mcp-code-api config first")
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		factory := provider.NewProviderFactory()
+		provider.InitializeDefaultProviders(factory)
+		r := router.NewEnhancedRouter(cfg, factory)
+		if err := r.Initialize(ctx); err != nil {
+			return fmt.Errorf("failed to initialize router: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Benchmarking %d target(s) on %d case(s), %d run(s) each\n", len(targets), len(suite.Cases), benchRuns)
+		report := bench.Run(ctx, cfg, r, targets, suite, bench.Options{
+			Runs:  benchRuns,
+			Judge: strings.Replace(benchJudge, "/", ":", 1),
+			Progress: func(result bench.Result) {
+				status := "ok"
+				switch {
+				case result.Error != "":
+					status = "error: " + strings.SplitN(result.Error, "\n", 2)[0]
+				case result.Valid != nil && !*result.Valid:
+					status = fmt.Sprintf("%d validation error(s)", result.Errors)
+				}
+				fmt.Fprintf(os.Stderr, "  %s %s #%d: %.0fms, %s\n", result.Target, result.Case, result.Run, result.LatencyMs, status)
+				for _, warning := range result.Warnings {
+					fmt.Fprintf(os.Stderr, "    warning: %s\n", warning)
+				}
+			},
+		})
+
+		historyPath := config.ExpandPath(benchHistory)
+		history, err := bench.LoadHistory(historyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		report.Compare(history)
+		if !benchNoSave {
+			if err := bench.AppendHistory(historyPath, report); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+		}
+
+		var data []byte
+		if benchFormat == "json" {
+			if data, err = report.JSON(); err != nil {
+				return fmt.Errorf("failed to encode report: %w", err)
+			}
+			data = append(data, '\n')
+		} else {
+			data = []byte(report.Markdown())
+		}
+		if benchOutput == "" {
+			if _, err := os.Stdout.Write(data); err != nil {
+				return err
+			}
+		} else {
+			if err := os.WriteFile(benchOutput, data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", benchOutput, err)
+			}
+			fmt.Fprintf(os.Stderr, "Report written to %s\n", benchOutput)
+		}
+
+		if benchFailReg && len(report.Regressions) > 0 {
+			return fmt.Errorf("%d regression(s) since the previous run", len(report.Regressions))
+		}
+		return nil
+	},
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&benchSuite, "suite", "", "YAML or JSON file of cases to run instead of the built-in suite")
+	benchCmd.Flags().StringArrayVar(&benchTargets, "target", nil, "provider or provider:model to benchmark (repeatable; default: all configured)")
+	benchCmd.Flags().IntVar(&benchRuns, "runs", 1, "runs of each case per target")
+	benchCmd.Flags().StringVar(&benchFormat, "format", "markdown", "report format: markdown or json")
+	benchCmd.Flags().StringVarP(&benchOutput, "output", "o", "", "write the report to a file instead of stdout")
+	benchCmd.Flags().StringVar(&benchJudge, "judge", "", "provider or provider:model scoring the code on a rubric (default: no judging)")
+	benchCmd.Flags().StringVar(&benchHistory, "history", bench.DefaultHistoryPath(), "file the summaries are kept in for comparing runs")
+	benchCmd.Flags().BoolVar(&benchNoSave, "no-save", false, "compare with the history without adding this run to it")
+	benchCmd.Flags().BoolVar(&benchFailReg, "fail-on-regression", false, "exit non-zero when a target regressed since the previous run")
+	rootCmd.AddCommand(benchCmd)
+}
@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/mcp"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// CI exit codes
+const (
+	ciExitFindings = 1 // Review found errors or a generation step failed
+	ciExitError    = 2 // Configuration or runtime error
+)
+
+// ExitError carries a specific process exit code out of a command
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ciBase        string
+	ciSARIF       string
+	ciAnnotations bool
+	ciManifest    string
+)
+
+// ciCmd groups the non-interactive CI entry points
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Non-interactive entry points for CI pipelines",
+	Long: `Run review and code generation non-interactively in CI pipelines using
+the same engine as the MCP server.
+
+API keys are read from the usual environment variables (ANTHROPIC_API_KEY,
+CEREBRAS_API_KEY, OPENROUTER_API_KEY, GEMINI_API_KEY, ...), so CI secrets can
+be passed through the environment.
+
+When running under GitHub Actions, findings are printed as workflow command
+annotations. Exit codes: 0 = clean, 1 = findings or failed steps, 2 = error.`,
+}
+
+// ciReviewCmd reviews files changed relative to a base ref
+var ciReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review files changed since a base ref",
+	Long: `Review each file changed since the base ref the way the review tool does:
+the file and its diff are sent to a provider, and the issues it finds are
+reported with their severity. The files are run through the language
+validators too.
+
+Syntax errors and error-severity issues fail the review; warnings and notes
+are only reported. A review that can't be run exits with 2.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return &ExitError{Code: ciExitError, Err: err}
+		}
+
+		files, err := changedFiles(ciBase)
+		if err != nil {
+			return &ExitError{Code: ciExitError, Err: err}
+		}
+		fmt.Printf("Reviewing %d changed file(s) against %s\n", len(files), ciBase)
+
+		findings, err := collectValidationFindings(files, cfg.Validation)
+		if err != nil {
+			return &ExitError{Code: ciExitError, Err: err}
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		r, err := newCIRouter(ctx, cfg)
+		if err != nil {
+			return &ExitError{Code: ciExitError, Err: err}
+		}
+
+		failed := 0
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil || bytes.IndexByte(data, 0) >= 0 {
+				continue // Binary files aren't reviewed
+			}
+			diff, err := fileDiff(ciBase, file)
+			if err == nil {
+				var review validation.FileFindings
+				if review, err = mcp.ReviewChange(ctx, r, file, diff); err == nil && len(review.Errors) > 0 {
+					findings = append(findings, review)
+				}
+			}
+			if err != nil {
+				failed++
+				printAnnotation("error", file, 0, 0, err.Error())
+			}
+		}
+
+		blocking := 0
+		for _, f := range findings {
+			for _, e := range f.Errors {
+				level := e.Level
+				if level == "" || level == "error" {
+					level = "error"
+					blocking++
+				}
+				printAnnotation(annotationLevel(level), f.FilePath, e.Line, e.Column, e.Message)
+			}
+		}
+
+		if ciSARIF != "" {
+			wd, _ := os.Getwd()
+			data, err := validation.NewSARIFLog(cfg.Server.Name, cfg.Server.Version, wd, findings).JSON()
+			if err != nil {
+				return &ExitError{Code: ciExitError, Err: fmt.Errorf("failed to encode SARIF: %w", err)}
+			}
+			if err := os.WriteFile(ciSARIF, data, 0644); err != nil {
+				return &ExitError{Code: ciExitError, Err: fmt.Errorf("failed to write SARIF: %w", err)}
+			}
+		}
+
+		if failed > 0 {
+			return &ExitError{Code: ciExitError, Err: fmt.Errorf("%d of %d review(s) failed", failed, len(files))}
+		}
+		if blocking > 0 {
+			return &ExitError{Code: ciExitFindings, Err: fmt.Errorf("review found %d error(s)", blocking)}
+		}
+		fmt.Println("No blocking findings")
+		return nil
+	},
+}
+
+// generateManifest describes a batch of generation steps for `ci generate`
+type generateManifest struct {
+	Generate []struct {
+		File         string   `yaml:"file"`
+		Prompt       string   `yaml:"prompt"`
+		ContextFiles []string `yaml:"context_files"`
+		Validate     *bool    `yaml:"validate"`
+	} `yaml:"generate"`
+}
+
+// ciGenerateCmd runs the generation steps listed in a manifest
+var ciGenerateCmd = &cobra.Command{
+	Use:          "generate",
+	Short:        "Generate files listed in a manifest",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(ciManifest)
+		if err != nil {
+			return &ExitError{Code: ciExitError, Err: fmt.Errorf("failed to read manifest: %w", err)}
+		}
+		var manifest generateManifest
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			return &ExitError{Code: ciExitError, Err: fmt.Errorf("failed to parse manifest: %w", err)}
+		}
+
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return &ExitError{Code: ciExitError, Err: err}
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		r, err := newCIRouter(ctx, cfg)
+		if err != nil {
+			return &ExitError{Code: ciExitError, Err: err}
+		}
+
+		// Relative paths in the manifest are resolved against its directory
+		baseDir := filepath.Dir(ciManifest)
+		resolve := func(path string) string {
+			path = config.ExpandPath(path)
+			if filepath.IsAbs(path) {
+				return path
+			}
+			return filepath.Join(baseDir, path)
+		}
+
+		failed := 0
+		for _, step := range manifest.Generate {
+			filePath := resolve(step.File)
+			var contextFiles []string
+			for _, f := range step.ContextFiles {
+				contextFiles = append(contextFiles, resolve(f))
+			}
+			validate := step.Validate == nil || *step.Validate
+
+			warn := func(providerName, message string) {
+				printAnnotation("warning", step.File, 0, 0, message)
+			}
+			result, err := r.GenerateCodeWithValidation(ctx, step.Prompt, filePath, contextFiles, "", "", validate, warn)
+			if err == nil {
+				err = utils.WriteFileContent(filePath, result)
+			}
+			if err != nil {
+				failed++
+				printAnnotation("error", step.File, 0, 0, err.Error())
+				continue
+			}
+			fmt.Printf("✅ Generated %s\n", step.File)
+		}
+
+		if failed > 0 {
+			return &ExitError{Code: ciExitFindings, Err: fmt.Errorf("%d of %d generation step(s) failed", failed, len(manifest.Generate))}
+		}
+		return nil
+	},
+}
+
+// newCIRouter returns the router the CI commands send requests through
+func newCIRouter(ctx context.Context, cfg *config.Config) (*router.EnhancedRouter, error) {
+	factory := provider.NewProviderFactory()
+	provider.InitializeDefaultProviders(factory)
+	r := router.NewEnhancedRouter(cfg, factory)
+	if err := r.Initialize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize router: %w", err)
+	}
+	return r, nil
+}
+
+// fileDiff returns the changes to file since base
+func fileDiff(base, file string) (string, error) {
+	out, err := exec.Command("git", "diff", base+"...HEAD", "--", file).Output()
+	if err != nil {
+		return "", fmt.Errorf("git diff of %s against %s failed: %w", file, base, err)
+	}
+	return string(out), nil
+}
+
+// changedFiles lists files added or modified relative to base that still exist
+func changedFiles(base string) ([]string, error) {
+	out, err := exec.Command("git", "diff", "--name-only", "--diff-filter=ACMR", base+"...HEAD").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff against %s failed: %w", base, err)
+	}
+	// git prints paths relative to the repository root
+	top, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	root := strings.TrimSpace(string(top))
+	wd, _ := os.Getwd()
+
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		path := filepath.Join(root, line)
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			path = rel
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// printAnnotation prints a finding as a GitHub workflow command when annotations
+// are enabled, otherwise as a plain line
+func printAnnotation(level, file string, line, col int, message string) {
+	if !ciAnnotations {
+		location := file
+		if line > 0 {
+			location = fmt.Sprintf("%s:%d", file, line)
+		}
+		fmt.Printf("%s: %s: %s\n", level, location, message)
+		return
+	}
+
+	props := "file=" + escapeAnnotationProperty(file)
+	if line > 0 {
+		props += fmt.Sprintf(",line=%d", line)
+		if col > 0 {
+			props += fmt.Sprintf(",col=%d", col)
+		}
+	}
+	fmt.Printf("::%s %s::%s\n", level, props, escapeAnnotationData(message))
+}
+
+// annotationLevel returns the workflow command for a SARIF level
+func annotationLevel(level string) string {
+	if level == "note" {
+		return "notice"
+	}
+	return level
+}
+
+// escapeAnnotationData escapes workflow command message data
+func escapeAnnotationData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeAnnotationProperty escapes workflow command property values
+func escapeAnnotationProperty(s string) string {
+	s = escapeAnnotationData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
+
+func init() {
+	ciCmd.PersistentFlags().BoolVar(&ciAnnotations, "annotations", os.Getenv("GITHUB_ACTIONS") == "true", "print findings as GitHub workflow annotations")
+
+	ciReviewCmd.Flags().StringVar(&ciBase, "base", "main", "base ref to diff against")
+	ciReviewCmd.Flags().StringVar(&ciSARIF, "sarif", "", "also write findings as SARIF to this file")
+
+	ciGenerateCmd.Flags().StringVar(&ciManifest, "manifest", "", "YAML manifest listing files to generate")
+	_ = ciGenerateCmd.MarkFlagRequired("manifest")
+
+	ciCmd.AddCommand(ciReviewCmd)
+	ciCmd.AddCommand(ciGenerateCmd)
+	rootCmd.AddCommand(ciCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/bench"
+	"github.com/cecil-the-coder/mcp-code-api/internal/metrics"
+	"github.com/cecil-the-coder/mcp-code-api/internal/retention"
+	"github.com/spf13/cobra"
+)
+
+var cleanDryRun bool
+
+// cleanCmd applies the retention policies on demand
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove old audit logs, benchmark history and metrics files",
+	Long: `Remove what the retention policies no longer keep: audit log files older
+than retention.audit.max_age or beyond retention.audit.max_size in total,
+benchmark history entries past retention.bench_history's limits and metrics
+files, such as those left by crashed servers, past retention.metrics'
+limits. Limits left at zero keep everything. A managed policy bundle that
+requires the audit log also sets its retention.
+
+A running server applies the same policies every retention.interval (1h by
+default). It also drops the write tool's backups past retention.backups'
+limits, which only it holds, and rotates its debug log once it grows past
+retention.log_max_size.`,
+	Example: `  # See what would be removed
+  mcp-code-api clean --dry-run
+
+  # Keep 90 days of audit logs, set for this run only
+  MCPCODE_RETENTION__AUDIT__MAX_AGE=2160h mcp-code-api clean`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return err
+		}
+
+		results, err := retention.NewJanitor(cfg, bench.DefaultHistoryPath(), metrics.StoreDir()).Clean(time.Now(), cleanDryRun)
+		verb := "REMOVED"
+		if cleanDryRun {
+			verb = "WOULD REMOVE"
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "TARGET\t%s\tFREED\tPATH\n", verb)
+		for _, result := range results {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", result.Target, result.Removed, formatBytes(result.Freed), result.Path)
+		}
+		if flushErr := w.Flush(); err == nil {
+			err = flushErr
+		}
+		return err
+	},
+}
+
+// formatBytes renders a byte count in KiB, MiB or GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+func init() {
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "report what would be removed without removing it")
+	rootCmd.AddCommand(cleanCmd)
+}
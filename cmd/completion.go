@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// completionCmd prints a shell completion script
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Print a completion script for the given shell. Besides commands and
+flags it completes provider names and enumerated flag values such as
+--format.
+
+Bash (needs the bash-completion package):
+
+  source <(mcp-code-api completion bash)
+  # or permanently:
+  mcp-code-api completion bash > /etc/bash_completion.d/mcp-code-api
+
+Zsh:
+
+  mcp-code-api completion zsh > "${fpath[1]}/_mcp-code-api"
+  # completion must be enabled: autoload -U compinit; compinit
+
+Fish:
+
+  mcp-code-api completion fish > ~/.config/fish/completions/mcp-code-api.fish
+
+PowerShell:
+
+  mcp-code-api completion powershell | Out-String | Invoke-Expression`,
+	Example: `  mcp-code-api completion bash > ~/.local/share/bash-completion/completions/mcp-code-api
+  mcp-code-api completion zsh > ~/.zfunc/_mcp-code-api`,
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	SilenceUsage:          true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		}
+		return fmt.Errorf("unsupported shell %q", args[0])
+	},
+}
+
+// registerCompletions adds completion of flag values and arguments to
+// commands defined in other files. It runs from Execute, once every
+// command's flags exist.
+func registerCompletions() {
+	fixed := func(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return values, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	flags := []struct {
+		cmd    *cobra.Command
+		flag   string
+		values func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)
+	}{
+		{benchCmd, "format", fixed("markdown", "json")},
+		{benchCmd, "suite", fileExtensions("yaml", "yml", "json")},
+		{validateCmd, "format", fixed("text", "sarif")},
+		{auditCmd, "format", fixed("text", "json")},
+		{auditCmd, "provider", completeProviders},
+		{ciGenerateCmd, "manifest", fileExtensions("yaml", "yml")},
+	}
+	for _, f := range flags {
+		if err := f.cmd.RegisterFlagCompletionFunc(f.flag, f.values); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s --%s completion: %v\n", f.cmd.Name(), f.flag, err)
+		}
+	}
+
+	for _, c := range []*cobra.Command{modelsListCmd, providersTestCmd, providersModelsCmd, logoutCmd} {
+		c.ValidArgsFunction = completeProviders
+	}
+}
+
+// completeProviders completes the names of built-in and custom providers
+// not already given on the command line
+func completeProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	given := make(map[string]bool)
+	for _, arg := range args {
+		given[arg] = true
+	}
+	cfg, bundle, err := loadManagedPolicy()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var names []string
+	for _, name := range allowedProviderNames(cfg, bundle) {
+		if !given[name] {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// fileExtensions completes file names with the given extensions
+func fileExtensions(extensions ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return extensions, cobra.ShellCompDirectiveFilterFileExt
+	}
+}
+
+func init() {
+	// Replace cobra's default completion command with the one above
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
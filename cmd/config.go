@@ -14,7 +14,14 @@ var configCmd = &cobra.Command{
 	Long: `Run the interactive setup wizard to configure the MCP server
 for your preferred IDE (Claude Code, Cursor, Cline, VS Code).
 
+On a terminal the wizard runs full-screen: pick providers from a list,
+enter keys in masked fields and choose models fetched live from each
+provider. Use --plain for line-based prompts (used automatically when
+input is not a terminal).
+
 The wizard will:
+- Offer to import credentials from Claude Code, gemini-cli, qwen-code
+  and provider environment variables
 - Guide you through API key setup
 - Configure IDE integrations
 - Set up automatic fallback providers
@@ -38,7 +45,11 @@ Supported IDEs:
 		fmt.Println()
 
 		// Run the interactive configuration
-		if err := interactive.Run(); err != nil {
+		run := interactive.Run
+		if configPlain {
+			run = interactive.RunPlain
+		}
+		if err := run(); err != nil {
 			return fmt.Errorf("configuration failed: %w", err)
 		}
 
@@ -53,6 +64,48 @@ Supported IDEs:
 	},
 }
 
+var (
+	configPlain bool
+
+	importPath      string
+	importOverwrite bool
+	importDryRun    bool
+	importKeyring   bool
+)
+
+// configImportCmd imports credentials from other AI CLIs
+var configImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import credentials from other AI CLIs and the environment",
+	Long: `Detect credentials left by other AI command line tools and add them
+to the configuration file, so providers work without re-entering keys.
+
+Sources checked:
+- Claude Code: ~/.claude/.credentials.json (OAuth) and ~/.claude.json (API key)
+- gemini-cli: ~/.gemini/oauth_creds.json and ~/.gemini/.env
+- qwen-code: ~/.qwen/oauth_creds.json
+- Environment: ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_API_KEY,
+  GOOGLE_API_KEY, DASHSCOPE_API_KEY, OPENROUTER_API_KEY, CEREBRAS_API_KEY,
+  MISTRAL_API_KEY
+
+Providers already in the configuration file are kept unless --overwrite
+is given.`,
+	Example: `  mcp-code-api config import --dry-run
+  mcp-code-api config import --path ./config.yaml
+  mcp-code-api config import --keyring`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return interactive.RunImport(importPath, importOverwrite, importDryRun, importKeyring)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configImportCmd)
+
+	configCmd.Flags().BoolVar(&configPlain, "plain", false, "use line-based prompts instead of the full-screen wizard")
+
+	configImportCmd.Flags().StringVar(&importPath, "path", "", "config file to update (default ~/.mcp-code-api/config.yaml)")
+	configImportCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "replace providers that are already configured")
+	configImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "list detected credentials without writing the config")
+	configImportCmd.Flags().BoolVar(&importKeyring, "keyring", false, "store the secrets in the OS credential store and reference them from the config")
 }
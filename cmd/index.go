@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/index"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	indexQuery string
+	indexTopK  int
+)
+
+// indexCmd builds or refreshes the repository index of a project
+var indexCmd = &cobra.Command{
+	Use:   "index [dir]",
+	Short: "Build or refresh the repository index used for retrieved context",
+	Long: `Embed the source files of the project in dir (default: the current
+directory) into the on-disk index that the write tool retrieves context
+snippets from when index.enabled is set. Only files added or changed since
+the last run are embedded, so this is cheap to re-run; writes refresh the
+index the same way, but building it ahead of time keeps the first write fast.
+
+With --query the snippets most relevant to the query are printed, to check
+what a prompt would retrieve.`,
+	Example: `  mcp-code-api index
+  mcp-code-api index ~/src/service --query "retry with exponential backoff"`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return err
+		}
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		root, err := filepath.Abs(config.ExpandPath(dir))
+		if err != nil {
+			return err
+		}
+		if !config.DirExists(root) {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+
+		if err := index.CheckPolicy(cfg); err != nil {
+			return err
+		}
+		embedder, err := index.NewEmbedder(cfg.Index.Embedding)
+		if err != nil {
+			return err
+		}
+		idx := index.Open(cfg.Index, embedder, root)
+		idx.Filter = func(path string) bool { return policy.WorkspaceAllows(cfg.Workspace, path) }
+		stats, err := idx.Update(cmd.Context())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d file(s), %d chunk(s) indexed with %s (%d embedded, %d removed)\n",
+			root, stats.Files, stats.Chunks, embedder.Name(), stats.Embedded, stats.Removed)
+		if !cfg.Index.Enabled {
+			fmt.Fprintln(os.Stderr, "Note: index.enabled is off, so writes don't retrieve context from it")
+		}
+
+		if indexQuery == "" {
+			return nil
+		}
+		snippets, err := idx.Search(cmd.Context(), indexQuery, indexTopK, cfg.Index.MinScore, nil)
+		if err != nil {
+			return err
+		}
+		for _, snippet := range snippets {
+			rel, _ := filepath.Rel(root, snippet.Path)
+			fmt.Printf("\n%.3f  %s:%d-%d\n", snippet.Score, rel, snippet.StartLine, snippet.EndLine)
+		}
+		return nil
+	},
+}
+
+func init() {
+	indexCmd.Flags().StringVarP(&indexQuery, "query", "q", "", "print the snippets most relevant to this text")
+	indexCmd.Flags().IntVarP(&indexTopK, "top", "k", 5, "number of snippets printed with --query")
+	rootCmd.AddCommand(indexCmd)
+}
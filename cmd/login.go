@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"github.com/cecil-the-coder/mcp-code-api/internal/config/interactive"
+	"github.com/cecil-the-coder/mcp-code-api/internal/keyring"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginPath    string
+	loginAPIKey  bool
+	loginKeyring bool
+)
+
+// loginCmd signs in to a single provider
+var loginCmd = &cobra.Command{
+	Use:   "login <provider>",
+	Short: "Sign in to a provider and save its credentials",
+	Long: `Sign in to one provider without re-running the configuration wizard.
+
+Anthropic, Gemini and Qwen use their browser OAuth flow (pass --api-key to
+enter an API key instead); cerebras, openrouter, openai, mistral and
+synthetic (a Hugging Face token) prompt for an API key. Only the provider's
+credentials are changed in the configuration file; its model and other
+settings are kept.`,
+	Example: `  mcp-code-api login anthropic
+  mcp-code-api login gemini --api-key
+  mcp-code-api login cerebras --keyring`,
+	Args:         cobra.ExactArgs(1),
+	ValidArgs:    []string{"cerebras", "openrouter", "anthropic", "gemini", "qwen", "openai", "mistral", "synthetic"},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return interactive.RunLogin(args[0], loginPath, loginAPIKey, loginKeyring)
+	},
+}
+
+// logoutCmd removes a provider's credentials
+var logoutCmd = &cobra.Command{
+	Use:   "logout <provider>",
+	Short: "Remove a provider's saved credentials",
+	Long: `Remove a provider's API key and OAuth tokens from the configuration
+file, along with any OS credential store entries it refers to.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return interactive.RunLogout(args[0], loginPath)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+
+	for _, c := range []*cobra.Command{loginCmd, logoutCmd} {
+		c.Flags().StringVar(&loginPath, "path", "", "config file to update (default ~/.mcp-code-api/config.yaml)")
+	}
+	loginCmd.Flags().BoolVar(&loginAPIKey, "api-key", false, "enter an API key instead of signing in with OAuth")
+	loginCmd.Flags().BoolVar(&loginKeyring, "keyring", keyring.Available(), "store the secrets in the OS credential store")
+}
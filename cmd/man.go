@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var manDir string
+
+// manCmd writes a man page for every command
+var manCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Generate man pages for every command",
+	Long: `Write a section 1 man page for mcp-code-api and each of its subcommands
+(mcp-code-api.1, mcp-code-api-server.1, mcp-code-api-bench.1, ...) into
+--dir, from the same descriptions, flags and examples shown by --help.`,
+	Example: `  mcp-code-api man --dir ./man
+  sudo mcp-code-api man --dir /usr/local/share/man/man1 && sudo mandb
+  man mcp-code-api-bench`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := os.MkdirAll(manDir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", manDir, err)
+		}
+		count, err := writeManPages(rootCmd, manDir, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %d man page(s) to %s\n", count, manDir)
+		return nil
+	},
+}
+
+// writeManPages writes the pages of cmd and its visible subcommands to dir
+func writeManPages(cmd *cobra.Command, dir string, date time.Time) (int, error) {
+	count := 0
+	for _, sub := range cmd.Commands() {
+		if !sub.IsAvailableCommand() || sub.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		n, err := writeManPages(sub, dir, date)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	path := filepath.Join(dir, manPageName(cmd)+".1")
+	if err := os.WriteFile(path, manPage(cmd, date), 0644); err != nil {
+		return count, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return count + 1, nil
+}
+
+// manPageName is the page name of cmd: its command path joined by dashes
+func manPageName(cmd *cobra.Command) string {
+	return strings.ReplaceAll(cmd.CommandPath(), " ", "-")
+}
+
+// manPage renders cmd as a roff man page
+func manPage(cmd *cobra.Command, date time.Time) []byte {
+	var b bytes.Buffer
+	name := manPageName(cmd)
+	fmt.Fprintf(&b, ".TH %q 1 %q %q %q\n", strings.ToUpper(name), date.Format("2006-01-02"), "mcp-code-api "+version, "User Commands")
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(name), roffEscape(cmd.Short))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n", roffEscape(cmd.UseLine()))
+
+	b.WriteString(".SH DESCRIPTION\n")
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	b.WriteString(roffText(description))
+
+	writeManFlags(&b, "OPTIONS", cmd.NonInheritedFlags())
+	writeManFlags(&b, "OPTIONS INHERITED FROM PARENT COMMANDS", cmd.InheritedFlags())
+
+	if cmd.Example != "" {
+		b.WriteString(".SH EXAMPLES\n.PP\n.RS\n.nf\n")
+		b.WriteString(roffLines(cmd.Example))
+		b.WriteString(".fi\n.RE\n")
+	}
+
+	var related []string
+	if cmd.HasParent() {
+		related = append(related, manPageName(cmd.Parent()))
+	}
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() && !sub.IsAdditionalHelpTopicCommand() {
+			related = append(related, manPageName(sub))
+		}
+	}
+	if len(related) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, page := range related {
+			sep := ","
+			if i == len(related)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(&b, ".BR %s (1)%s\n", roffEscape(page), sep)
+		}
+	}
+	return b.Bytes()
+}
+
+// writeManFlags writes a section listing the visible flags in set
+func writeManFlags(b *bytes.Buffer, title string, set *pflag.FlagSet) {
+	if !set.HasAvailableFlags() {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n", title)
+	set.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		varname, usage := pflag.UnquoteUsage(f)
+		term := "--" + f.Name
+		if f.Shorthand != "" && f.ShorthandDeprecated == "" {
+			term = "-" + f.Shorthand + ", " + term
+		}
+		if varname != "" {
+			term += " " + varname
+		}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "[]" && f.DefValue != "0" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintf(b, ".TP\n\\fB%s\\fP\n%s\n", roffEscape(term), roffEscape(usage))
+	})
+}
+
+// roffText renders help text as paragraphs, keeping indented blocks such as
+// examples and YAML snippets verbatim
+func roffText(text string) string {
+	var b strings.Builder
+	verbatim := false
+	b.WriteString(".PP\n")
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		switch {
+		case line == "":
+			if verbatim {
+				b.WriteString(".fi\n.RE\n")
+				verbatim = false
+			}
+			b.WriteString(".PP\n")
+			continue
+		case indented && !verbatim:
+			b.WriteString(".RS\n.nf\n")
+			verbatim = true
+		case !indented && verbatim:
+			b.WriteString(".fi\n.RE\n")
+			verbatim = false
+		}
+		b.WriteString(roffLine(line))
+	}
+	if verbatim {
+		b.WriteString(".fi\n.RE\n")
+	}
+	return b.String()
+}
+
+// roffLines renders each line of text as is
+func roffLines(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		b.WriteString(roffLine(line))
+	}
+	return b.String()
+}
+
+// roffLine escapes one line of text, guarding a leading dot or quote that
+// roff would read as a request
+func roffLine(line string) string {
+	line = roffEscape(line)
+	if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+		line = `\&` + line
+	}
+	return line + "\n"
+}
+
+// roffEscape escapes backslashes and hyphens for roff
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	return strings.ReplaceAll(s, "-", `\-`)
+}
+
+func init() {
+	manCmd.Flags().StringVar(&manDir, "dir", "man", "directory to write the pages to")
+	rootCmd.AddCommand(manCmd)
+}
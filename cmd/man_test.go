@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteManPages(t *testing.T) {
+	dir := t.TempDir()
+	count, err := writeManPages(rootCmd, dir, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count < 2 {
+		t.Fatalf("wrote %d pages, expected one per command", count)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "mcp-code-api-bench.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`.TH "MCP-CODE-API-BENCH" 1 "2025-01-02"`,
+		`mcp\-code\-api\-bench \- Compare configured models`,
+		`\fB\-\-format string\fP`,
+		".SH EXAMPLES",
+		`.BR mcp\-code\-api (1)`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("bench page is missing %q:\n%s", want, page)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mcp-code-api-help.1")); err == nil {
+		t.Error("wrote a page for the help command")
+	}
+}
+
+func TestRoffLineGuardsRequests(t *testing.T) {
+	if got := roffLine(".hidden 'x'"); got != "\\&.hidden 'x'\n" {
+		t.Errorf("roffLine = %q", got)
+	}
+	if got := roffLine(`C:\path`); got != "C:\\epath\n" {
+		t.Errorf("roffLine = %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var modelsRefresh bool
+
+// modelsCmd represents the models command group
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "Discover the models offered by configured providers",
+}
+
+// modelsListCmd lists each provider's models with their context size and
+// tool calling support
+var modelsListCmd = &cobra.Command{
+	Use:   "list [provider...]",
+	Short: "List models with their context size and tool calling support",
+	Long: `List the models each provider offers, as reported by its models API,
+with the context window and whether the model supports tool calling. A "-"
+means the provider doesn't report it.
+
+Lists are cached under ~/.mcp-code-api/cache/models for cache.models_ttl
+(default 6h); --refresh queries the providers again. Without arguments every
+authenticated provider is listed.`,
+	Example: `  mcp-code-api models list
+  mcp-code-api models list openrouter --refresh`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, bundle, err := loadManagedPolicy()
+		if err != nil {
+			return err
+		}
+		names, err := providersToCheck(cfg, bundle, args)
+		if err != nil {
+			return err
+		}
+		failed := 0
+
+		for i, name := range names {
+			if i > 0 {
+				fmt.Println()
+			}
+			models, fetched, err := cachedModels(cmd.Context(), cfg, name)
+			if err != nil {
+				failed++
+				fmt.Printf("%s: error: %v\n", name, err)
+				continue
+			}
+			fmt.Printf("%s: %d models (fetched %s ago)\n", name, len(models), time.Since(fetched).Round(time.Second))
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "  MODEL\tCONTEXT\tMAX OUTPUT\tTOOLS")
+			for _, m := range models {
+				tools := "-"
+				if m.ToolCalling != nil {
+					tools = yesNo(*m.ToolCalling)
+				}
+				fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", m.ID, formatTokens(m.ContextWindow), formatTokens(m.MaxOutput), tools)
+			}
+			w.Flush()
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d provider(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+func cachedModels(ctx context.Context, cfg *config.Config, name string) ([]api.ModelInfo, time.Time, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.DefaultRequestTimeout)*time.Second)
+	defer cancel()
+	return api.CachedModels(ctx, cfg, name, modelsRefresh)
+}
+
+// formatTokens abbreviates a token count, e.g. 128000 as "128K"; 0 is unknown
+func formatTokens(n int) string {
+	switch {
+	case n <= 0:
+		return "-"
+	case n >= 1_000_000 && n%1_000_000 == 0:
+		return fmt.Sprintf("%dM", n/1_000_000)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dM", n>>20)
+	case n >= 1000 && n%1000 == 0:
+		return fmt.Sprintf("%dK", n/1000)
+	case n >= 1024 && n%1024 == 0:
+		return fmt.Sprintf("%dK", n/1024)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func init() {
+	modelsListCmd.Flags().BoolVar(&modelsRefresh, "refresh", false, "Ignore cached model lists and query the providers again")
+	modelsCmd.AddCommand(modelsListCmd)
+	rootCmd.AddCommand(modelsCmd)
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+// providersCmd represents the providers command group
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "Inspect and test configured providers",
+	Long: `Inspect and test the configured AI providers without starting an
+IDE session. Useful for debugging API keys, OAuth and base URLs.`,
+}
+
+// providersListCmd lists providers and their configuration state
+var providersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List providers and whether they are enabled and authenticated",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, bundle, err := loadManagedPolicy()
+		if err != nil {
+			return err
+		}
+
+		enabled := make(map[string]bool)
+		for _, name := range cfg.Providers.Enabled {
+			enabled[name] = true
+		}
+
+		fmt.Printf("%-12s %-8s %-10s %s\n", "PROVIDER", "ENABLED", "AUTH", "MODEL")
+		for _, name := range allowedProviderNames(cfg, bundle) {
+			auth := api.GetProviderAuth(cfg, name)
+			method := auth.Method
+			if method == "" {
+				method = "-"
+			}
+			fmt.Printf("%-12s %-8s %-10s %s\n", name, yesNo(enabled[name]), method, auth.Model)
+		}
+		fmt.Printf("\nPreferred order: %v\n", cfg.Providers.Order)
+		return nil
+	},
+}
+
+// providersTestCmd checks connectivity and credentials for each provider
+var providersTestCmd = &cobra.Command{
+	Use:          "test [provider...]",
+	Short:        "Test credentials by calling each provider's models endpoint",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, bundle, err := loadManagedPolicy()
+		if err != nil {
+			return err
+		}
+		names, err := providersToCheck(cfg, bundle, args)
+		if err != nil {
+			return err
+		}
+		failed := 0
+
+		for _, name := range names {
+			start := time.Now()
+			models, err := listModels(cmd.Context(), cfg, name)
+			if err != nil {
+				failed++
+				fmt.Printf("❌ %-12s %v\n", name, err)
+				continue
+			}
+			fmt.Printf("✅ %-12s OK (%d models, %s)\n", name, len(models), time.Since(start).Round(time.Millisecond))
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d provider(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+// providersModelsCmd lists the models available from a provider
+var providersModelsCmd = &cobra.Command{
+	Use:   "models [provider...]",
+	Short: "List models available from each provider",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, bundle, err := loadManagedPolicy()
+		if err != nil {
+			return err
+		}
+		names, err := providersToCheck(cfg, bundle, args)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			fmt.Printf("%s:\n", name)
+			models, err := listModels(cmd.Context(), cfg, name)
+			if err != nil {
+				fmt.Printf("  error: %v\n", err)
+				continue
+			}
+			for _, model := range models {
+				fmt.Printf("  %s\n", model)
+			}
+		}
+		return nil
+	},
+}
+
+// allowedProviderNames returns the built-in and custom providers the managed
+// policy bundle, if any, allows
+func allowedProviderNames(cfg *config.Config, bundle *policy.Bundle) []string {
+	var names []string
+	for _, name := range api.ProviderNames(cfg) {
+		if bundle.AllowsProvider(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// providersToCheck returns the explicitly named providers, or every
+// authenticated one. Providers the managed policy bundle disallows are never
+// contacted.
+func providersToCheck(cfg *config.Config, bundle *policy.Bundle, args []string) ([]string, error) {
+	if len(args) > 0 {
+		for _, name := range args {
+			if !bundle.AllowsProvider(name) {
+				return nil, fmt.Errorf("provider %s is not allowed by the managed policy", name)
+			}
+		}
+		return args, nil
+	}
+	var names []string
+	for _, name := range allowedProviderNames(cfg, bundle) {
+		if api.GetProviderAuth(cfg, name).Configured {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func listModels(ctx context.Context, cfg *config.Config, name string) ([]string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.DefaultRequestTimeout)*time.Second)
+	defer cancel()
+	return api.ListModels(ctx, cfg, name)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func init() {
+	providersCmd.AddCommand(providersListCmd)
+	providersCmd.AddCommand(providersTestCmd)
+	providersCmd.AddCommand(providersModelsCmd)
+	rootCmd.AddCommand(providersCmd)
+}
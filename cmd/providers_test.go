@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+)
+
+func TestProvidersFollowManagedPolicy(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"cerebras", "openrouter", "internal"}
+	cfg.Providers.Cerebras = &config.CerebrasConfig{APIKey: "key"}
+	cfg.Providers.OpenRouter = &config.OpenRouterConfig{APIKey: "key"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"internal": {BaseURL: "http://127.0.0.1:1", Model: "coder"}}
+
+	names, err := providersToCheck(cfg, nil, nil)
+	if err != nil || !reflect.DeepEqual(names, []string{"cerebras", "openrouter", "internal"}) {
+		t.Errorf("providersToCheck() without a bundle = %v, %v; want every authenticated provider", names, err)
+	}
+
+	bundle := &policy.Bundle{AllowedProviders: []string{"cerebras", "internal"}}
+	bundle.Apply(cfg)
+	if listed := allowedProviderNames(cfg, bundle); slices.Contains(listed, "openrouter") || !slices.Contains(listed, "internal") {
+		t.Errorf("allowedProviderNames() = %v, want openrouter left out", listed)
+	}
+	names, err = providersToCheck(cfg, bundle, nil)
+	if err != nil || !reflect.DeepEqual(names, []string{"cerebras", "internal"}) {
+		t.Errorf("providersToCheck() = %v, %v; want only the allowed providers", names, err)
+	}
+	if _, err := providersToCheck(cfg, bundle, []string{"cerebras", "openrouter"}); err == nil {
+		t.Error("providersToCheck() accepted a provider the bundle disallows")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -41,6 +42,7 @@ Built with Go for maximum performance and reliability.`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
+	registerCompletions()
 	return rootCmd.Execute()
 }
 
@@ -51,28 +53,24 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default searches: ./config.yaml, ~/.mcp-code-api/config.yaml)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().Bool("debug", false, "debug mode with detailed logging")
+	rootCmd.PersistentFlags().Bool("no-persist", false, "keep refreshed tokens and server state in memory instead of writing them to disk")
 
 	// Bind flags to viper
 	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 	_ = viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
+	_ = viper.BindPFlag("server.no_persist", rootCmd.PersistentFlags().Lookup("no-persist"))
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	if cfgFile != "" {
 		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
+		viper.SetConfigFile(config.ExpandPath(cfgFile))
 	} else {
-		// Find home directory.
-		home, err := os.UserHomeDir()
-		if err != nil {
-			home = "."
-		}
-
 		// Try to find config.yaml in common locations
 		configLocations := []string{
-			"./config.yaml",                      // Current directory
-			home + "/.mcp-code-api/config.yaml",  // User config directory
+			"config.yaml",           // Current directory
+			config.ConfigFilePath(), // User config directory
 		}
 
 		configFound := false
@@ -87,8 +85,10 @@ func initConfig() {
 		// If no config file found, set default search paths
 		if !configFound {
 			viper.AddConfigPath(".")
-			viper.AddConfigPath(home + "/.mcp-code-api")
-			viper.AddConfigPath(home)
+			viper.AddConfigPath(config.ConfigDir())
+			if home := config.GetHomeDir(); home != "" {
+				viper.AddConfigPath(home)
+			}
 			viper.SetConfigType("yaml")
 			viper.SetConfigName("config")
 		}
@@ -99,7 +99,8 @@ func initConfig() {
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
 		if viper.GetBool("verbose") {
-			fmt.Println("Using config file:", viper.ConfigFileUsed())
+			// stdout carries the MCP stream when running as a server
+			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 		}
 	}
 }
@@ -5,20 +5,29 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/bench"
 	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
 	"github.com/cecil-the-coder/mcp-code-api/internal/mcp"
 	"github.com/cecil-the-coder/mcp-code-api/internal/metrics"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+	"github.com/cecil-the-coder/mcp-code-api/internal/proxy"
+	"github.com/cecil-the-coder/mcp-code-api/internal/retention"
+	"github.com/cecil-the-coder/mcp-code-api/internal/tracing"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 // serverCmd represents the server command
 var serverCmd = &cobra.Command{
-	Use:   "server",
-	Short: "Start MCP server",
+	Use:     "server",
+	Aliases: []string{"serve"},
+	Short:   "Start MCP server",
 	Long: `Start the Model Context Protocol (MCP) server that provides
 a single 'write' tool for all code operations.
 
@@ -27,28 +36,41 @@ The server will:
 - Route requests to Cerebras or OpenRouter APIs
 - Handle automatic fallback between providers
 - Provide visual diffs for code changes
-- Log all operations for debugging`,
+- Log all operations for debugging
+
+With --daemon the server listens on a unix socket instead of stdio, and
+editors connect to it with 'mcp-code-api attach', sharing one set of provider
+connections, rate limits and metrics.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize logging
 		logFile := viper.GetString("log-file")
-		if logFile == "" {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return fmt.Errorf("failed to get home directory: %w", err)
+		// With --no-persist logs only go to stderr, where container
+		// runtimes collect them
+		if logFile == "" && !config.NoPersist() {
+			home := config.GetHomeDir()
+			if home == "" {
+				return fmt.Errorf("failed to get home directory")
 			}
-			logFile = fmt.Sprintf("%s/mcp-code-api-debug.log", home)
+			logFile = filepath.Join(home, "mcp-code-api-debug.log")
 		}
 
-		if err := logger.SetLogFile(logFile); err != nil {
-			return fmt.Errorf("failed to set log file: %w", err)
+		if logFile != "" {
+			if err := logger.SetLogFile(logFile); err != nil {
+				return fmt.Errorf("failed to set log file: %w", err)
+			}
 		}
 
 		logger.Info("=== SERVER STARTUP ===")
 		logger.Info("MCP Code API server starting...")
-		logger.Infof("Log file location: %s", logFile)
+		if logFile != "" {
+			logger.Infof("Log file location: %s", logFile)
+		}
 
 		// Load configuration
-		cfg := config.Load()
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return err
+		}
 
 		// Apply logging configuration from config file
 		logger.SetDebug(cfg.Logging.Debug)
@@ -60,25 +82,12 @@ The server will:
 		logger.Debugf("Preferred provider order: %v", cfg.Providers.Order)
 		logger.Debugf("Enabled providers: %v", cfg.Providers.Enabled)
 
-		// Check API keys availability (log to file only, not stderr)
-		if cfg.Providers.Cerebras == nil || cfg.Providers.Cerebras.APIKey == "" {
-			logger.Info("No Cerebras API key found")
-		} else {
-			logger.Info("Cerebras API key configured")
-		}
-
-		if cfg.Providers.OpenRouter == nil || cfg.Providers.OpenRouter.APIKey == "" {
-			logger.Info("No OpenRouter API key found")
-		} else {
-			logger.Info("OpenRouter API key configured")
-		}
+		// Providers without credentials are skipped when the router starts,
+		// which fails if none is left
 
-		cerebrasAvail := cfg.Providers.Cerebras != nil && cfg.Providers.Cerebras.APIKey != ""
-		openrouterAvail := cfg.Providers.OpenRouter != nil && cfg.Providers.OpenRouter.APIKey != ""
-		geminiAvail := cfg.Providers.Gemini != nil && (cfg.Providers.Gemini.APIKey != "" || cfg.Providers.Gemini.AccessToken != "")
-		if !cerebrasAvail && !openrouterAvail && !geminiAvail {
-			logger.Error("No API keys available")
-			return fmt.Errorf("no API keys configured")
+		daemon, _ := cmd.Flags().GetBool("daemon")
+		if socket, _ := cmd.Flags().GetString("socket"); socket != "" {
+			cfg.Server.Socket = socket
 		}
 
 		logger.Info("Starting MCP server...")
@@ -97,10 +106,35 @@ The server will:
 			cancel()
 		}()
 
+		// Export OpenTelemetry spans if enabled
+		tracingCfg := cfg.Metrics.Tracing
+		if tracingCfg.ServiceVersion == "" {
+			tracingCfg.ServiceVersion = cfg.Server.Version
+		}
+		shutdownTracing, err := tracing.Init(tracingCfg)
+		if err != nil {
+			logger.Warnf("Failed to start tracing: %v", err)
+		} else {
+			defer func() {
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancelShutdown()
+				if err := shutdownTracing(shutdownCtx); err != nil {
+					logger.Warnf("Error flushing traces: %v", err)
+				}
+			}()
+		}
+
 		// Start the MCP server
 		server := mcp.NewServer(cfg)
+		defer validation.ShutdownLanguageServers()
 		logger.Info("MCP Server starting...")
 
+		// Keep the audit log, benchmark history, metrics files, backups and
+		// debug log within the retention limits
+		janitor := retention.NewJanitor(cfg, bench.DefaultHistoryPath(), metrics.StoreDir())
+		janitor.Backups = server.GetBackupStore()
+		go janitor.Run(ctx)
+
 		// Create shared metrics store
 		metricsStore, err := metrics.NewSharedMetricsStore()
 		if err != nil {
@@ -120,10 +154,14 @@ The server will:
 			}
 
 			metricsServer = metrics.NewMetricsServer(metricsStore, cfg.Metrics.Host, port)
+			metricsServer.SetRouter(server.GetRouter())
+			metricsServer.SetRequestLog(server.GetRequestLog())
+			metricsServer.SetSecurity(cfg.Metrics.Auth, cfg.Metrics.TLS)
+			metricsServer.SetPrefix(cfg.Metrics.PathPrefix)
 			if err := metricsServer.Start(); err != nil {
 				logger.Warnf("Failed to start metrics server: %v", err)
 			} else {
-				logger.Infof("Metrics server started on http://%s:%d", cfg.Metrics.Host, port)
+				logger.Infof("Metrics server started on %s", metricsServer.URL())
 				defer func() {
 					logger.Info("Shutting down metrics server...")
 					if err := metricsServer.Stop(); err != nil {
@@ -133,7 +171,34 @@ The server will:
 			}
 		}
 
-		if err := server.Start(ctx); err != nil {
+		// Start OpenAI-compatible proxy if enabled
+		if cfg.Proxy.Enabled {
+			proxyServer := proxy.NewServer(server.GetRouter(), cfg.Proxy)
+			if err := proxyServer.Start(); err != nil {
+				logger.Warnf("Failed to start proxy server: %v", err)
+			} else {
+				logger.Infof("OpenAI-compatible proxy started on http://%s:%d/v1", cfg.Proxy.Host, cfg.Proxy.Port)
+				defer func() {
+					if err := proxyServer.Stop(); err != nil {
+						logger.Warnf("Error stopping proxy server: %v", err)
+					}
+				}()
+			}
+		}
+
+		if daemon {
+			socket := mcp.DaemonSocket(cfg)
+			listener, err := mcp.ListenDaemon(socket)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(socket)
+			fmt.Fprintf(os.Stderr, "MCP daemon listening on %s\n", socket)
+
+			if err := server.ServeDaemon(ctx, listener); err != nil && err != context.Canceled {
+				return fmt.Errorf("MCP daemon failed: %w", err)
+			}
+		} else if err := server.Start(ctx); err != nil {
 			return fmt.Errorf("failed to start MCP server: %w", err)
 		}
 
@@ -152,6 +217,9 @@ func init() {
 	serverCmd.Flags().Int("metrics-port", 0, "port for metrics HTTP server (0 = use config default)")
 	_ = viper.BindPFlag("metrics_port", serverCmd.Flags().Lookup("metrics-port"))
 
+	serverCmd.Flags().Bool("daemon", false, "serve editors attached over a unix socket instead of stdio")
+	serverCmd.Flags().String("socket", "", "daemon socket path (default server.socket, then ~/.mcp-code-api/daemon.sock)")
+
 	// Add usage examples
 	serverCmd.SetUsageTemplate(serverCmd.UsageTemplate() + `
 Examples:
@@ -167,8 +235,33 @@ Examples:
   # Start server with custom metrics port
   mcp-code-api server --metrics-port 9090
 
+  # Share one server between editors: run the daemon once...
+  mcp-code-api server --daemon
+  # ...and configure each editor's MCP command as
+  mcp-code-api attach
+
   # Set API keys via environment variables
   CEREBRAS_API_KEY=your_key mcp-code-api server
   OPENROUTER_API_KEY=your_key mcp-code-api server
 `)
-}
\ No newline at end of file
+}
+
+// loadManagedConfig loads the user configuration and layers the
+// organization-managed policy bundle over it. A bundle that fails
+// verification is an error.
+func loadManagedConfig() (*config.Config, error) {
+	cfg, _, err := loadManagedPolicy()
+	return cfg, err
+}
+
+// loadManagedPolicy is loadManagedConfig that also returns the bundle, nil
+// if there is none, for commands that report on what it allows
+func loadManagedPolicy() (*config.Config, *policy.Bundle, error) {
+	cfg := config.Load()
+	bundle, err := policy.LoadManagedBundle()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load managed policy: %w", err)
+	}
+	bundle.Apply(cfg)
+	return cfg, bundle, nil
+}
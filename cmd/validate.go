@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateFormat string
+	validateOutput string
+)
+
+// validateCmd runs the syntax validation pipeline against files on disk
+var validateCmd = &cobra.Command{
+	Use:   "validate [files...]",
+	Short: "Run syntax validation on files and report findings",
+	Long: `Run the same language validators used by the write tool against files
+on disk. With --format sarif the findings are written as SARIF 2.1.0 so
+they can be uploaded to GitHub code scanning or other tooling.
+
+Exits with a non-zero status when any file has findings.`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadManagedConfig()
+		if err != nil {
+			return err
+		}
+		findings, err := collectValidationFindings(args, cfg.Validation)
+		if err != nil {
+			return err
+		}
+
+		switch validateFormat {
+		case "sarif":
+			wd, _ := os.Getwd()
+			data, err := validation.NewSARIFLog(cfg.Server.Name, cfg.Server.Version, wd, findings).JSON()
+			if err != nil {
+				return fmt.Errorf("failed to encode SARIF: %w", err)
+			}
+			if err := writeValidationOutput(data); err != nil {
+				return err
+			}
+		case "text":
+			var out string
+			for _, f := range findings {
+				out += fmt.Sprintf("%s\n%s\n\n", f.FilePath, validation.FormatValidationErrors(f.Errors, f.Language))
+			}
+			if err := writeValidationOutput([]byte(out)); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown format %q (expected text or sarif)", validateFormat)
+		}
+
+		if len(findings) > 0 {
+			return fmt.Errorf("validation failed for %d file(s)", len(findings))
+		}
+		return nil
+	},
+}
+
+// collectValidationFindings validates each file and returns those with errors
+func collectValidationFindings(files []string, cfg config.ValidationConfig) ([]validation.FileFindings, error) {
+	var findings []validation.FileFindings
+	for _, file := range files {
+		content, err := utils.ReadFileContent(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		language := validation.DetectLanguage(file)
+		result, err := language.ValidatorFor(cfg).Validate(content, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate %s: %w", file, err)
+		}
+		if !result.Valid {
+			findings = append(findings, validation.FileFindings{
+				FilePath: file,
+				Language: language,
+				Errors:   result.Errors,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// writeValidationOutput writes to --output, or stdout when not set
+func writeValidationOutput(data []byte) error {
+	if validateOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(validateOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", validateOutput, err)
+	}
+	return nil
+}
+
+func init() {
+	validateCmd.Flags().StringVar(&validateFormat, "format", "text", "output format: text or sarif")
+	validateCmd.Flags().StringVarP(&validateOutput, "output", "o", "", "write report to file instead of stdout")
+	rootCmd.AddCommand(validateCmd)
+}
@@ -7,22 +7,26 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
 	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
 	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
 )
 
+// anthropicDefaultModel is used when neither model nor models is configured
+const anthropicDefaultModel = "claude-3-5-sonnet-20241022"
+
 // AnthropicClient handles Anthropic API interactions
 type AnthropicClient struct {
-	config     config.AnthropicConfig
-	client     *http.Client
-	keyManager *APIKeyManager
-	lastUsage  *types.Usage  // Store last token usage
+	config        config.AnthropicConfig
+	client        *http.Client
+	keyManager    *APIKeyManager
+	modelSelector *ModelSelector
+	lastModel     string
+	lastUsage     *types.Usage  // Store last token usage
 }
 
 // NewAnthropicClient creates a new Anthropic client
@@ -36,12 +40,15 @@ func NewAnthropicClient(cfg config.AnthropicConfig) *AnthropicClient {
 		keys = append(keys, cfg.APIKeys...)
 	}
 
+	model := cfg.Model
+	if model == "" && len(cfg.Models) == 0 {
+		model = anthropicDefaultModel
+	}
 	return &AnthropicClient{
-		config:     cfg,
-		keyManager: NewAPIKeyManager("Anthropic", keys),
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		config:        cfg,
+		keyManager:    NewAPIKeyManager("Anthropic", keys, cfg.KeyBalancing),
+		modelSelector: newConfiguredModelSelector("Anthropic", model, cfg.Models, cfg.ModelStrategy),
+		client: &http.Client{},
 	}
 }
 
@@ -54,11 +61,17 @@ func (c *AnthropicClient) GenerateCode(ctx context.Context, prompt, contextStr,
 	// Determine language from file extension or explicit parameter
 	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
 
-	// Build the full prompt
-	fullPrompt := c.buildFullPrompt(prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+	// Build the prompt, keeping the context files apart so they can be cached
+	contextBlock, request := outboundPromptParts(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
 
 	// Prepare the request
-	requestData := c.prepareRequest(fullPrompt, detectedLanguage)
+	requestData, err := c.prepareRequest(contextBlock, request, prompts.System(ctx, "anthropic", detectedLanguage))
+	if err != nil {
+		return nil, err
+	}
+	c.lastModel = requestData.Model
+	// Stream when the caller wants to hear of the first token, e.g. a race
+	requestData.Stream = firstTokenFunc(ctx) != nil
 
 	// Use failover to try multiple API keys if needed
 	code, err := c.keyManager.ExecuteWithFailover(func(apiKey string) (string, error) {
@@ -68,24 +81,34 @@ func (c *AnthropicClient) GenerateCode(ctx context.Context, prompt, contextStr,
 			return "", err
 		}
 
-		// Store usage information
+		// Store usage information; input_tokens leaves out the cached prefix
+		// and output_tokens includes the thinking tokens
+		promptTokens := response.Usage.InputTokens + response.Usage.CacheCreationInputTokens + response.Usage.CacheReadInputTokens
 		c.lastUsage = &types.Usage{
-			PromptTokens:     response.Usage.InputTokens,
+			PromptTokens:     promptTokens,
 			CompletionTokens: response.Usage.OutputTokens,
-			TotalTokens:      response.Usage.InputTokens + response.Usage.OutputTokens,
+			TotalTokens:      promptTokens + response.Usage.OutputTokens,
+			CachedTokens:     response.Usage.CacheReadInputTokens,
 		}
-		logger.Debugf("Anthropic: Extracted token usage - Prompt: %d, Completion: %d, Total: %d",
-			c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
+		logger.Debugf("Anthropic: Extracted token usage - Prompt: %d (cached: %d, cache write: %d), Completion: %d, Total: %d",
+			c.lastUsage.PromptTokens, c.lastUsage.CachedTokens, response.Usage.CacheCreationInputTokens,
+			c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
 
-		// Extract and clean the content
-		if len(response.Content) == 0 {
+		if thinking := response.blocks("thinking"); thinking != "" && c.config.LogThinking {
+			logger.Debugf("Anthropic: Thinking:\n%s", thinking)
+		}
+
+		// Extract and clean the content; with thinking on, the answer
+		// follows the thinking blocks
+		content := response.blocks("text")
+		if content == "" {
 			return "", fmt.Errorf("no content in API response")
 		}
-		content := response.Content[0].Text
 		cleanedContent := utils.CleanCodeResponse(content)
 
 		return cleanedContent, nil
 	})
+	c.modelSelector.Record(requestData.Model, err)
 
 	if err != nil {
 		return nil, err
@@ -104,76 +127,70 @@ func (c *AnthropicClient) GenerateCode(ctx context.Context, prompt, contextStr,
 	return result, nil
 }
 
-// buildFullPrompt builds the complete prompt including context and existing content
-func (c *AnthropicClient) buildFullPrompt(prompt, contextStr, outputFile, detectedLanguage string, contextFiles []string) string {
-	var parts []string
-
-	// Add context files if provided
-	if len(contextFiles) > 0 {
-		filteredContextFiles := c.filterContextFiles(contextFiles, outputFile)
-
-		if len(filteredContextFiles) > 0 {
-			contextContent := "Context Files:\n"
-			for _, contextFile := range filteredContextFiles {
-				if content, err := utils.ReadFileContent(contextFile); err == nil && content != "" {
-					contextLang := utils.GetLanguageFromFile(contextFile, nil)
-					contextContent += fmt.Sprintf("\nFile: %s\n```%s\n%s\n```\n", contextFile, contextLang, content)
-				} else {
-					logger.Warnf("Could not read context file %s: %v", contextFile, err)
-				}
-			}
-			parts = append(parts, contextContent)
-		}
-	}
-
-	// Add additional context if provided
-	if contextStr != "" {
-		parts = append(parts, fmt.Sprintf("Context: %s", contextStr))
-	}
-
-	// Add existing file content if it exists
-	if existingContent, err := utils.ReadFileContent(outputFile); err == nil && existingContent != "" {
-		parts = append(parts, fmt.Sprintf("Existing file content:\n```%s\n%s\n```\n", detectedLanguage, existingContent))
+// GetModel returns the model used by the last request
+func (c *AnthropicClient) GetModel() string {
+	if c.lastModel != "" {
+		return c.lastModel
 	}
-
-	// Add the main prompt
-	parts = append(parts, fmt.Sprintf("Generate %s code for: %s", detectedLanguage, prompt))
-
-	return strings.Join(parts, "\n\n")
+	return c.config.Model
 }
 
-// filterContextFiles filters out the output file from context files
-func (c *AnthropicClient) filterContextFiles(contextFiles []string, outputFile string) []string {
-	var filtered []string
-	for _, file := range contextFiles {
-		contextAbs := filepath.Clean(file)
-		outputAbs := filepath.Clean(outputFile)
-
-		if contextAbs != outputAbs {
-			filtered = append(filtered, file)
-		}
+// prepareRequest prepares the API request payload. A context block large
+// enough to be cached is sent as its own content block with a cache
+// breakpoint, so the next request over the same files reads it from cache.
+func (c *AnthropicClient) prepareRequest(contextBlock, request, systemPrompt string) (AnthropicRequest, error) {
+	model, err := c.modelSelector.SelectModel()
+	if err != nil {
+		return AnthropicRequest{}, fmt.Errorf("failed to select model: %w", err)
 	}
-	return filtered
-}
 
-// prepareRequest prepares the API request payload
-func (c *AnthropicClient) prepareRequest(fullPrompt, detectedLanguage string) AnthropicRequest {
-	model := c.config.Model
-	if model == "" {
-		model = "claude-3-5-sonnet-20241022" // Default model
+	var content []AnthropicContentBlock
+	switch {
+	case contextBlock == "":
+		content = []AnthropicContentBlock{{Type: "text", Text: request}}
+	case c.config.DisablePromptCache || estimatePromptTokens(contextBlock) < anthropicMinCacheTokens:
+		content = []AnthropicContentBlock{{Type: "text", Text: contextBlock + "\n\n" + request}}
+	default:
+		content = []AnthropicContentBlock{
+			{Type: "text", Text: contextBlock, CacheControl: &AnthropicCacheControl{Type: "ephemeral"}},
+			{Type: "text", Text: request},
+		}
 	}
 
-	return AnthropicRequest{
+	requestData := AnthropicRequest{
 		Model:     model,
 		MaxTokens: 4096,
-		System:    fmt.Sprintf("You are an expert programmer. Generate ONLY clean, functional code in %s with no explanations, comments about the code generation process, or markdown formatting. Include necessary imports and ensure the code is ready to run. When modifying existing files, preserve the structure and style while implementing the requested changes. Output raw code only. Never use markdown code blocks.", detectedLanguage),
+		System:    systemPrompt,
 		Messages: []AnthropicMessage{
 			{
 				Role:    "user",
-				Content: fullPrompt,
+				Content: content,
 			},
 		},
 	}
+	if c.config.MaxTokens > 0 {
+		requestData.MaxTokens = c.config.MaxTokens
+	}
+	if budget := c.config.ThinkingBudget; budget > 0 {
+		requestData.Thinking = &AnthropicThinking{Type: "enabled", BudgetTokens: budget}
+		// The budget counts towards max_tokens, so leave room for the answer
+		if requestData.MaxTokens <= budget {
+			requestData.MaxTokens = budget + 4096
+		}
+	}
+	return requestData, nil
+}
+
+// cacheBreakpoint reports whether the request marks content for caching
+func (r AnthropicRequest) cacheBreakpoint() bool {
+	for _, message := range r.Messages {
+		for _, block := range message.Content {
+			if block.CacheControl != nil {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // makeAPICallWithKey makes the actual HTTP request to the Anthropic API with a specific API key
@@ -200,16 +217,36 @@ func (c *AnthropicClient) makeAPICallWithKey(ctx context.Context, requestData An
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
+	if requestData.cacheBreakpoint() {
+		req.Header.Set("anthropic-beta", anthropicPromptCachingBeta)
+	}
 
 	logger.Debugf("Making Anthropic API call to %s", url)
 
 	// Make the request
-	resp, err := c.client.Do(req)
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if requestData.Stream && isEventStream(resp) {
+		text, thinking, usage, err := readAnthropicStream(resp.Body, firstTokenFunc(ctx))
+		if err != nil {
+			return nil, err
+		}
+		content := []AnthropicContentBlock{{Type: "text", Text: text}}
+		if thinking != "" {
+			content = append([]AnthropicContentBlock{{Type: "thinking", Thinking: thinking}}, content...)
+		}
+		return &AnthropicResponse{
+			Role:    "assistant",
+			Content: content,
+			Model:   requestData.Model,
+			Usage:   usage,
+		}, nil
+	}
+
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -220,9 +257,9 @@ func (c *AnthropicClient) makeAPICallWithKey(ctx context.Context, requestData An
 	if resp.StatusCode != http.StatusOK {
 		var errorResponse AnthropicErrorResponse
 		if parseErr := json.Unmarshal(body, &errorResponse); parseErr == nil {
-			return nil, fmt.Errorf("Anthropic API error: %d - %s", resp.StatusCode, errorResponse.Error.Message)
+			return nil, newAPIError("Anthropic", resp, errorResponse.Error.Message)
 		}
-		return nil, fmt.Errorf("Anthropic API error: %d - %s", resp.StatusCode, string(body))
+		return nil, newAPIError("Anthropic", resp, string(body))
 	}
 
 	// Parse successful response
@@ -244,12 +281,20 @@ type AnthropicRequest struct {
 	MaxTokens int                `json:"max_tokens"`
 	System    string             `json:"system,omitempty"`
 	Messages  []AnthropicMessage `json:"messages"`
+	Stream    bool               `json:"stream,omitempty"`
+	Thinking  *AnthropicThinking `json:"thinking,omitempty"`
+}
+
+// AnthropicThinking turns on extended thinking with a budget of output tokens
+type AnthropicThinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 // AnthropicMessage represents a message in the conversation
 type AnthropicMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string                  `json:"role"`
+	Content []AnthropicContentBlock `json:"content"`
 }
 
 // AnthropicResponse represents the response from Anthropic API
@@ -262,16 +307,42 @@ type AnthropicResponse struct {
 	Usage   AnthropicUsage           `json:"usage"`
 }
 
-// AnthropicContentBlock represents a content block in the response
+// blocks returns the joined content of the response's blocks of type
+// blockType ("text" or "thinking")
+func (r *AnthropicResponse) blocks(blockType string) string {
+	var parts []string
+	for _, block := range r.Content {
+		if block.Type != blockType {
+			continue
+		}
+		if blockType == "thinking" {
+			parts = append(parts, block.Thinking)
+		} else {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// AnthropicContentBlock represents a content block in a message or the response
 type AnthropicContentBlock struct {
+	Type         string                 `json:"type"`
+	Text         string                 `json:"text"`
+	CacheControl *AnthropicCacheControl `json:"cache_control,omitempty"`
+	Thinking     string                 `json:"thinking,omitempty"` // Set in "thinking" response blocks
+}
+
+// AnthropicCacheControl marks the end of a prompt prefix to cache
+type AnthropicCacheControl struct {
 	Type string `json:"type"`
-	Text string `json:"text"`
 }
 
 // AnthropicUsage represents token usage information
 type AnthropicUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
 // AnthropicErrorResponse represents an error response
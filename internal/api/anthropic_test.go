@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestAnthropicExtendedThinking(t *testing.T) {
+	var req AnthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = AnthropicRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprint(w, `{"content":[{"type":"thinking","thinking":"Print a constant.","signature":"sig"},`+
+			`{"type":"text","text":"print(1)"}],"usage":{"input_tokens":10,"output_tokens":900}}`)
+	}))
+	defer srv.Close()
+
+	client := NewAnthropicClient(config.AnthropicConfig{APIKey: "key", BaseURL: srv.URL, MaxTokens: 8000, ThinkingBudget: 10000})
+	result, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Thinking == nil || req.Thinking.Type != "enabled" || req.Thinking.BudgetTokens != 10000 {
+		t.Errorf("thinking = %+v, want enabled with a budget of 10000", req.Thinking)
+	}
+	if req.MaxTokens <= 10000 {
+		t.Errorf("max_tokens = %d, want it raised above the thinking budget", req.MaxTokens)
+	}
+	if result.Code != "print(1)" {
+		t.Errorf("code = %q, want the text block without the thinking", result.Code)
+	}
+	if u := result.Usage; u.CompletionTokens != 900 || u.TotalTokens != 910 {
+		t.Errorf("usage = %+v, want thinking counted in the completion tokens", u)
+	}
+
+	client = NewAnthropicClient(config.AnthropicConfig{APIKey: "key", BaseURL: srv.URL, MaxTokens: 2048})
+	if _, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if req.Thinking != nil || req.MaxTokens != 2048 {
+		t.Errorf("thinking = %+v, max_tokens = %d, want no thinking and the configured 2048", req.Thinking, req.MaxTokens)
+	}
+}
+
+func TestReadAnthropicStreamSeparatesThinking(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"type":"message_start","message":{"usage":{"input_tokens":10}}}`,
+		`data: {"type":"content_block_delta","delta":{"type":"thinking_delta","thinking":"Print "}}`,
+		`data: {"type":"content_block_delta","delta":{"type":"thinking_delta","thinking":"a constant."}}`,
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"print(1)"}}`,
+		`data: {"type":"message_delta","usage":{"output_tokens":42}}`,
+	}, "\n\n")
+
+	firsts := 0
+	text, thinking, usage, err := readAnthropicStream(strings.NewReader(stream), func() { firsts++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "print(1)" || thinking != "Print a constant." {
+		t.Errorf("text = %q, thinking = %q", text, thinking)
+	}
+	if firsts != 1 || usage.OutputTokens != 42 {
+		t.Errorf("first token calls = %d, output tokens = %d, want 1 and 42", firsts, usage.OutputTokens)
+	}
+}
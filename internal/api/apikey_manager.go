@@ -1,18 +1,48 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
 )
 
+// rateWindow is the window for per-key request rate tracking
+const rateWindow = time.Minute
+
+// ErrNoAPIKeys is returned for a provider without API keys
+var ErrNoAPIKeys = errors.New("no API keys configured")
+
+// KeysUnavailableError is returned while every API key of a provider is in
+// backoff after failures or at its per-minute limit
+type KeysUnavailableError struct {
+	Provider string
+	Keys     int
+}
+
+func (e *KeysUnavailableError) Error() string {
+	if e.Keys == 1 {
+		return fmt.Sprintf("only API key for %s is unavailable (in backoff or rate limited)", e.Provider)
+	}
+	return fmt.Sprintf("all %d API keys for %s are currently unavailable", e.Keys, e.Provider)
+}
+
 // APIKeyManager manages multiple API keys with load balancing and failover
 type APIKeyManager struct {
 	providerName string
 	keys         []string
+	balancing    config.KeyBalancing
+	weights      []int  // Effective weights, one per key
+	current      []int  // Smooth weighted round-robin state
 	currentIndex uint32 // Atomic counter for round-robin
 	keyHealth    map[string]*keyHealth
 	mu           sync.RWMutex
@@ -25,33 +55,153 @@ type keyHealth struct {
 	lastSuccess  time.Time
 	isHealthy    bool
 	backoffUntil time.Time
+
+	// Usage, for rate tracking and least-recently-used selection
+	lastUsed      time.Time
+	totalRequests int
+	totalFailures int
+	rateLimitHits int         // Failures the provider answered with 429
+	recent        []time.Time // Request times within rateWindow
+}
+
+// KeyUsage is one API key's share of a multi-key provider's traffic, for
+// telling which key is using up its quota
+type KeyUsage struct {
+	Key           string  `json:"Key"` // Masked to its last characters, e.g. "...f3a9"
+	Requests      int64   `json:"Requests"`
+	Failures      int64   `json:"Failures"`
+	RateLimitHits int64   `json:"RateLimitHits"`
+	ErrorRate     float64 `json:"ErrorRate"` // Failures per request, 0 to 1
 }
 
-// NewAPIKeyManager creates a new API key manager
-func NewAPIKeyManager(providerName string, keys []string) *APIKeyManager {
+// keyManagers shares managers across clients, since the router creates a new
+// client for every request; without it the rotation and health state would
+// restart on each call
+var keyManagers = struct {
+	sync.Mutex
+	managers map[string]*APIKeyManager
+}{managers: make(map[string]*APIKeyManager)}
+
+// NewAPIKeyManager returns the key manager for a provider, reusing the
+// existing one while the keys and balancing settings are unchanged
+func NewAPIKeyManager(providerName string, keys []string, balancing config.KeyBalancing) *APIKeyManager {
 	if len(keys) == 0 {
 		return nil
 	}
 
+	requested := balancing.KeyStrategy
+	switch balancing.KeyStrategy {
+	case config.KeyStrategyRoundRobin, config.KeyStrategyWeighted, config.KeyStrategyLRU:
+	default:
+		balancing.KeyStrategy = config.KeyStrategyRoundRobin
+	}
+
+	keyManagers.Lock()
+	defer keyManagers.Unlock()
+
+	if existing, ok := keyManagers.managers[providerName]; ok &&
+		slices.Equal(existing.keys, keys) &&
+		existing.balancing.KeyStrategy == balancing.KeyStrategy &&
+		slices.Equal(existing.balancing.KeyWeights, balancing.KeyWeights) &&
+		existing.balancing.KeyRPMLimit == balancing.KeyRPMLimit {
+		return existing
+	}
+
+	if requested != "" && requested != balancing.KeyStrategy {
+		logger.Warnf("%s: unknown key_strategy %q, using %s", providerName, requested, balancing.KeyStrategy)
+	}
+
 	manager := &APIKeyManager{
 		providerName: providerName,
 		keys:         keys,
+		balancing:    balancing,
+		weights:      make([]int, len(keys)),
+		current:      make([]int, len(keys)),
 		currentIndex: 0,
 		keyHealth:    make(map[string]*keyHealth),
 	}
 
 	// Initialize health tracking for all keys
-	for _, key := range keys {
+	for i, key := range keys {
 		manager.keyHealth[key] = &keyHealth{
 			isHealthy:   true,
 			lastSuccess: time.Now(),
 		}
+		manager.weights[i] = 1
+		if i < len(balancing.KeyWeights) && balancing.KeyWeights[i] > 0 {
+			manager.weights[i] = balancing.KeyWeights[i]
+		}
 	}
+	keyManagers.managers[providerName] = manager
 
-	logger.Infof("APIKeyManager initialized for %s with %d key(s)", providerName, len(keys))
+	logger.Infof("APIKeyManager initialized for %s with %d key(s) (%s)", providerName, len(keys), balancing.KeyStrategy)
 	return manager
 }
 
+// KeyManagerStatus returns the status of every key manager in use, by provider
+func KeyManagerStatus() []map[string]interface{} {
+	keyManagers.Lock()
+	managers := make([]*APIKeyManager, 0, len(keyManagers.managers))
+	for _, m := range keyManagers.managers {
+		managers = append(managers, m)
+	}
+	keyManagers.Unlock()
+
+	sort.Slice(managers, func(i, j int) bool { return managers[i].providerName < managers[j].providerName })
+	statuses := make([]map[string]interface{}, 0, len(managers))
+	for _, m := range managers {
+		statuses = append(statuses, m.GetStatus())
+	}
+	return statuses
+}
+
+// KeyUsageFor returns the usage of each key of providerName, which is
+// matched ignoring case and dashes ("azure-openai" finds "AzureOpenAI").
+// Providers with a single key return nil.
+func KeyUsageFor(providerName string) []KeyUsage {
+	want := normalizeProviderName(providerName)
+	keyManagers.Lock()
+	var manager *APIKeyManager
+	for name, m := range keyManagers.managers {
+		if normalizeProviderName(name) == want {
+			manager = m
+			break
+		}
+	}
+	keyManagers.Unlock()
+	if manager == nil || len(manager.keys) < 2 {
+		return nil
+	}
+	return manager.Usage()
+}
+
+// normalizeProviderName folds the spellings of a provider's name
+func normalizeProviderName(name string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+// Usage returns the request, failure and rate limit counts of each key
+func (m *APIKeyManager) Usage() []KeyUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	usage := make([]KeyUsage, 0, len(m.keys))
+	for _, key := range m.keys {
+		health := m.keyHealth[key]
+		entry := KeyUsage{
+			Key:           maskKeySuffix(key),
+			Requests:      int64(health.totalRequests),
+			Failures:      int64(health.totalFailures),
+			RateLimitHits: int64(health.rateLimitHits),
+		}
+		if entry.Requests > 0 {
+			entry.ErrorRate = float64(entry.Failures) / float64(entry.Requests)
+		}
+		usage = append(usage, entry)
+	}
+	return usage
+}
+
 // GetCurrentKey returns the first available API key without advancing the round-robin counter
 // This is useful for queries that don't need load balancing (e.g., rate limit checks)
 func (m *APIKeyManager) GetCurrentKey() string {
@@ -74,43 +224,95 @@ func (m *APIKeyManager) GetCurrentKey() string {
 	return m.keys[0]
 }
 
-// GetNextKey returns the next available API key using round-robin load balancing
-// It skips keys that are currently in backoff due to failures
+// GetNextKey selects the next API key according to the configured strategy
+// and records its use. It skips keys in backoff due to failures and keys that
+// have reached their per-minute limit.
 func (m *APIKeyManager) GetNextKey() (string, error) {
 	if len(m.keys) == 0 {
-		return "", fmt.Errorf("no API keys configured for %s", m.providerName)
+		return "", fmt.Errorf("%w for %s", ErrNoAPIKeys, m.providerName)
 	}
 
-	// Single key fast path
-	if len(m.keys) == 1 {
-		m.mu.RLock()
-		health := m.keyHealth[m.keys[0]]
-		m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-		if m.isKeyAvailable(m.keys[0], health) {
-			return m.keys[0], nil
+	now := time.Now()
+	var available []int
+	for i, key := range m.keys {
+		health := m.keyHealth[key]
+		health.recent = pruneRequests(health.recent, now)
+		if m.isKeyAvailable(key, health) && !m.overRateLimit(health) {
+			available = append(available, i)
 		}
-		return "", fmt.Errorf("only API key for %s is unavailable (in backoff)", m.providerName)
+	}
+	if len(available) == 0 {
+		return "", &KeysUnavailableError{Provider: m.providerName, Keys: len(m.keys)}
 	}
 
-	// Try all keys in round-robin order
-	startIndex := atomic.AddUint32(&m.currentIndex, 1) % uint32(len(m.keys))
+	var index int
+	switch m.balancing.KeyStrategy {
+	case config.KeyStrategyWeighted:
+		index = m.nextWeighted(available)
+	case config.KeyStrategyLRU:
+		index = available[0]
+		for _, i := range available[1:] {
+			if m.keyHealth[m.keys[i]].lastUsed.Before(m.keyHealth[m.keys[index]].lastUsed) {
+				index = i
+			}
+		}
+	default:
+		// Round-robin from the next position, taking the first available key
+		start := int(atomic.AddUint32(&m.currentIndex, 1) % uint32(len(m.keys)))
+		index = available[0]
+		for _, i := range available {
+			if i >= start {
+				index = i
+				break
+			}
+		}
+	}
 
-	for i := 0; i < len(m.keys); i++ {
-		index := (int(startIndex) + i) % len(m.keys)
-		key := m.keys[index]
+	key := m.keys[index]
+	health := m.keyHealth[key]
+	health.lastUsed = now
+	health.totalRequests++
+	health.recent = append(health.recent, now)
 
-		m.mu.RLock()
-		health := m.keyHealth[key]
-		m.mu.RUnlock()
+	if len(m.keys) > 1 {
+		logger.Debugf("%s: Selected key #%d/%d (%s, %d request(s) in the last minute)",
+			m.providerName, index+1, len(m.keys), m.balancing.KeyStrategy, len(health.recent))
+	}
+	return key, nil
+}
 
-		if m.isKeyAvailable(key, health) {
-			logger.Debugf("%s: Selected key #%d/%d", m.providerName, index+1, len(m.keys))
-			return key, nil
+// nextWeighted picks among the available key indexes with smooth weighted
+// round-robin, which interleaves keys instead of sending bursts to one
+func (m *APIKeyManager) nextWeighted(available []int) int {
+	total := 0
+	best := -1
+	for _, i := range available {
+		m.current[i] += m.weights[i]
+		total += m.weights[i]
+		if best < 0 || m.current[i] > m.current[best] {
+			best = i
 		}
 	}
+	m.current[best] -= total
+	return best
+}
 
-	return "", fmt.Errorf("all %d API keys for %s are currently unavailable", len(m.keys), m.providerName)
+// overRateLimit reports whether a key has used up its per-minute allowance
+func (m *APIKeyManager) overRateLimit(health *keyHealth) bool {
+	return m.balancing.KeyRPMLimit > 0 && len(health.recent) >= m.balancing.KeyRPMLimit
+}
+
+// pruneRequests drops request times older than rateWindow
+func pruneRequests(times []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-rateWindow)
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
 }
 
 // isKeyAvailable checks if a key is available (not in backoff)
@@ -158,6 +360,10 @@ func (m *APIKeyManager) ReportFailure(key string, err error) {
 
 	health.lastFailure = time.Now()
 	health.failureCount++
+	health.totalFailures++
+	if isRateLimited(err) {
+		health.rateLimitHits++
+	}
 
 	// Exponential backoff: 1s, 2s, 4s, 8s, max 60s
 	backoffSeconds := 1 << uint(min(health.failureCount-1, 6))
@@ -181,7 +387,7 @@ func (m *APIKeyManager) ReportFailure(key string, err error) {
 // The operation function should accept an API key and return (result, error)
 func (m *APIKeyManager) ExecuteWithFailover(operation func(apiKey string) (string, error)) (string, error) {
 	if len(m.keys) == 0 {
-		return "", fmt.Errorf("no API keys configured for %s", m.providerName)
+		return "", fmt.Errorf("%w for %s", ErrNoAPIKeys, m.providerName)
 	}
 
 	var lastErr error
@@ -200,6 +406,10 @@ func (m *APIKeyManager) ExecuteWithFailover(operation func(apiKey string) (strin
 
 		// Execute the operation
 		result, err := operation(key)
+		if errors.Is(err, context.Canceled) {
+			// The caller gave up, e.g. a lost race or hedge; the key is fine
+			return "", err
+		}
 		if err != nil {
 			lastErr = err
 			m.ReportFailure(key, err)
@@ -230,6 +440,10 @@ func (m *APIKeyManager) GetStatus() map[string]interface{} {
 	status := make(map[string]interface{})
 	status["provider"] = m.providerName
 	status["total_keys"] = len(m.keys)
+	status["strategy"] = m.balancing.KeyStrategy
+	if m.balancing.KeyRPMLimit > 0 {
+		status["rpm_limit"] = m.balancing.KeyRPMLimit
+	}
 
 	healthyCount := 0
 	keyStatuses := make([]map[string]interface{}, 0, len(m.keys))
@@ -239,11 +453,23 @@ func (m *APIKeyManager) GetStatus() map[string]interface{} {
 		keyMasked := maskAPIKey(key)
 
 		keyStatus := map[string]interface{}{
-			"index":         i + 1,
-			"key_masked":    keyMasked,
-			"healthy":       health.isHealthy,
-			"failure_count": health.failureCount,
-			"last_success":  health.lastSuccess.Format(time.RFC3339),
+			"index":                i + 1,
+			"key_masked":           keyMasked,
+			"healthy":              health.isHealthy,
+			"failure_count":        health.failureCount,
+			"last_success":         health.lastSuccess.Format(time.RFC3339),
+			"total_requests":       health.totalRequests,
+			"total_failures":       health.totalFailures,
+			"rate_limit_hits":      health.rateLimitHits,
+			"requests_last_minute": len(pruneRequests(health.recent, time.Now())),
+		}
+
+		if m.balancing.KeyStrategy == config.KeyStrategyWeighted {
+			keyStatus["weight"] = m.weights[i]
+		}
+
+		if !health.lastUsed.IsZero() {
+			keyStatus["last_used"] = health.lastUsed.Format(time.RFC3339)
 		}
 
 		if !health.lastFailure.IsZero() {
@@ -277,6 +503,22 @@ func maskAPIKey(key string) string {
 	return key[:8] + "..." + key[len(key)-4:]
 }
 
+// maskKeySuffix masks an API key down to its last 4 characters, which is
+// enough to find it among a provider's keys
+func maskKeySuffix(key string) string {
+	if len(key) <= 12 {
+		return "***"
+	}
+	return "..." + key[len(key)-4:]
+}
+
+// isRateLimited reports whether err is the provider rejecting a request for
+// exceeding the key's rate limit or quota
+func isRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
 // min returns the minimum of two integers
 func min(a, b int) int {
 	if a < b {
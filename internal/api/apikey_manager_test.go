@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func pickKeys(t *testing.T, m *APIKeyManager, n int) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		key, err := m.GetNextKey()
+		if err != nil {
+			t.Fatalf("GetNextKey() error = %v", err)
+		}
+		counts[key]++
+	}
+	return counts
+}
+
+func TestAPIKeyManagerWeighted(t *testing.T) {
+	m := NewAPIKeyManager(t.Name(), []string{"a", "b"}, config.KeyBalancing{
+		KeyStrategy: config.KeyStrategyWeighted,
+		KeyWeights:  []int{3, 1},
+	})
+	counts := pickKeys(t, m, 8)
+	if counts["a"] != 6 || counts["b"] != 2 {
+		t.Errorf("weighted picks = %v, want a:6 b:2", counts)
+	}
+}
+
+func TestAPIKeyManagerLeastRecentlyUsed(t *testing.T) {
+	m := NewAPIKeyManager(t.Name(), []string{"a", "b", "c"}, config.KeyBalancing{KeyStrategy: config.KeyStrategyLRU})
+	counts := pickKeys(t, m, 6)
+	for _, key := range []string{"a", "b", "c"} {
+		if counts[key] != 2 {
+			t.Errorf("LRU picks = %v, want 2 each", counts)
+			break
+		}
+	}
+}
+
+func TestAPIKeyManagerRateLimit(t *testing.T) {
+	m := NewAPIKeyManager(t.Name(), []string{"a", "b"}, config.KeyBalancing{KeyRPMLimit: 1})
+	pickKeys(t, m, 2)
+	if key, err := m.GetNextKey(); err == nil {
+		t.Errorf("GetNextKey() = %q, want error once every key hit its per-minute limit", key)
+	}
+}
+
+func TestAPIKeyManagerSharedAcrossClients(t *testing.T) {
+	keys := []string{"a", "b"}
+	first := NewAPIKeyManager(t.Name(), keys, config.KeyBalancing{})
+	if NewAPIKeyManager(t.Name(), keys, config.KeyBalancing{}) != first {
+		t.Error("NewAPIKeyManager() created a new manager for unchanged keys")
+	}
+	if NewAPIKeyManager(t.Name(), []string{"a", "c"}, config.KeyBalancing{}) == first {
+		t.Error("NewAPIKeyManager() reused the manager after the keys changed")
+	}
+}
+
+func TestKeyUsage(t *testing.T) {
+	keys := []string{"sk-first-key-0001", "sk-second-key-0002"}
+	m := NewAPIKeyManager("KeyUsageTest", keys, config.KeyBalancing{})
+	counts := pickKeys(t, m, 4)
+	m.ReportFailure(keys[0], &APIError{Provider: "KeyUsageTest", StatusCode: 429, Message: "quota exceeded"})
+	m.ReportFailure(keys[0], fmt.Errorf("connection reset"))
+
+	usage := KeyUsageFor("key-usage-test")
+	if len(usage) != 2 {
+		t.Fatalf("KeyUsageFor() = %+v, want 2 keys", usage)
+	}
+	first := usage[0]
+	if first.Key != "...0001" || first.Requests != int64(counts[keys[0]]) || first.Failures != 2 || first.RateLimitHits != 1 {
+		t.Errorf("first key = %+v", first)
+	}
+	if want := 2 / float64(counts[keys[0]]); first.ErrorRate != want {
+		t.Errorf("ErrorRate = %v, want %v", first.ErrorRate, want)
+	}
+	if usage[1].Failures != 0 || usage[1].ErrorRate != 0 {
+		t.Errorf("second key = %+v", usage[1])
+	}
+
+	NewAPIKeyManager("SingleKeyUsageTest", []string{"sk-only-key-0003"}, config.KeyBalancing{})
+	if usage := KeyUsageFor("singlekeyusagetest"); usage != nil {
+		t.Errorf("single key provider usage = %+v, want nil", usage)
+	}
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/keyring"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigTokenStorage implements TokenStorage on the YAML config file, where
+// the config wizard puts OAuth logins. A provider whose config has a
+// token_ref keeps its token in the OS credential store instead.
+type ConfigTokenStorage struct {
+	path string
+}
+
+// flatTokenProviders keep their tokens as top-level provider fields
+// (access_token, refresh_token, token_expiry) rather than in an oauth block
+var flatTokenProviders = map[string]bool{"gemini": true}
+
+// NewConfigTokenStorage creates a token storage on the config file at path
+func NewConfigTokenStorage(path string) *ConfigTokenStorage {
+	return &ConfigTokenStorage{path: path}
+}
+
+// StoreToken saves provider's token to its credential store entry or the
+// config file
+func (cts *ConfigTokenStorage) StoreToken(provider string, token *OAuthConfig) error {
+	if token == nil {
+		return fmt.Errorf("token cannot be nil")
+	}
+
+	doc, err := cts.read()
+	if err != nil {
+		return err
+	}
+	section := tokenSection(doc, provider, true)
+
+	if ref, _ := section["token_ref"].(string); ref != "" {
+		account, err := keyring.Account(ref)
+		if err != nil {
+			return err
+		}
+		return keyring.SetToken(account, &keyring.Token{
+			AccessToken:  token.AccessToken,
+			RefreshToken: token.RefreshToken,
+			TokenType:    token.TokenType,
+			Expiry:       token.ExpiresAt,
+		})
+	}
+
+	section["access_token"] = token.AccessToken
+	section["refresh_token"] = token.RefreshToken
+	if flatTokenProviders[provider] {
+		section["token_expiry"] = token.ExpiresAt.Format(time.RFC3339)
+	} else {
+		section["expires_at"] = token.ExpiresAt.Format(time.RFC3339)
+		section["token_type"] = token.TokenType
+	}
+	return cts.write(doc)
+}
+
+// RetrieveToken returns provider's token. Unlike the other storages it also
+// returns expired tokens, since their refresh token is still good.
+func (cts *ConfigTokenStorage) RetrieveToken(provider string) (*OAuthConfig, error) {
+	doc, err := cts.read()
+	if err != nil {
+		return nil, err
+	}
+	section := tokenSection(doc, provider, false)
+	if section == nil {
+		return nil, fmt.Errorf("token not found for provider: %s", provider)
+	}
+
+	if ref, _ := section["token_ref"].(string); ref != "" {
+		stored, err := keyring.ResolveToken(ref)
+		if err != nil {
+			return nil, err
+		}
+		return &OAuthConfig{
+			AccessToken:  stored.AccessToken,
+			RefreshToken: stored.RefreshToken,
+			TokenType:    stored.TokenType,
+			ExpiresAt:    stored.Expiry,
+		}, nil
+	}
+
+	token := &OAuthConfig{}
+	token.AccessToken, _ = section["access_token"].(string)
+	token.RefreshToken, _ = section["refresh_token"].(string)
+	token.TokenType, _ = section["token_type"].(string)
+	expiryKey := "expires_at"
+	if flatTokenProviders[provider] {
+		expiryKey = "token_expiry"
+	}
+	switch expiry := section[expiryKey].(type) {
+	case time.Time:
+		token.ExpiresAt = expiry
+	case string:
+		token.ExpiresAt, _ = time.Parse(time.RFC3339, expiry)
+	}
+	if token.AccessToken == "" && token.RefreshToken == "" {
+		return nil, fmt.Errorf("token not found for provider: %s", provider)
+	}
+	return token, nil
+}
+
+// DeleteToken removes provider's token from the config file and from the
+// credential store entry it refers to
+func (cts *ConfigTokenStorage) DeleteToken(provider string) error {
+	doc, err := cts.read()
+	if err != nil {
+		return err
+	}
+	section := tokenSection(doc, provider, false)
+	if section == nil {
+		return nil
+	}
+
+	if ref, _ := section["token_ref"].(string); ref != "" {
+		if account, err := keyring.Account(ref); err == nil {
+			if err := keyring.Delete(account); err != nil && err != keyring.ErrNotFound {
+				return fmt.Errorf("failed to delete %s: %w", ref, err)
+			}
+		}
+	}
+	for _, key := range []string{"access_token", "refresh_token", "token_expiry", "expires_at", "token_type", "token_ref"} {
+		delete(section, key)
+	}
+	return cts.write(doc)
+}
+
+// ListTokens returns the providers with a token in the config file
+func (cts *ConfigTokenStorage) ListTokens() ([]string, error) {
+	doc, err := cts.read()
+	if err != nil {
+		return nil, err
+	}
+	providers, _ := doc["providers"].(map[string]interface{})
+
+	var names []string
+	for name := range providers {
+		section := tokenSection(doc, name, false)
+		if section == nil {
+			continue
+		}
+		if section["access_token"] != nil || section["token_ref"] != nil {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// IsTokenValid checks if a token exists and is not expired
+func (cts *ConfigTokenStorage) IsTokenValid(provider string) bool {
+	token, err := cts.RetrieveToken(provider)
+	if err != nil {
+		return false
+	}
+	return token.AccessToken != "" && !isExpired(token)
+}
+
+func (cts *ConfigTokenStorage) read() (map[string]interface{}, error) {
+	data, err := os.ReadFile(cts.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	return doc, nil
+}
+
+func (cts *ConfigTokenStorage) write(doc map[string]interface{}) error {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal updated config: %w", err)
+	}
+	if err := os.WriteFile(cts.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write updated config file: %w", err)
+	}
+	return nil
+}
+
+// tokenSection returns the map holding provider's token fields, creating it
+// if create is set, or nil
+func tokenSection(doc map[string]interface{}, provider string, create bool) map[string]interface{} {
+	child := func(parent map[string]interface{}, key string) map[string]interface{} {
+		section, ok := parent[key].(map[string]interface{})
+		if !ok && create {
+			section = make(map[string]interface{})
+			parent[key] = section
+		}
+		return section
+	}
+
+	section := child(doc, "providers")
+	if section == nil {
+		return nil
+	}
+	section = child(section, provider)
+	if section == nil || flatTokenProviders[provider] {
+		return section
+	}
+	return child(section, "oauth")
+}
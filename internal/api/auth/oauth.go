@@ -17,6 +17,7 @@ type OAuthAuthenticatorImpl struct {
 	provider string
 	config   *OAuthConfig
 	storage  TokenStorage
+	tokens   *TokenManager
 	client   *http.Client
 	state    string
 	lastAuth time.Time
@@ -28,10 +29,20 @@ func NewOAuthAuthenticator(provider string, storage TokenStorage) *OAuthAuthenti
 	return &OAuthAuthenticatorImpl{
 		provider: provider,
 		storage:  storage,
+		tokens:   NewTokenManager(storage),
 		client:   &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
+// UseTokenManager makes the authenticator refresh through tokens, such as
+// the process-wide Tokens(), instead of its own manager
+func (a *OAuthAuthenticatorImpl) UseTokenManager(tokens *TokenManager) {
+	a.tokens = tokens
+	if a.config != nil && a.config.AccessToken != "" {
+		a.tokens.Register(a.provider, a.config, a.exchangeRefreshToken)
+	}
+}
+
 // Authenticate performs authentication with the given config
 func (a *OAuthAuthenticatorImpl) Authenticate(ctx context.Context, config AuthConfig) error {
 	if config.Method != AuthMethodOAuth {
@@ -56,6 +67,7 @@ func (a *OAuthAuthenticatorImpl) Authenticate(ctx context.Context, config AuthCo
 	// Check if we have a stored token
 	if storedToken, err := a.storage.RetrieveToken(a.provider); err == nil && storedToken != nil {
 		a.config = storedToken
+		a.tokens.Register(a.provider, a.config, a.exchangeRefreshToken)
 		if !a.isTokenExpired(storedToken) {
 			a.isAuth = true
 			a.lastAuth = time.Now()
@@ -103,7 +115,8 @@ func (a *OAuthAuthenticatorImpl) GetToken() (string, error) {
 	return a.config.AccessToken, nil
 }
 
-// RefreshToken refreshes the authentication token
+// RefreshToken refreshes the authentication token through the token
+// manager, which stores the refreshed token
 func (a *OAuthAuthenticatorImpl) RefreshToken(ctx context.Context) error {
 	if a.config == nil || a.config.RefreshToken == "" {
 		return &AuthError{
@@ -113,21 +126,35 @@ func (a *OAuthAuthenticatorImpl) RefreshToken(ctx context.Context) error {
 		}
 	}
 
+	a.tokens.Register(a.provider, a.config, a.exchangeRefreshToken)
+	token, err := a.tokens.Refresh(ctx, a.provider)
+	if err != nil {
+		return err
+	}
+
+	a.config = token
+	a.isAuth = true
+	a.lastAuth = time.Now()
+	return nil
+}
+
+// exchangeRefreshToken is the RefreshFunc for the token endpoint in token
+func (a *OAuthAuthenticatorImpl) exchangeRefreshToken(ctx context.Context, token *OAuthConfig) (*OAuthConfig, error) {
 	// Use token URL or refresh URL
-	tokenURL := a.config.RefreshURL
+	tokenURL := token.RefreshURL
 	if tokenURL == "" {
-		tokenURL = a.config.TokenURL
+		tokenURL = token.TokenURL
 	}
 
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
-	data.Set("refresh_token", a.config.RefreshToken)
-	data.Set("client_id", a.config.ClientID)
-	data.Set("client_secret", a.config.ClientSecret)
+	data.Set("refresh_token", token.RefreshToken)
+	data.Set("client_id", token.ClientID)
+	data.Set("client_secret", token.ClientSecret)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		return &AuthError{
+		return nil, &AuthError{
 			Provider: a.provider,
 			Code:     ErrCodeNetworkError,
 			Message:  "Failed to create refresh request",
@@ -140,7 +167,7 @@ func (a *OAuthAuthenticatorImpl) RefreshToken(ctx context.Context) error {
 
 	resp, err := a.client.Do(req)
 	if err != nil {
-		return &AuthError{
+		return nil, &AuthError{
 			Provider: a.provider,
 			Code:     ErrCodeNetworkError,
 			Message:  "Network error during token refresh",
@@ -150,7 +177,7 @@ func (a *OAuthAuthenticatorImpl) RefreshToken(ctx context.Context) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return &AuthError{
+		return nil, &AuthError{
 			Provider: a.provider,
 			Code:     ErrCodeRefreshFailed,
 			Message:  fmt.Sprintf("Token refresh failed with status %d", resp.StatusCode),
@@ -159,7 +186,7 @@ func (a *OAuthAuthenticatorImpl) RefreshToken(ctx context.Context) error {
 
 	var tokenResp OAuthTokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return &AuthError{
+		return nil, &AuthError{
 			Provider: a.provider,
 			Code:     ErrCodeRefreshFailed,
 			Message:  "Failed to parse refresh response",
@@ -167,27 +194,15 @@ func (a *OAuthAuthenticatorImpl) RefreshToken(ctx context.Context) error {
 		}
 	}
 
-	// Update config with new token
-	a.config.AccessToken = tokenResp.AccessToken
-	a.config.RefreshToken = tokenResp.RefreshToken
+	// Update a copy of the token with the new one
+	fresh := *token
+	fresh.AccessToken = tokenResp.AccessToken
+	fresh.RefreshToken = tokenResp.RefreshToken
 	if tokenResp.ExpiresIn > 0 {
-		a.config.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-	}
-	a.config.TokenType = tokenResp.TokenType
-
-	// Store the updated token
-	if err := a.storage.StoreToken(a.provider, a.config); err != nil {
-		return &AuthError{
-			Provider: a.provider,
-			Code:     ErrCodeNetworkError,
-			Message:  "Failed to store refreshed token",
-			Details:  err.Error(),
-		}
+		fresh.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
 	}
-
-	a.isAuth = true
-	a.lastAuth = time.Now()
-	return nil
+	fresh.TokenType = tokenResp.TokenType
+	return &fresh, nil
 }
 
 // Logout clears authentication state
@@ -201,6 +216,7 @@ func (a *OAuthAuthenticatorImpl) Logout(ctx context.Context) error {
 		}
 	}
 
+	a.tokens.Remove(a.provider)
 	a.config = nil
 	a.isAuth = false
 	a.lastAuth = time.Time{}
@@ -349,6 +365,7 @@ func (a *OAuthAuthenticatorImpl) HandleCallback(ctx context.Context, code, state
 			Details:  err.Error(),
 		}
 	}
+	a.tokens.Register(a.provider, a.config, a.exchangeRefreshToken)
 
 	a.isAuth = true
 	a.lastAuth = time.Now()
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// DefaultRefreshMargin is how long before expiry a token is refreshed
+const DefaultRefreshMargin = 5 * time.Minute
+
+// RefreshFunc exchanges token's refresh token for a new token. It returns a
+// copy of token with the new access token, refresh token and expiry.
+type RefreshFunc func(ctx context.Context, token *OAuthConfig) (*OAuthConfig, error)
+
+// TokenManager owns the OAuth tokens of every provider. It hands out valid
+// access tokens, refreshes each token shortly before it expires, lets
+// concurrent callers share a single refresh, and saves refreshed tokens to
+// its storage.
+type TokenManager struct {
+	storage TokenStorage
+	margin  time.Duration
+	closed  atomic.Bool
+
+	mutex  sync.Mutex
+	tokens map[string]*managedToken
+
+	storeMutex sync.Mutex
+}
+
+// managedToken is a provider's token; mutex is held for the duration of a
+// refresh so that callers waiting on it get the refreshed token
+type managedToken struct {
+	mutex      sync.Mutex
+	token      *OAuthConfig
+	refresh    RefreshFunc
+	timer      *time.Timer
+	generation atomic.Uint64
+}
+
+// NewTokenManager creates a token manager that saves refreshed tokens to
+// storage, which may be nil
+func NewTokenManager(storage TokenStorage) *TokenManager {
+	return &TokenManager{
+		storage: storage,
+		margin:  DefaultRefreshMargin,
+		tokens:  make(map[string]*managedToken),
+	}
+}
+
+var (
+	sharedTokens     *TokenManager
+	sharedTokensOnce sync.Once
+)
+
+// Tokens returns the process-wide token manager. Clients are created per
+// request, so tokens are registered here to outlive them; refreshed tokens
+// are saved to ~/.mcp-code-api/config.yaml or the credential store entry it
+// refers to, unless config.NoPersist.
+func Tokens() *TokenManager {
+	sharedTokensOnce.Do(func() {
+		var storage TokenStorage
+		if config.GetHomeDir() != "" && !config.NoPersist() {
+			storage = NewConfigTokenStorage(config.ConfigFilePath())
+		}
+		sharedTokens = NewTokenManager(storage)
+	})
+	return sharedTokens
+}
+
+// Register hands provider's token and the function that refreshes it to the
+// manager. If a token that expires later is already registered it is kept,
+// since callers registering on every request may hold a stale copy.
+func (m *TokenManager) Register(provider string, token *OAuthConfig, refresh RefreshFunc) {
+	if token == nil {
+		return
+	}
+	entry := m.entry(provider)
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+
+	entry.refresh = refresh
+	if entry.token == nil || token.ExpiresAt.After(entry.token.ExpiresAt) {
+		tokenCopy := *token
+		entry.token = &tokenCopy
+		entry.generation.Add(1)
+		m.schedule(provider, entry)
+	}
+}
+
+// Remove forgets provider's token and stops its scheduled refresh
+func (m *TokenManager) Remove(provider string) {
+	m.mutex.Lock()
+	entry, exists := m.tokens[provider]
+	delete(m.tokens, provider)
+	m.mutex.Unlock()
+
+	if exists {
+		entry.mutex.Lock()
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		entry.mutex.Unlock()
+	}
+}
+
+// Token returns a valid token for provider, refreshing it first if it
+// expires within the refresh margin. If that refresh fails but the token has
+// not yet expired, the current token is returned.
+func (m *TokenManager) Token(ctx context.Context, provider string) (*OAuthConfig, error) {
+	entry, err := m.lookup(provider)
+	if err != nil {
+		return nil, err
+	}
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+
+	if m.needsRefresh(entry.token) {
+		if err := m.refreshLocked(ctx, provider, entry); err != nil {
+			if isExpired(entry.token) {
+				return nil, err
+			}
+			logger.Warnf("%s: token refresh failed, using current token until it expires: %v", provider, err)
+		}
+	}
+	tokenCopy := *entry.token
+	return &tokenCopy, nil
+}
+
+// Refresh refreshes provider's token now, for example after the provider
+// rejected it. Callers arriving while a refresh is running wait for it and
+// get its result instead of refreshing again.
+func (m *TokenManager) Refresh(ctx context.Context, provider string) (*OAuthConfig, error) {
+	entry, err := m.lookup(provider)
+	if err != nil {
+		return nil, err
+	}
+	generation := entry.generation.Load()
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+
+	if entry.generation.Load() == generation {
+		if err := m.refreshLocked(ctx, provider, entry); err != nil {
+			return nil, err
+		}
+	}
+	tokenCopy := *entry.token
+	return &tokenCopy, nil
+}
+
+// Close stops all scheduled refreshes
+func (m *TokenManager) Close() {
+	m.closed.Store(true)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, entry := range m.tokens {
+		entry.mutex.Lock()
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		entry.mutex.Unlock()
+	}
+}
+
+func (m *TokenManager) entry(provider string) *managedToken {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, exists := m.tokens[provider]
+	if !exists {
+		entry = &managedToken{}
+		m.tokens[provider] = entry
+	}
+	return entry
+}
+
+func (m *TokenManager) lookup(provider string) (*managedToken, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, exists := m.tokens[provider]
+	if !exists {
+		return nil, &AuthError{
+			Provider: provider,
+			Code:     ErrCodeInvalidConfig,
+			Message:  "No OAuth token registered",
+		}
+	}
+	return entry, nil
+}
+
+func (m *TokenManager) needsRefresh(token *OAuthConfig) bool {
+	if token.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Now().After(token.ExpiresAt.Add(-m.margin))
+}
+
+func isExpired(token *OAuthConfig) bool {
+	return !token.ExpiresAt.IsZero() && time.Now().After(token.ExpiresAt)
+}
+
+// refreshLocked refreshes entry's token; the caller holds entry.mutex
+func (m *TokenManager) refreshLocked(ctx context.Context, provider string, entry *managedToken) error {
+	if entry.refresh == nil || entry.token.RefreshToken == "" {
+		return &AuthError{
+			Provider: provider,
+			Code:     ErrCodeRefreshFailed,
+			Message:  "No refresh token available",
+		}
+	}
+
+	current := *entry.token
+	fresh, err := entry.refresh(ctx, &current)
+	if err != nil {
+		if authErr, ok := err.(*AuthError); ok {
+			return authErr
+		}
+		return &AuthError{
+			Provider: provider,
+			Code:     ErrCodeRefreshFailed,
+			Message:  "Token refresh failed",
+			Details:  err.Error(),
+		}
+	}
+	// Servers may leave the refresh token out when it didn't change
+	if fresh.RefreshToken == "" {
+		fresh.RefreshToken = current.RefreshToken
+	}
+
+	entry.token = fresh
+	entry.generation.Add(1)
+	logger.Debugf("%s: OAuth token refreshed, new expiry: %s", provider, fresh.ExpiresAt.Format(time.RFC3339))
+
+	m.persist(provider, fresh)
+	m.schedule(provider, entry)
+	return nil
+}
+
+// persist saves a refreshed token. A failure is only logged: the token is
+// valid in memory, and the next refresh will try again.
+func (m *TokenManager) persist(provider string, token *OAuthConfig) {
+	if m.storage == nil {
+		return
+	}
+	tokenCopy := *token
+	m.storeMutex.Lock()
+	defer m.storeMutex.Unlock()
+	if err := m.storage.StoreToken(provider, &tokenCopy); err != nil {
+		logger.Warnf("%s: failed to save refreshed token: %v", provider, err)
+	}
+}
+
+// schedule arranges for entry's token to be refreshed before it expires;
+// the caller holds entry.mutex
+func (m *TokenManager) schedule(provider string, entry *managedToken) {
+	if entry.timer != nil {
+		entry.timer.Stop()
+		entry.timer = nil
+	}
+	token := entry.token
+	if m.closed.Load() || entry.refresh == nil || token.RefreshToken == "" || token.ExpiresAt.IsZero() {
+		return
+	}
+	delay := time.Until(token.ExpiresAt.Add(-m.margin))
+	if delay <= 0 {
+		// Already due; the next Token call refreshes it
+		return
+	}
+
+	generation := entry.generation.Load()
+	entry.timer = time.AfterFunc(delay, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		entry.mutex.Lock()
+		defer entry.mutex.Unlock()
+		if m.closed.Load() || entry.generation.Load() != generation {
+			return
+		}
+		if err := m.refreshLocked(ctx, provider, entry); err != nil {
+			logger.Warnf("%s: scheduled token refresh failed: %v", provider, err)
+		}
+	})
+}
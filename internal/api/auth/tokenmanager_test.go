@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingRefresh returns a RefreshFunc issuing "access-N" tokens valid for
+// an hour, and the number of refreshes made
+func countingRefresh(delay time.Duration) (RefreshFunc, *atomic.Int32) {
+	var calls atomic.Int32
+	return func(ctx context.Context, token *OAuthConfig) (*OAuthConfig, error) {
+		n := calls.Add(1)
+		time.Sleep(delay)
+		fresh := *token
+		fresh.AccessToken = "access-" + string(rune('0'+n))
+		fresh.ExpiresAt = time.Now().Add(time.Hour)
+		return &fresh, nil
+	}, &calls
+}
+
+func TestTokenManagerSharesRefresh(t *testing.T) {
+	storage := NewMemoryTokenStorage()
+	m := NewTokenManager(storage)
+	defer m.Close()
+
+	refresh, calls := countingRefresh(20 * time.Millisecond)
+	m.Register("gemini", &OAuthConfig{
+		AccessToken:  "expired",
+		RefreshToken: "refresh",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	}, refresh)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := m.Token(context.Background(), "gemini")
+			if err != nil || token.AccessToken != "access-1" {
+				t.Errorf("Token() = %+v, %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("refreshed %d times, want 1", n)
+	}
+	stored, err := storage.RetrieveToken("gemini")
+	if err != nil || stored.AccessToken != "access-1" || stored.RefreshToken != "refresh" {
+		t.Errorf("stored token = %+v, %v", stored, err)
+	}
+
+	// A forced refresh after the provider rejects the token runs once more
+	if token, err := m.Refresh(context.Background(), "gemini"); err != nil || token.AccessToken != "access-2" {
+		t.Errorf("Refresh() = %+v, %v", token, err)
+	}
+}
+
+func TestTokenManagerKeepsNewerToken(t *testing.T) {
+	m := NewTokenManager(nil)
+	defer m.Close()
+
+	refresh, calls := countingRefresh(0)
+	m.Register("gemini", &OAuthConfig{AccessToken: "stale", RefreshToken: "r", ExpiresAt: time.Now().Add(-time.Minute)}, refresh)
+	if _, err := m.Token(context.Background(), "gemini"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A client built from the config file registers the stale token again
+	m.Register("gemini", &OAuthConfig{AccessToken: "stale", RefreshToken: "r", ExpiresAt: time.Now().Add(-time.Minute)}, refresh)
+	token, err := m.Token(context.Background(), "gemini")
+	if err != nil || token.AccessToken != "access-1" {
+		t.Errorf("Token() = %+v, %v", token, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("refreshed %d times, want 1", n)
+	}
+}
+
+func TestTokenManagerSchedulesRefresh(t *testing.T) {
+	m := NewTokenManager(nil)
+	m.margin = time.Hour - 50*time.Millisecond
+	defer m.Close()
+
+	refresh, calls := countingRefresh(0)
+	m.Register("qwen", &OAuthConfig{AccessToken: "a", RefreshToken: "r", ExpiresAt: time.Now().Add(time.Hour)}, refresh)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if calls.Load() == 0 {
+		t.Fatal("token was not refreshed before expiry")
+	}
+}
+
+func TestTokenManagerUnregistered(t *testing.T) {
+	m := NewTokenManager(nil)
+	if _, err := m.Token(context.Background(), "anthropic"); err == nil {
+		t.Error("Token() succeeded for an unregistered provider")
+	}
+}
+
+func TestConfigTokenStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := "providers:\n  gemini:\n    model: gemini-2.0-flash-exp\n  anthropic:\n    model: claude\n"
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	storage := NewConfigTokenStorage(path)
+
+	expiry := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, provider := range []string{"gemini", "anthropic"} {
+		if err := storage.StoreToken(provider, &OAuthConfig{AccessToken: provider + "-access", RefreshToken: "r", ExpiresAt: expiry, TokenType: "Bearer"}); err != nil {
+			t.Fatal(err)
+		}
+		token, err := storage.RetrieveToken(provider)
+		if err != nil || token.AccessToken != provider+"-access" || !token.ExpiresAt.Equal(expiry) {
+			t.Errorf("RetrieveToken(%s) = %+v, %v", provider, token, err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"token_expiry:", "oauth:", "model: gemini-2.0-flash-exp"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("config file is missing %q:\n%s", want, data)
+		}
+	}
+	if providers, _ := storage.ListTokens(); len(providers) != 2 {
+		t.Errorf("ListTokens() = %v", providers)
+	}
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+const (
+	// azureCognitiveServicesScope is the Entra ID scope for Azure OpenAI
+	azureCognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+	// defaultAzureAuthorityHost is the Entra ID login endpoint of the public cloud
+	defaultAzureAuthorityHost = "https://login.microsoftonline.com"
+)
+
+// AzureOpenAIClient handles Azure OpenAI API interactions. Azure uses the OpenAI
+// chat completions wire format but a different URL scheme (deployment name and
+// api-version) and authentication headers (api-key or Entra ID bearer token).
+type AzureOpenAIClient struct {
+	config     config.AzureOpenAIConfig
+	client     *http.Client
+	keyManager *APIKeyManager
+	lastUsage  *types.Usage
+}
+
+// aadTokenCache caches Entra ID tokens per service principal across clients,
+// since the router creates a new client for every request
+var aadTokenCache = struct {
+	sync.Mutex
+	tokens map[string]aadToken
+}{tokens: make(map[string]aadToken)}
+
+type aadToken struct {
+	value   string
+	expires time.Time
+}
+
+// NewAzureOpenAIClient creates a new Azure OpenAI client
+func NewAzureOpenAIClient(cfg config.AzureOpenAIConfig) *AzureOpenAIClient {
+	return &AzureOpenAIClient{
+		config:     cfg,
+		keyManager: NewAPIKeyManager("AzureOpenAI", cfg.GetAllAPIKeys(), cfg.KeyBalancing),
+		client:     &http.Client{},
+	}
+}
+
+// GenerateCode generates code using Azure OpenAI with automatic key failover
+func (c *AzureOpenAIClient) GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
+	if c.config.Endpoint == "" || c.config.Deployment == "" {
+		return nil, fmt.Errorf("azure-openai: endpoint and deployment are required")
+	}
+	if c.keyManager == nil && !c.config.UsesAAD() {
+		return nil, fmt.Errorf("no Azure OpenAI API key or Entra ID credentials configured")
+	}
+
+	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
+
+	// Build the full prompt
+	fullPrompt := outboundPrompt(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+
+	requestData := c.prepareRequest(fullPrompt, prompts.System(ctx, "azure-openai", detectedLanguage))
+	requestData.ResponseFormat = responseFormat(ctx)
+	applyReasoning(&requestData, reasoningEffort(ctx, c.config.ReasoningEffort))
+
+	call := func(setAuth func(*http.Request)) (string, error) {
+		response, err := c.makeAPICall(ctx, requestData, setAuth)
+		if err != nil {
+			return "", err
+		}
+		c.lastUsage = &types.Usage{
+			PromptTokens:     response.Usage.PromptTokens,
+			CompletionTokens: response.Usage.CompletionTokens,
+			TotalTokens:      response.Usage.TotalTokens,
+		}
+		logger.Debugf("AzureOpenAI: Extracted token usage - Prompt: %d, Completion: %d, Total: %d",
+			c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
+		return utils.CleanCodeResponse(response.Choices[0].Message.Content), nil
+	}
+
+	var code string
+	var err error
+	if c.keyManager != nil {
+		code, err = c.keyManager.ExecuteWithFailover(func(apiKey string) (string, error) {
+			return call(func(req *http.Request) { req.Header.Set("api-key", apiKey) })
+		})
+	} else {
+		var token string
+		token, err = c.getAADToken(ctx)
+		if err == nil {
+			code, err = call(func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) })
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CodeGenerationResult{
+		Code:  code,
+		Usage: c.lastUsage,
+	}, nil
+}
+
+// GetModel returns the model name reported for metrics
+func (c *AzureOpenAIClient) GetModel() string {
+	if c.config.Model != "" {
+		return c.config.Model
+	}
+	return c.config.Deployment
+}
+
+// prepareRequest prepares the API request payload. Azure selects the model by the
+// deployment in the URL; the model field is informational only.
+func (c *AzureOpenAIClient) prepareRequest(fullPrompt, systemPrompt string) CerebrasRequest {
+	requestData := CerebrasRequest{
+		Model: c.GetModel(),
+		Messages: []CerebrasMessage{
+			{
+				Role:    "system",
+				Content: systemPrompt,
+			},
+			{
+				Role:    "user",
+				Content: fullPrompt,
+			},
+		},
+		Temperature: &c.config.Temperature,
+		Stream:      false,
+	}
+	if c.config.MaxTokens > 0 {
+		requestData.MaxTokens = c.config.MaxTokens
+	}
+	return requestData
+}
+
+// chatCompletionsURL builds the deployment-scoped chat completions URL
+func (c *AzureOpenAIClient) chatCompletionsURL() string {
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimSuffix(c.config.Endpoint, "/"),
+		url.PathEscape(c.config.Deployment),
+		url.QueryEscape(c.config.APIVersion))
+}
+
+// makeAPICall makes the HTTP request; setAuth adds the authentication header
+func (c *AzureOpenAIClient) makeAPICall(ctx context.Context, requestData CerebrasRequest, setAuth func(*http.Request)) (*CerebrasResponse, error) {
+	jsonBody, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := c.chatCompletionsURL()
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", strconv.Itoa(len(jsonBody)))
+	setAuth(req)
+
+	logger.Debugf("Making Azure OpenAI API call to %s", endpoint)
+
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse CerebrasErrorResponse
+		if parseErr := json.Unmarshal(body, &errorResponse); parseErr == nil && errorResponse.Error.Message != "" {
+			return nil, newAPIError("Azure OpenAI", resp, errorResponse.Error.Message)
+		}
+		return nil, newAPIError("Azure OpenAI", resp, string(body))
+	}
+
+	var response CerebrasResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in API response")
+	}
+	return &response, nil
+}
+
+// getAADToken returns a static token if configured, otherwise acquires (and
+// caches) one with the client credentials flow
+func (c *AzureOpenAIClient) getAADToken(ctx context.Context) (string, error) {
+	if c.config.ADToken != "" {
+		return c.config.ADToken, nil
+	}
+
+	authority := strings.TrimSuffix(c.config.AuthorityHost, "/")
+	if authority == "" {
+		authority = defaultAzureAuthorityHost
+	}
+	cacheKey := authority + "/" + c.config.TenantID + "/" + c.config.ClientID
+	aadTokenCache.Lock()
+	cached, ok := aadTokenCache.tokens[cacheKey]
+	aadTokenCache.Unlock()
+	if ok && time.Until(cached.expires) > time.Minute {
+		return cached.value, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.config.ClientID},
+		"client_secret": {c.config.ClientSecret},
+		"scope":         {azureCognitiveServicesScope},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", authority, url.PathEscape(c.config.TenantID))
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
+	if err != nil {
+		return "", fmt.Errorf("Entra ID token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Entra ID token error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	aadTokenCache.Lock()
+	aadTokenCache.tokens[cacheKey] = aadToken{
+		value:   tokenResponse.AccessToken,
+		expires: time.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second),
+	}
+	aadTokenCache.Unlock()
+
+	return tokenResponse.AccessToken, nil
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+const azureChatResponse = `{"choices":[{"message":{"role":"assistant","content":"print(1)"}}],` +
+	`"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`
+
+func TestAzureOpenAIClientUsesDeploymentAndKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o-prod/chat/completions" || r.URL.Query().Get("api-version") != "2024-10-21" {
+			t.Errorf("request URL = %s, want the deployment's chat completions with the api-version", r.URL)
+		}
+		if key := r.Header.Get("api-key"); key != "azure-key" {
+			t.Errorf("api-key = %q, want azure-key", key)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization = %q, want none with an API key", auth)
+		}
+		_, _ = w.Write([]byte(azureChatResponse))
+	}))
+	defer server.Close()
+
+	client := NewAzureOpenAIClient(config.AzureOpenAIConfig{
+		Endpoint:   server.URL + "/",
+		Deployment: "gpt-4o-prod",
+		APIVersion: "2024-10-21",
+		APIKey:     "azure-key",
+	})
+	result, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if result.Code != "print(1)" || result.Usage.TotalTokens != 12 {
+		t.Errorf("GenerateCode() = %+v with usage %+v, want print(1) with 12 tokens", result, result.Usage)
+	}
+	if client.GetModel() != "gpt-4o-prod" {
+		t.Errorf("GetModel() = %q, want the deployment when no model is set", client.GetModel())
+	}
+}
+
+func TestAzureOpenAIClientEntraIDToken(t *testing.T) {
+	tokenRequests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/contoso/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_id") != "app" ||
+			r.Form.Get("client_secret") != "secret" || r.Form.Get("scope") != azureCognitiveServicesScope {
+			t.Errorf("token request form = %v", r.Form)
+		}
+		_, _ = w.Write([]byte(`{"access_token":"entra-token","expires_in":3600}`))
+	})
+	mux.HandleFunc("/openai/deployments/gpt-4o/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer entra-token" {
+			t.Errorf("Authorization = %q, want the Entra ID token", auth)
+		}
+		if key := r.Header.Get("api-key"); key != "" {
+			t.Errorf("api-key = %q, want none with Entra ID", key)
+		}
+		_, _ = w.Write([]byte(azureChatResponse))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := config.AzureOpenAIConfig{
+		Endpoint:      server.URL,
+		Deployment:    "gpt-4o",
+		APIVersion:    "2024-10-21",
+		TenantID:      "contoso",
+		ClientID:      "app",
+		ClientSecret:  "secret",
+		AuthorityHost: server.URL,
+	}
+	// The router creates a client per request; the token outlives them
+	for range 2 {
+		if _, err := NewAzureOpenAIClient(cfg).GenerateCode(t.Context(), "print one", "", "main.py", nil, nil); err != nil {
+			t.Fatalf("GenerateCode() error = %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requests = %d, want 1 for two calls", tokenRequests)
+	}
+}
+
+func TestAzureOpenAIClientReportsEntraIDErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	defer server.Close()
+
+	client := NewAzureOpenAIClient(config.AzureOpenAIConfig{
+		Endpoint:      server.URL,
+		Deployment:    "gpt-4o",
+		TenantID:      "fabrikam",
+		ClientID:      "app",
+		ClientSecret:  "wrong",
+		AuthorityHost: server.URL,
+	})
+	if _, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil); err == nil {
+		t.Error("GenerateCode() succeeded with a rejected service principal")
+	}
+}
+
+func TestAzureOpenAIClientParsesErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer static" {
+			t.Errorf("Authorization = %q, want the configured ad_token", auth)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"code":"429","message":"Rate limit exceeded"}}`))
+	}))
+	defer server.Close()
+
+	client := NewAzureOpenAIClient(config.AzureOpenAIConfig{Endpoint: server.URL, Deployment: "gpt-4o", ADToken: "static"})
+	_, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Rate limit exceeded" {
+		t.Errorf("GenerateCode() error = %v, want the API error message", err)
+	}
+}
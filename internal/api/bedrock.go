@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// BedrockClient handles AWS Bedrock API interactions. It uses the Converse API,
+// which provides one request format across Claude, Llama, Titan and the other
+// Bedrock model families, and signs requests with SigV4.
+type BedrockClient struct {
+	config    config.BedrockConfig
+	client    *http.Client
+	lastUsage *types.Usage
+}
+
+// BedrockMessage represents a Converse API message
+type BedrockMessage struct {
+	Role    string                `json:"role"`
+	Content []BedrockContentBlock `json:"content"`
+}
+
+// BedrockContentBlock represents a text content block
+type BedrockContentBlock struct {
+	Text string `json:"text"`
+}
+
+// BedrockRequest represents a Converse API request
+type BedrockRequest struct {
+	Messages        []BedrockMessage        `json:"messages"`
+	System          []BedrockContentBlock   `json:"system,omitempty"`
+	InferenceConfig *BedrockInferenceConfig `json:"inferenceConfig,omitempty"`
+}
+
+// BedrockInferenceConfig holds generation parameters
+type BedrockInferenceConfig struct {
+	MaxTokens   int      `json:"maxTokens,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// BedrockResponse represents a Converse API response
+type BedrockResponse struct {
+	Output struct {
+		Message BedrockMessage `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      struct {
+		InputTokens  int `json:"inputTokens"`
+		OutputTokens int `json:"outputTokens"`
+		TotalTokens  int `json:"totalTokens"`
+	} `json:"usage"`
+}
+
+// NewBedrockClient creates a new AWS Bedrock client
+func NewBedrockClient(cfg config.BedrockConfig) *BedrockClient {
+	return &BedrockClient{
+		config: cfg,
+		client: &http.Client{},
+	}
+}
+
+// GenerateCode generates code using a Bedrock model
+func (c *BedrockClient) GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
+	if !c.config.HasCredentials() {
+		return nil, fmt.Errorf("bedrock: region and AWS credentials are required")
+	}
+	if c.config.Model == "" {
+		return nil, fmt.Errorf("bedrock: no model configured")
+	}
+
+	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
+
+	// Build the full prompt
+	fullPrompt := outboundPrompt(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+
+	requestData := c.prepareRequest(fullPrompt, prompts.System(ctx, "bedrock", detectedLanguage))
+
+	response, err := c.makeAPICall(ctx, requestData)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lastUsage = &types.Usage{
+		PromptTokens:     response.Usage.InputTokens,
+		CompletionTokens: response.Usage.OutputTokens,
+		TotalTokens:      response.Usage.TotalTokens,
+	}
+	logger.Debugf("Bedrock: Extracted token usage - Prompt: %d, Completion: %d, Total: %d",
+		c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
+
+	var text strings.Builder
+	for _, block := range response.Output.Message.Content {
+		text.WriteString(block.Text)
+	}
+
+	return &types.CodeGenerationResult{
+		Code:  utils.CleanCodeResponse(text.String()),
+		Usage: c.lastUsage,
+	}, nil
+}
+
+// GetModel returns the Bedrock model ID
+func (c *BedrockClient) GetModel() string {
+	return c.config.Model
+}
+
+// prepareRequest prepares the Converse request payload
+func (c *BedrockClient) prepareRequest(fullPrompt, systemPrompt string) BedrockRequest {
+	requestData := BedrockRequest{}
+	if bedrockSupportsSystemPrompt(c.config.Model) {
+		requestData.System = []BedrockContentBlock{{Text: systemPrompt}}
+	} else {
+		fullPrompt = systemPrompt + "\n\n" + fullPrompt
+	}
+	requestData.Messages = []BedrockMessage{
+		{
+			Role:    "user",
+			Content: []BedrockContentBlock{{Text: fullPrompt}},
+		},
+	}
+
+	if c.config.MaxTokens > 0 || c.config.Temperature != 0 {
+		requestData.InferenceConfig = &BedrockInferenceConfig{MaxTokens: c.config.MaxTokens}
+		if c.config.Temperature != 0 {
+			temperature := c.config.Temperature
+			requestData.InferenceConfig.Temperature = &temperature
+		}
+	}
+	return requestData
+}
+
+// bedrockSupportsSystemPrompt reports whether the model accepts Converse system
+// content; Titan text models reject it, so the instructions go in the user turn
+func bedrockSupportsSystemPrompt(modelID string) bool {
+	return !strings.Contains(modelID, "amazon.titan-text")
+}
+
+// endpoint returns the Bedrock runtime base URL for the configured region
+func (c *BedrockClient) endpoint() string {
+	if c.config.BaseURL != "" {
+		return strings.TrimSuffix(c.config.BaseURL, "/")
+	}
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", c.config.Region)
+}
+
+// makeAPICall sends the signed Converse request
+func (c *BedrockClient) makeAPICall(ctx context.Context, requestData BedrockRequest) (*BedrockResponse, error) {
+	jsonBody, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Model IDs contain ':' which must be percent-encoded in the path
+	endpoint := fmt.Sprintf("%s/model/%s/converse", c.endpoint(), awsURIEncode(c.config.Model))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	signSigV4(req, jsonBody, c.credentials(), c.config.Region, "bedrock", time.Now())
+
+	logger.Debugf("Making Bedrock API call to %s", endpoint)
+
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse struct {
+			Message string `json:"message"`
+		}
+		if parseErr := json.Unmarshal(body, &errorResponse); parseErr == nil && errorResponse.Message != "" {
+			return nil, newAPIError("Bedrock", resp, errorResponse.Message)
+		}
+		return nil, newAPIError("Bedrock", resp, string(body))
+	}
+
+	var response BedrockResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	if len(response.Output.Message.Content) == 0 {
+		return nil, fmt.Errorf("no content in API response (stop reason: %s)", response.StopReason)
+	}
+	return &response, nil
+}
+
+// credentials returns the configured AWS credentials
+func (c *BedrockClient) credentials() awsCredentials {
+	return awsCredentials{
+		AccessKeyID:     c.config.AccessKeyID,
+		SecretAccessKey: c.config.SecretAccessKey,
+		SessionToken:    c.config.SessionToken,
+	}
+}
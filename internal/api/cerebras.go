@@ -3,33 +3,38 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
 	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
 	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
 )
+// cerebrasRateLimitCooldown is how long a model that answered 429 is left
+// out when the response doesn't say when its quota resets
+const cerebrasRateLimitCooldown = time.Minute
 // CerebrasClient handles Cerebras API interactions
 type CerebrasClient struct {
-	config     config.CerebrasConfig
-	client     *http.Client
-	keyManager *APIKeyManager
-	lastUsage  *types.Usage
+	config        config.CerebrasConfig
+	client        *http.Client
+	keyManager    *APIKeyManager
+	modelSelector *ModelSelector
+	lastModel     string
+	lastUsage     *types.Usage
 }
 // NewCerebrasClient creates a new Cerebras client
 func NewCerebrasClient(cfg config.CerebrasConfig) *CerebrasClient {
 	return &CerebrasClient{
-		config:     cfg,
-		keyManager: NewAPIKeyManager("Cerebras", cfg.GetAllAPIKeys()),
-		client: &http.Client{
-			Timeout: 60 * time.Second, // Configurable timeout
-		},
+		config:        cfg,
+		keyManager:    NewAPIKeyManager("Cerebras", cfg.GetAllAPIKeys(), cfg.KeyBalancing),
+		modelSelector: newConfiguredModelSelector("Cerebras", cfg.Model, cfg.Models, cfg.ModelStrategy),
+		client: &http.Client{},
 	}
 }
 // GenerateCode generates code using the Cerebras API with automatic failover
@@ -40,13 +45,38 @@ func (c *CerebrasClient) GenerateCode(ctx context.Context, prompt, contextStr, o
 	// Determine language from file extension or explicit parameter
 	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
 	// Build the full prompt
-	fullPrompt := c.buildFullPrompt(prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+	fullPrompt := outboundPrompt(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
 	// Prepare the request
-	requestData := c.prepareRequest(fullPrompt, detectedLanguage)
+	requestData, err := c.prepareRequest(fullPrompt, prompts.System(ctx, "cerebras", detectedLanguage))
+	if errors.Is(err, errModelsRateLimited) {
+		wait := c.modelSelector.RateLimitWait()
+		return nil, &APIError{
+			Provider:   "Cerebras",
+			StatusCode: http.StatusTooManyRequests,
+			Message:    fmt.Sprintf("every configured model is rate limited, the first resets in %s", wait.Round(time.Second)),
+			RetryAfter: wait,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.lastModel = requestData.Model
+	// Stream when the caller wants to hear of the first token, e.g. a race
+	requestData.Stream = firstTokenFunc(ctx) != nil
 	// Use failover to try multiple API keys if needed
 	code, err := c.keyManager.ExecuteWithFailover(func(apiKey string) (string, error) {
-		// Make the API call with this specific key
+		// Make the API call with this specific key, moving on to the next
+		// model while the current one is rate limited
 		response, err := c.makeAPICallWithKey(ctx, requestData, apiKey)
+		for isRateLimited(err) {
+			next, ok := c.rotateModel(requestData.Model, err)
+			if !ok {
+				break
+			}
+			requestData.Model = next
+			c.lastModel = next
+			response, err = c.makeAPICallWithKey(ctx, requestData, apiKey)
+		}
 		if err != nil {
 			return "", err
 		}
@@ -63,6 +93,7 @@ func (c *CerebrasClient) GenerateCode(ctx context.Context, prompt, contextStr, o
 			c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
 		return cleanedContent, nil
 	})
+	c.modelSelector.Record(requestData.Model, err)
 	if err != nil {
 		return nil, err
 	}
@@ -77,73 +108,58 @@ func (c *CerebrasClient) GenerateCode(ctx context.Context, prompt, contextStr, o
 	}
 	return result, nil
 }
-// buildFullPrompt builds the complete prompt including context and existing content
-func (c *CerebrasClient) buildFullPrompt(prompt, contextStr, outputFile, detectedLanguage string, contextFiles []string) string {
-	var parts []string
-	// Add context files if provided
-	if len(contextFiles) > 0 {
-		// Filter out the output file from context files to avoid duplication
-		filteredContextFiles := c.filterContextFiles(contextFiles, outputFile)
-		if len(filteredContextFiles) > 0 {
-			contextContent := "Context Files:\n"
-			for _, contextFile := range filteredContextFiles {
-				if content, err := utils.ReadFileContent(contextFile); err == nil && content != "" {
-					contextLang := utils.GetLanguageFromFile(contextFile, nil)
-					contextContent += fmt.Sprintf("\nFile: %s\n```%s\n%s\n```\n", contextFile, contextLang, content)
-				} else {
-					logger.Warnf("Could not read context file %s: %v", contextFile, err)
-				}
-			}
-			parts = append(parts, contextContent)
-		}
-	}
-	// Add additional context if provided
-	if contextStr != "" {
-		parts = append(parts, fmt.Sprintf("Context: %s", contextStr))
+// rotateModel takes a model that answered 429 out of rotation until its
+// quota resets and returns the next model to try, if another is available.
+// Free tier quotas are per model, so another model usually still has room.
+func (c *CerebrasClient) rotateModel(model string, err error) (string, bool) {
+	cooldown := cerebrasRateLimitCooldown
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		cooldown = apiErr.RetryAfter
 	}
-	// Add existing file content if it exists
-	if existingContent, err := utils.ReadFileContent(outputFile); err == nil && existingContent != "" {
-		parts = append(parts, fmt.Sprintf("Existing file content:\n```%s\n%s\n```\n", detectedLanguage, existingContent))
+	c.modelSelector.RecordRateLimit(model, time.Now().Add(cooldown))
+
+	next, selectErr := c.modelSelector.SelectModel()
+	if selectErr != nil || next == model {
+		return "", false
 	}
-	// Add the main prompt
-	parts = append(parts, fmt.Sprintf("Generate %s code for: %s", detectedLanguage, prompt))
-	return strings.Join(parts, "\n\n")
+	logger.Warnf("Cerebras: %s is rate limited for %s, switching to %s", model, cooldown.Round(time.Second), next)
+	return next, true
 }
-// filterContextFiles filters out the output file from context files
-func (c *CerebrasClient) filterContextFiles(contextFiles []string, outputFile string) []string {
-	var filtered []string
-	for _, file := range contextFiles {
-		// Resolve paths for comparison
-		contextAbs := filepath.Clean(file)
-		outputAbs := filepath.Clean(outputFile)
-		if contextAbs != outputAbs {
-			filtered = append(filtered, file)
-		}
+
+// GetModel returns the model used by the last request
+func (c *CerebrasClient) GetModel() string {
+	if c.lastModel != "" {
+		return c.lastModel
 	}
-	return filtered
+	return c.config.Model
 }
 // prepareRequest prepares the API request payload
-func (c *CerebrasClient) prepareRequest(fullPrompt, detectedLanguage string) CerebrasRequest {
+func (c *CerebrasClient) prepareRequest(fullPrompt, systemPrompt string) (CerebrasRequest, error) {
+	model, err := c.modelSelector.SelectModel()
+	if err != nil {
+		return CerebrasRequest{}, fmt.Errorf("failed to select model: %w", err)
+	}
 	requestData := CerebrasRequest{
-		Model: c.config.Model,
+		Model: model,
 		Messages: []CerebrasMessage{
 			{
 				Role:    "system",
-				Content: fmt.Sprintf("You are an expert programmer. Generate ONLY clean, functional code in %s with no explanations, comments about the code generation process, or markdown formatting. Include necessary imports and ensure the code is ready to run. When modifying existing files, preserve the structure and style while implementing the requested changes. Output raw code only. Never use markdown code blocks.", detectedLanguage),
+				Content: systemPrompt,
 			},
 			{
 				Role:    "user",
 				Content: fullPrompt,
 			},
 		},
-		Temperature: c.config.Temperature,
+		Temperature: &c.config.Temperature,
 		Stream:      false,
 	}
 	// Add max_tokens if explicitly set
 	if c.config.MaxTokens > 0 {
 		requestData.MaxTokens = c.config.MaxTokens
 	}
-	return requestData
+	return requestData, nil
 }
 // makeAPICallWithKey makes the actual HTTP request to the Cerebras API with a specific API key
 func (c *CerebrasClient) makeAPICallWithKey(ctx context.Context, requestData CerebrasRequest, apiKey string) (*CerebrasResponse, error) {
@@ -164,11 +180,22 @@ func (c *CerebrasClient) makeAPICallWithKey(ctx context.Context, requestData Cer
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	logger.Debugf("Making Cerebras API call to %s", url)
 	// Make the request
-	resp, err := c.client.Do(req)
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	if requestData.Stream && isEventStream(resp) {
+		content, usage, err := readChatStream(resp.Body, firstTokenFunc(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &CerebrasResponse{
+			Model:   requestData.Model,
+			Choices: []CerebrasChoice{{Message: CerebrasMessage{Role: "assistant", Content: content}}},
+			Usage:   CerebrasUsage{PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens, TotalTokens: usage.TotalTokens},
+		}, nil
+	}
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -176,11 +203,16 @@ func (c *CerebrasClient) makeAPICallWithKey(ctx context.Context, requestData Cer
 	}
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
+		message := string(body)
 		var errorResponse CerebrasErrorResponse
 		if parseErr := json.Unmarshal(body, &errorResponse); parseErr == nil {
-			return nil, fmt.Errorf("Cerebras API error: %d - %s", resp.StatusCode, errorResponse.Error.Message)
+			message = errorResponse.Error.Message
+		}
+		apiErr := newAPIError("Cerebras", resp, message)
+		if resp.StatusCode == http.StatusTooManyRequests && apiErr.RetryAfter == 0 {
+			apiErr.RetryAfter = cerebrasRateLimitReset(resp.Header)
 		}
-		return nil, fmt.Errorf("Cerebras API error: %d - %s", resp.StatusCode, string(body))
+		return nil, apiErr
 	}
 	// Parse successful response
 	var response CerebrasResponse
@@ -192,13 +224,35 @@ func (c *CerebrasClient) makeAPICallWithKey(ctx context.Context, requestData Cer
 	}
 	return &response, nil
 }
+// cerebrasRateLimitReset returns how long until the quota a 429 ran into
+// resets, from Cerebras' x-ratelimit-* headers: the daily request quota when
+// it is used up, else the per-minute token quota. 0 if the headers are absent.
+func cerebrasRateLimitReset(h http.Header) time.Duration {
+	reset := h.Get("x-ratelimit-reset-tokens-minute")
+	if strings.TrimSpace(h.Get("x-ratelimit-remaining-requests-day")) == "0" {
+		reset = h.Get("x-ratelimit-reset-requests-day")
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(reset), 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
 // CerebrasRequest represents the request payload for Cerebras API
 type CerebrasRequest struct {
-	Model       string            `json:"model"`
-	Messages    []CerebrasMessage `json:"messages"`
-	Temperature float64           `json:"temperature"`
-	MaxTokens   int               `json:"max_tokens,omitempty"`
-	Stream      bool              `json:"stream"`
+	Model         string            `json:"model"`
+	Messages      []CerebrasMessage `json:"messages"`
+	Temperature   *float64          `json:"temperature,omitempty"` // Left out for reasoning models, which reject it
+	MaxTokens     int               `json:"max_tokens,omitempty"`
+	Stream        bool              `json:"stream"`
+	StreamOptions *StreamOptions    `json:"stream_options,omitempty"` // Custom providers only report streamed usage when asked
+
+	// Set from WithResponseFormat for OpenAI-compatible endpoints
+	ResponseFormat *types.ResponseFormat `json:"response_format,omitempty"`
+
+	// Reasoning models take max_completion_tokens instead of max_tokens; see applyReasoning
+	MaxCompletionTokens int    `json:"max_completion_tokens,omitempty"`
+	ReasoningEffort     string `json:"reasoning_effort,omitempty"`
 }
 // CerebrasMessage represents a message in the conversation
 type CerebrasMessage struct {
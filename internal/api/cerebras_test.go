@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestCerebrasRotatesRateLimitedModels(t *testing.T) {
+	limited, free := t.Name()+"-limited", t.Name()+"-free"
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request CerebrasRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, request.Model)
+		if request.Model == limited {
+			w.Header().Set("x-ratelimit-remaining-requests-day", "0")
+			w.Header().Set("x-ratelimit-reset-requests-day", "3600.5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"Requests per day limit exceeded"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"x = 1"}}],"usage":{"total_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	cfg := config.CerebrasConfig{APIKey: t.Name(), BaseURL: server.URL, Models: []string{limited, free}}
+	for i := 0; i < 2; i++ {
+		client := NewCerebrasClient(cfg)
+		if _, err := client.GenerateCode(t.Context(), "set x", "", "main.py", nil, nil); err != nil {
+			t.Fatalf("GenerateCode() error = %v", err)
+		}
+		if client.GetModel() != free {
+			t.Errorf("GetModel() = %q, want %q", client.GetModel(), free)
+		}
+	}
+	// The limited model is skipped until its daily quota resets
+	if want := []string{limited, free, free}; !slices.Equal(got, want) {
+		t.Errorf("models requested = %v, want %v", got, want)
+	}
+}
+
+func TestCerebrasAllModelsRateLimited(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("x-ratelimit-reset-tokens-minute", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	cfg := config.CerebrasConfig{APIKey: t.Name(), BaseURL: server.URL, Models: []string{t.Name() + "-a", t.Name() + "-b"}}
+	if _, err := NewCerebrasClient(cfg).GenerateCode(t.Context(), "set x", "", "main.py", nil, nil); !isRateLimited(err) {
+		t.Fatalf("GenerateCode() error = %v, want a 429", err)
+	}
+	_, err := NewCerebrasClient(cfg).GenerateCode(t.Context(), "set x", "", "main.py", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter <= 0 || apiErr.RetryAfter > 30*time.Second {
+		t.Errorf("GenerateCode() error = %v, want a 429 with the time until the first model resets", err)
+	}
+	if calls != 2 {
+		t.Errorf("API calls = %d, want 2 (none while every model is rate limited)", calls)
+	}
+}
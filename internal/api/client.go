@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// CodeClient is a provider client the router can generate code with. Every
+// client is built per request, so per-request provider overrides apply.
+type CodeClient interface {
+	GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error)
+	// GetModel returns the model that served the last request, or the
+	// configured one before the first
+	GetModel() string
+}
+
+// NewClient builds the client for providerName from p, or says what is
+// missing from its config. Racing providers are not clients: they keep
+// statistics across requests and are built by the router.
+func NewClient(providerName string, p config.ProvidersConfig) (CodeClient, error) {
+	switch providerName {
+	case "anthropic":
+		if p.Anthropic != nil && p.Anthropic.APIKey != "" {
+			return NewAnthropicClient(*p.Anthropic), nil
+		}
+		return nil, fmt.Errorf("anthropic: no config or API key")
+	case "cerebras":
+		if p.Cerebras != nil && (p.Cerebras.APIKey != "" || len(p.Cerebras.APIKeys) > 0) {
+			return NewCerebrasClient(*p.Cerebras), nil
+		}
+		return nil, fmt.Errorf("cerebras: no config or API key")
+	case "openrouter":
+		if p.OpenRouter != nil && p.OpenRouter.APIKey != "" {
+			return NewOpenRouterClient(*p.OpenRouter), nil
+		}
+		return nil, fmt.Errorf("openrouter: no config or API key")
+	case "openai":
+		if p.OpenAI != nil && len(p.OpenAI.GetAllAPIKeys()) > 0 {
+			return NewOpenAIClient(*p.OpenAI), nil
+		}
+		return nil, fmt.Errorf("openai: no config or API key")
+	case "gemini":
+		if p.Gemini != nil && (p.Gemini.APIKey != "" || p.Gemini.AccessToken != "") {
+			return NewGeminiClient(*p.Gemini), nil
+		}
+		return nil, fmt.Errorf("gemini: no config or API key/OAuth")
+	case "azure-openai":
+		if p.AzureOpenAI != nil && p.AzureOpenAI.HasCredentials() {
+			return NewAzureOpenAIClient(*p.AzureOpenAI), nil
+		}
+		return nil, fmt.Errorf("azure-openai: no endpoint/deployment or credentials")
+	case "bedrock":
+		if p.Bedrock != nil && p.Bedrock.HasCredentials() {
+			return NewBedrockClient(*p.Bedrock), nil
+		}
+		return nil, fmt.Errorf("bedrock: no region or AWS credentials")
+	case "qwen":
+		if p.Qwen != nil && len(p.Qwen.GetAllAPIKeys()) > 0 {
+			return NewQwenClient(*p.Qwen), nil
+		}
+		return nil, fmt.Errorf("qwen: no config or API key")
+	case "synthetic":
+		if p.Synthetic != nil && len(p.Synthetic.GetAllAPIKeys()) > 0 {
+			return NewSyntheticClient(*p.Synthetic), nil
+		}
+		return nil, fmt.Errorf("synthetic: no config or Hugging Face token")
+	case "mistral":
+		if p.Mistral != nil && len(p.Mistral.GetAllAPIKeys()) > 0 {
+			return NewMistralClient(*p.Mistral), nil
+		}
+		return nil, fmt.Errorf("mistral: no config or API key")
+	case "vertex":
+		if p.Vertex != nil && p.Vertex.HasCredentials() {
+			return NewVertexClient(*p.Vertex), nil
+		}
+		return nil, fmt.Errorf("vertex: no project, region or credentials")
+	case "lmstudio", "llamacpp":
+		local := p.LMStudio
+		if providerName == "llamacpp" {
+			local = p.LlamaCpp
+		}
+		if local != nil && local.BaseURL != "" {
+			return NewLocalClient(providerName, *local), nil
+		}
+		return nil, fmt.Errorf("%s: no base_url configured", providerName)
+	case "mock":
+		return NewMockClient(mockConfig(p)), nil
+	}
+	if custom, ok := p.Custom[providerName]; ok {
+		return NewCustomClient(providerName, custom), nil
+	}
+	return nil, fmt.Errorf("unknown provider: %s", providerName)
+}
+
+// withModel returns a copy of p in which providerName uses model, e.g. for
+// one racer of a race. A provider it can't set the model of is an error, so
+// a racer never silently runs another model than the one it is credited
+// with.
+func withModel(p config.ProvidersConfig, providerName, model string) (config.ProvidersConfig, error) {
+	switch providerName {
+	case "anthropic":
+		if p.Anthropic != nil {
+			cfg := *p.Anthropic
+			cfg.Model = model
+			cfg.Models = nil
+			p.Anthropic = &cfg
+		}
+	case "cerebras":
+		if p.Cerebras != nil {
+			cfg := *p.Cerebras
+			cfg.Model = model
+			cfg.Models = nil
+			p.Cerebras = &cfg
+		}
+	case "openrouter":
+		if p.OpenRouter != nil {
+			cfg := *p.OpenRouter
+			cfg.Model = model
+			cfg.Models = nil
+			p.OpenRouter = &cfg
+		}
+	case "openai":
+		if p.OpenAI != nil {
+			cfg := *p.OpenAI
+			cfg.Model = model
+			cfg.Models = nil
+			p.OpenAI = &cfg
+		}
+	case "gemini":
+		if p.Gemini != nil {
+			cfg := *p.Gemini
+			cfg.Model = model
+			cfg.Models = nil
+			p.Gemini = &cfg
+		}
+	case "azure-openai":
+		// Azure serves a model through a deployment, which the racer names
+		if p.AzureOpenAI != nil {
+			cfg := *p.AzureOpenAI
+			cfg.Deployment = model
+			cfg.Model = model
+			p.AzureOpenAI = &cfg
+		}
+	case "bedrock":
+		if p.Bedrock != nil {
+			cfg := *p.Bedrock
+			cfg.Model = model
+			p.Bedrock = &cfg
+		}
+	case "qwen":
+		if p.Qwen != nil {
+			cfg := *p.Qwen
+			cfg.Model = model
+			cfg.Models = nil
+			p.Qwen = &cfg
+		}
+	case "synthetic":
+		if p.Synthetic != nil {
+			cfg := *p.Synthetic
+			cfg.Model = model
+			cfg.Models = nil
+			p.Synthetic = &cfg
+		}
+	case "mistral":
+		if p.Mistral != nil {
+			cfg := *p.Mistral
+			cfg.Model = model
+			p.Mistral = &cfg
+		}
+	case "vertex":
+		if p.Vertex != nil {
+			cfg := *p.Vertex
+			cfg.Model = model
+			p.Vertex = &cfg
+		}
+	case "lmstudio":
+		if p.LMStudio != nil {
+			cfg := *p.LMStudio
+			cfg.Model = model
+			p.LMStudio = &cfg
+		}
+	case "llamacpp":
+		if p.LlamaCpp != nil {
+			cfg := *p.LlamaCpp
+			cfg.Model = model
+			p.LlamaCpp = &cfg
+		}
+	case "mock":
+		cfg := mockConfig(p)
+		cfg.Model = model
+		p.Mock = &cfg
+	default:
+		custom, ok := p.Custom[providerName]
+		if !ok {
+			return p, fmt.Errorf("%s can't run model %s: unknown provider", providerName, model)
+		}
+		custom.Model = model
+		p.Custom = map[string]config.CustomProviderConfig{providerName: custom}
+	}
+	return p, nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestNewClient(t *testing.T) {
+	p := config.ProvidersConfig{
+		Cerebras: &config.CerebrasConfig{APIKeys: []string{"key"}, Model: "zai-glm-4.6"},
+		Qwen:     &config.QwenConfig{APIKeys: []string{"key"}, Model: "qwen-max"},
+		OpenAI:   &config.OpenAIConfig{APIKey: "key", Models: []string{"gpt-4o", "gpt-4o-mini"}},
+		Custom:   map[string]config.CustomProviderConfig{"together": {BaseURL: "http://localhost", Model: "qwen"}},
+	}
+	for name, want := range map[string]string{"cerebras": "zai-glm-4.6", "qwen": "qwen-max", "openai": "gpt-4o", "together": "qwen", "mock": ""} {
+		client, err := NewClient(name, p)
+		if err != nil {
+			t.Fatalf("NewClient(%s): %v", name, err)
+		}
+		if want != "" && client.GetModel() != want {
+			t.Errorf("NewClient(%s) model = %q, want %q", name, client.GetModel(), want)
+		}
+	}
+	for name, want := range map[string]string{"anthropic": "no config or API key", "nope": "unknown provider"} {
+		if _, err := NewClient(name, p); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("NewClient(%s) error = %v, want %q", name, err, want)
+		}
+	}
+}
+
+func TestWithModelLeavesConfigUnchanged(t *testing.T) {
+	p := config.ProvidersConfig{
+		OpenRouter: &config.OpenRouterConfig{APIKey: "key", Model: "a", Models: []string{"a", "b"}},
+		Custom:     map[string]config.CustomProviderConfig{"together": {BaseURL: "http://localhost", Model: "qwen"}},
+	}
+	racer, err := withModel(p, "openrouter", "c")
+	if err != nil || racer.OpenRouter.Model != "c" || racer.OpenRouter.Models != nil {
+		t.Errorf("racer config = %+v (%v), want only model c", racer.OpenRouter, err)
+	}
+	withModel(p, "together", "llama")
+	if p.OpenRouter.Model != "a" || len(p.OpenRouter.Models) != 2 || p.Custom["together"].Model != "qwen" {
+		t.Errorf("withModel changed the shared config: %+v %+v", p.OpenRouter, p.Custom)
+	}
+}
+
+func TestWithModelRacesEveryProvider(t *testing.T) {
+	p := config.ProvidersConfig{
+		AzureOpenAI: &config.AzureOpenAIConfig{Endpoint: "https://example.openai.azure.com", Deployment: "gpt-4o", APIKey: "key"},
+		LMStudio:    &config.LocalProviderConfig{BaseURL: "http://localhost:1234/v1", Model: "loaded"},
+		LlamaCpp:    &config.LocalProviderConfig{BaseURL: "http://localhost:8080/v1"},
+	}
+	for _, name := range []string{"azure-openai", "lmstudio", "llamacpp", "mock"} {
+		racer, err := withModel(p, name, "qwen2.5-coder")
+		if err != nil {
+			t.Fatalf("withModel(%s): %v", name, err)
+		}
+		client, err := NewClient(name, racer)
+		if err != nil {
+			t.Fatalf("NewClient(%s): %v", name, err)
+		}
+		if got := client.GetModel(); got != "qwen2.5-coder" {
+			t.Errorf("%s racer model = %q, want qwen2.5-coder", name, got)
+		}
+	}
+	if p.LMStudio.Model != "loaded" || p.AzureOpenAI.Deployment != "gpt-4o" {
+		t.Errorf("withModel changed the shared config: %+v %+v", p.LMStudio, p.AzureOpenAI)
+	}
+	if _, err := withModel(p, "nope", "x"); err == nil {
+		t.Error("withModel(nope) succeeded, want an unknown provider error")
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/guard"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/symbols"
+	"github.com/cecil-the-coder/mcp-code-api/internal/transform"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// SentContextFile is a context file as a provider received it: cut down to
+// what the prompt needs, wrapped by the guard and redacted
+type SentContextFile struct {
+	Path string
+	Text string
+}
+
+// contextSentKey is the context key for the callback set by WithContextSent
+type contextSentKey struct{}
+
+// WithContextSent returns a context asking for the context files of each
+// prompt built to be passed to fn as they are sent. fn is called once per
+// provider attempt, concurrently if attempts are hedged.
+func WithContextSent(ctx context.Context, fn func(files []SentContextFile)) context.Context {
+	return context.WithValue(ctx, contextSentKey{}, fn)
+}
+
+// contextFilesSection returns the context files other than outputFile as the
+// context part of a prompt, or "" if none could be read. With a guard
+// attached to ctx each file goes in a delimited block marked as data; with
+// a symbol extractor, Go files are cut down to what prompt needs.
+func contextFilesSection(ctx context.Context, prompt string, contextFiles []string, outputFile string) string {
+	var files []symbols.File
+	for _, contextFile := range contextFiles {
+		if filepath.Clean(contextFile) == filepath.Clean(outputFile) {
+			continue
+		}
+		raw, err := utils.ReadFileContentCached(contextFile)
+		if err != nil || raw == "" {
+			logger.Warnf("Could not read context file %s: %v", contextFile, err)
+			continue
+		}
+		content, _ := utils.DecodeText(raw)
+		files = append(files, symbols.File{Path: contextFile, Content: content})
+	}
+	reduced := symbols.FromContext(ctx).Reduce(prompt, outputFile, files)
+
+	g := guard.FromContext(ctx)
+	onSent, _ := ctx.Value(contextSentKey{}).(func([]SentContextFile))
+	var sent []SentContextFile
+	var contextContent string
+	for _, file := range files {
+		content := file.Content
+		if r, ok := reduced[file.Path]; ok {
+			content = r
+		}
+		contextLang := utils.GetLanguageFromFile(file.Path, nil)
+		var block string
+		if g != nil {
+			block = g.Wrap(file.Path, contextLang, content)
+		} else {
+			block = fmt.Sprintf("\nFile: %s\n```%s\n%s\n```\n", file.Path, contextLang, content)
+		}
+		contextContent += block
+		if onSent != nil {
+			// The session maps values to the same placeholders as in the prompt
+			sent = append(sent, SentContextFile{Path: file.Path, Text: transform.Outbound(ctx, block)})
+		}
+	}
+	if onSent != nil {
+		onSent(sent)
+	}
+	if contextContent == "" {
+		return ""
+	}
+	if g != nil {
+		return guard.Header + contextContent
+	}
+	return "Context Files:\n" + contextContent
+}
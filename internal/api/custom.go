@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// CustomClient handles user-defined OpenAI-compatible providers from
+// providers.custom. Keys are optional, for self-hosted endpoints. Built-in
+// OpenAI-compatible providers (Qwen, Synthetic) are presets of it.
+type CustomClient struct {
+	name       string
+	config     config.CustomProviderConfig
+	client     *http.Client
+	keyManager *APIKeyManager
+	lastUsage  *types.Usage
+
+	// Set by presets: modelSelector picks the model among several instead
+	// of config.Model, and errorMessage reads errors that aren't
+	// OpenAI-style
+	modelSelector *ModelSelector
+	errorMessage  func(body []byte) string
+	lastModel     string
+}
+
+// NewCustomClient creates a client for the custom provider name
+func NewCustomClient(name string, cfg config.CustomProviderConfig) *CustomClient {
+	return &CustomClient{
+		name:       name,
+		config:     cfg,
+		keyManager: NewAPIKeyManager(name, cfg.GetAllAPIKeys(), cfg.KeyBalancing),
+		client:     &http.Client{},
+	}
+}
+
+// presetModel is the model a preset reports before its first request: the
+// configured one, or the first of its models
+func presetModel(model string, models []string) string {
+	if model == "" && len(models) > 0 {
+		return models[0]
+	}
+	return model
+}
+
+// GenerateCode generates code using the custom provider, with key failover when several keys are configured
+func (c *CustomClient) GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
+	if err := c.config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
+
+	// Build the full prompt
+	fullPrompt := outboundPrompt(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+
+	requestData, err := c.prepareRequest(fullPrompt, prompts.System(ctx, c.name, detectedLanguage))
+	if err != nil {
+		return nil, err
+	}
+	c.lastModel = requestData.Model
+	// Stream when the caller wants to hear of the first token; usage then
+	// only arrives if asked for
+	if firstTokenFunc(ctx) != nil {
+		requestData.Stream = true
+		requestData.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+	requestData.ResponseFormat = responseFormat(ctx)
+	applyReasoning(&requestData, reasoningEffort(ctx, c.config.ReasoningEffort))
+
+	call := func(apiKey string) (string, error) {
+		response, err := c.makeAPICall(ctx, requestData, apiKey)
+		if err != nil {
+			return "", err
+		}
+		c.lastUsage = &types.Usage{
+			PromptTokens:     response.Usage.PromptTokens,
+			CompletionTokens: response.Usage.CompletionTokens,
+			TotalTokens:      response.Usage.TotalTokens,
+		}
+		logger.Debugf("%s: Extracted token usage - Prompt: %d, Completion: %d, Total: %d",
+			c.name, c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
+		return utils.CleanCodeResponse(response.Choices[0].Message.Content), nil
+	}
+
+	var code string
+	if c.keyManager != nil {
+		code, err = c.keyManager.ExecuteWithFailover(call)
+	} else {
+		code, err = call("")
+	}
+	if c.modelSelector != nil {
+		c.modelSelector.Record(requestData.Model, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CodeGenerationResult{
+		Code:  code,
+		Usage: c.lastUsage,
+	}, nil
+}
+
+// GetModel returns the model used by the last request, or the configured one
+func (c *CustomClient) GetModel() string {
+	if c.lastModel != "" {
+		return c.lastModel
+	}
+	return c.config.Model
+}
+
+// prepareRequest prepares the chat completions payload
+func (c *CustomClient) prepareRequest(fullPrompt, systemPrompt string) (CerebrasRequest, error) {
+	model := c.config.Model
+	if c.modelSelector != nil {
+		var err error
+		if model, err = c.modelSelector.SelectModel(); err != nil {
+			return CerebrasRequest{}, fmt.Errorf("failed to select model: %w", err)
+		}
+	}
+	requestData := CerebrasRequest{
+		Model: model,
+		Messages: []CerebrasMessage{
+			{
+				Role:    "system",
+				Content: systemPrompt,
+			},
+			{
+				Role:    "user",
+				Content: fullPrompt,
+			},
+		},
+		Temperature: &c.config.Temperature,
+		Stream:      false,
+	}
+	if c.config.MaxTokens > 0 {
+		requestData.MaxTokens = c.config.MaxTokens
+	}
+	return requestData, nil
+}
+
+// makeAPICall makes the chat completions request, adding the configured extra headers
+func (c *CustomClient) makeAPICall(ctx context.Context, requestData CerebrasRequest, apiKey string) (*CerebrasResponse, error) {
+	jsonBody, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(c.config.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range c.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", strconv.Itoa(len(jsonBody)))
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	logger.Debugf("Making %s API call to %s", c.name, endpoint)
+
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if requestData.Stream && isEventStream(resp) {
+		content, usage, err := readChatStream(resp.Body, firstTokenFunc(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &CerebrasResponse{
+			Model:   requestData.Model,
+			Choices: []CerebrasChoice{{Message: CerebrasMessage{Role: "assistant", Content: content}}},
+			Usage:   CerebrasUsage{PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens, TotalTokens: usage.TotalTokens},
+		}, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		errorMessage := openAIErrorMessage
+		if c.errorMessage != nil {
+			errorMessage = c.errorMessage
+		}
+		if message := errorMessage(body); message != "" {
+			return nil, newAPIError(c.name, resp, message)
+		}
+		return nil, newAPIError(c.name, resp, string(body))
+	}
+
+	var response CerebrasResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in API response")
+	}
+	return &response, nil
+}
+
+// openAIErrorMessage returns the message of an OpenAI-style
+// {"error": {"message": ...}} body, or "" if it isn't one
+func openAIErrorMessage(body []byte) string {
+	var errorResponse CerebrasErrorResponse
+	if json.Unmarshal(body, &errorResponse) != nil {
+		return ""
+	}
+	return errorResponse.Error.Message
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestCustomClientUsesKeyEnvAndHeaders(t *testing.T) {
+	t.Setenv("TEST_CUSTOM_KEY", "secret")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want Bearer secret", got)
+		}
+		if got := r.Header.Get("X-Title"); got != "mcp" {
+			t.Errorf("X-Title = %q, want mcp", got)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"print(1)"}}],"usage":{"total_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	client := NewCustomClient("together", config.CustomProviderConfig{
+		BaseURL:   srv.URL + "/v1",
+		APIKeyEnv: "TEST_CUSTOM_KEY",
+		Model:     "qwen",
+		// viper lowercases map keys; header names are case-insensitive
+		Headers: map[string]string{"x-title": "mcp"},
+	})
+	result, err := client.GenerateCode(t.Context(), "print one", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if result.Code != "print(1)" || result.Usage.TotalTokens != 3 {
+		t.Errorf("GenerateCode() = %+v, want print(1) with 3 tokens", result)
+	}
+}
+
+func TestCustomClientRejectsUnsetKeyEnv(t *testing.T) {
+	client := NewCustomClient("together", config.CustomProviderConfig{
+		BaseURL:   "http://127.0.0.1:0/v1",
+		APIKeyEnv: "TEST_CUSTOM_KEY_UNSET",
+		Model:     "qwen",
+	})
+	if _, err := client.GenerateCode(t.Context(), "print one", "", "", nil, nil); err == nil {
+		t.Error("GenerateCode() error = nil, want unset environment variable error")
+	}
+}
+
+func TestCustomClientSendsResponseFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResponseFormat *types.ResponseFormat `json:"response_format"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		format := body.ResponseFormat
+		if format == nil || format.Type != "json_schema" || format.JSONSchema == nil ||
+			format.JSONSchema.Name != "plan" || !format.JSONSchema.Strict || format.JSONSchema.Schema["type"] != "object" {
+			t.Errorf("response_format = %+v, want the plan schema", format)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"steps\":[]}"}}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewCustomClient("together", config.CustomProviderConfig{BaseURL: srv.URL, Model: "qwen"})
+	ctx := WithGenerateOptions(t.Context(), types.GenerateOptions{
+		ResponseFormat: &types.ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &types.JSONSchema{
+				Name:   "plan",
+				Schema: map[string]interface{}{"type": "object"},
+				Strict: true,
+			},
+		},
+	})
+	result, err := client.GenerateCode(ctx, "plan it", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if result.Code != `{"steps":[]}` {
+		t.Errorf("GenerateCode() = %q, want the JSON plan", result.Code)
+	}
+}
@@ -7,10 +7,9 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
-	"time"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/auth"
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
 	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
@@ -27,20 +26,33 @@ const (
 type GeminiClient struct {
 	config             config.GeminiConfig
 	client             *http.Client
+	modelSelector      *ModelSelector
+	lastModel          string
 	oauth2Config       *oauth2.Config
 	oauth2Token        *oauth2.Token
 	tokenMutex         sync.RWMutex
 }
 func NewGeminiClient(cfg config.GeminiConfig) *GeminiClient {
+	model := cfg.Model
+	if model == "" && len(cfg.Models) == 0 {
+		model = geminiDefaultModel
+	}
 	client := &GeminiClient{
-		config: cfg,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:        cfg,
+		client:        &http.Client{},
+		modelSelector: newConfiguredModelSelector("Gemini", model, cfg.Models, cfg.ModelStrategy),
 	}
 	if cfg.ClientID != "" && cfg.RefreshToken != "" {
 		client.oauth2Config = client.createOAuth2Config()
 		client.oauth2Token = client.createOAuth2Token()
+		// The router creates a client per request; the shared token manager
+		// keeps the refreshed token between them
+		auth.Tokens().Register("gemini", &auth.OAuthConfig{
+			AccessToken:  cfg.AccessToken,
+			RefreshToken: cfg.RefreshToken,
+			ExpiresAt:    cfg.TokenExpiry,
+			TokenType:    "Bearer",
+		}, client.refreshOAuthToken)
 		logger.Debugf("Gemini: OAuth token refresh enabled")
 	}
 	return client
@@ -57,14 +69,36 @@ func (c *GeminiClient) getEndpoint(model string) string {
 	return fmt.Sprintf("models/%s:generateContent", model)
 }
 
+// GenerateCode generates code with the model chosen by the model selector
 func (c *GeminiClient) GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
-	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
-	fullPrompt := c.buildFullPrompt(prompt, contextStr, outputFile, detectedLanguage, contextFiles)
-	model := c.config.Model
-	if model == "" {
-		model = geminiDefaultModel
+	model, err := c.modelSelector.SelectModel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to select model: %w", err)
 	}
+	c.lastModel = model
+	result, err := c.generateCode(ctx, model, prompt, contextStr, outputFile, language, contextFiles)
+	c.modelSelector.Record(model, err)
+	return result, err
+}
+
+// generateCode makes one generateContent call to model
+func (c *GeminiClient) generateCode(ctx context.Context, model, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
+	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
+	contextBlock, request := outboundPromptParts(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
 	endpoint := c.getEndpoint(model)
+
+	// Large context goes in a cachedContent the request refers to; the
+	// Cloud Code API has no cachedContents
+	fullPrompt := request
+	var cachedContent string
+	if contextBlock != "" {
+		if c.oauth2Token == nil && !c.config.DisablePromptCache && estimatePromptTokens(contextBlock) >= geminiMinCacheTokens {
+			cachedContent = c.cachedContent(ctx, model, contextBlock)
+		}
+		if cachedContent == "" {
+			fullPrompt = contextBlock + "\n\n" + request
+		}
+	}
 	reqBody := GenerateContentRequest{
 		Contents: []Content{
 			{
@@ -74,6 +108,7 @@ func (c *GeminiClient) GenerateCode(ctx context.Context, prompt, contextStr, out
 				},
 			},
 		},
+		CachedContent: cachedContent,
 		GenerationConfig: &GenerationConfig{
 			Temperature:     0.7,
 			TopP:            0.95,
@@ -135,7 +170,7 @@ func (c *GeminiClient) GenerateCode(ctx context.Context, prompt, contextStr, out
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Gemini API error: %d - %s", resp.StatusCode, string(body))
+		return nil, newAPIError("Gemini", resp, string(body))
 	}
 
 	var apiResp GenerateContentResponse
@@ -179,15 +214,17 @@ func (c *GeminiClient) GenerateCode(ctx context.Context, prompt, contextStr, out
 			PromptTokens:     apiResp.UsageMetadata.PromptTokenCount,
 			CompletionTokens: apiResp.UsageMetadata.CandidatesTokenCount,
 			TotalTokens:      apiResp.UsageMetadata.TotalTokenCount,
+			CachedTokens:     apiResp.UsageMetadata.CachedContentTokenCount,
 		}
-		logger.Debugf("Gemini: Extracted token usage - Prompt: %d, Completion: %d, Total: %d",
-			usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+		logger.Debugf("Gemini: Extracted token usage - Prompt: %d (cached: %d), Completion: %d, Total: %d",
+			usage.PromptTokens, usage.CachedTokens, usage.CompletionTokens, usage.TotalTokens)
 	} else {
 		logger.Warnf("Gemini: No usage metadata in response")
 	}
 	return &types.CodeGenerationResult{
-		Code:  cleanedCode,
-		Usage: usage,
+		Code:      cleanedCode,
+		Usage:     usage,
+		Citations: candidate.citations(),
 	}, nil
 }
 func (c *GeminiClient) getBaseURL() string {
@@ -234,7 +271,7 @@ func (c *GeminiClient) doRequest(ctx context.Context, method, endpoint string, b
 		return nil, fmt.Errorf("Gemini requires OAuth or API key authentication")
 	}
 	logger.Debugf("Gemini: Making API call to %s", url)
-	resp, err := c.client.Do(req)
+	resp, err := doRequest(c.client, req, geminiRequestTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -260,98 +297,50 @@ func (c *GeminiClient) createOAuth2Token() *oauth2.Token {
 		TokenType:    "Bearer",
 	}
 }
-func (c *GeminiClient) isTokenExpired() bool {
-	c.tokenMutex.RLock()
-	defer c.tokenMutex.RUnlock()
-	if c.oauth2Token == nil {
-		return true
-	}
-	return c.oauth2Token.Expiry.IsZero() || c.oauth2Token.Expiry.Before(time.Now().Add(5*time.Minute))
-}
 func (c *GeminiClient) ensureValidToken(ctx context.Context) error {
-	logger.Debugf("Gemini: ensureValidToken called")
 	if c.oauth2Config == nil || c.oauth2Token == nil {
-		logger.Debugf("Gemini: OAuth not configured (config=%v, token=%v)", c.oauth2Config != nil, c.oauth2Token != nil)
-		return nil
-	}
-	isExpired := c.isTokenExpired()
-	logger.Debugf("Gemini: Token expired check: %v", isExpired)
-	if !isExpired {
-		return nil
-	}
-	logger.Debugf("Gemini: Acquiring token mutex lock")
-	c.tokenMutex.Lock()
-	defer c.tokenMutex.Unlock()
-	logger.Debugf("Gemini: Token mutex lock acquired")
-	if c.oauth2Token != nil && !c.oauth2Token.Expiry.IsZero() && !c.oauth2Token.Expiry.Before(time.Now().Add(5*time.Minute)) {
-		logger.Debugf("Gemini: Token already refreshed by another goroutine")
 		return nil
 	}
-	logger.Debugf("Gemini: Refreshing expired OAuth token")
-	logger.Debugf("Gemini: Current refresh token: %s...", c.oauth2Token.RefreshToken[:10])
-	tokenSource := c.oauth2Config.TokenSource(ctx, c.oauth2Token)
-	logger.Debugf("Gemini: Created token source, calling Token()")
-	newToken, err := tokenSource.Token()
-	logger.Debugf("Gemini: Token() call completed, err=%v", err)
+	token, err := auth.Tokens().Token(ctx, "gemini")
 	if err != nil {
 		return fmt.Errorf("failed to refresh OAuth token: %w", err)
 	}
-	c.oauth2Token = newToken
-	c.config.AccessToken = newToken.AccessToken
-	c.config.RefreshToken = newToken.RefreshToken
-	c.config.TokenExpiry = newToken.Expiry
-	logger.Debugf("Gemini: OAuth token refreshed successfully, new expiry: %s", newToken.Expiry.Format(time.RFC3339))
-	if err := c.persistToken(); err != nil {
-		logger.Warnf("Failed to persist updated token to config file: %v. Don't fail the request, token is valid in memory", err)
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+	c.oauth2Token = &oauth2.Token{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.ExpiresAt,
+		TokenType:    "Bearer",
 	}
+	c.config.AccessToken = token.AccessToken
+	c.config.RefreshToken = token.RefreshToken
+	c.config.TokenExpiry = token.ExpiresAt
 	return nil
 }
-func (c *GeminiClient) persistToken() error {
-	logger.Debugf("Gemini: Persisting token to config file")
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
-	}
-	configPath := filepath.Join(homeDir, ".mcp-code-api", "config.yaml")
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
-	}
-	var configMap map[string]interface{}
-	if err := yaml.Unmarshal(configData, &configMap); err != nil {
-		return fmt.Errorf("failed to parse config YAML: %w", err)
-	}
-	providers, ok := configMap["providers"].(map[string]interface{})
-	if !ok {
-		providers = make(map[string]interface{})
-		configMap["providers"] = providers
-	}
-	gemini, ok := providers["gemini"].(map[string]interface{})
-	if !ok {
-		gemini = make(map[string]interface{})
-		providers["gemini"] = gemini
-	}
-	gemini["access_token"] = c.config.AccessToken
-	gemini["refresh_token"] = c.config.RefreshToken
-	gemini["token_expiry"] = c.config.TokenExpiry.Format(time.RFC3339)
-	updatedData, err := yaml.Marshal(configMap)
+
+// refreshOAuthToken is the token manager's RefreshFunc for Google OAuth
+func (c *GeminiClient) refreshOAuthToken(ctx context.Context, token *auth.OAuthConfig) (*auth.OAuthConfig, error) {
+	// Without an access token the token source goes straight to a refresh
+	fresh, err := c.oauth2Config.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
 	if err != nil {
-		return fmt.Errorf("failed to marshal updated config: %w", err)
-	}
-	if err := os.WriteFile(configPath, updatedData, 0600); err != nil {
-		return fmt.Errorf("failed to write updated config file: %w", err)
+		return nil, err
 	}
-	logger.Debugf("Gemini: Token persisted successfully to %s", configPath)
-	return nil
+	refreshed := *token
+	refreshed.AccessToken = fresh.AccessToken
+	refreshed.RefreshToken = fresh.RefreshToken
+	refreshed.ExpiresAt = fresh.Expiry
+	refreshed.TokenType = fresh.Type()
+	return &refreshed, nil
 }
 
 func (c *GeminiClient) persistProjectID(projectID string) error {
-	logger.Debugf("Gemini: Persisting project ID to config file")
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+	if config.NoPersist() {
+		c.config.ProjectID = projectID
+		return nil
 	}
-	configPath := filepath.Join(homeDir, ".mcp-code-api", "config.yaml")
+	logger.Debugf("Gemini: Persisting project ID to config file")
+	configPath := config.ConfigFilePath()
 	configData, err := os.ReadFile(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
@@ -382,53 +371,18 @@ func (c *GeminiClient) persistProjectID(projectID string) error {
 	logger.Debugf("Gemini: Project ID persisted successfully to %s", configPath)
 	return nil
 }
-// buildFullPrompt builds the complete prompt including context and existing content
-func (c *GeminiClient) buildFullPrompt(prompt, contextStr, outputFile, detectedLanguage string, contextFiles []string) string {
-	var parts []string
-	// Add context files if provided
-	if len(contextFiles) > 0 {
-		filteredContextFiles := c.filterContextFiles(contextFiles, outputFile)
-		if len(filteredContextFiles) > 0 {
-			contextContent := "Context Files:\n"
-			for _, contextFile := range filteredContextFiles {
-				if content, err := utils.ReadFileContent(contextFile); err == nil && content != "" {
-					contextLang := utils.GetLanguageFromFile(contextFile, nil)
-					contextContent += fmt.Sprintf("\nFile: %s\n```%s\n%s\n```\n", contextFile, contextLang, content)
-				} else {
-					logger.Warnf("Could not read context file %s: %v", contextFile, err)
-				}
-			}
-			parts = append(parts, contextContent)
-		}
-	}
-	// Add additional context if provided
-	if contextStr != "" {
-		parts = append(parts, fmt.Sprintf("Context: %s", contextStr))
-	}
-	// Add existing file content if it exists
-	if existingContent, err := utils.ReadFileContent(outputFile); err == nil && existingContent != "" {
-		parts = append(parts, fmt.Sprintf("Existing file content:\n```%s\n%s\n```\n", detectedLanguage, existingContent))
-	}
-	// Add the prompt
-	parts = append(parts, fmt.Sprintf("Generate %s code for: %s", detectedLanguage, prompt))
-	return strings.Join(parts, "\n\n")
-}
-// filterContextFiles filters out the output file from context files
-func (c *GeminiClient) filterContextFiles(contextFiles []string, outputFile string) []string {
-	var filtered []string
-	for _, file := range contextFiles {
-		contextAbs := filepath.Clean(file)
-		outputAbs := filepath.Clean(outputFile)
-		if contextAbs != outputAbs {
-			filtered = append(filtered, file)
-		}
+// GetModel returns the model used by the last request
+func (c *GeminiClient) GetModel() string {
+	if c.lastModel != "" {
+		return c.lastModel
 	}
-	return filtered
+	return c.config.Model
 }
 // Request/Response types for Gemini API
 type GenerateContentRequest struct {
 	Contents         []Content         `json:"contents"`
 	GenerationConfig *GenerationConfig `json:"generationConfig,omitempty"`
+	CachedContent    string            `json:"cachedContent,omitempty"` // Name of a cachedContent the contents follow
 }
 type Content struct {
 	Role  string `json:"role"`
@@ -448,13 +402,48 @@ type GenerateContentResponse struct {
 	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
 }
 type Candidate struct {
-	Content      Content `json:"content"`
-	FinishReason string  `json:"finishReason,omitempty"`
+	Content          Content           `json:"content"`
+	FinishReason     string            `json:"finishReason,omitempty"`
+	CitationMetadata *CitationMetadata `json:"citationMetadata,omitempty"`
+}
+
+// CitationMetadata lists the sources a candidate recites; the Gemini API
+// calls them citationSources, Vertex AI citations
+type CitationMetadata struct {
+	CitationSources []CitationSource `json:"citationSources,omitempty"`
+	Citations       []CitationSource `json:"citations,omitempty"`
+}
+type CitationSource struct {
+	StartIndex int    `json:"startIndex,omitempty"`
+	EndIndex   int    `json:"endIndex,omitempty"`
+	URI        string `json:"uri,omitempty"`
+	Title      string `json:"title,omitempty"`
+	License    string `json:"license,omitempty"`
+}
+
+// citations returns the sources the candidate recites
+func (c Candidate) citations() []types.Citation {
+	if c.CitationMetadata == nil {
+		return nil
+	}
+	var citations []types.Citation
+	for _, source := range append(c.CitationMetadata.CitationSources, c.CitationMetadata.Citations...) {
+		citations = append(citations, types.Citation{
+			URI:        source.URI,
+			Title:      source.Title,
+			License:    source.License,
+			StartIndex: source.StartIndex,
+			EndIndex:   source.EndIndex,
+		})
+	}
+	return citations
 }
 type UsageMetadata struct {
 	PromptTokenCount     int `json:"promptTokenCount"`
 	CandidatesTokenCount int `json:"candidatesTokenCount"`
 	TotalTokenCount      int `json:"totalTokenCount"`
+	// Part of PromptTokenCount read from the cachedContent
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
 }
 // CloudCode request/response wrappers
 type CloudCodeRequestWrapper struct {
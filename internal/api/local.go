@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// localHealthTTL is how long a health probe result is reused. Short enough to
+// notice a server being started, long enough that a request (and its
+// validation retries) costs at most one probe.
+const localHealthTTL = 15 * time.Second
+
+// LocalClient handles local OpenAI-compatible servers such as LM Studio and
+// llama.cpp's llama-server. Each request is preceded by a (cached) health
+// probe so an idle local server fails fast and the router falls back to the
+// next provider instead of waiting for a connection timeout.
+type LocalClient struct {
+	name      string
+	config    config.LocalProviderConfig
+	client    *http.Client
+	lastUsage *types.Usage
+}
+
+// localHealth caches probe results per base URL across clients, since the
+// router creates a new client for every request
+var localHealth = struct {
+	sync.Mutex
+	results map[string]localHealthResult
+}{results: make(map[string]localHealthResult)}
+
+type localHealthResult struct {
+	healthy bool
+	checked time.Time
+}
+
+// NewLocalClient creates a client for the local provider name ("lmstudio" or "llamacpp")
+func NewLocalClient(name string, cfg config.LocalProviderConfig) *LocalClient {
+	cfg.BaseURL = localBaseURL(cfg.BaseURL)
+	return &LocalClient{
+		name:   name,
+		config: cfg,
+		client: &http.Client{},
+	}
+}
+
+// GenerateCode generates code using the local server
+func (c *LocalClient) GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
+	if c.config.BaseURL == "" {
+		return nil, fmt.Errorf("%s: no base_url configured", c.name)
+	}
+	if !c.IsHealthy(ctx) {
+		return nil, fmt.Errorf("%s: not running at %s", c.name, c.config.BaseURL)
+	}
+
+	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
+
+	// Build the full prompt
+	fullPrompt := outboundPrompt(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+
+	requestData := c.prepareRequest(fullPrompt, prompts.System(ctx, c.name, detectedLanguage))
+	requestData.ResponseFormat = responseFormat(ctx)
+
+	response, err := c.makeAPICall(ctx, requestData)
+	if err != nil {
+		// A failed call usually means the server went away; probe again next time
+		forgetLocalHealth(c.config.BaseURL)
+		return nil, err
+	}
+
+	c.lastUsage = &types.Usage{
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+		TotalTokens:      response.Usage.TotalTokens,
+	}
+	logger.Debugf("%s: Extracted token usage - Prompt: %d, Completion: %d, Total: %d",
+		c.name, c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
+
+	return &types.CodeGenerationResult{
+		Code:  utils.CleanCodeResponse(response.Choices[0].Message.Content),
+		Usage: c.lastUsage,
+	}, nil
+}
+
+// GetModel returns the configured model name
+func (c *LocalClient) GetModel() string {
+	return c.config.Model
+}
+
+// IsHealthy reports whether the local server answered a recent health probe
+func (c *LocalClient) IsHealthy(ctx context.Context) bool {
+	localHealth.Lock()
+	cached, ok := localHealth.results[c.config.BaseURL]
+	localHealth.Unlock()
+	if ok && time.Since(cached.checked) < localHealthTTL {
+		return cached.healthy
+	}
+
+	healthy := ProbeLocalService(ctx, c.config.BaseURL)
+	logger.Debugf("%s: health probe of %s: healthy=%v", c.name, c.config.BaseURL, healthy)
+
+	localHealth.Lock()
+	localHealth.results[c.config.BaseURL] = localHealthResult{healthy: healthy, checked: time.Now()}
+	localHealth.Unlock()
+	return healthy
+}
+
+// forgetLocalHealth drops the cached probe result for baseURL
+func forgetLocalHealth(baseURL string) {
+	localHealth.Lock()
+	delete(localHealth.results, baseURL)
+	localHealth.Unlock()
+}
+
+// localBaseURL returns baseURL with a localhost host replaced by 127.0.0.1
+// on Windows. There localhost resolves to ::1 first, and as Windows retries
+// a refused connection for about two seconds before trying 127.0.0.1,
+// servers listening on IPv4 only (LM Studio, llama-server) would fail the
+// health probe.
+func localBaseURL(baseURL string) string {
+	if runtime.GOOS != "windows" {
+		return baseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || !strings.EqualFold(u.Hostname(), "localhost") {
+		return baseURL
+	}
+	u.Host = "127.0.0.1"
+	if port := u.Port(); port != "" {
+		u.Host += ":" + port
+	}
+	return u.String()
+}
+
+// ProbeLocalService reports whether a local OpenAI-compatible server is
+// answering at baseURL. llama.cpp exposes /health; LM Studio and others are
+// detected through the models endpoint or the root path.
+func ProbeLocalService(ctx context.Context, baseURL string) bool {
+	if baseURL == "" {
+		return false
+	}
+	baseURL = strings.TrimSuffix(localBaseURL(baseURL), "/")
+	root := strings.TrimSuffix(baseURL, "/v1")
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	for _, endpoint := range []string{root + "/health", baseURL + "/models", root + "/v1/models", root + "/"} {
+		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return false
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			// Connection refused on one path means the server is down on all of them
+			return false
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareRequest prepares the chat completions payload
+func (c *LocalClient) prepareRequest(fullPrompt, systemPrompt string) CerebrasRequest {
+	requestData := CerebrasRequest{
+		Model: c.config.Model,
+		Messages: []CerebrasMessage{
+			{
+				Role:    "system",
+				Content: systemPrompt,
+			},
+			{
+				Role:    "user",
+				Content: fullPrompt,
+			},
+		},
+		Temperature: &c.config.Temperature,
+		Stream:      false,
+	}
+	if c.config.MaxTokens > 0 {
+		requestData.MaxTokens = c.config.MaxTokens
+	}
+	return requestData
+}
+
+// makeAPICall makes the chat completions request
+func (c *LocalClient) makeAPICall(ctx context.Context, requestData CerebrasRequest) (*CerebrasResponse, error) {
+	jsonBody, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(c.config.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", strconv.Itoa(len(jsonBody)))
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	logger.Debugf("Making %s API call to %s", c.name, endpoint)
+
+	resp, err := doRequest(c.client, req, localRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse CerebrasErrorResponse
+		if parseErr := json.Unmarshal(body, &errorResponse); parseErr == nil && errorResponse.Error.Message != "" {
+			return nil, newAPIError(c.name, resp, errorResponse.Error.Message)
+		}
+		return nil, newAPIError(c.name, resp, string(body))
+	}
+
+	var response CerebrasResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in API response")
+	}
+	return &response, nil
+}
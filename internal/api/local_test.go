@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestProbeLocalService(t *testing.T) {
+	// LM Studio has no /health, only the OpenAI models endpoint
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+
+	if !ProbeLocalService(t.Context(), srv.URL+"/v1") {
+		t.Error("ProbeLocalService() = false for a server answering /v1/models")
+	}
+
+	srv.Close()
+	if ProbeLocalService(t.Context(), srv.URL+"/v1") {
+		t.Error("ProbeLocalService() = true for a stopped server")
+	}
+}
+
+func TestLocalClientFailsFastWhenDown(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	baseURL := srv.URL + "/v1"
+	srv.Close()
+
+	client := NewLocalClient("lmstudio", config.LocalProviderConfig{BaseURL: baseURL, Model: "local-model"})
+	_, err := client.GenerateCode(t.Context(), "hello", "", "", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("GenerateCode() error = %v, want not running", err)
+	}
+}
+
+func TestLocalBaseURL(t *testing.T) {
+	tests := map[string]string{
+		"http://localhost:1234/v1": "http://127.0.0.1:1234/v1",
+		"http://LocalHost/v1":      "http://127.0.0.1/v1",
+		"http://127.0.0.1:8080/v1": "http://127.0.0.1:8080/v1",
+		"http://gpu-box:8080/v1":   "http://gpu-box:8080/v1",
+		"http://localhost.lan:80/": "http://localhost.lan:80/",
+	}
+	for baseURL, want := range tests {
+		if runtime.GOOS != "windows" {
+			want = baseURL
+		}
+		if got := localBaseURL(baseURL); got != want {
+			t.Errorf("localBaseURL(%q) = %q, want %q", baseURL, got, want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
+	"github.com/cecil-the-coder/mcp-code-api/internal/transform"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// MistralFIMMarker marks the insertion point in an existing file. When the
+// target file contains it exactly once and FIM is enabled, the Mistral client
+// completes the gap with the Codestral fill-in-the-middle endpoint instead of
+// regenerating the whole file.
+const MistralFIMMarker = "<FILL>"
+
+// MistralClient handles Mistral API interactions. Chat generation uses the
+// OpenAI-compatible chat completions endpoint; code completion at a marker
+// uses the Codestral FIM endpoint.
+type MistralClient struct {
+	config     config.MistralConfig
+	client     *http.Client
+	keyManager *APIKeyManager
+	lastUsage  *types.Usage
+	lastModel  string
+}
+
+// MistralFIMRequest represents a Codestral fill-in-the-middle request
+type MistralFIMRequest struct {
+	Model       string  `json:"model"`
+	Prompt      string  `json:"prompt"`
+	Suffix      string  `json:"suffix,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+	MaxTokens   int     `json:"max_tokens,omitempty"`
+}
+
+// NewMistralClient creates a new Mistral client
+func NewMistralClient(cfg config.MistralConfig) *MistralClient {
+	return &MistralClient{
+		config:     cfg,
+		keyManager: NewAPIKeyManager("Mistral", cfg.GetAllAPIKeys(), cfg.KeyBalancing),
+		client:     &http.Client{},
+	}
+}
+
+// GenerateCode generates code using the Mistral API with automatic key failover
+func (c *MistralClient) GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
+	if c.keyManager == nil {
+		return nil, fmt.Errorf("no Mistral API key configured")
+	}
+
+	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
+
+	if c.config.FIM {
+		if existing, err := utils.ReadFileContent(outputFile); err == nil && strings.Count(existing, MistralFIMMarker) == 1 {
+			return c.fillInMiddle(ctx, prompt, existing, detectedLanguage)
+		}
+	}
+
+	// Build the full prompt
+	fullPrompt := outboundPrompt(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+
+	requestData := c.prepareRequest(fullPrompt, prompts.System(ctx, "mistral", detectedLanguage))
+	c.lastModel = requestData.Model
+
+	code, err := c.keyManager.ExecuteWithFailover(func(apiKey string) (string, error) {
+		var response CerebrasResponse
+		if err := c.post(ctx, "/chat/completions", requestData, apiKey, &response); err != nil {
+			return "", err
+		}
+		if len(response.Choices) == 0 {
+			return "", fmt.Errorf("no choices in API response")
+		}
+		c.lastUsage = &types.Usage{
+			PromptTokens:     response.Usage.PromptTokens,
+			CompletionTokens: response.Usage.CompletionTokens,
+			TotalTokens:      response.Usage.TotalTokens,
+		}
+		logger.Debugf("Mistral: Extracted token usage - Prompt: %d, Completion: %d, Total: %d",
+			c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
+		return utils.CleanCodeResponse(response.Choices[0].Message.Content), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CodeGenerationResult{
+		Code:  code,
+		Usage: c.lastUsage,
+	}, nil
+}
+
+// GetModel returns the model used by the last request
+func (c *MistralClient) GetModel() string {
+	if c.lastModel != "" {
+		return c.lastModel
+	}
+	return c.config.Model
+}
+
+// fillInMiddle completes the gap at MistralFIMMarker in existing and returns
+// the whole file. FIM has no instruction field, so the prompt is passed as a
+// comment just before the gap and removed again from the result.
+func (c *MistralClient) fillInMiddle(ctx context.Context, prompt, existing, detectedLanguage string) (*types.CodeGenerationResult, error) {
+	prefix, suffix, _ := strings.Cut(existing, MistralFIMMarker)
+
+	fimPrompt := prefix
+	if commentOpen, commentClose, ok := utils.CommentDelimiters(detectedLanguage); ok {
+		fimPrompt += commentOpen + strings.ReplaceAll(prompt, "\n", " ") + commentClose + "\n"
+	}
+
+	requestData := MistralFIMRequest{
+		Model:       c.config.FIMModel,
+		Prompt:      transform.Outbound(ctx, fimPrompt),
+		Suffix:      transform.Outbound(ctx, suffix),
+		Temperature: c.config.Temperature,
+		MaxTokens:   c.config.MaxTokens,
+	}
+	c.lastModel = requestData.Model
+
+	logger.Debugf("Mistral: Using FIM with %s (%d byte prefix, %d byte suffix)", requestData.Model, len(prefix), len(suffix))
+
+	middle, err := c.keyManager.ExecuteWithFailover(func(apiKey string) (string, error) {
+		var response CerebrasResponse
+		if err := c.post(ctx, "/fim/completions", requestData, apiKey, &response); err != nil {
+			return "", err
+		}
+		if len(response.Choices) == 0 {
+			return "", fmt.Errorf("no choices in FIM response")
+		}
+		c.lastUsage = &types.Usage{
+			PromptTokens:     response.Usage.PromptTokens,
+			CompletionTokens: response.Usage.CompletionTokens,
+			TotalTokens:      response.Usage.TotalTokens,
+		}
+		return response.Choices[0].Message.Content, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CodeGenerationResult{
+		Code:  prefix + middle + suffix,
+		Usage: c.lastUsage,
+	}, nil
+}
+
+// prepareRequest prepares the chat completions payload
+func (c *MistralClient) prepareRequest(fullPrompt, systemPrompt string) CerebrasRequest {
+	requestData := CerebrasRequest{
+		Model: c.config.Model,
+		Messages: []CerebrasMessage{
+			{
+				Role:    "system",
+				Content: systemPrompt,
+			},
+			{
+				Role:    "user",
+				Content: fullPrompt,
+			},
+		},
+		Temperature: &c.config.Temperature,
+		Stream:      false,
+	}
+	if c.config.MaxTokens > 0 {
+		requestData.MaxTokens = c.config.MaxTokens
+	}
+	return requestData
+}
+
+// post sends a JSON request to path under the configured base URL and decodes the response
+func (c *MistralClient) post(ctx context.Context, path string, requestData interface{}, apiKey string, out interface{}) error {
+	jsonBody, err := json.Marshal(requestData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(c.config.BaseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", strconv.Itoa(len(jsonBody)))
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	logger.Debugf("Making Mistral API call to %s", endpoint)
+
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Mistral returns {"message": ...} for most errors
+		var errorResponse struct {
+			Message string `json:"message"`
+		}
+		if parseErr := json.Unmarshal(body, &errorResponse); parseErr == nil && errorResponse.Message != "" {
+			return newAPIError("Mistral", resp, errorResponse.Message)
+		}
+		return newAPIError("Mistral", resp, string(body))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestMistralFillInMiddle(t *testing.T) {
+	var got MistralFIMRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fim/completions" {
+			t.Errorf("request path = %s, want /fim/completions", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"\treturn a + b\n"}}],"usage":{"total_tokens":7}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "add.go")
+	if err := os.WriteFile(path, []byte("func add(a, b int) int {\n<FILL>}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewMistralClient(config.MistralConfig{
+		APIKey:   "test",
+		BaseURL:  server.URL,
+		FIM:      true,
+		FIMModel: "codestral-latest",
+	})
+	result, err := client.GenerateCode(t.Context(), "sum the arguments", "", path, nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+
+	if want := "func add(a, b int) int {\n\treturn a + b\n}\n"; result.Code != want {
+		t.Errorf("Code = %q, want %q", result.Code, want)
+	}
+	if want := "func add(a, b int) int {\n// sum the arguments\n"; got.Prompt != want {
+		t.Errorf("FIM prompt = %q, want %q", got.Prompt, want)
+	}
+	if got.Suffix != "}\n" {
+		t.Errorf("FIM suffix = %q, want %q", got.Suffix, "}\n")
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestMockClientFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	builtin := NewMockClient(config.MockConfig{})
+	first, err := builtin.GenerateCode(ctx, "Add a health check\nwith details", "", "/src/Server.java", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if !strings.Contains(first.Code, "public class Server {") || !strings.Contains(first.Code, "Mock response for: Add a health check\n") {
+		t.Errorf("built-in java fixture = %q", first.Code)
+	}
+	second, _ := builtin.GenerateCode(ctx, "Add a health check\nwith details", "", "/src/Server.java", nil, nil)
+	if second.Code != first.Code {
+		t.Error("GenerateCode() is not deterministic")
+	}
+	if first.Usage == nil || first.Usage.TotalTokens == 0 {
+		t.Errorf("GenerateCode() usage = %+v", first.Usage)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "python.py"), []byte("print({{printf \"%q\" .Summary}})\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configured := NewMockClient(config.MockConfig{
+		Model:       "mock-coder",
+		Fixtures:    map[string]string{"default": "// {{.Language}}\n"},
+		FixturesDir: dir,
+	})
+	tests := []struct {
+		file, want string
+	}{
+		{"app.py", "print(\"hello\")\n"},
+		{"main.go", "// go\n"},
+		{"notes.txt", "// text\n"},
+	}
+	for _, tt := range tests {
+		result, err := configured.GenerateCode(ctx, "hello", "", tt.file, nil, nil)
+		if err != nil || result.Code != tt.want {
+			t.Errorf("GenerateCode(%s) = %q, %v; want %q", tt.file, result.Code, err, tt.want)
+		}
+	}
+	if configured.GetModel() != "mock-coder" || builtin.GetModel() != "mock" {
+		t.Errorf("GetModel() = %q, %q", configured.GetModel(), builtin.GetModel())
+	}
+}
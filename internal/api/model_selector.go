@@ -1,36 +1,90 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
 )
 
+// modelFailoverCooldown is how long the failover strategy skips a model
+// after it failed, before trying it again
+const modelFailoverCooldown = time.Minute
+
+// errModelsRateLimited is returned by SelectModel while every model is
+// rate limited; RateLimitWait says for how long
+var errModelsRateLimited = errors.New("every model is rate limited")
+
 // ModelSelector implements different strategies for selecting models
 type ModelSelector struct {
+	providerName string
 	models       []string
 	strategy     string
 	currentIndex int
 	mutex        sync.Mutex
-	failedModels map[string]bool
+	failedModels map[string]time.Time // Model to the end of its failover cooldown
+	rateLimited  map[string]time.Time // Model to the end of its rate limit window; skipped by every strategy
 }
 
-// NewModelSelector creates a new ModelSelector with the given models and strategy
-func NewModelSelector(models []string, strategy string) *ModelSelector {
+// modelSelectors shares selectors across clients, like keyManagers, so that
+// round-robin position and failed models outlive the per-request client.
+// They are keyed by provider and models, since racers and profiles build
+// clients for one model of a provider alongside its models list.
+var modelSelectors = struct {
+	sync.Mutex
+	selectors map[string]*ModelSelector
+}{selectors: make(map[string]*ModelSelector)}
+
+// NewModelSelector returns the model selector for a provider's models,
+// reusing the existing one while the strategy is unchanged
+func NewModelSelector(providerName string, models []string, strategy string) *ModelSelector {
 	// Seed the random number generator
 	rand.Seed(time.Now().UnixNano())
 
-	return &ModelSelector{
+	modelSelectors.Lock()
+	defer modelSelectors.Unlock()
+
+	key := providerName + "|" + strings.Join(models, ",")
+	if existing, ok := modelSelectors.selectors[key]; ok && existing.strategy == strategy {
+		return existing
+	}
+
+	selector := &ModelSelector{
+		providerName: providerName,
 		models:       models,
 		strategy:     strategy,
 		currentIndex: 0,
 		mutex:        sync.Mutex{},
-		failedModels: make(map[string]bool),
+		failedModels: make(map[string]time.Time),
+		rateLimited:  make(map[string]time.Time),
+	}
+	modelSelectors.selectors[key] = selector
+	if len(models) > 1 {
+		logger.Infof("ModelSelector initialized for %s with %d models (%s)", providerName, len(models), strategy)
 	}
+	return selector
 }
 
-// SelectModel selects a model based on the configured strategy
+// newConfiguredModelSelector returns the selector for a provider config's
+// models list, or its single model when the list is empty. Strategy
+// defaults to failover.
+func newConfiguredModelSelector(providerName, model string, models []string, strategy string) *ModelSelector {
+	if len(models) == 0 && model != "" {
+		models = []string{model}
+	}
+	if strategy == "" {
+		strategy = "failover"
+	}
+	return NewModelSelector(providerName, models, strategy)
+}
+
+// SelectModel selects a model based on the configured strategy, leaving out
+// rate-limited models
 func (ms *ModelSelector) SelectModel() (string, error) {
 	ms.mutex.Lock()
 	defer ms.mutex.Unlock()
@@ -38,6 +92,9 @@ func (ms *ModelSelector) SelectModel() (string, error) {
 	if len(ms.models) == 0 {
 		return "", fmt.Errorf("no models available")
 	}
+	if !ms.anyAvailable(time.Now()) {
+		return "", errModelsRateLimited
+	}
 
 	switch ms.strategy {
 	case "round-robin":
@@ -51,12 +108,80 @@ func (ms *ModelSelector) SelectModel() (string, error) {
 	}
 }
 
-// RecordFailure marks a model as failed for the failover strategy
+// RecordRateLimit takes model out of rotation until the given time, e.g.
+// when its quota is used up
+func (ms *ModelSelector) RecordRateLimit(model string, until time.Time) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.rateLimited[model] = until
+}
+
+// RateLimitWait returns how long until the first rate-limited model is
+// available again, or 0 if one already is
+func (ms *ModelSelector) RateLimitWait() time.Duration {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	now := time.Now()
+	if ms.anyAvailable(now) {
+		return 0
+	}
+	var wait time.Duration
+	for _, until := range ms.rateLimited {
+		if d := until.Sub(now); wait == 0 || d < wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// available reports whether model is outside a rate limit window
+func (ms *ModelSelector) available(model string, now time.Time) bool {
+	until, limited := ms.rateLimited[model]
+	return !limited || !now.Before(until)
+}
+
+// anyAvailable reports whether some model is outside a rate limit window
+func (ms *ModelSelector) anyAvailable(now time.Time) bool {
+	for _, model := range ms.models {
+		if ms.available(model, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record reports the outcome of a request to model. A request the caller
+// cancelled, e.g. a lost race, says nothing about the model.
+func (ms *ModelSelector) Record(model string, err error) {
+	switch {
+	case err == nil:
+		ms.RecordSuccess(model)
+	case errors.Is(err, context.Canceled):
+	default:
+		ms.RecordFailure(model)
+	}
+}
+
+// RecordFailure marks a model as failed for the failover strategy, which
+// skips it for modelFailoverCooldown
 func (ms *ModelSelector) RecordFailure(model string) {
 	ms.mutex.Lock()
 	defer ms.mutex.Unlock()
 
-	ms.failedModels[model] = true
+	ms.failedModels[model] = time.Now().Add(modelFailoverCooldown)
+	if len(ms.models) > 1 {
+		logger.Debugf("%s: model %s failed, skipping it for %v", ms.providerName, model, modelFailoverCooldown)
+	}
+}
+
+// RecordSuccess clears a model's failure
+func (ms *ModelSelector) RecordSuccess(model string) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	delete(ms.failedModels, model)
 }
 
 // Reset clears the failed models map
@@ -64,33 +189,47 @@ func (ms *ModelSelector) Reset() {
 	ms.mutex.Lock()
 	defer ms.mutex.Unlock()
 
-	ms.failedModels = make(map[string]bool)
+	ms.failedModels = make(map[string]time.Time)
 }
 
 func (ms *ModelSelector) selectFailover() (string, error) {
-	// Find the first model that hasn't failed
+	// Find the first model that isn't cooling down after a failure
+	now := time.Now()
 	for _, model := range ms.models {
-		if !ms.failedModels[model] {
+		if until, failed := ms.failedModels[model]; ms.available(model, now) && (!failed || now.After(until)) {
 			return model, nil
 		}
 	}
 
-	// If all models have failed, reset and return the first model
-	ms.failedModels = make(map[string]bool)
-	if len(ms.models) > 0 {
-		return ms.models[0], nil
+	// If all models have failed, reset and return the first available model
+	ms.failedModels = make(map[string]time.Time)
+	for _, model := range ms.models {
+		if ms.available(model, now) {
+			return model, nil
+		}
 	}
 
 	return "", fmt.Errorf("no models available")
 }
 
 func (ms *ModelSelector) selectRoundRobin() string {
-	model := ms.models[ms.currentIndex]
-	ms.currentIndex = (ms.currentIndex + 1) % len(ms.models)
-	return model
+	now := time.Now()
+	for {
+		model := ms.models[ms.currentIndex]
+		ms.currentIndex = (ms.currentIndex + 1) % len(ms.models)
+		if ms.available(model, now) {
+			return model
+		}
+	}
 }
 
 func (ms *ModelSelector) selectRandom() string {
-	index := rand.Intn(len(ms.models))
-	return ms.models[index]
-}
\ No newline at end of file
+	now := time.Now()
+	var available []string
+	for _, model := range ms.models {
+		if ms.available(model, now) {
+			available = append(available, model)
+		}
+	}
+	return available[rand.Intn(len(available))]
+}
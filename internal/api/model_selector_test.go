@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestModelSelectorSharedAcrossClients(t *testing.T) {
+	models := []string{"a", "b"}
+	first := NewModelSelector(t.Name(), models, "round-robin")
+	if NewModelSelector(t.Name(), models, "round-robin") != first {
+		t.Error("NewModelSelector() created a new selector for unchanged models")
+	}
+	if NewModelSelector(t.Name(), []string{"a"}, "round-robin") == first {
+		t.Error("NewModelSelector() shared the selector of a different models list")
+	}
+	if NewModelSelector(t.Name(), models, "random") == first {
+		t.Error("NewModelSelector() reused the selector after the strategy changed")
+	}
+}
+
+func TestModelSelectorFailover(t *testing.T) {
+	ms := newConfiguredModelSelector(t.Name(), "", []string{"a", "b"}, "")
+
+	// A cancelled request, e.g. a lost race, leaves the model in place
+	ms.Record("a", fmt.Errorf("call: %w", context.Canceled))
+	if model, _ := ms.SelectModel(); model != "a" {
+		t.Errorf("after cancellation SelectModel() = %q, want a", model)
+	}
+	ms.Record("a", errors.New("server error"))
+	if model, _ := ms.SelectModel(); model != "b" {
+		t.Errorf("after a failure SelectModel() = %q, want b", model)
+	}
+	ms.Record("a", nil)
+	if model, _ := ms.SelectModel(); model != "a" {
+		t.Errorf("after a success SelectModel() = %q, want a", model)
+	}
+	ms.Record("a", errors.New("server error"))
+	ms.Record("b", errors.New("server error"))
+	if model, _ := ms.SelectModel(); model != "a" {
+		t.Errorf("with every model failed SelectModel() = %q, want the first", model)
+	}
+}
+
+func TestCerebrasClientRoundRobinModels(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request CerebrasRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, request.Model)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"x = 1"}}],"usage":{"total_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	cfg := config.CerebrasConfig{
+		APIKey:        "test",
+		BaseURL:       server.URL,
+		Models:        []string{t.Name() + "-a", t.Name() + "-b"},
+		ModelStrategy: "round-robin",
+	}
+	for i := 0; i < 3; i++ {
+		// A new client per request, as the router builds them
+		client := NewCerebrasClient(cfg)
+		if _, err := client.GenerateCode(t.Context(), "set x", "", "main.py", nil, nil); err != nil {
+			t.Fatalf("GenerateCode() error = %v", err)
+		}
+		if client.GetModel() != got[i] {
+			t.Errorf("GetModel() = %q, want %q", client.GetModel(), got[i])
+		}
+	}
+	if want := []string{cfg.Models[0], cfg.Models[1], cfg.Models[0]}; !slices.Equal(got, want) {
+		t.Errorf("models requested = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// defaultModelsTTL is how long discovered models are reused when
+// cache.models_ttl is not set
+const defaultModelsTTL = 6 * time.Hour
+
+// cachedModels is a provider's discovered models as stored on disk
+type cachedModels struct {
+	Fetched time.Time   `json:"fetched"`
+	Models  []ModelInfo `json:"models"`
+}
+
+// CachedModels returns the provider's models like DiscoverModels, but reuses
+// the result of an earlier discovery for cache.models_ttl. The models cache
+// lives in the "models" directory under cache.dir and is used whether or not
+// the response cache is enabled. refresh skips the cached list. It also
+// returns when the models were discovered.
+func CachedModels(ctx context.Context, cfg *config.Config, providerName string, refresh bool) ([]ModelInfo, time.Time, error) {
+	path := modelCachePath(cfg.Cache, providerName)
+	ttl := cfg.Cache.ModelsTTL
+	if ttl <= 0 {
+		ttl = defaultModelsTTL
+	}
+
+	if !refresh {
+		if data, err := os.ReadFile(path); err == nil {
+			var cached cachedModels
+			if err := json.Unmarshal(data, &cached); err == nil && time.Since(cached.Fetched) < ttl {
+				return cached.Models, cached.Fetched, nil
+			}
+		}
+	}
+
+	models, err := DiscoverModels(ctx, cfg, providerName)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	fetched := time.Now()
+	if config.NoPersist() {
+		return models, fetched, nil
+	}
+
+	// A failure to cache is only logged; the list is still good
+	data, err := json.Marshal(cachedModels{Fetched: fetched, Models: models})
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+	}
+	if err == nil {
+		err = os.WriteFile(path+".tmp", data, 0600)
+	}
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		logger.Warnf("Failed to cache %s models: %v", providerName, err)
+	}
+	return models, fetched, nil
+}
+
+// modelCachePath returns the file caching the provider's models
+func modelCachePath(cfg config.CacheConfig, providerName string) string {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(config.ConfigDir(), "cache")
+	}
+	return filepath.Join(config.ExpandPath(dir), "models", providerName+".json")
+}
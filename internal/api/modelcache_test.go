@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestCachedModels(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// OpenRouter reports context sizes and supported parameters
+		w.Write([]byte(`{"data":[
+			{"id":"qwen/qwen3-coder","context_length":262144,"top_provider":{"max_completion_tokens":65536},"supported_parameters":["tools","temperature"]},
+			{"id":"acme/base","context_length":8192,"supported_parameters":["temperature"]},
+			{"id":"acme/plain","capabilities":["chat"]}
+		]}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Cache.Dir = t.TempDir()
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{
+		"router": {BaseURL: srv.URL + "/v1", Model: "qwen/qwen3-coder"},
+	}
+
+	models, _, err := CachedModels(t.Context(), cfg, "router", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 3 {
+		t.Fatalf("CachedModels() = %+v", models)
+	}
+	base, plain, coder := models[0], models[1], models[2]
+	if coder.ContextWindow != 262144 || coder.MaxOutput != 65536 || coder.ToolCalling == nil || !*coder.ToolCalling {
+		t.Errorf("qwen/qwen3-coder = %+v", coder)
+	}
+	if base.ToolCalling == nil || *base.ToolCalling {
+		t.Errorf("acme/base = %+v, want no tool calling", base)
+	}
+	if plain.ToolCalling != nil || plain.ContextWindow != 0 {
+		t.Errorf("acme/plain = %+v, want unknown capabilities", plain)
+	}
+
+	// Served from the cache until refreshed
+	if _, _, err := CachedModels(t.Context(), cfg, "router", false); err != nil || calls.Load() != 1 {
+		t.Errorf("second call: err = %v, %d requests", err, calls.Load())
+	}
+	if _, _, err := CachedModels(t.Context(), cfg, "router", true); err != nil || calls.Load() != 2 {
+		t.Errorf("refresh: err = %v, %d requests", err, calls.Load())
+	}
+}
@@ -0,0 +1,474 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// KnownProviders lists the providers understood by the configuration, in display order
+var KnownProviders = []string{"anthropic", "cerebras", "openrouter", "gemini", "openai", "qwen", "azure-openai", "bedrock", "vertex", "mistral", "synthetic", "lmstudio", "llamacpp", "mock"}
+
+// ProviderNames returns KnownProviders followed by the user-defined custom
+// providers from cfg, sorted by name
+func ProviderNames(cfg *config.Config) []string {
+	names := append([]string(nil), KnownProviders...)
+	var custom []string
+	for name := range cfg.Providers.Custom {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
+// ProviderAuth describes how a provider is authenticated in the loaded config
+type ProviderAuth struct {
+	Configured bool
+	Method     string // "api_key", "api_keys", "oauth", "entra_id", "sigv4", "service_account", "local", "api_key_env", "none" or ""
+	Model      string
+	BaseURL    string
+}
+
+// GetProviderAuth inspects cfg for the credentials of providerName
+func GetProviderAuth(cfg *config.Config, providerName string) ProviderAuth {
+	p := cfg.Providers
+	keyAuth := func(key string, keys []string) string {
+		if key != "" {
+			return "api_key"
+		}
+		if len(keys) > 0 {
+			return "api_keys"
+		}
+		return ""
+	}
+
+	var auth ProviderAuth
+	switch providerName {
+	case "anthropic":
+		if p.Anthropic != nil {
+			auth = ProviderAuth{Method: keyAuth(p.Anthropic.APIKey, p.Anthropic.APIKeys), Model: p.Anthropic.Model, BaseURL: p.Anthropic.BaseURL}
+		}
+	case "cerebras":
+		if p.Cerebras != nil {
+			auth = ProviderAuth{Method: keyAuth(p.Cerebras.APIKey, p.Cerebras.APIKeys), Model: p.Cerebras.Model, BaseURL: p.Cerebras.BaseURL}
+		}
+	case "openrouter":
+		if p.OpenRouter != nil {
+			auth = ProviderAuth{Method: keyAuth(p.OpenRouter.APIKey, p.OpenRouter.APIKeys), Model: p.OpenRouter.Model, BaseURL: p.OpenRouter.BaseURL}
+		}
+	case "gemini":
+		if p.Gemini != nil {
+			method := keyAuth(p.Gemini.APIKey, nil)
+			if method == "" && p.Gemini.AccessToken != "" {
+				method = "oauth"
+			}
+			auth = ProviderAuth{Method: method, Model: p.Gemini.Model, BaseURL: p.Gemini.BaseURL}
+		}
+	case "openai":
+		if p.OpenAI != nil {
+			auth = ProviderAuth{Method: keyAuth(p.OpenAI.APIKey, p.OpenAI.APIKeys), Model: p.OpenAI.Model, BaseURL: p.OpenAI.BaseURL}
+		}
+	case "qwen":
+		if p.Qwen != nil {
+			auth = ProviderAuth{Method: keyAuth(p.Qwen.APIKey, p.Qwen.APIKeys), Model: p.Qwen.Model, BaseURL: p.Qwen.BaseURL}
+		}
+	case "azure-openai":
+		if p.AzureOpenAI != nil && p.AzureOpenAI.Endpoint != "" && p.AzureOpenAI.Deployment != "" {
+			method := keyAuth(p.AzureOpenAI.APIKey, p.AzureOpenAI.APIKeys)
+			if method == "" && p.AzureOpenAI.UsesAAD() {
+				method = "entra_id"
+			}
+			auth = ProviderAuth{Method: method, Model: p.AzureOpenAI.Deployment, BaseURL: p.AzureOpenAI.Endpoint}
+		}
+	case "bedrock":
+		if p.Bedrock != nil && p.Bedrock.HasCredentials() {
+			auth = ProviderAuth{Method: "sigv4", Model: p.Bedrock.Model, BaseURL: p.Bedrock.Region}
+		}
+	case "mistral":
+		if p.Mistral != nil {
+			auth = ProviderAuth{Method: keyAuth(p.Mistral.APIKey, p.Mistral.APIKeys), Model: p.Mistral.Model, BaseURL: p.Mistral.BaseURL}
+		}
+	case "synthetic":
+		if p.Synthetic != nil {
+			auth = ProviderAuth{Method: keyAuth(p.Synthetic.APIKey, p.Synthetic.APIKeys), Model: p.Synthetic.Model, BaseURL: p.Synthetic.BaseURL}
+		}
+	case "vertex":
+		if p.Vertex != nil && p.Vertex.HasCredentials() {
+			method := "service_account"
+			if p.Vertex.AccessToken != "" {
+				method = "oauth"
+			}
+			auth = ProviderAuth{Method: method, Model: p.Vertex.Model, BaseURL: vertexEndpoint(*p.Vertex)}
+		}
+	case "lmstudio", "llamacpp":
+		local := p.LMStudio
+		if providerName == "llamacpp" {
+			local = p.LlamaCpp
+		}
+		// Local servers have a default for every field, so they only count
+		// as configured once enabled
+		if local != nil && local.BaseURL != "" && slices.Contains(p.Enabled, providerName) {
+			auth = ProviderAuth{Method: "local", Model: local.Model, BaseURL: local.BaseURL}
+		}
+	case "mock":
+		// Needs nothing, so like local servers it only counts once enabled
+		if slices.Contains(p.Enabled, providerName) {
+			auth = ProviderAuth{Method: "none", Model: NewMockClient(mockConfig(p)).GetModel()}
+		}
+	default:
+		if custom, ok := p.Custom[providerName]; ok && custom.Validate() == nil {
+			method := keyAuth(custom.APIKey, custom.APIKeys)
+			if method == "" && custom.APIKeyEnv != "" {
+				method = "api_key_env"
+			}
+			if method == "" {
+				method = "none" // Self-hosted endpoint without auth
+			}
+			auth = ProviderAuth{Method: method, Model: custom.Model, BaseURL: custom.BaseURL}
+		}
+	}
+	auth.Configured = auth.Method != ""
+	return auth
+}
+
+// firstKey returns the single key, or the first of the key list
+func firstKey(key string, keys []string) string {
+	if key != "" {
+		return key
+	}
+	if len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// ModelInfo describes a model as reported by its provider's models endpoint.
+// Zero values mean the provider didn't say.
+type ModelInfo struct {
+	ID            string `json:"id"`
+	Name          string `json:"name,omitempty"`
+	ContextWindow int    `json:"context_window,omitempty"` // Tokens the model accepts
+	MaxOutput     int    `json:"max_output,omitempty"`
+	ToolCalling   *bool  `json:"tool_calling,omitempty"` // nil if the provider doesn't report it
+
+	InputCost  float64 `json:"input_cost,omitempty"`  // USD per million prompt tokens
+	OutputCost float64 `json:"output_cost,omitempty"` // USD per million completion tokens
+}
+
+// ListModels queries the provider's models endpoint and returns the model IDs.
+// It is used for configuration diagnostics and does not go through the router.
+func ListModels(ctx context.Context, cfg *config.Config, providerName string) ([]string, error) {
+	models, err := DiscoverModels(ctx, cfg, providerName)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+	}
+	return ids, nil
+}
+
+// DiscoverModels queries the provider's models endpoint and returns its
+// models, sorted by ID, with whatever context size and tool calling details
+// the provider reports. See CachedModels for a cached variant.
+func DiscoverModels(ctx context.Context, cfg *config.Config, providerName string) ([]ModelInfo, error) {
+	p := cfg.Providers
+	var url string
+	headers := make(map[string]string)
+
+	switch providerName {
+	case "anthropic":
+		if p.Anthropic == nil || firstKey(p.Anthropic.APIKey, p.Anthropic.APIKeys) == "" {
+			return nil, fmt.Errorf("anthropic: no API key configured")
+		}
+		baseURL := p.Anthropic.BaseURL
+		if baseURL == "" {
+			baseURL = "https://api.anthropic.com"
+		}
+		url = strings.TrimSuffix(baseURL, "/") + "/v1/models"
+		headers["x-api-key"] = firstKey(p.Anthropic.APIKey, p.Anthropic.APIKeys)
+		headers["anthropic-version"] = "2023-06-01"
+	case "cerebras":
+		if p.Cerebras == nil || firstKey(p.Cerebras.APIKey, p.Cerebras.APIKeys) == "" {
+			return nil, fmt.Errorf("cerebras: no API key configured")
+		}
+		url = strings.TrimSuffix(p.Cerebras.BaseURL, "/") + "/v1/models"
+		headers["Authorization"] = "Bearer " + firstKey(p.Cerebras.APIKey, p.Cerebras.APIKeys)
+	case "openrouter":
+		if p.OpenRouter == nil || firstKey(p.OpenRouter.APIKey, p.OpenRouter.APIKeys) == "" {
+			return nil, fmt.Errorf("openrouter: no API key configured")
+		}
+		url = strings.TrimSuffix(p.OpenRouter.BaseURL, "/") + "/v1/models"
+		headers["Authorization"] = "Bearer " + firstKey(p.OpenRouter.APIKey, p.OpenRouter.APIKeys)
+	case "openai":
+		if p.OpenAI == nil || firstKey(p.OpenAI.APIKey, p.OpenAI.APIKeys) == "" {
+			return nil, fmt.Errorf("openai: no API key configured")
+		}
+		url = strings.TrimSuffix(p.OpenAI.BaseURL, "/") + "/models"
+		headers["Authorization"] = "Bearer " + firstKey(p.OpenAI.APIKey, p.OpenAI.APIKeys)
+	case "qwen":
+		if p.Qwen == nil || firstKey(p.Qwen.APIKey, p.Qwen.APIKeys) == "" {
+			return nil, fmt.Errorf("qwen: no API key configured")
+		}
+		url = qwenBaseURL(p.Qwen.BaseURL) + "/v1/models"
+		headers["Authorization"] = "Bearer " + firstKey(p.Qwen.APIKey, p.Qwen.APIKeys)
+	case "gemini":
+		if p.Gemini == nil || p.Gemini.APIKey == "" {
+			return nil, fmt.Errorf("gemini: model listing requires an API key (OAuth uses the Cloud Code API)")
+		}
+		baseURL := p.Gemini.BaseURL
+		if baseURL == "" {
+			baseURL = standardGeminiBaseURL
+		}
+		url = strings.TrimSuffix(baseURL, "/") + "/models"
+		headers["x-goog-api-key"] = p.Gemini.APIKey
+	case "azure-openai":
+		if p.AzureOpenAI == nil || firstKey(p.AzureOpenAI.APIKey, p.AzureOpenAI.APIKeys) == "" || p.AzureOpenAI.Endpoint == "" {
+			return nil, fmt.Errorf("azure-openai: model listing requires an endpoint and API key")
+		}
+		url = fmt.Sprintf("%s/openai/models?api-version=%s", strings.TrimSuffix(p.AzureOpenAI.Endpoint, "/"), p.AzureOpenAI.APIVersion)
+		headers["api-key"] = firstKey(p.AzureOpenAI.APIKey, p.AzureOpenAI.APIKeys)
+	case "bedrock":
+		if p.Bedrock == nil || !p.Bedrock.HasCredentials() {
+			return nil, fmt.Errorf("bedrock: no region or AWS credentials configured")
+		}
+		// Model listing is on the control plane, not bedrock-runtime
+		url = fmt.Sprintf("https://bedrock.%s.amazonaws.com/foundation-models", p.Bedrock.Region)
+	case "mistral":
+		if p.Mistral == nil || firstKey(p.Mistral.APIKey, p.Mistral.APIKeys) == "" {
+			return nil, fmt.Errorf("mistral: no API key configured")
+		}
+		url = strings.TrimSuffix(p.Mistral.BaseURL, "/") + "/models"
+		headers["Authorization"] = "Bearer " + firstKey(p.Mistral.APIKey, p.Mistral.APIKeys)
+	case "synthetic":
+		if p.Synthetic == nil || firstKey(p.Synthetic.APIKey, p.Synthetic.APIKeys) == "" {
+			return nil, fmt.Errorf("synthetic: no Hugging Face token configured")
+		}
+		baseURL := p.Synthetic.BaseURL
+		if baseURL == "" {
+			baseURL = defaultSyntheticBaseURL
+		}
+		url = strings.TrimSuffix(baseURL, "/") + "/models"
+		headers["Authorization"] = "Bearer " + firstKey(p.Synthetic.APIKey, p.Synthetic.APIKeys)
+	case "vertex":
+		if p.Vertex == nil || !p.Vertex.HasCredentials() {
+			return nil, fmt.Errorf("vertex: no project, region or credentials configured")
+		}
+		token, err := vertexAccessToken(*p.Vertex)
+		if err != nil {
+			return nil, err
+		}
+		// Publisher models are only listed on the v1beta1 API
+		url = vertexEndpoint(*p.Vertex) + "/v1beta1/publishers/google/models"
+		headers["Authorization"] = "Bearer " + token
+		headers["x-goog-user-project"] = p.Vertex.ProjectID
+	case "lmstudio", "llamacpp":
+		local := p.LMStudio
+		if providerName == "llamacpp" {
+			local = p.LlamaCpp
+		}
+		if local == nil || local.BaseURL == "" {
+			return nil, fmt.Errorf("%s: no base_url configured", providerName)
+		}
+		url = strings.TrimSuffix(localBaseURL(local.BaseURL), "/") + "/models"
+		if local.APIKey != "" {
+			headers["Authorization"] = "Bearer " + local.APIKey
+		}
+	case "mock":
+		return []ModelInfo{{ID: NewMockClient(mockConfig(p)).GetModel(), ToolCalling: boolPtr(true)}}, nil
+	default:
+		custom, ok := p.Custom[providerName]
+		if !ok {
+			return nil, fmt.Errorf("%s: model listing not supported", providerName)
+		}
+		if err := custom.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", providerName, err)
+		}
+		url = strings.TrimSuffix(custom.BaseURL, "/") + "/models"
+		for k, v := range custom.Headers {
+			headers[k] = v
+		}
+		if keys := custom.GetAllAPIKeys(); len(keys) > 0 {
+			headers["Authorization"] = "Bearer " + keys[0]
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if providerName == "bedrock" {
+		creds := awsCredentials{AccessKeyID: p.Bedrock.AccessKeyID, SecretAccessKey: p.Bedrock.SecretAccessKey, SessionToken: p.Bedrock.SessionToken}
+		signSigV4(req, nil, creds, p.Bedrock.Region, "bedrock", time.Now())
+	}
+	return FetchModels(providerName, req)
+}
+
+// FetchModels sends a prepared models endpoint request and parses the
+// response. It understands the OpenAI-compatible, Anthropic, Gemini, Bedrock
+// and Vertex formats, including the context sizes and capabilities reported
+// by OpenRouter, Mistral, Gemini and llama.cpp.
+func FetchModels(providerName string, req *http.Request) ([]ModelInfo, error) {
+	logger.Debugf("Listing %s models at %s", providerName, req.URL.Redacted())
+
+	client := &http.Client{Timeout: time.Duration(config.DefaultAPITimeout) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s models API error: %d - %s", providerName, resp.StatusCode, string(body))
+	}
+
+	models, err := parseModels(body)
+	if err != nil {
+		return nil, err
+	}
+	// Every model Anthropic lists takes tools
+	if providerName == "anthropic" {
+		for i := range models {
+			models[i].ToolCalling = boolPtr(true)
+		}
+	}
+	return models, nil
+}
+
+// parseModels parses a models response. OpenAI-compatible and Anthropic APIs
+// use {"data":[{"id":...}]}, Gemini uses {"models":[{"name":"models/..."}]},
+// Bedrock uses {"modelSummaries":[{"modelId":...}]} and Vertex uses
+// {"publisherModels":[{"name":"publishers/google/models/..."}]}.
+func parseModels(body []byte) ([]ModelInfo, error) {
+	var parsed struct {
+		Data []struct {
+			ID               string `json:"id"`
+			Name             string `json:"name"`               // OpenRouter
+			DisplayName      string `json:"display_name"`       // Anthropic
+			ContextLength    int    `json:"context_length"`     // OpenRouter
+			ContextWindow    int    `json:"context_window"`     // Groq and others
+			MaxContextLength int    `json:"max_context_length"` // Mistral
+			TopProvider      struct {
+				MaxCompletionTokens int `json:"max_completion_tokens"`
+			} `json:"top_provider"` // OpenRouter
+			SupportedParameters []string `json:"supported_parameters"` // OpenRouter
+			// Mistral reports capabilities as an object, llama.cpp reports
+			// model metadata and OpenRouter per-token prices as strings;
+			// other servers use these names for other shapes, so they are
+			// decoded leniently
+			Capabilities json.RawMessage `json:"capabilities"`
+			Meta         json.RawMessage `json:"meta"`
+			Pricing      json.RawMessage `json:"pricing"`
+		} `json:"data"`
+		Models []struct {
+			Name                       string   `json:"name"`
+			DisplayName                string   `json:"displayName"`
+			InputTokenLimit            int      `json:"inputTokenLimit"`
+			OutputTokenLimit           int      `json:"outputTokenLimit"`
+			SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+		ModelSummaries []struct {
+			ModelID   string `json:"modelId"`
+			ModelName string `json:"modelName"`
+		} `json:"modelSummaries"`
+		PublisherModels []struct {
+			Name string `json:"name"`
+		} `json:"publisherModels"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse models response: %w", err)
+	}
+
+	var models []ModelInfo
+	for _, m := range parsed.Data {
+		info := ModelInfo{
+			ID:            m.ID,
+			Name:          cmp.Or(m.DisplayName, m.Name),
+			ContextWindow: cmp.Or(m.ContextLength, m.ContextWindow, m.MaxContextLength),
+			MaxOutput:     m.TopProvider.MaxCompletionTokens,
+		}
+		if m.SupportedParameters != nil {
+			info.ToolCalling = boolPtr(slices.Contains(m.SupportedParameters, "tools"))
+		}
+		var capabilities struct {
+			FunctionCalling *bool `json:"function_calling"`
+		}
+		if json.Unmarshal(m.Capabilities, &capabilities) == nil && capabilities.FunctionCalling != nil {
+			info.ToolCalling = capabilities.FunctionCalling
+		}
+		var meta struct {
+			NCtxTrain int `json:"n_ctx_train"`
+		}
+		if info.ContextWindow == 0 && json.Unmarshal(m.Meta, &meta) == nil {
+			info.ContextWindow = meta.NCtxTrain
+		}
+		var pricing struct {
+			Prompt     string `json:"prompt"`
+			Completion string `json:"completion"`
+		}
+		if json.Unmarshal(m.Pricing, &pricing) == nil {
+			info.InputCost = perMillion(pricing.Prompt)
+			info.OutputCost = perMillion(pricing.Completion)
+		}
+		models = append(models, info)
+	}
+	for _, m := range parsed.Models {
+		info := ModelInfo{
+			ID:            strings.TrimPrefix(m.Name, "models/"),
+			Name:          m.DisplayName,
+			ContextWindow: m.InputTokenLimit,
+			MaxOutput:     m.OutputTokenLimit,
+		}
+		if m.SupportedGenerationMethods != nil {
+			// Gemma and embedding models don't take function declarations
+			info.ToolCalling = boolPtr(strings.HasPrefix(info.ID, "gemini-") &&
+				slices.Contains(m.SupportedGenerationMethods, "generateContent"))
+		}
+		models = append(models, info)
+	}
+	for _, m := range parsed.ModelSummaries {
+		models = append(models, ModelInfo{ID: m.ModelID, Name: m.ModelName})
+	}
+	for _, m := range parsed.PublisherModels {
+		models = append(models, ModelInfo{ID: strings.TrimPrefix(m.Name, "publishers/google/models/")})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// perMillion converts a per-token price to USD per million tokens; prices
+// that don't parse, and OpenRouter's -1 for variable pricing, are unknown
+func perMillion(perToken string) float64 {
+	price, err := strconv.ParseFloat(perToken, 64)
+	if err != nil || price < 0 {
+		return 0
+	}
+	return price * 1e6
+}
+
+// mockConfig returns the mock provider's configuration; it works unconfigured
+func mockConfig(p config.ProvidersConfig) config.MockConfig {
+	if p.Mock == nil {
+		return config.MockConfig{}
+	}
+	return *p.Mock
+}
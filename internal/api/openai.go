@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// defaultOpenAIBaseURL is OpenAI's API
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// NewOpenAIClient returns a client for OpenAI's chat completions endpoint,
+// or another server speaking the same API at the configured base URL
+func NewOpenAIClient(cfg config.OpenAIConfig) *CustomClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	c := NewCustomClient("openai", config.CustomProviderConfig{
+		BaseURL: baseURL,
+		Model:   presetModel(cfg.Model, cfg.Models),
+	})
+	c.keyManager = NewAPIKeyManager("OpenAI", cfg.GetAllAPIKeys(), config.KeyBalancing{})
+	c.modelSelector = newConfiguredModelSelector("OpenAI", cfg.Model, cfg.Models, cfg.ModelStrategy)
+	return c
+}
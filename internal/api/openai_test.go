@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestOpenAIClientRotatesModels(t *testing.T) {
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("request = %s with %q, want chat completions with the key", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req CerebrasRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		models = append(models, req.Model)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"print(1)"}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.OpenAIConfig{
+		APIKey:        "sk-test",
+		BaseURL:       server.URL + "/v1",
+		Models:        []string{t.Name() + "-gpt-4o", t.Name() + "-gpt-4o-mini"},
+		ModelStrategy: "round-robin",
+	}
+	for range 2 {
+		client := NewOpenAIClient(cfg)
+		if _, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil); err != nil {
+			t.Fatalf("GenerateCode() error = %v", err)
+		}
+		if client.GetModel() != models[len(models)-1] {
+			t.Errorf("GetModel() = %q, want the model just used, %q", client.GetModel(), models[len(models)-1])
+		}
+	}
+	if len(models) != 2 || models[0] == models[1] {
+		t.Errorf("models = %q, want both configured models in turn", models)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,6 +13,7 @@ import (
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
 	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
 	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
 )
 // OpenRouterClient handles OpenRouter API interactions
@@ -22,27 +22,18 @@ type OpenRouterClient struct {
 	client        *http.Client
 	keyManager    *APIKeyManager
 	modelSelector *ModelSelector
+	selectedModel string // Chosen by modelSelector, before any :free suffix
 	lastUsedModel string
 	lastUsage     *types.Usage
 	mutex         sync.RWMutex
 }
 // NewOpenRouterClient creates a new OpenRouter client
 func NewOpenRouterClient(cfg config.OpenRouterConfig) *OpenRouterClient {
-	models := cfg.Models
-	if len(models) == 0 && cfg.Model != "" {
-		models = []string{cfg.Model}
-	}
-	strategy := cfg.ModelStrategy
-	if strategy == "" {
-		strategy = "failover"
-	}
 	return &OpenRouterClient{
 		config:        cfg,
-		keyManager:    NewAPIKeyManager("OpenRouter", cfg.GetAllAPIKeys()),
-		modelSelector: NewModelSelector(models, strategy),
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		keyManager:    NewAPIKeyManager("OpenRouter", cfg.GetAllAPIKeys(), cfg.KeyBalancing),
+		modelSelector: newConfiguredModelSelector("OpenRouter", cfg.Model, cfg.Models, cfg.ModelStrategy),
+		client: &http.Client{},
 	}
 }
 // GenerateCode generates code using the OpenRouter API with automatic failover
@@ -51,7 +42,16 @@ func (c *OpenRouterClient) GenerateCode(ctx context.Context, prompt, contextStr,
 		return nil, fmt.Errorf("no OpenRouter API key configured")
 	}
 
-	// Check rate limits before attempting API call
+	// Check rate limits before attempting API call: a request rate limit
+	// the last response said is used up, then the (cached) credit limits
+	if wait, window := rateLimitWait(c.keyManager.GetCurrentKey(), time.Now()); wait > 0 {
+		return nil, &APIError{
+			Provider:   "OpenRouter",
+			StatusCode: http.StatusTooManyRequests,
+			Message:    fmt.Sprintf("request rate limit reached (%d requests), resets in %s", window.Limit, wait.Round(time.Second)),
+			RetryAfter: wait,
+		}
+	}
 	rateLimits, err := c.GetRateLimits(ctx)
 	if err != nil {
 		logger.Warnf("OpenRouter: Failed to check rate limits (continuing anyway): %v", err)
@@ -69,11 +69,17 @@ func (c *OpenRouterClient) GenerateCode(ctx context.Context, prompt, contextStr,
 	}
 
 	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
-	fullPrompt := c.buildFullPrompt(prompt, contextStr, outputFile, detectedLanguage, contextFiles)
-	requestData, err := c.prepareRequest(fullPrompt, detectedLanguage)
+	fullPrompt := outboundPrompt(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+	requestData, err := c.prepareRequest(fullPrompt, prompts.System(ctx, "openrouter", detectedLanguage))
 	if err != nil {
 		return nil, err
 	}
+	// Stream when the caller wants to hear of the first token, e.g. a race
+	requestData.Stream = firstTokenFunc(ctx) != nil
+	requestData.ResponseFormat = responseFormat(ctx)
+	if effort := reasoningEffort(ctx, c.config.ReasoningEffort); effort != "" {
+		requestData.Reasoning = &OpenRouterReasoning{Effort: effort}
+	}
 	code, err := c.keyManager.ExecuteWithFailover(func(apiKey string) (string, error) {
 		response, err := c.makeAPICallWithKey(ctx, requestData, apiKey)
 		if err != nil {
@@ -91,6 +97,7 @@ func (c *OpenRouterClient) GenerateCode(ctx context.Context, prompt, contextStr,
 			c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
 		return cleanedContent, nil
 	})
+	c.modelSelector.Record(c.selectedModel, err)
 	if err != nil {
 		return nil, err
 	}
@@ -105,51 +112,13 @@ func (c *OpenRouterClient) GenerateCode(ctx context.Context, prompt, contextStr,
 	}
 	return result, nil
 }
-// buildFullPrompt builds the complete prompt including context and existing content
-func (c *OpenRouterClient) buildFullPrompt(prompt, contextStr, outputFile, detectedLanguage string, contextFiles []string) string {
-	var parts []string
-	if len(contextFiles) > 0 {
-		filteredContextFiles := c.filterContextFiles(contextFiles, outputFile)
-		if len(filteredContextFiles) > 0 {
-			contextContent := "Context Files:\n"
-			for _, contextFile := range filteredContextFiles {
-				if content, err := utils.ReadFileContent(contextFile); err == nil && content != "" {
-					contextLang := utils.GetLanguageFromFile(contextFile, nil)
-					contextContent += fmt.Sprintf("\nFile: %s\n```%s\n%s\n```\n", contextFile, contextLang, content)
-				} else {
-					logger.Warnf("Could not read context file %s: %v", contextFile, err)
-				}
-			}
-			parts = append(parts, contextContent)
-		}
-	}
-	if contextStr != "" {
-		parts = append(parts, fmt.Sprintf("Context: %s", contextStr))
-	}
-	if existingContent, err := utils.ReadFileContent(outputFile); err == nil && existingContent != "" {
-		parts = append(parts, fmt.Sprintf("Existing file content:\n```%s\n%s\n```\n", detectedLanguage, existingContent))
-	}
-	parts = append(parts, fmt.Sprintf("Generate %s code for: %s", detectedLanguage, prompt))
-	return strings.Join(parts, "\n\n")
-}
-// filterContextFiles filters out the output file from context files
-func (c *OpenRouterClient) filterContextFiles(contextFiles []string, outputFile string) []string {
-	var filtered []string
-	for _, file := range contextFiles {
-		contextAbs := filepath.Clean(file)
-		outputAbs := filepath.Clean(outputFile)
-		if contextAbs != outputAbs {
-			filtered = append(filtered, file)
-		}
-	}
-	return filtered
-}
 // prepareRequest prepares the API request payload
-func (c *OpenRouterClient) prepareRequest(fullPrompt, detectedLanguage string) (OpenRouterRequest, error) {
-	modelName, err := c.modelSelector.SelectModel()
+func (c *OpenRouterClient) prepareRequest(fullPrompt, systemPrompt string) (OpenRouterRequest, error) {
+	selected, err := c.modelSelector.SelectModel()
 	if err != nil {
 		return OpenRouterRequest{}, fmt.Errorf("failed to select model: %w", err)
 	}
+	modelName := selected
 	if c.config.FreeOnly && !strings.HasSuffix(modelName, ":free") {
 		modelName = modelName + ":free"
 		logger.Debugf("OpenRouter: free_only enabled, using model: %s", modelName)
@@ -157,6 +126,7 @@ func (c *OpenRouterClient) prepareRequest(fullPrompt, detectedLanguage string) (
 		logger.Debugf("OpenRouter: selected model: %s (strategy: %s)", modelName, c.config.ModelStrategy)
 	}
 	c.mutex.Lock()
+	c.selectedModel = selected
 	c.lastUsedModel = modelName
 	c.mutex.Unlock()
 	requestData := OpenRouterRequest{
@@ -164,7 +134,7 @@ func (c *OpenRouterClient) prepareRequest(fullPrompt, detectedLanguage string) (
 		Messages: []OpenRouterMessage{
 			{
 				Role:    "system",
-				Content: fmt.Sprintf("You are an expert programmer. Generate ONLY clean, functional code in %s with no explanations, comments about the code generation process, or markdown formatting. Include necessary imports and ensure the code is ready to run. When modifying existing files, preserve the structure and style while implementing the requested changes. Output raw code only. Never use markdown code blocks.", detectedLanguage),
+				Content: systemPrompt,
 			},
 			{
 				Role:    "user",
@@ -194,11 +164,23 @@ func (c *OpenRouterClient) makeAPICallWithKey(ctx context.Context, requestData O
 	req.Header.Set("HTTP-Referer", c.config.SiteURL)
 	req.Header.Set("X-Title", c.config.SiteName)
 	logger.Debugf("Making OpenRouter API call to %s", url)
-	resp, err := c.client.Do(req)
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	recordRateLimitHeaders(apiKey, resp.Header, time.Now())
+	if requestData.Stream && isEventStream(resp) {
+		content, usage, err := readChatStream(resp.Body, firstTokenFunc(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &OpenRouterResponse{
+			Model:   requestData.Model,
+			Choices: []OpenRouterChoice{{Message: OpenRouterMessage{Role: "assistant", Content: content}}},
+			Usage:   OpenRouterUsage{PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens, TotalTokens: usage.TotalTokens},
+		}, nil
+	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
@@ -206,9 +188,9 @@ func (c *OpenRouterClient) makeAPICallWithKey(ctx context.Context, requestData O
 	if resp.StatusCode != http.StatusOK {
 		var errorResponse OpenRouterErrorResponse
 		if parseErr := json.Unmarshal(body, &errorResponse); parseErr == nil {
-			return nil, fmt.Errorf("OpenRouter API error: %d - %s", resp.StatusCode, errorResponse.Error.Message)
+			return nil, newAPIError("OpenRouter", resp, errorResponse.Error.Message)
 		}
-		return nil, fmt.Errorf("OpenRouter API error: %d - %s", resp.StatusCode, string(body))
+		return nil, newAPIError("OpenRouter", resp, string(body))
 	}
 	var response OpenRouterResponse
 	if err := json.Unmarshal(body, &response); err != nil {
@@ -228,6 +210,14 @@ func (c *OpenRouterClient) GetLastUsedModel() string {
 	defer c.mutex.RUnlock()
 	return c.lastUsedModel
 }
+// GetModel returns the model used in the last API call, or the configured
+// one before the first
+func (c *OpenRouterClient) GetModel() string {
+	if model := c.GetLastUsedModel(); model != "" {
+		return model
+	}
+	return c.config.Model
+}
 // OpenRouterRequest represents the request payload for OpenRouter API
 type OpenRouterRequest struct {
 	Model          string               `json:"model"`
@@ -237,6 +227,13 @@ type OpenRouterRequest struct {
 	HTTPUserAgent  string               `json:"x-title,omitempty"`
 	Temperature    float64              `json:"temperature,omitempty"`
 	MaxTokens      int                  `json:"max_tokens,omitempty"`
+	ResponseFormat *types.ResponseFormat `json:"response_format,omitempty"`
+	Reasoning      *OpenRouterReasoning  `json:"reasoning,omitempty"`
+}
+// OpenRouterReasoning is OpenRouter's unified reasoning control, which it
+// translates for each model (reasoning_effort for OpenAI's)
+type OpenRouterReasoning struct {
+	Effort string `json:"effort"`
 }
 
 // OpenRouterMessage represents a message in the conversation
@@ -294,7 +291,9 @@ type OpenRouterRateLimits struct {
 	} `json:"rate_limit,omitempty"` // Rate limit information
 }
 
-// GetRateLimits queries the OpenRouter API for current rate limit information
+// GetRateLimits returns the key's credit and rate limit information from the
+// OpenRouter API, reusing a response younger than the configured
+// rate_limit_ttl
 func (c *OpenRouterClient) GetRateLimits(ctx context.Context) (*OpenRouterRateLimits, error) {
 	if c.keyManager == nil {
 		return nil, fmt.Errorf("no OpenRouter API key configured")
@@ -306,6 +305,14 @@ func (c *OpenRouterClient) GetRateLimits(ctx context.Context) (*OpenRouterRateLi
 		return nil, fmt.Errorf("no valid API key available")
 	}
 
+	ttl := c.config.RateLimitTTL
+	if ttl <= 0 {
+		ttl = defaultRateLimitTTL
+	}
+	if rateLimits, ok := cachedRateLimits(apiKey, ttl, time.Now()); ok {
+		return rateLimits, nil
+	}
+
 	// Build the request URL
 	url := c.config.BaseURL + "/v1/key"
 
@@ -320,7 +327,7 @@ func (c *OpenRouterClient) GetRateLimits(ctx context.Context) (*OpenRouterRateLi
 
 	logger.Debugf("Querying OpenRouter rate limits at %s", url)
 
-	resp, err := c.client.Do(req)
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -332,7 +339,7 @@ func (c *OpenRouterClient) GetRateLimits(ctx context.Context) (*OpenRouterRateLi
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OpenRouter rate limits API error: %d - %s", resp.StatusCode, string(body))
+		return nil, newAPIError("OpenRouter rate limits", resp, string(body))
 	}
 
 	var rateLimits OpenRouterRateLimits
@@ -346,5 +353,6 @@ func (c *OpenRouterClient) GetRateLimits(ctx context.Context) (*OpenRouterRateLi
 		rateLimits.Limit,
 		rateLimits.IsFreeTier)
 
+	storeRateLimits(apiKey, &rateLimits, time.Now())
 	return &rateLimits, nil
 }
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRateLimitTTL is how long a /key response is reused by default
+const defaultRateLimitTTL = time.Minute
+
+// openRouterLimitState is what is known of one key's limits: the /key
+// response, reused until it is ttl old, and the request rate limit reported
+// in the X-RateLimit-* headers of the latest completion
+type openRouterLimitState struct {
+	limits  *OpenRouterRateLimits
+	fetched time.Time
+
+	window headerRateLimit
+}
+
+// headerRateLimit is a request rate limit read from response headers
+type headerRateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// openRouterLimits is shared by all clients, since the router creates a new
+// client for every request; it is keyed by API key
+var openRouterLimits = struct {
+	sync.Mutex
+	states map[string]*openRouterLimitState
+}{states: make(map[string]*openRouterLimitState)}
+
+// cachedRateLimits returns apiKey's /key response if it is younger than ttl
+func cachedRateLimits(apiKey string, ttl time.Duration, now time.Time) (*OpenRouterRateLimits, bool) {
+	openRouterLimits.Lock()
+	defer openRouterLimits.Unlock()
+	state, ok := openRouterLimits.states[apiKey]
+	if !ok || state.limits == nil || now.Sub(state.fetched) >= ttl {
+		return nil, false
+	}
+	limits := *state.limits
+	return &limits, true
+}
+
+// storeRateLimits caches apiKey's /key response
+func storeRateLimits(apiKey string, limits *OpenRouterRateLimits, now time.Time) {
+	openRouterLimits.Lock()
+	defer openRouterLimits.Unlock()
+	state := limitState(apiKey)
+	stored := *limits
+	state.limits = &stored
+	state.fetched = now
+}
+
+// recordRateLimitHeaders keeps the request rate limit a response reported
+// for apiKey. Responses without the headers leave the last state.
+func recordRateLimitHeaders(apiKey string, header http.Header, now time.Time) {
+	window, ok := parseRateLimitHeaders(header, now)
+	if !ok {
+		return
+	}
+	openRouterLimits.Lock()
+	defer openRouterLimits.Unlock()
+	limitState(apiKey).window = window
+}
+
+// rateLimitWait returns how long apiKey must wait for its request rate
+// limit to reset, or 0 if the last response left requests remaining
+func rateLimitWait(apiKey string, now time.Time) (time.Duration, headerRateLimit) {
+	openRouterLimits.Lock()
+	defer openRouterLimits.Unlock()
+	state, ok := openRouterLimits.states[apiKey]
+	if !ok || state.window.Limit == 0 || state.window.Remaining > 0 || !state.window.Reset.After(now) {
+		return 0, headerRateLimit{}
+	}
+	return state.window.Reset.Sub(now), state.window
+}
+
+// limitState returns apiKey's state, creating it (caller holds the lock)
+func limitState(apiKey string) *openRouterLimitState {
+	state, ok := openRouterLimits.states[apiKey]
+	if !ok {
+		state = &openRouterLimitState{}
+		openRouterLimits.states[apiKey] = state
+	}
+	return state
+}
+
+// parseRateLimitHeaders reads X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset. OpenRouter sends the reset as Unix milliseconds; Unix
+// seconds and seconds from now are accepted too.
+func parseRateLimitHeaders(header http.Header, now time.Time) (headerRateLimit, bool) {
+	limit, err := strconv.Atoi(strings.TrimSpace(header.Get("X-RateLimit-Limit")))
+	if err != nil || limit <= 0 {
+		return headerRateLimit{}, false
+	}
+	remaining, err := strconv.Atoi(strings.TrimSpace(header.Get("X-RateLimit-Remaining")))
+	if err != nil {
+		return headerRateLimit{}, false
+	}
+
+	window := headerRateLimit{Limit: limit, Remaining: remaining}
+	if reset, err := strconv.ParseInt(strings.TrimSpace(header.Get("X-RateLimit-Reset")), 10, 64); err == nil {
+		switch {
+		case reset > 1e12:
+			window.Reset = time.UnixMilli(reset)
+		case reset > 1e9:
+			window.Reset = time.Unix(reset, 0)
+		default:
+			window.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return window, true
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestOpenRouterRateLimits(t *testing.T) {
+	var keyCalls, completions atomic.Int32
+	var remaining atomic.Int32
+	remaining.Store(5)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/key":
+			keyCalls.Add(1)
+			_, _ = w.Write([]byte(`{"limit": null, "limit_remaining": null, "usage": 1.5, "is_free_tier": false}`))
+		case config.OpenRouterAPIEndpoint:
+			completions.Add(1)
+			w.Header().Set("X-RateLimit-Limit", "20")
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining.Load())))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10))
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"package main\n"}}],"usage":{"total_tokens":3}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	newClient := func() *OpenRouterClient {
+		// Clients are created per request; the limits must outlive them
+		return NewOpenRouterClient(config.OpenRouterConfig{APIKey: "sk-or-" + t.Name(), Model: "test/model", BaseURL: server.URL})
+	}
+	generate := func() error {
+		_, err := newClient().GenerateCode(t.Context(), "write main", "", "main.go", nil, nil)
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := generate(); err != nil {
+			t.Fatalf("GenerateCode() error = %v", err)
+		}
+	}
+	if keyCalls.Load() != 1 {
+		t.Errorf("/v1/key called %d times for 3 generations, want 1", keyCalls.Load())
+	}
+
+	// The headers of the last completion say the window is used up
+	remaining.Store(0)
+	if err := generate(); err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	err := generate()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter <= 0 {
+		t.Fatalf("GenerateCode() with no requests remaining = %v, want a 429 with Retry-After", err)
+	}
+	if completions.Load() != 4 {
+		t.Errorf("%d completions requested, want 4", completions.Load())
+	}
+}
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, tc := range []struct {
+		reset string
+		want  time.Time
+	}{
+		{"1700000060000", now.Add(time.Minute)},
+		{"1700000060", now.Add(time.Minute)},
+		{"60", now.Add(time.Minute)},
+	} {
+		header := http.Header{}
+		header.Set("X-RateLimit-Limit", "20")
+		header.Set("X-RateLimit-Remaining", "3")
+		header.Set("X-RateLimit-Reset", tc.reset)
+		window, ok := parseRateLimitHeaders(header, now)
+		if !ok || window.Limit != 20 || window.Remaining != 3 || !window.Reset.Equal(tc.want) {
+			t.Errorf("reset %s: got %+v, %v", tc.reset, window, ok)
+		}
+	}
+	if _, ok := parseRateLimitHeaders(http.Header{}, now); ok {
+		t.Error("parsed a response without rate limit headers")
+	}
+}
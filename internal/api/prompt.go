@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/transform"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// outboundPromptParts builds a generation prompt as the context files block,
+// which clients with prompt caching send as a separate cacheable part, and
+// the request: the additional context, the existing content of outputFile
+// and the task. Compliance transformations (e.g. PII scrubbing) are applied
+// to both, since they are about to leave the machine.
+func outboundPromptParts(ctx context.Context, prompt, contextStr, outputFile, detectedLanguage string, contextFiles []string) (contextBlock, request string) {
+	contextBlock = contextFilesSection(ctx, prompt, contextFiles, outputFile)
+
+	var parts []string
+	if contextStr != "" {
+		parts = append(parts, fmt.Sprintf("Context: %s", contextStr))
+	}
+	if raw, err := utils.ReadFileContentCached(outputFile); err == nil && raw != "" {
+		existingContent, _ := utils.DecodeText(raw)
+		parts = append(parts, fmt.Sprintf("Existing file content:\n```%s\n%s\n```\n", detectedLanguage, existingContent))
+	}
+	parts = append(parts, fmt.Sprintf("Generate %s code for: %s", detectedLanguage, prompt))
+
+	return transform.Outbound(ctx, contextBlock), transform.Outbound(ctx, strings.Join(parts, "\n\n"))
+}
+
+// outboundPrompt builds a generation prompt as one message, context files
+// first
+func outboundPrompt(ctx context.Context, prompt, contextStr, outputFile, detectedLanguage string, contextFiles []string) string {
+	contextBlock, request := outboundPromptParts(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+	if contextBlock == "" {
+		return request
+	}
+	return contextBlock + "\n\n" + request
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// Provider-side prompt caching. The context files block is the part of a
+// prompt that repeats across iterative edits of the same codebase, so it is
+// sent where the provider can cache it and bill later reads at a discount.
+const (
+	// anthropicMinCacheTokens is the shortest prefix Anthropic caches
+	anthropicMinCacheTokens = 1024
+	// anthropicPromptCachingBeta is the beta header for cache_control
+	anthropicPromptCachingBeta = "prompt-caching-2024-07-31"
+
+	// geminiMinCacheTokens is the smallest content Gemini caches
+	geminiMinCacheTokens = 4096
+	// geminiCacheTTL is how long a Gemini cachedContent lives
+	geminiCacheTTL = 5 * time.Minute
+	// geminiCacheMargin is how long before expiry a cachedContent stops
+	// being used, so it doesn't expire mid-request
+	geminiCacheMargin = 30 * time.Second
+)
+
+// estimatePromptTokens approximates the tokens of text at four bytes per token
+func estimatePromptTokens(text string) int {
+	return len(text) / 4
+}
+
+// geminiCache maps a model and context block to the cachedContent holding
+// it. The router creates a client per request, so the map is shared.
+var geminiCache = struct {
+	sync.Mutex
+	entries map[string]geminiCacheEntry
+}{entries: make(map[string]geminiCacheEntry)}
+
+// geminiCacheEntry is a cachedContent and when it expires
+type geminiCacheEntry struct {
+	name    string
+	expires time.Time
+}
+
+// geminiCacheKey identifies the context block text for model at baseURL
+func geminiCacheKey(baseURL, model, text string) string {
+	sum := sha256.Sum256([]byte(baseURL + "\x00" + model + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedContent returns the name of a cachedContent holding the context
+// block text for model, creating one if there is none that is still fresh.
+// It returns "" if the content could not be cached; the caller then sends
+// it inline.
+func (c *GeminiClient) cachedContent(ctx context.Context, model, text string) string {
+	key := geminiCacheKey(c.getBaseURL(), model, text)
+	now := time.Now()
+
+	geminiCache.Lock()
+	entry, ok := geminiCache.entries[key]
+	for k, e := range geminiCache.entries {
+		if !e.expires.After(now) {
+			delete(geminiCache.entries, k)
+		}
+	}
+	geminiCache.Unlock()
+	if ok && entry.expires.After(now.Add(geminiCacheMargin)) {
+		logger.Debugf("Gemini: Reusing cached context %s", entry.name)
+		return entry.name
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "cachedContents", CachedContentRequest{
+		Model: "models/" + model,
+		Contents: []Content{
+			{Role: "user", Parts: []Part{{Text: text}}},
+		},
+		TTL: fmt.Sprintf("%ds", int(geminiCacheTTL.Seconds())),
+	})
+	if err != nil {
+		logger.Debugf("Gemini: Could not cache context: %v", err)
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		logger.Debugf("Gemini: Could not cache context: %v", newAPIError("Gemini", resp, string(body)))
+		return ""
+	}
+	var cached CachedContentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cached); err != nil || cached.Name == "" {
+		logger.Debugf("Gemini: Could not parse cachedContent response: %v", err)
+		return ""
+	}
+	expires := cached.ExpireTime
+	if expires.IsZero() {
+		expires = now.Add(geminiCacheTTL)
+	}
+
+	geminiCache.Lock()
+	geminiCache.entries[key] = geminiCacheEntry{name: cached.Name, expires: expires}
+	geminiCache.Unlock()
+	logger.Debugf("Gemini: Cached context as %s until %s", cached.Name, expires.Format(time.RFC3339))
+	return cached.Name
+}
+
+// CachedContentRequest creates a Gemini cachedContent
+type CachedContentRequest struct {
+	Model    string    `json:"model"`
+	Contents []Content `json:"contents"`
+	TTL      string    `json:"ttl"`
+}
+
+// CachedContentResponse is a created Gemini cachedContent
+type CachedContentResponse struct {
+	Name       string    `json:"name"`
+	ExpireTime time.Time `json:"expireTime"`
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// writeContextFile writes a Go file of about size bytes to a temp dir
+func writeContextFile(t *testing.T, size int) string {
+	path := filepath.Join(t.TempDir(), "lib.go")
+	body := "package lib\n" + strings.Repeat("// shared context line\n", size/23)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAnthropicMarksLargeContextForCaching(t *testing.T) {
+	var beta string
+	var blocks []AnthropicContentBlock
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		beta = r.Header.Get("anthropic-beta")
+		var req AnthropicRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		blocks = req.Messages[0].Content
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"package main"}],`+
+			`"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":2000}}`)
+	}))
+	defer srv.Close()
+	client := NewAnthropicClient(config.AnthropicConfig{APIKey: "cache-key", BaseURL: srv.URL})
+
+	result, err := client.GenerateCode(t.Context(), "main", "", "main.go", nil, []string{writeContextFile(t, 8000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 || blocks[0].CacheControl == nil || blocks[1].CacheControl != nil || beta != anthropicPromptCachingBeta {
+		t.Errorf("blocks = %+v, beta = %q, want the context block marked for caching", blocks, beta)
+	}
+	if u := result.Usage; u.PromptTokens != 2010 || u.CachedTokens != 2000 || u.TotalTokens != 2015 {
+		t.Errorf("usage = %+v, want cache reads counted as prompt tokens", u)
+	}
+
+	if _, err := client.GenerateCode(t.Context(), "main", "", "main.go", nil, []string{writeContextFile(t, 400)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].CacheControl != nil || beta != "" {
+		t.Errorf("blocks = %+v, beta = %q, want small context sent inline", blocks, beta)
+	}
+}
+
+func TestGeminiReusesCachedContent(t *testing.T) {
+	var created atomic.Int32
+	var refs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cachedContents" {
+			created.Add(1)
+			fmt.Fprint(w, `{"name":"cachedContents/abc"}`)
+			return
+		}
+		var req GenerateContentRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		refs = append(refs, req.CachedContent)
+		if strings.Contains(req.Contents[0].Parts[0].Text, "shared context line") {
+			t.Error("context sent inline alongside the cachedContent")
+		}
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"package main"}]}}],`+
+			`"usageMetadata":{"promptTokenCount":5000,"candidatesTokenCount":5,"totalTokenCount":5005,"cachedContentTokenCount":4990}}`)
+	}))
+	defer srv.Close()
+	client := NewGeminiClient(config.GeminiConfig{APIKey: "key", BaseURL: srv.URL, Model: "gemini-2.5-flash"})
+	contextFile := writeContextFile(t, 20000)
+
+	for range 2 {
+		result, err := client.GenerateCode(t.Context(), "main", "", "main.go", nil, []string{contextFile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Usage.CachedTokens != 4990 {
+			t.Errorf("cached tokens = %d, want 4990", result.Usage.CachedTokens)
+		}
+	}
+	if n := created.Load(); n != 1 || len(refs) != 2 || refs[1] != "cachedContents/abc" {
+		t.Errorf("created %d cachedContents, requests referred to %q; want one, reused", n, refs)
+	}
+}
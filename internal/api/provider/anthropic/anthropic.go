@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/auth"
@@ -28,6 +29,9 @@ func NewAnthropicProvider(config provider.ProviderConfig) *AnthropicProvider {
 
 	// Initialize OAuth authenticator
 	oauthAuth := auth.NewAnthropicOAuthAuthenticator(storage)
+	// Refresh and persist through the shared token manager, which keeps
+	// the token written by the config wizard up to date
+	oauthAuth.UseTokenManager(auth.Tokens())
 
 	// Initialize tool manager
 	toolManager := tools.NewToolFormatManager()
@@ -69,22 +73,29 @@ func (p *AnthropicProvider) Description() string {
 	return "Anthropic Claude models with native tool calling and OAuth support"
 }
 
-// GetModels returns available Anthropic models
+// GetModels returns available Anthropic models from the models API
 func (p *AnthropicProvider) GetModels(ctx context.Context) ([]provider.Model, error) {
 	if !p.IsAuthenticated() {
 		return nil, fmt.Errorf("not authenticated")
 	}
 
-	// TODO: Implement actual models API call
-	// For now, return static list
-	models := []provider.Model{
-		{ID: "claude-3-5-sonnet-20241022", Name: "Claude 3.5 Sonnet", Provider: p.Type(), MaxTokens: 200000, SupportsStreaming: true, SupportsToolCalling: true, Description: "Anthropic's most capable model"},
-		{ID: "claude-3-5-haiku-20241022", Name: "Claude 3.5 Haiku", Provider: p.Type(), MaxTokens: 200000, SupportsStreaming: true, SupportsToolCalling: true, Description: "Anthropic's fastest model"},
-		{ID: "claude-3-opus-20240229", Name: "Claude 3 Opus", Provider: p.Type(), MaxTokens: 200000, SupportsStreaming: true, SupportsToolCalling: true, Description: "Anthropic's previous generation model"},
-		{ID: "claude-2.1", Name: "Claude 2.1", Provider: p.Type(), MaxTokens: 200000, SupportsStreaming: true, SupportsToolCalling: false, Description: "Anthropic's capable model for simple tasks"},
+	config := p.GetConfig()
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
 	}
-
-	return models, nil
+	headers := map[string]string{"anthropic-version": "2023-06-01"}
+	if config.APIKey != "" {
+		headers["x-api-key"] = config.APIKey
+	} else {
+		token, err := p.oauthAuth.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		headers["Authorization"] = "Bearer " + token
+		headers["anthropic-beta"] = "oauth-2025-04-20"
+	}
+	return p.FetchModels(ctx, strings.TrimSuffix(baseURL, "/")+"/v1/models", headers)
 }
 
 // GetDefaultModel returns the default model
@@ -73,6 +73,9 @@ func InitializeDefaultProviders(factory *DefaultProviderFactory) {
 	factory.RegisterProvider(types.ProviderTypeQwen, func(config types.ProviderConfig) types.Provider {
 		return &SimpleProviderStub{name: "qwen", providerType: types.ProviderTypeQwen, config: config}
 	})
+	factory.RegisterProvider(types.ProviderTypeSynthetic, func(config types.ProviderConfig) types.Provider {
+		return &SimpleProviderStub{name: "synthetic", providerType: types.ProviderTypeSynthetic, config: config}
+	})
 	factory.RegisterProvider(types.ProviderTypeCerebras, func(config types.ProviderConfig) types.Provider {
 		return &SimpleProviderStub{name: "cerebras", providerType: types.ProviderTypeCerebras, config: config}
 	})
@@ -82,6 +85,21 @@ func InitializeDefaultProviders(factory *DefaultProviderFactory) {
 		return &SimpleProviderStub{name: "openrouter", providerType: types.ProviderTypeOpenRouter, config: config}
 	})
 
+	// Register Azure OpenAI provider
+	factory.RegisterProvider(types.ProviderTypeAzureOpenAI, func(config types.ProviderConfig) types.Provider {
+		return &SimpleProviderStub{name: "azure-openai", providerType: types.ProviderTypeAzureOpenAI, config: config}
+	})
+
+	// Register AWS Bedrock provider
+	factory.RegisterProvider(types.ProviderTypeBedrock, func(config types.ProviderConfig) types.Provider {
+		return &SimpleProviderStub{name: "bedrock", providerType: types.ProviderTypeBedrock, config: config}
+	})
+
+	// Register Google Vertex AI provider
+	factory.RegisterProvider(types.ProviderTypeVertex, func(config types.ProviderConfig) types.Provider {
+		return &SimpleProviderStub{name: "vertex", providerType: types.ProviderTypeVertex, config: config}
+	})
+
 	// Register alias providers using registry package to break circular import (Phase 3 implementation)
 	aliasinit.RegisterAliasProviders(factory)
 
@@ -95,6 +113,17 @@ func InitializeDefaultProviders(factory *DefaultProviderFactory) {
 	factory.RegisterProvider(types.ProviderTypeOllama, func(config types.ProviderConfig) types.Provider {
 		return &SimpleProviderStub{name: "ollama", providerType: types.ProviderTypeOllama, config: config}
 	})
+
+	// Register the built-in mock provider for demos and tests
+	factory.RegisterProvider(types.ProviderTypeMock, func(config types.ProviderConfig) types.Provider {
+		return &SimpleProviderStub{name: "mock", providerType: types.ProviderTypeMock, config: config}
+	})
+
+	// Register the generic type behind user-defined custom providers; the
+	// instance takes its name from the config
+	factory.RegisterProvider(types.ProviderTypeOpenAICompatible, func(config types.ProviderConfig) types.Provider {
+		return &SimpleProviderStub{name: config.Name, providerType: types.ProviderTypeOpenAICompatible, config: config}
+	})
 }
 
 // SimpleProviderStub implements types.Provider interface
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/auth"
@@ -28,6 +29,9 @@ func NewGeminiProvider(config provider.ProviderConfig) *GeminiProvider {
 
 	// Initialize OAuth authenticator
 	oauthAuth := auth.NewGeminiOAuthAuthenticator(storage)
+	// Refresh and persist through the shared token manager, which keeps
+	// the token written by the config wizard up to date
+	oauthAuth.UseTokenManager(auth.Tokens())
 
 	// Initialize tool manager
 	toolManager := tools.NewToolFormatManager()
@@ -69,24 +73,23 @@ func (p *GeminiProvider) Description() string {
 	return "Google Gemini models with OAuth support"
 }
 
-// GetModels returns available Gemini models
+// GetModels returns available Gemini models from the Generative Language API
 func (p *GeminiProvider) GetModels(ctx context.Context) ([]provider.Model, error) {
 	if !p.IsAuthenticated() {
 		return nil, fmt.Errorf("not authenticated")
 	}
 
-	// TODO: Implement actual Gemini models API call
-	// For now, return static list
-	models := []provider.Model{
-		{ID: "gemini-2.0-flash-exp", Name: "Gemini 2.0 Flash Experimental", Provider: p.Type(), MaxTokens: 1048576, SupportsStreaming: true, SupportsToolCalling: true, Description: "Google's latest experimental flash model"},
-		{ID: "gemini-1.5-pro", Name: "Gemini 1.5 Pro", Provider: p.Type(), MaxTokens: 30720, SupportsStreaming: true, SupportsToolCalling: true, Description: "Google's most capable model"},
-		{ID: "gemini-1.5-flash", Name: "Gemini 1.5 Flash", Provider: p.Type(), MaxTokens: 1048576, SupportsStreaming: true, SupportsToolCalling: true, Description: "Google's fastest model"},
-		{ID: "gemini-1.0-pro", Name: "Gemini 1.0 Pro", Provider: p.Type(), MaxTokens: 512000, SupportsStreaming: true, SupportsToolCalling: true, Description: "Google's balanced model"},
-		{ID: "gemini-1.0-flash", Name: "Gemini 1.0 Flash", Provider: p.Type(), MaxTokens: 1048576, SupportsStreaming: true, SupportsToolCalling: true, Description: "Google's flash model"},
-		{ID: "gemini-pro", Name: "Gemini Pro", Provider: p.Type(), MaxTokens: 30720, SupportsStreaming: true, SupportsToolCalling: true, Description: "Google's previous generation model"},
+	config := p.GetConfig()
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("model listing requires an API key (OAuth uses the Cloud Code API)")
 	}
-
-	return models, nil
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+	return p.FetchModels(ctx, strings.TrimSuffix(baseURL, "/")+"/models", map[string]string{
+		"x-goog-api-key": config.APIKey,
+	})
 }
 
 // GetDefaultModel returns the default model
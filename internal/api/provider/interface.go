@@ -168,11 +168,26 @@ type GenerateOptions struct {
 	Stop           []string               `json:"stop,omitempty"`
 	Stream         bool                   `json:"stream"`
 	Tools          []Tool                 `json:"tools,omitempty"`
-	ResponseFormat string                 `json:"response_format,omitempty"`
+	ResponseFormat *ResponseFormat        `json:"response_format,omitempty"`
 	Timeout        time.Duration          `json:"timeout,omitempty"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// ResponseFormat asks an OpenAI-compatible provider for machine-readable
+// output. Type is "json_object" for any valid JSON, or "json_schema" to
+// have the output match JSONSchema.
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is the schema a "json_schema" response format must match
+type JSONSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict,omitempty"` // Reject output that does not match exactly
+}
+
 // ProviderMetrics represents metrics for a provider
 type ProviderMetrics struct {
 	RequestCount    int64         `json:"request_count"`
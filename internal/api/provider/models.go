@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+)
+
+// FetchModels lists the models at a provider's models endpoint for its
+// GetModels. Streaming, and tool calling where the endpoint doesn't report
+// it, follow the provider's configuration.
+func (p *BaseProvider) FetchModels(ctx context.Context, url string, headers map[string]string) ([]Model, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	discovered, err := api.FetchModels(p.name, req)
+	if err != nil {
+		return nil, err
+	}
+
+	config := p.GetConfig()
+	models := make([]Model, 0, len(discovered))
+	for _, m := range discovered {
+		model := Model{
+			ID:                  m.ID,
+			Name:                m.Name,
+			Provider:            config.Type,
+			MaxTokens:           m.ContextWindow,
+			OutputTokens:        m.MaxOutput,
+			SupportsStreaming:   config.SupportsStreaming,
+			SupportsToolCalling: config.SupportsToolCalling,
+		}
+		if model.Name == "" {
+			model.Name = m.ID
+		}
+		if m.ToolCalling != nil {
+			model.SupportsToolCalling = *m.ToolCalling
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
@@ -58,24 +59,15 @@ func (p *OpenAIProvider) Description() string {
 	return "OpenAI - GPT models with native API access"
 }
 
-// GetModels returns available models
+// GetModels returns available models from the models API
 func (p *OpenAIProvider) GetModels(ctx context.Context) ([]provider.Model, error) {
 	if p.apiKey == "" {
 		return nil, fmt.Errorf("no OpenAI API key configured")
 	}
 
-	// TODO: Implement actual models API call
-	// For now, return static list
-	models := []provider.Model{
-		{ID: "gpt-4o", Name: "GPT-4o", Provider: p.Type(), MaxTokens: 128000, SupportsStreaming: true, SupportsToolCalling: true, Description: "OpenAI's latest reasoning model"},
-		{ID: "gpt-4o-mini", Name: "GPT-4o Mini", Provider: p.Type(), MaxTokens: 128000, SupportsStreaming: true, SupportsToolCalling: true, Description: "OpenAI's compact reasoning model"},
-		{ID: "gpt-4", Name: "GPT-4", Provider: p.Type(), MaxTokens: 8192, SupportsStreaming: true, SupportsToolCalling: true, Description: "OpenAI's previous generation model"},
-		{ID: "gpt-4-turbo", Name: "GPT-4 Turbo", Provider: p.Type(), MaxTokens: 4096, SupportsStreaming: true, SupportsToolCalling: true, Description: "OpenAI's balanced model"},
-		{ID: "gpt-3.5-turbo", Name: "GPT-3.5 Turbo", Provider: p.Type(), MaxTokens: 4096, SupportsStreaming: true, SupportsToolCalling: true, Description: "OpenAI's fast, cost-effective model"},
-		{ID: "gpt-3.5", Name: "GPT-3.5", Provider: p.Type(), MaxTokens: 4096, SupportsStreaming: false, SupportsToolCalling: true, Description: "OpenAI's capable model"},
-	}
-
-	return models, nil
+	return p.FetchModels(ctx, strings.TrimSuffix(p.baseURL, "/")+"/models", map[string]string{
+		"Authorization": "Bearer " + p.apiKey,
+	})
 }
 
 // GetDefaultModel returns the default model
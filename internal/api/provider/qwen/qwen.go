@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
@@ -50,21 +51,24 @@ func (p *QwenProvider) Description() string {
 	return "Qwen (Tongji AI) models with OAuth support"
 }
 
-// GetModels returns available Qwen models
+// GetModels returns available Qwen models from DashScope's OpenAI-compatible
+// models API
 func (p *QwenProvider) GetModels(ctx context.Context) ([]provider.Model, error) {
 	if !p.IsAuthenticated() {
 		return nil, fmt.Errorf("not authenticated")
 	}
 
-	// TODO: Implement actual Qwen models API call
-	// For now, return static list
-	models := []provider.Model{
-		{ID: "qwen-max", Name: "Qwen Max", Provider: p.Type(), MaxTokens: 8192, SupportsStreaming: true, SupportsToolCalling: true, Description: "Qwen's most capable model"},
-		{ID: "qwen-plus", Name: "Qwen Plus", Provider: p.Type(), MaxTokens: 32768, SupportsStreaming: true, SupportsToolCalling: true, Description: "Qwen's balanced model"},
-		{ID: "qwen-turbo", Name: "Qwen Turbo", Provider: p.Type(), MaxTokens: 6144, SupportsStreaming: true, SupportsToolCalling: true, Description: "Qwen's fastest model"},
-		{ID: "qwen", Name: "Qwen 7B Chat", Provider: p.Type(), MaxTokens: 6144, SupportsStreaming: true, SupportsToolCalling: true, Description: "Qwen's capable model"},
+	config := p.GetConfig()
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("model listing requires an API key")
+	}
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "https://dashscope-intl.aliyuncs.com/compatible-mode"
 	}
-	return models, nil
+	return p.FetchModels(ctx, strings.TrimSuffix(baseURL, "/")+"/v1/models", map[string]string{
+		"Authorization": "Bearer " + config.APIKey,
+	})
 }
 
 // GetDefaultModel returns the default model
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// defaultQwenBaseURL is DashScope's OpenAI-compatible API
+const defaultQwenBaseURL = "https://dashscope-intl.aliyuncs.com/compatible-mode"
+
+// qwenBaseURL returns the root of DashScope's OpenAI-compatible API for the
+// configured base URL. Earlier configs point at the native API (/api/v1),
+// whose request format differs; the same host's compatible mode is used.
+func qwenBaseURL(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if baseURL == "" {
+		return defaultQwenBaseURL
+	}
+	if native, ok := strings.CutSuffix(baseURL, "/api/v1"); ok {
+		return native + "/compatible-mode"
+	}
+	return baseURL
+}
+
+// NewQwenClient returns a client for Alibaba Qwen through DashScope's
+// OpenAI-compatible chat completions endpoint, which returns OpenAI-style
+// errors
+func NewQwenClient(cfg config.QwenConfig) *CustomClient {
+	c := NewCustomClient("qwen", config.CustomProviderConfig{
+		BaseURL:     qwenBaseURL(cfg.BaseURL) + "/v1",
+		Model:       presetModel(cfg.Model, cfg.Models),
+		MaxTokens:   cfg.MaxTokens,
+		Temperature: cfg.Temperature,
+	})
+	c.keyManager = NewAPIKeyManager("Qwen", cfg.GetAllAPIKeys(), cfg.KeyBalancing)
+	c.modelSelector = newConfiguredModelSelector("Qwen", cfg.Model, cfg.Models, cfg.ModelStrategy)
+	return c
+}
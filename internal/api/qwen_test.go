@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestQwenBaseURL(t *testing.T) {
+	for baseURL, want := range map[string]string{
+		"":                                       defaultQwenBaseURL,
+		"https://dashscope.aliyuncs.com/api/v1/": "https://dashscope.aliyuncs.com/compatible-mode",
+		"https://dashscope.aliyuncs.com/compatible-mode/": "https://dashscope.aliyuncs.com/compatible-mode",
+	} {
+		if got := qwenBaseURL(baseURL); got != want {
+			t.Errorf("qwenBaseURL(%q) = %q, want %q", baseURL, got, want)
+		}
+	}
+}
+
+// The request and response handling are CustomClient's; the preset adds
+// the compatible-mode URL and the choice among several models
+func TestQwenClientUsesCompatibleMode(t *testing.T) {
+	var got CerebrasRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compatible-mode/v1/chat/completions" {
+			t.Errorf("request path = %s, want /compatible-mode/v1/chat/completions", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"print(1)"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewQwenClient(config.QwenConfig{
+		APIKey:  "test",
+		BaseURL: server.URL + "/api/v1",
+		Models:  []string{"qwen3-coder-plus", "qwen-max"},
+	})
+	if _, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil); err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if got.Model != "qwen3-coder-plus" || client.GetModel() != "qwen3-coder-plus" {
+		t.Errorf("model = %q (GetModel %q), want the first of the configured models", got.Model, client.GetModel())
+	}
+}
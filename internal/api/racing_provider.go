@@ -2,8 +2,8 @@ package api
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -13,26 +13,34 @@ import (
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
 )
 
+// raceResult is the outcome of one racer's request
 type raceResult struct {
-	code          string
-	usage         *types.Usage
-	providerModel string
-	duration      time.Duration
+	index  int
+	result *types.CodeGenerationResult
+	err    error
 }
 
+// RacingProvider sends a request to several models at once and commits to
+// the first to stream back a token, cancelling the rest
 type RacingProvider struct {
 	config          *config.RacingConfig
 	configRef       *config.Config
 	lastWinner      string
-	lastCompletions map[string]time.Duration
+	lastFirstTokens map[string]time.Duration
+	lastAbandoned   []string
+	stats           *RacingStats
 	mu              sync.RWMutex
 }
 
-func NewRacingProvider(cfg *config.RacingConfig, configRef *config.Config) *RacingProvider {
+// NewRacingProvider creates a racing provider. Race outcomes are added to
+// stats, which also excludes models too slow to keep racing; stats may be
+// nil.
+func NewRacingProvider(cfg *config.RacingConfig, configRef *config.Config, stats *RacingStats) *RacingProvider {
 	return &RacingProvider{
 		config:          cfg,
 		configRef:       configRef,
-		lastCompletions: make(map[string]time.Duration),
+		lastFirstTokens: make(map[string]time.Duration),
+		stats:           stats,
 	}
 }
 
@@ -59,169 +67,126 @@ func (r *RacingProvider) resolveProviderName(nameOrAlias string) string {
 	return nameOrAlias
 }
 
+// GenerateCode races the configured models on time to first token. The
+// first racer to stream a token wins; the others' requests are cancelled at
+// once and reported by GetLastAbandoned. Providers that cannot stream count
+// their first token when their response completes.
 func (r *RacingProvider) GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
-	models := r.config.Models
-	if len(models) == 0 {
+	if len(r.config.Models) == 0 {
 		return nil, fmt.Errorf("no models configured for racing")
 	}
-	numRacers := r.config.NumRacers
-	if numRacers > 0 && int(numRacers) < len(models) {
-		models = models[:numRacers]
-	}
+	models := r.stats.Pool(r.config)
 	logger.Infof("Racing %d models: %v", len(models), models)
-	cancelCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	resultChan := make(chan raceResult, 1)
-	errChan := make(chan error, len(models))
-	r.mu.RLock()
-	gracePeriod := time.Duration(r.config.GracePeriodMS) * time.Millisecond
-	if gracePeriod <= 0 {
-		gracePeriod = 500 * time.Millisecond
-	}
-	r.mu.RUnlock()
+
 	start := time.Now()
-	var wg sync.WaitGroup
-	wg.Add(len(models))
-	for _, providerModel := range models {
-		go func(pm string) {
-			defer wg.Done()
-			providerName, modelName, err := r.parseProviderModel(pm)
-			if err != nil {
-				logger.Errorf("[%s] parse error: %v", pm, err)
-				select {
-				case errChan <- fmt.Errorf("[%s] parse error: %w", pm, err):
-				case <-cancelCtx.Done():
-				}
-				return
-			}
-			var code string
-			var usage *types.Usage
-			var clientErr error
-			switch providerName {
-			case "anthropic":
-				if r.configRef.Providers.Anthropic == nil {
-					clientErr = fmt.Errorf("anthropic provider config not found")
-				} else {
-					var result *types.CodeGenerationResult
-					result, clientErr = NewAnthropicClient(*r.configRef.Providers.Anthropic).GenerateCode(cancelCtx, prompt, contextStr, outputFile, language, contextFiles)
-					if clientErr == nil {
-						code = result.Code
-						usage = result.Usage
-					}
-				}
-			case "cerebras":
-				if r.configRef.Providers.Cerebras == nil {
-					clientErr = fmt.Errorf("cerebras provider config not found")
-				} else {
-					var result *types.CodeGenerationResult
-					result, clientErr = NewCerebrasClient(*r.configRef.Providers.Cerebras).GenerateCode(cancelCtx, prompt, contextStr, outputFile, language, contextFiles)
-					if clientErr == nil {
-						code = result.Code
-						usage = result.Usage
-					}
-				}
-			case "openrouter":
-				if r.configRef.Providers.OpenRouter == nil {
-					clientErr = fmt.Errorf("openrouter provider config not found")
-				} else {
-					orcCopy := *r.configRef.Providers.OpenRouter
-					orcCopy.Model = modelName
-					orcCopy.Models = nil
-					var result *types.CodeGenerationResult
-					result, clientErr = NewOpenRouterClient(orcCopy).GenerateCode(cancelCtx, prompt, contextStr, outputFile, language, contextFiles)
-					if clientErr == nil {
-						code = result.Code
-						usage = result.Usage
-					}
-				}
-			case "gemini":
-				if r.configRef.Providers.Gemini == nil {
-					clientErr = fmt.Errorf("gemini provider config not found")
-				} else {
-					var result *types.CodeGenerationResult
-					result, clientErr = NewGeminiClient(*r.configRef.Providers.Gemini).GenerateCode(cancelCtx, prompt, contextStr, outputFile, language, contextFiles)
-					if clientErr == nil {
-						code = result.Code
-						usage = result.Usage
-					}
-				}
-			default:
-				clientErr = fmt.Errorf("unknown provider: %s", providerName)
-			}
-			if clientErr != nil {
-				if !errors.Is(clientErr, context.Canceled) && !strings.Contains(clientErr.Error(), "context canceled") {
-					logger.Errorf("[%s] error: %v", pm, clientErr)
-					select {
-					case errChan <- fmt.Errorf("[%s] error: %w", pm, clientErr):
-					case <-cancelCtx.Done():
-					}
-				} else {
-					logger.Debugf("[%s] canceled (another model won the race)", pm)
-				}
-				return
-			}
-			duration := time.Since(start)
-			logger.Infof("[%s] completed in %v", pm, duration)
-			select {
-			case resultChan <- raceResult{code: code, usage: usage, providerModel: pm, duration: duration}:
-			case <-cancelCtx.Done():
+	// The caller hears of the winner's first token, not the losers'
+	outerFirstToken := firstTokenFunc(ctx)
+	firstTokens := make(chan int, len(models))
+	results := make(chan raceResult, len(models))
+	cancels := make([]context.CancelFunc, len(models))
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+	for i, pm := range models {
+		racerCtx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		var once sync.Once
+		firstToken := func() { once.Do(func() { firstTokens <- i }) }
+		go func() {
+			result, err := r.generate(WithFirstToken(racerCtx, firstToken), pm, prompt, contextStr, outputFile, language, contextFiles)
+			if err == nil {
+				firstToken()
 			}
-		}(providerModel)
+			results <- raceResult{index: i, result: result, err: err}
+		}()
 	}
-	doneChan := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(doneChan)
-	}()
-	select {
-	case result := <-resultChan:
-		winnerResult := result.code
-		winnerUsage := result.usage
-		winnerTime := result.duration
-		if winnerUsage != nil {
-			logger.Infof("🏆 WINNER: %s in %v (tokens: %d, tokens/sec: %.1f)",
-				result.providerModel, winnerTime, winnerUsage.TotalTokens,
-				float64(winnerUsage.TotalTokens)/(winnerTime.Seconds()))
-		} else {
-			logger.Infof("🏆 WINNER: %s in %v (no token data)", result.providerModel, winnerTime)
+
+	winner := -1
+	finished := make([]bool, len(models))
+	var failures []string
+	outcome := raceOutcome{raced: models}
+	defer func() { r.stats.record(outcome) }()
+	// commit makes racer i the winner and cancels every racer still running
+	commit := func(i int) {
+		winner = i
+		ttft := time.Since(start)
+		var abandoned []string
+		for j, cancel := range cancels {
+			if j != i && !finished[j] {
+				cancel()
+				abandoned = append(abandoned, models[j])
+			}
+		}
+		logger.Infof("🏆 WINNER: %s, first token in %v (cancelled %d racer(s))", models[i], ttft, len(abandoned))
+		if outerFirstToken != nil {
+			outerFirstToken()
 		}
+		outcome.winner, outcome.ttft, outcome.abandoned = models[i], ttft, abandoned
 
 		r.mu.Lock()
-		r.lastWinner = result.providerModel
-		r.lastCompletions = make(map[string]time.Duration)
-		r.lastCompletions[result.providerModel] = result.duration
+		r.lastWinner = models[i]
+		r.lastFirstTokens = map[string]time.Duration{models[i]: ttft}
+		r.lastAbandoned = abandoned
 		r.mu.Unlock()
+	}
 
-		graceTimer := time.NewTimer(gracePeriod)
-		defer graceTimer.Stop()
-		for {
-			select {
-			case <-graceTimer.C:
-				cancel()
-			case res := <-resultChan:
-				logger.Infof("[late completion] %s also completed during grace period in %v", res.providerModel, res.duration)
-				r.mu.Lock()
-				r.lastCompletions[res.providerModel] = res.duration
-				r.mu.Unlock()
-			case <-doneChan:
-				return &types.CodeGenerationResult{Code: winnerResult, Usage: winnerUsage}, nil
-			case <-ctx.Done():
-				cancel()
-				return nil, fmt.Errorf("race canceled: %w", ctx.Err())
+	for pending := len(models); pending > 0; {
+		select {
+		case i := <-firstTokens:
+			if winner < 0 {
+				commit(i)
 			}
+		case res := <-results:
+			pending--
+			finished[res.index] = true
+			pm := models[res.index]
+			if res.err == nil && winner < 0 {
+				// Its first-token signal is still queued
+				commit(res.index)
+			}
+			if res.index == winner {
+				if res.err != nil {
+					outcome.winner = ""
+					outcome.failed = append(outcome.failed, pm)
+					return nil, fmt.Errorf("race winner %s failed after its first token: %w", pm, res.err)
+				}
+				if usage := res.result.Usage; usage != nil {
+					duration := time.Since(start)
+					logger.Infof("[%s] completed in %v (tokens: %d, tokens/sec: %.1f)",
+						pm, duration, usage.TotalTokens, float64(usage.TotalTokens)/duration.Seconds())
+				}
+				return res.result, nil
+			}
+			if res.err != nil && winner < 0 {
+				logger.Errorf("[%s] error: %v", pm, res.err)
+				failures = append(failures, fmt.Sprintf("[%s] error: %v", pm, res.err))
+				outcome.failed = append(outcome.failed, pm)
+			}
+		case <-ctx.Done():
+			outcome.raced = nil // the caller gave up; nothing was learned
+			return nil, fmt.Errorf("race canceled: %w", ctx.Err())
 		}
-	case <-doneChan:
-		cancel()
-		var errors []string
-		close(errChan)
-		for err := range errChan {
-			errors = append(errors, err.Error())
-		}
-		return nil, fmt.Errorf("all %d racers failed: %s", len(models), strings.Join(errors, "; "))
-	case <-ctx.Done():
-		cancel()
-		return nil, fmt.Errorf("race canceled: %w", ctx.Err())
 	}
+	return nil, fmt.Errorf("all %d racers failed: %s", len(models), strings.Join(failures, "; "))
+}
+
+// generate makes one racer's request to the model named by providerModel
+func (r *RacingProvider) generate(ctx context.Context, providerModel, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
+	providerName, modelName, err := r.parseProviderModel(providerModel)
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %w", err)
+	}
+	providers, err := withModel(r.configRef.Providers, providerName, modelName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewClient(providerName, providers)
+	if err != nil {
+		return nil, err
+	}
+	return client.GenerateCode(ctx, prompt, contextStr, outputFile, language, contextFiles)
 }
 
 func (r *RacingProvider) GetLastWinner() string {
@@ -230,12 +195,23 @@ func (r *RacingProvider) GetLastWinner() string {
 	return r.lastWinner
 }
 
-func (r *RacingProvider) GetLastCompletions() map[string]time.Duration {
+// GetLastFirstTokens returns the time to first token of the last race's
+// winner, keyed by its provider:model
+func (r *RacingProvider) GetLastFirstTokens() map[string]time.Duration {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	completions := make(map[string]time.Duration)
-	for k, v := range r.lastCompletions {
-		completions[k] = v
+	firstTokens := make(map[string]time.Duration, len(r.lastFirstTokens))
+	for k, v := range r.lastFirstTokens {
+		firstTokens[k] = v
 	}
-	return completions
-}
\ No newline at end of file
+	return firstTokens
+}
+
+// GetLastAbandoned returns the provider:model of each racer the last race
+// cancelled after the winner's first token. Their prompts were already sent
+// and may be billed.
+func (r *RacingProvider) GetLastAbandoned() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.lastAbandoned)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestRacingCommitsToFirstTokenAndCancelsLosers(t *testing.T) {
+	cancelled := make(chan string, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CerebrasRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			t.Errorf("request stream = %v, err = %v; want a streamed request", req.Stream, err)
+		}
+		chunk := func(content string) {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", content)
+			w.(http.Flusher).Flush()
+		}
+		wait := func(d time.Duration) bool {
+			select {
+			case <-time.After(d):
+				return true
+			case <-r.Context().Done():
+				cancelled <- req.Model
+				return false
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch req.Model {
+		case "quick-start": // first token at once, done after 300ms
+			chunk("print(")
+			if !wait(300 * time.Millisecond) {
+				return
+			}
+			chunk("1)")
+		case "quick-finish": // whole response after 100ms
+			if !wait(100 * time.Millisecond) {
+				return
+			}
+			chunk("print(2)")
+		default:
+			wait(5 * time.Second)
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":2,\"total_tokens\":12}}\n\ndata: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Cerebras = &config.CerebrasConfig{APIKey: "key", BaseURL: srv.URL}
+	racing := NewRacingProvider(&config.RacingConfig{
+		Models: []string{"cerebras:quick-finish", "cerebras:quick-start", "cerebras:stalled"},
+	}, cfg, nil)
+
+	result, err := racing.GenerateCode(t.Context(), "print one", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if result.Code != "print(1)" || result.Usage == nil || result.Usage.TotalTokens != 12 {
+		t.Errorf("result = %q, %+v; want print(1) with 12 tokens", result.Code, result.Usage)
+	}
+	if winner := racing.GetLastWinner(); winner != "cerebras:quick-start" {
+		t.Errorf("winner = %q, want cerebras:quick-start", winner)
+	}
+	abandoned := racing.GetLastAbandoned()
+	slices.Sort(abandoned)
+	if want := []string{"cerebras:quick-finish", "cerebras:stalled"}; !slices.Equal(abandoned, want) {
+		t.Errorf("abandoned = %v, want %v", abandoned, want)
+	}
+
+	var seen []string
+	for range 2 {
+		select {
+		case model := <-cancelled:
+			seen = append(seen, model)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("losers' requests were not cancelled (saw %v)", seen)
+		}
+	}
+}
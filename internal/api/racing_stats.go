@@ -0,0 +1,213 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+const (
+	// defaultSlownessThreshold applies when a racing config sets none
+	defaultSlownessThreshold = 2.5
+
+	// minWinsForSlowness is how many wins a model needs before its average
+	// time to first token is trusted for slowness exclusion
+	minWinsForSlowness = 3
+)
+
+// RacingStats accumulates race outcomes for one racing provider across
+// requests. Losers are cancelled before their first token, so a model's
+// time to first token is averaged over the races it won.
+type RacingStats struct {
+	mu     sync.Mutex
+	races  int64
+	models map[string]*racerStats
+}
+
+// racerStats is one model's running totals
+type racerStats struct {
+	races     int64
+	wins      int64
+	failures  int64
+	abandoned int64
+	totalTTFT time.Duration
+}
+
+// raceOutcome is what one race reports to RacingStats
+type raceOutcome struct {
+	raced     []string
+	winner    string // empty if no racer succeeded
+	ttft      time.Duration
+	failed    []string
+	abandoned []string
+}
+
+// RacerStatus is one model's statistics in a RacingReport
+type RacerStatus struct {
+	Model     string  `json:"model"`
+	Races     int64   `json:"races"`
+	Wins      int64   `json:"wins"`
+	WinRate   float64 `json:"win_rate"`
+	Failures  int64   `json:"failures"`
+	Abandoned int64   `json:"abandoned"`
+	AvgTTFTMS float64 `json:"avg_ttft_ms,omitempty"`
+	Excluded  bool    `json:"excluded"` // Too slow to keep racing
+	InPool    bool    `json:"in_pool"`  // Raced in the next request
+}
+
+// RacingReport is a racing provider's statistics, served at /api/racing
+type RacingReport struct {
+	Provider          string        `json:"provider"`
+	Races             int64         `json:"races"`
+	NumRacers         int           `json:"num_racers"`
+	SlownessThreshold float64       `json:"slowness_threshold"`
+	Pool              []string      `json:"pool"`
+	Models            []RacerStatus `json:"models"`
+}
+
+// NewRacingStats creates empty racing statistics
+func NewRacingStats() *RacingStats {
+	return &RacingStats{models: make(map[string]*racerStats)}
+}
+
+// Pool returns the models the next race should run: the configured models
+// less those excluded as too slow, cut to num_racers
+func (s *RacingStats) Pool(cfg *config.RacingConfig) []string {
+	if s == nil {
+		return limitRacers(cfg.Models, cfg.NumRacers)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pool(cfg)
+}
+
+// pool implements Pool. Caller must hold the mutex.
+func (s *RacingStats) pool(cfg *config.RacingConfig) []string {
+	excluded := s.excluded(cfg)
+	var pool []string
+	for _, model := range cfg.Models {
+		if !excluded[model] {
+			pool = append(pool, model)
+		}
+	}
+	return limitRacers(pool, cfg.NumRacers)
+}
+
+// excluded returns the models whose average time to first token is more
+// than slowness_threshold times the fastest model's. Only models with
+// minWinsForSlowness wins are compared, so the fastest is never excluded.
+// Caller must hold the mutex.
+func (s *RacingStats) excluded(cfg *config.RacingConfig) map[string]bool {
+	threshold := cfg.SlownessThreshold
+	if threshold <= 0 {
+		threshold = defaultSlownessThreshold
+	}
+	var fastest time.Duration
+	for _, model := range cfg.Models {
+		if avg, ok := s.avgTTFT(model); ok && (fastest == 0 || avg < fastest) {
+			fastest = avg
+		}
+	}
+	excluded := make(map[string]bool)
+	for _, model := range cfg.Models {
+		if avg, ok := s.avgTTFT(model); ok && float64(avg) > threshold*float64(fastest) {
+			excluded[model] = true
+		}
+	}
+	return excluded
+}
+
+// avgTTFT returns model's average time to first token, if it has won often
+// enough to be trusted. Caller must hold the mutex.
+func (s *RacingStats) avgTTFT(model string) (time.Duration, bool) {
+	stats := s.models[model]
+	if stats == nil || stats.wins < minWinsForSlowness {
+		return 0, false
+	}
+	return stats.totalTTFT / time.Duration(stats.wins), true
+}
+
+// record adds one race's outcome
+func (s *RacingStats) record(outcome raceOutcome) {
+	if s == nil || len(outcome.raced) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.races++
+	model := func(name string) *racerStats {
+		if s.models[name] == nil {
+			s.models[name] = &racerStats{}
+		}
+		return s.models[name]
+	}
+	for _, name := range outcome.raced {
+		model(name).races++
+	}
+	if outcome.winner != "" {
+		model(outcome.winner).wins++
+		model(outcome.winner).totalTTFT += outcome.ttft
+	}
+	for _, name := range outcome.failed {
+		model(name).failures++
+	}
+	for _, name := range outcome.abandoned {
+		model(name).abandoned++
+	}
+}
+
+// Report returns the statistics of the racing provider named provider,
+// whose configuration is cfg
+func (s *RacingStats) Report(provider string, cfg *config.RacingConfig) RacingReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	threshold := cfg.SlownessThreshold
+	if threshold <= 0 {
+		threshold = defaultSlownessThreshold
+	}
+	report := RacingReport{
+		Provider:          provider,
+		Races:             s.races,
+		NumRacers:         cfg.NumRacers,
+		SlownessThreshold: threshold,
+		Pool:              s.pool(cfg),
+		Models:            []RacerStatus{},
+	}
+	if report.Pool == nil {
+		report.Pool = []string{}
+	}
+
+	excluded := s.excluded(cfg)
+	inPool := make(map[string]bool)
+	for _, model := range report.Pool {
+		inPool[model] = true
+	}
+	for _, model := range cfg.Models {
+		status := RacerStatus{Model: model, Excluded: excluded[model], InPool: inPool[model]}
+		if stats := s.models[model]; stats != nil {
+			status.Races = stats.races
+			status.Wins = stats.wins
+			status.Failures = stats.failures
+			status.Abandoned = stats.abandoned
+			if stats.races > 0 {
+				status.WinRate = float64(stats.wins) / float64(stats.races)
+			}
+			if stats.wins > 0 {
+				status.AvgTTFTMS = float64(stats.totalTTFT/time.Duration(stats.wins)) / float64(time.Millisecond)
+			}
+		}
+		report.Models = append(report.Models, status)
+	}
+	return report
+}
+
+// limitRacers cuts models to numRacers; zero or less races them all
+func limitRacers(models []string, numRacers int) []string {
+	if numRacers > 0 && numRacers < len(models) {
+		return models[:numRacers]
+	}
+	return models
+}
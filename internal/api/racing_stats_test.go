@@ -0,0 +1,44 @@
+package api
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestRacingStatsExcludesSlowModels(t *testing.T) {
+	cfg := &config.RacingConfig{
+		Models:            []string{"cerebras:fast", "openrouter:slow", "gemini:new", "anthropic:steady"},
+		NumRacers:         2,
+		SlownessThreshold: 2,
+	}
+	stats := NewRacingStats()
+	if pool := stats.Pool(cfg); !slices.Equal(pool, []string{"cerebras:fast", "openrouter:slow"}) {
+		t.Errorf("initial pool = %v", pool)
+	}
+
+	for range minWinsForSlowness {
+		stats.record(raceOutcome{raced: cfg.Models, winner: "cerebras:fast", ttft: 100 * time.Millisecond, abandoned: cfg.Models[1:]})
+		stats.record(raceOutcome{raced: cfg.Models, winner: "openrouter:slow", ttft: 300 * time.Millisecond, failed: []string{"cerebras:fast"}})
+		stats.record(raceOutcome{raced: cfg.Models, winner: "anthropic:steady", ttft: 150 * time.Millisecond})
+	}
+
+	// slow's 300ms is over twice fast's 100ms; new has too few wins to judge
+	if pool := stats.Pool(cfg); !slices.Equal(pool, []string{"cerebras:fast", "gemini:new"}) {
+		t.Errorf("pool = %v, want slow excluded", pool)
+	}
+
+	report := stats.Report("racing", cfg)
+	if report.Races != 9 || len(report.Models) != 4 {
+		t.Fatalf("report = %+v", report)
+	}
+	fast, slow := report.Models[0], report.Models[1]
+	if fast.Wins != 3 || fast.Failures != 3 || fast.AvgTTFTMS != 100 || !fast.InPool || fast.Excluded {
+		t.Errorf("fast = %+v", fast)
+	}
+	if slow.Wins != 3 || slow.Abandoned != 3 || !slow.Excluded || slow.InPool {
+		t.Errorf("slow = %+v", slow)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ReasoningEfforts are the values reasoning_effort accepts, least effort first
+var ReasoningEfforts = []string{"minimal", "low", "medium", "high"}
+
+// ValidateReasoningEffort returns an error unless effort is empty or one of
+// ReasoningEfforts
+func ValidateReasoningEffort(effort string) error {
+	if effort == "" {
+		return nil
+	}
+	for _, valid := range ReasoningEfforts {
+		if effort == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("reasoning_effort must be one of %s, got %q", strings.Join(ReasoningEfforts, ", "), effort)
+}
+
+// IsReasoningModel reports whether model is an OpenAI reasoning model (the
+// o-series or gpt-5), which rejects temperature and max_tokens. A routing
+// prefix such as "openai/" is ignored.
+func IsReasoningModel(model string) bool {
+	name := strings.ToLower(model[strings.LastIndex(model, "/")+1:])
+	if len(name) >= 2 && name[0] == 'o' && name[1] >= '1' && name[1] <= '9' {
+		return true
+	}
+	return strings.HasPrefix(name, "gpt-5") && !strings.Contains(name, "chat")
+}
+
+// reasoningEffortKey is the context key for the effort set by WithReasoningEffort
+type reasoningEffortKey struct{}
+
+// WithReasoningEffort returns a context asking reasoning models to think with
+// effort for this request, overriding the provider's reasoning_effort
+func WithReasoningEffort(ctx context.Context, effort string) context.Context {
+	if effort == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, reasoningEffortKey{}, effort)
+}
+
+// reasoningEffort returns the effort set by WithReasoningEffort, or configured
+func reasoningEffort(ctx context.Context, configured string) string {
+	if effort, _ := ctx.Value(reasoningEffortKey{}).(string); effort != "" {
+		return effort
+	}
+	return configured
+}
+
+// applyReasoning adapts an OpenAI-compatible request for a reasoning model:
+// max_tokens becomes max_completion_tokens, temperature is left out and the
+// effort is sent. Requests for other models are left alone.
+func applyReasoning(requestData *CerebrasRequest, effort string) {
+	if !IsReasoningModel(requestData.Model) {
+		return
+	}
+	requestData.MaxCompletionTokens = requestData.MaxTokens
+	requestData.MaxTokens = 0
+	requestData.Temperature = nil
+	requestData.ReasoningEffort = effort
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestIsReasoningModel(t *testing.T) {
+	tests := map[string]bool{
+		"o1":                 true,
+		"o3-mini":            true,
+		"openai/o4-mini":     true,
+		"gpt-5":              true,
+		"gpt-5-mini":         true,
+		"gpt-5-chat-latest":  false,
+		"gpt-4o":             false,
+		"openai/gpt-4o-mini": false,
+		"qwen-3-coder":       false,
+		"ollama":             false,
+	}
+	for model, want := range tests {
+		if got := IsReasoningModel(model); got != want {
+			t.Errorf("IsReasoningModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestCustomClientAdaptsRequestForReasoningModels(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"print(1)"}}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.CustomProviderConfig{BaseURL: srv.URL, Model: "o3-mini", MaxTokens: 4096, Temperature: 0.2, ReasoningEffort: "low"}
+	ctx := WithReasoningEffort(t.Context(), "high")
+	if _, err := NewCustomClient("openai", cfg).GenerateCode(ctx, "print one", "", "", nil, nil); err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if _, ok := body["temperature"]; ok {
+		t.Errorf("request has temperature %v, want none for a reasoning model", body["temperature"])
+	}
+	if _, ok := body["max_tokens"]; ok || body["max_completion_tokens"] != float64(4096) {
+		t.Errorf("request has max_tokens %v, max_completion_tokens %v, want only max_completion_tokens 4096", body["max_tokens"], body["max_completion_tokens"])
+	}
+	if body["reasoning_effort"] != "high" {
+		t.Errorf("reasoning_effort = %v, want the request's high over the configured low", body["reasoning_effort"])
+	}
+
+	cfg.Model = "gpt-4o"
+	if _, err := NewCustomClient("openai", cfg).GenerateCode(ctx, "print one", "", "", nil, nil); err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if body["temperature"] != 0.2 || body["max_tokens"] != float64(4096) || body["reasoning_effort"] != nil {
+		t.Errorf("request for gpt-4o = %v, want temperature and max_tokens without reasoning_effort", body)
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+)
+
+// responseFormatKey is the context key for the format set by WithResponseFormat
+type responseFormatKey struct{}
+
+// WithResponseFormat returns a context asking OpenRouter and the
+// OpenAI-compatible providers (custom, local and Azure OpenAI) to return
+// output in format, e.g. JSON matching a schema. Other providers ignore it,
+// so callers that need valid JSON should still check what comes back.
+func WithResponseFormat(ctx context.Context, format *types.ResponseFormat) context.Context {
+	return context.WithValue(ctx, responseFormatKey{}, format)
+}
+
+// WithGenerateOptions returns a context carrying the per-request options in
+// opts that the clients read from the context
+func WithGenerateOptions(ctx context.Context, opts types.GenerateOptions) context.Context {
+	if opts.ResponseFormat != nil {
+		ctx = WithResponseFormat(ctx, opts.ResponseFormat)
+	}
+	return ctx
+}
+
+// responseFormat returns the format set by WithResponseFormat, or nil for
+// the provider's default free-form output
+func responseFormat(ctx context.Context) *types.ResponseFormat {
+	format, _ := ctx.Value(responseFormatKey{}).(*types.ResponseFormat)
+	return format
+}
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// BudgetTracker enforces daily request and token limits. Counters reset at
+// local midnight and are kept in memory only.
+type BudgetTracker struct {
+	limits   config.LimitsConfig
+	day      string
+	requests int
+	tokens   int
+	mutex    sync.Mutex
+}
+
+// NewBudgetTracker creates a budget tracker. Returns nil if no limits are set.
+func NewBudgetTracker(limits config.LimitsConfig) *BudgetTracker {
+	if limits.MaxRequestsPerDay <= 0 && limits.MaxTokensPerDay <= 0 {
+		return nil
+	}
+	return &BudgetTracker{limits: limits}
+}
+
+// Reserve counts a new request against today's budget, or returns an error if
+// the request or token budget is already exhausted
+func (b *BudgetTracker) Reserve() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.rollover()
+
+	if b.limits.MaxTokensPerDay > 0 && b.tokens >= b.limits.MaxTokensPerDay {
+		return fmt.Errorf("daily token budget exhausted (%d/%d tokens)", b.tokens, b.limits.MaxTokensPerDay)
+	}
+	if b.limits.MaxRequestsPerDay > 0 && b.requests >= b.limits.MaxRequestsPerDay {
+		return fmt.Errorf("daily request budget exhausted (%d/%d requests)", b.requests, b.limits.MaxRequestsPerDay)
+	}
+	b.requests++
+	return nil
+}
+
+// RecordTokens adds token usage to today's total
+func (b *BudgetTracker) RecordTokens(tokens int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.rollover()
+	b.tokens += tokens
+}
+
+// rollover resets counters when the day changes. Caller must hold the mutex.
+func (b *BudgetTracker) rollover() {
+	today := time.Now().Format("2006-01-02")
+	if b.day != today {
+		b.day = today
+		b.requests = 0
+		b.tokens = 0
+	}
+}
@@ -0,0 +1,203 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// ResponseCache stores generated code on disk keyed by a hash of the request inputs,
+// so identical requests re-issued after a restart don't hit the provider again
+type ResponseCache struct {
+	dir        string
+	ttl        time.Duration
+	maxEntries int
+	mutex      sync.Mutex
+	hits       int64
+	misses     int64
+}
+
+// cacheEntry is the on-disk representation of a cached generation
+type cacheEntry struct {
+	Created  time.Time    `json:"created"`
+	Provider string       `json:"provider"`
+	Model    string       `json:"model"`
+	Code     string       `json:"code"`
+	Usage    *types.Usage `json:"usage,omitempty"`
+}
+
+// NewResponseCache creates a response cache from configuration.
+// Returns nil if caching is disabled.
+func NewResponseCache(cfg config.CacheConfig) (*ResponseCache, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(config.ConfigDir(), "cache")
+	}
+	dir = config.ExpandPath(dir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	return &ResponseCache{
+		dir:        dir,
+		ttl:        ttl,
+		maxEntries: cfg.MaxEntries,
+	}, nil
+}
+
+// Key computes the cache key for a request. The key covers the prompt, the model,
+// the current content of the output file and the content of every context file.
+func (c *ResponseCache) Key(providerName, model, prompt, filePath string, contextFiles []string) string {
+	h := sha256.New()
+	writeField := func(s string) {
+		fmt.Fprintf(h, "%d:%s\n", len(s), s)
+	}
+
+	writeField(providerName)
+	writeField(model)
+	writeField(prompt)
+	writeField(filePath)
+	existing, _ := utils.ReadFileContentCached(filePath)
+	writeField(existing)
+
+	sorted := append([]string{}, contextFiles...)
+	sort.Strings(sorted)
+	for _, file := range sorted {
+		content, _ := utils.ReadFileContentCached(file)
+		writeField(file)
+		writeField(content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns a cached generation if present and not expired
+func (c *ResponseCache) Get(key string) (*cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		c.misses++
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logger.Debugf("Cache: discarding corrupt entry %s: %v", key, err)
+		_ = os.Remove(c.entryPath(key))
+		c.misses++
+		return nil, false
+	}
+
+	if time.Since(entry.Created) > c.ttl {
+		_ = os.Remove(c.entryPath(key))
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	return &entry, true
+}
+
+// Put stores a generation in the cache
+func (c *ResponseCache) Put(key, providerName, model, code string, usage *types.Usage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, err := json.Marshal(cacheEntry{
+		Created:  time.Now(),
+		Provider: providerName,
+		Model:    model,
+		Code:     code,
+		Usage:    usage,
+	})
+	if err != nil {
+		logger.Warnf("Cache: failed to encode entry: %v", err)
+		return
+	}
+
+	tmpFile := c.entryPath(key) + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		logger.Warnf("Cache: failed to write entry: %v", err)
+		return
+	}
+	if err := os.Rename(tmpFile, c.entryPath(key)); err != nil {
+		logger.Warnf("Cache: failed to store entry: %v", err)
+		return
+	}
+
+	c.prune()
+}
+
+// Stats returns the number of cache hits and misses since startup
+func (c *ResponseCache) Stats() (hits, misses int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.hits, c.misses
+}
+
+// prune removes expired entries and, if maxEntries is set, the oldest entries
+// beyond the limit (caller must hold lock)
+func (c *ResponseCache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type fileAge struct {
+		path    string
+		modTime time.Time
+	}
+	var live []fileAge
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.dir, e.Name())
+		if time.Since(info.ModTime()) > c.ttl {
+			_ = os.Remove(path)
+			continue
+		}
+		live = append(live, fileAge{path: path, modTime: info.ModTime()})
+	}
+
+	if c.maxEntries <= 0 || len(live) <= c.maxEntries {
+		return
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return live[i].modTime.Before(live[j].modTime)
+	})
+	for _, f := range live[:len(live)-c.maxEntries] {
+		_ = os.Remove(f.path)
+	}
+}
+
+func (c *ResponseCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
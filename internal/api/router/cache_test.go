@@ -0,0 +1,135 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestResponseCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "main.go")
+	helper := filepath.Join(dir, "helper.go")
+	other := filepath.Join(dir, "other.go")
+	for path, content := range map[string]string{target: "package main\n", helper: "package main\n\nfunc helper() {}\n", other: "package main\n"} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := NewResponseCache(config.CacheConfig{Enabled: true, Dir: filepath.Join(dir, "cache")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := func() string {
+		return c.Key("cerebras", "zai-glm-4.6", "add main", target, []string{helper, other})
+	}
+	base := key()
+	if got := c.Key("cerebras", "zai-glm-4.6", "add main", target, []string{other, helper}); got != base {
+		t.Error("the order of the context files changed the key")
+	}
+	for name, changed := range map[string]string{
+		"provider":      c.Key("openrouter", "zai-glm-4.6", "add main", target, []string{helper, other}),
+		"model":         c.Key("cerebras", "qwen-3-coder-480b", "add main", target, []string{helper, other}),
+		"prompt":        c.Key("cerebras", "zai-glm-4.6", "add a main", target, []string{helper, other}),
+		"context files": c.Key("cerebras", "zai-glm-4.6", "add main", target, []string{helper}),
+	} {
+		if changed == base {
+			t.Errorf("a different %s gave the same key", name)
+		}
+	}
+
+	// Edits are seen through the size, since the modification time may not move
+	if err := os.WriteFile(helper, []byte("package main\n\nfunc helper() int { return 1 }\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	afterContext := key()
+	if afterContext == base {
+		t.Error("editing a context file left the key unchanged")
+	}
+	if err := os.WriteFile(target, []byte("package main\n\nfunc main() {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if key() == afterContext {
+		t.Error("editing the output file left the key unchanged")
+	}
+}
+
+func TestResponseCacheExpires(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewResponseCache(config.CacheConfig{Enabled: true, Dir: dir, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Put("fresh", "cerebras", "zai-glm-4.6", "package main\n", &types.Usage{TotalTokens: 7})
+	entry, ok := c.Get("fresh")
+	if !ok || entry.Code != "package main\n" || entry.Provider != "cerebras" || entry.Usage.TotalTokens != 7 {
+		t.Fatalf("Get(fresh) = %+v, %v; want the stored entry", entry, ok)
+	}
+
+	// An entry older than the TTL is a miss and is removed
+	stale, err := json.Marshal(cacheEntry{Created: time.Now().Add(-2 * time.Hour), Provider: "cerebras", Code: "old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.entryPath("stale"), stale, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("stale"); ok {
+		t.Error("Get(stale) hit an entry past its TTL")
+	}
+	if _, err := os.Stat(c.entryPath("stale")); !os.IsNotExist(err) {
+		t.Errorf("expired entry still on disk: %v", err)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Error("Get(missing) hit")
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 2 {
+		t.Errorf("Stats() = %d hits, %d misses; want 1 and 2", hits, misses)
+	}
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	c, err := NewResponseCache(config.CacheConfig{Dir: t.TempDir()})
+	if c != nil || err != nil {
+		t.Fatalf("NewResponseCache(disabled) = %v, %v; want nil", c, err)
+	}
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package main\n"}}]}`)
+	}))
+	defer server.Close()
+	generateTwice := func(r *EnhancedRouter) int32 {
+		calls.Store(0)
+		for range 2 {
+			if _, err := r.GenerateCodeWithValidation(context.Background(), "add main", "main.go", nil, "", "", false, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return calls.Load()
+	}
+
+	if n := generateTwice(fallbackRouter(server.URL, server.URL, t.Name())); n != 2 {
+		t.Errorf("provider calls without a cache = %d, want 2", n)
+	}
+	cached := fallbackRouter(server.URL, server.URL, t.Name())
+	if cached.cache, err = NewResponseCache(config.CacheConfig{Enabled: true, Dir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	if n := generateTwice(cached); n != 1 {
+		t.Errorf("provider calls with a cache = %d, want 1", n)
+	}
+}
@@ -0,0 +1,140 @@
+package router
+
+import (
+	"sort"
+	"strings"
+)
+
+// ModelCapabilities describes what a configured provider/model can do
+type ModelCapabilities struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Streaming        bool    `json:"streaming"`
+	ToolCalling      bool    `json:"tool_calling"`
+	ToolFormat       string  `json:"tool_format"`
+	ResponsesAPI     bool    `json:"responses_api"`
+	MaxContextTokens int     `json:"max_context_tokens,omitempty"` // 0 = unknown
+	MaxOutputTokens  int     `json:"max_output_tokens,omitempty"`  // 0 = unknown
+	InputCost        float64 `json:"input_cost,omitempty"`         // USD per million prompt tokens
+	OutputCost       float64 `json:"output_cost,omitempty"`        // USD per million completion tokens
+	Vision           bool    `json:"vision"`
+	StructuredOutput bool    `json:"structured_output"`
+	Healthy          bool    `json:"healthy"`
+	Known            bool    `json:"known"` // false when the model capability registry has no entry
+}
+
+// knownModel holds published limits for a model family, matched by ID prefix
+type knownModel struct {
+	prefix           string
+	maxContext       int
+	vision           bool
+	structuredOutput bool
+}
+
+// knownModels is ordered so more specific prefixes come first
+var knownModels = []knownModel{
+	{"claude-opus-4", 200000, true, false},
+	{"claude-sonnet-4", 200000, true, false},
+	{"claude-3-7", 200000, true, false},
+	{"claude-3-5-haiku", 200000, false, false},
+	{"claude-3-5", 200000, true, false},
+	{"claude-3", 200000, true, false},
+	{"gpt-4.1", 1047576, true, true},
+	{"gpt-4o", 128000, true, true},
+	{"o4-mini", 200000, true, true},
+	{"o3", 200000, true, true},
+	{"gemini-2.5", 1048576, true, true},
+	{"gemini-2.0", 1048576, true, true},
+	{"gemini-1.5-pro", 2097152, true, true},
+	{"gemini-1.5-flash", 1048576, true, true},
+	{"qwen/qwen3-coder", 262144, false, true},
+	{"qwen3-coder", 262144, false, true},
+	{"qwen-max", 32768, false, true},
+	{"llama-3.3-70b", 128000, false, true},
+	{"llama3.1-8b", 128000, false, true},
+	{"zai-glm-4.6", 131072, false, true},
+	{"codestral", 256000, false, true},
+	{"devstral", 128000, false, true},
+	{"mistral-large", 128000, false, true},
+	{"mistral-small", 128000, false, true},
+	{"meta.llama3-1", 128000, false, false},
+	{"meta.llama3", 8192, false, false},
+	{"amazon.titan-text-premier", 32000, false, false},
+	{"amazon.titan-text", 8192, false, false},
+}
+
+// lookupKnownModel finds the built-in limits for model, if any
+func lookupKnownModel(model string) (knownModel, bool) {
+	model = strings.ToLower(model)
+	// Strip routing prefixes like "anthropic/" and suffixes like ":free"
+	if i := strings.LastIndex(model, "/"); i >= 0 && !strings.HasPrefix(model, "qwen/") {
+		model = model[i+1:]
+	}
+	model = strings.TrimSuffix(model, ":free")
+	// Bedrock IDs: optional cross-region prefix ("us."), vendor prefix for Claude
+	for _, prefix := range []string{"us.", "eu.", "apac.", "anthropic."} {
+		model = strings.TrimPrefix(model, prefix)
+	}
+	for _, km := range knownModels {
+		if strings.HasPrefix(model, km.prefix) {
+			return km, true
+		}
+	}
+	return knownModel{}, false
+}
+
+// GetCapabilities returns the capability matrix for every provider in the live
+// registry, one entry per configured model
+func (r *EnhancedRouter) GetCapabilities() []ModelCapabilities {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []ModelCapabilities
+	for providerType, p := range r.providers {
+		name := string(providerType)
+		healthy := true
+		if status := r.healthStatus[providerType]; status != nil {
+			healthy = status.IsHealthy
+		}
+
+		models := strings.Split(r.configuredModel(name), ",")
+		for _, model := range models {
+			model = strings.TrimSpace(model)
+			caps := ModelCapabilities{
+				Provider:     name,
+				Model:        model,
+				Streaming:    p.SupportsStreaming(),
+				ToolCalling:  p.SupportsToolCalling(),
+				ToolFormat:   string(p.GetToolFormat()),
+				ResponsesAPI: p.SupportsResponsesAPI(),
+				Healthy:      healthy,
+			}
+			if km, ok := lookupKnownModel(model); ok {
+				caps.Vision = km.vision
+				caps.StructuredOutput = km.structuredOutput
+			}
+			if spec, ok := r.models.Lookup(name, model); ok {
+				caps.Known = true
+				caps.MaxContextTokens = spec.MaxContext
+				caps.MaxOutputTokens = spec.MaxOutput
+				caps.InputCost = spec.InputCost
+				caps.OutputCost = spec.OutputCost
+				if spec.ToolCalling != nil {
+					caps.ToolCalling = *spec.ToolCalling
+				}
+				if spec.Streaming != nil {
+					caps.Streaming = *spec.Streaming
+				}
+			}
+			result = append(result, caps)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Provider != result[j].Provider {
+			return result[i].Provider < result[j].Provider
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result
+}
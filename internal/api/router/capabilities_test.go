@@ -0,0 +1,27 @@
+package router
+
+import "testing"
+
+func TestLookupKnownModel(t *testing.T) {
+	tests := []struct {
+		model      string
+		known      bool
+		maxContext int
+	}{
+		{"claude-sonnet-4-20250514", true, 200000},
+		{"anthropic/claude-3-5-sonnet", true, 200000},
+		{"gpt-4o-mini", true, 128000},
+		{"qwen/qwen3-coder:free", true, 262144},
+		{"gemini-2.5-pro", true, 1048576},
+		{"us.anthropic.claude-3-7-sonnet-20250219-v1:0", true, 200000},
+		{"meta.llama3-1-70b-instruct-v1:0", true, 128000},
+		{"some-unknown-model", false, 0},
+	}
+
+	for _, tt := range tests {
+		km, ok := lookupKnownModel(tt.model)
+		if ok != tt.known || km.maxContext != tt.maxContext {
+			t.Errorf("lookupKnownModel(%q) = (%d, %v), want (%d, %v)", tt.model, km.maxContext, ok, tt.maxContext, tt.known)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -12,7 +13,14 @@ import (
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
 	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/guard"
+	"github.com/cecil-the-coder/mcp-code-api/internal/index"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
+	"github.com/cecil-the-coder/mcp-code-api/internal/symbols"
+	"github.com/cecil-the-coder/mcp-code-api/internal/tracing"
+	"github.com/cecil-the-coder/mcp-code-api/internal/transform"
 	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
 	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
 )
@@ -26,6 +34,21 @@ type EnhancedRouter struct {
 	metrics              RouterMetrics
 	providerMetrics      map[string]*ProviderMetricsTracker
 	overallLatencyTracker *LatencyTracker // Track overall request latencies
+	transforms           *transform.Pipeline // Compliance transformations applied to outbound prompts
+	guard                *guard.Guard             // Prompt-injection guard for context files (nil = off)
+	symbols              *symbols.Extractor       // Cuts Go context files down to relevant declarations (nil = off)
+	residency            *policy.ResidencyChecker // Data-residency constraints (nil = unrestricted)
+	license              *policy.LicenseChecker   // License policy for generated code (nil = off)
+	index                *index.Manager           // Repository index for retrieved context (nil = off)
+	cache                *ResponseCache           // Optional response cache (nil = disabled)
+	budget               *BudgetTracker           // Daily usage limits (nil = unlimited)
+	queue                *GenerationQueue         // In-flight generation limit (nil = unlimited)
+	rateLimits           *RateLimiter             // Per-provider request rates (nil = unlimited)
+	retry                *RetryPolicy             // Retries of transient provider errors (nil = disabled)
+	prompts              *prompts.Set             // Custom prompt templates (nil = built-in)
+	recorder             *Recorder                // Record/replay of provider responses (nil = off)
+	models               *ModelRegistry           // Model limits, cost and capabilities (nil = built-in table only)
+	racingStats          map[string]*api.RacingStats // Race outcomes per racing provider, kept across requests
 	mutex                sync.RWMutex
 	logger               *log.Logger
 }
@@ -44,6 +67,16 @@ type RouterMetrics struct {
 	SuccessfulRequests int64 `json:"SuccessfulRequests"`
 	FailedRequests     int64 `json:"FailedRequests"`
 	FallbackAttempts   int64 `json:"FallbackAttempts"`
+
+	// Requests that waited for a slot under limits.max_concurrent, and for how long
+	QueuedRequests int64         `json:"QueuedRequests"`
+	TotalQueueWait time.Duration `json:"TotalQueueWait"`
+	MaxQueueWait   time.Duration `json:"MaxQueueWait"`
+
+	// Requests sent to a second provider under providers.hedging, and how
+	// many of those the second provider answered first
+	HedgedRequests int64 `json:"HedgedRequests"`
+	HedgeWins      int64 `json:"HedgeWins"`
 }
 
 // ValidationWarningFunc is called to send validation warnings to the client
@@ -58,6 +91,10 @@ func NewEnhancedRouter(config *config.Config, factory *provider.DefaultProviderF
 		healthStatus:         make(map[types.ProviderType]*HealthStatus),
 		providerMetrics:      make(map[string]*ProviderMetricsTracker),
 		overallLatencyTracker: NewLatencyTracker(1000), // Track last 1000 overall requests
+		racingStats: map[string]*api.RacingStats{
+			"racing":        api.NewRacingStats(),
+			"racing-clever": api.NewRacingStats(),
+		},
 		metrics: RouterMetrics{
 			TotalRequests:      0,
 			SuccessfulRequests: 0,
@@ -70,10 +107,107 @@ func NewEnhancedRouter(config *config.Config, factory *provider.DefaultProviderF
 
 // Initialize initializes the router with configured providers
 func (r *EnhancedRouter) Initialize(ctx context.Context) error {
+	// Build the compliance transformation pipeline first - a broken redaction
+	// config must stop the server rather than silently sending unscrubbed data
+	transforms, err := transform.NewPipelineFromConfig(r.config.Redaction)
+	if err != nil {
+		return fmt.Errorf("failed to build redaction pipeline: %w", err)
+	}
+	r.transforms = transforms
+	if transforms != nil {
+		r.logger.Printf("Redaction enabled with %d transformer(s)", transforms.Len())
+	}
+
+	// A broken template would otherwise fail every request
+	promptSet, err := prompts.NewSet(r.config.Templates)
+	if err != nil {
+		return fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+	r.prompts = promptSet
+
+	contextGuard, err := guard.New(r.config.ContextFiles.Guard)
+	if err != nil {
+		return fmt.Errorf("failed to build context guard: %w", err)
+	}
+	r.guard = contextGuard
+	if contextGuard != nil {
+		r.logger.Printf("Context guard enabled (strip: %v)", r.config.ContextFiles.Guard.Strip)
+	}
+
+	r.symbols = symbols.New(r.config.ContextFiles.Symbols)
+	if r.symbols != nil {
+		r.logger.Printf("Go symbol extraction enabled for context files over %d bytes", r.config.ContextFiles.Symbols.MinSize)
+	}
+
+	r.residency = policy.NewResidencyChecker(r.config.Residency)
+	if r.residency != nil {
+		r.logger.Printf("Data-residency policies enabled (%d rule(s))", len(r.config.Residency.Policies))
+	}
+
+	licenseChecker, err := policy.NewLicenseChecker(r.config.License)
+	if err != nil {
+		return fmt.Errorf("failed to load license policy: %w", err)
+	}
+	r.license = licenseChecker
+	if licenseChecker != nil {
+		r.logger.Printf("License policy enabled (mode: %s, %d blocklist window(s))", r.config.License.Mode, licenseChecker.Windows())
+	}
+
+	if r.config.Index.Enabled {
+		if err := index.CheckPolicy(r.config); err != nil {
+			return fmt.Errorf("failed to set up the repository index: %w", err)
+		}
+	}
+	indexManager, err := index.NewManager(r.config.Index)
+	if err != nil {
+		return fmt.Errorf("failed to set up the repository index: %w", err)
+	}
+	r.index = indexManager
+	if indexManager != nil {
+		// Files the write tool may not read are not embedded either
+		indexManager.Filter = func(path string) bool { return policy.WorkspaceAllows(r.config.Workspace, path) }
+		r.logger.Printf("Repository index enabled (embedding: %s)", indexManager.Embedder().Name())
+	}
+
+	cache, err := NewResponseCache(r.config.Cache)
+	if err != nil {
+		// Caching is an optimization - keep serving without it
+		logger.Warnf("Response cache disabled: %v", err)
+	}
+	r.cache = cache
+
+	// Replaying without recordings would silently fail every request
+	recorder, err := NewRecorder(r.config.Recording)
+	if err != nil {
+		return fmt.Errorf("failed to set up recording: %w", err)
+	}
+	r.recorder = recorder
+	if recorder != nil {
+		r.logger.Printf("Recording mode: %s (%s)", r.config.Recording.Mode, recorder.dir)
+	}
+
+	switch r.config.Providers.Strategy {
+	case "", config.StrategyOrder, config.StrategyCost:
+	default:
+		return fmt.Errorf("unknown providers.strategy %q (expected %q or %q)", r.config.Providers.Strategy, config.StrategyOrder, config.StrategyCost)
+	}
+
+	if p := r.config.Providers.Pipeline; p != nil && (p.Draft == "" || p.Refine == "") {
+		return fmt.Errorf("providers.pipeline needs both a draft and a refine provider")
+	}
+
+	r.budget = NewBudgetTracker(r.config.Limits)
+	r.queue = NewGenerationQueue(r.config.Limits.MaxConcurrent)
+	r.rateLimits = NewRateLimiter(r.config.Limits.Providers)
+	r.retry = NewRetryPolicy(r.config.Retry)
+	r.models = NewModelRegistry(r.config.Models)
+
 	// Only initialize providers that are enabled and have API keys configured
 	for _, providerName := range r.config.Providers.Enabled {
 		var apiKey string
 		var model string
+		providerType := types.ProviderType(providerName)
+		factoryType := providerType
 
 		// Get API key and model from config
 		switch providerName {
@@ -110,11 +244,60 @@ func (r *EnhancedRouter) Initialize(ctx context.Context) error {
 				apiKey = r.config.Providers.OpenAI.APIKey
 				model = r.config.Providers.OpenAI.Model
 			}
+		case "azure-openai":
+			if r.config.Providers.AzureOpenAI != nil && r.config.Providers.AzureOpenAI.HasCredentials() {
+				apiKey = "configured" // API key or Entra ID; the client picks the auth header
+				model = r.config.Providers.AzureOpenAI.Deployment
+			}
+		case "bedrock":
+			if r.config.Providers.Bedrock != nil && r.config.Providers.Bedrock.HasCredentials() {
+				apiKey = "configured" // SigV4 credentials; the client signs each request
+				model = r.config.Providers.Bedrock.Model
+			}
+		case "mistral":
+			if r.config.Providers.Mistral != nil {
+				if keys := r.config.Providers.Mistral.GetAllAPIKeys(); len(keys) > 0 {
+					apiKey = keys[0]
+				}
+				model = r.config.Providers.Mistral.Model
+			}
+		case "vertex":
+			if r.config.Providers.Vertex != nil && r.config.Providers.Vertex.HasCredentials() {
+				apiKey = "configured" // Service account or access token; the client authenticates
+				model = r.config.Providers.Vertex.Model
+			}
+		case "lmstudio", "llamacpp":
+			if local := localProviderConfig(r.config.Providers, providerName); local != nil && local.BaseURL != "" {
+				apiKey = "local" // No credentials; availability is health-probed instead
+				model = local.Model
+			}
+		case "mock":
+			apiKey = "mock" // Canned responses; needs no credentials
+			model = providerModel(r.config.Providers, providerName)
 		case "qwen":
-			if r.config.Providers.Qwen != nil && r.config.Providers.Qwen.APIKey != "" {
-				apiKey = r.config.Providers.Qwen.APIKey
+			if r.config.Providers.Qwen != nil {
+				if keys := r.config.Providers.Qwen.GetAllAPIKeys(); len(keys) > 0 {
+					apiKey = keys[0]
+				}
 				model = r.config.Providers.Qwen.Model
 			}
+		case "synthetic":
+			if r.config.Providers.Synthetic != nil {
+				if keys := r.config.Providers.Synthetic.GetAllAPIKeys(); len(keys) > 0 {
+					apiKey = keys[0]
+				}
+				model = r.config.Providers.Synthetic.Model
+			}
+		default:
+			if custom, ok := r.config.Providers.Custom[providerName]; ok {
+				if err := custom.Validate(); err != nil {
+					r.logger.Printf("Skipping custom provider %s: %v", providerName, err)
+					continue
+				}
+				apiKey = "configured" // Keys are optional for self-hosted endpoints
+				model = custom.Model
+				factoryType = types.ProviderTypeOpenAICompatible
+			}
 		}
 
 		// Skip if no API key
@@ -133,62 +316,208 @@ func (r *EnhancedRouter) Initialize(ctx context.Context) error {
 		}
 
 		// Create provider
-		providerType := types.ProviderType(providerName)
-		provider, err := r.factory.CreateProvider(providerType, providerConfig)
+		provider, err := r.factory.CreateProvider(factoryType, providerConfig)
 		if err != nil {
 			r.logger.Printf("Failed to create provider %s: %v", providerName, err)
 			continue
 		}
 
-		// Store provider
-		r.mutex.Lock()
-		r.providers[providerType] = provider
-
-		// Initialize health status (will be updated on first request)
-		r.healthStatus[providerType] = &HealthStatus{
+		// Initialize health status (will be updated on first request). Local
+		// servers are probed now so a stopped one shows up immediately.
+		health := &HealthStatus{
 			IsHealthy:    true,
 			LastChecked:  time.Now(),
 			ErrorMessage: "",
 			ResponseTime: 0,
 		}
+		if local := localProviderConfig(r.config.Providers, providerName); local != nil {
+			if !api.NewLocalClient(providerName, *local).IsHealthy(ctx) {
+				health.IsHealthy = false
+				health.ErrorMessage = fmt.Sprintf("not running at %s", local.BaseURL)
+				r.logger.Printf("⚠️ Provider %s is not running at %s; it will be skipped until it comes up", providerName, local.BaseURL)
+			}
+		}
+
+		// Store provider
+		r.mutex.Lock()
+		r.providers[providerType] = provider
+		r.healthStatus[providerType] = health
 		r.mutex.Unlock()
 
 		r.logger.Printf("✅ Provider %s initialized successfully", providerName)
 	}
 
 	r.logger.Printf("Router initialized with %d providers", len(r.providers))
+	if len(r.providers) == 0 && !r.racingConfigured() {
+		return fmt.Errorf("no providers available: none of the enabled providers (%s) has its credentials or address configured", strings.Join(r.config.Providers.Enabled, ", "))
+	}
+
+	// Learn the models' context windows in the background; until then the
+	// built-in table and config overrides apply
+	var discoverable []string
+	for providerType := range r.providers {
+		if name := string(providerType); name != "mock" && name != "racing" && name != "racing-clever" {
+			discoverable = append(discoverable, name)
+		}
+	}
+	go r.models.Seed(context.Background(), r.config, discoverable)
 	return nil
 }
 
-// GenerateCodeWithValidation generates code with validation retry and provider failover
+// GenerateCodeWithValidation generates code with validation retry and provider
+// failover. A non-empty requestedProvider sends the request to that provider
+// only, with requestedModel (or its configured model, if that is empty); an
+// unknown or disabled provider or a model it doesn't offer is an error.
+// requestedModel alone may be given as "provider/model".
 func (r *EnhancedRouter) GenerateCodeWithValidation(
 	ctx context.Context,
 	prompt string,
 	filePath string,
 	contextFiles []string,
+	requestedProvider string,
+	requestedModel string,
 	validateCode bool,
 	warningCallback ValidationWarningFunc,
-) (string, error) {
-	const maxRetriesPerProvider = 2
+) (code string, err error) {
+	ctx, span := tracing.Start(ctx, "router.generate", tracing.Bool("mcp.validate", validateCode))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
 
 	// Update total requests counter
 	r.mutex.Lock()
 	r.metrics.TotalRequests++
 	r.mutex.Unlock()
 
-	// Try providers in the preferred order
-	preferredOrder := r.config.Providers.Order
+	prompt = withPreamble(ctx, r.withRetrievedContext(ctx, prompt, filePath, contextFiles))
+	validationCfg := r.ValidationConfig(ctx)
+	maxRetriesPerProvider := validationCfg.RetryBudget()
+
+	requestedProvider, requestedModel, err = r.resolveSelection(requestedProvider, requestedModel)
+	if err != nil {
+		r.mutex.Lock()
+		r.metrics.FailedRequests++
+		r.mutex.Unlock()
+		return "", err
+	}
+
+	if r.budget != nil {
+		if err := r.budget.Reserve(); err != nil {
+			r.mutex.Lock()
+			r.metrics.FailedRequests++
+			r.mutex.Unlock()
+			return "", err
+		}
+	}
+
+	if r.queue != nil {
+		wait, err := r.queue.Acquire(ctx)
+		if err != nil {
+			r.mutex.Lock()
+			r.metrics.FailedRequests++
+			r.mutex.Unlock()
+			return "", err
+		}
+		defer r.queue.Release()
+		if wait > 0 {
+			logger.Debugf("Waited %v for a generation slot", wait)
+		}
+	}
+
+	// Read the files every provider attempt sends at once; retries and
+	// fallbacks then only check that they are unchanged
+	utils.PrefetchFiles(append([]string{filePath}, contextFiles...))
+
+	// Try providers in the preferred order, with fallback chains expanded
+	// into their provider:model steps; a project may set its own order
+	preferredOrder := config.ExpandOrder(r.config.Providers.Order)
 	if len(preferredOrder) == 0 {
 		// Default order if not specified
 		preferredOrder = []string{"anthropic", "cerebras", "openrouter", "gemini"}
 	}
+	if project := projectFrom(ctx); project != nil && len(project.Order) > 0 {
+		preferredOrder = r.projectOrder(preferredOrder, project.Order)
+	}
+
+	// A language profile picks the provider, model and settings for this file type
+	profile := r.profileFor(ctx, filePath)
+	if profile != nil {
+		preferredOrder = profile.order(preferredOrder)
+		ctx = withProfile(ctx, profile)
+		logger.Debugf("Using %s profile (model: %s)", profile.language, profile.Model)
+		span.SetAttributes(tracing.String("mcp.profile", profile.language))
+	}
+
+	// A provider named in the request replaces the order, and its model the
+	// profile's; the profile's other settings still apply
+	if requestedProvider != "" {
+		selected := &languageProfile{}
+		if profile != nil {
+			*selected = *profile
+		}
+		selected.provider, selected.model = requestedProvider, requestedModel
+		profile = selected
+		preferredOrder = []string{requestedProvider}
+		ctx = withProfile(ctx, profile)
+		logger.Debugf("Using requested provider %s (model: %s)", requestedProvider, requestedModel)
+		span.SetAttributes(tracing.String("mcp.requested_provider", requestedProvider))
+	}
+
+	promptTokens := estimateTokens(prompt, filePath, contextFiles)
+
+	// The cost strategy tries the cheapest model first; a provider the
+	// profile names explicitly stays first
+	if r.config.Providers.Strategy == config.StrategyCost {
+		pinned := 0
+		if profile != nil && profile.provider != "" {
+			pinned = 1
+		}
+		preferredOrder = append(preferredOrder[:pinned:pinned],
+			r.costOrder(ctx, preferredOrder[pinned:], promptTokens, estimateTokens("", filePath, nil))...)
+	}
 
 	logger.Debugf("=== ENHANCED ROUTER DEBUG ===")
 	logger.Debugf("Preferred order: %s", strings.Join(preferredOrder, ", "))
 	logger.Debugf("Enabled providers: %s", strings.Join(r.config.Providers.Enabled, ", "))
 	logger.Debugf("Validation enabled: %v", validateCode)
 
-	for _, providerName := range preferredOrder {
+	// Attach a redaction session so every attempt (including retries and
+	// fallbacks) uses the same placeholders for the same values
+	if r.transforms != nil {
+		ctx = transform.WithSession(ctx, r.transforms.NewSession())
+	}
+	ctx = prompts.WithSet(ctx, r.prompts)
+	ctx = guard.WithGuard(ctx, r.guard)
+	ctx = symbols.WithExtractor(ctx, r.symbols)
+
+	// The draft + refine pipeline goes first unless the request names a provider
+	if requestedProvider == "" && r.pipelineEnabled(filePath, contextFiles) {
+		start := time.Now()
+		if code, ok := r.runPipeline(ctx, prompt, filePath, contextFiles, validateCode, maxRetriesPerProvider, warningCallback); ok {
+			r.mutex.Lock()
+			r.metrics.SuccessfulRequests++
+			r.mutex.Unlock()
+			recordAttempts(ctx, nil, time.Since(start))
+			r.warnInsecure(ctx, filePath, code, warningCallback)
+			return code, nil
+		}
+		logger.Warnf("Pipeline: no usable code, falling back to %s", strings.Join(preferredOrder, ", "))
+	}
+
+	var policyErr, contextErr error
+	var attempts []ProviderAttempt
+	var unvalidated *validationError // Latest code that failed validation, for allow_unvalidated
+	attempted := 0
+	hedgedSteps := make(map[string]bool) // Steps already tried as another's hedge
+
+	for i, step := range preferredOrder {
+		if hedgedSteps[step] {
+			continue
+		}
+		// A step of a fallback chain names the model to use
+		stepCtx, providerName := stepContext(ctx, step)
+
 		// Skip if not enabled
 		enabled := false
 		for _, enabledProvider := range r.config.Providers.Enabled {
@@ -202,19 +531,68 @@ func (r *EnhancedRouter) GenerateCodeWithValidation(
 			continue
 		}
 
-		logger.Debugf("Trying provider: %s", providerName)
+		// Enforce data-residency policy before any bytes are sent
+		if err := r.checkResidency(providerName, filePath, contextFiles); err != nil {
+			logger.Warnf("Skipping %s: %v", providerName, err)
+			if policyErr == nil {
+				policyErr = err
+			}
+			continue
+		}
+
+		// A model that can't hold the prompt would only fail after a round trip
+		if model, maxContext := r.exceedsContext(stepCtx, providerName, promptTokens); model != "" {
+			logger.Warnf("Skipping %s: prompt of ~%d tokens exceeds %s's %d-token context window", providerName, promptTokens, model, maxContext)
+			if contextErr == nil {
+				contextErr = fmt.Errorf("prompt of ~%d tokens exceeds the %d-token context window of %s (%s)", promptTokens, maxContext, model, providerName)
+			}
+			continue
+		}
+		attempted++
 
-		// Try this provider with retry logic
-		result, err := r.tryProviderWithRetry(ctx, providerName, prompt, filePath, contextFiles, validateCode, maxRetriesPerProvider, warningCallback)
+		logger.Debugf("Trying provider: %s", step)
+		span.SetAttributes(tracing.Int("mcp.providers_attempted", attempted))
+
+		// Try this provider with retry logic, hedged with another if it is slow
+		try := func(ctx context.Context, providerName string) (string, error) {
+			return r.tryProviderWithRetry(ctx, providerName, prompt, filePath, contextFiles, validateCode, maxRetriesPerProvider, warningCallback)
+		}
+		var result string
+		start := time.Now()
+		if hedge, after := r.hedgeFor(ctx, preferredOrder, i, filePath, contextFiles, promptTokens); hedge != "" {
+			var hedged bool
+			result, hedged, err = r.tryHedged(ctx, step, hedge, after, try)
+			hedgedSteps[hedge] = hedged
+		} else {
+			result, err = try(stepCtx, providerName)
+		}
 		if err == nil {
 			logger.Debugf("%s: Success!", providerName)
 			r.mutex.Lock()
 			r.metrics.SuccessfulRequests++
 			r.mutex.Unlock()
+			if len(attempts) > 0 {
+				logger.Warnf("%s succeeded after %d failed provider(s); first failure: %s", providerName, len(attempts), attempts[0].Error)
+			}
+			recordAttempts(ctx, attempts, time.Since(start))
+			r.warnInsecure(ctx, filePath, result, warningCallback)
 			return result, nil
 		}
 
 		logger.Debugf("%s: Failed after retries: %v", providerName, err)
+		attempts = append(attempts, newProviderAttempt(providerName, providerModel(r.providersFor(stepCtx, providerName), providerName), time.Since(start), err))
+
+		var valErr *validationError
+		var insecureErr *insecureCodeError
+		if errors.As(err, &valErr) || errors.As(err, &insecureErr) {
+			if valErr != nil {
+				unvalidated = valErr
+			}
+			if !validationCfg.FallbackOnFailure() {
+				logger.Warnf("%s: code failed validation and validation fallback is off; not trying other providers", providerName)
+				break
+			}
+		}
 
 		// Mark fallback attempt
 		r.mutex.Lock()
@@ -222,11 +600,82 @@ func (r *EnhancedRouter) GenerateCodeWithValidation(
 		r.mutex.Unlock()
 	}
 
+	// As a last resort, and only if allowed, the latest code that failed
+	// validation is returned with a warning
+	if unvalidated != nil && validationCfg.AllowUnvalidated {
+		logger.Warnf("No provider produced code that passes validation; returning unvalidated code")
+		if warningCallback != nil {
+			warningCallback("", fmt.Sprintf("⚠️ No provider produced code that passes validation; writing the last attempt unvalidated:\n%v", unvalidated))
+		}
+		r.mutex.Lock()
+		r.metrics.SuccessfulRequests++
+		r.mutex.Unlock()
+		recordUnvalidated(ctx, attempts)
+		r.warnInsecure(ctx, filePath, unvalidated.code, warningCallback)
+		return unvalidated.code, nil
+	}
+
 	// All providers failed
 	r.mutex.Lock()
 	r.metrics.FailedRequests++
 	r.mutex.Unlock()
-	return "", fmt.Errorf("all providers failed or no API keys configured")
+	if attempted == 0 && policyErr != nil {
+		return "", fmt.Errorf("no provider satisfies the data-residency policy: %w", policyErr)
+	}
+	if attempted == 0 && contextErr != nil {
+		return "", &GenerationError{
+			Kind: ErrorKindContextTooLarge,
+			Err:  fmt.Errorf("no provider's model can hold the prompt; use fewer or smaller context files: %w", contextErr),
+		}
+	}
+	return "", newGenerationError(fmt.Errorf("all providers failed or no API keys configured"), attempts)
+}
+
+// racingConfigured reports whether an enabled racing provider has models to
+// race; racing providers are virtual, so they aren't initialized
+func (r *EnhancedRouter) racingConfigured() bool {
+	for _, providerName := range r.config.Providers.Enabled {
+		switch providerName {
+		case "racing":
+			if r.config.Providers.Racing != nil && len(r.config.Providers.Racing.Models) > 0 {
+				return true
+			}
+		case "racing-clever":
+			if r.config.Providers.RacingClever != nil && len(r.config.Providers.RacingClever.Models) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkResidency verifies a provider may receive the output file and context files.
+// Virtual racing providers are checked against every provider they race.
+func (r *EnhancedRouter) checkResidency(providerName, filePath string, contextFiles []string) error {
+	if r.residency == nil {
+		return nil
+	}
+
+	paths := append([]string{filePath}, contextFiles...)
+
+	var racing *config.RacingConfig
+	switch providerName {
+	case "racing":
+		racing = r.config.Providers.Racing
+	case "racing-clever":
+		racing = r.config.Providers.RacingClever
+	}
+	if racing == nil {
+		return r.residency.Check(providerName, paths)
+	}
+
+	for _, providerModel := range racing.Models {
+		racer := strings.TrimSpace(strings.SplitN(providerModel, ":", 2)[0])
+		if err := r.residency.Check(racer, paths); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // tryProviderWithRetry tries a single provider with validation retry logic
@@ -252,21 +701,36 @@ func (r *EnhancedRouter) tryProviderWithRetry(
 
 		// Call the provider
 		result, err := r.callProvider(ctx, providerName, currentPrompt, filePath, contextFiles)
+		var licenseErr *policy.LicenseViolationError
+		if errors.As(err, &licenseErr) && attempt < maxRetries {
+			logger.Debugf("%s: %v", providerName, err)
+			currentPrompt = fmt.Sprintf("%s\n\n🚨 PREVIOUS ATTEMPT REPRODUCED CODE THE LICENSE POLICY FORBIDS:\n%s\n\nPlease write an original implementation instead.", originalPrompt, policy.FormatLicenseFindings(licenseErr.Findings))
+			continue
+		}
 		if err != nil {
 			// Provider call failed (API error, network error, etc.)
 			logger.Debugf("%s: API call failed: %v", providerName, err)
 			return "", err
 		}
 
-		// Clean the result
-		cleanResult := utils.CleanCodeResponse(result)
+		// Clean the result, and format it so validation and the diff see
+		// the project's style
+		cleanResult := r.FormatCode(ctx, filePath, utils.CleanCodeResponse(result))
 
-		// Validate if requested
-		if validateCode && filePath != "" {
+		// Validate if requested; a blocking security scan validates every
+		// file
+		validationCfg := r.ValidationConfig(ctx)
+		if (validateCode || validationCfg.Security.Blocks()) && filePath != "" {
 			language := validation.DetectLanguage(filePath)
 
-			if language != validation.LanguageUnknown {
-				validator := language.GetValidator()
+			var validator validation.Validator
+			if validateCode && language != validation.LanguageUnknown {
+				validator = language.ValidatorFor(validationCfg)
+			} else if validationCfg.Security.Blocks() {
+				validator = &validation.SecurityValidator{Validator: &validation.NoOpValidator{}, Config: validationCfg.Security}
+			}
+
+			if validator != nil {
 				validationResult, err := validator.Validate(cleanResult, filePath)
 
 				if err != nil {
@@ -274,7 +738,7 @@ func (r *EnhancedRouter) tryProviderWithRetry(
 
 					// On last attempt, return error
 					if attempt >= maxRetries {
-						return "", fmt.Errorf("validation error after %d retries: %w", maxRetries, err)
+						return "", &validationError{err: fmt.Errorf("validation error after %d retries: %w", maxRetries, err), code: cleanResult}
 					}
 
 					// Retry with error feedback
@@ -307,10 +771,15 @@ func (r *EnhancedRouter) tryProviderWithRetry(
 						logger.Debugf("%s: Auto-fix failed", providerName)
 					}
 
-					// On last attempt, return error
+					// On last attempt, return error; code the security scan
+					// blocks is never kept for allow_unvalidated
 					if attempt >= maxRetries {
 						errorMsg := validation.FormatValidationErrors(validationResult.Errors, language)
-						return "", fmt.Errorf("validation failed after %d retries:\n%s", maxRetries, errorMsg)
+						err := fmt.Errorf("validation failed after %d retries:\n%s", maxRetries, errorMsg)
+						if len(validationResult.Security) > 0 {
+							return "", &insecureCodeError{err: err}
+						}
+						return "", &validationError{err: err, code: cleanResult}
 					}
 
 					// Retry with validation feedback
@@ -332,6 +801,38 @@ func (r *EnhancedRouter) tryProviderWithRetry(
 	return "", fmt.Errorf("max retries exceeded")
 }
 
+// warnInsecure reports the security findings in code when the scan only
+// warns; blocking scans run as part of validation
+func (r *EnhancedRouter) warnInsecure(ctx context.Context, filePath, code string, warningCallback ValidationWarningFunc) {
+	cfg := r.ValidationConfig(ctx).Security
+	if !cfg.Warns() {
+		return
+	}
+	findings := validation.ScanSecurity(cfg, code, filePath)
+	if len(findings) == 0 {
+		return
+	}
+	logger.Warnf("Security scan flagged %d issue(s) in the code for %s", len(findings), filePath)
+	if warningCallback != nil {
+		warningCallback("", validation.FormatSecurityFindings(findings))
+	}
+}
+
+// FormatCode runs the configured formatter for filePath's language, or the
+// one of the project attached to ctx, over code. Code the formatter rejects
+// is returned unchanged for validation to report on.
+func (r *EnhancedRouter) FormatCode(ctx context.Context, filePath, code string) string {
+	formatted, ran, err := validation.Format(validation.ProjectFormatting(r.config.Formatting, projectFrom(ctx)), filePath, code)
+	if err != nil {
+		logger.Debugf("Formatting %s: %v", filePath, err)
+		return code
+	}
+	if ran {
+		logger.Debugf("Formatted %s", filePath)
+	}
+	return formatted
+}
+
 // callProvider calls a specific provider to generate code
 func (r *EnhancedRouter) callProvider(ctx context.Context, providerName, prompt, filePath string, contextFiles []string) (string, error) {
 	// Ensure provider metrics tracker exists
@@ -342,117 +843,147 @@ func (r *EnhancedRouter) callProvider(ctx context.Context, providerName, prompt,
 	tracker := r.providerMetrics[providerName]
 	r.mutex.Unlock()
 
-	// Start timing
-	startTime := time.Now()
-	language := ""
 	var result string
 	var err error
 	var modelUsed string
 	var tokenUsage *types.Usage
-
-	switch providerName {
-	case "anthropic":
-		if r.config.Providers.Anthropic != nil && r.config.Providers.Anthropic.APIKey != "" {
-			logger.Debugf("Anthropic: API key found, attempting call")
-			client := api.NewAnthropicClient(*r.config.Providers.Anthropic)
-			cgResult, err := client.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
-			if err == nil {
-				result = cgResult.Code
-				tokenUsage = cgResult.Usage
-			}
-			modelUsed = r.config.Providers.Anthropic.Model
-		} else {
-			err = fmt.Errorf("anthropic: no config or API key")
+	var citations []types.Citation
+	var startTime time.Time
+	var ttft time.Duration
+	var attempts int
+
+	requestModel := providerModel(r.providersFor(ctx, providerName), providerName)
+
+	ctx, span := tracing.Start(ctx, "provider.generate "+providerName,
+		tracing.String("gen_ai.system", providerName),
+		tracing.String("gen_ai.request.model", requestModel),
+	)
+	defer func() {
+		span.SetAttributes(tracing.Int("mcp.retry_count", max(attempts-1, 0)))
+		span.RecordError(err)
+		span.End()
+	}()
+
+	// In replay mode recordings stand in for every provider
+	var recordKey string
+	if r.recorder != nil {
+		recordKey = r.recorder.Key(providerName, prompt, filePath, contextFiles)
+	}
+	if r.recorder.Replaying() {
+		var rec *recording
+		if rec, err = r.recorder.Load(providerName, recordKey); err != nil {
+			return "", err
 		}
+		logger.Infof("Router: replaying recorded %s response (model: %s)", providerName, rec.Model)
+		span.SetAttributes(tracing.Bool("mcp.replay", true), tracing.String("gen_ai.response.model", rec.Model))
+		recordGeneration(ctx, GenerationInfo{Provider: providerName, Model: rec.Model, Cached: true})
+		return r.checkLicense(ctx, rec.Code, nil)
+	}
 
-	case "cerebras":
-		if r.config.Providers.Cerebras != nil && (r.config.Providers.Cerebras.APIKey != "" || len(r.config.Providers.Cerebras.APIKeys) > 0) {
-			logger.Debugf("Cerebras: API key found, attempting call")
-			client := api.NewCerebrasClient(*r.config.Providers.Cerebras)
-			cgResult, err := client.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
-			if err == nil {
-				result = cgResult.Code
-				tokenUsage = cgResult.Usage
-			}
-			modelUsed = r.config.Providers.Cerebras.Model
-		} else {
-			err = fmt.Errorf("cerebras: no config or API key")
+	// Serve identical requests from the response cache
+	var cacheKey string
+	if r.cache != nil {
+		cacheKey = r.cache.Key(providerName, requestModel, prompt, filePath, contextFiles)
+		if entry, ok := r.cache.Get(cacheKey); ok {
+			logger.Infof("Router: cache hit for %s (model: %s, age: %v)", providerName, entry.Model, time.Since(entry.Created).Round(time.Second))
+			span.SetAttributes(tracing.Bool("mcp.cache_hit", true), tracing.String("gen_ai.response.model", entry.Model))
+			recordGeneration(ctx, GenerationInfo{Provider: providerName, Model: entry.Model, Cached: true})
+			return r.checkLicense(ctx, entry.Code, nil)
 		}
+	}
 
-	case "openrouter":
-		if r.config.Providers.OpenRouter != nil && r.config.Providers.OpenRouter.APIKey != "" {
-			logger.Debugf("OpenRouter: API key found, attempting call")
-			client := api.NewOpenRouterClient(*r.config.Providers.OpenRouter)
-			cgResult, err := client.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
-			if err == nil {
-				result = cgResult.Code
-				tokenUsage = cgResult.Usage
-			}
-			modelUsed = client.GetLastUsedModel()
-		} else {
-			err = fmt.Errorf("openrouter: no config or API key")
+	// Retry transient failures (429, 5xx, timeouts) on this provider before
+	// the caller falls back to the next one
+	for attempt := 1; ; attempt++ {
+		// Queue behind the provider's rate limit; a full queue falls back to the next provider
+		if err = r.rateLimits.Wait(ctx, providerName); err != nil {
+			return "", err
 		}
-
-	case "racing":
-		if r.config.Providers.Racing != nil && len(r.config.Providers.Racing.Models) > 0 {
-			logger.Debugf("Racing: Starting model race with %d models", len(r.config.Providers.Racing.Models))
-			racingProvider := api.NewRacingProvider(r.config.Providers.Racing, r.config)
-			cgResult, err := racingProvider.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
-			if err == nil {
-				result = cgResult.Code
-				tokenUsage = cgResult.Usage
-			}
-			winner := racingProvider.GetLastWinner()
-			if winner != "" {
-				modelUsed = winner
-			} else {
-				modelUsed = "racing"
+		attempts = attempt
+
+		startTime = time.Now()
+		ttft = 0
+		attemptCtx, cancel := r.withProviderTimeout(ctx, providerName)
+		// Ask streaming providers for their first token to measure TTFT
+		attemptCtx = api.WithFirstToken(attemptCtx, func() {
+			if ttft == 0 {
+				ttft = time.Since(startTime)
+				signalFirstToken(ctx)
 			}
-		} else {
-			err = fmt.Errorf("racing: no models configured")
+		})
+		result, modelUsed, tokenUsage, citations, err = r.invokeProvider(attemptCtx, providerName, prompt, filePath, contextFiles)
+		if err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			err = fmt.Errorf("%s: no response within %v: %w", providerName, r.providerTimeout(providerName), err)
 		}
+		cancel()
 
-	case "racing-clever":
-		if r.config.Providers.RacingClever != nil && len(r.config.Providers.RacingClever.Models) > 0 {
-			logger.Debugf("Racing-Clever: Starting model race with %d models", len(r.config.Providers.RacingClever.Models))
-			racingProvider := api.NewRacingProvider(r.config.Providers.RacingClever, r.config)
-			cgResult, err := racingProvider.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
-			if err == nil {
-				result = cgResult.Code
-				tokenUsage = cgResult.Usage
-			}
-			winner := racingProvider.GetLastWinner()
-			if winner != "" {
-				modelUsed = winner
-			} else {
-				modelUsed = "racing-clever"
-			}
-		} else {
-			err = fmt.Errorf("racing-clever: no models configured")
+		delay, retry := r.retry.Backoff(ctx, attempt, err)
+		if !retry {
+			break
 		}
-
-	case "gemini":
-		if r.config.Providers.Gemini != nil && (r.config.Providers.Gemini.APIKey != "" || r.config.Providers.Gemini.AccessToken != "") {
-			logger.Debugf("Gemini: Calling API (OAuth: %v)", r.config.Providers.Gemini.AccessToken != "")
-			client := api.NewGeminiClient(*r.config.Providers.Gemini)
-			cgResult, err := client.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
-			if err == nil {
-				result = cgResult.Code
-				tokenUsage = cgResult.Usage
-			}
-			modelUsed = r.config.Providers.Gemini.Model
-		} else {
-			err = fmt.Errorf("gemini: no config or API key/OAuth")
+		logger.Warnf("%s: transient error on attempt %d/%d, retrying in %v: %v",
+			providerName, attempt, r.retry.MaxAttempts(), delay.Round(time.Millisecond), err)
+		if !sleepContext(ctx, delay) {
+			break
 		}
+	}
 
-	default:
-		err = fmt.Errorf("unknown provider: %s", providerName)
+	// A request cancelled by the caller, e.g. a hedge that lost, says
+	// nothing about the provider's health
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return "", err
 	}
 
 	// Record timing and update metrics
 	latency := time.Since(startTime)
 	success := err == nil
 
+	if modelUsed != "" {
+		span.SetAttributes(tracing.String("gen_ai.response.model", modelUsed))
+	}
+	if tokenUsage != nil {
+		span.SetAttributes(
+			tracing.Int("gen_ai.usage.input_tokens", tokenUsage.PromptTokens),
+			tracing.Int("gen_ai.usage.output_tokens", tokenUsage.CompletionTokens),
+			tracing.Int("gen_ai.usage.total_tokens", tokenUsage.TotalTokens),
+			tracing.Int("gen_ai.usage.cache_read_input_tokens", tokenUsage.CachedTokens),
+		)
+	}
+
+	// Restore redacted values in the generated code
+	if success {
+		if session := transform.SessionFromContext(ctx); session != nil {
+			result = session.Inbound(result)
+			logger.Debugf("Router: %d value(s) redacted for %s", session.Substitutions(), providerName)
+		}
+	}
+
+	if success {
+		recordGeneration(ctx, GenerationInfo{Provider: providerName, Model: modelUsed, Usage: tokenUsage, TTFT: ttft})
+	}
+
+	if success && r.cache != nil && result != "" {
+		r.cache.Put(cacheKey, providerName, modelUsed, result, tokenUsage)
+	}
+
+	if success && r.recorder.Recording() {
+		if err := r.recorder.Save(recordKey, &recording{
+			Provider:     providerName,
+			Model:        modelUsed,
+			Prompt:       prompt,
+			FilePath:     filePath,
+			ContextFiles: contextFiles,
+			Code:         result,
+			Usage:        tokenUsage,
+		}); err != nil {
+			logger.Warnf("Recording: %v", err)
+		}
+	}
+
+	if success && r.budget != nil && tokenUsage != nil {
+		r.budget.RecordTokens(tokenUsage.TotalTokens)
+	}
+
 	// Debug logging for token usage
 	if tokenUsage != nil {
 		logger.Debugf("Router: Provider %s returned tokenUsage - Total: %d", providerName, tokenUsage.TotalTokens)
@@ -462,14 +993,19 @@ func (r *EnhancedRouter) callProvider(ctx context.Context, providerName, prompt,
 
 	// Update provider-level metrics
 	tracker.RecordRequest(success, latency, tokenUsage)
+	if success && ttft > 0 {
+		tracker.RecordFirstToken(ttft)
+		span.SetAttributes(tracing.Int("gen_ai.server.time_to_first_token_ms", int(ttft.Milliseconds())))
+	}
 
 	// Update overall latency tracking (for successful requests only)
 	if success {
 		r.overallLatencyTracker.Add(latency)
 	}
 
-	// Update model-level metrics (for multi-model providers)
-	if success && modelUsed != "" {
+	// Update model-level metrics (for multi-model providers), failures
+	// included so that a failing model of a models list stands out
+	if modelUsed != "" && modelUsed != providerName {
 		modelKey := fmt.Sprintf("%s:%s", providerName, modelUsed)
 		r.mutex.Lock()
 		if r.providerMetrics[modelKey] == nil {
@@ -480,10 +1016,13 @@ func (r *EnhancedRouter) callProvider(ctx context.Context, providerName, prompt,
 
 		if tokenUsage != nil {
 			logger.Debugf("Router: Recording model metrics for %s with tokenUsage - Total: %d", modelKey, tokenUsage.TotalTokens)
-		} else {
+		} else if success {
 			logger.Warnf("Router: Recording model metrics for %s with nil tokenUsage", modelKey)
 		}
 		modelTracker.RecordRequest(success, latency, tokenUsage)
+		if success && ttft > 0 {
+			modelTracker.RecordFirstToken(ttft)
+		}
 		logger.Debugf("Recorded metrics for model: %s (key: %s)", modelUsed, modelKey)
 	}
 
@@ -503,13 +1042,206 @@ func (r *EnhancedRouter) callProvider(ctx context.Context, providerName, prompt,
 	}
 	r.mutex.Unlock()
 
-	return result, err
+	if err != nil {
+		return result, err
+	}
+	return r.checkLicense(ctx, result, citations)
+}
+
+// invokeProvider makes a single generation call to providerName
+func (r *EnhancedRouter) invokeProvider(ctx context.Context, providerName, prompt, filePath string, contextFiles []string) (result, modelUsed string, tokenUsage *types.Usage, citations []types.Citation, err error) {
+	language := ""
+	p := r.providersFor(ctx, providerName)
+
+	switch providerName {
+	case "racing":
+		if p.Racing != nil && len(p.Racing.Models) > 0 {
+			logger.Debugf("Racing: Starting model race with %d models", len(p.Racing.Models))
+			racingProvider := api.NewRacingProvider(p.Racing, r.config, r.racingStats["racing"])
+			var cgResult *types.CodeGenerationResult
+			cgResult, err = racingProvider.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
+			if err == nil {
+				result = cgResult.Code
+				tokenUsage = cgResult.Usage
+				citations = cgResult.Citations
+			}
+			r.recordAbandoned("racing", racingProvider.GetLastAbandoned(), tokenUsage, prompt, filePath, contextFiles)
+			winner := racingProvider.GetLastWinner()
+			if winner != "" {
+				modelUsed = winner
+			} else {
+				modelUsed = "racing"
+			}
+		} else {
+			err = fmt.Errorf("racing: no models configured")
+		}
+
+	case "racing-clever":
+		if p.RacingClever != nil && len(p.RacingClever.Models) > 0 {
+			logger.Debugf("Racing-Clever: Starting model race with %d models", len(p.RacingClever.Models))
+			racingProvider := api.NewRacingProvider(p.RacingClever, r.config, r.racingStats["racing-clever"])
+			var cgResult *types.CodeGenerationResult
+			cgResult, err = racingProvider.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
+			if err == nil {
+				result = cgResult.Code
+				tokenUsage = cgResult.Usage
+				citations = cgResult.Citations
+			}
+			r.recordAbandoned("racing-clever", racingProvider.GetLastAbandoned(), tokenUsage, prompt, filePath, contextFiles)
+			winner := racingProvider.GetLastWinner()
+			if winner != "" {
+				modelUsed = winner
+			} else {
+				modelUsed = "racing-clever"
+			}
+		} else {
+			err = fmt.Errorf("racing-clever: no models configured")
+		}
+
+	default:
+		var client api.CodeClient
+		if client, err = api.NewClient(providerName, p); err != nil {
+			break
+		}
+		logger.Debugf("%s: Calling model %s", providerName, client.GetModel())
+		var cgResult *types.CodeGenerationResult
+		cgResult, err = client.GenerateCode(ctx, prompt, "", filePath, &language, contextFiles)
+		if err == nil {
+			result = cgResult.Code
+			tokenUsage = cgResult.Usage
+			citations = cgResult.Citations
+		}
+		modelUsed = client.GetModel()
+	}
+
+	return result, modelUsed, tokenUsage, citations, err
+}
+
+// configuredModel returns the model configured for a provider, used to key the response cache
+func (r *EnhancedRouter) configuredModel(providerName string) string {
+	return providerModel(r.config.Providers, providerName)
+}
+
+// providerModel returns the model p configures for a provider
+func providerModel(p config.ProvidersConfig, providerName string) string {
+	switch providerName {
+	case "anthropic":
+		if p.Anthropic != nil {
+			if len(p.Anthropic.Models) > 0 {
+				return strings.Join(p.Anthropic.Models, ",")
+			}
+			return p.Anthropic.Model
+		}
+	case "cerebras":
+		if p.Cerebras != nil {
+			if len(p.Cerebras.Models) > 0 {
+				return strings.Join(p.Cerebras.Models, ",")
+			}
+			return p.Cerebras.Model
+		}
+	case "openrouter":
+		if p.OpenRouter != nil {
+			if len(p.OpenRouter.Models) > 0 {
+				return strings.Join(p.OpenRouter.Models, ",")
+			}
+			return p.OpenRouter.Model
+		}
+	case "gemini":
+		if p.Gemini != nil {
+			if len(p.Gemini.Models) > 0 {
+				return strings.Join(p.Gemini.Models, ",")
+			}
+			return p.Gemini.Model
+		}
+	case "openai":
+		if p.OpenAI != nil {
+			if len(p.OpenAI.Models) > 0 {
+				return strings.Join(p.OpenAI.Models, ",")
+			}
+			return p.OpenAI.Model
+		}
+	case "azure-openai":
+		if p.AzureOpenAI != nil {
+			return p.AzureOpenAI.Deployment
+		}
+	case "bedrock":
+		if p.Bedrock != nil {
+			return p.Bedrock.Model
+		}
+	case "qwen":
+		if p.Qwen != nil {
+			if len(p.Qwen.Models) > 0 {
+				return strings.Join(p.Qwen.Models, ",")
+			}
+			return p.Qwen.Model
+		}
+	case "synthetic":
+		if p.Synthetic != nil {
+			if len(p.Synthetic.Models) > 0 {
+				return strings.Join(p.Synthetic.Models, ",")
+			}
+			return p.Synthetic.Model
+		}
+	case "mistral":
+		if p.Mistral != nil {
+			return p.Mistral.Model
+		}
+	case "vertex":
+		if p.Vertex != nil {
+			return p.Vertex.Model
+		}
+	case "lmstudio", "llamacpp":
+		if local := localProviderConfig(p, providerName); local != nil {
+			return local.Model
+		}
+	case "mock":
+		if p.Mock != nil && p.Mock.Model != "" {
+			return p.Mock.Model
+		}
+		return "mock"
+	case "racing":
+		if p.Racing != nil {
+			return strings.Join(p.Racing.Models, ",")
+		}
+	case "racing-clever":
+		if p.RacingClever != nil {
+			return strings.Join(p.RacingClever.Models, ",")
+		}
+	default:
+		if custom, ok := p.Custom[providerName]; ok {
+			return custom.Model
+		}
+	}
+	return ""
+}
+
+// localProviderConfig returns the config of a local server provider, or nil
+// if providerName is not one (or is not configured)
+func localProviderConfig(p config.ProvidersConfig, providerName string) *config.LocalProviderConfig {
+	switch providerName {
+	case "lmstudio":
+		return p.LMStudio
+	case "llamacpp":
+		return p.LlamaCpp
+	}
+	return nil
 }
 
 // GenerateCode routes an API call to the appropriate provider (legacy method without validation)
 func (r *EnhancedRouter) GenerateCode(ctx context.Context, prompt, contextFile, outputFile, language string, contextFiles []string) (string, error) {
 	// Use the new validation method with validation disabled
-	return r.GenerateCodeWithValidation(ctx, prompt, outputFile, contextFiles, false, nil)
+	return r.GenerateCodeWithValidation(ctx, prompt, outputFile, contextFiles, "", "", false, nil)
+}
+
+// ContextGuard returns the prompt-injection guard for context files, or nil
+// if it is off
+func (r *EnhancedRouter) ContextGuard() *guard.Guard {
+	return r.guard
+}
+
+// GetPrompts returns the custom prompt templates, or nil for the built-in ones
+func (r *EnhancedRouter) GetPrompts() *prompts.Set {
+	return r.prompts
 }
 
 // GetMetrics returns a copy of the current router metrics (thread-safe)
@@ -517,11 +1249,17 @@ func (r *EnhancedRouter) GetMetrics() RouterMetrics {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
+	queue := r.queue.Stats()
 	return RouterMetrics{
 		TotalRequests:      r.metrics.TotalRequests,
 		SuccessfulRequests: r.metrics.SuccessfulRequests,
 		FailedRequests:     r.metrics.FailedRequests,
 		FallbackAttempts:   r.metrics.FallbackAttempts,
+		QueuedRequests:     queue.Queued,
+		TotalQueueWait:     queue.TotalWait,
+		MaxQueueWait:       queue.MaxWait,
+		HedgedRequests:     r.metrics.HedgedRequests,
+		HedgeWins:          r.metrics.HedgeWins,
 	}
 }
 
@@ -567,13 +1305,33 @@ func (r *EnhancedRouter) GetProviderMetrics() map[string]ProviderMetrics {
 		case "openai":
 			hasAPIKey = r.config.Providers.OpenAI != nil && r.config.Providers.OpenAI.APIKey != ""
 		case "qwen":
-			hasAPIKey = r.config.Providers.Qwen != nil && r.config.Providers.Qwen.APIKey != ""
+			hasAPIKey = r.config.Providers.Qwen != nil && len(r.config.Providers.Qwen.GetAllAPIKeys()) > 0
+		case "synthetic":
+			hasAPIKey = r.config.Providers.Synthetic != nil && len(r.config.Providers.Synthetic.GetAllAPIKeys()) > 0
+		case "azure-openai":
+			hasAPIKey = r.config.Providers.AzureOpenAI != nil && r.config.Providers.AzureOpenAI.HasCredentials()
+		case "bedrock":
+			hasAPIKey = r.config.Providers.Bedrock != nil && r.config.Providers.Bedrock.HasCredentials()
+		case "mistral":
+			hasAPIKey = r.config.Providers.Mistral != nil && len(r.config.Providers.Mistral.GetAllAPIKeys()) > 0
+		case "vertex":
+			hasAPIKey = r.config.Providers.Vertex != nil && r.config.Providers.Vertex.HasCredentials()
+		case "lmstudio", "llamacpp":
+			// Local servers need no key, only an address
+			local := localProviderConfig(r.config.Providers, providerName)
+			hasAPIKey = local != nil && local.BaseURL != ""
+		case "mock":
+			hasAPIKey = true // Needs no credentials
 		case "racing":
 			// Virtual provider - check if models are configured
 			hasAPIKey = r.config.Providers.Racing != nil && len(r.config.Providers.Racing.Models) > 0
 		case "racing-clever":
 			// Virtual provider - check if models are configured
 			hasAPIKey = r.config.Providers.RacingClever != nil && len(r.config.Providers.RacingClever.Models) > 0
+		default:
+			if custom, ok := r.config.Providers.Custom[providerName]; ok {
+				hasAPIKey = custom.Validate() == nil
+			}
 		}
 
 		if !hasAPIKey {
@@ -581,15 +1339,18 @@ func (r *EnhancedRouter) GetProviderMetrics() map[string]ProviderMetrics {
 		}
 
 		// If provider has been used, get its metrics
+		var metrics ProviderMetrics
 		if tracker, exists := r.providerMetrics[providerName]; exists {
-			result[providerName] = tracker.GetMetrics()
+			metrics = tracker.GetMetrics()
 		} else {
 			// Provider not used yet - create empty metrics
-			result[providerName] = ProviderMetrics{
+			metrics = ProviderMetrics{
 				Name:     providerName,
 				IsModel:  false,
 			}
 		}
+		metrics.Keys = api.KeyUsageFor(providerName)
+		result[providerName] = metrics
 	}
 
 	// Add any additional model-level metrics (for multi-model providers)
@@ -612,6 +1373,36 @@ type OverallLatencyMetrics struct {
 	MaxLatency time.Duration `json:"MaxLatency"`
 }
 
+// LatencyHistograms holds the histograms behind the router's latency
+// percentiles, for merging the metrics of several instances. Providers and
+// TTFT are keyed like GetProviderMetrics; providers not used yet are absent.
+type LatencyHistograms struct {
+	Overall   *LatencyHistogram            `json:"overall"`
+	Providers map[string]*LatencyHistogram `json:"providers,omitempty"`
+	TTFT      map[string]*LatencyHistogram `json:"ttft,omitempty"`
+}
+
+// GetLatencyHistograms returns the latency histograms of all requests and of
+// each provider and model (thread-safe)
+func (r *EnhancedRouter) GetLatencyHistograms() LatencyHistograms {
+	histograms := LatencyHistograms{
+		Overall:   r.overallLatencyTracker.Histogram(),
+		Providers: make(map[string]*LatencyHistogram),
+		TTFT:      make(map[string]*LatencyHistogram),
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for key, tracker := range r.providerMetrics {
+		latency, ttft := tracker.Histograms()
+		histograms.Providers[key] = latency
+		if ttft.Count > 0 {
+			histograms.TTFT[key] = ttft
+		}
+	}
+	return histograms
+}
+
 // GetOverallLatencyMetrics returns overall latency percentiles for all requests (thread-safe)
 func (r *EnhancedRouter) GetOverallLatencyMetrics() OverallLatencyMetrics {
 	min, p50, p95, p99, max := r.overallLatencyTracker.GetPercentiles()
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestInitializeNeedsAProvider(t *testing.T) {
+	factory := provider.NewProviderFactory()
+	provider.InitializeDefaultProviders(factory)
+
+	// The documented mock-only setup needs no API key
+	mock := &config.Config{}
+	mock.Providers.Enabled = []string{"mock"}
+	mock.Providers.Mock = &config.MockConfig{}
+	if err := NewEnhancedRouter(mock, factory).Initialize(t.Context()); err != nil {
+		t.Errorf("Initialize() with only the mock provider = %v", err)
+	}
+
+	local := &config.Config{}
+	local.Providers.Enabled = []string{"cerebras", "custom"}
+	local.Providers.Custom = map[string]config.CustomProviderConfig{"custom": {BaseURL: "http://127.0.0.1:1", Model: "coder"}}
+	if err := NewEnhancedRouter(local, factory).Initialize(t.Context()); err != nil {
+		t.Errorf("Initialize() with a keyless custom provider = %v", err)
+	}
+
+	none := &config.Config{}
+	none.Providers.Enabled = []string{"cerebras", "openrouter"}
+	none.Providers.Cerebras = &config.CerebrasConfig{Model: "zai-glm-4.6"}
+	if err := NewEnhancedRouter(none, factory).Initialize(t.Context()); err == nil || !strings.Contains(err.Error(), "cerebras, openrouter") {
+		t.Errorf("Initialize() without credentials = %v, want an error naming the enabled providers", err)
+	}
+}
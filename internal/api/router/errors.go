@@ -0,0 +1,160 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+)
+
+// ErrorKind classifies why a generation failed, so callers can react
+// without parsing messages
+type ErrorKind string
+
+const (
+	ErrorKindAuth             ErrorKind = "auth_error"        // Key missing, invalid or not allowed
+	ErrorKindRateLimited      ErrorKind = "rate_limited"      // Provider quota or rate limit hit
+	ErrorKindContextTooLarge  ErrorKind = "context_too_large" // Prompt exceeds the model's context window
+	ErrorKindValidationFailed ErrorKind = "validation_failed" // Generated code failed validation after retries
+	ErrorKindLicenseViolation ErrorKind = "license_violation" // Generated code reproduced sources the license policy forbids
+	ErrorKindProvider         ErrorKind = "provider_error"    // Any other provider or network failure
+)
+
+// ProviderAttempt describes one provider's attempt at a generation,
+// including its retries. Kind and Error are empty for the winning attempt.
+type ProviderAttempt struct {
+	Provider          string    `json:"provider"`
+	Model             string    `json:"model,omitempty"`
+	DurationMs        int64     `json:"duration_ms"`
+	Kind              ErrorKind `json:"kind,omitempty"`
+	StatusCode        int       `json:"status_code,omitempty"`
+	RetryAfterSeconds float64   `json:"retry_after_seconds,omitempty"`
+	Error             string    `json:"error,omitempty"`
+}
+
+// GenerationError is returned when no provider produced code. Kind is the
+// attempts' common kind, or ErrorKindProvider when they differ.
+type GenerationError struct {
+	Kind     ErrorKind
+	Attempts []ProviderAttempt
+	Err      error
+}
+
+func (e *GenerationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *GenerationError) Unwrap() error {
+	return e.Err
+}
+
+// newGenerationError wraps err with the failed attempts, deriving the kind
+// from them
+func newGenerationError(err error, attempts []ProviderAttempt) *GenerationError {
+	kind := ErrorKindProvider
+	for i, attempt := range attempts {
+		if i == 0 {
+			kind = attempt.Kind
+		} else if attempt.Kind != kind {
+			kind = ErrorKindProvider
+			break
+		}
+	}
+	return &GenerationError{Kind: kind, Attempts: attempts, Err: err}
+}
+
+// newProviderAttempt records a provider's failure after elapsed
+func newProviderAttempt(providerName, model string, elapsed time.Duration, err error) ProviderAttempt {
+	attempt := ProviderAttempt{
+		Provider:   providerName,
+		Model:      model,
+		DurationMs: elapsed.Milliseconds(),
+		Kind:       ClassifyError(err),
+		Error:      err.Error(),
+	}
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		attempt.StatusCode = apiErr.StatusCode
+		attempt.RetryAfterSeconds = apiErr.RetryAfter.Seconds()
+	}
+	return attempt
+}
+
+// validationError marks a result rejected by validation after the retries
+type validationError struct {
+	err  error
+	code string // The rejected code
+}
+
+func (e *validationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *validationError) Unwrap() error {
+	return e.err
+}
+
+// insecureCodeError marks a result a blocking security scan still flags
+// after the retries. Unlike a validationError it carries no code, since
+// allow_unvalidated must never write it.
+type insecureCodeError struct {
+	err error
+}
+
+func (e *insecureCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *insecureCodeError) Unwrap() error {
+	return e.err
+}
+
+// ClassifyError returns the kind of a provider or generation failure
+func ClassifyError(err error) ErrorKind {
+	var genErr *GenerationError
+	if errors.As(err, &genErr) {
+		return genErr.Kind
+	}
+	var valErr *validationError
+	var insecureErr *insecureCodeError
+	if errors.As(err, &valErr) || errors.As(err, &insecureErr) {
+		return ErrorKindValidationFailed
+	}
+
+	var licenseErr *policy.LicenseViolationError
+	if errors.As(err, &licenseErr) {
+		return ErrorKindLicenseViolation
+	}
+
+	if errors.Is(err, api.ErrNoAPIKeys) {
+		return ErrorKindAuth
+	}
+	var keysErr *api.KeysUnavailableError
+	if errors.As(err, &keysErr) {
+		return ErrorKindRateLimited
+	}
+
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
+		return ErrorKindProvider
+	}
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorKindAuth
+	case http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case http.StatusRequestEntityTooLarge:
+		return ErrorKindContextTooLarge
+	}
+	// Providers answer an oversized prompt with a 400 and differing messages
+	message := strings.ToLower(apiErr.Message)
+	for _, hint := range []string{"context length", "context window", "context_length", "maximum context", "too many tokens", "prompt is too long"} {
+		if strings.Contains(message, hint) {
+			return ErrorKindContextTooLarge
+		}
+	}
+	return ErrorKindProvider
+}
@@ -0,0 +1,183 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"unauthorized", &api.APIError{Provider: "anthropic", StatusCode: 401, Message: "invalid x-api-key"}, ErrorKindAuth},
+		{"forbidden", &api.APIError{Provider: "gemini", StatusCode: 403, Message: "permission denied"}, ErrorKindAuth},
+		{"rate limited", fmt.Errorf("cerebras: %w", &api.APIError{Provider: "cerebras", StatusCode: 429}), ErrorKindRateLimited},
+		{"too large", &api.APIError{Provider: "openrouter", StatusCode: 413}, ErrorKindContextTooLarge},
+		{"context length", &api.APIError{Provider: "openrouter", StatusCode: 400, Message: "This model's maximum context length is 8192 tokens"}, ErrorKindContextTooLarge},
+		{"bad request", &api.APIError{Provider: "openrouter", StatusCode: 400, Message: "invalid model"}, ErrorKindProvider},
+		{"validation", &validationError{err: errors.New("validation failed after 2 retries")}, ErrorKindValidationFailed},
+		{"no keys", fmt.Errorf("%w for qwen", api.ErrNoAPIKeys), ErrorKindAuth},
+		{"keys unavailable", &api.KeysUnavailableError{Provider: "cerebras", Keys: 2}, ErrorKindRateLimited},
+		{"network", errors.New("connection refused"), ErrorKindProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGenerationErrorKind(t *testing.T) {
+	rateLimited := &api.APIError{Provider: "cerebras", StatusCode: 429, RetryAfter: 30 * time.Second}
+	unauthorized := &api.APIError{Provider: "anthropic", StatusCode: 401}
+
+	same := newGenerationError(errors.New("all failed"), []ProviderAttempt{
+		newProviderAttempt("cerebras", "qwen-3-coder-480b", time.Second, rateLimited),
+		newProviderAttempt("openrouter", "qwen/qwen3-coder", time.Second, rateLimited),
+	})
+	if same.Kind != ErrorKindRateLimited {
+		t.Errorf("kind = %s, want %s", same.Kind, ErrorKindRateLimited)
+	}
+	if attempt := same.Attempts[0]; attempt.StatusCode != 429 || attempt.RetryAfterSeconds != 30 || attempt.DurationMs != 1000 || attempt.Model != "qwen-3-coder-480b" {
+		t.Errorf("attempt = %+v, want status 429, retry after 30s and the model", attempt)
+	}
+
+	mixed := newGenerationError(errors.New("all failed"), []ProviderAttempt{
+		newProviderAttempt("cerebras", "", 0, rateLimited),
+		newProviderAttempt("anthropic", "", 0, unauthorized),
+	})
+	if mixed.Kind != ErrorKindProvider {
+		t.Errorf("kind = %s, want %s", mixed.Kind, ErrorKindProvider)
+	}
+	if ClassifyError(fmt.Errorf("wrapped: %w", mixed)) != ErrorKindProvider || !errors.Is(mixed, mixed.Err) {
+		t.Error("GenerationError should classify and unwrap through wrapping")
+	}
+}
+
+// fallbackRouter tries primary, then backup, with apiKey for both
+func fallbackRouter(primary, backup, apiKey string) *EnhancedRouter {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"primary", "backup"}
+	cfg.Providers.Order = []string{"primary", "backup"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{
+		"primary": {BaseURL: primary, APIKey: apiKey, Model: "coder"},
+		"backup":  {BaseURL: backup, APIKey: apiKey, Model: "coder"},
+	}
+	return NewEnhancedRouter(cfg, nil)
+}
+
+func TestFallbackRecordsAttempts(t *testing.T) {
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"invalid api key"}}`, http.StatusUnauthorized)
+	}))
+	defer unauthorized.Close()
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package backup\n"}}]}`)
+	}))
+	defer backup.Close()
+
+	ctx, info := WithGenerationInfo(context.Background())
+	if _, err := fallbackRouter(unauthorized.URL, backup.URL, t.Name()).GenerateCodeWithValidation(ctx, "main", "main.go", nil, "", "", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Attempts) != 2 {
+		t.Fatalf("attempts = %+v, want the failed primary and the backup", info.Attempts)
+	}
+	if failed := info.Attempts[0]; failed.Provider != "primary" || failed.Model != "coder" || failed.Kind != ErrorKindAuth || failed.StatusCode != 401 {
+		t.Errorf("first attempt = %+v, want primary's auth error", failed)
+	}
+	if won := info.Attempts[1]; won.Provider != "backup" || won.Kind != "" || won.Error != "" {
+		t.Errorf("last attempt = %+v, want backup without an error", won)
+	}
+}
+
+// A failure of a built-in client must reach the fallback loop, not be lost
+// to a shadowed error
+func TestAzureOpenAIFailureFallsBack(t *testing.T) {
+	azure := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"Access denied due to invalid subscription key"}}`, http.StatusUnauthorized)
+	}))
+	defer azure.Close()
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package backup\n"}}]}`)
+	}))
+	defer backup.Close()
+
+	r := fallbackRouter(backup.URL, backup.URL, t.Name())
+	r.config.Providers.Enabled = []string{"azure-openai", "backup"}
+	r.config.Providers.Order = []string{"azure-openai", "backup"}
+	r.config.Providers.AzureOpenAI = &config.AzureOpenAIConfig{Endpoint: azure.URL, Deployment: "gpt-4o", APIKey: t.Name()}
+
+	ctx, info := WithGenerationInfo(context.Background())
+	code, err := r.GenerateCodeWithValidation(ctx, "main", "main.go", nil, "", "", false, nil)
+	if err != nil || !strings.Contains(code, "package backup") {
+		t.Fatalf("GenerateCodeWithValidation() = %q, %v; want the backup's code", code, err)
+	}
+	if len(info.Attempts) != 2 || info.Attempts[0].Provider != "azure-openai" || info.Attempts[0].Kind != ErrorKindAuth {
+		t.Errorf("attempts = %+v, want azure-openai's auth error then the backup", info.Attempts)
+	}
+}
+
+func TestBedrockFailureFallsBack(t *testing.T) {
+	bedrock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			t.Errorf("Authorization = %q, want a SigV4 signature", r.Header.Get("Authorization"))
+		}
+		http.Error(w, `{"message":"The security token included in the request is invalid."}`, http.StatusForbidden)
+	}))
+	defer bedrock.Close()
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package backup\n"}}]}`)
+	}))
+	defer backup.Close()
+
+	r := fallbackRouter(backup.URL, backup.URL, t.Name())
+	r.config.Providers.Enabled = []string{"bedrock", "backup"}
+	r.config.Providers.Order = []string{"bedrock", "backup"}
+	r.config.Providers.Bedrock = &config.BedrockConfig{
+		Region: "us-east-1", Model: "anthropic.claude-3-5-sonnet-20240620-v1:0",
+		AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: t.Name(), BaseURL: bedrock.URL,
+	}
+
+	ctx, info := WithGenerationInfo(context.Background())
+	code, err := r.GenerateCodeWithValidation(ctx, "main", "main.go", nil, "", "", false, nil)
+	if err != nil || !strings.Contains(code, "package backup") {
+		t.Fatalf("GenerateCodeWithValidation() = %q, %v; want the backup's code", code, err)
+	}
+	if len(info.Attempts) != 2 || info.Attempts[0].Provider != "bedrock" || info.Attempts[0].Kind != ErrorKindAuth {
+		t.Errorf("attempts = %+v, want bedrock's auth error then the backup", info.Attempts)
+	}
+}
+
+func TestAllProvidersFailedReturnsGenerationError(t *testing.T) {
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "600")
+		http.Error(w, `{"error":{"message":"slow down"}}`, http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+
+	_, err := fallbackRouter(limited.URL, limited.URL, t.Name()).GenerateCodeWithValidation(context.Background(), "main", "main.go", nil, "", "", false, nil)
+	var genErr *GenerationError
+	if !errors.As(err, &genErr) {
+		t.Fatalf("error = %v, want a GenerationError", err)
+	}
+	if genErr.Kind != ErrorKindRateLimited || len(genErr.Attempts) != 2 {
+		t.Errorf("kind = %s with %d attempts, want rate_limited with 2", genErr.Kind, len(genErr.Attempts))
+	}
+	if !strings.Contains(genErr.Error(), "all providers failed") {
+		t.Errorf("message = %q", genErr.Error())
+	}
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/index"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+)
+
+// GenerationInfo describes which provider and model produced a result.
+// Callers attach it to the request context to learn about the winning attempt.
+type GenerationInfo struct {
+	Provider string
+	Model    string
+	Cached   bool
+	Usage    *types.Usage      // Tokens spent on the winning attempt; nil when cached
+	TTFT     time.Duration     // Time to the winning attempt's first streamed token; 0 if it didn't stream
+	Attempts []ProviderAttempt // Failed providers in the order tried, then the winning one
+	// Unvalidated is set when no provider's code passed validation and the
+	// last attempt was returned anyway, as validation.allow_unvalidated allows
+	Unvalidated bool
+	// LicenseFindings are what the license policy flagged in the result in
+	// annotate mode
+	LicenseFindings []policy.LicenseFinding
+	// Retrieved are the snippets the repository index added to the prompt,
+	// as path:start-end
+	Retrieved []string
+}
+
+type generationInfoKey struct{}
+
+// WithGenerationInfo returns a context carrying an empty GenerationInfo that the
+// router fills in when a provider call succeeds
+func WithGenerationInfo(ctx context.Context) (context.Context, *GenerationInfo) {
+	info := &GenerationInfo{}
+	return context.WithValue(ctx, generationInfoKey{}, info), info
+}
+
+// recordGeneration stores the successful attempt in ctx, if requested
+func recordGeneration(ctx context.Context, generation GenerationInfo) {
+	if info, ok := ctx.Value(generationInfoKey{}).(*GenerationInfo); ok {
+		generation.Retrieved = info.Retrieved // Recorded for the request, before any attempt
+		*info = generation
+	}
+}
+
+// recordUnvalidated marks the result in ctx, if requested, as code that
+// failed validation, returned after the failed attempts
+func recordUnvalidated(ctx context.Context, failed []ProviderAttempt) {
+	if info, ok := ctx.Value(generationInfoKey{}).(*GenerationInfo); ok {
+		info.Attempts = failed
+		info.Unvalidated = true
+	}
+}
+
+// recordRetrieved stores the snippets the index added to the prompt in
+// ctx, if requested
+func recordRetrieved(ctx context.Context, snippets []index.Snippet) {
+	if info, ok := ctx.Value(generationInfoKey{}).(*GenerationInfo); ok {
+		for _, snippet := range snippets {
+			info.Retrieved = append(info.Retrieved, fmt.Sprintf("%s:%d-%d", snippet.Path, snippet.StartLine, snippet.EndLine))
+		}
+	}
+}
+
+// recordAttempts stores the failed attempts and the winning one, which took
+// elapsed, in ctx, if requested
+func recordAttempts(ctx context.Context, failed []ProviderAttempt, elapsed time.Duration) {
+	if info, ok := ctx.Value(generationInfoKey{}).(*GenerationInfo); ok {
+		info.Attempts = append(failed, ProviderAttempt{Provider: info.Provider, Model: info.Model, DurationMs: elapsed.Milliseconds()})
+	}
+}
+
+// recordLicenseFindings stores what the license policy flagged in the
+// result in ctx, if requested
+func recordLicenseFindings(ctx context.Context, findings []policy.LicenseFinding) {
+	if info, ok := ctx.Value(generationInfoKey{}).(*GenerationInfo); ok {
+		info.LicenseFindings = findings
+	}
+}
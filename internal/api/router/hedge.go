@@ -0,0 +1,135 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// firstTokenSignalKey is the context key for the callback a hedged attempt
+// uses to report its first token
+type firstTokenSignalKey struct{}
+
+// withFirstTokenSignal returns a context whose provider calls close the
+// returned channel when their first token arrives
+func withFirstTokenSignal(ctx context.Context) (context.Context, <-chan struct{}) {
+	ch := make(chan struct{})
+	var once sync.Once
+	signal := func() { once.Do(func() { close(ch) }) }
+	return context.WithValue(ctx, firstTokenSignalKey{}, signal), ch
+}
+
+// signalFirstToken reports a first token to the hedge waiting on ctx, if any
+func signalFirstToken(ctx context.Context) {
+	if signal, ok := ctx.Value(firstTokenSignalKey{}).(func()); ok {
+		signal()
+	}
+}
+
+// stepContext returns the provider of a preferred_order step, and ctx with
+// the step's model if it names one
+func stepContext(ctx context.Context, step string) (context.Context, string) {
+	providerName, model := config.SplitOrderStep(step)
+	if model != "" {
+		ctx = withModel(ctx, providerName, model)
+	}
+	return ctx, providerName
+}
+
+// hedgeFor returns the step to hedge step i of order with and how long to
+// wait for a first token before doing so, or "" if the step's provider is
+// not hedged or no step it could be hedged with may take the request
+func (r *EnhancedRouter) hedgeFor(ctx context.Context, order []string, i int, filePath string, contextFiles []string, promptTokens int) (string, time.Duration) {
+	_, providerName := stepContext(ctx, order[i])
+	hedge, ok := r.config.Providers.Hedging[providerName]
+	if !ok || hedge.After <= 0 {
+		return "", 0
+	}
+	candidates := order[i+1:]
+	if hedge.With != "" {
+		candidates = config.ExpandOrder([]string{hedge.With})
+	}
+	for _, step := range candidates {
+		stepCtx, name := stepContext(ctx, step)
+		if step == order[i] || !slices.Contains(r.config.Providers.Enabled, name) {
+			continue
+		}
+		if r.checkResidency(name, filePath, contextFiles) != nil {
+			continue
+		}
+		if model, _ := r.exceedsContext(stepCtx, name, promptTokens); model != "" {
+			continue
+		}
+		return step, hedge.After
+	}
+	return "", 0
+}
+
+// hedgeResult is the outcome of one attempt of a hedged request
+type hedgeResult struct {
+	step string
+	code string
+	err  error
+	info *GenerationInfo
+}
+
+// tryHedged makes the request with the primary step, and also with the
+// hedge step if the primary has no first token after the given delay. The
+// first attempt to succeed wins and the other is cancelled. It reports
+// whether the hedge step was tried.
+func (r *EnhancedRouter) tryHedged(ctx context.Context, primary, hedge string, after time.Duration, try func(ctx context.Context, providerName string) (string, error)) (code string, hedged bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each attempt gets its own generation info; the winner's is passed on
+	results := make(chan hedgeResult, 2)
+	run := func(ctx context.Context, step string) {
+		ctx, providerName := stepContext(ctx, step)
+		ctx, info := WithGenerationInfo(ctx)
+		code, err := try(ctx, providerName)
+		results <- hedgeResult{step: step, code: code, err: err, info: info}
+	}
+	primaryCtx, firstToken := withFirstTokenSignal(ctx)
+	go run(primaryCtx, primary)
+
+	timer := time.NewTimer(after)
+	defer timer.Stop()
+	var errs []error
+	for running := 1; running > 0; {
+		select {
+		case <-firstToken:
+			// The primary is answering; let it finish on its own
+			timer.Stop()
+			firstToken = nil
+		case <-timer.C:
+			logger.Infof("Hedging: no first token from %s after %v, also trying %s", primary, after, hedge)
+			r.mutex.Lock()
+			r.metrics.HedgedRequests++
+			r.mutex.Unlock()
+			hedged = true
+			running++
+			go run(ctx, hedge)
+		case res := <-results:
+			running--
+			if res.err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", res.step, res.err))
+				continue
+			}
+			if res.step == hedge {
+				logger.Infof("Hedging: %s answered before %s", hedge, primary)
+				r.mutex.Lock()
+				r.metrics.HedgeWins++
+				r.mutex.Unlock()
+			}
+			recordGeneration(ctx, *res.info)
+			return res.code, hedged, nil
+		}
+	}
+	return "", hedged, errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// hedgeRouter routes to primary, hedged after 50ms with backup
+func hedgeRouter(primary, backup string) *EnhancedRouter {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"hedged", "backup"}
+	cfg.Providers.Order = []string{"hedged", "backup"}
+	cfg.Providers.Hedging = map[string]config.HedgeConfig{"hedged": {After: 50 * time.Millisecond}}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{
+		"hedged": {BaseURL: primary, APIKey: "key", Model: "coder"},
+		"backup": {BaseURL: backup, APIKey: "key", Model: "coder"},
+	}
+	return NewEnhancedRouter(cfg, nil)
+}
+
+func TestHedgeAnswersForSlowProvider(t *testing.T) {
+	done := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer slow.Close()
+	defer close(done)
+	var backupCalls atomic.Int32
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backupCalls.Add(1)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package backup\n"}}]}`)
+	}))
+	defer backup.Close()
+
+	r := hedgeRouter(slow.URL, backup.URL)
+	ctx, info := WithGenerationInfo(context.Background())
+	code, err := r.GenerateCodeWithValidation(ctx, "main", "main.go", nil, "", "", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "package backup") || info.Provider != "backup" {
+		t.Errorf("code = %q from %q, want the hedge's", code, info.Provider)
+	}
+	if m := r.GetMetrics(); m.HedgedRequests != 1 || m.HedgeWins != 1 {
+		t.Errorf("hedged = %d, wins = %d, want 1 and 1", m.HedgedRequests, m.HedgeWins)
+	}
+	if n := backupCalls.Load(); n != 1 {
+		t.Errorf("backup called %d times, want once", n)
+	}
+}
+
+func TestNoHedgeOnceFirstTokenArrives(t *testing.T) {
+	streaming := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"package main\\n\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(150 * time.Millisecond)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer streaming.Close()
+	var backupCalls atomic.Int32
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backupCalls.Add(1)
+	}))
+	defer backup.Close()
+
+	r := hedgeRouter(streaming.URL, backup.URL)
+	code, err := r.GenerateCodeWithValidation(context.Background(), "main", "main.go", nil, "", "", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "package main") {
+		t.Errorf("code = %q, want the primary's", code)
+	}
+	if n := backupCalls.Load(); n != 0 || r.GetMetrics().HedgedRequests != 0 {
+		t.Errorf("backup called %d times; a provider that is streaming must not be hedged", n)
+	}
+}
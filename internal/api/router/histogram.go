@@ -0,0 +1,119 @@
+package router
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// histogramGamma is the ratio between the bounds of neighbouring histogram
+// buckets; quantiles read from a histogram are within 1% of the exact value
+const histogramGamma = 1.02
+
+var histogramLogGamma = math.Log(histogramGamma)
+
+// LatencyHistogram is a mergeable sketch of a set of latencies: each latency
+// is counted in a bucket whose bounds grow by histogramGamma, so histograms
+// from several instances can be added together and queried for percentiles,
+// which averaging each instance's percentiles cannot give
+type LatencyHistogram struct {
+	Counts map[int]int64 `json:"counts"` // Bucket index -> latencies in it
+	Count  int64         `json:"count"`
+	Sum    time.Duration `json:"sum"`
+	Min    time.Duration `json:"min"`
+	Max    time.Duration `json:"max"`
+}
+
+// NewLatencyHistogram creates an empty histogram
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{Counts: make(map[int]int64)}
+}
+
+// Add counts a latency
+func (h *LatencyHistogram) Add(latency time.Duration) {
+	if h.Counts == nil {
+		h.Counts = make(map[int]int64)
+	}
+	h.Counts[histogramBucket(latency)]++
+	if h.Count == 0 || latency < h.Min {
+		h.Min = latency
+	}
+	if latency > h.Max {
+		h.Max = latency
+	}
+	h.Count++
+	h.Sum += latency
+}
+
+// Merge adds other's latencies to h
+func (h *LatencyHistogram) Merge(other *LatencyHistogram) {
+	if other == nil || other.Count == 0 {
+		return
+	}
+	if h.Counts == nil {
+		h.Counts = make(map[int]int64)
+	}
+	for bucket, count := range other.Counts {
+		h.Counts[bucket] += count
+	}
+	if h.Count == 0 || other.Min < h.Min {
+		h.Min = other.Min
+	}
+	if other.Max > h.Max {
+		h.Max = other.Max
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+}
+
+// Quantile returns the latency below which a fraction q of the latencies
+// fall, picked the same way as LatencyTracker.GetPercentiles
+func (h *LatencyHistogram) Quantile(q float64) time.Duration {
+	if h == nil || h.Count == 0 {
+		return 0
+	}
+	rank := int64(float64(h.Count) * q)
+	if rank >= h.Count {
+		rank = h.Count - 1
+	}
+
+	buckets := make([]int, 0, len(h.Counts))
+	for bucket := range h.Counts {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+
+	var seen int64
+	for _, bucket := range buckets {
+		seen += h.Counts[bucket]
+		if seen > rank {
+			return min(max(histogramValue(bucket), h.Min), h.Max)
+		}
+	}
+	return h.Max
+}
+
+// Average returns the mean latency
+func (h *LatencyHistogram) Average() time.Duration {
+	if h == nil || h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// histogramBucket returns the index of the bucket counting latency: bucket i
+// holds latencies in (gamma^(i-1), gamma^i] microseconds
+func histogramBucket(latency time.Duration) int {
+	us := float64(latency) / float64(time.Microsecond)
+	if us <= 1 {
+		return 0
+	}
+	return int(math.Ceil(math.Log(us) / histogramLogGamma))
+}
+
+// histogramValue returns the latency bucket i stands for, chosen so that its
+// relative error to any latency in the bucket is at most (gamma-1)/(gamma+1)
+func histogramValue(bucket int) time.Duration {
+	us := 2 * math.Pow(histogramGamma, float64(bucket)) / (histogramGamma + 1)
+	return time.Duration(us * float64(time.Microsecond))
+}
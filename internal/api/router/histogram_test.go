@@ -0,0 +1,73 @@
+package router
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogramQuantiles(t *testing.T) {
+	tracker := NewLatencyTracker(1000)
+	for i := 1; i <= 1000; i++ {
+		tracker.Add(time.Duration(i) * time.Millisecond)
+	}
+	histogram := tracker.Histogram()
+
+	_, p50, p95, p99, _ := tracker.GetPercentiles()
+	for _, tc := range []struct {
+		q    float64
+		want time.Duration
+	}{{0.50, p50}, {0.95, p95}, {0.99, p99}} {
+		got := histogram.Quantile(tc.q)
+		if diff := float64(got-tc.want) / float64(tc.want); diff < -0.01 || diff > 0.01 {
+			t.Errorf("Quantile(%v) = %v, want %v within 1%%", tc.q, got, tc.want)
+		}
+	}
+	if histogram.Min != time.Millisecond || histogram.Max != time.Second {
+		t.Errorf("min, max = %v, %v, want 1ms, 1s", histogram.Min, histogram.Max)
+	}
+	if got, want := histogram.Average(), tracker.GetAverage(); got != want {
+		t.Errorf("Average() = %v, want %v", got, want)
+	}
+}
+
+func TestLatencyHistogramMerge(t *testing.T) {
+	// A fast instance with 900 requests and a slow one with 100: averaging
+	// their P50s gives about 5s, but the combined P50 is about 100ms
+	fast, slow := NewLatencyHistogram(), NewLatencyHistogram()
+	for i := 0; i < 900; i++ {
+		fast.Add(100 * time.Millisecond)
+	}
+	for i := 0; i < 100; i++ {
+		slow.Add(10 * time.Second)
+	}
+
+	// Histograms travel between instances as JSON
+	data, err := json.Marshal(slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded LatencyHistogram
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	merged := NewLatencyHistogram()
+	merged.Merge(fast)
+	merged.Merge(&decoded)
+	if merged.Count != 1000 {
+		t.Fatalf("Count = %d, want 1000", merged.Count)
+	}
+	if p50 := merged.Quantile(0.50); p50 < 99*time.Millisecond || p50 > 101*time.Millisecond {
+		t.Errorf("P50 = %v, want about 100ms", p50)
+	}
+	if p95 := merged.Quantile(0.95); p95 < 9900*time.Millisecond || p95 > 10*time.Second {
+		t.Errorf("P95 = %v, want about 10s", p95)
+	}
+	if merged.Min != 100*time.Millisecond || merged.Max != 10*time.Second {
+		t.Errorf("min, max = %v, %v", merged.Min, merged.Max)
+	}
+	if (&LatencyHistogram{}).Quantile(0.5) != 0 {
+		t.Error("empty histogram has a nonzero quantile")
+	}
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"context"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+)
+
+// checkLicense applies the license policy to a provider's code and the
+// citations it reported. Findings are recorded for the caller, or in reject
+// mode returned as a *policy.LicenseViolationError.
+func (r *EnhancedRouter) checkLicense(ctx context.Context, code string, citations []types.Citation) (string, error) {
+	findings := r.license.Check(code, citations)
+	if len(findings) == 0 {
+		return code, nil
+	}
+	if r.license.Rejects() {
+		return "", &policy.LicenseViolationError{Findings: findings}
+	}
+	logger.Warnf("License policy: generated code %s", policy.FormatLicenseFindings(findings))
+	recordLicenseFindings(ctx, findings)
+	return code, nil
+}
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+)
+
+const blocklisted = "package vendor\n\nfunc Checksum(data []byte) uint32 {\n\tvar sum uint32\n\tfor _, b := range data {\n\t\tsum = sum*31 + uint32(b)\n\t}\n\treturn sum\n}\n"
+
+// codeServer answers every request with code
+func codeServer(code string, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		content, _ := json.Marshal(code)
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%s}}]}`, content)
+	}))
+}
+
+func licenseRouter(t *testing.T, mode, primary, backup string) *EnhancedRouter {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "checksum.go"), []byte(blocklisted), 0600); err != nil {
+		t.Fatal(err)
+	}
+	checker, err := policy.NewLicenseChecker(config.LicenseConfig{Enabled: true, Mode: mode, Blocklist: []string{dir}, MinLines: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := fallbackRouter(primary, backup, t.Name())
+	r.license = checker
+	return r
+}
+
+func TestLicensePolicyRejectFallsBack(t *testing.T) {
+	var copierCalls, originalCalls atomic.Int32
+	copier := codeServer(blocklisted, &copierCalls)
+	defer copier.Close()
+	original := codeServer("package main\n\nfunc main() {}\n", &originalCalls)
+	defer original.Close()
+
+	ctx, info := WithGenerationInfo(context.Background())
+	code, err := licenseRouter(t, config.LicenseModeReject, copier.URL, original.URL).GenerateCodeWithValidation(ctx, "checksum", "main.go", nil, "", "", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "func main() {}") {
+		t.Errorf("code = %q, want the backup's original code", code)
+	}
+	if copierCalls.Load() != 3 || originalCalls.Load() != 1 {
+		t.Errorf("calls = %d and %d, want the copier retried twice, then the backup", copierCalls.Load(), originalCalls.Load())
+	}
+	if len(info.Attempts) != 2 || info.Attempts[0].Kind != ErrorKindLicenseViolation {
+		t.Errorf("attempts = %+v, want the copier's license violation first", info.Attempts)
+	}
+}
+
+func TestLicensePolicyAnnotate(t *testing.T) {
+	var calls atomic.Int32
+	copier := codeServer(blocklisted, &calls)
+	defer copier.Close()
+
+	ctx, info := WithGenerationInfo(context.Background())
+	if _, err := licenseRouter(t, config.LicenseModeAnnotate, copier.URL, copier.URL).GenerateCodeWithValidation(ctx, "checksum", "main.go", nil, "", "", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.LicenseFindings) != 1 || info.LicenseFindings[0].Kind != policy.LicenseFindingBlocklist {
+		t.Errorf("license findings = %+v, want the copy", info.LicenseFindings)
+	}
+
+	// Every provider copying fails as a license violation
+	var genErr *GenerationError
+	_, err := licenseRouter(t, config.LicenseModeReject, copier.URL, copier.URL).GenerateCodeWithValidation(context.Background(), "checksum", "main.go", nil, "", "", false, nil)
+	if !errors.As(err, &genErr) || genErr.Kind != ErrorKindLicenseViolation {
+		t.Errorf("error = %v, want license_violation", err)
+	}
+}
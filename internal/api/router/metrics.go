@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
 )
@@ -27,6 +28,15 @@ type ProviderMetrics struct {
 	LastUsed           time.Time     `json:"LastUsed"`
 	TotalTokens        int64         `json:"TotalTokens"`
 	AvgTokensPerSec    float64       `json:"AvgTokensPerSec"`
+	AbandonedRequests  int64         `json:"AbandonedRequests,omitempty"` // Racers cancelled after another won
+	AbandonedTokens    int64         `json:"AbandonedTokens,omitempty"`   // Estimated prompt tokens sent by abandoned racers
+	AbandonedCost      float64       `json:"AbandonedCost,omitempty"`     // Estimated USD cost of those prompt tokens
+	AvgTTFT            time.Duration `json:"AvgTTFT,omitempty"`           // Time to first token of streamed responses
+	P50TTFT            time.Duration `json:"P50TTFT,omitempty"`
+	P95TTFT            time.Duration `json:"P95TTFT,omitempty"`
+
+	// Keys is the per-key usage of providers with several API keys
+	Keys []api.KeyUsage `json:"Keys,omitempty"`
 }
 
 // LatencyTracker maintains latency history for percentile calculations
@@ -87,6 +97,19 @@ func (lt *LatencyTracker) GetPercentiles() (min, p50, p95, p99, max time.Duratio
 	return min, p50, p95, p99, max
 }
 
+// Histogram returns a histogram of the stored latencies, which unlike the
+// percentiles can be merged with other instances' histograms
+func (lt *LatencyTracker) Histogram() *LatencyHistogram {
+	lt.mutex.RLock()
+	defer lt.mutex.RUnlock()
+
+	histogram := NewLatencyHistogram()
+	for _, l := range lt.latencies {
+		histogram.Add(l)
+	}
+	return histogram
+}
+
 // GetAverage calculates the average latency
 func (lt *LatencyTracker) GetAverage() time.Duration {
 	lt.mutex.RLock()
@@ -107,6 +130,7 @@ func (lt *LatencyTracker) GetAverage() time.Duration {
 type ProviderMetricsTracker struct {
 	metrics         *ProviderMetrics
 	latencyTracker  *LatencyTracker
+	ttftTracker     *LatencyTracker
 	mutex           sync.RWMutex
 }
 
@@ -118,6 +142,7 @@ func NewProviderMetricsTracker(providerName string) *ProviderMetricsTracker {
 			IsModel: false,
 		},
 		latencyTracker: NewLatencyTracker(1000), // Keep last 1000 requests
+		ttftTracker:    NewLatencyTracker(1000),
 	}
 }
 
@@ -130,6 +155,7 @@ func NewModelMetricsTracker(providerName, modelName string) *ProviderMetricsTrac
 			IsModel: true,
 		},
 		latencyTracker: NewLatencyTracker(1000), // Keep last 1000 requests
+		ttftTracker:    NewLatencyTracker(1000),
 	}
 }
 
@@ -172,6 +198,28 @@ func (pmt *ProviderMetricsTracker) RecordRequest(success bool, latency time.Dura
 	}
 }
 
+// RecordFirstToken records the time to first token of a streamed response
+func (pmt *ProviderMetricsTracker) RecordFirstToken(ttft time.Duration) {
+	pmt.ttftTracker.Add(ttft)
+}
+
+// Histograms returns histograms of the request latencies and times to first
+// token behind GetMetrics' percentiles
+func (pmt *ProviderMetricsTracker) Histograms() (latency, ttft *LatencyHistogram) {
+	return pmt.latencyTracker.Histogram(), pmt.ttftTracker.Histogram()
+}
+
+// RecordAbandoned records a request cancelled after another racer won, and
+// the estimated prompt tokens and cost it was already charged for
+func (pmt *ProviderMetricsTracker) RecordAbandoned(promptTokens int, cost float64) {
+	pmt.mutex.Lock()
+	defer pmt.mutex.Unlock()
+
+	pmt.metrics.AbandonedRequests++
+	pmt.metrics.AbandonedTokens += int64(promptTokens)
+	pmt.metrics.AbandonedCost += cost
+}
+
 // GetMetrics returns a snapshot of current metrics with calculated percentiles
 func (pmt *ProviderMetricsTracker) GetMetrics() ProviderMetrics {
 	pmt.mutex.RLock()
@@ -190,6 +238,10 @@ func (pmt *ProviderMetricsTracker) GetMetrics() ProviderMetrics {
 	// Calculate average from latency tracker
 	metrics.AvgLatency = pmt.latencyTracker.GetAverage()
 
+	// Time to first token, only known for streamed responses
+	_, metrics.P50TTFT, metrics.P95TTFT, _, _ = pmt.ttftTracker.GetPercentiles()
+	metrics.AvgTTFT = pmt.ttftTracker.GetAverage()
+
 	// Calculate tokens per second
 	if metrics.SuccessfulRequests > 0 && metrics.TotalTokens > 0 && metrics.AvgLatency > 0 {
 		// tokens/sec = total_tokens / (avg_latency_seconds * successful_requests)
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestExpandOrder(t *testing.T) {
+	got := config.ExpandOrder([]string{
+		"cerebras:zai-glm-4.6 -> openrouter:qwen/qwen3-coder -> anthropic",
+		"openrouter",
+		"cerebras:zai-glm-4.6",
+	})
+	want := []string{"cerebras:zai-glm-4.6", "openrouter:qwen/qwen3-coder", "anthropic", "openrouter"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandOrder = %q, want %q", got, want)
+	}
+}
+
+func TestFallbackChainKeepsEquivalentModel(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	var models []string
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		models = append(models, req.Model)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package main\n"}}]}`)
+	}))
+	defer secondary.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"primary", "secondary"}
+	cfg.Providers.Order = []string{"primary:coder-large -> secondary:coder-large-equivalent", "secondary"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{
+		"primary":   {BaseURL: failing.URL, APIKey: "key", Model: "coder-small"},
+		"secondary": {BaseURL: secondary.URL, APIKey: "key", Model: "secondary-default"},
+	}
+	r := NewEnhancedRouter(cfg, nil)
+
+	if _, err := r.GenerateCodeWithValidation(context.Background(), "main", "main.go", nil, "", "", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(models, []string{"coder-large-equivalent"}) {
+		t.Errorf("secondary was asked for %q, want the chain's model", models)
+	}
+}
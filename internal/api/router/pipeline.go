@@ -0,0 +1,79 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/audit"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// runPipeline drafts code with providers.pipeline's draft provider and has
+// its refine provider, with maxRetries validation retries, rework the draft
+// when it fails validation or its diff touches more than max_lines lines.
+// ok is false if neither stage produced code, and the caller should fall
+// back to the preferred order.
+func (r *EnhancedRouter) runPipeline(ctx context.Context, prompt, filePath string, contextFiles []string, validateCode bool, maxRetries int, warningCallback ValidationWarningFunc) (code string, ok bool) {
+	p := r.config.Providers.Pipeline
+
+	// No retries on the draft: a draft that fails validation goes to the refiner
+	draft, draftErr := r.tryProviderWithRetry(ctx, p.Draft, prompt, filePath, contextFiles, validateCode, 0, warningCallback)
+	var reason string
+	switch {
+	case draftErr != nil:
+		reason = draftErr.Error()
+	case p.MaxLines > 0:
+		existing, _ := utils.ReadFileContent(filePath)
+		diff := audit.SummarizeDiff(existing, draft)
+		if changed := diff.LinesAdded + diff.LinesRemoved; changed > p.MaxLines {
+			reason = fmt.Sprintf("the draft changes %d lines (max_lines %d)", changed, p.MaxLines)
+		}
+	}
+	if reason == "" {
+		logger.Debugf("Pipeline: accepted %s draft", p.Draft)
+		return draft, true
+	}
+	logger.Infof("Pipeline: refining with %s: %s", p.Refine, reason)
+	if warningCallback != nil {
+		warningCallback(p.Refine, fmt.Sprintf("⚠️ Refining %s draft with %s", p.Draft, p.Refine))
+	}
+
+	var refinePrompt string
+	if draftErr != nil {
+		refinePrompt = fmt.Sprintf("%s\n\nA first attempt by another model failed:\n%v\n\nWrite code that does not have this problem.", prompt, draftErr)
+	} else {
+		refinePrompt = fmt.Sprintf("%s\n\nA faster model drafted this code:\n```\n%s\n```\n\nReview the draft, fix any mistakes, and return the complete corrected file.", prompt, draft)
+	}
+	refined, err := r.tryProviderWithRetry(ctx, p.Refine, refinePrompt, filePath, contextFiles, validateCode, maxRetries, warningCallback)
+	if err == nil {
+		return refined, true
+	}
+	logger.Warnf("Pipeline: %s could not refine the draft: %v", p.Refine, err)
+	if draftErr == nil {
+		// The draft was valid, only large; it beats nothing
+		return draft, true
+	}
+	return "", false
+}
+
+// pipelineEnabled reports whether the draft + refine pipeline can run for
+// this request: both its providers are enabled and may receive the files
+func (r *EnhancedRouter) pipelineEnabled(filePath string, contextFiles []string) bool {
+	p := r.config.Providers.Pipeline
+	if p == nil {
+		return false
+	}
+	for _, providerName := range []string{p.Draft, p.Refine} {
+		if !slices.Contains(r.config.Providers.Enabled, providerName) {
+			logger.Debugf("Pipeline: skipped, %s is not enabled", providerName)
+			return false
+		}
+		if err := r.checkResidency(providerName, filePath, contextFiles); err != nil {
+			logger.Debugf("Pipeline: skipped, %v", err)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestPipelineRefinesLargeOrInvalidDrafts(t *testing.T) {
+	var refinePrompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		refinePrompts = append(refinePrompts, req.Messages[len(req.Messages)-1].Content)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"package main\n\nfunc main() {}\n"}}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"mock", "refiner"}
+	cfg.Providers.Order = []string{"mock", "refiner"}
+	cfg.Providers.Mock = &config.MockConfig{Fixtures: map[string]string{"go": "package main\n\nfunc main() {\n\tprintln(1)\n}\n"}}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"refiner": {BaseURL: srv.URL, APIKey: "key", Model: "strong"}}
+	cfg.Providers.Pipeline = &config.PipelineConfig{Draft: "mock", Refine: "refiner"}
+	r := NewEnhancedRouter(cfg, nil)
+	output := filepath.Join(t.TempDir(), "main.go")
+
+	// A valid draft is kept when no max_lines is set
+	code, err := r.GenerateCodeWithValidation(context.Background(), "write main", output, nil, "", "", true, nil)
+	if err != nil || !strings.Contains(code, "println(1)") || len(refinePrompts) != 0 {
+		t.Fatalf("small draft: code %q, err %v, %d refinements", code, err, len(refinePrompts))
+	}
+
+	// A draft touching more than max_lines lines is refined
+	cfg.Providers.Pipeline.MaxLines = 2
+	code, err = r.GenerateCodeWithValidation(context.Background(), "write main", output, nil, "", "", true, nil)
+	if err != nil || !strings.Contains(code, "func main() {}") {
+		t.Fatalf("large draft: code %q, err %v", code, err)
+	}
+	if len(refinePrompts) != 1 || !strings.Contains(refinePrompts[0], "println(1)") {
+		t.Errorf("refine prompt = %q, want the draft", refinePrompts)
+	}
+
+	// A draft that fails validation is refined with the failure
+	cfg.Providers.Pipeline.MaxLines = 0
+	cfg.Providers.Mock.Fixtures["go"] = "package main\n\nfunc main() {\n"
+	code, err = r.GenerateCodeWithValidation(context.Background(), "write main", output, nil, "", "", true, nil)
+	if err != nil || !strings.Contains(code, "func main() {}") {
+		t.Fatalf("invalid draft: code %q, err %v", code, err)
+	}
+	if len(refinePrompts) != 2 || !strings.Contains(refinePrompts[1], "failed") {
+		t.Errorf("refine prompt = %q, want the validation failure", refinePrompts[len(refinePrompts)-1])
+	}
+}
@@ -0,0 +1,259 @@
+package router
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// languageProfile is the profile selected for one request
+type languageProfile struct {
+	language string
+	provider string // Provider named by the profile's model, "" if none
+	model    string // Model override for provider, "" to keep the configured one
+	config.LanguageProfile
+}
+
+type profileKey struct{}
+
+// profileFor returns the profile configured for the language of filePath,
+// with the request's project conventions applied, or nil
+func (r *EnhancedRouter) profileFor(ctx context.Context, filePath string) *languageProfile {
+	project := projectFrom(ctx)
+	if (len(r.config.Profiles) == 0 && project == nil) || filePath == "" {
+		return nil
+	}
+	language := strings.ToLower(utils.GetLanguageFromFile(filePath, nil))
+	cfg, ok := r.config.Profiles[language]
+	if project != nil {
+		if model := r.projectModel(project.Model); model != "" {
+			cfg.Model, ok = model, true
+		}
+		if override, found := project.Profiles[language]; found {
+			cfg, ok = mergeProfile(cfg, r.projectProfile(override)), true
+		}
+	}
+	if !ok {
+		return nil
+	}
+
+	profile := &languageProfile{language: language, LanguageProfile: cfg}
+	if cfg.Model != "" {
+		profile.provider, profile.model = splitProviderModel(cfg.Model)
+	}
+	return profile
+}
+
+// splitProviderModel splits "provider/model" or "provider:model" at the first
+// separator, so model IDs may themselves contain either ("openrouter/qwen/qwen3-coder")
+func splitProviderModel(s string) (providerName, model string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexAny(s, "/:")
+	if i < 0 {
+		return s, ""
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+}
+
+// order returns the providers to try: the profile's provider first, then its
+// fallback list (or the global order)
+func (p *languageProfile) order(global []string) []string {
+	candidates := global
+	if len(p.Providers) > 0 {
+		candidates = config.ExpandOrder(p.Providers)
+	}
+	if p.provider != "" {
+		candidates = append([]string{p.provider}, candidates...)
+	}
+
+	seen := make(map[string]bool)
+	var order []string
+	for _, name := range candidates {
+		if !seen[name] {
+			seen[name] = true
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+func withProfile(ctx context.Context, profile *languageProfile) context.Context {
+	if profile == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, profileKey{}, profile)
+}
+
+// withModel returns ctx with providerName using model, as a step of a
+// fallback chain asks; the language profile's other settings still apply
+func withModel(ctx context.Context, providerName, model string) context.Context {
+	selected := &languageProfile{}
+	if profile, _ := ctx.Value(profileKey{}).(*languageProfile); profile != nil {
+		*selected = *profile
+	}
+	selected.provider, selected.model = providerName, model
+	return withProfile(ctx, selected)
+}
+
+// providersFor returns the provider configuration to use for providerName in
+// this request: the configured one with the request's language profile
+// applied. Provider configs are copied, never modified in place.
+func (r *EnhancedRouter) providersFor(ctx context.Context, providerName string) config.ProvidersConfig {
+	p := r.config.Providers
+	profile, _ := ctx.Value(profileKey{}).(*languageProfile)
+	if profile == nil {
+		return p
+	}
+
+	model := ""
+	if providerName == profile.provider {
+		model = profile.model
+	}
+	temperature := func(t *float64) {
+		if profile.Temperature != nil {
+			*t = *profile.Temperature
+		}
+	}
+	maxTokens := func(n *int) {
+		if profile.MaxTokens > 0 {
+			*n = profile.MaxTokens
+		}
+	}
+
+	switch providerName {
+	case "anthropic":
+		if p.Anthropic != nil {
+			c := *p.Anthropic
+			if model != "" {
+				c.Model = model
+				c.Models = nil
+			}
+			maxTokens(&c.MaxTokens)
+			p.Anthropic = &c
+		}
+	case "cerebras":
+		if p.Cerebras != nil {
+			c := *p.Cerebras
+			if model != "" {
+				c.Model = model
+				c.Models = nil
+			}
+			temperature(&c.Temperature)
+			maxTokens(&c.MaxTokens)
+			p.Cerebras = &c
+		}
+	case "openrouter":
+		if p.OpenRouter != nil {
+			c := *p.OpenRouter
+			if model != "" {
+				c.Model = model
+				c.Models = nil
+			}
+			p.OpenRouter = &c
+		}
+	case "gemini":
+		if p.Gemini != nil {
+			c := *p.Gemini
+			if model != "" {
+				c.Model = model
+				c.Models = nil
+			}
+			p.Gemini = &c
+		}
+	case "azure-openai":
+		if p.AzureOpenAI != nil {
+			c := *p.AzureOpenAI
+			if model != "" {
+				// Azure addresses models by deployment
+				c.Deployment = model
+			}
+			temperature(&c.Temperature)
+			maxTokens(&c.MaxTokens)
+			p.AzureOpenAI = &c
+		}
+	case "bedrock":
+		if p.Bedrock != nil {
+			c := *p.Bedrock
+			if model != "" {
+				c.Model = model
+			}
+			temperature(&c.Temperature)
+			maxTokens(&c.MaxTokens)
+			p.Bedrock = &c
+		}
+	case "qwen":
+		if p.Qwen != nil {
+			c := *p.Qwen
+			if model != "" {
+				c.Model = model
+				c.Models = nil
+			}
+			temperature(&c.Temperature)
+			maxTokens(&c.MaxTokens)
+			p.Qwen = &c
+		}
+	case "synthetic":
+		if p.Synthetic != nil {
+			c := *p.Synthetic
+			if model != "" {
+				c.Model = model
+				c.Models = nil
+			}
+			temperature(&c.Temperature)
+			maxTokens(&c.MaxTokens)
+			p.Synthetic = &c
+		}
+	case "mistral":
+		if p.Mistral != nil {
+			c := *p.Mistral
+			if model != "" {
+				c.Model = model
+			}
+			temperature(&c.Temperature)
+			maxTokens(&c.MaxTokens)
+			p.Mistral = &c
+		}
+	case "vertex":
+		if p.Vertex != nil {
+			c := *p.Vertex
+			if model != "" {
+				c.Model = model
+			}
+			temperature(&c.Temperature)
+			maxTokens(&c.MaxTokens)
+			p.Vertex = &c
+		}
+	case "lmstudio", "llamacpp":
+		if local := localProviderConfig(p, providerName); local != nil {
+			c := *local
+			if model != "" {
+				c.Model = model
+			}
+			temperature(&c.Temperature)
+			maxTokens(&c.MaxTokens)
+			if providerName == "lmstudio" {
+				p.LMStudio = &c
+			} else {
+				p.LlamaCpp = &c
+			}
+		}
+	case "mock":
+		if p.Mock != nil && model != "" {
+			c := *p.Mock
+			c.Model = model
+			p.Mock = &c
+		}
+	default:
+		if custom, ok := p.Custom[providerName]; ok {
+			if model != "" {
+				custom.Model = model
+			}
+			temperature(&custom.Temperature)
+			maxTokens(&custom.MaxTokens)
+			p.Custom = map[string]config.CustomProviderConfig{providerName: custom}
+		}
+	}
+	return p
+}
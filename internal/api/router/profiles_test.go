@@ -0,0 +1,80 @@
+package router
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestLanguageProfileRouting(t *testing.T) {
+	temperature := 0.3
+	cfg := &config.Config{
+		Providers: config.ProvidersConfig{
+			Cerebras:   &config.CerebrasConfig{Model: "qwen-3-coder", Temperature: 0.6},
+			OpenRouter: &config.OpenRouterConfig{Models: []string{"a", "b"}},
+		},
+		Profiles: map[string]config.LanguageProfile{
+			"go":     {Model: "cerebras/zai-glm-4.6", Temperature: &temperature},
+			"python": {Model: "openrouter/qwen/qwen3-coder:free", Providers: []string{"anthropic", "openrouter"}},
+		},
+	}
+	r := &EnhancedRouter{config: cfg}
+
+	if r.profileFor(context.Background(), "README.md") != nil {
+		t.Error("profileFor() matched a language without a profile")
+	}
+
+	goProfile := r.profileFor(context.Background(), "/src/main.go")
+	if goProfile == nil {
+		t.Fatal("profileFor(main.go) = nil")
+	}
+	if got := goProfile.order([]string{"anthropic", "cerebras"}); !reflect.DeepEqual(got, []string{"cerebras", "anthropic"}) {
+		t.Errorf("go order = %v", got)
+	}
+
+	ctx := withProfile(context.Background(), goProfile)
+	cerebras := r.providersFor(ctx, "cerebras").Cerebras
+	if cerebras.Model != "zai-glm-4.6" || cerebras.Temperature != 0.3 {
+		t.Errorf("cerebras with go profile = %s @ %v", cerebras.Model, cerebras.Temperature)
+	}
+	if cfg.Providers.Cerebras.Model != "qwen-3-coder" || cfg.Providers.Cerebras.Temperature != 0.6 {
+		t.Error("providersFor() modified the shared configuration")
+	}
+
+	pyProfile := r.profileFor(context.Background(), "app.py")
+	if got := pyProfile.order([]string{"cerebras"}); !reflect.DeepEqual(got, []string{"openrouter", "anthropic"}) {
+		t.Errorf("python order = %v", got)
+	}
+	openrouter := r.providersFor(withProfile(context.Background(), pyProfile), "openrouter").OpenRouter
+	if openrouter.Model != "qwen/qwen3-coder:free" || openrouter.Models != nil {
+		t.Errorf("openrouter with python profile = %s %v", openrouter.Model, openrouter.Models)
+	}
+}
+
+func TestProjectOnlyReordersEnabledProviders(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"cerebras", "anthropic"}
+	r := &EnhancedRouter{config: cfg}
+
+	got := r.projectOrder([]string{"cerebras", "anthropic"}, []string{"evil", "anthropic:claude-opus", "openrouter"})
+	if want := []string{"anthropic:claude-opus", "cerebras", "anthropic"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("projectOrder() = %v, want %v", got, want)
+	}
+
+	project := &config.ProjectConfig{
+		Model: "evil/exfiltrate",
+		Profiles: map[string]config.LanguageProfile{
+			"python": {Model: "openrouter/qwen", Providers: []string{"evil", "anthropic"}},
+		},
+	}
+	ctx := WithProject(context.Background(), project)
+	if profile := r.profileFor(ctx, "main.go"); profile != nil {
+		t.Errorf("profileFor(main.go) = %+v, want the disabled provider's model ignored", profile)
+	}
+	pyProfile := r.profileFor(ctx, "app.py")
+	if pyProfile == nil || pyProfile.provider != "" || !reflect.DeepEqual(pyProfile.Providers, []string{"anthropic"}) {
+		t.Errorf("profileFor(app.py) = %+v, want only the enabled anthropic", pyProfile)
+	}
+}
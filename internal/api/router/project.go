@@ -0,0 +1,97 @@
+package router
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+type projectKey struct{}
+
+// WithProject attaches the conventions of the project a request writes to,
+// which override the global configuration for that request
+func WithProject(ctx context.Context, project *config.ProjectConfig) context.Context {
+	if project == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, projectKey{}, project)
+}
+
+// projectFrom returns the project attached to ctx, or nil
+func projectFrom(ctx context.Context) *config.ProjectConfig {
+	project, _ := ctx.Value(projectKey{}).(*config.ProjectConfig)
+	return project
+}
+
+// withPreamble puts the project's preamble, if any, before prompt
+func withPreamble(ctx context.Context, prompt string) string {
+	project := projectFrom(ctx)
+	if project == nil || strings.TrimSpace(project.Preamble) == "" {
+		return prompt
+	}
+	return strings.TrimSpace(project.Preamble) + "\n\n" + prompt
+}
+
+// isEnabled reports whether providerName is enabled, by the user and the
+// managed bundle
+func (r *EnhancedRouter) isEnabled(providerName string) bool {
+	return slices.Contains(r.config.Providers.Enabled, providerName)
+}
+
+// projectOrder returns order with the steps of the project's preferred order
+// moved first. A convention file comes with the repository, so it may only
+// reorder the enabled providers; steps naming others are dropped.
+func (r *EnhancedRouter) projectOrder(order, projectOrder []string) []string {
+	var steps []string
+	for _, step := range config.ExpandOrder(projectOrder) {
+		if providerName, _ := config.SplitOrderStep(step); r.isEnabled(providerName) {
+			steps = append(steps, step)
+		} else {
+			logger.Warnf("Ignoring %s in the project's preferred_order: not an enabled provider", step)
+		}
+	}
+	return config.ExpandOrder(append(steps, order...))
+}
+
+// projectModel returns model, a provider/model the project asks for, or ""
+// if its provider isn't enabled
+func (r *EnhancedRouter) projectModel(model string) string {
+	if model == "" {
+		return ""
+	}
+	if providerName, _ := splitProviderModel(model); !r.isEnabled(providerName) {
+		logger.Warnf("Ignoring the project's model %s: not an enabled provider", model)
+		return ""
+	}
+	return model
+}
+
+// projectProfile returns a project's language profile with the models and
+// providers that aren't enabled left out
+func (r *EnhancedRouter) projectProfile(profile config.LanguageProfile) config.LanguageProfile {
+	profile.Model = r.projectModel(profile.Model)
+	if len(profile.Providers) > 0 {
+		profile.Providers = r.projectOrder(nil, profile.Providers)
+	}
+	return profile
+}
+
+// mergeProfile returns base with the settings override sets
+func mergeProfile(base, override config.LanguageProfile) config.LanguageProfile {
+	if override.Model != "" {
+		base.Model = override.Model
+	}
+	if len(override.Providers) > 0 {
+		base.Providers = override.Providers
+	}
+	if override.Temperature != nil {
+		base.Temperature = override.Temperature
+	}
+	if override.MaxTokens > 0 {
+		base.MaxTokens = override.MaxTokens
+	}
+	return base
+}
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// GenerationQueue limits how many generations run at once. Requests over the
+// limit wait in arrival order for a slot.
+type GenerationQueue struct {
+	limit   int
+	active  int
+	waiters []chan struct{} // Closed when the waiter is handed a slot
+
+	queued    int64         // Requests that had to wait
+	totalWait time.Duration // Sum of their waits
+	maxWait   time.Duration
+	mutex     sync.Mutex
+}
+
+// QueueStats is a snapshot of a GenerationQueue
+type QueueStats struct {
+	Active    int
+	Waiting   int
+	Queued    int64
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// NewGenerationQueue creates a queue allowing limit generations at once.
+// Returns nil (no limit) if limit is not positive.
+func NewGenerationQueue(limit int) *GenerationQueue {
+	if limit <= 0 {
+		return nil
+	}
+	return &GenerationQueue{limit: limit}
+}
+
+// Acquire waits for a generation slot and returns how long it waited. The
+// caller must Release the slot unless an error is returned.
+func (q *GenerationQueue) Acquire(ctx context.Context) (time.Duration, error) {
+	q.mutex.Lock()
+	if q.active < q.limit && len(q.waiters) == 0 {
+		q.active++
+		q.mutex.Unlock()
+		return 0, nil
+	}
+	ready := make(chan struct{})
+	q.waiters = append(q.waiters, ready)
+	q.mutex.Unlock()
+
+	start := time.Now()
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		q.mutex.Lock()
+		if i := slices.Index(q.waiters, ready); i >= 0 {
+			q.waiters = slices.Delete(q.waiters, i, i+1)
+			q.mutex.Unlock()
+			return time.Since(start), fmt.Errorf("gave up waiting for a generation slot (%d in flight): %w", q.limit, ctx.Err())
+		}
+		// Handed a slot just as the context ended; pass it on
+		q.mutex.Unlock()
+		q.Release()
+		return time.Since(start), fmt.Errorf("gave up waiting for a generation slot (%d in flight): %w", q.limit, ctx.Err())
+	}
+
+	wait := time.Since(start)
+	q.mutex.Lock()
+	q.queued++
+	q.totalWait += wait
+	q.maxWait = max(q.maxWait, wait)
+	q.mutex.Unlock()
+	return wait, nil
+}
+
+// Release frees a slot, handing it to the longest waiting request
+func (q *GenerationQueue) Release() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.waiters) > 0 {
+		// The slot passes straight to the waiter, so active is unchanged
+		close(q.waiters[0])
+		q.waiters = q.waiters[1:]
+		return
+	}
+	q.active--
+}
+
+// Stats returns the queue's current state and wait totals; zero for a nil queue
+func (q *GenerationQueue) Stats() QueueStats {
+	if q == nil {
+		return QueueStats{}
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return QueueStats{
+		Active:    q.active,
+		Waiting:   len(q.waiters),
+		Queued:    q.queued,
+		TotalWait: q.totalWait,
+		MaxWait:   q.maxWait,
+	}
+}
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGenerationQueueIsFIFO(t *testing.T) {
+	q := NewGenerationQueue(1)
+	if _, err := q.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			if _, err := q.Acquire(context.Background()); err == nil {
+				order <- i
+				q.Release()
+			}
+		}()
+		// Wait until this waiter is queued so arrival order is fixed
+		for q.Stats().Waiting != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	q.Release()
+	for want := 0; want < 3; want++ {
+		if got := <-order; got != want {
+			t.Fatalf("waiter %d got the slot, want %d", got, want)
+		}
+	}
+	if stats := q.Stats(); stats.Active != 0 || stats.Queued != 3 || stats.MaxWait <= 0 {
+		t.Errorf("stats = %+v, want 3 queued and no slot in use", stats)
+	}
+}
+
+func TestGenerationQueueCancelledWaiterLeaves(t *testing.T) {
+	q := NewGenerationQueue(1)
+	q.Acquire(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Acquire(ctx); err == nil {
+		t.Fatal("Acquire succeeded with every slot in use")
+	}
+	if stats := q.Stats(); stats.Waiting != 0 || stats.Active != 1 {
+		t.Errorf("stats = %+v, want the cancelled waiter gone", stats)
+	}
+	q.Release()
+	if _, err := q.Acquire(context.Background()); err != nil {
+		t.Errorf("slot not freed: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// recordAbandoned charges each racer a race cancelled for the prompt it had
+// already sent, in the metrics of racingName and of racingName:provider:model.
+// Prompt tokens come from the winner's usage, or are estimated from the
+// prompt's size; cost uses the model registry's input price.
+func (r *EnhancedRouter) recordAbandoned(racingName string, abandoned []string, usage *types.Usage, prompt, filePath string, contextFiles []string) {
+	if len(abandoned) == 0 {
+		return
+	}
+	promptTokens := 0
+	if usage != nil {
+		promptTokens = usage.PromptTokens
+	}
+	if promptTokens == 0 {
+		promptTokens = estimateTokens(prompt, filePath, contextFiles)
+	}
+
+	var total float64
+	for _, pm := range abandoned {
+		providerName, model, _ := strings.Cut(pm, ":")
+		spec, _ := r.models.Lookup(providerName, model)
+		cost := float64(promptTokens) * spec.InputCost / 1e6
+		total += cost
+
+		modelKey := racingName + ":" + pm
+		r.mutex.Lock()
+		if r.providerMetrics[racingName] == nil {
+			r.providerMetrics[racingName] = NewProviderMetricsTracker(racingName)
+		}
+		if r.providerMetrics[modelKey] == nil {
+			r.providerMetrics[modelKey] = NewModelMetricsTracker(racingName, pm)
+		}
+		tracker, modelTracker := r.providerMetrics[racingName], r.providerMetrics[modelKey]
+		r.mutex.Unlock()
+
+		tracker.RecordAbandoned(promptTokens, cost)
+		modelTracker.RecordAbandoned(promptTokens, cost)
+	}
+	logger.Debugf("%s: abandoned %d racer(s), ~%d prompt tokens each, ~$%.4f", racingName, len(abandoned), promptTokens, total)
+}
+
+// GetRacingStats returns the race statistics of each racing provider that
+// has models configured
+func (r *EnhancedRouter) GetRacingStats() []api.RacingReport {
+	reports := []api.RacingReport{}
+	for name, cfg := range map[string]*config.RacingConfig{
+		"racing":        r.config.Providers.Racing,
+		"racing-clever": r.config.Providers.RacingClever,
+	} {
+		if cfg != nil && len(cfg.Models) > 0 {
+			reports = append(reports, r.racingStats[name].Report(name, cfg))
+		}
+	}
+	slices.SortFunc(reports, func(a, b api.RacingReport) int { return strings.Compare(a.Provider, b.Provider) })
+	return reports
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// defaultMaxQueueWait bounds queueing when a rate limit sets no max_wait
+const defaultMaxQueueWait = 30 * time.Second
+
+// RateLimiter enforces per-provider request rates with token buckets, so
+// bursts from parallel sessions are queued locally instead of tripping the
+// provider's 429s.
+type RateLimiter struct {
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket is a reservation-based token bucket. A request that finds no
+// token takes one from the future and sleeps until it is due, so waiting
+// requests are served in arrival order.
+type tokenBucket struct {
+	perSecond float64
+	burst     float64
+	maxWait   time.Duration
+	tokens    float64
+	last      time.Time
+	mutex     sync.Mutex
+}
+
+// NewRateLimiter creates a limiter for the configured providers. Returns nil
+// if no provider has a rate limit.
+func NewRateLimiter(limits map[string]config.ProviderRateLimit) *RateLimiter {
+	buckets := make(map[string]*tokenBucket)
+	for name, limit := range limits {
+		if limit.RequestsPerMinute <= 0 {
+			continue
+		}
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		maxWait := limit.MaxWait
+		if maxWait <= 0 {
+			maxWait = defaultMaxQueueWait
+		}
+		buckets[name] = &tokenBucket{
+			perSecond: float64(limit.RequestsPerMinute) / 60,
+			burst:     float64(burst),
+			maxWait:   maxWait,
+			tokens:    float64(burst),
+			last:      time.Now(),
+		}
+	}
+	if len(buckets) == 0 {
+		return nil
+	}
+	return &RateLimiter{buckets: buckets}
+}
+
+// Wait blocks until providerName may send a request. It returns an error
+// without waiting if the queue is already longer than the provider's max
+// wait, or when ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context, providerName string) error {
+	if l == nil {
+		return nil
+	}
+	bucket, ok := l.buckets[providerName]
+	if !ok {
+		return nil
+	}
+
+	delay, err := bucket.reserve(time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", providerName, err)
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	logger.Debugf("Router: %s rate limited, queued for %v", providerName, delay.Round(time.Millisecond))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		bucket.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait for it
+func (b *tokenBucket) reserve(now time.Time) (time.Duration, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.perSecond
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0, nil
+	}
+	delay := time.Duration(-b.tokens / b.perSecond * float64(time.Second))
+	if delay > b.maxWait {
+		b.tokens++
+		return 0, fmt.Errorf("rate limit queue full (next slot in %v, max wait %v)", delay.Round(time.Second), b.maxWait)
+	}
+	return delay, nil
+}
+
+// cancel returns the token of an abandoned reservation
+func (b *tokenBucket) cancel() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tokens++
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestTokenBucketQueuesThenRejects(t *testing.T) {
+	limiter := NewRateLimiter(map[string]config.ProviderRateLimit{
+		"cerebras": {RequestsPerMinute: 60, Burst: 2, MaxWait: 1500 * time.Millisecond},
+	})
+	bucket := limiter.buckets["cerebras"]
+	now := bucket.last
+
+	wantDelays := []time.Duration{0, 0, time.Second}
+	for i, want := range wantDelays {
+		delay, err := bucket.reserve(now)
+		if err != nil || delay != want {
+			t.Fatalf("reserve #%d = (%v, %v), want (%v, nil)", i+1, delay, err, want)
+		}
+	}
+
+	// The next slot is 2s away, beyond the 1.5s max wait
+	if delay, err := bucket.reserve(now); err == nil {
+		t.Fatalf("reserve #4 = %v, want queue full error", delay)
+	}
+
+	// A rejected request must not consume a slot
+	if delay, err := bucket.reserve(now.Add(time.Second)); err != nil || delay != time.Second {
+		t.Errorf("reserve after 1s = (%v, %v), want (1s, nil)", delay, err)
+	}
+}
+
+func TestNewRateLimiterWithoutLimits(t *testing.T) {
+	if l := NewRateLimiter(map[string]config.ProviderRateLimit{"openai": {}}); l != nil {
+		t.Error("NewRateLimiter() should return nil when no provider has a rate")
+	}
+	var l *RateLimiter
+	if err := l.Wait(t.Context(), "openai"); err != nil {
+		t.Errorf("nil limiter Wait() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,158 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// Recording modes
+const (
+	RecordMode = "record"
+	ReplayMode = "replay"
+)
+
+// Recorder saves provider responses to disk in record mode and serves them
+// back in replay mode, when it stands in for every provider so no API key or
+// network access is needed
+type Recorder struct {
+	mode  string
+	dir   string
+	mutex sync.Mutex
+}
+
+// recording is the on-disk representation of one provider response
+type recording struct {
+	Recorded     time.Time    `json:"recorded"`
+	Provider     string       `json:"provider"`
+	Model        string       `json:"model"`
+	Prompt       string       `json:"prompt"`
+	FilePath     string       `json:"file_path"`
+	ContextFiles []string     `json:"context_files,omitempty"`
+	Code         string       `json:"code"`
+	Usage        *types.Usage `json:"usage,omitempty"`
+}
+
+// NewRecorder creates a recorder from configuration.
+// Returns nil if recording is disabled.
+func NewRecorder(cfg config.RecordingConfig) (*Recorder, error) {
+	if cfg.Mode == "" {
+		return nil, nil
+	}
+	if cfg.Mode != RecordMode && cfg.Mode != ReplayMode {
+		return nil, fmt.Errorf("unknown recording mode %q (expected %q or %q)", cfg.Mode, RecordMode, ReplayMode)
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(config.ConfigDir(), "recordings")
+	}
+	dir = config.ExpandPath(dir)
+	if cfg.Mode == RecordMode {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+		}
+	} else if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("recordings directory: %w", err)
+	}
+
+	return &Recorder{mode: cfg.Mode, dir: dir}, nil
+}
+
+// Replaying reports whether responses are served from recordings
+func (r *Recorder) Replaying() bool {
+	return r != nil && r.mode == ReplayMode
+}
+
+// Recording reports whether provider responses are being saved
+func (r *Recorder) Recording() bool {
+	return r != nil && r.mode == RecordMode
+}
+
+// Key computes the recording key for a request. Unlike the cache key it
+// leaves out the model and the directories of the output and context files,
+// so recordings replay on other machines, temp paths and configurations.
+func (r *Recorder) Key(providerName, prompt, filePath string, contextFiles []string) string {
+	h := sha256.New()
+	writeField := func(s string) {
+		fmt.Fprintf(h, "%d:%s\n", len(s), s)
+	}
+
+	writeField(providerName)
+	writeField(prompt)
+	writeField(filepath.Ext(filePath))
+	existing, _ := utils.ReadFileContentCached(filePath)
+	writeField(existing)
+
+	type contextFile struct{ name, content string }
+	var files []contextFile
+	for _, file := range contextFiles {
+		content, _ := utils.ReadFileContentCached(file)
+		files = append(files, contextFile{filepath.Base(file), content})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].name != files[j].name {
+			return files[i].name < files[j].name
+		}
+		return files[i].content < files[j].content
+	})
+	for _, file := range files {
+		writeField(file.name)
+		writeField(file.content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Save stores a provider response under key
+func (r *Recorder) Save(key string, rec *recording) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rec.Recorded = time.Now()
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+
+	tmpFile := r.path(key) + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	if err := os.Rename(tmpFile, r.path(key)); err != nil {
+		return fmt.Errorf("failed to store recording: %w", err)
+	}
+	return nil
+}
+
+// Load returns the recording stored under key
+func (r *Recorder) Load(providerName, key string) (*recording, error) {
+	data, err := os.ReadFile(r.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: no recording for this request in %s (key %s)", providerName, r.dir, key[:12])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read recording: %w", providerName, err)
+	}
+
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("%s: corrupt recording %s: %w", providerName, r.path(key), err)
+	}
+	return &rec, nil
+}
+
+func (r *Recorder) path(key string) string {
+	return filepath.Join(r.dir, key+".json")
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestRecordingReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	recorder, err := NewRecorder(config.RecordingConfig{Mode: RecordMode, Dir: dir})
+	if err != nil {
+		t.Fatalf("NewRecorder(record) error = %v", err)
+	}
+	key := recorder.Key("cerebras", "write a rate limiter", "/tmp/run-1/limiter.go", nil)
+	if other := recorder.Key("cerebras", "write a rate limiter", "/tmp/run-2/limiter.go", nil); other != key {
+		t.Error("Key() depends on the output file's directory")
+	}
+	if err := recorder.Save(key, &recording{Provider: "cerebras", Model: "qwen-3-coder", Code: "package limiter"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	replayer, err := NewRecorder(config.RecordingConfig{Mode: ReplayMode, Dir: dir})
+	if err != nil {
+		t.Fatalf("NewRecorder(replay) error = %v", err)
+	}
+
+	// Replay needs no provider configuration at all
+	r := NewEnhancedRouter(&config.Config{}, nil)
+	r.recorder = replayer
+	code, err := r.callProvider(context.Background(), "cerebras", "write a rate limiter", "/home/ci/limiter.go", nil)
+	if err != nil || code != "package limiter" {
+		t.Errorf("callProvider() in replay = %q, %v", code, err)
+	}
+	if _, err := r.callProvider(context.Background(), "cerebras", "something else", "/home/ci/limiter.go", nil); err == nil {
+		t.Error("callProvider() replayed a request that was never recorded")
+	}
+
+	if _, err := NewRecorder(config.RecordingConfig{Mode: "playback", Dir: dir}); err == nil {
+		t.Error("NewRecorder() accepted an unknown mode")
+	}
+	if _, err := NewRecorder(config.RecordingConfig{Mode: ReplayMode, Dir: filepath.Join(dir, "missing")}); err == nil {
+		t.Error("NewRecorder() accepted a missing replay directory")
+	}
+}
@@ -0,0 +1,168 @@
+package router
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// ModelSpec is what the router knows about a model
+type ModelSpec struct {
+	MaxContext  int     // Context window in tokens; 0 = unknown
+	MaxOutput   int     // Completion tokens; 0 = unknown
+	InputCost   float64 // USD per million prompt tokens; 0 = unknown
+	OutputCost  float64 // USD per million completion tokens; 0 = unknown
+	ToolCalling *bool   // nil = unknown
+	Streaming   *bool   // nil = unknown
+}
+
+// ModelRegistry maps models to their limits, cost and capabilities. Entries
+// are layered: the built-in table, then what each provider's models API
+// reports, then overrides from the models section of the config.
+type ModelRegistry struct {
+	overrides []config.ModelOverride
+
+	mutex      sync.RWMutex
+	discovered map[string]map[string]api.ModelInfo // Provider -> lowercased model ID
+}
+
+// NewModelRegistry creates a registry with the configured overrides
+func NewModelRegistry(overrides []config.ModelOverride) *ModelRegistry {
+	return &ModelRegistry{
+		overrides:  overrides,
+		discovered: make(map[string]map[string]api.ModelInfo),
+	}
+}
+
+// AddDiscovered records the models a provider's models API reported
+func (m *ModelRegistry) AddDiscovered(providerName string, models []api.ModelInfo) {
+	byID := make(map[string]api.ModelInfo, len(models))
+	for _, model := range models {
+		byID[strings.ToLower(model.ID)] = model
+	}
+	m.mutex.Lock()
+	m.discovered[providerName] = byID
+	m.mutex.Unlock()
+}
+
+// Seed discovers the models of each provider, through the models cache so
+// that a restart doesn't query every provider again. Providers that can't
+// list their models keep the built-in limits.
+func (m *ModelRegistry) Seed(ctx context.Context, cfg *config.Config, providerNames []string) {
+	for _, name := range providerNames {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(config.DefaultRequestTimeout)*time.Second)
+		models, _, err := api.CachedModels(ctx, cfg, name, false)
+		cancel()
+		if err != nil {
+			logger.Debugf("Model registry: no model list for %s: %v", name, err)
+			continue
+		}
+		m.AddDiscovered(name, models)
+	}
+}
+
+// Lookup returns what is known about model on providerName, and whether
+// anything is. A nil registry knows only the built-in table.
+func (m *ModelRegistry) Lookup(providerName, model string) (ModelSpec, bool) {
+	var spec ModelSpec
+	known := false
+
+	if km, ok := lookupKnownModel(model); ok {
+		spec.MaxContext = km.maxContext
+		known = true
+	}
+	if m == nil {
+		return spec, known
+	}
+
+	m.mutex.RLock()
+	info, ok := m.discovered[providerName][strings.ToLower(model)]
+	m.mutex.RUnlock()
+	if ok {
+		known = true
+		spec.MaxContext = cmp.Or(info.ContextWindow, spec.MaxContext)
+		spec.MaxOutput = cmp.Or(info.MaxOutput, spec.MaxOutput)
+		spec.InputCost = cmp.Or(info.InputCost, spec.InputCost)
+		spec.OutputCost = cmp.Or(info.OutputCost, spec.OutputCost)
+		if info.ToolCalling != nil {
+			spec.ToolCalling = info.ToolCalling
+		}
+	}
+
+	for _, o := range m.overrides {
+		if !strings.EqualFold(o.ID, model) || (o.Provider != "" && o.Provider != providerName) {
+			continue
+		}
+		known = true
+		spec.MaxContext = cmp.Or(o.MaxContext, spec.MaxContext)
+		spec.MaxOutput = cmp.Or(o.MaxOutput, spec.MaxOutput)
+		spec.InputCost = cmp.Or(o.InputCost, spec.InputCost)
+		spec.OutputCost = cmp.Or(o.OutputCost, spec.OutputCost)
+		if o.ToolCalling != nil {
+			spec.ToolCalling = o.ToolCalling
+		}
+		if o.Streaming != nil {
+			spec.Streaming = o.Streaming
+		}
+	}
+	return spec, known
+}
+
+// Lists reports whether providerName's models API listed model, and whether
+// the registry has that list to check against. Models with a config override
+// count as listed.
+func (m *ModelRegistry) Lists(providerName, model string) (listed, checked bool) {
+	if m == nil {
+		return false, false
+	}
+	for _, o := range m.overrides {
+		if strings.EqualFold(o.ID, model) && (o.Provider == "" || o.Provider == providerName) {
+			return true, true
+		}
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	models, checked := m.discovered[providerName]
+	_, listed = models[strings.ToLower(model)]
+	return listed, checked
+}
+
+// estimateTokens approximates the tokens of the assembled prompt: the
+// instructions plus the existing output file and the context files, at four
+// bytes per token. Unreadable files count as empty, as they do when the
+// prompt is built.
+func estimateTokens(prompt, filePath string, contextFiles []string) int {
+	size := int64(len(prompt))
+	for i, path := range append([]string{filePath}, contextFiles...) {
+		if path == "" || (i > 0 && path == filePath) {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+	}
+	return int(size / 4)
+}
+
+// exceedsContext returns providerName's model and its context window if a
+// prompt of promptTokens doesn't fit, or "" if it fits or the window is
+// unknown. Providers that pick among several models are not checked.
+func (r *EnhancedRouter) exceedsContext(ctx context.Context, providerName string, promptTokens int) (model string, maxContext int) {
+	model = providerModel(r.providersFor(ctx, providerName), providerName)
+	if model == "" || strings.Contains(model, ",") {
+		return "", 0
+	}
+	spec, _ := r.models.Lookup(providerName, model)
+	if spec.MaxContext == 0 || promptTokens < spec.MaxContext {
+		return "", 0
+	}
+	return model, spec.MaxContext
+}
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestModelRegistryLayers(t *testing.T) {
+	tools := false
+	m := NewModelRegistry([]config.ModelOverride{
+		{ID: "gpt-4o", Provider: "azure-openai", MaxContext: 32000},
+		{ID: "qwen3-coder-30b", OutputCost: 1.5, ToolCalling: &tools},
+	})
+	m.AddDiscovered("openrouter", []api.ModelInfo{{ID: "qwen3-coder-30b", ContextWindow: 262144, InputCost: 0.5}})
+
+	if spec, ok := m.Lookup("openai", "gpt-4o"); !ok || spec.MaxContext != 128000 {
+		t.Errorf("built-in gpt-4o = %+v, %v", spec, ok)
+	}
+	if spec, _ := m.Lookup("azure-openai", "gpt-4o"); spec.MaxContext != 32000 {
+		t.Errorf("overridden gpt-4o = %+v", spec)
+	}
+	spec, ok := m.Lookup("openrouter", "Qwen3-Coder-30B")
+	if !ok || spec.MaxContext != 262144 || spec.InputCost != 0.5 || spec.OutputCost != 1.5 || spec.ToolCalling == nil || *spec.ToolCalling {
+		t.Errorf("discovered qwen3-coder-30b = %+v, %v", spec, ok)
+	}
+	if _, ok := m.Lookup("lmstudio", "some-local-model"); ok {
+		t.Error("Lookup() knows an unlisted model")
+	}
+}
+
+func TestRouterSkipsModelsThatCannotHoldPrompt(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"mock"}
+	cfg.Providers.Order = []string{"mock"}
+	cfg.Providers.Mock = &config.MockConfig{Model: "mock-small"}
+	r := NewEnhancedRouter(cfg, nil)
+	r.models = NewModelRegistry([]config.ModelOverride{{ID: "mock-small", MaxContext: 100}})
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "main.go")
+	large := filepath.Join(dir, "large.go")
+	if err := os.WriteFile(large, []byte(strings.Repeat("x", 1000)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.GenerateCodeWithValidation(context.Background(), "write main", output, nil, "", "", false, nil); err != nil {
+		t.Fatalf("small prompt: %v", err)
+	}
+	_, err := r.GenerateCodeWithValidation(context.Background(), "write main", output, []string{large}, "", "", false, nil)
+	if err == nil || !strings.Contains(err.Error(), "context window") {
+		t.Errorf("large prompt error = %v, want context window error", err)
+	}
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+)
+
+func TestResidencyViolationSendsNothing(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	restricted := t.TempDir()
+	r := fallbackRouter(server.URL, server.URL, t.Name())
+	r.config.Residency = config.ResidencyConfig{
+		Regions:  map[string]string{"primary": "us"},
+		Policies: []config.ResidencyPolicyConfig{{Path: restricted, AllowedRegions: []string{"eu"}}},
+	}
+	r.residency = policy.NewResidencyChecker(r.config.Residency)
+
+	_, err := r.GenerateCodeWithValidation(context.Background(), "main", filepath.Join(restricted, "main.go"), nil, "", "", false, nil)
+	var violation *policy.ViolationError
+	if !errors.As(err, &violation) {
+		t.Fatalf("err = %v, want a residency violation", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("calls = %d, want no request sent", calls.Load())
+	}
+}
@@ -0,0 +1,81 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/index"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// retrievedHeader introduces the snippets retrieved from the index
+const retrievedHeader = "Relevant code from the repository (retrieved automatically by similarity to the request; use it for names and conventions, it may be incomplete):\n"
+
+// withRetrievedContext puts the index's snippets most relevant to prompt
+// before it, when the index is on and the request wants them. Files the
+// request already sends are left out. A failing index only costs the
+// snippets.
+func (r *EnhancedRouter) withRetrievedContext(ctx context.Context, prompt, filePath string, contextFiles []string) string {
+	if r.index == nil || filePath == "" || !index.AutoContext(ctx, r.config.Index.AutoContext) {
+		return prompt
+	}
+	snippets, err := r.index.Retrieve(ctx, filePath, prompt, contextFiles)
+	if err != nil {
+		logger.Warnf("Index: no retrieved context for %s: %v", filePath, err)
+		return prompt
+	}
+	snippets = r.shareableSnippets(snippets)
+	if len(snippets) == 0 {
+		return prompt
+	}
+	recordRetrieved(ctx, snippets)
+
+	root := index.RootFor(filePath)
+	var section strings.Builder
+	section.WriteString(retrievedHeader)
+	for _, snippet := range snippets {
+		path := snippet.Path
+		if rel, err := filepath.Rel(root, path); err == nil {
+			path = rel
+		}
+		label := fmt.Sprintf("%s (lines %d-%d)", path, snippet.StartLine, snippet.EndLine)
+		language := utils.GetLanguageFromFile(snippet.Path, nil)
+		if r.guard != nil {
+			section.WriteString(r.guard.Wrap(label, language, snippet.Text))
+		} else {
+			fmt.Fprintf(&section, "\nFile: %s\n```%s\n%s\n```\n", label, language, snippet.Text)
+		}
+	}
+	logger.Debugf("Index: added %d snippet(s) to the prompt for %s", len(snippets), filePath)
+	return section.String() + "\n" + prompt
+}
+
+// shareableSnippets drops the snippets of files the workspace doesn't let
+// requests read, or that a residency policy keeps from any enabled
+// provider: the prompt is built before a provider is chosen, and unlike
+// context files a snippet shouldn't rule a provider out.
+func (r *EnhancedRouter) shareableSnippets(snippets []index.Snippet) []index.Snippet {
+	var shareable []index.Snippet
+	for _, snippet := range snippets {
+		if !policy.WorkspaceAllows(r.config.Workspace, snippet.Path) {
+			logger.Debugf("Index: leaving out %s, which the workspace denies", snippet.Path)
+			continue
+		}
+		restricted := false
+		for _, providerName := range r.config.Providers.Enabled {
+			if err := r.checkResidency(providerName, snippet.Path, nil); err != nil {
+				logger.Debugf("Index: leaving out %s: %v", snippet.Path, err)
+				restricted = true
+				break
+			}
+		}
+		if !restricted {
+			shareable = append(shareable, snippet)
+		}
+	}
+	return shareable
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/index"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+)
+
+func TestRetrievedContextInPrompt(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "backoff.go"), []byte("package example\n\n// Backoff returns the exponential backoff delay before a retry attempt\nfunc Backoff(attempt int) int {\n\treturn 1 << attempt\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompts = append(prompts, req.Messages[len(req.Messages)-1].Content)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package example\n"}}]}`)
+	}))
+	defer server.Close()
+
+	r := fallbackRouter(server.URL, server.URL, t.Name())
+	r.config.Index = config.IndexConfig{Enabled: true, AutoContext: true, Dir: t.TempDir()}
+	r.index, _ = index.NewManager(r.config.Index)
+
+	target := filepath.Join(root, "client.go")
+	ctx, info := WithGenerationInfo(context.Background())
+	if _, err := r.GenerateCodeWithValidation(ctx, "retry failed requests with exponential backoff", target, nil, "", "", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "backoff.go (lines 1-6)") || !strings.Contains(prompts[0], "func Backoff") {
+		t.Errorf("prompts = %q, want backoff.go retrieved", prompts)
+	}
+	if len(info.Retrieved) != 1 || info.Retrieved[0] != filepath.Join(root, "backoff.go")+":1-6" {
+		t.Errorf("retrieved = %q, want backoff.go:1-6", info.Retrieved)
+	}
+
+	// The request can turn retrieval off
+	prompts = nil
+	if _, err := r.GenerateCodeWithValidation(index.WithAutoContext(context.Background(), false), "retry failed requests with exponential backoff", target, nil, "", "", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 1 || strings.Contains(prompts[0], "func Backoff") {
+		t.Errorf("prompts = %q, want no retrieved context", prompts)
+	}
+}
+
+func TestRetrievedContextRespectsPolicies(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                "module example\n",
+		"client-x/backoff.go":   "package clientx\n\n// Backoff returns the exponential backoff delay before a retry attempt\nfunc Backoff(attempt int) int {\n\treturn 1 << attempt\n}\n",
+		"secrets/backoff.go":    "package secrets\n\n// RetryBackoff is the exponential backoff delay of a retry attempt\nconst RetryBackoff = 2\n",
+		"internal/retry/run.go": "package retry\n\n// Run retries failed requests\nfunc Run() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		prompts = append(prompts, string(body))
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package example\n"}}]}`)
+	}))
+	defer server.Close()
+
+	r := fallbackRouter(server.URL, server.URL, t.Name())
+	r.config.Index = config.IndexConfig{Enabled: true, AutoContext: true, Dir: t.TempDir()}
+	r.config.Workspace.DeniedPaths = []string{"secrets"}
+	r.config.Residency = config.ResidencyConfig{
+		Regions:  map[string]string{"primary": "eu"},
+		Policies: []config.ResidencyPolicyConfig{{Path: filepath.Join(root, "client-x"), AllowedRegions: []string{"eu"}}},
+	}
+	r.residency = policy.NewResidencyChecker(r.config.Residency)
+	r.index, _ = index.NewManager(r.config.Index)
+	r.index.Filter = func(path string) bool { return policy.WorkspaceAllows(r.config.Workspace, path) }
+
+	ctx, info := WithGenerationInfo(context.Background())
+	if _, err := r.GenerateCodeWithValidation(ctx, "retry failed requests with exponential backoff", filepath.Join(root, "main.go"), nil, "", "", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 1 || strings.Contains(prompts[0], "func Backoff") || strings.Contains(prompts[0], "RetryBackoff") {
+		t.Errorf("prompts = %q, want neither the client-x nor the denied snippet", prompts)
+	}
+	if len(info.Retrieved) != 1 || !strings.HasSuffix(info.Retrieved[0], "run.go:1-4") {
+		t.Errorf("retrieved = %q, want only run.go", info.Retrieved)
+	}
+}
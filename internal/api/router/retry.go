@@ -0,0 +1,107 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// RetryPolicy decides whether and when a failed provider call is retried
+// before the router moves on to the next provider
+type RetryPolicy struct {
+	config config.RetryConfig
+}
+
+// NewRetryPolicy creates a retry policy. Returns nil if retries are disabled.
+func NewRetryPolicy(cfg config.RetryConfig) *RetryPolicy {
+	if cfg.MaxAttempts <= 1 {
+		return nil
+	}
+	if cfg.Multiplier < 1 {
+		cfg.Multiplier = 1
+	}
+	return &RetryPolicy{config: cfg}
+}
+
+// MaxAttempts returns the number of attempts per provider
+func (p *RetryPolicy) MaxAttempts() int {
+	if p == nil {
+		return 1
+	}
+	return p.config.MaxAttempts
+}
+
+// Backoff returns how long to wait before retrying after the given failed
+// attempt (1-based), or false if err should not be retried
+func (p *RetryPolicy) Backoff(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	if p == nil || err == nil || attempt >= p.config.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	transient, retryAfter := isTransient(err)
+	if !transient {
+		return 0, false
+	}
+	if retryAfter > 0 {
+		// Honor the provider's hint, unless another provider is the faster way out
+		if p.config.MaxRetryAfter > 0 && retryAfter > p.config.MaxRetryAfter {
+			return 0, false
+		}
+		return retryAfter, true
+	}
+
+	delay := float64(p.config.InitialBackoff) * math.Pow(p.config.Multiplier, float64(attempt-1))
+	if p.config.MaxBackoff > 0 && delay > float64(p.config.MaxBackoff) {
+		delay = float64(p.config.MaxBackoff)
+	}
+	if p.config.Jitter > 0 {
+		delay *= 1 + p.config.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay), true
+}
+
+// isTransient reports whether err is worth retrying, and the server's
+// Retry-After hint if it sent one
+func isTransient(err error) (bool, time.Duration) {
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests,
+			http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+			529: // Anthropic "overloaded"
+			return true, apiErr.RetryAfter
+		}
+		return false, 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true, 0
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, 0
+	}
+	return false, 0
+}
+
+// sleepContext waits for d, returning false if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
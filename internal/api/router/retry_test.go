@@ -0,0 +1,53 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := NewRetryPolicy(config.RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     90 * time.Second,
+		Multiplier:     2,
+		MaxRetryAfter:  time.Minute,
+	})
+	ctx := context.Background()
+	serverErr := fmt.Errorf("request failed: %w", &api.APIError{Provider: "Cerebras", StatusCode: 503})
+
+	tests := []struct {
+		name      string
+		attempt   int
+		err       error
+		wantDelay time.Duration
+		wantRetry bool
+	}{
+		{"first backoff", 1, serverErr, time.Second, true},
+		{"second backoff", 2, serverErr, 2 * time.Second, true},
+		{"attempts exhausted", 3, serverErr, 0, false},
+		{"retry-after honored", 1, &api.APIError{StatusCode: 429, RetryAfter: 5 * time.Second}, 5 * time.Second, true},
+		{"retry-after too long", 1, &api.APIError{StatusCode: 429, RetryAfter: 2 * time.Minute}, 0, false},
+		{"client error", 1, &api.APIError{StatusCode: 400}, 0, false},
+		{"not an API error", 1, errors.New("no API key"), 0, false},
+	}
+	for _, tt := range tests {
+		delay, retry := policy.Backoff(ctx, tt.attempt, tt.err)
+		if delay != tt.wantDelay || retry != tt.wantRetry {
+			t.Errorf("%s: Backoff() = (%v, %v), want (%v, %v)", tt.name, delay, retry, tt.wantDelay, tt.wantRetry)
+		}
+	}
+}
+
+func TestRetryPolicyDisabled(t *testing.T) {
+	policy := NewRetryPolicy(config.RetryConfig{MaxAttempts: 1})
+	if _, retry := policy.Backoff(context.Background(), 1, &api.APIError{StatusCode: 503}); retry {
+		t.Error("Backoff() retried with max_attempts 1")
+	}
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// insecureThenSafeServer answers with code that skips TLS verification, then
+// with code that doesn't
+func insecureThenSafeServer(calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `package main\n\nvar insecure = true\n`
+		if calls.Add(1) == 1 {
+			content = `package main\n\nimport \"crypto/tls\"\n\nvar cfg = &tls.Config{InsecureSkipVerify: true}\n`
+		}
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":"%s"}}]}`, content)
+	}))
+}
+
+func TestSecurityScanModes(t *testing.T) {
+	tests := []struct {
+		mode      string
+		wantCalls int32
+		wantWarn  bool
+	}{
+		{config.SecurityModeBlock, 2, false},
+		{config.SecurityModeWarn, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var calls atomic.Int32
+			server := insecureThenSafeServer(&calls)
+			defer server.Close()
+
+			r := fallbackRouter(server.URL, server.URL, t.Name())
+			r.config.Validation.Security = config.SecurityScanConfig{Enabled: true, Mode: tt.mode}
+			var warnings []string
+			code, err := r.GenerateCodeWithValidation(context.Background(), "main", "main.go", nil, "", "", false, func(_, message string) {
+				warnings = append(warnings, message)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+			warned := len(warnings) > 0 && strings.Contains(warnings[len(warnings)-1], "Security scan flagged 1 issue")
+			if warned != tt.wantWarn {
+				t.Errorf("warnings = %q, want a security warning: %v", warnings, tt.wantWarn)
+			}
+			if insecure := strings.Contains(code, "InsecureSkipVerify"); insecure != tt.wantWarn {
+				t.Errorf("code = %q, want the insecure code only when warning", code)
+			}
+		})
+	}
+}
+
+func TestBlockedCodeIsNeverWrittenUnvalidated(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package main\n\nimport \"crypto/tls\"\n\nvar cfg = &tls.Config{InsecureSkipVerify: true}\n"}}]}`)
+	}))
+	defer server.Close()
+
+	r := fallbackRouter(server.URL, server.URL, t.Name())
+	r.config.Validation.Security = config.SecurityScanConfig{Enabled: true, Mode: config.SecurityModeBlock}
+	retries, allow := 0, true
+	ctx := WithValidationOptions(context.Background(), ValidationOptions{Retries: &retries, AllowUnvalidated: &allow})
+	code, err := r.GenerateCodeWithValidation(ctx, "main", "main.go", nil, "", "", true, nil)
+	if err == nil || code != "" {
+		t.Fatalf("code = %q, err = %v; want the insecure code rejected", code, err)
+	}
+	if kind := ClassifyError(err); kind != ErrorKindValidationFailed {
+		t.Errorf("kind = %s, want %s", kind, ErrorKindValidationFailed)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want both providers tried", calls.Load())
+	}
+}
@@ -0,0 +1,60 @@
+package router
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+)
+
+// resolveSelection checks a provider and model requested for one call and
+// returns the provider and model to use. model may name its provider as
+// "provider/model" when providerName is empty; both empty means no
+// selection. The provider must be enabled, and the model must be one its
+// models API lists, if the registry has that list.
+func (r *EnhancedRouter) resolveSelection(providerName, model string) (string, string, error) {
+	providerName, model = strings.TrimSpace(providerName), strings.TrimSpace(model)
+	if providerName == "" && model != "" {
+		providerName, model = splitProviderModel(model)
+		if model == "" {
+			return "", "", fmt.Errorf("model %q does not name a provider; pass provider as well, or use provider/model", providerName)
+		}
+	}
+	if providerName == "" {
+		return "", "", nil
+	}
+
+	if !slices.Contains(r.config.Providers.Enabled, providerName) {
+		known := providerName == "racing" || providerName == "racing-clever" ||
+			slices.Contains(api.ProviderNames(r.config), providerName)
+		if !known {
+			return "", "", fmt.Errorf("unknown provider %q (enabled: %s)", providerName, strings.Join(r.config.Providers.Enabled, ", "))
+		}
+		return "", "", fmt.Errorf("provider %q is not enabled (enabled: %s)", providerName, strings.Join(r.config.Providers.Enabled, ", "))
+	}
+	if model == "" {
+		return providerName, "", nil
+	}
+
+	switch providerName {
+	case "racing", "racing-clever":
+		return "", "", fmt.Errorf("%s races its configured models and does not take a model", providerName)
+	}
+	// Azure's models API lists base models, not the deployments it is called
+	// with; configured models, one or a models list, are always accepted
+	if providerName == "azure-openai" || slices.Contains(strings.Split(r.configuredModel(providerName), ","), model) {
+		return providerName, model, nil
+	}
+	if listed, checked := r.models.Lists(providerName, model); checked && !listed {
+		return "", "", fmt.Errorf("unknown model %q for provider %s (see mcp-code-api models list %s)", model, providerName, providerName)
+	}
+	return providerName, model, nil
+}
+
+// CheckSelection returns the error GenerateCodeWithValidation would give for
+// requestedProvider and requestedModel, or nil if they can be used
+func (r *EnhancedRouter) CheckSelection(requestedProvider, requestedModel string) error {
+	_, _, err := r.resolveSelection(requestedProvider, requestedModel)
+	return err
+}
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestRequestedProviderAndModel(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"cerebras", "mock"}
+	cfg.Providers.Order = []string{"cerebras", "mock"}
+	cfg.Providers.Mock = &config.MockConfig{Model: "mock-small"}
+	r := NewEnhancedRouter(cfg, nil)
+	r.models = NewModelRegistry(nil)
+	r.models.AddDiscovered("mock", []api.ModelInfo{{ID: "mock-small"}, {ID: "mock-large"}})
+
+	// cerebras comes first but has no API key; the request names mock
+	ctx, info := WithGenerationInfo(context.Background())
+	if _, err := r.GenerateCodeWithValidation(ctx, "write main", "", nil, "", "mock/mock-large", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if info.Provider != "mock" || info.Model != "mock-large" {
+		t.Errorf("generated by %s/%s, want mock/mock-large", info.Provider, info.Model)
+	}
+
+	for _, tt := range []struct {
+		provider, model, want string
+	}{
+		{"nope", "", "unknown provider"},
+		{"anthropic", "", "not enabled"},
+		{"mock", "mock-huge", "unknown model"},
+		{"", "mock-large", "does not name a provider"},
+	} {
+		_, err := r.GenerateCodeWithValidation(context.Background(), "write main", "", nil, tt.provider, tt.model, false, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("provider %q model %q: error = %v, want %q", tt.provider, tt.model, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// minOutputTokens is the completion size assumed when costing a request for
+// a new or small file
+const minOutputTokens = 1000
+
+// costOrder sorts providers cheapest-first by the estimated cost of the
+// request on each one's model. Providers whose price is unknown follow in
+// their original order.
+func (r *EnhancedRouter) costOrder(ctx context.Context, order []string, promptTokens, outputTokens int) []string {
+	type ranked struct {
+		name  string
+		cost  float64
+		known bool
+	}
+	candidates := make([]ranked, len(order))
+	for i, name := range order {
+		stepCtx, providerName := stepContext(ctx, name)
+		cost, known := r.estimateCost(stepCtx, providerName, promptTokens, outputTokens)
+		candidates[i] = ranked{name, cost, known}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].known != candidates[j].known {
+			return candidates[i].known
+		}
+		return candidates[i].known && candidates[i].cost < candidates[j].cost
+	})
+
+	sorted := make([]string, len(candidates))
+	var costs []string
+	for i, c := range candidates {
+		sorted[i] = c.name
+		if c.known {
+			costs = append(costs, fmt.Sprintf("%s $%.4f", c.name, c.cost))
+		} else {
+			costs = append(costs, c.name+" unknown")
+		}
+	}
+	logger.Debugf("Cost order for ~%d prompt tokens: %s", promptTokens, strings.Join(costs, ", "))
+	return sorted
+}
+
+// estimateCost returns the cost in USD of a request to providerName's model,
+// and whether its price is known. Local servers and OpenRouter's free models
+// cost nothing; providers that pick among several models are unknown.
+func (r *EnhancedRouter) estimateCost(ctx context.Context, providerName string, promptTokens, outputTokens int) (float64, bool) {
+	switch providerName {
+	case "lmstudio", "llamacpp", "mock":
+		return 0, true
+	}
+	model := providerModel(r.providersFor(ctx, providerName), providerName)
+	if model == "" || strings.Contains(model, ",") {
+		return 0, false
+	}
+	if strings.HasSuffix(model, ":free") {
+		return 0, true
+	}
+
+	spec, _ := r.models.Lookup(providerName, model)
+	if spec.InputCost == 0 && spec.OutputCost == 0 {
+		return 0, false
+	}
+	outputTokens = max(outputTokens, minOutputTokens)
+	if spec.MaxOutput > 0 {
+		outputTokens = min(outputTokens, spec.MaxOutput)
+	}
+	return (float64(promptTokens)*spec.InputCost + float64(outputTokens)*spec.OutputCost) / 1e6, true
+}
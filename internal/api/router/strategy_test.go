@@ -0,0 +1,36 @@
+package router
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestCostOrder(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{
+		"together":  {Model: "big-coder"},
+		"fireworks": {Model: "small-coder"},
+		"novita":    {Model: "unpriced-coder"},
+	}
+	cfg.Providers.OpenRouter = &config.OpenRouterConfig{Model: "qwen/qwen3-coder:free"}
+	r := NewEnhancedRouter(cfg, nil)
+	r.models = NewModelRegistry([]config.ModelOverride{
+		{ID: "big-coder", InputCost: 3, OutputCost: 15},
+		{ID: "small-coder", InputCost: 0.2, OutputCost: 0.6},
+	})
+
+	order := []string{"novita", "together", "fireworks", "openrouter", "lmstudio"}
+	got := r.costOrder(context.Background(), order, 20000, 0)
+	want := []string{"openrouter", "lmstudio", "fireworks", "together", "novita"}
+	if !slices.Equal(got, want) {
+		t.Errorf("costOrder() = %v, want %v", got, want)
+	}
+
+	// $3 x 20k prompt tokens plus $15 x the 1000 assumed completion tokens
+	if cost, ok := r.estimateCost(context.Background(), "together", 20000, 0); !ok || cost != 0.075 {
+		t.Errorf("estimateCost(together) = %v, %v, want 0.075", cost, ok)
+	}
+}
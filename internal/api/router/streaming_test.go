@@ -0,0 +1,61 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestStreamedResponseRecordsTimeToFirstToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream        bool `json:"stream"`
+			StreamOptions struct {
+				IncludeUsage bool `json:"include_usage"`
+			} `json:"stream_options"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream || !req.StreamOptions.IncludeUsage {
+			t.Errorf("request did not ask for a stream with usage: %+v", req)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{"package main\n\n", "func main() {}\n"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", piece)
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	r := NewEnhancedRouter(cfg, nil)
+
+	code, err := r.GenerateCodeWithValidation(context.Background(), "main", "main.go", nil, "", "", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(code) != "package main\n\nfunc main() {}" {
+		t.Errorf("code = %q, want the accumulated stream", code)
+	}
+
+	m := r.GetProviderMetrics()["stub"]
+	if m.TotalTokens != 15 {
+		t.Errorf("TotalTokens = %d, want the usage from the final chunk", m.TotalTokens)
+	}
+	if m.P50TTFT <= 0 || m.P50TTFT >= m.P50Latency {
+		t.Errorf("P50TTFT = %v, want a positive time before the full latency %v", m.P50TTFT, m.P50Latency)
+	}
+}
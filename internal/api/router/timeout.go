@@ -0,0 +1,24 @@
+package router
+
+import (
+	"context"
+	"time"
+)
+
+// providerTimeout returns how long one attempt on providerName may take:
+// its providers.timeouts entry, else providers.timeout. 0 leaves the
+// client's built-in timeout.
+func (r *EnhancedRouter) providerTimeout(providerName string) time.Duration {
+	if timeout, ok := r.config.Providers.Timeouts[providerName]; ok && timeout > 0 {
+		return timeout
+	}
+	return r.config.Providers.Timeout
+}
+
+// withProviderTimeout bounds ctx by providerName's timeout, if configured
+func (r *EnhancedRouter) withProviderTimeout(ctx context.Context, providerName string) (context.Context, context.CancelFunc) {
+	if timeout := r.providerTimeout(providerName); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestProviderTimeoutFallsBackToNextProvider(t *testing.T) {
+	done := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer slow.Close()
+	defer close(done)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"package main\n\nfunc main() {}\n"}}]}`))
+	}))
+	defer fast.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"slow", "fast"}
+	cfg.Providers.Order = []string{"slow", "fast"}
+	cfg.Providers.Timeout = 5 * time.Second
+	cfg.Providers.Timeouts = map[string]time.Duration{"slow": 50 * time.Millisecond}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{
+		"slow": {BaseURL: slow.URL, APIKey: "key", Model: "coder"},
+		"fast": {BaseURL: fast.URL, APIKey: "key", Model: "coder"},
+	}
+	r := NewEnhancedRouter(cfg, nil)
+
+	if got := r.providerTimeout("fast"); got != 5*time.Second {
+		t.Errorf("fast timeout = %v, want the global providers.timeout", got)
+	}
+
+	start := time.Now()
+	code, err := r.GenerateCodeWithValidation(context.Background(), "main", "main.go", nil, "", "", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "func main()") {
+		t.Errorf("code = %q, want the fast provider's", code)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %v; the slow provider's timeout was not enforced", elapsed)
+	}
+}
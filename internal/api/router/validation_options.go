@@ -0,0 +1,39 @@
+package router
+
+import (
+	"context"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// ValidationOptions override the validation settings of the configuration
+// for one request; nil fields keep the configured value
+type ValidationOptions struct {
+	Retries          *int
+	Fallback         *bool
+	AllowUnvalidated *bool
+}
+
+type validationOptionsKey struct{}
+
+// WithValidationOptions returns a context whose requests use opts
+func WithValidationOptions(ctx context.Context, opts ValidationOptions) context.Context {
+	return context.WithValue(ctx, validationOptionsKey{}, opts)
+}
+
+// ValidationConfig returns the validation settings for the request in ctx:
+// the configuration, then the project's, then the request's
+func (r *EnhancedRouter) ValidationConfig(ctx context.Context) config.ValidationConfig {
+	cfg := projectFrom(ctx).ApplyValidation(r.config.Validation)
+	opts, _ := ctx.Value(validationOptionsKey{}).(ValidationOptions)
+	if opts.Retries != nil {
+		cfg.Retries = opts.Retries
+	}
+	if opts.Fallback != nil {
+		cfg.Fallback = opts.Fallback
+	}
+	if opts.AllowUnvalidated != nil {
+		cfg.AllowUnvalidated = *opts.AllowUnvalidated
+	}
+	return cfg
+}
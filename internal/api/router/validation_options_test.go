@@ -0,0 +1,75 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// invalidGoServer answers every request with Go code that doesn't parse
+func invalidGoServer(calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"package main\n\nfunc main() {\n"}}]}`)
+	}))
+}
+
+func TestValidationRetryBudgetAndFallback(t *testing.T) {
+	retries, noFallback := 0, false
+	tests := []struct {
+		name        string
+		opts        ValidationOptions
+		wantPrimary int32
+		wantBackup  int32
+	}{
+		{"default", ValidationOptions{}, 3, 3},
+		{"no retries", ValidationOptions{Retries: &retries}, 1, 1},
+		{"no fallback", ValidationOptions{Retries: &retries, Fallback: &noFallback}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var primaryCalls, backupCalls atomic.Int32
+			primary, backup := invalidGoServer(&primaryCalls), invalidGoServer(&backupCalls)
+			defer primary.Close()
+			defer backup.Close()
+
+			ctx := WithValidationOptions(context.Background(), tt.opts)
+			_, err := fallbackRouter(primary.URL, backup.URL, t.Name()).GenerateCodeWithValidation(ctx, "main", "main.go", nil, "", "", true, nil)
+			var genErr *GenerationError
+			if !errors.As(err, &genErr) || genErr.Kind != ErrorKindValidationFailed {
+				t.Fatalf("error = %v, want validation_failed", err)
+			}
+			if primaryCalls.Load() != tt.wantPrimary || backupCalls.Load() != tt.wantBackup {
+				t.Errorf("calls = %d and %d, want %d and %d", primaryCalls.Load(), backupCalls.Load(), tt.wantPrimary, tt.wantBackup)
+			}
+		})
+	}
+}
+
+func TestAllowUnvalidatedReturnsLastAttempt(t *testing.T) {
+	var calls atomic.Int32
+	server := invalidGoServer(&calls)
+	defer server.Close()
+
+	retries, allow := 0, true
+	ctx := WithValidationOptions(context.Background(), ValidationOptions{Retries: &retries, AllowUnvalidated: &allow})
+	ctx, info := WithGenerationInfo(ctx)
+	var warnings []string
+	code, err := fallbackRouter(server.URL, server.URL, t.Name()).GenerateCodeWithValidation(ctx, "main", "main.go", nil, "", "", true, func(_, message string) {
+		warnings = append(warnings, message)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code == "" || !info.Unvalidated || len(info.Attempts) != 2 {
+		t.Errorf("code = %q, unvalidated = %v, attempts = %+v; want the last attempt, unvalidated after 2 failures", code, info.Unvalidated, info.Attempts)
+	}
+	if len(warnings) == 0 || !strings.Contains(warnings[len(warnings)-1], "unvalidated") {
+		t.Errorf("warnings = %q, want the last about unvalidated code", warnings)
+	}
+}
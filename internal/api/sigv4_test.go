@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignSigV4Vanilla checks the signer against the "get-vanilla" case from
+// the AWS SigV4 test suite
+func TestSignSigV4Vanilla(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	signSigV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCanonicalURIDoubleEncodes(t *testing.T) {
+	got := canonicalURI("/model/" + awsURIEncode("anthropic.claude-v2:1") + "/converse")
+	if !strings.Contains(got, "anthropic.claude-v2%253A1") {
+		t.Errorf("canonicalURI = %s, want double-encoded colon", got)
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+)
+
+// firstTokenKey is the context key for the callback set by WithFirstToken
+type firstTokenKey struct{}
+
+// WithFirstToken returns a context asking providers that can stream their
+// response to do so, calling fn when the first content arrives. Providers
+// that cannot stream ignore it. fn may be called more than once if a call
+// is retried with another API key, so it should be idempotent.
+func WithFirstToken(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, firstTokenKey{}, fn)
+}
+
+// firstTokenFunc returns the callback set by WithFirstToken, or nil if the
+// caller did not ask for a streamed response
+func firstTokenFunc(ctx context.Context) func() {
+	fn, _ := ctx.Value(firstTokenKey{}).(func())
+	return fn
+}
+
+// isEventStream reports whether resp is a server-sent event stream. Some
+// OpenAI-compatible servers ignore the stream flag and reply with a single
+// JSON body, which is then parsed as usual.
+func isEventStream(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return resp.StatusCode == http.StatusOK && mediaType == "text/event-stream"
+}
+
+// StreamOptions asks an OpenAI-compatible server for extra stream events
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// maxEventSize bounds one server-sent event line
+const maxEventSize = 1 << 20
+
+// readEvents calls fn with the data of each server-sent event in body until
+// the stream ends or sends [DONE]
+func readEvents(body io.Reader, fn func(data []byte) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	for scanner.Scan() {
+		data, ok := bytes.CutPrefix(scanner.Bytes(), []byte("data:"))
+		if !ok {
+			continue // event names, comments and keep-alives
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			return nil
+		}
+		if len(data) == 0 {
+			continue
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read response stream: %w", err)
+	}
+	return nil
+}
+
+// chatStreamChunk is one event of an OpenAI-compatible chat completions stream
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// readChatStream reads an OpenAI-compatible chat completions stream,
+// calling onFirst when the first content arrives. It returns the content
+// and the usage sent with the final chunk.
+func readChatStream(body io.Reader, onFirst func()) (string, types.Usage, error) {
+	var content bytes.Buffer
+	var usage types.Usage
+	err := readEvents(body, func(data []byte) error {
+		var chunk chatStreamChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return errors.New(chunk.Error.Message)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if content.Len() == 0 && onFirst != nil {
+				onFirst()
+			}
+			content.WriteString(choice.Delta.Content)
+		}
+		if chunk.Usage != nil {
+			usage = types.Usage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+		return nil
+	})
+	if err == nil && content.Len() == 0 {
+		err = errors.New("no content in API response stream")
+	}
+	return content.String(), usage, err
+}
+
+// anthropicStreamEvent is one event of an Anthropic Messages stream
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage AnthropicUsage `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Text     string `json:"text"`
+		Thinking string `json:"thinking"`
+	} `json:"delta"`
+	Usage AnthropicUsage `json:"usage"`
+	Error AnthropicError `json:"error"`
+}
+
+// readAnthropicStream reads an Anthropic Messages stream, calling onFirst
+// when the first text arrives; thinking does not count. It returns the
+// text, the thinking and the usage reported by the message_start and
+// message_delta events.
+func readAnthropicStream(body io.Reader, onFirst func()) (string, string, AnthropicUsage, error) {
+	var text, thinking bytes.Buffer
+	var usage AnthropicUsage
+	err := readEvents(body, func(data []byte) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to parse stream event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+			usage.CacheCreationInputTokens = event.Message.Usage.CacheCreationInputTokens
+			usage.CacheReadInputTokens = event.Message.Usage.CacheReadInputTokens
+		case "content_block_delta":
+			thinking.WriteString(event.Delta.Thinking)
+			if event.Delta.Text == "" {
+				return nil
+			}
+			if text.Len() == 0 && onFirst != nil {
+				onFirst()
+			}
+			text.WriteString(event.Delta.Text)
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "error":
+			return errors.New(event.Error.Message)
+		}
+		return nil
+	})
+	if err == nil && text.Len() == 0 {
+		err = errors.New("no content in API response stream")
+	}
+	return text.String(), thinking.String(), usage, err
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// defaultSyntheticBaseURL is the Hugging Face router's OpenAI-compatible API
+const defaultSyntheticBaseURL = "https://router.huggingface.co/v1"
+
+// NewSyntheticClient returns a client for Synthetic, Hugging Face's Inference
+// Providers, through the Hugging Face router's OpenAI-compatible chat
+// completions endpoint
+func NewSyntheticClient(cfg config.SyntheticConfig) *CustomClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultSyntheticBaseURL
+	}
+	c := NewCustomClient("synthetic", config.CustomProviderConfig{
+		BaseURL:     baseURL,
+		Model:       presetModel(cfg.Model, cfg.Models),
+		MaxTokens:   cfg.MaxTokens,
+		Temperature: cfg.Temperature,
+	})
+	c.keyManager = NewAPIKeyManager("Synthetic", cfg.GetAllAPIKeys(), cfg.KeyBalancing)
+	c.modelSelector = newConfiguredModelSelector("Synthetic", cfg.Model, cfg.Models, cfg.ModelStrategy)
+	c.errorMessage = huggingFaceErrorMessage
+	return c
+}
+
+// huggingFaceErrorMessage reads the router's OpenAI-style
+// {"error": {"message": ...}} errors and the older Inference API's plain
+// {"error": "..."}
+func huggingFaceErrorMessage(body []byte) string {
+	var errorResponse struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &errorResponse) != nil || len(errorResponse.Error) == 0 {
+		return ""
+	}
+	var message string
+	if json.Unmarshal(errorResponse.Error, &message) == nil {
+		return message
+	}
+	var detail struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(errorResponse.Error, &detail)
+	return detail.Message
+}
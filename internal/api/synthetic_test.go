@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestSyntheticClientSelectsHubModel(t *testing.T) {
+	var got CerebrasRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer hf_test" {
+			t.Errorf("Authorization = %q, want Bearer hf_test", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"print(1)"}}],` +
+			`"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`))
+	}))
+	defer server.Close()
+
+	client := NewSyntheticClient(config.SyntheticConfig{
+		APIKey:  "hf_test",
+		BaseURL: server.URL + "/v1",
+		Models:  []string{"Qwen/Qwen2.5-Coder-32B-Instruct", "deepseek-ai/DeepSeek-V3"},
+	})
+	result, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	if result.Code != "print(1)" || result.Usage.TotalTokens != 15 {
+		t.Errorf("GenerateCode() = %+v with usage %+v, want print(1) with 15 tokens", result, result.Usage)
+	}
+	if got.Model != "Qwen/Qwen2.5-Coder-32B-Instruct" || client.GetModel() != got.Model {
+		t.Errorf("model = %q (GetModel %q), want the first of the configured models", got.Model, client.GetModel())
+	}
+}
+
+func TestSyntheticClientParsesInferenceAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"Model is currently loading"}`))
+	}))
+	defer server.Close()
+
+	client := NewSyntheticClient(config.SyntheticConfig{APIKey: "hf_test", BaseURL: server.URL, Model: "bigcode/starcoder2-15b"})
+	_, err := client.GenerateCode(t.Context(), "print one", "", "main.py", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Model is currently loading" {
+		t.Errorf("GenerateCode() error = %v, want the API error message", err)
+	}
+}
+
+func TestHuggingFaceErrorMessage(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"error":"Model is currently loading"}`:                    "Model is currently loading",
+		`{"error":{"message":"Invalid token","type":"auth_error"}}`: "Invalid token",
+		`Bad Gateway`: "",
+	} {
+		if got := huggingFaceErrorMessage([]byte(body)); got != want {
+			t.Errorf("huggingFaceErrorMessage(%s) = %q, want %q", body, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Built-in request timeouts, used when the caller's context has no deadline.
+// The router sets one from providers.timeout and providers.timeouts.
+const (
+	defaultRequestTimeout = 60 * time.Second
+	geminiRequestTimeout  = 30 * time.Second
+	localRequestTimeout   = 300 * time.Second // Local models can be slow to load
+)
+
+// doRequest sends req, bounded by fallback unless its context already has a
+// deadline
+func doRequest(client *http.Client, req *http.Request, fallback time.Duration) (*http.Response, error) {
+	if _, ok := req.Context().Deadline(); ok {
+		return client.Do(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), fallback)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The deadline covers reading the body too, as http.Client.Timeout does
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context when its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
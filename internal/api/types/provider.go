@@ -11,20 +11,27 @@ import (
 type ProviderType string
 
 const (
-	ProviderTypeOpenAI     ProviderType = "openai"
-	ProviderTypeAnthropic  ProviderType = "anthropic"
-	ProviderTypeGemini     ProviderType = "gemini"
-	ProviderTypeQwen       ProviderType = "qwen"
-	ProviderTypeCerebras   ProviderType = "cerebras"
-	ProviderTypeOpenRouter ProviderType = "openrouter"
-	ProviderTypeSynthetic  ProviderType = "synthetic"
-	ProviderTypexAI        ProviderType = "xai"
-	ProviderTypeFireworks  ProviderType = "fireworks"
-	ProviderTypeDeepseek   ProviderType = "deepseek"
-	ProviderTypeMistral    ProviderType = "mistral"
-	ProviderTypeLMStudio   ProviderType = "lmstudio"
-	ProviderTypeLlamaCpp   ProviderType = "llamacpp"
-	ProviderTypeOllama     ProviderType = "ollama"
+	ProviderTypeOpenAI      ProviderType = "openai"
+	ProviderTypeAnthropic   ProviderType = "anthropic"
+	ProviderTypeGemini      ProviderType = "gemini"
+	ProviderTypeQwen        ProviderType = "qwen"
+	ProviderTypeCerebras    ProviderType = "cerebras"
+	ProviderTypeOpenRouter  ProviderType = "openrouter"
+	ProviderTypeAzureOpenAI ProviderType = "azure-openai"
+	ProviderTypeBedrock     ProviderType = "bedrock"
+	ProviderTypeVertex      ProviderType = "vertex"
+	ProviderTypeSynthetic   ProviderType = "synthetic"
+	ProviderTypexAI         ProviderType = "xai"
+	ProviderTypeFireworks   ProviderType = "fireworks"
+	ProviderTypeDeepseek    ProviderType = "deepseek"
+	ProviderTypeMistral     ProviderType = "mistral"
+	ProviderTypeLMStudio    ProviderType = "lmstudio"
+	ProviderTypeLlamaCpp    ProviderType = "llamacpp"
+	ProviderTypeOllama      ProviderType = "ollama"
+	ProviderTypeMock        ProviderType = "mock"
+
+	// ProviderTypeOpenAICompatible backs user-defined providers from providers.custom
+	ProviderTypeOpenAICompatible ProviderType = "openai-compatible"
 )
 
 // AuthMethod represents the authentication method
@@ -96,12 +103,23 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	CachedTokens     int `json:"cached_tokens,omitempty"` // Prompt tokens read from the provider's prompt cache
 }
 
 // CodeGenerationResult represents the result of code generation including token usage
 type CodeGenerationResult struct {
-	Code  string  `json:"code"`
-	Usage *Usage  `json:"usage,omitempty"`
+	Code      string     `json:"code"`
+	Usage     *Usage     `json:"usage,omitempty"`
+	Citations []Citation `json:"citations,omitempty"` // Sources the provider reports the response quotes
+}
+
+// Citation is a source a provider reports part of its response recites
+type Citation struct {
+	URI        string `json:"uri,omitempty"`
+	Title      string `json:"title,omitempty"`
+	License    string `json:"license,omitempty"`
+	StartIndex int    `json:"start_index,omitempty"` // Recited span of the response text
+	EndIndex   int    `json:"end_index,omitempty"`
 }
 
 // ChatMessage represents a chat message
@@ -174,11 +192,26 @@ type GenerateOptions struct {
 	Stop           []string               `json:"stop,omitempty"`
 	Stream         bool                   `json:"stream"`
 	Tools          []Tool                 `json:"tools,omitempty"`
-	ResponseFormat string                 `json:"response_format,omitempty"`
+	ResponseFormat *ResponseFormat        `json:"response_format,omitempty"`
 	Timeout        timepkg.Duration       `json:"timeout,omitempty"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// ResponseFormat asks an OpenAI-compatible provider for machine-readable
+// output. Type is "json_object" for any valid JSON, or "json_schema" to
+// have the output match JSONSchema.
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is the schema a "json_schema" response format must match
+type JSONSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict,omitempty"` // Reject output that does not match exactly
+}
+
 // ProviderMetrics represents metrics for a provider
 type ProviderMetrics struct {
 	RequestCount    int64            `json:"request_count"`
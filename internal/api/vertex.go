@@ -0,0 +1,258 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+// vertexScope is the OAuth scope required by the Vertex AI API
+const vertexScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// VertexClient handles Google Vertex AI interactions. Vertex serves the same
+// generateContent wire format as the Gemini API, but under a project/region
+// scoped URL and authenticated with GCP IAM instead of an API key.
+type VertexClient struct {
+	config    config.VertexConfig
+	client    *http.Client
+	lastUsage *types.Usage
+}
+
+// vertexTokenSources caches service-account token sources per key file, since
+// the router creates a new client for every request
+var vertexTokenSources = struct {
+	sync.Mutex
+	sources map[string]oauth2.TokenSource
+}{sources: make(map[string]oauth2.TokenSource)}
+
+// VertexRequest represents a Vertex generateContent request
+type VertexRequest struct {
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// NewVertexClient creates a new Vertex AI client
+func NewVertexClient(cfg config.VertexConfig) *VertexClient {
+	return &VertexClient{
+		config: cfg,
+		client: &http.Client{},
+	}
+}
+
+// GenerateCode generates code using a Vertex AI publisher model
+func (c *VertexClient) GenerateCode(ctx context.Context, prompt, contextStr, outputFile string, language *string, contextFiles []string) (*types.CodeGenerationResult, error) {
+	if !c.config.HasCredentials() {
+		return nil, fmt.Errorf("vertex: project, region and credentials are required")
+	}
+	if c.config.Model == "" {
+		return nil, fmt.Errorf("vertex: no model configured")
+	}
+
+	detectedLanguage := utils.GetLanguageFromFile(outputFile, language)
+
+	// Build the full prompt
+	fullPrompt := outboundPrompt(ctx, prompt, contextStr, outputFile, detectedLanguage, contextFiles)
+
+	requestData := c.prepareRequest(fullPrompt, prompts.System(ctx, "vertex", detectedLanguage))
+
+	response, err := c.makeAPICall(ctx, requestData)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.UsageMetadata != nil {
+		c.lastUsage = &types.Usage{
+			PromptTokens:     response.UsageMetadata.PromptTokenCount,
+			CompletionTokens: response.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      response.UsageMetadata.TotalTokenCount,
+		}
+		logger.Debugf("Vertex: Extracted token usage - Prompt: %d, Completion: %d, Total: %d",
+			c.lastUsage.PromptTokens, c.lastUsage.CompletionTokens, c.lastUsage.TotalTokens)
+	}
+
+	var text strings.Builder
+	for _, part := range response.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+
+	return &types.CodeGenerationResult{
+		Code:      utils.CleanCodeResponse(text.String()),
+		Usage:     c.lastUsage,
+		Citations: response.Candidates[0].citations(),
+	}, nil
+}
+
+// GetModel returns the Vertex publisher model
+func (c *VertexClient) GetModel() string {
+	return c.config.Model
+}
+
+// prepareRequest prepares the generateContent request payload
+func (c *VertexClient) prepareRequest(fullPrompt, systemPrompt string) VertexRequest {
+	requestData := VertexRequest{
+		Contents: []Content{
+			{
+				Role:  "user",
+				Parts: []Part{{Text: fullPrompt}},
+			},
+		},
+		SystemInstruction: &Content{
+			Role:  "system",
+			Parts: []Part{{Text: systemPrompt}},
+		},
+	}
+	if c.config.MaxTokens > 0 || c.config.Temperature != 0 {
+		requestData.GenerationConfig = &GenerationConfig{
+			Temperature:     c.config.Temperature,
+			MaxOutputTokens: c.config.MaxTokens,
+		}
+	}
+	return requestData
+}
+
+// vertexEndpoint returns the regional (or global) Vertex AI base URL
+func vertexEndpoint(cfg config.VertexConfig) string {
+	if cfg.BaseURL != "" {
+		return strings.TrimSuffix(cfg.BaseURL, "/")
+	}
+	if cfg.Region == "global" {
+		return "https://aiplatform.googleapis.com"
+	}
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com", cfg.Region)
+}
+
+// makeAPICall sends the authenticated generateContent request
+func (c *VertexClient) makeAPICall(ctx context.Context, requestData VertexRequest) (*GenerateContentResponse, error) {
+	token, err := vertexAccessToken(c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBody, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/google/models/%s:generateContent",
+		vertexEndpoint(c.config),
+		url.PathEscape(c.config.ProjectID),
+		url.PathEscape(c.config.Region),
+		url.PathEscape(c.config.Model))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	logger.Debugf("Making Vertex AI API call to %s", endpoint)
+
+	resp, err := doRequest(c.client, req, defaultRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if parseErr := json.Unmarshal(body, &errorResponse); parseErr == nil && errorResponse.Error.Message != "" {
+			return nil, newAPIError("Vertex AI", resp, errorResponse.Error.Message)
+		}
+		return nil, newAPIError("Vertex AI", resp, string(body))
+	}
+
+	var response GenerateContentResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	if len(response.Candidates) == 0 || len(response.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no content in API response")
+	}
+	return &response, nil
+}
+
+// vertexAccessToken returns the configured static access token, or mints one
+// from the service account key file (cached and refreshed automatically)
+func vertexAccessToken(cfg config.VertexConfig) (string, error) {
+	if cfg.AccessToken != "" {
+		return cfg.AccessToken, nil
+	}
+
+	vertexTokenSources.Lock()
+	source, ok := vertexTokenSources.sources[cfg.CredentialsFile]
+	if !ok {
+		jwtConfig, err := loadServiceAccount(cfg.CredentialsFile)
+		if err != nil {
+			vertexTokenSources.Unlock()
+			return "", err
+		}
+		// The token source outlives this request, so it must not inherit its context
+		source = oauth2.ReuseTokenSource(nil, jwtConfig.TokenSource(context.Background()))
+		vertexTokenSources.sources[cfg.CredentialsFile] = source
+	}
+	vertexTokenSources.Unlock()
+
+	token, err := source.Token()
+	if err != nil {
+		return "", fmt.Errorf("vertex: failed to obtain access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// loadServiceAccount parses a service account JSON key into a JWT config
+func loadServiceAccount(path string) (*jwt.Config, error) {
+	data, err := os.ReadFile(config.ExpandPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("vertex: failed to read credentials file: %w", err)
+	}
+
+	var key struct {
+		Type         string `json:"type"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKey   string `json:"private_key"`
+		PrivateKeyID string `json:"private_key_id"`
+		TokenURI     string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("vertex: failed to parse credentials file: %w", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, fmt.Errorf("vertex: %s is not a service account key (type %q)", path, key.Type)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	return &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{vertexScope},
+		TokenURL:     key.TokenURI,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestVertexEndpoint(t *testing.T) {
+	tests := []struct {
+		cfg  config.VertexConfig
+		want string
+	}{
+		{config.VertexConfig{Region: "us-central1"}, "https://us-central1-aiplatform.googleapis.com"},
+		{config.VertexConfig{Region: "global"}, "https://aiplatform.googleapis.com"},
+		{config.VertexConfig{Region: "europe-west4", BaseURL: "https://vertex.internal.example/"}, "https://vertex.internal.example"},
+	}
+
+	for _, tt := range tests {
+		if got := vertexEndpoint(tt.cfg); got != tt.want {
+			t.Errorf("vertexEndpoint(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestLoadServiceAccountRejectsOtherCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adc.json")
+	// gcloud user credentials are not a service account key
+	if err := os.WriteFile(path, []byte(`{"type":"authorized_user","client_id":"x","refresh_token":"y"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadServiceAccount(path); err == nil {
+		t.Error("loadServiceAccount() error = nil, want error for authorized_user credentials")
+	}
+}
@@ -0,0 +1,224 @@
+// Package audit keeps an append-only JSONL record of every file the write
+// tool modifies, for compliance review of AI-authored changes.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// Operations recorded in the audit log
+const (
+	OperationCreate  = "create"
+	OperationUpdate  = "update"
+	OperationRestore = "restore"
+)
+
+// filePrefix and fileSuffix frame the per-day log file names (audit-2006-01-02.jsonl)
+const (
+	filePrefix = "audit-"
+	fileSuffix = ".jsonl"
+)
+
+// Entry is one file modification
+type Entry struct {
+	Time         time.Time   `json:"time"`
+	Operation    string      `json:"operation"`
+	FilePath     string      `json:"file_path"`
+	Provider     string      `json:"provider,omitempty"`
+	Model        string      `json:"model,omitempty"`
+	Cached       bool        `json:"cached,omitempty"`
+	PromptSHA256 string      `json:"prompt_sha256,omitempty"`
+	Checksum     string      `json:"checksum"` // SHA-256 of the content written
+	Diff         DiffSummary `json:"diff"`
+	Caller       string      `json:"caller,omitempty"` // MCP client and IDE
+	User         string      `json:"user,omitempty"`   // OS user running the server
+}
+
+// DiffSummary counts the lines changed by a write
+type DiffSummary struct {
+	LinesAdded   int `json:"lines_added"`
+	LinesRemoved int `json:"lines_removed"`
+	BytesBefore  int `json:"bytes_before"`
+	BytesAfter   int `json:"bytes_after"`
+}
+
+// Log appends entries to one JSONL file per day in its directory
+type Log struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Dir returns the configured audit directory, defaulting to ~/.mcp-code-api/audit
+func Dir(cfg config.AuditConfig) string {
+	if cfg.Dir == "" {
+		return filepath.Join(config.ConfigDir(), "audit")
+	}
+	return config.ExpandPath(cfg.Dir)
+}
+
+// NewLog creates an audit log from configuration. Returns nil if auditing is disabled.
+func NewLog(cfg config.AuditConfig) (*Log, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	dir := Dir(cfg)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	return &Log{dir: dir}, nil
+}
+
+// Record appends an entry. Each entry is written with a single append so
+// concurrent servers sharing the directory do not interleave lines.
+func (l *Log) Record(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	path := filepath.Join(l.dir, filePrefix+entry.Time.Format("2006-01-02")+fileSuffix)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// Filter selects entries when querying the log. Zero fields match everything.
+type Filter struct {
+	Since    time.Time
+	Until    time.Time
+	File     string // Substring of the file path
+	Provider string
+	Limit    int // Most recent entries to return, 0 = all
+}
+
+func (f Filter) matches(entry Entry) bool {
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	if f.File != "" && !strings.Contains(entry.FilePath, f.File) {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(entry.Provider, f.Provider) {
+		return false
+	}
+	return true
+}
+
+// Query reads the audit files in dir and returns matching entries, oldest first
+func Query(dir string, filter Filter) ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var entries []Entry
+	for _, file := range files {
+		// Skip whole days outside the window
+		day, err := time.Parse("2006-01-02", strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), filePrefix), fileSuffix))
+		if err == nil {
+			if !filter.Since.IsZero() && day.Add(24*time.Hour).Before(filter.Since) {
+				continue
+			}
+			if !filter.Until.IsZero() && day.After(filter.Until) {
+				continue
+			}
+		}
+
+		fileEntries, err := readFile(file, filter)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+func readFile(path string, filter Filter) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid audit entry: %w", path, lineNo, err)
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// SummarizeDiff counts added and removed lines between two versions of a
+// file. Lines are matched as a multiset, so moved lines count as unchanged.
+func SummarizeDiff(before, after string) DiffSummary {
+	summary := DiffSummary{BytesBefore: len(before), BytesAfter: len(after)}
+
+	remaining := make(map[string]int)
+	if before != "" {
+		for _, line := range strings.Split(before, "\n") {
+			remaining[line]++
+		}
+	}
+	if after != "" {
+		for _, line := range strings.Split(after, "\n") {
+			if remaining[line] > 0 {
+				remaining[line]--
+			} else {
+				summary.LinesAdded++
+			}
+		}
+	}
+	for _, count := range remaining {
+		summary.LinesRemoved += count
+	}
+	return summary
+}
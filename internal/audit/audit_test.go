@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestRecordAndQuery(t *testing.T) {
+	dir := t.TempDir()
+	log, err := NewLog(config.AuditConfig{Enabled: true, Dir: dir})
+	if err != nil {
+		t.Fatalf("NewLog() error = %v", err)
+	}
+
+	day1 := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+	entries := []Entry{
+		{Time: day1, Operation: OperationCreate, FilePath: "src/main.go", Provider: "cerebras", Model: "qwen-3"},
+		{Time: day2, Operation: OperationUpdate, FilePath: "src/util.go", Provider: "anthropic", Model: "claude"},
+		{Time: day2.Add(time.Minute), Operation: OperationRestore, FilePath: "src/util.go"},
+	}
+	for _, e := range entries {
+		if err := log.Record(e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	all, err := Query(dir, Filter{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("Query() = %d entries, %v; want 3", len(all), err)
+	}
+	if all[0].FilePath != "src/main.go" || all[2].Operation != OperationRestore {
+		t.Errorf("Query() not in chronological order: %+v", all)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"since", Filter{Since: day2}, 2},
+		{"until", Filter{Until: day1}, 1},
+		{"file", Filter{File: "util"}, 2},
+		{"provider", Filter{Provider: "Cerebras"}, 1},
+		{"limit", Filter{Limit: 1}, 1},
+	}
+	for _, tt := range tests {
+		got, err := Query(dir, tt.filter)
+		if err != nil || len(got) != tt.want {
+			t.Errorf("%s: Query() = %d entries, %v; want %d", tt.name, len(got), err, tt.want)
+		}
+	}
+}
+
+func TestNewLogDisabled(t *testing.T) {
+	log, err := NewLog(config.AuditConfig{Dir: t.TempDir()})
+	if log != nil || err != nil {
+		t.Fatalf("NewLog() = %v, %v; want nil, nil", log, err)
+	}
+	if err := log.Record(Entry{FilePath: "x"}); err != nil {
+		t.Errorf("nil Log Record() = %v", err)
+	}
+}
+
+func TestSummarizeDiff(t *testing.T) {
+	got := SummarizeDiff("a\nb\nc", "a\nc\nd\ne")
+	want := DiffSummary{LinesAdded: 2, LinesRemoved: 1, BytesBefore: 5, BytesAfter: 7}
+	if got != want {
+		t.Errorf("SummarizeDiff() = %+v, want %+v", got, want)
+	}
+	if got := SummarizeDiff("", "x\ny"); got.LinesAdded != 2 || got.LinesRemoved != 0 {
+		t.Errorf("SummarizeDiff() for a new file = %+v", got)
+	}
+}
@@ -0,0 +1,472 @@
+// Package bench runs a suite of prompts against provider:model pairs and
+// compares their latency, time to first token and the quality of the
+// generated code: whether it passes validation and tests, its complexity and
+// optionally a judge model's score. Results are kept in a history so model
+// regressions show up between runs.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// Case is one prompt of a suite. File names the file the code is generated
+// for, which sets the language; it is never written.
+type Case struct {
+	Name     string `yaml:"name" json:"name"`
+	File     string `yaml:"file" json:"file"`
+	Prompt   string `yaml:"prompt" json:"prompt"`
+	Validate *bool  `yaml:"validate,omitempty" json:"validate,omitempty"` // Defaults to true
+	Test     *Test  `yaml:"test,omitempty" json:"test,omitempty"`
+}
+
+// Suite is the set of prompts a benchmark runs
+type Suite struct {
+	Cases []Case `yaml:"cases" json:"cases"`
+}
+
+// DefaultSuite is run when no suite file is given: a small task in each of
+// the most used languages
+var DefaultSuite = Suite{Cases: []Case{
+	{
+		Name:   "go-lru-cache",
+		File:   "lru.go",
+		Prompt: "Write a Go package lru with a generic, concurrency-safe LRU cache: New[K comparable, V any](capacity int), Get, Put and Len.",
+	},
+	{
+		Name:   "python-csv-report",
+		File:   "report.py",
+		Prompt: "Write a Python script that reads a CSV file of name,department,salary rows given on the command line and prints the average salary per department, sorted by department.",
+	},
+	{
+		Name:   "typescript-debounce",
+		File:   "debounce.ts",
+		Prompt: "Write a TypeScript module exporting a typed debounce function with cancel and flush methods on the returned function.",
+	},
+}}
+
+// LoadSuite reads a suite from a YAML or JSON file
+func LoadSuite(path string) (Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Suite{}, fmt.Errorf("failed to read suite: %w", err)
+	}
+	var suite Suite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return Suite{}, fmt.Errorf("failed to parse suite %s: %w", path, err)
+	}
+	for i, c := range suite.Cases {
+		if c.File == "" || c.Prompt == "" {
+			return Suite{}, fmt.Errorf("case %d of %s needs a file and a prompt", i+1, path)
+		}
+		if c.Name == "" {
+			suite.Cases[i].Name = c.File
+		}
+		if c.Test != nil && (c.Test.File == "" || c.Test.Content == "") {
+			return Suite{}, fmt.Errorf("test of case %d of %s needs a file and content", i+1, path)
+		}
+	}
+	if len(suite.Cases) == 0 {
+		return Suite{}, fmt.Errorf("suite %s has no cases", path)
+	}
+	return suite, nil
+}
+
+// Targets returns every configured provider:model pair: each enabled
+// provider with its configured model, and the models named for enabled
+// providers in preferred_order, fallback chains, racing and OpenRouter's
+// model list. Racing providers are left out; their models are benchmarked
+// one by one.
+func Targets(cfg *config.Config) []string {
+	p := cfg.Providers
+	steps := slices.Clone(p.Enabled)
+	steps = append(steps, p.Order...)
+	if p.OpenRouter != nil {
+		for _, model := range p.OpenRouter.Models {
+			steps = append(steps, "openrouter:"+model)
+		}
+	}
+	for _, racing := range []*config.RacingConfig{p.Racing, p.RacingClever} {
+		if racing != nil {
+			steps = append(steps, racing.Models...)
+		}
+	}
+
+	var targets []string
+	for _, step := range config.ExpandOrder(steps) {
+		providerName, _ := config.SplitOrderStep(step)
+		if providerName == "racing" || providerName == "racing-clever" || !slices.Contains(p.Enabled, providerName) {
+			continue
+		}
+		targets = append(targets, step)
+	}
+	return targets
+}
+
+// Result is one run of one case against one target
+type Result struct {
+	Target    string  `json:"target"`
+	Case      string  `json:"case"`
+	Run       int     `json:"run"`
+	Model     string  `json:"model,omitempty"` // Model that answered, as reported by the provider
+	TTFTMs    float64 `json:"ttft_ms,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Tokens    int     `json:"output_tokens,omitempty"`
+	Valid     *bool   `json:"valid,omitempty"` // nil when not validated
+	Errors    int     `json:"validation_errors,omitempty"`
+	Error     string  `json:"error,omitempty"`
+
+	TestsPassed *bool    `json:"tests_passed,omitempty"` // nil when the case has no test
+	TestOutput  string   `json:"test_output,omitempty"`  // End of the output of failed tests
+	Complexity  int      `json:"complexity,omitempty"`
+	JudgeScore  float64  `json:"judge_score,omitempty"` // 1-10, 0 when not judged
+	JudgeReason string   `json:"judge_reason,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"` // Tests or judging that couldn't run
+}
+
+// Summary aggregates the results of one target
+type Summary struct {
+	Target          string  `json:"target"`
+	Model           string  `json:"model,omitempty"`
+	Runs            int     `json:"runs"`
+	Succeeded       int     `json:"succeeded"`
+	Validated       int     `json:"validated"`
+	Valid           int     `json:"valid"`
+	PassRate        float64 `json:"validation_pass_rate"` // Valid / Validated
+	TTFTMedianMs    float64 `json:"ttft_median_ms,omitempty"`
+	LatencyMedianMs float64 `json:"latency_median_ms"`
+	LatencyP95Ms    float64 `json:"latency_p95_ms"`
+	TokensPerSecond float64 `json:"output_tokens_per_second,omitempty"`
+	Tested          int     `json:"tested,omitempty"`
+	TestsPassed     int     `json:"tests_passed,omitempty"`
+	TestPassRate    float64 `json:"test_pass_rate,omitempty"` // TestsPassed / Tested
+	ComplexityMean  float64 `json:"complexity_mean,omitempty"`
+	Judged          int     `json:"judged,omitempty"`
+	JudgeScoreMean  float64 `json:"judge_score_mean,omitempty"`
+}
+
+// Report is the outcome of a benchmark
+type Report struct {
+	Suite     string    `json:"suite"` // Digest of the cases, to compare like with like
+	Started   time.Time `json:"started"`
+	Duration  string    `json:"duration"`
+	Cases     []string  `json:"cases"`
+	Runs      int       `json:"runs"`
+	Summaries []Summary `json:"summaries"`
+	Results   []Result  `json:"results"`
+	// Regressions against the previous run of the same suite, see Compare
+	Regressions []string `json:"regressions,omitempty"`
+}
+
+// Options configure a benchmark
+type Options struct {
+	Runs int // Runs of each case per target; at least 1
+	// Judge is the provider or provider:model scoring the code on a rubric;
+	// "" skips judging
+	Judge string
+	// Progress, if set, is called after each run
+	Progress func(Result)
+}
+
+// Run generates every case of suite with each target, one request at a
+// time so targets don't compete for rate limits. Generated code is validated
+// here rather than by the router, so the pass rate is that of the first
+// answer, without validation retries; code that passes is then tested and
+// judged.
+func Run(ctx context.Context, cfg *config.Config, r *router.EnhancedRouter, targets []string, suite Suite, opts Options) *Report {
+	runs := max(opts.Runs, 1)
+	report := &Report{Suite: suite.Digest(), Started: time.Now(), Runs: runs}
+	for _, c := range suite.Cases {
+		report.Cases = append(report.Cases, c.Name)
+	}
+
+	// Each run generates its file in an empty directory, so no earlier
+	// answer is sent as context and tests see only this one
+	dir, err := os.MkdirTemp("", "mcp-bench-*")
+	if err != nil {
+		dir = os.TempDir()
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	for _, target := range targets {
+		providerName, model := config.SplitOrderStep(target)
+		for _, c := range suite.Cases {
+			for run := 1; run <= runs; run++ {
+				if ctx.Err() != nil {
+					report.finish()
+					return report
+				}
+				runDir, err := os.MkdirTemp(dir, "run-*")
+				if err != nil {
+					runDir = dir
+				}
+				result := runCase(ctx, cfg, r, providerName, model, runDir, c, opts.Judge)
+				result.Target, result.Case, result.Run = target, c.Name, run
+				report.Results = append(report.Results, result)
+				if opts.Progress != nil {
+					opts.Progress(result)
+				}
+			}
+		}
+	}
+	report.finish()
+	return report
+}
+
+// runCase generates one case in dir and scores the result
+func runCase(ctx context.Context, cfg *config.Config, r *router.EnhancedRouter, providerName, model, dir string, c Case, judgeTarget string) Result {
+	filePath := filepath.Join(dir, c.File)
+	genCtx, info := router.WithGenerationInfo(ctx)
+	start := time.Now()
+	code, err := r.GenerateCodeWithValidation(genCtx, c.Prompt, filePath, nil, providerName, model, false, nil)
+	result := Result{LatencyMs: milliseconds(time.Since(start))}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Model = info.Model
+	result.TTFTMs = milliseconds(info.TTFT)
+	if info.Usage != nil {
+		result.Tokens = info.Usage.CompletionTokens
+	}
+
+	language := validation.DetectLanguage(filePath)
+	if (c.Validate == nil || *c.Validate) && language != validation.LanguageUnknown {
+		validated, err := language.ValidatorFor(cfg.Validation).Validate(code, filePath)
+		if err != nil {
+			result.Error = fmt.Sprintf("validation error: %v", err)
+			return result
+		}
+		result.Valid = &validated.Valid
+		result.Errors = len(validated.Errors)
+	}
+	result.Complexity = Complexity(filePath, code)
+	if result.Valid != nil && !*result.Valid {
+		return result // Not worth testing or judging
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err == nil {
+		err = os.WriteFile(filePath, []byte(code), 0644)
+	}
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to write %s: %v", c.File, err))
+		return result
+	}
+	if c.Test != nil {
+		passed, output, err := runTest(ctx, dir, c.Test)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("tests not run: %v", err))
+		} else {
+			result.TestsPassed, result.TestOutput = &passed, output
+		}
+	}
+	if judgeTarget != "" {
+		verdict, err := judge(ctx, r, judgeTarget, filePath, c)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("not judged: %v", err))
+		} else {
+			result.JudgeScore, result.JudgeReason = verdict.Score, verdict.Reason
+		}
+	}
+	return result
+}
+
+// finish computes the summaries
+func (r *Report) finish() {
+	r.Duration = time.Since(r.Started).Round(time.Millisecond).String()
+	byTarget := make(map[string][]Result)
+	var order []string
+	for _, result := range r.Results {
+		if _, ok := byTarget[result.Target]; !ok {
+			order = append(order, result.Target)
+		}
+		byTarget[result.Target] = append(byTarget[result.Target], result)
+	}
+
+	r.Summaries = nil
+	for _, target := range order {
+		s := Summary{Target: target}
+		var ttfts, latencies []float64
+		var tokens, complexity, measured int
+		var generating, judgeScores float64
+		for _, result := range byTarget[target] {
+			s.Runs++
+			if result.Error != "" {
+				continue
+			}
+			s.Succeeded++
+			if result.Model != "" {
+				s.Model = result.Model
+			}
+			latencies = append(latencies, result.LatencyMs)
+			if result.TTFTMs > 0 {
+				ttfts = append(ttfts, result.TTFTMs)
+			}
+			if result.Tokens > 0 {
+				tokens += result.Tokens
+				generating += result.LatencyMs - result.TTFTMs
+			}
+			if result.Valid != nil {
+				s.Validated++
+				if *result.Valid {
+					s.Valid++
+				}
+			}
+			if result.TestsPassed != nil {
+				s.Tested++
+				if *result.TestsPassed {
+					s.TestsPassed++
+				}
+			}
+			if result.Complexity > 0 {
+				complexity += result.Complexity
+				measured++
+			}
+			if result.JudgeScore > 0 {
+				s.Judged++
+				judgeScores += result.JudgeScore
+			}
+		}
+		if s.Validated > 0 {
+			s.PassRate = float64(s.Valid) / float64(s.Validated)
+		}
+		if s.Tested > 0 {
+			s.TestPassRate = float64(s.TestsPassed) / float64(s.Tested)
+		}
+		if measured > 0 {
+			s.ComplexityMean = float64(complexity) / float64(measured)
+		}
+		if s.Judged > 0 {
+			s.JudgeScoreMean = judgeScores / float64(s.Judged)
+		}
+		s.TTFTMedianMs = percentile(ttfts, 50)
+		s.LatencyMedianMs = percentile(latencies, 50)
+		s.LatencyP95Ms = percentile(latencies, 95)
+		if generating > 0 {
+			s.TokensPerSecond = float64(tokens) / (generating / 1000)
+		}
+		r.Summaries = append(r.Summaries, s)
+	}
+}
+
+// JSON renders the report as indented JSON
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Markdown renders the summaries as a markdown table, fastest median
+// latency first, followed by the failed runs
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Benchmark %s\n\n", r.Started.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "%d case(s) × %d run(s) per target: %s. Took %s.\n\n", len(r.Cases), r.Runs, strings.Join(r.Cases, ", "), r.Duration)
+
+	summaries := slices.Clone(r.Summaries)
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if (summaries[i].Succeeded == 0) != (summaries[j].Succeeded == 0) {
+			return summaries[j].Succeeded == 0
+		}
+		return summaries[i].LatencyMedianMs < summaries[j].LatencyMedianMs
+	})
+
+	b.WriteString("| Target | Model | Succeeded | Validation pass rate | Test pass rate | Complexity | Judge | TTFT p50 | Latency p50 | Latency p95 | Tokens/s |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, s := range summaries {
+		judgeScore := "-"
+		if s.Judged > 0 {
+			judgeScore = fmt.Sprintf("%.1f/10", s.JudgeScoreMean)
+		}
+		complexity := "-"
+		if s.ComplexityMean > 0 {
+			complexity = fmt.Sprintf("%.1f", s.ComplexityMean)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d/%d | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+			s.Target, orDash(s.Model), s.Succeeded, s.Runs, formatRatio(s.PassRate, s.Valid, s.Validated),
+			formatRatio(s.TestPassRate, s.TestsPassed, s.Tested), complexity, judgeScore,
+			formatMs(s.TTFTMedianMs), formatMs(s.LatencyMedianMs), formatMs(s.LatencyP95Ms), formatRate(s.TokensPerSecond))
+	}
+
+	if len(r.Regressions) > 0 {
+		b.WriteString("\n## Regressions\n\n")
+		for _, regression := range r.Regressions {
+			fmt.Fprintf(&b, "- %s\n", regression)
+		}
+	}
+
+	var failures []Result
+	for _, result := range r.Results {
+		if result.Error != "" {
+			failures = append(failures, result)
+		}
+	}
+	if len(failures) > 0 {
+		b.WriteString("\n## Failures\n\n")
+		for _, f := range failures {
+			fmt.Fprintf(&b, "- %s, %s (run %d): %s\n", f.Target, f.Case, f.Run, strings.ReplaceAll(f.Error, "\n", " "))
+		}
+	}
+	return b.String()
+}
+
+// percentile returns the p-th percentile of values by nearest rank, 0 for
+// none
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func formatMs(ms float64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	if ms >= 1000 {
+		return fmt.Sprintf("%.2fs", ms/1000)
+	}
+	return fmt.Sprintf("%.0fms", ms)
+}
+
+// formatRatio renders a pass rate with its counts, "-" when nothing was
+// checked
+func formatRatio(rate float64, passed, checked int) string {
+	if checked == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%% (%d/%d)", 100*rate, passed, checked)
+}
+
+func formatRate(rate float64) string {
+	if rate <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", rate)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestTargets(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"cerebras", "openrouter", "racing"}
+	cfg.Providers.Order = []string{"cerebras:zai-glm-4.6 -> anthropic"}
+	cfg.Providers.OpenRouter = &config.OpenRouterConfig{Models: []string{"qwen/qwen3-coder"}}
+	cfg.Providers.Racing = &config.RacingConfig{Models: []string{"cerebras:qwen-3-coder-480b", "gemini:gemini-2.5-pro"}}
+
+	want := []string{"cerebras", "openrouter", "cerebras:zai-glm-4.6", "openrouter:qwen/qwen3-coder", "cerebras:qwen-3-coder-480b"}
+	if got := Targets(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("Targets() = %v, want %v", got, want)
+	}
+}
+
+func TestRunReportsValidationPassRate(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"mock"}
+	cfg.Providers.Mock = &config.MockConfig{Fixtures: map[string]string{
+		"go":     "package main\n\nfunc main() {}\n",
+		"python": "def broken(:\n",
+	}}
+	r := router.NewEnhancedRouter(cfg, nil)
+
+	suitePath := filepath.Join(t.TempDir(), "suite.yaml")
+	os.WriteFile(suitePath, []byte(`
+cases:
+  - name: go-main
+    file: main.go
+    prompt: write main
+  - file: broken.py
+    prompt: write a function
+`), 0600)
+	suite, err := LoadSuite(suitePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Run(context.Background(), cfg, r, []string{"mock", "absent"}, suite, Options{Runs: 2})
+	if len(report.Results) != 8 || len(report.Summaries) != 2 {
+		t.Fatalf("got %d results and %d summaries, want 8 and 2", len(report.Results), len(report.Summaries))
+	}
+	mock := report.Summaries[0]
+	if mock.Runs != 4 || mock.Succeeded != 4 || mock.LatencyMedianMs <= 0 {
+		t.Errorf("mock summary = %+v", mock)
+	}
+	// Python is only checked where python3 is installed
+	if mock.Validated > 0 && mock.Valid != 2 {
+		t.Errorf("mock summary = %+v, want the Go runs valid", mock)
+	}
+
+	markdown := report.Markdown()
+	if !strings.Contains(markdown, "| mock | mock | 4/4 |") || !strings.Contains(markdown, "## Failures") {
+		t.Errorf("Markdown() =\n%s", markdown)
+	}
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Summaries[0].Target != "mock" {
+		t.Errorf("JSON() = %s, %v", data, err)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+	if got := percentile(values, 50); got != 3 {
+		t.Errorf("p50 = %v, want 3", got)
+	}
+	if got := percentile(values, 95); got != 5 {
+		t.Errorf("p95 = %v, want 5", got)
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("p50 of nothing = %v, want 0", got)
+	}
+}
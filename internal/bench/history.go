@@ -0,0 +1,128 @@
+package bench
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// How much worse than its previous run a target must do to be reported as
+// a regression
+const (
+	regressionPassRate = 0.15 // Drop in validation or test pass rate
+	regressionJudge    = 1.0  // Drop in mean judge score, out of 10
+	regressionLatency  = 1.5  // Factor by which median latency grew
+)
+
+// HistoryEntry is one target's summary from a past benchmark
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	Suite   string    `json:"suite"`
+	Summary Summary   `json:"summary"`
+}
+
+// DefaultHistoryPath is where benchmark summaries are kept by default
+func DefaultHistoryPath() string {
+	return filepath.Join(config.ConfigDir(), "bench", "history.jsonl")
+}
+
+// Digest identifies the suite's cases, so runs are only compared with runs
+// of the same prompts
+func (s Suite) Digest() string {
+	h := sha256.New()
+	for _, c := range s.Cases {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", c.Name, c.File, c.Prompt)
+		if c.Test != nil {
+			fmt.Fprintf(h, "%s\x00%s\x00", c.Test.File, c.Test.Content)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// LoadHistory reads the entries at path, oldest first. A missing file is an
+// empty history; unreadable lines are skipped.
+func LoadHistory(path string) ([]HistoryEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// AppendHistory adds the report's summaries to the history at path
+func AppendHistory(path string, r *Report) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create benchmark history directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open benchmark history: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, s := range r.Summaries {
+		if err := enc.Encode(HistoryEntry{Time: r.Started, Suite: r.Suite, Summary: s}); err != nil {
+			return fmt.Errorf("failed to write benchmark history: %w", err)
+		}
+	}
+	return nil
+}
+
+// Compare sets the report's regressions: targets doing clearly worse than
+// in their latest earlier run of the same suite, by pass rates, judge score
+// or median latency. Targets that failed every run are left to the failures.
+func (r *Report) Compare(history []HistoryEntry) {
+	previous := make(map[string]Summary)
+	for _, entry := range history {
+		if entry.Suite == r.Suite && entry.Time.Before(r.Started) {
+			previous[entry.Summary.Target] = entry.Summary
+		}
+	}
+
+	r.Regressions = nil
+	for _, s := range r.Summaries {
+		before, ok := previous[s.Target]
+		if !ok || s.Succeeded == 0 {
+			continue
+		}
+		if before.Model != "" && s.Model != "" && before.Model != s.Model {
+			// Another model now answers for the target; say so alongside
+			// any drop
+			r.Regressions = append(r.Regressions, fmt.Sprintf("%s: model changed from %s to %s", s.Target, before.Model, s.Model))
+		}
+		if before.Validated > 0 && s.Validated > 0 && before.PassRate-s.PassRate >= regressionPassRate {
+			r.Regressions = append(r.Regressions, fmt.Sprintf("%s: validation pass rate fell from %.0f%% to %.0f%%", s.Target, 100*before.PassRate, 100*s.PassRate))
+		}
+		if before.Tested > 0 && s.Tested > 0 && before.TestPassRate-s.TestPassRate >= regressionPassRate {
+			r.Regressions = append(r.Regressions, fmt.Sprintf("%s: test pass rate fell from %.0f%% to %.0f%%", s.Target, 100*before.TestPassRate, 100*s.TestPassRate))
+		}
+		if before.Judged > 0 && s.Judged > 0 && before.JudgeScoreMean-s.JudgeScoreMean >= regressionJudge {
+			r.Regressions = append(r.Regressions, fmt.Sprintf("%s: judge score fell from %.1f to %.1f", s.Target, before.JudgeScoreMean, s.JudgeScoreMean))
+		}
+		if before.LatencyMedianMs > 0 && s.LatencyMedianMs >= regressionLatency*before.LatencyMedianMs {
+			r.Regressions = append(r.Regressions, fmt.Sprintf("%s: median latency grew from %s to %s", s.Target, formatMs(before.LatencyMedianMs), formatMs(s.LatencyMedianMs)))
+		}
+	}
+}
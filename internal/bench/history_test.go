@@ -0,0 +1,49 @@
+package bench
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistoryRegressions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	suite := DefaultSuite.Digest()
+
+	earlier := &Report{Suite: suite, Started: time.Now().Add(-time.Hour), Summaries: []Summary{
+		{Target: "cerebras", Model: "zai-glm-4.6", Succeeded: 3, Validated: 3, Valid: 3, PassRate: 1, LatencyMedianMs: 1000},
+		{Target: "anthropic", Succeeded: 3, Judged: 3, JudgeScoreMean: 8, LatencyMedianMs: 2000},
+	}}
+	if err := AppendHistory(path, earlier); err != nil {
+		t.Fatal(err)
+	}
+	other := &Report{Suite: "other", Started: earlier.Started, Summaries: []Summary{{Target: "anthropic", Succeeded: 1, Judged: 1, JudgeScoreMean: 10}}}
+	if err := AppendHistory(path, other); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := LoadHistory(path)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("LoadHistory() = %d entries, %v", len(history), err)
+	}
+
+	current := &Report{Suite: suite, Started: time.Now(), Summaries: []Summary{
+		{Target: "cerebras", Model: "zai-glm-4.7", Succeeded: 3, Validated: 3, Valid: 2, PassRate: 2.0 / 3, LatencyMedianMs: 1200},
+		{Target: "anthropic", Succeeded: 3, Judged: 3, JudgeScoreMean: 7.5, LatencyMedianMs: 3500},
+		{Target: "gemini", Succeeded: 3, LatencyMedianMs: 9000},
+	}}
+	current.Compare(history)
+	want := []string{
+		"cerebras: model changed from zai-glm-4.6 to zai-glm-4.7",
+		"cerebras: validation pass rate fell from 100% to 67%",
+		"anthropic: median latency grew from 2.00s to 3.50s",
+	}
+	if !reflect.DeepEqual(current.Regressions, want) {
+		t.Errorf("Regressions = %q, want %q", current.Regressions, want)
+	}
+
+	if history, err := LoadHistory(filepath.Join(t.TempDir(), "missing.jsonl")); err != nil || history != nil {
+		t.Errorf("LoadHistory(missing) = %v, %v", history, err)
+	}
+}
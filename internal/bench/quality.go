@@ -0,0 +1,207 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
+)
+
+// testTimeout bounds one run of a case's tests
+const testTimeout = 2 * time.Minute
+
+// Test checks generated code by running tests against it. Content is the
+// test file, e.g. written once with the test_generate tool; it is placed next
+// to the generated file before Command runs in their directory.
+type Test struct {
+	File    string   `yaml:"file" json:"file"`
+	Content string   `yaml:"content" json:"content"`
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"` // Defaults by the test file's language
+}
+
+// testCommand returns the command running a test file, by its language
+func testCommand(test *Test) ([]string, error) {
+	if len(test.Command) > 0 {
+		return test.Command, nil
+	}
+	switch validation.DetectLanguage(test.File) {
+	case validation.LanguageGo:
+		return []string{"go", "test", "./..."}, nil
+	case validation.LanguagePython:
+		if validation.GetToolCache().IsAvailable("pytest") {
+			return []string{"pytest", "-q", test.File}, nil
+		}
+		return []string{"python3", test.File}, nil
+	case validation.LanguageJavaScript:
+		return []string{"node", "--test", test.File}, nil
+	}
+	return nil, fmt.Errorf("no default test command for %s; set command", test.File)
+}
+
+// runTest writes the test next to the generated code in dir and runs it. A
+// Go test gets a go.mod so the package builds on its own.
+func runTest(ctx context.Context, dir string, test *Test) (bool, string, error) {
+	command, err := testCommand(test)
+	if err != nil {
+		return false, "", err
+	}
+	if !validation.GetToolCache().IsAvailable(command[0]) {
+		return false, "", fmt.Errorf("%s is not installed", command[0])
+	}
+	path := filepath.Join(dir, test.File)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, "", err
+	}
+	if err := os.WriteFile(path, []byte(test.Content), 0644); err != nil {
+		return false, "", err
+	}
+	if validation.DetectLanguage(test.File) == validation.LanguageGo {
+		goMod := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(goMod); os.IsNotExist(err) {
+			os.WriteFile(goMod, []byte("module bench\n\ngo 1.21\n"), 0644)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return false, fmt.Sprintf("timed out after %v", testTimeout), nil
+	}
+	if err != nil {
+		if _, failed := err.(*exec.ExitError); failed {
+			return false, lastLines(string(output), 20), nil
+		}
+		return false, "", err
+	}
+	return true, "", nil
+}
+
+// lastLines returns the last n lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// branchKeywords are the decision points counted in languages other than Go
+var branchKeywords = regexp.MustCompile(`\b(?:if|elif|for|foreach|while|case|catch|except)\b|&&|\|\||\?\?|\band\b|\bor\b`)
+
+// commentsAndStrings matches comments and string literals, left out when
+// counting decision points
+var commentsAndStrings = regexp.MustCompile("(?s)//[^\n]*|#[^\n]*|/\\*.*?\\*/|\"(?:\\\\.|[^\"\\\\\n])*\"|'(?:\\\\.|[^'\\\\\n])*'|`[^`]*`")
+
+// Complexity returns the cyclomatic complexity of code: for Go that of its
+// most complex function, from the syntax tree; for other languages that of
+// the file as a whole, counted from branch keywords and boolean operators.
+// It returns 0 for Go code that doesn't parse.
+func Complexity(filePath, code string) int {
+	if validation.DetectLanguage(filePath) == validation.LanguageGo {
+		return goComplexity(code)
+	}
+	stripped := commentsAndStrings.ReplaceAllString(code, " ")
+	return len(branchKeywords.FindAllString(stripped, -1)) + 1
+}
+
+// goComplexity returns the highest cyclomatic complexity of the functions
+// in Go code
+func goComplexity(code string) int {
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.SkipObjectResolution)
+	if err != nil {
+		return 0
+	}
+	highest := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		var body *ast.BlockStmt
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		default:
+			return true
+		}
+		if body == nil {
+			return false
+		}
+		complexity := 1
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false // Counted on its own
+			case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+				complexity++
+			case *ast.CaseClause:
+				if n.List != nil {
+					complexity++
+				}
+			case *ast.CommClause:
+				if n.Comm != nil {
+					complexity++
+				}
+			case *ast.BinaryExpr:
+				if n.Op == token.LAND || n.Op == token.LOR {
+					complexity++
+				}
+			}
+			return true
+		})
+		highest = max(highest, complexity)
+		return true
+	})
+	return highest
+}
+
+// judgeVerdict is a judge model's rubric score of generated code
+type judgeVerdict struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// judgePrompt asks for a rubric score of the code in filePath
+func judgePrompt(filePath, task string) string {
+	return fmt.Sprintf(`Grade the code in %s (its content is in the context), written for this task:
+
+%s
+
+Score it from 1 to 10 on this rubric: correctness and completeness for the task (5 points), error and edge case handling (2), readability and idiomatic style (2), efficiency (1). Do not rewrite the code.
+
+Reply with only a JSON object of the form {"score": <1-10>, "reason": "<one sentence>"}.`, filepath.Base(filePath), task)
+}
+
+// judge has the judge target score the code generated for c at filePath
+func judge(ctx context.Context, r *router.EnhancedRouter, judgeTarget, filePath string, c Case) (*judgeVerdict, error) {
+	providerName, model := config.SplitOrderStep(judgeTarget)
+	response, err := r.GenerateCodeWithValidation(ctx, judgePrompt(filePath, c.Prompt), "", []string{filePath}, providerName, model, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON in the judge's response")
+	}
+	var verdict judgeVerdict
+	if err := json.Unmarshal([]byte(response[start:end+1]), &verdict); err != nil {
+		return nil, fmt.Errorf("invalid judge response: %w", err)
+	}
+	if verdict.Score < 1 || verdict.Score > 10 {
+		return nil, fmt.Errorf("judge score %v is outside 1-10", verdict.Score)
+	}
+	return &verdict, nil
+}
@@ -0,0 +1,99 @@
+package bench
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
+)
+
+func TestComplexity(t *testing.T) {
+	goCode := `package p
+
+func simple() {}
+
+func branchy(xs []int, ok bool) int {
+	n := 0
+	for _, x := range xs {
+		if x > 0 && ok {
+			n++
+		}
+		switch x {
+		case 1, 2:
+		case 3:
+		default:
+		}
+	}
+	f := func() { if ok {} } // counted on its own
+	f()
+	return n
+}
+`
+	if got := Complexity("p.go", goCode); got != 6 {
+		t.Errorf("Complexity(go) = %d, want 6", got)
+	}
+	if got := Complexity("p.go", "package p\nfunc {"); got != 0 {
+		t.Errorf("Complexity(invalid go) = %d, want 0", got)
+	}
+
+	pyCode := "def f(x):\n    # if this were a branch\n    if x and x > 1:\n        return 'elif'\n    for i in x:\n        pass\n"
+	if got := Complexity("f.py", pyCode); got != 4 {
+		t.Errorf("Complexity(python) = %d, want 4", got)
+	}
+}
+
+func TestRunScoresTestsAndJudge(t *testing.T) {
+	if !validation.GetToolCache().IsAvailable("go") {
+		t.Skip("go command not available")
+	}
+	judgeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Verdict: {\"score\": 7, \"reason\": \"Correct but terse.\"}"}}]}`))
+	}))
+	defer judgeServer.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"mock", "benchjudge"}
+	cfg.Providers.Mock = &config.MockConfig{Fixtures: map[string]string{
+		"go": "package add\n\nfunc Add(a, b int) int { return a + b }\n",
+	}}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"benchjudge": {BaseURL: judgeServer.URL, APIKey: "key", Model: "judge"}}
+	r := router.NewEnhancedRouter(cfg, nil)
+
+	testFor := func(want int) *Test {
+		return &Test{File: "add_test.go", Content: strings.ReplaceAll(`package add
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(2, 2) != WANT {
+		t.Fatal("wrong sum")
+	}
+}
+`, "WANT", string(rune('0'+want)))}
+	}
+	suite := Suite{Cases: []Case{
+		{Name: "passes", File: "add.go", Prompt: "add two ints", Test: testFor(4)},
+		{Name: "fails", File: "add.go", Prompt: "add two ints", Test: testFor(5)},
+	}}
+
+	report := Run(context.Background(), cfg, r, []string{"mock"}, suite, Options{Judge: "benchjudge"})
+	pass, fail := report.Results[0], report.Results[1]
+	if pass.TestsPassed == nil || !*pass.TestsPassed || fail.TestsPassed == nil || *fail.TestsPassed {
+		t.Fatalf("tests passed = %v, %v (warnings %v), want true, false", pass.TestsPassed, fail.TestsPassed, pass.Warnings)
+	}
+	if !strings.Contains(fail.TestOutput, "wrong sum") {
+		t.Errorf("test output = %q, want the failure", fail.TestOutput)
+	}
+	if pass.JudgeScore != 7 || pass.JudgeReason != "Correct but terse." || pass.Complexity != 1 {
+		t.Errorf("result = %+v, want judge score 7 and complexity 1", pass)
+	}
+	s := report.Summaries[0]
+	if s.Tested != 2 || s.TestsPassed != 1 || s.Judged != 2 || s.JudgeScoreMean != 7 {
+		t.Errorf("summary = %+v", s)
+	}
+}
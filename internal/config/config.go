@@ -5,6 +5,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
@@ -14,11 +15,31 @@ import (
 
 // Config holds all configuration for the MCP server
 type Config struct {
-	Server    ServerConfig    `mapstructure:"server"`
-	Providers ProvidersConfig `mapstructure:"providers"`
-	Auth      AuthConfig      `mapstructure:"auth"`
-	Logging   LoggingConfig   `mapstructure:"logging"`
-	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Server       ServerConfig               `mapstructure:"server"`
+	Providers    ProvidersConfig            `mapstructure:"providers"`
+	Auth         AuthConfig                 `mapstructure:"auth"`
+	Logging      LoggingConfig              `mapstructure:"logging"`
+	Metrics      MetricsConfig              `mapstructure:"metrics"`
+	Redaction    RedactionConfig            `mapstructure:"redaction"`
+	Residency    ResidencyConfig            `mapstructure:"residency"`
+	License      LicenseConfig              `mapstructure:"license"`
+	Cache        CacheConfig                `mapstructure:"cache"`
+	Limits       LimitsConfig               `mapstructure:"limits"`
+	Workspace    WorkspaceConfig            `mapstructure:"workspace"`
+	ContextFiles ContextFilesConfig         `mapstructure:"context_files"`
+	Index        IndexConfig                `mapstructure:"index"`
+	Responses    ResponsesConfig            `mapstructure:"responses"`
+	Provenance   ProvenanceConfig           `mapstructure:"provenance"`
+	Audit        AuditConfig                `mapstructure:"audit"`
+	Templates    TemplatesConfig            `mapstructure:"templates"`
+	Profiles     map[string]LanguageProfile `mapstructure:"profiles"` // Keyed by output language, e.g. "go"
+	Validation   ValidationConfig           `mapstructure:"validation"`
+	Formatting   FormattingConfig           `mapstructure:"formatting"`
+	Proxy        ProxyConfig                `mapstructure:"proxy"`
+	Retry        RetryConfig                `mapstructure:"retry"`
+	Recording    RecordingConfig            `mapstructure:"recording"`
+	Retention    RetentionConfig            `mapstructure:"retention"`
+	Models       []ModelOverride            `mapstructure:"models"` // Corrections to the model capability registry
 }
 
 // ServerConfig holds server-specific configuration
@@ -27,6 +48,18 @@ type ServerConfig struct {
 	Version     string        `mapstructure:"version"`
 	Description string        `mapstructure:"description"`
 	Timeout     time.Duration `mapstructure:"timeout"`
+
+	// FrameRecovery skips corrupted stdio frames (replying with a parse
+	// error) instead of terminating the session
+	FrameRecovery bool `mapstructure:"frame_recovery"`
+
+	// Socket is the unix socket of `server --daemon`, which `attach`
+	// bridges stdio to (default ~/.mcp-code-api/daemon.sock)
+	Socket string `mapstructure:"socket"`
+
+	// NoPersist keeps credentials and state in memory, e.g. in containers
+	// with a read-only or throwaway file system; see NoPersist
+	NoPersist bool `mapstructure:"no_persist"`
 }
 
 // ProvidersConfig holds provider configuration
@@ -34,6 +67,19 @@ type ProvidersConfig struct {
 	Active        string              `mapstructure:"active"`
 	Primary       string              `mapstructure:"primary"`
 	Order         []string            `mapstructure:"preferred_order"`
+	Strategy      string              `mapstructure:"strategy,omitempty"` // "order" (default) or "cost"
+	Pipeline      *PipelineConfig     `mapstructure:"pipeline,omitempty"` // Draft with a fast model, refine with a stronger one
+
+	// Timeout bounds each provider attempt; Timeouts overrides it per
+	// provider name. Unset, clients use their built-in 60s (Gemini 30s,
+	// local servers 300s).
+	Timeout  time.Duration            `mapstructure:"timeout,omitempty"`
+	Timeouts map[string]time.Duration `mapstructure:"timeouts,omitempty"`
+
+	// Hedging sends a slow provider's request to a second provider as well,
+	// keyed by the slow provider's name
+	Hedging map[string]HedgeConfig `mapstructure:"hedging,omitempty"`
+
 	Enabled       []string            `mapstructure:"enabled"`
 	OpenAI        *OpenAIConfig       `mapstructure:"openai"`
 	Anthropic     *AnthropicConfig    `mapstructure:"anthropic"`
@@ -42,12 +88,71 @@ type ProvidersConfig struct {
 	Synthetic     *SyntheticConfig    `mapstructure:"synthetic"`
 	Cerebras      *CerebrasConfig     `mapstructure:"cerebras"`
 	OpenRouter    *OpenRouterConfig   `mapstructure:"openrouter"`
+	AzureOpenAI   *AzureOpenAIConfig  `mapstructure:"azure-openai"`
+	Bedrock       *BedrockConfig      `mapstructure:"bedrock"`
+	Vertex        *VertexConfig       `mapstructure:"vertex"`
+	Mistral       *MistralConfig      `mapstructure:"mistral"`
+	LMStudio      *LocalProviderConfig `mapstructure:"lmstudio"`
+	LlamaCpp      *LocalProviderConfig `mapstructure:"llamacpp"`
+	Mock          *MockConfig         `mapstructure:"mock"`
 	Racing        *RacingConfig       `mapstructure:"racing"`        // Virtual provider for racing
 	RacingClever  *RacingConfig       `mapstructure:"racing-clever"` // Virtual provider for clever racing
 	// Alias providers (built-in)
 	Aliases map[string]ProviderConfig `mapstructure:"aliases"`
-	// Custom providers (user-defined)
-	Custom map[string]ProviderConfig `mapstructure:"custom"`
+	// Custom providers (user-defined), keyed by the name used in
+	// preferred_order/enabled
+	Custom map[string]CustomProviderConfig `mapstructure:"custom"`
+}
+
+// Routing strategies: try providers in preferred_order, or cheapest model first
+const (
+	StrategyOrder = "order"
+	StrategyCost  = "cost"
+)
+
+// chainSeparator joins the steps of a fallback chain in preferred_order
+const chainSeparator = "->"
+
+// ExpandOrder flattens preferred_order into the provider attempts to make,
+// each "provider" or "provider:model". An entry may be a fallback chain of
+// such steps, e.g. "cerebras:zai-glm-4.6 -> openrouter:qwen/qwen3-coder",
+// so that failover stays on a model of equivalent capability instead of the
+// next provider's default. Repeated steps are tried once.
+func ExpandOrder(order []string) []string {
+	seen := make(map[string]bool)
+	var steps []string
+	for _, entry := range order {
+		for _, step := range strings.Split(entry, chainSeparator) {
+			providerName, model := SplitOrderStep(step)
+			if providerName == "" {
+				continue
+			}
+			step = providerName
+			if model != "" {
+				step += ":" + model
+			}
+			if !seen[step] {
+				seen[step] = true
+				steps = append(steps, step)
+			}
+		}
+	}
+	return steps
+}
+
+// SplitOrderStep splits a step of preferred_order into its provider and
+// model; the model is "" when the step names only a provider
+func SplitOrderStep(step string) (providerName, model string) {
+	providerName, model, _ = strings.Cut(strings.TrimSpace(step), ":")
+	return strings.TrimSpace(providerName), strings.TrimSpace(model)
+}
+
+// PipelineConfig has a fast provider draft code and a stronger one refine
+// the draft when it fails validation or changes too much of the file
+type PipelineConfig struct {
+	Draft    string `mapstructure:"draft"`               // Provider that drafts, e.g. cerebras
+	Refine   string `mapstructure:"refine"`              // Provider that refines, e.g. anthropic
+	MaxLines int    `mapstructure:"max_lines,omitempty"` // Refine drafts whose diff touches more lines (0 = only on validation failure)
 }
 
 // ProviderConfig represents configuration for a specific provider
@@ -74,6 +179,21 @@ type ProviderConfig struct {
 	MaxRequestsPerMinute int `json:"max_requests_per_minute,omitempty"`
 }
 
+// Key strategies for providers with several API keys
+const (
+	KeyStrategyRoundRobin = "round-robin"
+	KeyStrategyWeighted   = "weighted"
+	KeyStrategyLRU        = "least-recently-used"
+)
+
+// KeyBalancing selects how a provider spreads requests across its API keys.
+// Keys in failure backoff or over their per-minute limit are always skipped.
+type KeyBalancing struct {
+	KeyStrategy string `mapstructure:"key_strategy,omitempty"`  // round-robin (default), weighted or least-recently-used
+	KeyWeights  []int  `mapstructure:"key_weights,omitempty"`   // Relative share per key for "weighted", in api_keys order (default 1)
+	KeyRPMLimit int    `mapstructure:"key_rpm_limit,omitempty"` // Max requests per key per minute (0 = unlimited)
+}
+
 // OAuthConfig represents OAuth configuration
 type OAuthConfig struct {
 	ClientID     string   `json:"client_id"`
@@ -87,9 +207,12 @@ type OAuthConfig struct {
 // OpenAIConfig holds OpenAI-specific configuration
 type OpenAIConfig struct {
 	APIKey          string   `mapstructure:"api_key"`
-	APIKeys         []string `mapstructure:"api_keys,omitempty"` // Multiple API keys for load balancing
+	APIKeyRef       string   `mapstructure:"api_key_ref,omitempty"` // Credential store reference instead of api_key, e.g. keyring:openai
+	APIKeys         []string `mapstructure:"api_keys,omitempty"`    // Multiple API keys for load balancing
 	BaseURL         string   `mapstructure:"base_url,omitempty"`
 	Model           string   `mapstructure:"model,omitempty"`
+	Models          []string `mapstructure:"models,omitempty"`         // Models to choose from; Model is used if empty
+	ModelStrategy   string   `mapstructure:"model_strategy,omitempty"` // "failover" (default), "round-robin" or "random"
 	UseResponsesAPI bool     `mapstructure:"use_responses_api,omitempty"`
 }
 
@@ -97,10 +220,26 @@ type OpenAIConfig struct {
 type AnthropicConfig struct {
 	DisplayName string   `mapstructure:"display_name,omitempty"` // Optional display name for provider (e.g., "z.ai")
 	APIKey      string   `mapstructure:"api_key"`
-	APIKeys     []string `mapstructure:"api_keys,omitempty"` // Multiple API keys for load balancing
+	APIKeyRef   string   `mapstructure:"api_key_ref,omitempty"` // Credential store reference instead of api_key, e.g. keyring:anthropic
+	APIKeys     []string `mapstructure:"api_keys,omitempty"`    // Multiple API keys for load balancing
 	BaseURL     string   `mapstructure:"base_url,omitempty"`
 	Model       string   `mapstructure:"model,omitempty"`
 
+	Models        []string `mapstructure:"models,omitempty"`         // Models to choose from; Model is used if empty
+	ModelStrategy string   `mapstructure:"model_strategy,omitempty"` // "failover" (default), "round-robin" or "random"
+
+	DisablePromptCache bool `mapstructure:"disable_prompt_cache,omitempty"` // Don't mark large context files for prompt caching
+
+	// Extended thinking: ThinkingBudget tokens (at least 1024; 0 turns it
+	// off) the model may think before answering, within MaxTokens
+	// (default 4096, raised above the budget if needed). LogThinking
+	// writes the thinking to the debug log.
+	MaxTokens      int  `mapstructure:"max_tokens,omitempty"`
+	ThinkingBudget int  `mapstructure:"thinking_budget,omitempty"`
+	LogThinking    bool `mapstructure:"log_thinking,omitempty"`
+
+	KeyBalancing `mapstructure:",squash"`
+
 	// OAuth configuration
 	ClientID     string   `mapstructure:"client_id,omitempty"`
 	ClientSecret string   `mapstructure:"client_secret,omitempty"`
@@ -112,9 +251,15 @@ type AnthropicConfig struct {
 
 // GeminiConfig holds Gemini-specific configuration
 type GeminiConfig struct {
-	APIKey  string `mapstructure:"api_key"`
-	BaseURL string `mapstructure:"base_url,omitempty"`
-	Model   string `mapstructure:"model,omitempty"`
+	APIKey    string `mapstructure:"api_key"`
+	APIKeyRef string `mapstructure:"api_key_ref,omitempty"` // Credential store reference instead of api_key, e.g. keyring:gemini
+	BaseURL   string `mapstructure:"base_url,omitempty"`
+	Model     string `mapstructure:"model,omitempty"`
+
+	Models        []string `mapstructure:"models,omitempty"`         // Models to choose from; Model is used if empty
+	ModelStrategy string   `mapstructure:"model_strategy,omitempty"` // "failover" (default), "round-robin" or "random"
+
+	DisablePromptCache bool `mapstructure:"disable_prompt_cache,omitempty"` // Don't put large context files in cachedContents (API key auth only)
 
 	// OAuth configuration
 	ClientID     string   `mapstructure:"client_id,omitempty"`
@@ -129,16 +274,28 @@ type GeminiConfig struct {
 	RefreshToken string    `mapstructure:"refresh_token,omitempty"`
 	TokenExpiry  time.Time `mapstructure:"token_expiry,omitempty"` // RFC3339 format
 	DisplayName  string    `mapstructure:"display_name,omitempty"`
+	TokenRef     string    `mapstructure:"token_ref,omitempty"` // Credential store reference holding the tokens as JSON, e.g. keyring:gemini-oauth
 
 	// Cloud Code API project ID (free tier users get this from server during onboarding)
 	ProjectID string `mapstructure:"project_id,omitempty"`
 }
 
-// QwenConfig holds Qwen-specific configuration
+// QwenConfig holds Qwen-specific configuration. Requests go to DashScope's
+// OpenAI-compatible API; BaseURL is its root without the version, e.g.
+// https://dashscope-intl.aliyuncs.com/compatible-mode
 type QwenConfig struct {
-	APIKey  string `mapstructure:"api_key"`
-	BaseURL string `mapstructure:"base_url,omitempty"`
-	Model   string `mapstructure:"model,omitempty"`
+	APIKey    string `mapstructure:"api_key"`
+	APIKeyRef string `mapstructure:"api_key_ref,omitempty"` // Credential store reference instead of api_key, e.g. keyring:qwen
+	BaseURL   string `mapstructure:"base_url,omitempty"`
+	Model     string `mapstructure:"model,omitempty"`
+
+	APIKeys       []string `mapstructure:"api_keys,omitempty"`       // Multiple API keys for load balancing
+	Models        []string `mapstructure:"models,omitempty"`         // Models to choose from; Model is used if empty
+	ModelStrategy string   `mapstructure:"model_strategy,omitempty"` // "failover" (default), "round-robin" or "random"
+	MaxTokens     int      `mapstructure:"max_tokens,omitempty"`
+	Temperature   float64  `mapstructure:"temperature,omitempty"`
+
+	KeyBalancing `mapstructure:",squash"`
 
 	// OAuth configuration
 	ClientID     string   `mapstructure:"client_id,omitempty"`
@@ -149,27 +306,45 @@ type QwenConfig struct {
 	AuthURL      string   `mapstructure:"auth_url,omitempty"`
 }
 
-// SyntheticConfig holds Synthetic (Hugging Face) configuration
+// SyntheticConfig holds Synthetic (Hugging Face) configuration. Requests go
+// to the Hugging Face router's OpenAI-compatible API, which serves Hub
+// models such as Qwen/Qwen2.5-Coder-32B-Instruct; the key is an HF token.
 type SyntheticConfig struct {
 	APIKey  string `mapstructure:"api_key"`
 	BaseURL string `mapstructure:"base_url,omitempty"`
 	Model   string `mapstructure:"model,omitempty"`
+
+	APIKeyRef     string   `mapstructure:"api_key_ref,omitempty"`    // Credential store reference instead of api_key, e.g. keyring:synthetic
+	APIKeys       []string `mapstructure:"api_keys,omitempty"`       // Multiple API keys for load balancing
+	Models        []string `mapstructure:"models,omitempty"`         // Models to choose from; Model is used if empty
+	ModelStrategy string   `mapstructure:"model_strategy,omitempty"` // "failover" (default), "round-robin" or "random"
+	MaxTokens     int      `mapstructure:"max_tokens,omitempty"`
+	Temperature   float64  `mapstructure:"temperature,omitempty"`
+
+	KeyBalancing `mapstructure:",squash"`
 }
 
 // CerebrasConfig holds Cerebras API configuration
 type CerebrasConfig struct {
 	DisplayName string   `mapstructure:"display_name,omitempty"` // Optional display name for provider
 	APIKey      string   `mapstructure:"api_key"`
-	APIKeys     []string `mapstructure:"api_keys,omitempty"` // Multiple API keys for load balancing
+	APIKeyRef   string   `mapstructure:"api_key_ref,omitempty"` // Credential store reference instead of api_key, e.g. keyring:cerebras
+	APIKeys     []string `mapstructure:"api_keys,omitempty"`    // Multiple API keys for load balancing
 	Model       string   `mapstructure:"model"`
 	MaxTokens   int      `mapstructure:"max_tokens"`
 	Temperature float64  `mapstructure:"temperature"`
 	BaseURL     string   `mapstructure:"base_url"`
+
+	Models        []string `mapstructure:"models,omitempty"`         // Models to choose from; Model is used if empty
+	ModelStrategy string   `mapstructure:"model_strategy,omitempty"` // "failover" (default), "round-robin" or "random"
+
+	KeyBalancing `mapstructure:",squash"`
 }
 
 // OpenRouterConfig holds OpenRouter API configuration
 type OpenRouterConfig struct {
 	APIKey        string   `mapstructure:"api_key"`
+	APIKeyRef     string   `mapstructure:"api_key_ref,omitempty"`    // Credential store reference instead of api_key, e.g. keyring:openrouter
 	APIKeys       []string `mapstructure:"api_keys,omitempty"`       // Multiple API keys for load balancing
 	Model         string   `mapstructure:"model,omitempty"`          // Single model (fallback if models list empty)
 	Models        []string `mapstructure:"models,omitempty"`         // List of models to use
@@ -178,13 +353,152 @@ type OpenRouterConfig struct {
 	SiteURL       string   `mapstructure:"site_url,omitempty"`
 	SiteName      string   `mapstructure:"site_name,omitempty"`
 	BaseURL       string   `mapstructure:"base_url,omitempty"`
+
+	// RateLimitTTL is how long the credit and rate limits fetched from
+	// /key are reused before generations check them again (default 1m)
+	RateLimitTTL time.Duration `mapstructure:"rate_limit_ttl,omitempty"`
+
+	// ReasoningEffort (minimal, low, medium or high) is how hard reasoning
+	// models think; OpenRouter ignores it for models that don't reason
+	ReasoningEffort string `mapstructure:"reasoning_effort,omitempty"`
+
+	KeyBalancing `mapstructure:",squash"`
+}
+
+// AzureOpenAIConfig holds Azure OpenAI configuration. Azure addresses models by
+// deployment name under a per-resource endpoint and requires an api-version.
+type AzureOpenAIConfig struct {
+	Endpoint    string   `mapstructure:"endpoint"`        // e.g. https://my-resource.openai.azure.com
+	Deployment  string   `mapstructure:"deployment"`      // Deployment name (used in the URL)
+	APIVersion  string   `mapstructure:"api_version"`     // e.g. 2024-10-21
+	Model       string   `mapstructure:"model,omitempty"` // Underlying model, for display/metrics and to recognize reasoning models
+	APIKey      string   `mapstructure:"api_key,omitempty"`
+	APIKeyRef   string   `mapstructure:"api_key_ref,omitempty"` // Credential store reference instead of api_key, e.g. keyring:azure-openai
+	APIKeys     []string `mapstructure:"api_keys,omitempty"`    // Multiple API keys for load balancing
+	MaxTokens   int      `mapstructure:"max_tokens,omitempty"`
+	Temperature float64  `mapstructure:"temperature,omitempty"`
+
+	// ReasoningEffort (minimal, low, medium or high) is sent when Model is
+	// a reasoning model (o-series or gpt-5)
+	ReasoningEffort string `mapstructure:"reasoning_effort,omitempty"`
+
+	KeyBalancing `mapstructure:",squash"`
+
+	// Microsoft Entra ID (AAD) authentication, used when no API key is set.
+	// Either a pre-acquired token or client credentials for a service principal.
+	ADToken      string `mapstructure:"ad_token,omitempty"`
+	TenantID     string `mapstructure:"tenant_id,omitempty"`
+	ClientID     string `mapstructure:"client_id,omitempty"`
+	ClientSecret string `mapstructure:"client_secret,omitempty"`
+	// AuthorityHost is the Entra ID login endpoint for sovereign clouds;
+	// default https://login.microsoftonline.com
+	AuthorityHost string `mapstructure:"authority_host,omitempty"`
+}
+
+// BedrockConfig holds AWS Bedrock configuration. Requests are signed with
+// SigV4 using static or session credentials; the model is a Bedrock model ID.
+type BedrockConfig struct {
+	Region          string  `mapstructure:"region"`               // e.g. us-east-1
+	Model           string  `mapstructure:"model"`                // e.g. anthropic.claude-3-5-sonnet-20240620-v1:0
+	AccessKeyID     string  `mapstructure:"access_key_id,omitempty"`
+	SecretAccessKey string  `mapstructure:"secret_access_key,omitempty"`
+	SessionToken    string  `mapstructure:"session_token,omitempty"` // For temporary (STS) credentials
+	BaseURL         string  `mapstructure:"base_url,omitempty"`      // Override, e.g. a VPC endpoint
+	MaxTokens       int     `mapstructure:"max_tokens,omitempty"`
+	Temperature     float64 `mapstructure:"temperature,omitempty"`
+}
+
+// VertexConfig holds Google Vertex AI configuration. Unlike the Gemini
+// consumer API, Vertex bills a GCP project and authenticates with a service
+// account (or a pre-acquired OAuth access token).
+type VertexConfig struct {
+	ProjectID       string  `mapstructure:"project_id"`
+	Region          string  `mapstructure:"region"`                     // e.g. us-central1, or "global"
+	Model           string  `mapstructure:"model"`                      // Publisher model, e.g. gemini-2.0-flash-001
+	CredentialsFile string  `mapstructure:"credentials_file,omitempty"` // Service account JSON key
+	AccessToken     string  `mapstructure:"access_token,omitempty"`     // e.g. from `gcloud auth print-access-token`
+	BaseURL         string  `mapstructure:"base_url,omitempty"`         // Override, e.g. a Private Service Connect endpoint
+	MaxTokens       int     `mapstructure:"max_tokens,omitempty"`
+	Temperature     float64 `mapstructure:"temperature,omitempty"`
+}
+
+// MistralConfig holds Mistral configuration. Codestral-only keys from
+// codestral.mistral.ai work by pointing BaseURL at that domain.
+type MistralConfig struct {
+	APIKey      string   `mapstructure:"api_key"`
+	APIKeyRef   string   `mapstructure:"api_key_ref,omitempty"` // Credential store reference instead of api_key, e.g. keyring:mistral
+	APIKeys     []string `mapstructure:"api_keys,omitempty"`    // Multiple API keys for load balancing
+	BaseURL     string   `mapstructure:"base_url,omitempty"`
+	Model       string   `mapstructure:"model,omitempty"`     // Chat model for whole-file generation
+	FIM         bool     `mapstructure:"fim,omitempty"`       // Complete at the <FILL> marker via fill-in-the-middle
+	FIMModel    string   `mapstructure:"fim_model,omitempty"` // Model for FIM requests
+	MaxTokens   int      `mapstructure:"max_tokens,omitempty"`
+	Temperature float64  `mapstructure:"temperature,omitempty"`
+
+	KeyBalancing `mapstructure:",squash"`
+}
+
+// LocalProviderConfig holds configuration for a local OpenAI-compatible
+// server (LM Studio, llama.cpp). No credentials are needed; the provider is
+// health-probed before each request so it can sit first in preferred_order
+// with cloud providers as fallback.
+type LocalProviderConfig struct {
+	BaseURL     string  `mapstructure:"base_url"`
+	Model       string  `mapstructure:"model,omitempty"`   // Most local servers serve whatever model is loaded
+	APIKey      string  `mapstructure:"api_key,omitempty"` // Only if the server was started with one
+	MaxTokens   int     `mapstructure:"max_tokens,omitempty"`
+	Temperature float64 `mapstructure:"temperature,omitempty"`
+}
+
+// MockConfig configures the built-in mock provider, which returns
+// deterministic canned code without calling any API, for demos and
+// integration tests of the MCP tooling. Fixtures are Go text/templates with
+// {{.Language}}, {{.FilePath}}, {{.Prompt}} and {{.Summary}} (the prompt's
+// first line).
+type MockConfig struct {
+	Model       string            `mapstructure:"model,omitempty"`        // Model name reported in results
+	Fixtures    map[string]string `mapstructure:"fixtures,omitempty"`     // Code per language, e.g. "go"; "default" for the rest
+	FixturesDir string            `mapstructure:"fixtures_dir,omitempty"` // Files named <language>.<ext>, e.g. go.go or python.py
+	Latency     time.Duration     `mapstructure:"latency,omitempty"`      // Simulated response time
+}
+
+// CustomProviderConfig holds a user-defined provider. Only the
+// "openai-compatible" type (chat completions under base_url) is supported,
+// which covers Together, Fireworks, Novita, DeepInfra, vLLM and similar.
+type CustomProviderConfig struct {
+	Type        string            `mapstructure:"type,omitempty"` // Defaults to "openai-compatible"
+	BaseURL     string            `mapstructure:"base_url"`       // Up to and including the version, e.g. https://api.together.xyz/v1
+	APIKey      string            `mapstructure:"api_key,omitempty"`
+	APIKeyRef   string            `mapstructure:"api_key_ref,omitempty"` // Credential store reference instead of api_key, e.g. keyring:together
+	APIKeys     []string          `mapstructure:"api_keys,omitempty"`    // Multiple API keys for load balancing
+	APIKeyEnv   string            `mapstructure:"api_key_env,omitempty"` // Environment variable holding the key
+	Model       string            `mapstructure:"model"`
+	Headers     map[string]string `mapstructure:"headers,omitempty"` // Extra request headers
+	MaxTokens   int               `mapstructure:"max_tokens,omitempty"`
+	Temperature float64           `mapstructure:"temperature,omitempty"`
+
+	// ReasoningEffort (minimal, low, medium or high) is sent when Model is
+	// a reasoning model (o-series or gpt-5)
+	ReasoningEffort string `mapstructure:"reasoning_effort,omitempty"`
+
+	KeyBalancing `mapstructure:",squash"`
+}
+
+// CustomProviderTypeOpenAICompatible is the only supported custom provider type
+const CustomProviderTypeOpenAICompatible = "openai-compatible"
+
+// HedgeConfig hedges a provider's requests: when no first token has arrived
+// after After, the request also goes to With and the first to complete wins
+type HedgeConfig struct {
+	After time.Duration `mapstructure:"after"`          // 0 disables hedging
+	With  string        `mapstructure:"with,omitempty"` // Provider or provider:model; default: the next one in preferred_order
 }
 
 // RacingConfig holds configuration for racing virtual providers
 type RacingConfig struct {
 	Models          []string `mapstructure:"models"`                     // Provider:model strings (e.g., "openrouter:deepseek/deepseek-chat-v3.1:free")
 	NumRacers       int      `mapstructure:"num_racers,omitempty"`       // How many models to race (0 = race all)
-	GracePeriodMS   int      `mapstructure:"grace_period_ms,omitempty"`  // Milliseconds to wait after first win for performance profiling
+	GracePeriodMS   int      `mapstructure:"grace_period_ms,omitempty"`  // Unused: losers are cancelled as soon as the winner streams its first token
 	SlownessThreshold float64 `mapstructure:"slowness_threshold,omitempty"` // Multiplier for slowness detection (default 2.5)
 	EnableStatePersistence bool `mapstructure:"enable_state_persistence,omitempty"` // Save model performance to disk
 }
@@ -223,9 +537,408 @@ type LoggingConfig struct {
 
 // MetricsConfig holds metrics/monitoring configuration
 type MetricsConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Port    int           `mapstructure:"port"`
+	Host    string        `mapstructure:"host"`
+	Tracing TracingConfig `mapstructure:"tracing"`
+
+	// RequestLog is how many recent write tool calls, with diffs, are kept
+	// in memory for /api/requests (0 disables)
+	RequestLog int `mapstructure:"request_log"`
+
+	// PathPrefix mounts the dashboard, API and probes below a path, e.g.
+	// "/metrics", for serving behind a reverse proxy
+	PathPrefix string `mapstructure:"path_prefix,omitempty"`
+
+	// Auth and TLS protect the dashboard and API when host is not loopback
+	Auth MetricsAuthConfig `mapstructure:"auth"`
+	TLS  TLSConfig         `mapstructure:"tls"`
+}
+
+// MetricsAuthConfig requires credentials for the metrics server: HTTP basic
+// auth with Username and Password, a bearer Token, or both. /healthz and
+// /readyz stay open for container probes.
+type MetricsAuthConfig struct {
+	Username string `mapstructure:"username,omitempty"`
+	Password string `mapstructure:"password,omitempty"`
+	Token    string `mapstructure:"token,omitempty"`
+}
+
+// Enabled reports whether any credentials are configured
+func (c MetricsAuthConfig) Enabled() bool {
+	return c.Token != "" || (c.Username != "" && c.Password != "")
+}
+
+// TLSConfig serves an HTTP server over HTTPS with a PEM certificate and key
+type TLSConfig struct {
+	CertFile string `mapstructure:"cert_file,omitempty"`
+	KeyFile  string `mapstructure:"key_file,omitempty"`
+}
+
+// Enabled reports whether a certificate is configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration. Spans are exported
+// over OTLP/HTTP (JSON) independently of the metrics HTTP server.
+type TracingConfig struct {
+	Enabled        bool              `mapstructure:"enabled"`
+	Endpoint       string            `mapstructure:"endpoint"` // Collector base URL; /v1/traces is appended
+	ServiceName    string            `mapstructure:"service_name"`
+	ServiceVersion string            `mapstructure:"service_version"`   // Defaults to server.version
+	Headers        map[string]string `mapstructure:"headers,omitempty"` // e.g. collector auth
+	SampleRatio    float64           `mapstructure:"sample_ratio"`      // Fraction of traces kept, 0-1
+}
+
+// RedactionConfig holds compliance scrubbing rules applied to prompts and
+// context before they are sent to any provider
+type RedactionConfig struct {
+	Enabled             bool                     `mapstructure:"enabled"`
+	RestorePlaceholders bool                     `mapstructure:"restore_placeholders"` // Replace placeholders in generated code with the original values
+	Emails              bool                     `mapstructure:"emails"`
+	Names               []string                 `mapstructure:"names,omitempty"`      // Dictionary of names to scrub (case-insensitive, whole word)
+	NamesFile           string                   `mapstructure:"names_file,omitempty"` // File with one name per line
+	Hostnames           []string                 `mapstructure:"hostnames,omitempty"`  // Hostname globs, e.g. "*.corp.example.com"
+	Patterns            []RedactionPatternConfig `mapstructure:"patterns,omitempty"`   // Additional named regular expressions
+}
+
+// RedactionPatternConfig is a named regular expression to scrub
+type RedactionPatternConfig struct {
+	Name  string `mapstructure:"name"`
+	Regex string `mapstructure:"regex"`
+}
+
+// ResidencyConfig holds data-residency tags for providers and the path
+// policies the router enforces before sending any file content
+type ResidencyConfig struct {
+	Regions  map[string]string       `mapstructure:"regions"`  // Provider name -> region tag (e.g. "eu", "us")
+	Policies []ResidencyPolicyConfig `mapstructure:"policies"` // Path-scoped region restrictions
+}
+
+// ResidencyPolicyConfig restricts files under Path to providers in AllowedRegions
+type ResidencyPolicyConfig struct {
+	Path           string   `mapstructure:"path"`
+	AllowedRegions []string `mapstructure:"allowed_regions"`
+}
+
+// License policy modes
+const (
+	LicenseModeAnnotate = "annotate" // Findings are reported with the result
+	LicenseModeReject   = "reject"   // The code is sent back to the model, then the next provider is tried
+)
+
+// LicenseConfig keeps code the organization may not reproduce out of
+// generated output: results are compared with a blocklist of sources and
+// checked against the citations providers report
+type LicenseConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Mode    string `mapstructure:"mode"` // "annotate" (default) or "reject"
+	// Blocklist lists files and directories (searched recursively) of code
+	// that must not appear verbatim in generated output
+	Blocklist []string `mapstructure:"blocklist,omitempty"`
+	// MinLines is how many consecutive matching lines count as a verbatim
+	// copy; 0 means DefaultLicenseMinLines
+	MinLines int `mapstructure:"min_lines"`
+	// DeniedLicenses are the licenses (e.g. GPL-3.0) that make a cited source
+	// a finding; empty means every cited source is one
+	DeniedLicenses []string `mapstructure:"denied_licenses,omitempty"`
+}
+
+// DefaultLicenseMinLines is the shortest verbatim copy the license policy flags
+const DefaultLicenseMinLines = 6
+
+// CacheConfig holds response cache configuration
+type CacheConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	TTL        time.Duration `mapstructure:"ttl"`                   // How long cached generations stay valid
+	Dir        string        `mapstructure:"dir,omitempty"`         // Defaults to ~/.mcp-code-api/cache
+	MaxEntries int           `mapstructure:"max_entries,omitempty"` // 0 = unlimited
+	ModelsTTL  time.Duration `mapstructure:"models_ttl,omitempty"`  // How long discovered model lists are reused
+}
+
+// LimitsConfig holds usage budgets enforced by the router (0 = unlimited)
+type LimitsConfig struct {
+	MaxRequestsPerDay int `mapstructure:"max_requests_per_day,omitempty"`
+	MaxTokensPerDay   int `mapstructure:"max_tokens_per_day,omitempty"`
+
+	// MaxConcurrent caps generations in flight in this server (shared by
+	// daemon sessions); further requests wait in arrival order. 0 = no limit.
+	MaxConcurrent int `mapstructure:"max_concurrent,omitempty"`
+
+	// Providers rate-limits requests per provider name
+	Providers map[string]ProviderRateLimit `mapstructure:"providers,omitempty"`
+}
+
+// ProviderRateLimit is a token-bucket limit for one provider. Requests over
+// the rate wait in a queue for up to MaxWait before the router falls back to
+// the next provider.
+type ProviderRateLimit struct {
+	RequestsPerMinute int           `mapstructure:"requests_per_minute"`
+	Burst             int           `mapstructure:"burst,omitempty"`    // Requests allowed back-to-back (default 1)
+	MaxWait           time.Duration `mapstructure:"max_wait,omitempty"` // Longest queueing time (default 30s)
+}
+
+// RetryConfig controls retries of transient provider errors (429, 5xx,
+// timeouts) before the router falls back to the next provider
+type RetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"` // Attempts per provider, including the first (1 = no retries)
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	Multiplier     float64       `mapstructure:"multiplier"`
+	Jitter         float64       `mapstructure:"jitter"`          // Randomizes each backoff by up to this fraction
+	MaxRetryAfter  time.Duration `mapstructure:"max_retry_after"` // Longer Retry-After values fall back at once
+}
+
+// WorkspaceConfig restricts which paths the write tool may touch
+type WorkspaceConfig struct {
+	AllowedPaths []string `mapstructure:"allowed_paths,omitempty"` // Empty = unrestricted
+	DeniedPaths  []string `mapstructure:"denied_paths,omitempty"`  // Glob patterns; defaults to DefaultDeniedPaths
+}
+
+// ContextFilesConfig limits the expansion of directories and glob patterns
+// ("internal/api/**/*.go") in the write tool's context_files
+type ContextFilesConfig struct {
+	MaxFiles    int   `mapstructure:"max_files"`     // Max context files after expansion
+	MaxFileSize int64 `mapstructure:"max_file_size"` // Bytes; larger matched files are skipped
+
+	Guard   ContextGuardConfig   `mapstructure:"guard"`
+	Symbols ContextSymbolsConfig `mapstructure:"symbols"`
+}
+
+// ContextGuardConfig defends against instructions planted in context files.
+// Enabled, context is sent in delimited blocks marked as data and lines that
+// look like prompt injections are reported in the write tool's response.
+type ContextGuardConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`
+	Strip    bool     `mapstructure:"strip"`              // Replace flagged lines with a marker before sending
+	Patterns []string `mapstructure:"patterns,omitempty"` // Extra regular expressions flagged as high risk
+}
+
+// ContextSymbolsConfig cuts Go context files down to the declarations a
+// write to a Go file needs: the types, functions and values its prompt or
+// the edited file name, with function bodies left out
+type ContextSymbolsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	MinSize int  `mapstructure:"min_size"` // Bytes; smaller files are sent whole
+}
+
+// IndexConfig embeds project files into a local vector store so the
+// snippets most relevant to a write prompt can be sent as context with it
+type IndexConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AutoContext adds retrieved snippets to every write; the write tool's
+	// auto_context argument overrides it per call
+	AutoContext bool            `mapstructure:"auto_context"`
+	Dir         string          `mapstructure:"dir,omitempty"` // Defaults to ~/.mcp-code-api/index
+	Embedding   EmbeddingConfig `mapstructure:"embedding"`
+	TopK        int             `mapstructure:"top_k"`       // Snippets per prompt; default 5
+	ChunkLines  int             `mapstructure:"chunk_lines"` // Lines per snippet; default 40
+	MaxFiles    int             `mapstructure:"max_files"`   // Files indexed per project; default 5000
+	// MinScore leaves out snippets less similar to the prompt (cosine, 0-1)
+	MinScore float64 `mapstructure:"min_score"`
+	// Extensions replaces the file extensions indexed, e.g. [".go", ".proto"]
+	Extensions []string `mapstructure:"extensions,omitempty"`
+}
+
+// EmbeddingConfig selects how text is embedded for the index
+type EmbeddingConfig struct {
+	// Provider is "local" (default): hashed identifiers and words, no model
+	// or network needed; or "openai": an OpenAI-compatible /embeddings
+	// endpoint such as OpenAI, Ollama or LM Studio
+	Provider   string `mapstructure:"provider"`
+	BaseURL    string `mapstructure:"base_url,omitempty"`   // Defaults to https://api.openai.com/v1
+	APIKey     string `mapstructure:"api_key,omitempty"`    // Defaults to $OPENAI_API_KEY
+	Model      string `mapstructure:"model,omitempty"`      // Defaults to text-embedding-3-small
+	Dimensions int    `mapstructure:"dimensions,omitempty"` // Local vectors' size; default 512
+	// Trusted lets an embedding API receive project files although
+	// residency policies or redaction are configured, which it bypasses
+	Trusted bool `mapstructure:"trusted"`
+}
+
+// ResponsesConfig bounds how much of a write goes back into the agent's
+// context. A diff left out of a response can still be read as a resource.
+type ResponsesConfig struct {
+	MaxLength              int `mapstructure:"max_length"`                // Bytes of response text; longer text is truncated (0 = no limit)
+	WriteOnlyDiffThreshold int `mapstructure:"write_only_diff_threshold"` // Bytes; longer diffs are left out as with write_only (0 = never)
+}
+
+// ProvenanceConfig controls the generation trailer comment appended to written files
+type ProvenanceConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// AuditConfig controls the append-only log of write-tool file modifications
+type AuditConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Dir     string `mapstructure:"dir,omitempty"` // Defaults to ~/.mcp-code-api/audit
+}
+
+// RetentionConfig bounds the files that grow on long-running installs. The
+// server applies it every Interval; `mcp-code-api clean` applies it on demand.
+// Zero limits keep everything.
+type RetentionConfig struct {
+	Interval     time.Duration   `mapstructure:"interval"`      // How often the server cleans up (default 1h)
+	Audit        RetentionPolicy `mapstructure:"audit"`         // Audit log day files
+	BenchHistory RetentionPolicy `mapstructure:"bench_history"` // Benchmark history entries
+	Metrics      RetentionPolicy `mapstructure:"metrics"`       // Instance metrics files, e.g. of crashed servers
+	Backups      RetentionPolicy `mapstructure:"backups"`       // The write tool's in-memory backups for restore_previous
+	LogMaxSize   int64           `mapstructure:"log_max_size"`  // Bytes; a larger debug log is rotated to <file>.1
+}
+
+// RetentionPolicy removes the oldest data past an age or a total size
+type RetentionPolicy struct {
+	MaxAge  time.Duration `mapstructure:"max_age"`
+	MaxSize int64         `mapstructure:"max_size"` // Bytes
+}
+
+// ValidationConfig controls how generated code is checked before it is written
+type ValidationConfig struct {
+	// GoMode is "syntax" (default: gofmt, the file alone) or "package": also
+	// go build and go vet the file's package in its module, so references to
+	// undeclared symbols are caught
+	GoMode string `mapstructure:"go_mode"`
+
+	// LSP also checks generated code with a language server
+	LSP LSPConfig `mapstructure:"lsp"`
+
+	// Retries is how many times a provider is asked to fix code that failed
+	// validation before it counts as failed; nil means DefaultValidationRetries
+	Retries *int `mapstructure:"retries,omitempty"`
+	// Fallback tries the next provider when a provider's code still fails
+	// validation after its retries; nil means true. Off, the request fails.
+	Fallback *bool `mapstructure:"fallback,omitempty"`
+	// AllowUnvalidated writes the last generated code, with a warning, when
+	// no provider produced code that passes validation
+	AllowUnvalidated bool `mapstructure:"allow_unvalidated"`
+
+	// Security scans generated code for hardcoded secrets, command injection
+	// and insecure TLS before it is written
+	Security SecurityScanConfig `mapstructure:"security"`
+}
+
+// DefaultValidationRetries is the validation retry budget per provider
+const DefaultValidationRetries = 2
+
+// RetryBudget returns how many validation retries a provider gets
+func (v ValidationConfig) RetryBudget() int {
+	if v.Retries == nil || *v.Retries < 0 {
+		return DefaultValidationRetries
+	}
+	return *v.Retries
+}
+
+// FallbackOnFailure reports whether code failing validation moves on to the
+// next provider
+func (v ValidationConfig) FallbackOnFailure() bool {
+	return v.Fallback == nil || *v.Fallback
+}
+
+// Security scan modes
+const (
+	SecurityModeWarn  = "warn"  // Findings are reported with the written code
+	SecurityModeBlock = "block" // Findings fail validation and go back to the model
+)
+
+// SecurityScanConfig checks generated code with built-in rules and, when they
+// are installed, gosec for Go, bandit for Python and semgrep with the
+// configured rulesets
+type SecurityScanConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Mode    string `mapstructure:"mode"` // "warn" (default) or "block"
+	// Semgrep lists rulesets (registry names like p/secrets, or paths) to run
+	// with semgrep on every language; empty skips semgrep
+	Semgrep []string `mapstructure:"semgrep,omitempty"`
+	// Timeout bounds each external scanner; one that takes longer is skipped
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Blocks reports whether findings reject the code
+func (s SecurityScanConfig) Blocks() bool {
+	return s.Enabled && s.Mode == SecurityModeBlock
+}
+
+// Warns reports whether findings are only reported
+func (s SecurityScanConfig) Warns() bool {
+	return s.Enabled && s.Mode != SecurityModeBlock
+}
+
+// LSPConfig checks generated code with a language server: the document is
+// opened in the server in memory and the errors it publishes are fed back to
+// the model. Servers are started on first use, one per project root, and kept
+// running between requests.
+type LSPConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Servers overrides the server command per language; by default go uses
+	// gopls, python pyright, and javascript and typescript
+	// typescript-language-server. An empty command turns the check off for
+	// the language.
+	Servers map[string][]string `mapstructure:"servers,omitempty"`
+	// Timeout bounds the wait for diagnostics; a server that takes longer is
+	// skipped
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// FormattingConfig runs a formatter over generated code before it is
+// validated and written, so it matches project style without another round
+// trip to the model
+type FormattingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Commands overrides the formatter per language (go, python, javascript,
+	// typescript, rust, ...). The code is piped through stdin; {file} in an
+	// argument is replaced by the target path. An empty command turns
+	// formatting off for the language.
+	Commands map[string][]string `mapstructure:"commands,omitempty"`
+}
+
+// LanguageProfile routes requests for one output language: which provider and
+// model to try first, the fallback order, and generation settings
+type LanguageProfile struct {
+	Model       string   `mapstructure:"model,omitempty"`       // provider/model tried first, e.g. cerebras/zai-glm-4.6
+	Providers   []string `mapstructure:"providers,omitempty"`   // Fallback order after Model's provider; defaults to providers.order
+	Temperature *float64 `mapstructure:"temperature,omitempty"` // Applied to every provider that supports it
+	MaxTokens   int      `mapstructure:"max_tokens,omitempty"`  // Applied to every provider that supports it
+}
+
+// TemplatesConfig customizes the system prompt sent to providers and the
+// instructions returned to MCP clients. Prompts are Go text/templates with
+// {{.Language}} and {{.Provider}}; inline values override files in Dir.
+type TemplatesConfig struct {
+	Dir          string            `mapstructure:"dir,omitempty"`          // system.tmpl, system.<provider>.tmpl, language.<language>.tmpl, instructions.tmpl
+	System       string            `mapstructure:"system,omitempty"`       // Shared system prompt
+	Providers    map[string]string `mapstructure:"providers,omitempty"`    // Per-provider system prompt
+	Languages    map[string]string `mapstructure:"languages,omitempty"`    // Instructions appended for a language
+	Instructions string            `mapstructure:"instructions,omitempty"` // MCP initialize instructions (plain text)
+}
+
+// RecordingConfig captures provider responses to disk ("record") or serves
+// them back without calling any provider ("replay"), so tests and CI can run
+// without API keys or network access
+type RecordingConfig struct {
+	Mode string `mapstructure:"mode,omitempty"` // "record" or "replay"; empty = off
+	Dir  string `mapstructure:"dir,omitempty"`  // Defaults to ~/.mcp-code-api/recordings
+}
+
+// ModelOverride sets what the router assumes about a model, taking precedence
+// over the built-in table and the provider's models API. Zero fields keep
+// the discovered or built-in value.
+type ModelOverride struct {
+	ID          string  `mapstructure:"id"`                    // Model ID as configured for the provider
+	Provider    string  `mapstructure:"provider,omitempty"`    // Only for this provider; empty = any
+	MaxContext  int     `mapstructure:"max_context,omitempty"` // Context window in tokens
+	MaxOutput   int     `mapstructure:"max_output,omitempty"`  // Completion tokens
+	InputCost   float64 `mapstructure:"input_cost,omitempty"`  // USD per million prompt tokens
+	OutputCost  float64 `mapstructure:"output_cost,omitempty"` // USD per million completion tokens
+	ToolCalling *bool   `mapstructure:"tool_calling,omitempty"`
+	Streaming   *bool   `mapstructure:"streaming,omitempty"`
+}
+
+// ProxyConfig holds settings for the OpenAI-compatible HTTP proxy
+type ProxyConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
-	Port    int    `mapstructure:"port"`
 	Host    string `mapstructure:"host"`
+	Port    int    `mapstructure:"port"`
+	APIKey  string `mapstructure:"api_key,omitempty"` // Optional bearer token required from clients
 }
 
 // Load loads configuration from environment variables and config files
@@ -235,6 +948,7 @@ func Load() *Config {
 	viper.SetDefault("server.version", "1.0.0")
 	viper.SetDefault("server.description", "MCP Code API - Multi-Provider Code Generation Server")
 	viper.SetDefault("server.timeout", "60s")
+	viper.SetDefault("server.frame_recovery", true)
 
 	// Provider defaults
 	viper.SetDefault("providers.active", "")
@@ -249,6 +963,55 @@ func Load() *Config {
 	viper.SetDefault("metrics.enabled", false)
 	viper.SetDefault("metrics.port", 8080)
 	viper.SetDefault("metrics.host", "localhost")
+	viper.SetDefault("metrics.request_log", 100)
+	viper.SetDefault("metrics.tracing.enabled", false)
+	viper.SetDefault("metrics.tracing.endpoint", "http://localhost:4318")
+	viper.SetDefault("metrics.tracing.service_name", "mcp-code-api")
+	viper.SetDefault("metrics.tracing.sample_ratio", 1.0)
+
+	// Cache defaults
+	viper.SetDefault("cache.enabled", false)
+	viper.SetDefault("cache.ttl", "24h")
+	viper.SetDefault("cache.max_entries", 500)
+	viper.SetDefault("cache.models_ttl", "6h")
+
+	// Redaction defaults
+	viper.SetDefault("proxy.enabled", false)
+	viper.SetDefault("proxy.host", "localhost")
+	viper.SetDefault("proxy.port", 8090)
+	viper.SetDefault("workspace.denied_paths", DefaultDeniedPaths)
+	viper.SetDefault("context_files.max_files", 50)
+	viper.SetDefault("context_files.max_file_size", 256*1024)
+	viper.SetDefault("context_files.guard.enabled", false)
+	viper.SetDefault("context_files.symbols.enabled", false)
+	viper.SetDefault("context_files.symbols.min_size", 4096)
+	viper.SetDefault("responses.max_length", 0)
+	viper.SetDefault("responses.write_only_diff_threshold", 32*1024)
+	viper.SetDefault("provenance.enabled", false)
+	viper.SetDefault("audit.enabled", false)
+	viper.SetDefault("license.enabled", false)
+	viper.SetDefault("index.enabled", false)
+	viper.SetDefault("index.auto_context", true)
+	viper.SetDefault("index.embedding.provider", "local")
+	viper.SetDefault("index.top_k", 5)
+	viper.SetDefault("index.chunk_lines", 40)
+	viper.SetDefault("index.max_files", 5000)
+	viper.SetDefault("license.mode", "annotate")
+	viper.SetDefault("license.min_lines", DefaultLicenseMinLines)
+	viper.SetDefault("retention.interval", "1h")
+	viper.SetDefault("retention.bench_history.max_size", 10*1024*1024)
+	viper.SetDefault("retention.log_max_size", 50*1024*1024)
+	viper.SetDefault("validation.go_mode", "syntax")
+	viper.SetDefault("validation.lsp.enabled", false)
+	viper.SetDefault("validation.lsp.timeout", "15s")
+	viper.SetDefault("validation.allow_unvalidated", false)
+	viper.SetDefault("validation.security.enabled", false)
+	viper.SetDefault("validation.security.mode", "warn")
+	viper.SetDefault("validation.security.timeout", "30s")
+	viper.SetDefault("formatting.enabled", false)
+	viper.SetDefault("redaction.enabled", false)
+	viper.SetDefault("redaction.restore_placeholders", true)
+	viper.SetDefault("redaction.emails", true)
 
 	// OpenAI defaults
 	viper.SetDefault("providers.openai.api_key", "")
@@ -268,7 +1031,7 @@ func Load() *Config {
 
 	// Qwen defaults
 	viper.SetDefault("providers.qwen.api_key", "")
-	viper.SetDefault("providers.qwen.base_url", "https://dashscope.aliyuncs.com/api/v1")
+	viper.SetDefault("providers.qwen.base_url", "https://dashscope.aliyuncs.com/compatible-mode")
 	viper.SetDefault("providers.qwen.model", "qwen-max")
 
 	// Cerebras defaults (legacy support)
@@ -286,15 +1049,53 @@ func Load() *Config {
 	viper.SetDefault("providers.openrouter.model_strategy", "failover") // Default: failover
 	viper.SetDefault("providers.openrouter.free_only", false)
 
+	// Azure OpenAI defaults
+	viper.SetDefault("providers.azure-openai.api_version", "2024-10-21")
+
+	// AWS Bedrock defaults
+	viper.SetDefault("providers.bedrock.region", "us-east-1")
+	viper.SetDefault("providers.bedrock.model", "anthropic.claude-3-5-sonnet-20240620-v1:0")
+
+	// Google Vertex AI defaults
+	viper.SetDefault("providers.vertex.region", "us-central1")
+	viper.SetDefault("providers.vertex.model", "gemini-2.0-flash-001")
+
+	// Mistral defaults
+	viper.SetDefault("providers.mistral.api_key", "")
+	viper.SetDefault("providers.mistral.base_url", "https://api.mistral.ai/v1")
+	viper.SetDefault("providers.mistral.model", "codestral-latest")
+	viper.SetDefault("providers.mistral.fim_model", "codestral-latest")
+	viper.SetDefault("providers.mistral.fim", true)
+
+	// Synthetic (Hugging Face) defaults
+	viper.SetDefault("providers.synthetic.api_key", "")
+	viper.SetDefault("providers.synthetic.base_url", "https://router.huggingface.co/v1")
+	viper.SetDefault("providers.synthetic.model", "Qwen/Qwen2.5-Coder-32B-Instruct")
+
+	// Local server defaults
+	viper.SetDefault("providers.lmstudio.base_url", "http://localhost:1234/v1")
+	viper.SetDefault("providers.lmstudio.model", "local-model")
+	viper.SetDefault("providers.llamacpp.base_url", "http://localhost:8080/v1")
+	viper.SetDefault("providers.llamacpp.model", "local-model")
+
+	// Mock provider defaults
+	viper.SetDefault("providers.mock.model", "mock")
+
+	// Retry defaults
+	viper.SetDefault("retry.max_attempts", 3)
+	viper.SetDefault("retry.initial_backoff", "1s")
+	viper.SetDefault("retry.max_backoff", "15s")
+	viper.SetDefault("retry.multiplier", 2.0)
+	viper.SetDefault("retry.jitter", 0.2)
+	viper.SetDefault("retry.max_retry_after", "30s")
+
 	// Racing defaults
 	viper.SetDefault("providers.racing.num_racers", 0) // 0 = race all models
-	viper.SetDefault("providers.racing.grace_period_ms", 500)
 	viper.SetDefault("providers.racing.slowness_threshold", 2.5)
 	viper.SetDefault("providers.racing.enable_state_persistence", false)
 
 	// Racing-Clever defaults
 	viper.SetDefault("providers.racing-clever.num_racers", 0) // 0 = race all models
-	viper.SetDefault("providers.racing-clever.grace_period_ms", 500)
 	viper.SetDefault("providers.racing-clever.slowness_threshold", 2.5)
 	viper.SetDefault("providers.racing-clever.enable_state_persistence", false)
 
@@ -308,9 +1109,8 @@ func Load() *Config {
 	viper.SetConfigType("yaml")
 
 	// Add config paths (viper doesn't expand $HOME, so do it manually)
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		viper.AddConfigPath(homeDir + "/.mcp-code-api")
+	if GetHomeDir() != "" {
+		viper.AddConfigPath(ConfigDir())
 	}
 	viper.AddConfigPath(".")
 
@@ -346,12 +1146,41 @@ func Load() *Config {
 	bindLegacyEnv("providers.openrouter.site_url", "OPENROUTER_SITE_URL")
 	bindLegacyEnv("providers.openrouter.site_name", "OPENROUTER_SITE_NAME")
 	bindLegacyEnv("providers.openrouter.base_url", "OPENROUTER_BASE_URL")
+	bindLegacyEnv("providers.azure-openai.api_key", "AZURE_OPENAI_API_KEY")
+	bindLegacyEnv("providers.azure-openai.endpoint", "AZURE_OPENAI_ENDPOINT")
+	bindLegacyEnv("providers.azure-openai.deployment", "AZURE_OPENAI_DEPLOYMENT")
+	bindLegacyEnv("providers.azure-openai.api_version", "AZURE_OPENAI_API_VERSION")
+	bindLegacyEnv("providers.azure-openai.ad_token", "AZURE_OPENAI_AD_TOKEN")
+	bindLegacyEnv("providers.bedrock.region", "AWS_DEFAULT_REGION")
+	bindLegacyEnv("providers.bedrock.region", "AWS_REGION") // Takes precedence, as in the AWS SDKs
+	bindLegacyEnv("providers.bedrock.access_key_id", "AWS_ACCESS_KEY_ID")
+	bindLegacyEnv("providers.bedrock.secret_access_key", "AWS_SECRET_ACCESS_KEY")
+	bindLegacyEnv("providers.bedrock.session_token", "AWS_SESSION_TOKEN")
+	bindLegacyEnv("providers.bedrock.model", "BEDROCK_MODEL")
+	bindLegacyEnv("providers.mistral.api_key", "MISTRAL_API_KEY")
+	bindLegacyEnv("providers.mistral.base_url", "MISTRAL_BASE_URL")
+	bindLegacyEnv("providers.synthetic.api_key", "HF_TOKEN")
+	bindLegacyEnv("providers.synthetic.base_url", "SYNTHETIC_BASE_URL")
+	bindLegacyEnv("providers.vertex.project_id", "GOOGLE_CLOUD_PROJECT")
+	bindLegacyEnv("providers.vertex.region", "GOOGLE_CLOUD_LOCATION")
+	bindLegacyEnv("providers.vertex.credentials_file", "GOOGLE_APPLICATION_CREDENTIALS")
+	bindLegacyEnv("providers.vertex.model", "VERTEX_MODEL")
+	bindLegacyEnv("providers.lmstudio.base_url", "LMSTUDIO_BASE_URL")
+	bindLegacyEnv("providers.llamacpp.base_url", "LLAMACPP_BASE_URL")
+	bindLegacyEnv("metrics.tracing.endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT")
+	bindLegacyEnv("metrics.tracing.service_name", "OTEL_SERVICE_NAME")
+	bindLegacyEnv("recording.mode", "MCP_RECORDING_MODE")
+	bindLegacyEnv("recording.dir", "MCP_RECORDING_DIR")
+
+	// MCPCODE_CONFIG_JSON and MCPCODE_SECTION__KEY variables, for running
+	// without a config file
+	applyEnvConfig()
 
 	var cfg Config
 
 	// Configure unmarshal with custom decode hooks for time.Time
 	// Compose with default hooks to preserve standard conversions
-	err = viper.Unmarshal(&cfg, viper.DecodeHook(
+	err := viper.Unmarshal(&cfg, viper.DecodeHook(
 		mapstructure.ComposeDecodeHookFunc(
 			mapstructure.StringToTimeDurationHookFunc(),
 			mapstructure.StringToSliceHookFunc(","),
@@ -371,6 +1200,7 @@ func Load() *Config {
 		return &Config{}
 	}
 
+	resolveCredentialRefs(&cfg)
 	return &cfg
 }
 
@@ -581,6 +1411,104 @@ func (c *CerebrasConfig) GetAllAPIKeys() []string {
 	return nil
 }
 
+// GetAllAPIKeys returns all API keys for Azure OpenAI
+func (c *AzureOpenAIConfig) GetAllAPIKeys() []string {
+	if len(c.APIKeys) > 0 {
+		return c.APIKeys
+	}
+	if c.APIKey != "" {
+		return []string{c.APIKey}
+	}
+	return nil
+}
+
+// UsesAAD reports whether Entra ID authentication is configured
+func (c *AzureOpenAIConfig) UsesAAD() bool {
+	return c.ADToken != "" || (c.TenantID != "" && c.ClientID != "" && c.ClientSecret != "")
+}
+
+// HasCredentials reports whether the provider can authenticate
+func (c *AzureOpenAIConfig) HasCredentials() bool {
+	return c.Endpoint != "" && c.Deployment != "" && (len(c.GetAllAPIKeys()) > 0 || c.UsesAAD())
+}
+
+// HasCredentials reports whether the provider can sign requests
+func (c *BedrockConfig) HasCredentials() bool {
+	return c.Region != "" && c.AccessKeyID != "" && c.SecretAccessKey != ""
+}
+
+// HasCredentials reports whether the provider can authenticate to a project
+func (c *VertexConfig) HasCredentials() bool {
+	return c.ProjectID != "" && c.Region != "" && (c.CredentialsFile != "" || c.AccessToken != "")
+}
+
+// GetAllAPIKeys returns all API keys for a custom provider, reading
+// APIKeyEnv when no key is set inline
+func (c *CustomProviderConfig) GetAllAPIKeys() []string {
+	if len(c.APIKeys) > 0 {
+		return c.APIKeys
+	}
+	if c.APIKey != "" {
+		return []string{c.APIKey}
+	}
+	if c.APIKeyEnv != "" {
+		if key := os.Getenv(c.APIKeyEnv); key != "" {
+			return []string{key}
+		}
+	}
+	return nil
+}
+
+// Validate checks that a custom provider can be instantiated
+func (c *CustomProviderConfig) Validate() error {
+	if c.Type != "" && c.Type != CustomProviderTypeOpenAICompatible {
+		return fmt.Errorf("unsupported type %q (only %q is supported)", c.Type, CustomProviderTypeOpenAICompatible)
+	}
+	if c.BaseURL == "" {
+		return fmt.Errorf("base_url is required")
+	}
+	if c.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	if c.APIKeyEnv != "" && len(c.GetAllAPIKeys()) == 0 {
+		return fmt.Errorf("environment variable %s is not set", c.APIKeyEnv)
+	}
+	return nil
+}
+
+// GetAllAPIKeys returns all API keys for Qwen
+func (c *QwenConfig) GetAllAPIKeys() []string {
+	if len(c.APIKeys) > 0 {
+		return c.APIKeys
+	}
+	if c.APIKey != "" {
+		return []string{c.APIKey}
+	}
+	return nil
+}
+
+// GetAllAPIKeys returns all API keys for Synthetic
+func (c *SyntheticConfig) GetAllAPIKeys() []string {
+	if len(c.APIKeys) > 0 {
+		return c.APIKeys
+	}
+	if c.APIKey != "" {
+		return []string{c.APIKey}
+	}
+	return nil
+}
+
+// GetAllAPIKeys returns all API keys for Mistral
+func (c *MistralConfig) GetAllAPIKeys() []string {
+	if len(c.APIKeys) > 0 {
+		return c.APIKeys
+	}
+	if c.APIKey != "" {
+		return []string{c.APIKey}
+	}
+	return nil
+}
+
 // GetAllAPIKeys returns all API keys for OpenRouter
 func (c *OpenRouterConfig) GetAllAPIKeys() []string {
 	if len(c.APIKeys) > 0 {
@@ -4,8 +4,38 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
+// DefaultDeniedPaths are the files the write tool refuses to write or read
+// as context unless workspace.denied_paths is set: secrets, credentials and
+// the server's own state (including the audit log)
+var DefaultDeniedPaths = []string{
+	".env",
+	".env.*",
+	".git",
+	".netrc",
+	"*.pem",
+	"id_rsa*",
+	"id_ed25519*",
+	"~/.ssh",
+	"~/.aws",
+	"~/.gnupg",
+	"~/.kube",
+	"~/.docker",
+	"~/.config/gcloud",
+	"~/.mcp-code-api",
+}
+
+func init() {
+	// gcloud keeps its credentials under %AppData% on Windows
+	if runtime.GOOS == "windows" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			DefaultDeniedPaths = append(DefaultDeniedPaths, filepath.Join(dir, "gcloud"))
+		}
+	}
+}
+
 // MCP tool usage rules for all IDEs
 type MCPRules struct {
 	Raw      string
@@ -60,6 +90,30 @@ func GetHomeDir() string {
 	return ""
 }
 
+// appDirName names the directory holding the configuration, credentials,
+// caches and logs
+const appDirName = "mcp-code-api"
+
+// ConfigDir returns the directory holding the configuration file and the
+// server's state: ~/.mcp-code-api, or %AppData%\mcp-code-api on Windows
+// unless an existing ~/.mcp-code-api is found there
+func ConfigDir() string {
+	home := GetHomeDir()
+	legacy := filepath.Join(home, "."+appDirName)
+	if runtime.GOOS != "windows" || (home != "" && DirExists(legacy)) {
+		return legacy
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, appDirName)
+	}
+	return legacy
+}
+
+// ConfigFilePath returns the default configuration file path
+func ConfigFilePath() string {
+	return filepath.Join(ConfigDir(), "config.yaml")
+}
+
 // getEnv returns the value of an environment variable
 func getEnv(key string) string {
 	return os.Getenv(key)
@@ -248,3 +302,21 @@ func DirExists(path string) bool {
 	info, err := os.Stat(path)
 	return err == nil && info.IsDir()
 }
+
+// ExpandPath expands a leading ~ to the user's home directory. ~/ and, on
+// Windows, ~\ are followed by a path within it, and ~/.mcp-code-api paths are resolved against ConfigDir so they name the same
+// directory on Windows.
+func ExpandPath(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !(os.PathSeparator == '\\' && strings.HasPrefix(path, `~\`)) {
+		return path
+	}
+	home := GetHomeDir()
+	if home == "" {
+		return path
+	}
+	rest := filepath.FromSlash(strings.TrimLeft(path[1:], `/\`))
+	if first, within, _ := strings.Cut(rest, string(os.PathSeparator)); first == "."+appDirName {
+		return filepath.Join(ConfigDir(), within)
+	}
+	return filepath.Join(home, rest)
+}
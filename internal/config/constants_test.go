@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// setHome points the home directory at a temporary one on every platform
+func setHome(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("AppData", filepath.Join(home, "AppData", "Roaming"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	return home
+}
+
+func TestExpandPath(t *testing.T) {
+	home := setHome(t)
+
+	tests := map[string]string{
+		"~":                      home,
+		"~/src/app":              filepath.Join(home, "src", "app"),
+		"~/.mcp-code-api/tokens": filepath.Join(ConfigDir(), "tokens"),
+		"relative/path":          "relative/path",
+		"~user/file":             "~user/file",
+	}
+	if runtime.GOOS == "windows" {
+		tests[`~\src\app`] = filepath.Join(home, "src", "app")
+	}
+	for path, want := range tests {
+		if got := ExpandPath(path); got != want {
+			t.Errorf("ExpandPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	home := setHome(t)
+
+	legacy := filepath.Join(home, ".mcp-code-api")
+	want := legacy
+	if runtime.GOOS == "windows" {
+		want = filepath.Join(home, "AppData", "Roaming", "mcp-code-api")
+	}
+	if got := ConfigDir(); got != want {
+		t.Errorf("ConfigDir() = %q, want %q", got, want)
+	}
+	if got := ConfigFilePath(); got != filepath.Join(want, "config.yaml") {
+		t.Errorf("ConfigFilePath() = %q", got)
+	}
+
+	// An existing ~/.mcp-code-api keeps being used everywhere
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := ConfigDir(); got != legacy {
+		t.Errorf("ConfigDir() = %q with %s present", got, legacy)
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"github.com/cecil-the-coder/mcp-code-api/internal/keyring"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// resolveCredentialRefs reads the secrets the config keeps in the OS
+// credential store. A key set directly or through the environment wins over
+// its api_key_ref, and a secret that can't be read leaves the provider
+// unconfigured with a warning.
+func resolveCredentialRefs(cfg *Config) {
+	p := &cfg.Providers
+	resolve := func(provider, ref string, apiKey *string) {
+		if ref == "" || *apiKey != "" {
+			return
+		}
+		secret, err := keyring.Resolve(ref)
+		if err != nil {
+			logger.Warnf("%s: failed to read api_key_ref: %v", provider, err)
+			return
+		}
+		*apiKey = secret
+	}
+
+	if p.OpenAI != nil {
+		resolve("openai", p.OpenAI.APIKeyRef, &p.OpenAI.APIKey)
+	}
+	if p.Anthropic != nil {
+		resolve("anthropic", p.Anthropic.APIKeyRef, &p.Anthropic.APIKey)
+	}
+	if p.Gemini != nil {
+		resolve("gemini", p.Gemini.APIKeyRef, &p.Gemini.APIKey)
+		if p.Gemini.TokenRef != "" && p.Gemini.AccessToken == "" {
+			if token, err := keyring.ResolveToken(p.Gemini.TokenRef); err != nil {
+				logger.Warnf("gemini: failed to read token_ref: %v", err)
+			} else {
+				p.Gemini.AccessToken = token.AccessToken
+				p.Gemini.RefreshToken = token.RefreshToken
+				p.Gemini.TokenExpiry = token.Expiry
+			}
+		}
+	}
+	if p.Qwen != nil {
+		resolve("qwen", p.Qwen.APIKeyRef, &p.Qwen.APIKey)
+	}
+	if p.Cerebras != nil {
+		resolve("cerebras", p.Cerebras.APIKeyRef, &p.Cerebras.APIKey)
+	}
+	if p.OpenRouter != nil {
+		resolve("openrouter", p.OpenRouter.APIKeyRef, &p.OpenRouter.APIKey)
+	}
+	if p.AzureOpenAI != nil {
+		resolve("azure-openai", p.AzureOpenAI.APIKeyRef, &p.AzureOpenAI.APIKey)
+	}
+	if p.Mistral != nil {
+		resolve("mistral", p.Mistral.APIKeyRef, &p.Mistral.APIKey)
+	}
+	if p.Synthetic != nil {
+		resolve("synthetic", p.Synthetic.APIKeyRef, &p.Synthetic.APIKey)
+	}
+	for name, custom := range p.Custom {
+		if custom.APIKeyRef != "" && custom.APIKey == "" {
+			resolve(name, custom.APIKeyRef, &custom.APIKey)
+			p.Custom[name] = custom
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/spf13/viper"
+)
+
+// Environment-only configuration, for containers with no config file:
+// MCPCODE_CONFIG_JSON holds a whole configuration as JSON, and variables like
+// MCPCODE_PROVIDERS__CEREBRAS__API_KEY set single keys, a double underscore
+// separating the levels. Single keys take precedence over the JSON, which
+// takes precedence over the config file.
+const (
+	EnvPrefix     = "MCPCODE_"
+	EnvConfigJSON = "MCPCODE_CONFIG_JSON"
+)
+
+// applyEnvConfig merges MCPCODE_CONFIG_JSON and the MCPCODE_ key variables
+// into viper. Invalid JSON and variables naming no configuration key are
+// logged and ignored.
+func applyEnvConfig() {
+	bindNoPersistEnv()
+	if data := os.Getenv(EnvConfigJSON); data != "" {
+		var settings map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &settings); err != nil {
+			logger.Warnf("Ignoring %s: %v", EnvConfigJSON, err)
+		} else if err := viper.MergeConfigMap(settings); err != nil {
+			logger.Warnf("Ignoring %s: %v", EnvConfigJSON, err)
+		}
+	}
+
+	environ := os.Environ()
+	sort.Strings(environ)
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, EnvPrefix) || !strings.Contains(name, "__") {
+			continue
+		}
+		key, leaf, ok := envKey(name)
+		if !ok {
+			logger.Warnf("Ignoring %s: no such configuration key", name)
+			continue
+		}
+		viper.Set(key, envValue(value, leaf))
+	}
+}
+
+// envKey returns the configuration key an MCPCODE_ variable names, found by
+// walking the Config type so that e.g. AZURE_OPENAI matches the azure-openai
+// key, and the type of the value at that key
+func envKey(name string) (string, reflect.Type, bool) {
+	segments := strings.Split(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "__")
+	t := reflect.TypeOf(Config{})
+	keys := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if segment == "" {
+			return "", nil, false
+		}
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			key, field, ok := fieldByKey(t, segment)
+			if !ok {
+				return "", nil, false
+			}
+			keys = append(keys, key)
+			t = field
+		case reflect.Map:
+			// Map keys such as custom provider names are taken as given
+			keys = append(keys, segment)
+			t = t.Elem()
+		default:
+			return "", nil, false
+		}
+	}
+	return strings.Join(keys, "."), t, true
+}
+
+// fieldByKey finds the field of struct type t whose mapstructure key matches
+// segment, with dashes in the key written as underscores. Squashed embedded
+// structs are searched too.
+func fieldByKey(t reflect.Type, segment string) (string, reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("mapstructure")
+		key, options, _ := strings.Cut(tag, ",")
+		if strings.Contains(options, "squash") {
+			if key, fieldType, ok := fieldByKey(field.Type, segment); ok {
+				return key, fieldType, true
+			}
+			continue
+		}
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		if key == segment || strings.ReplaceAll(key, "-", "_") == segment {
+			return key, field.Type, true
+		}
+	}
+	return "", nil, false
+}
+
+// envValue converts a variable's value for a key of type t. Lists of strings
+// are comma separated, as in the legacy variables; structured values (maps,
+// lists of objects) are given as JSON. Scalars are left to the decoder.
+func envValue(value string, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			return value
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+			return decoded
+		}
+	}
+	return value
+}
+
+func init() {
+	// NoPersist may be asked before the configuration is loaded
+	bindNoPersistEnv()
+}
+
+// bindNoPersistEnv lets MCPCODE_NO_PERSIST set server.no_persist
+func bindNoPersistEnv() {
+	_ = viper.BindEnv("server.no_persist", "MCPCODE_SERVER__NO_PERSIST", "MCPCODE_NO_PERSIST")
+}
+
+// NoPersist reports whether credentials and server state are kept in memory
+// instead of being written to disk, as set by --no-persist, MCPCODE_NO_PERSIST
+// or server.no_persist. Refreshed OAuth tokens, onboarded Gemini project IDs,
+// cached model lists and the shared metrics files are then not saved under
+// ConfigDir.
+func NoPersist() bool {
+	return viper.GetBool("server.no_persist")
+}
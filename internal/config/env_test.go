@@ -0,0 +1,69 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoadFromEnvironmentOnly(t *testing.T) {
+	setHome(t)
+	t.Chdir(t.TempDir())
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	t.Setenv(EnvConfigJSON, `{"providers": {"cerebras": {"api_key": "from-json", "model": "zai-glm-4.6"}}, "cache": {"enabled": true}}`)
+	t.Setenv("MCPCODE_PROVIDERS__CEREBRAS__API_KEY", "from-env")
+	t.Setenv("MCPCODE_PROVIDERS__AZURE_OPENAI__ENDPOINT", "https://example.openai.azure.com")
+	t.Setenv("MCPCODE_PROVIDERS__ENABLED", "cerebras,together")
+	t.Setenv("MCPCODE_PROVIDERS__CUSTOM__TOGETHER__BASE_URL", "https://api.together.xyz/v1")
+	t.Setenv("MCPCODE_PROVIDERS__CUSTOM__TOGETHER__HEADERS", `{"x-team": "bench"}`)
+	t.Setenv("MCPCODE_METRICS__PORT", "9090")
+	t.Setenv("MCPCODE_PROVIDERS__NO_SUCH_KEY", "ignored")
+	t.Setenv("MCPCODE_NO_PERSIST", "true")
+
+	cfg := Load()
+	if cfg.Providers.Cerebras == nil || cfg.Providers.Cerebras.APIKey != "from-env" || cfg.Providers.Cerebras.Model != "zai-glm-4.6" {
+		t.Errorf("cerebras = %+v, want the variable's key over the JSON's and the JSON's model", cfg.Providers.Cerebras)
+	}
+	if !cfg.Cache.Enabled {
+		t.Error("cache.enabled from MCPCODE_CONFIG_JSON not applied")
+	}
+	if cfg.Providers.AzureOpenAI == nil || cfg.Providers.AzureOpenAI.Endpoint != "https://example.openai.azure.com" {
+		t.Errorf("azure-openai = %+v", cfg.Providers.AzureOpenAI)
+	}
+	if want := []string{"cerebras", "together"}; !reflect.DeepEqual(cfg.Providers.Enabled, want) {
+		t.Errorf("enabled = %v, want %v", cfg.Providers.Enabled, want)
+	}
+	custom := cfg.Providers.Custom["together"]
+	if custom.BaseURL != "https://api.together.xyz/v1" || custom.Headers["x-team"] != "bench" {
+		t.Errorf("custom together = %+v", custom)
+	}
+	if cfg.Metrics.Port != 9090 {
+		t.Errorf("metrics.port = %d, want 9090", cfg.Metrics.Port)
+	}
+	if !cfg.Server.NoPersist || !NoPersist() {
+		t.Error("MCPCODE_NO_PERSIST not applied")
+	}
+}
+
+func TestEnvKey(t *testing.T) {
+	tests := map[string]string{
+		"MCPCODE_PROVIDERS__CEREBRAS__API_KEY":         "providers.cerebras.api_key",
+		"MCPCODE_PROVIDERS__RACING_CLEVER__NUM_RACERS": "providers.racing-clever.num_racers",
+		"MCPCODE_PROVIDERS__CEREBRAS__API_KEYS":        "providers.cerebras.api_keys",
+		"MCPCODE_SERVER__NO_PERSIST":                   "server.no_persist",
+		"MCPCODE_PROVIDERS__CEREBRAS":                  "providers.cerebras",
+	}
+	for name, want := range tests {
+		if got, _, ok := envKey(name); !ok || got != want {
+			t.Errorf("envKey(%s) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	for _, name := range []string{"MCPCODE_PROVIDERS__CEREBRAS__NOPE", "MCPCODE_SERVER__TIMEOUT__X", "MCPCODE_PROVIDERS____X"} {
+		if got, _, ok := envKey(name); ok {
+			t.Errorf("envKey(%s) = %q, want no key", name, got)
+		}
+	}
+}
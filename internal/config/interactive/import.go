@@ -0,0 +1,341 @@
+package interactive
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/keyring"
+	"gopkg.in/yaml.v3"
+)
+
+// importedCredential is a credential found in another AI CLI's files or in
+// the environment
+type importedCredential struct {
+	Provider  string
+	Source    string
+	APIKey    string
+	OAuth     *oauthTokenData
+	ProjectID string
+}
+
+// describe returns a one-line summary with the secret masked
+func (c importedCredential) describe() string {
+	return fmt.Sprintf("%-10s %s", c.Provider, c.summary())
+}
+
+// summary describes the credential and where it came from, without the
+// provider name
+func (c importedCredential) summary() string {
+	kind := "API key " + maskSecret(c.APIKey)
+	if c.OAuth != nil {
+		kind = "OAuth token"
+		if c.OAuth.ExpiresAt != "" {
+			kind += " (expires " + c.OAuth.ExpiresAt + ")"
+		}
+	}
+	return kind + " from " + c.Source
+}
+
+// credentialEnvVars lists the environment variables checked for each
+// provider, in order of preference
+var credentialEnvVars = []struct {
+	provider string
+	names    []string
+}{
+	{"cerebras", []string{"CEREBRAS_API_KEY"}},
+	{"openrouter", []string{"OPENROUTER_API_KEY"}},
+	{"anthropic", []string{"ANTHROPIC_API_KEY"}},
+	{"gemini", []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"}},
+	{"qwen", []string{"DASHSCOPE_API_KEY", "QWEN_API_KEY"}},
+	{"openai", []string{"OPENAI_API_KEY"}},
+	{"mistral", []string{"MISTRAL_API_KEY"}},
+	{"synthetic", []string{"HF_TOKEN", "HUGGING_FACE_HUB_TOKEN"}},
+}
+
+// detectCredentials looks for credentials left by Claude Code, gemini-cli
+// and qwen-code under home, then for provider API keys in the environment.
+// A provider's OAuth login is preferred over an API key; only the first
+// credential of each kind is returned per provider.
+func detectCredentials(home string, getenv func(string) string) []importedCredential {
+	var found []importedCredential
+	seen := make(map[string]bool)
+	add := func(c importedCredential) {
+		key := c.Provider + "/apikey"
+		if c.OAuth != nil {
+			key = c.Provider + "/oauth"
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		found = append(found, c)
+	}
+
+	// Claude Code keeps its claude.ai login in ~/.claude/.credentials.json
+	// (the macOS build uses the Keychain instead) and an API key in
+	// ~/.claude.json
+	var claudeCreds struct {
+		ClaudeAiOauth struct {
+			AccessToken  string `json:"accessToken"`
+			RefreshToken string `json:"refreshToken"`
+			ExpiresAt    int64  `json:"expiresAt"`
+		} `json:"claudeAiOauth"`
+	}
+	path := filepath.Join(home, ".claude", ".credentials.json")
+	if readJSON(path, &claudeCreds) && claudeCreds.ClaudeAiOauth.AccessToken != "" {
+		add(importedCredential{
+			Provider: "anthropic",
+			Source:   "Claude Code (" + path + ")",
+			OAuth: &oauthTokenData{
+				AccessToken:  claudeCreds.ClaudeAiOauth.AccessToken,
+				RefreshToken: claudeCreds.ClaudeAiOauth.RefreshToken,
+				ExpiresAt:    formatExpiry(claudeCreds.ClaudeAiOauth.ExpiresAt),
+				TokenType:    "Bearer",
+			},
+		})
+	}
+	var claudeConfig struct {
+		PrimaryAPIKey string `json:"primaryApiKey"`
+	}
+	path = filepath.Join(home, ".claude.json")
+	if readJSON(path, &claudeConfig) && claudeConfig.PrimaryAPIKey != "" {
+		add(importedCredential{
+			Provider: "anthropic",
+			Source:   "Claude Code (" + path + ")",
+			APIKey:   claudeConfig.PrimaryAPIKey,
+		})
+	}
+
+	// gemini-cli and qwen-code share the Google-style oauth_creds.json format
+	if c, ok := readCLIOAuth(filepath.Join(home, ".gemini", "oauth_creds.json")); ok {
+		c.Provider = "gemini"
+		c.Source = "gemini-cli (" + filepath.Join(home, ".gemini", "oauth_creds.json") + ")"
+		c.ProjectID = getenv("GOOGLE_CLOUD_PROJECT")
+		add(c)
+	}
+	path = filepath.Join(home, ".gemini", ".env")
+	if env := readDotEnv(path); env != nil {
+		for _, name := range []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"} {
+			if env[name] != "" {
+				add(importedCredential{Provider: "gemini", Source: "gemini-cli (" + path + ")", APIKey: env[name]})
+			}
+		}
+	}
+	if c, ok := readCLIOAuth(filepath.Join(home, ".qwen", "oauth_creds.json")); ok {
+		c.Provider = "qwen"
+		c.Source = "qwen-code (" + filepath.Join(home, ".qwen", "oauth_creds.json") + ")"
+		add(c)
+	}
+
+	for _, vars := range credentialEnvVars {
+		for _, name := range vars.names {
+			if value := strings.TrimSpace(getenv(name)); value != "" {
+				add(importedCredential{Provider: vars.provider, Source: "$" + name, APIKey: value})
+			}
+		}
+	}
+
+	return found
+}
+
+// readCLIOAuth reads an oauth_creds.json written by gemini-cli or qwen-code
+func readCLIOAuth(path string) (importedCredential, bool) {
+	var creds struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		ExpiryDate   int64  `json:"expiry_date"`
+	}
+	if !readJSON(path, &creds) || (creds.AccessToken == "" && creds.RefreshToken == "") {
+		return importedCredential{}, false
+	}
+	if creds.TokenType == "" {
+		creds.TokenType = "Bearer"
+	}
+	return importedCredential{
+		OAuth: &oauthTokenData{
+			AccessToken:  creds.AccessToken,
+			RefreshToken: creds.RefreshToken,
+			ExpiresAt:    formatExpiry(creds.ExpiryDate),
+			TokenType:    creds.TokenType,
+		},
+	}, true
+}
+
+// readJSON decodes path into v, reporting whether it existed and parsed
+func readJSON(path string, v interface{}) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// readDotEnv reads KEY=VALUE lines, or returns nil if path can't be read
+func readDotEnv(path string) map[string]string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		env[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return env
+}
+
+// formatExpiry converts a Unix time in milliseconds to RFC3339
+func formatExpiry(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
+
+// maskSecret shows only the ends of a secret
+func maskSecret(secret string) string {
+	if len(secret) <= 12 {
+		return "***"
+	}
+	return secret[:6] + "..." + secret[len(secret)-4:]
+}
+
+// apply fills the provider's credentials in c; it returns false if the
+// provider already has credentials of that kind
+func (c *collectedConfig) apply(cred importedCredential) bool {
+	if cred.Provider == "gemini" && cred.ProjectID != "" && c.geminiProjectID == "" {
+		c.geminiProjectID = cred.ProjectID
+	}
+	if cred.OAuth != nil {
+		token := c.oauthToken(cred.Provider)
+		if token == nil || *token != nil {
+			return false
+		}
+		*token = cred.OAuth
+		return true
+	}
+	key := c.apiKey(cred.Provider)
+	if key == nil || *key != "" {
+		return false
+	}
+	*key = cred.APIKey
+	return true
+}
+
+// importCredentials is the wizard step offering detected credentials. It
+// returns the providers that were imported.
+func (w *Wizard) importCredentials() map[string]bool {
+	imported := make(map[string]bool)
+
+	found := detectCredentials(config.GetHomeDir(), os.Getenv)
+	if len(found) == 0 {
+		return imported
+	}
+
+	fmt.Println("\n🔎 Found existing credentials:")
+	for i, cred := range found {
+		fmt.Printf("   %d. %s\n", i+1, cred.describe())
+	}
+	fmt.Println()
+
+	answer := strings.ToLower(w.prompt("Import these credentials? (Y/n): ", true))
+	if answer != "" && answer != "y" && answer != "yes" {
+		return imported
+	}
+
+	for _, cred := range found {
+		if w.config.apply(cred) {
+			imported[cred.Provider] = true
+		}
+	}
+	fmt.Printf("✅ Imported credentials for %d provider(s); default models will be used\n", len(imported))
+	return imported
+}
+
+// RunImport detects credentials from other AI CLIs and the environment and
+// adds them to the config file at configPath, creating it if needed.
+// Providers already present in an existing file are left untouched unless
+// overwrite is set. With dryRun, the detected credentials are only listed.
+// With useKeyring, the secrets go to the OS credential store and the file
+// only refers to them.
+func RunImport(configPath string, overwrite, dryRun, useKeyring bool) error {
+	found := detectCredentials(config.GetHomeDir(), os.Getenv)
+	if len(found) == 0 {
+		fmt.Println("No credentials found in ~/.claude, ~/.gemini, ~/.qwen or provider environment variables.")
+		return nil
+	}
+
+	configPath = userConfigPath(configPath)
+
+	existing := make(map[string]bool)
+	_, statErr := os.Stat(configPath)
+	exists := statErr == nil
+	if exists && !overwrite {
+		var current struct {
+			Providers map[string]interface{} `yaml:"providers"`
+		}
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read existing config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &current); err != nil {
+			return fmt.Errorf("failed to parse existing config: %w", err)
+		}
+		for name := range current.Providers {
+			existing[name] = true
+		}
+	}
+
+	if useKeyring && !keyring.Available() {
+		return fmt.Errorf("--keyring: %w", keyring.ErrUnsupported)
+	}
+
+	w := &Wizard{config: &collectedConfig{useKeyring: useKeyring}}
+	imported := 0
+	fmt.Println("🔎 Found credentials:")
+	for _, cred := range found {
+		status := ""
+		switch {
+		case existing[cred.Provider]:
+			status = " (skipped: already configured)"
+		case !w.config.apply(cred):
+			status = " (skipped: already imported)"
+		default:
+			imported++
+		}
+		fmt.Printf("   • %s%s\n", cred.describe(), status)
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run: %s was not changed.\n", configPath)
+		return nil
+	}
+	if imported == 0 {
+		fmt.Println("\nNothing to import; use --overwrite to replace configured providers.")
+		return nil
+	}
+
+	if err := w.writeConfiguration(configPath, exists); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ Imported %d credential(s) into %s\n", imported, configPath)
+	return nil
+}
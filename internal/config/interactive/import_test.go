@@ -0,0 +1,75 @@
+package interactive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectCredentials(t *testing.T) {
+	home := t.TempDir()
+	writeTestFile(t, filepath.Join(home, ".claude", ".credentials.json"),
+		`{"claudeAiOauth":{"accessToken":"sk-ant-oat","refreshToken":"sk-ant-ort","expiresAt":1767225600000}}`)
+	writeTestFile(t, filepath.Join(home, ".gemini", "oauth_creds.json"),
+		`{"access_token":"ya29.token","refresh_token":"1//refresh","token_type":"Bearer","expiry_date":1767225600000}`)
+	writeTestFile(t, filepath.Join(home, ".gemini", ".env"), "# comment\nexport GEMINI_API_KEY=\"AIza-from-dotenv\"\n")
+
+	env := map[string]string{
+		"OPENAI_API_KEY":       "sk-openai",
+		"ANTHROPIC_API_KEY":    "sk-ant-api",
+		"GEMINI_API_KEY":       "AIza-from-env",
+		"GOOGLE_CLOUD_PROJECT": "my-project",
+	}
+	found := detectCredentials(home, func(name string) string { return env[name] })
+
+	type key struct{ provider, source string }
+	got := make(map[key]importedCredential)
+	for _, c := range found {
+		got[key{c.Provider, c.Source}] = c
+	}
+	if len(found) != 5 {
+		t.Fatalf("found %d credentials, want 5: %+v", len(found), found)
+	}
+
+	claude := got[key{"anthropic", "Claude Code (" + filepath.Join(home, ".claude", ".credentials.json") + ")"}]
+	if claude.OAuth == nil || claude.OAuth.RefreshToken != "sk-ant-ort" || claude.OAuth.ExpiresAt != "2026-01-01T00:00:00Z" {
+		t.Errorf("Claude Code OAuth = %+v", claude.OAuth)
+	}
+	gemini := got[key{"gemini", "gemini-cli (" + filepath.Join(home, ".gemini", "oauth_creds.json") + ")"}]
+	if gemini.OAuth == nil || gemini.ProjectID != "my-project" {
+		t.Errorf("gemini-cli credential = %+v", gemini)
+	}
+	// The .env key comes first, so the environment's Gemini key is dropped
+	if c := got[key{"gemini", "gemini-cli (" + filepath.Join(home, ".gemini", ".env") + ")"}]; c.APIKey != "AIza-from-dotenv" {
+		t.Errorf("gemini-cli .env key = %q", c.APIKey)
+	}
+	if c := got[key{"openai", "$OPENAI_API_KEY"}]; c.APIKey != "sk-openai" {
+		t.Errorf("OPENAI_API_KEY = %q", c.APIKey)
+	}
+	if c := got[key{"anthropic", "$ANTHROPIC_API_KEY"}]; c.APIKey != "sk-ant-api" {
+		t.Errorf("ANTHROPIC_API_KEY = %q", c.APIKey)
+	}
+}
+
+func TestApplyKeepsExistingCredentials(t *testing.T) {
+	cfg := &collectedConfig{openaiAPIKey: "typed"}
+	if cfg.apply(importedCredential{Provider: "openai", APIKey: "imported"}) {
+		t.Error("apply() replaced an existing OpenAI key")
+	}
+	if !cfg.apply(importedCredential{Provider: "qwen", OAuth: &oauthTokenData{AccessToken: "t"}}) || cfg.qwenOAuth == nil {
+		t.Error("apply() did not set Qwen OAuth")
+	}
+	if cfg.openaiAPIKey != "typed" {
+		t.Errorf("openaiAPIKey = %q", cfg.openaiAPIKey)
+	}
+}
@@ -0,0 +1,207 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/keyring"
+	"gopkg.in/yaml.v3"
+)
+
+// credentialKeys are the provider settings login replaces and logout removes
+var credentialKeys = []string{
+	"api_key", "api_key_ref", "oauth",
+	"access_token", "refresh_token", "token_expiry", "token_ref",
+	"client_id", "client_secret",
+}
+
+// userConfigPath returns configPath with ~ expanded, defaulting to the user
+// config file
+func userConfigPath(configPath string) string {
+	if configPath == "" {
+		configPath = config.ConfigFilePath()
+	}
+	return config.ExpandPath(configPath)
+}
+
+// findProvider returns the wizard's choice for name, or nil
+func findProvider(name string) *providerChoice {
+	for i := range providerChoices {
+		if providerChoices[i].name == name {
+			return &providerChoices[i]
+		}
+	}
+	return nil
+}
+
+// RunLogin signs in to provider with its OAuth flow, or with an API key if
+// useAPIKey is set or the provider has no OAuth, and saves the credentials
+// to the config file at configPath. Only the provider's credentials change;
+// its other settings are kept. With useKeyring, the secrets go to the OS
+// credential store and the file only refers to them.
+func RunLogin(provider, configPath string, useAPIKey, useKeyring bool) error {
+	choice := findProvider(provider)
+	if choice == nil {
+		var names []string
+		for _, c := range providerChoices {
+			names = append(names, c.name)
+		}
+		return fmt.Errorf("unknown provider %q (choose from %s)", provider, strings.Join(names, ", "))
+	}
+	if useKeyring && !keyring.Available() {
+		return fmt.Errorf("--keyring: %w", keyring.ErrUnsupported)
+	}
+
+	w := NewWizard()
+	w.config.useKeyring = useKeyring
+	if choice.oauth && !useAPIKey {
+		if err := w.loginOAuth(choice); err != nil {
+			return fmt.Errorf("%s login failed: %w", choice.title, err)
+		}
+	} else {
+		fmt.Printf("Get your %s API key at: %s\n", choice.title, choice.keyURL)
+		*w.config.apiKey(provider) = w.prompt(fmt.Sprintf("Enter your %s API key: ", choice.title), false)
+	}
+
+	configPath = userConfigPath(configPath)
+	if err := w.writeCredentials(configPath, provider); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Logged in to %s; credentials saved to %s\n", choice.title, configPath)
+	return nil
+}
+
+// writeCredentials saves provider's collected credentials to configPath. An
+// existing provider section only has its credential settings replaced; a new
+// file or provider gets the section the wizard would write.
+func (w *Wizard) writeCredentials(configPath, provider string) error {
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return w.writeConfiguration(configPath, false)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read existing config: %w", err)
+	}
+	var existing map[string]interface{}
+	if err := yaml.Unmarshal(data, &existing); err != nil {
+		return fmt.Errorf("failed to parse existing config: %w", err)
+	}
+	section := providerSection(existing, provider)
+	if section == nil {
+		return w.writeConfiguration(configPath, true)
+	}
+
+	// Let the wizard's merge build the new section, then keep the existing
+	// one with just its credentials swapped in
+	w.prepareSecrets()
+	merged, err := w.mergeWithExistingConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to merge with existing config: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal([]byte(merged), &doc); err != nil {
+		return fmt.Errorf("failed to parse merged config: %w", err)
+	}
+	fresh := providerSection(doc, provider)
+	for _, key := range credentialKeys {
+		delete(section, key)
+		if value, ok := fresh[key]; ok {
+			section[key] = value
+		}
+	}
+	if projectID, ok := fresh["project_id"]; ok {
+		section["project_id"] = projectID
+	}
+	doc["providers"].(map[string]interface{})[provider] = section
+
+	return writeYAMLFile(configPath, doc)
+}
+
+// RunLogout removes provider's credentials from the config file at
+// configPath, along with the credential store entries they refer to
+func RunLogout(provider, configPath string) error {
+	configPath = userConfigPath(configPath)
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	section := providerSection(doc, provider)
+	removed := 0
+	if section != nil {
+		refs := []interface{}{section["api_key_ref"], section["token_ref"]}
+		if oauth, ok := section["oauth"].(map[string]interface{}); ok {
+			refs = append(refs, oauth["token_ref"])
+		}
+		for _, ref := range refs {
+			deleteStoredSecret(ref)
+		}
+		for _, key := range credentialKeys {
+			if _, ok := section[key]; ok {
+				delete(section, key)
+				removed++
+			}
+		}
+	}
+
+	if removed == 0 {
+		fmt.Printf("%s has no credentials in %s\n", provider, configPath)
+	} else {
+		if err := writeYAMLFile(configPath, doc); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Logged out of %s; credentials removed from %s\n", provider, configPath)
+	}
+
+	for _, vars := range credentialEnvVars {
+		if vars.provider != provider {
+			continue
+		}
+		for _, name := range vars.names {
+			if os.Getenv(name) != "" {
+				fmt.Printf("⚠️  $%s is still set and will be used for %s\n", name, provider)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteStoredSecret removes the credential store entry ref points to, if
+// ref is a keyring reference
+func deleteStoredSecret(ref interface{}) {
+	value, _ := ref.(string)
+	if !keyring.IsRef(value) {
+		return
+	}
+	account, err := keyring.Account(value)
+	if err != nil {
+		return
+	}
+	if err := keyring.Delete(account); err != nil && err != keyring.ErrNotFound {
+		fmt.Printf("⚠️  Could not remove %s from %s: %v\n", value, keyring.Name(), err)
+	}
+}
+
+// providerSection returns the providers.<provider> map in doc, or nil
+func providerSection(doc map[string]interface{}, provider string) map[string]interface{} {
+	providers, _ := doc["providers"].(map[string]interface{})
+	section, _ := providers[provider].(map[string]interface{})
+	return section
+}
+
+func writeYAMLFile(path string, doc map[string]interface{}) error {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
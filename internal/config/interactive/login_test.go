@@ -0,0 +1,39 @@
+package interactive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoginReplacesOnlyCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	existing := "providers:\n  gemini:\n    access_token: old-token\n    refresh_token: old-refresh\n    model: gemini-2.5-pro\n    project_id: my-project\n  enabled: [gemini]\n"
+	if err := os.WriteFile(path, []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Wizard{config: &collectedConfig{geminiAPIKey: "AIza-new-key"}}
+	if err := w.writeCredentials(path, "gemini"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	for _, want := range []string{"api_key: AIza-new-key", "model: gemini-2.5-pro", "project_id: my-project"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("config is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "old-token") || strings.Contains(got, "old-refresh") {
+		t.Errorf("old OAuth tokens were kept:\n%s", got)
+	}
+
+	if err := RunLogout("gemini", path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(path)
+	if strings.Contains(string(data), "api_key") || !strings.Contains(string(data), "model: gemini-2.5-pro") {
+		t.Errorf("logout left:\n%s", data)
+	}
+}
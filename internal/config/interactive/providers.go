@@ -0,0 +1,122 @@
+package interactive
+
+import (
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// providerChoice describes a provider the wizard can configure
+type providerChoice struct {
+	name         string
+	title        string
+	description  string
+	keyURL       string
+	defaultModel string
+	oauth        bool
+}
+
+// providerChoices lists the wizard's providers in menu order
+var providerChoices = []providerChoice{
+	{"cerebras", "Cerebras", "Fast inference with ZhipuAI GLM and other models", "https://cloud.cerebras.ai", "zai-glm-4.6", false},
+	{"openrouter", "OpenRouter", "Access to multiple models with fallback support", "https://openrouter.ai/keys", "qwen/qwen3-coder", false},
+	{"anthropic", "Anthropic Claude", "Advanced reasoning with API key or OAuth", "https://console.anthropic.com/settings/keys", "claude-3-5-sonnet-20241022", true},
+	{"gemini", "Google Gemini", "Multimodal AI with API key or OAuth", "https://makersuite.google.com/app/apikey", "gemini-2.0-flash-exp", true},
+	{"qwen", "Alibaba Qwen", "Chinese language models with API key or OAuth", "https://dashscope.console.aliyun.com/", "qwen-max", true},
+	{"openai", "OpenAI", "GPT models with API key", "https://platform.openai.com/api-keys", "gpt-4o", false},
+	{"mistral", "Mistral", "Codestral code models with fill-in-the-middle", "https://console.mistral.ai/api-keys", "codestral-latest", false},
+	{"synthetic", "Synthetic (Hugging Face)", "Open models on Hugging Face Inference Providers", "https://huggingface.co/settings/tokens", "Qwen/Qwen2.5-Coder-32B-Instruct", false},
+}
+
+// apiKey returns the field holding provider's API key, or nil
+func (c *collectedConfig) apiKey(provider string) *string {
+	switch provider {
+	case "cerebras":
+		return &c.cerebrasAPIKey
+	case "openrouter":
+		return &c.openrouterAPIKey
+	case "anthropic":
+		return &c.anthropicAPIKey
+	case "gemini":
+		return &c.geminiAPIKey
+	case "qwen":
+		return &c.qwenAPIKey
+	case "openai":
+		return &c.openaiAPIKey
+	case "mistral":
+		return &c.mistralAPIKey
+	case "synthetic":
+		return &c.syntheticAPIKey
+	}
+	return nil
+}
+
+// oauthToken returns the field holding provider's OAuth login, or nil for
+// providers without OAuth
+func (c *collectedConfig) oauthToken(provider string) **oauthTokenData {
+	switch provider {
+	case "anthropic":
+		return &c.anthropicOAuth
+	case "gemini":
+		return &c.geminiOAuth
+	case "qwen":
+		return &c.qwenOAuth
+	}
+	return nil
+}
+
+// models returns the field holding provider's models, or nil
+func (c *collectedConfig) models(provider string) *[]string {
+	switch provider {
+	case "cerebras":
+		return &c.cerebrasModels
+	case "openrouter":
+		return &c.openrouterModels
+	case "anthropic":
+		return &c.anthropicModels
+	case "gemini":
+		return &c.geminiModels
+	case "qwen":
+		return &c.qwenModels
+	case "openai":
+		return &c.openaiModels
+	case "mistral":
+		return &c.mistralModels
+	case "synthetic":
+		return &c.syntheticModels
+	}
+	return nil
+}
+
+// hasCredentials reports whether provider has an API key or OAuth login
+func (c *collectedConfig) hasCredentials(provider string) bool {
+	if key := c.apiKey(provider); key != nil && *key != "" {
+		return true
+	}
+	token := c.oauthToken(provider)
+	return token != nil && *token != nil
+}
+
+// listModelsConfig returns a configuration with just provider's API key,
+// for querying its models endpoint
+func (c *collectedConfig) listModelsConfig(provider string) *config.Config {
+	cfg := &config.Config{}
+	p := &cfg.Providers
+	switch provider {
+	case "cerebras":
+		p.Cerebras = &config.CerebrasConfig{APIKey: c.cerebrasAPIKey, BaseURL: "https://api.cerebras.ai"}
+	case "openrouter":
+		p.OpenRouter = &config.OpenRouterConfig{APIKey: c.openrouterAPIKey, BaseURL: "https://openrouter.ai/api"}
+	case "anthropic":
+		p.Anthropic = &config.AnthropicConfig{APIKey: c.anthropicAPIKey}
+	case "gemini":
+		p.Gemini = &config.GeminiConfig{APIKey: c.geminiAPIKey}
+	case "qwen":
+		p.Qwen = &config.QwenConfig{APIKey: c.qwenAPIKey}
+	case "openai":
+		p.OpenAI = &config.OpenAIConfig{APIKey: c.openaiAPIKey, BaseURL: "https://api.openai.com/v1"}
+	case "mistral":
+		p.Mistral = &config.MistralConfig{APIKey: c.mistralAPIKey, BaseURL: "https://api.mistral.ai/v1"}
+	case "synthetic":
+		p.Synthetic = &config.SyntheticConfig{APIKey: c.syntheticAPIKey, BaseURL: "https://router.huggingface.co/v1"}
+	}
+	return cfg
+}
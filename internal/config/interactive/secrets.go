@@ -0,0 +1,53 @@
+package interactive
+
+import (
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/keyring"
+)
+
+// storeSecrets moves the collected API keys and OAuth tokens into the OS
+// credential store, recording the references the config file gets instead.
+// API keys are stored under the provider name and tokens under
+// "<provider>-oauth". Nothing is referenced unless every secret was stored.
+func (c *collectedConfig) storeSecrets() error {
+	refs := make(map[string]string)
+	for _, choice := range providerChoices {
+		if key := c.apiKey(choice.name); key != nil && *key != "" {
+			if err := keyring.Set(choice.name, *key); err != nil {
+				return err
+			}
+			refs[choice.name] = keyring.Ref(choice.name)
+		}
+		if token := c.oauthToken(choice.name); token != nil && *token != nil {
+			account := choice.name + "-oauth"
+			expiry, _ := time.Parse(time.RFC3339, (*token).ExpiresAt)
+			if err := keyring.SetToken(account, &keyring.Token{
+				AccessToken:  (*token).AccessToken,
+				RefreshToken: (*token).RefreshToken,
+				TokenType:    (*token).TokenType,
+				Expiry:       expiry,
+			}); err != nil {
+				return err
+			}
+			refs[account] = keyring.Ref(account)
+		}
+	}
+	c.secretRefs = refs
+	return nil
+}
+
+// apiKeyField returns the config key and value for provider's API key: a
+// credential store reference once storeSecrets has saved it, else the key
+func (c *collectedConfig) apiKeyField(provider string) (string, string) {
+	if ref, ok := c.secretRefs[provider]; ok {
+		return "api_key_ref", ref
+	}
+	return "api_key", *c.apiKey(provider)
+}
+
+// tokenRef returns the credential store reference for provider's OAuth
+// token, or "" if the token goes in the config file
+func (c *collectedConfig) tokenRef(provider string) string {
+	return c.secretRefs[provider+"-oauth"]
+}
@@ -0,0 +1,34 @@
+package interactive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateYAMLWritesSecretRefs(t *testing.T) {
+	w := &Wizard{config: &collectedConfig{
+		cerebrasAPIKey: "csk-secret",
+		geminiOAuth:    &oauthTokenData{AccessToken: "ya29-secret", RefreshToken: "1//secret"},
+		openaiAPIKey:   "sk-plain",
+		secretRefs: map[string]string{
+			"cerebras":     "keyring:cerebras",
+			"gemini-oauth": "keyring:gemini-oauth",
+		},
+	}}
+
+	yaml := w.generateYAML()
+	for _, want := range []string{
+		`api_key_ref: "keyring:cerebras"`,
+		`token_ref: "keyring:gemini-oauth"`,
+		`api_key: "sk-plain"`,
+	} {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("generated config is missing %s:\n%s", want, yaml)
+		}
+	}
+	for _, secret := range []string{"csk-secret", "ya29-secret", "1//secret"} {
+		if strings.Contains(yaml, secret) {
+			t.Errorf("generated config contains stored secret %q", secret)
+		}
+	}
+}
@@ -0,0 +1,697 @@
+package interactive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/keyring"
+	"github.com/cecil-the-coder/mcp-code-api/internal/tui"
+)
+
+// pageKind is a step of the full-screen wizard
+type pageKind int
+
+const (
+	pageImport pageKind = iota
+	pageProviders
+	pageAuth
+	pageAPIKey
+	pageOptions
+	pageModels
+	pageReview
+)
+
+// Review page save targets
+const (
+	saveUserConfig = iota
+	saveLocalConfig
+	saveCustomPath
+	saveSkip
+)
+
+// listModelsTimeout bounds the live model lookup on the models page
+const listModelsTimeout = 15 * time.Second
+
+// wizardPage is one screen; which fields are used depends on kind
+type wizardPage struct {
+	kind     pageKind
+	provider *providerChoice
+
+	list   tui.List
+	input  tui.TextInput
+	fields []tui.TextInput
+	focus  int
+
+	// auth is the provider's auth page, for its API key page
+	auth *wizardPage
+
+	// Models page state; fetchedKey is the API key the list was fetched with
+	loading    bool
+	fetchedKey *string
+	fetchErr   error
+}
+
+// wizardModel is the full-screen wizard. It collects into the Wizard's
+// config and leaves writing the file to runTUI.
+type wizardModel struct {
+	w        *Wizard
+	found    []importedCredential
+	imported map[string]bool
+
+	pages   []*wizardPage
+	current int
+	status  string
+	spinner tui.Spinner
+	height  int
+
+	// Outcome
+	cancelled bool
+	savePath  string
+	merge     bool
+}
+
+type modelsMsg struct {
+	provider string
+	models   []string
+	err      error
+}
+
+type oauthDoneMsg struct {
+	provider string
+	err      error
+}
+
+func newWizardModel(w *Wizard, found []importedCredential) *wizardModel {
+	m := &wizardModel{
+		w:        w,
+		found:    found,
+		imported: make(map[string]bool),
+		merge:    true,
+	}
+	w.config.useKeyring = keyring.Available()
+
+	if len(found) > 0 {
+		page := &wizardPage{kind: pageImport, list: tui.List{Multi: true}}
+		for _, cred := range found {
+			page.list.Items = append(page.list.Items, tui.ListItem{Label: cred.Provider, Detail: cred.summary(), Checked: true})
+		}
+		m.pages = append(m.pages, page)
+	}
+
+	providers := &wizardPage{kind: pageProviders, list: tui.List{Multi: true}}
+	for _, choice := range providerChoices {
+		providers.list.Items = append(providers.list.Items, tui.ListItem{Label: choice.title, Detail: choice.description})
+	}
+	m.pages = append(m.pages, providers)
+	return m
+}
+
+func (m *wizardModel) Init() tui.Cmd {
+	return nil
+}
+
+func (m *wizardModel) page() *wizardPage {
+	return m.pages[m.current]
+}
+
+func (m *wizardModel) Update(msg tui.Msg) (tui.Model, tui.Cmd) {
+	switch msg := msg.(type) {
+	case tui.WindowSizeMsg:
+		m.height = msg.Height
+		return m, nil
+
+	case tui.SpinnerTickMsg:
+		if m.page().loading {
+			return m, m.spinner.Update()
+		}
+		return m, nil
+
+	case modelsMsg:
+		for _, p := range m.pages {
+			if p.kind == pageModels && p.provider.name == msg.provider && p.loading {
+				m.fillModels(p, msg.models, msg.err)
+			}
+		}
+		return m, nil
+
+	case oauthDoneMsg:
+		if msg.err != nil {
+			m.status = tui.Red("OAuth login failed: " + msg.err.Error())
+			return m, nil
+		}
+		m.status = tui.Green("✓ Signed in to " + msg.provider)
+		return m, m.next()
+
+	case tui.KeyMsg:
+		switch msg.Type {
+		case tui.KeyCtrlC:
+			m.cancelled = true
+			return m, tui.Quit
+		case tui.KeyEsc:
+			return m, m.back()
+		}
+		return m, m.handleKey(m.page(), msg)
+	}
+	return m, nil
+}
+
+// handleKey updates the current page for a key press
+func (m *wizardModel) handleKey(p *wizardPage, key tui.KeyMsg) tui.Cmd {
+	m.status = ""
+	c := m.w.config
+
+	switch p.kind {
+	case pageImport:
+		if key.Type != tui.KeyEnter {
+			p.list.Update(key)
+			return nil
+		}
+		for i, item := range p.list.Items {
+			if item.Checked && c.apply(m.found[i]) {
+				m.imported[m.found[i].Provider] = true
+			}
+		}
+		providers := m.pages[m.current+1]
+		for i, choice := range providerChoices {
+			if m.imported[choice.name] {
+				providers.list.Items[i].Checked = true
+				providers.list.Items[i].Detail = "imported"
+			}
+		}
+		return m.next()
+
+	case pageProviders:
+		if key.Type != tui.KeyEnter {
+			p.list.Update(key)
+			return nil
+		}
+		var selected []*providerChoice
+		for i, item := range p.list.Items {
+			if item.Checked {
+				selected = append(selected, &providerChoices[i])
+			}
+		}
+		if len(selected) == 0 {
+			m.status = tui.Red("Select at least one provider with space")
+			return nil
+		}
+		m.pages = append(m.pages[:m.current+1], m.providerPages(selected)...)
+		return m.next()
+
+	case pageAuth:
+		if key.Type != tui.KeyEnter {
+			p.list.Update(key)
+			return nil
+		}
+		if p.list.Selected() == 0 {
+			*c.oauthToken(p.provider.name) = nil
+			return m.next()
+		}
+		w, provider := m.w, p.provider
+		return tui.Exec(func() error {
+			return w.loginOAuth(provider)
+		}, func(err error) tui.Msg {
+			return oauthDoneMsg{provider: provider.title, err: err}
+		})
+
+	case pageAPIKey:
+		if key.Type != tui.KeyEnter {
+			p.input.Update(key)
+			return nil
+		}
+		apiKey := p.input.String()
+		if apiKey == "" {
+			m.status = tui.Red("API key is required")
+			return nil
+		}
+		*c.apiKey(p.provider.name) = apiKey
+		if p.auth != nil {
+			*c.oauthToken(p.provider.name) = nil
+		}
+		return m.next()
+
+	case pageOptions:
+		switch key.Type {
+		case tui.KeyTab, tui.KeyDown:
+			p.focus = (p.focus + 1) % len(p.fields)
+		case tui.KeyShiftTab, tui.KeyUp:
+			p.focus = (p.focus + len(p.fields) - 1) % len(p.fields)
+		case tui.KeyEnter:
+			if p.focus < len(p.fields)-1 {
+				p.focus++
+				return nil
+			}
+			if err := m.saveOptions(p); err != nil {
+				m.status = tui.Red(err.Error())
+				return nil
+			}
+			return m.next()
+		default:
+			p.fields[p.focus].Update(key)
+		}
+		return nil
+
+	case pageModels:
+		if p.loading {
+			return nil
+		}
+		switch key.Type {
+		case tui.KeyTab:
+			if custom := strings.TrimSpace(p.list.Query()); custom != "" {
+				p.list.Items = append([]tui.ListItem{{Label: custom, Detail: "custom", Checked: true}}, p.list.Items...)
+				p.list.SetQuery("")
+			}
+		case tui.KeyEnter:
+			models := p.list.Checked()
+			if len(models) == 0 {
+				if i := p.list.Selected(); i >= 0 {
+					models = []string{p.list.Items[i].Label}
+				} else if custom := strings.TrimSpace(p.list.Query()); custom != "" {
+					models = []string{custom}
+				}
+			}
+			if len(models) == 0 {
+				m.status = tui.Red("Select at least one model")
+				return nil
+			}
+			*c.models(p.provider.name) = models
+			return m.next()
+		default:
+			p.list.Update(key)
+		}
+		return nil
+
+	case pageReview:
+		switch {
+		case key.Type == tui.KeyEnter:
+			return m.finish(p)
+		case key.Type == tui.KeyTab:
+			m.merge = !m.merge
+		case key.Type == tui.KeyCtrlK && keyring.Available():
+			m.w.config.useKeyring = !m.w.config.useKeyring
+		case key.Type == tui.KeyUp || key.Type == tui.KeyDown:
+			p.list.Update(key)
+		case p.list.Selected() == saveCustomPath:
+			p.input.Update(key)
+		default:
+			p.list.Update(key)
+		}
+	}
+	return nil
+}
+
+// providerPages returns the pages configuring the selected providers,
+// followed by the review page
+func (m *wizardModel) providerPages(selected []*providerChoice) []*wizardPage {
+	c := m.w.config
+	var pages []*wizardPage
+	for _, choice := range selected {
+		if !m.imported[choice.name] {
+			var auth *wizardPage
+			if choice.oauth {
+				auth = &wizardPage{kind: pageAuth, provider: choice, list: tui.List{Items: []tui.ListItem{
+					{Label: "API key", Detail: "recommended for most users"},
+					{Label: "OAuth", Detail: "log in with your browser"},
+				}}}
+				pages = append(pages, auth)
+			}
+			keyPage := &wizardPage{kind: pageAPIKey, provider: choice, auth: auth}
+			keyPage.input = tui.TextInput{Prompt: "API key: ", Placeholder: "paste your key", Mask: true}
+			keyPage.input.SetValue(*c.apiKey(choice.name))
+			pages = append(pages, keyPage)
+		}
+
+		switch choice.name {
+		case "cerebras":
+			pages = append(pages, &wizardPage{kind: pageOptions, provider: choice, fields: []tui.TextInput{
+				textField("Temperature: ", "0.6", c.cerebrasTemperature),
+				textField("Max tokens:  ", "unlimited", c.cerebrasMaxTokens),
+			}})
+		case "openrouter":
+			pages = append(pages, &wizardPage{kind: pageOptions, provider: choice, fields: []tui.TextInput{
+				textField("Site URL:  ", "https://github.com/cecil-the-coder/mcp-code-api", c.openrouterSiteURL),
+				textField("Site name: ", "MCP Code API", c.openrouterSiteName),
+			}})
+		}
+
+		pages = append(pages, &wizardPage{kind: pageModels, provider: choice, list: tui.List{Multi: true, Filter: true}})
+	}
+
+	review := &wizardPage{kind: pageReview, list: tui.List{Items: []tui.ListItem{
+		{Label: config.ConfigFilePath(), Detail: "user config directory"},
+		{Label: "config.yaml", Detail: "current directory"},
+		{Label: "Custom path"},
+		{Label: "Don't save"},
+	}}}
+	review.input = tui.TextInput{Prompt: "Path: ", Placeholder: "/path/to/config.yaml"}
+	return append(pages, review)
+}
+
+func textField(prompt, placeholder, value string) tui.TextInput {
+	field := tui.TextInput{Prompt: prompt, Placeholder: placeholder}
+	field.SetValue(value)
+	return field
+}
+
+// skip reports whether p doesn't apply given the answers so far
+func (m *wizardModel) skip(p *wizardPage) bool {
+	return p.kind == pageAPIKey && p.auth != nil && p.auth.list.Selected() == 1
+}
+
+// next moves to the following page that applies
+func (m *wizardModel) next() tui.Cmd {
+	for i := m.current + 1; i < len(m.pages); i++ {
+		if !m.skip(m.pages[i]) {
+			m.current = i
+			return m.enter(m.pages[i])
+		}
+	}
+	return nil
+}
+
+// back returns to the previous page that applies
+func (m *wizardModel) back() tui.Cmd {
+	m.status = ""
+	for i := m.current - 1; i >= 0; i-- {
+		if !m.skip(m.pages[i]) {
+			m.current = i
+			return nil
+		}
+	}
+	return nil
+}
+
+// enter prepares a page as it is shown; the models page fetches the
+// provider's model list, again if the API key changed
+func (m *wizardModel) enter(p *wizardPage) tui.Cmd {
+	if p.kind != pageModels {
+		return nil
+	}
+	key := *m.w.config.apiKey(p.provider.name)
+	if p.fetchedKey != nil && *p.fetchedKey == key {
+		return nil
+	}
+	p.fetchedKey = &key
+	p.loading = true
+
+	provider := p.provider.name
+	cfg := m.w.config.listModelsConfig(provider)
+	fetch := func() tui.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), listModelsTimeout)
+		defer cancel()
+		models, err := api.ListModels(ctx, cfg, provider)
+		return modelsMsg{provider: provider, models: models, err: err}
+	}
+	return tui.Batch(fetch, m.spinner.Tick())
+}
+
+// fillModels lists the fetched models after the ones already chosen and the
+// provider's default, which start out checked
+func (m *wizardModel) fillModels(p *wizardPage, models []string, err error) {
+	p.loading = false
+	p.fetchErr = err
+
+	chosen := *m.w.config.models(p.provider.name)
+	if len(chosen) == 0 {
+		chosen = []string{p.provider.defaultModel}
+	}
+
+	var items []tui.ListItem
+	seen := make(map[string]bool)
+	for _, model := range chosen {
+		items = append(items, tui.ListItem{Label: model, Checked: true})
+		seen[model] = true
+	}
+	if !seen[p.provider.defaultModel] {
+		items = append(items, tui.ListItem{Label: p.provider.defaultModel, Detail: "default"})
+		seen[p.provider.defaultModel] = true
+	}
+	for _, model := range models {
+		if !seen[model] {
+			items = append(items, tui.ListItem{Label: model})
+			seen[model] = true
+		}
+	}
+	p.list.Items = items
+	p.list.SetQuery("")
+}
+
+// saveOptions validates and stores the provider's extra settings
+func (m *wizardModel) saveOptions(p *wizardPage) error {
+	c := m.w.config
+	switch p.provider.name {
+	case "cerebras":
+		temp, maxTokens := p.fields[0].String(), p.fields[1].String()
+		if temp != "" {
+			if t, err := strconv.ParseFloat(temp, 64); err != nil || t < 0 || t > 1 {
+				p.focus = 0
+				return fmt.Errorf("temperature must be between 0.0 and 1.0")
+			}
+		}
+		if maxTokens != "" {
+			if n, err := strconv.Atoi(maxTokens); err != nil || n <= 0 {
+				p.focus = 1
+				return fmt.Errorf("max tokens must be a positive number")
+			}
+		}
+		c.cerebrasTemperature, c.cerebrasMaxTokens = temp, maxTokens
+	case "openrouter":
+		c.openrouterSiteURL, c.openrouterSiteName = p.fields[0].String(), p.fields[1].String()
+	}
+	return nil
+}
+
+// finish records where to save and quits
+func (m *wizardModel) finish(p *wizardPage) tui.Cmd {
+	path, err := reviewPath(p)
+	if err != nil {
+		m.status = tui.Red(err.Error())
+		return nil
+	}
+	m.savePath = path
+	return tui.Quit
+}
+
+// reviewPath returns the file chosen on the review page, or "" to skip
+// saving
+func reviewPath(p *wizardPage) (string, error) {
+	switch p.list.Selected() {
+	case saveUserConfig:
+		return config.ConfigFilePath(), nil
+	case saveLocalConfig:
+		return "config.yaml", nil
+	case saveCustomPath:
+		if p.input.String() == "" {
+			return "", fmt.Errorf("enter a path for the config file")
+		}
+		return config.ExpandPath(p.input.String()), nil
+	}
+	return "", nil
+}
+
+func (m *wizardModel) View() string {
+	p := m.page()
+
+	// Count the pages that apply for the progress indicator
+	step, steps := 0, 0
+	for i, page := range m.pages {
+		if m.skip(page) {
+			continue
+		}
+		steps++
+		if i <= m.current {
+			step++
+		}
+	}
+	if p.kind == pageProviders || p.kind == pageImport {
+		steps = 0 // Unknown until providers are chosen
+	}
+
+	var b strings.Builder
+	b.WriteString(tui.Bold("MCP Code API Setup"))
+	if steps > 0 {
+		b.WriteString(tui.Faint(fmt.Sprintf("  ·  step %d of %d", step, steps)))
+	}
+	b.WriteString("\n\n")
+
+	var help string
+	switch p.kind {
+	case pageImport:
+		b.WriteString(tui.Bold("Found existing credentials") + "\n")
+		b.WriteString("These were found in other AI tools and your environment.\n\n")
+		b.WriteString(p.list.View())
+		help = "space toggle · enter import selected · ctrl+c quit"
+
+	case pageProviders:
+		b.WriteString(tui.Bold("Choose providers") + "\n\n")
+		b.WriteString(p.list.View())
+		help = "space toggle · enter continue · esc back · ctrl+c quit"
+
+	case pageAuth:
+		b.WriteString(tui.Bold(p.provider.title+": authentication") + "\n\n")
+		b.WriteString(p.list.View())
+		help = "enter select · esc back"
+
+	case pageAPIKey:
+		b.WriteString(tui.Bold(p.provider.title+": API key") + "\n")
+		b.WriteString("Get your API key at " + tui.Cyan(p.provider.keyURL) + "\n\n")
+		b.WriteString(p.input.View())
+		help = "enter continue · esc back"
+
+	case pageOptions:
+		b.WriteString(tui.Bold(p.provider.title+": options") + "\n")
+		b.WriteString("Leave a field empty for its default.\n\n")
+		for i := range p.fields {
+			field := p.fields[i]
+			if i == p.focus {
+				b.WriteString(tui.Cyan("> ") + field.View() + "\n")
+			} else {
+				b.WriteString("  " + field.Prompt + string(field.Value) + "\n")
+			}
+		}
+		help = "tab next field · enter continue · esc back"
+
+	case pageModels:
+		b.WriteString(tui.Bold(p.provider.title+": models") + "\n")
+		if p.loading {
+			b.WriteString("\n" + m.spinner.View() + " Fetching available models...")
+			help = "esc back"
+			break
+		}
+		if p.fetchErr != nil {
+			b.WriteString(tui.Faint("Could not list models ("+firstLine(p.fetchErr.Error())+"); type a name and press tab to add one.") + "\n")
+		}
+		b.WriteString("\n")
+		p.list.Height = max(m.height-12, 5)
+		b.WriteString(p.list.View())
+		help = "type to filter · space toggle · tab add typed model · enter continue · esc back"
+
+	case pageReview:
+		b.WriteString(tui.Bold("Review") + "\n\n")
+		b.WriteString(m.summary())
+		secrets := "in the config file"
+		if m.w.config.useKeyring {
+			secrets = "in " + keyring.Name()
+		}
+		b.WriteString("\n  Secrets stored " + secrets + "\n")
+		b.WriteString("\n" + tui.Bold("Save to") + "\n")
+		b.WriteString(p.list.View() + "\n")
+		if p.list.Selected() == saveCustomPath {
+			b.WriteString("\n" + p.input.View() + "\n")
+		}
+		keys := "enter save · "
+		if keyring.Available() {
+			keys += "ctrl+k keyring · "
+		}
+		help = keys + "esc back · ctrl+c quit"
+		if path, _ := reviewPath(p); path != "" {
+			if _, err := os.Stat(path); err == nil {
+				mode := "merge providers into it"
+				if !m.merge {
+					mode = "replace it"
+				}
+				b.WriteString("\n" + tui.Faint(path+" exists; will "+mode))
+				help = keys + "tab merge/replace · esc back · ctrl+c quit"
+			}
+		}
+	}
+
+	if m.status != "" {
+		b.WriteString("\n\n" + m.status)
+	}
+	b.WriteString("\n\n" + tui.Faint(help))
+	return b.String()
+}
+
+// summary lists the configured providers for the review page
+func (m *wizardModel) summary() string {
+	c := m.w.config
+	var b strings.Builder
+	for _, choice := range providerChoices {
+		if !c.hasCredentials(choice.name) {
+			continue
+		}
+		auth := "API key " + maskSecret(*c.apiKey(choice.name))
+		if token := c.oauthToken(choice.name); token != nil && *token != nil {
+			auth = "OAuth"
+		}
+		models := *c.models(choice.name)
+		if len(models) == 0 {
+			models = []string{choice.defaultModel}
+		}
+		fmt.Fprintf(&b, "  %s %-17s %s  %s\n", tui.Green("✓"), choice.title, auth, tui.Faint(strings.Join(models, ", ")))
+	}
+	return b.String()
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	if len(line) > 80 {
+		line = line[:77] + "..."
+	}
+	return line
+}
+
+// loginOAuth runs the browser OAuth flow for provider on the plain
+// terminal, while the full-screen wizard is suspended
+func (w *Wizard) loginOAuth(provider *providerChoice) error {
+	_, tokenInfo, err := w.configureProviderOAuth(provider.name, provider.title)
+	if err != nil {
+		return err
+	}
+	if tokenInfo == nil {
+		return fmt.Errorf("no token received")
+	}
+	*w.config.oauthToken(provider.name) = &oauthTokenData{
+		AccessToken:  tokenInfo.AccessToken,
+		RefreshToken: tokenInfo.RefreshToken,
+		ExpiresAt:    tokenInfo.ExpiresAt.Format(time.RFC3339),
+		TokenType:    tokenInfo.TokenType,
+	}
+	*w.config.apiKey(provider.name) = ""
+
+	if provider.name == "gemini" {
+		projectID, err := w.performGeminiOnboarding(tokenInfo)
+		if err != nil {
+			fmt.Printf("\n⚠️  Warning: Gemini onboarding failed: %v\n", err)
+			fmt.Println("   You may need to set GOOGLE_CLOUD_PROJECT environment variable manually.")
+			fmt.Print("Press Enter to continue...")
+			w.reader.ReadString('\n')
+		} else {
+			w.config.geminiProjectID = projectID
+		}
+	}
+	return nil
+}
+
+// runTUI runs the full-screen wizard and saves its result
+func (w *Wizard) runTUI() error {
+	m := newWizardModel(w, detectCredentials(config.GetHomeDir(), os.Getenv))
+	final, err := tui.NewProgram(m).Run()
+	if err != nil {
+		return err
+	}
+	m = final.(*wizardModel)
+	if m.cancelled {
+		return fmt.Errorf("configuration cancelled")
+	}
+
+	if m.savePath == "" {
+		fmt.Println("Skipping configuration save.")
+		printNextSteps("")
+		return nil
+	}
+	_, statErr := os.Stat(m.savePath)
+	if err := w.writeConfiguration(m.savePath, statErr == nil && m.merge); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Configuration saved to: %s\n", m.savePath)
+	printNextSteps(m.savePath)
+	return nil
+}
@@ -0,0 +1,130 @@
+package interactive
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/tui"
+)
+
+// press sends keys to the model, running commands that don't need the
+// terminal, and returns the last command
+func press(m *wizardModel, keys ...tui.KeyMsg) tui.Cmd {
+	var cmd tui.Cmd
+	for _, key := range keys {
+		_, cmd = m.Update(key)
+	}
+	return cmd
+}
+
+func keys(types ...tui.KeyType) []tui.KeyMsg {
+	var msgs []tui.KeyMsg
+	for _, t := range types {
+		msgs = append(msgs, tui.KeyMsg{Type: t})
+	}
+	return msgs
+}
+
+func text(s string) tui.KeyMsg {
+	return tui.KeyMsg{Type: tui.KeyRune, Runes: []rune(s)}
+}
+
+func TestWizardModelFlow(t *testing.T) {
+	w := &Wizard{config: &collectedConfig{}}
+	found := []importedCredential{{Provider: "openai", Source: "$OPENAI_API_KEY", APIKey: "sk-imported"}}
+	m := newWizardModel(w, found)
+
+	// Import the key, then add Mistral (7th, before Synthetic) to the
+	// preselected OpenAI
+	press(m, keys(tui.KeyEnter)...)
+	if m.page().kind != pageProviders || !m.page().list.Items[5].Checked {
+		t.Fatalf("providers page = %+v, want OpenAI preselected", m.page().list.Items)
+	}
+	press(m, keys(tui.KeyEnd, tui.KeyUp)...)
+	press(m, text(" "))
+	cmd := press(m, keys(tui.KeyEnter)...)
+
+	// Imported OpenAI skips straight to its models, which fetch live
+	if m.page().kind != pageModels || m.page().provider.name != "openai" || cmd == nil {
+		t.Fatalf("page = %v for %s, want openai models with a fetch", m.page().kind, m.page().provider.name)
+	}
+	m.Update(modelsMsg{provider: "openai", models: []string{"gpt-4o", "o3"}})
+	if got := m.page().list.Checked(); len(got) != 1 || got[0] != "gpt-4o" {
+		t.Fatalf("checked models = %v, want the default", got)
+	}
+	press(m, text("o3"))
+	press(m, text(" "))
+	press(m, keys(tui.KeyEnter)...)
+	if got := w.config.openaiModels; len(got) != 2 || got[1] != "o3" {
+		t.Errorf("openaiModels = %v, want gpt-4o and o3", got)
+	}
+
+	// Mistral asks for a key; an empty one is refused
+	if m.page().kind != pageAPIKey {
+		t.Fatalf("page = %v, want API key", m.page().kind)
+	}
+	press(m, keys(tui.KeyEnter)...)
+	if m.status == "" || m.page().kind != pageAPIKey {
+		t.Error("empty API key was accepted")
+	}
+	press(m, text("mistral-key"))
+	press(m, keys(tui.KeyEnter)...)
+	if w.config.mistralAPIKey != "mistral-key" {
+		t.Errorf("mistralAPIKey = %q", w.config.mistralAPIKey)
+	}
+
+	// Listing fails: the default is offered and a custom model can be added
+	m.Update(modelsMsg{provider: "mistral", err: errTest})
+	press(m, text("my-codestral"))
+	press(m, keys(tui.KeyTab)...)
+	press(m, keys(tui.KeyEnter)...)
+	if got := w.config.mistralModels; len(got) != 2 || got[0] != "my-codestral" {
+		t.Errorf("mistralModels = %v, want my-codestral first", got)
+	}
+
+	// Esc goes back without losing answers
+	if m.page().kind != pageReview {
+		t.Fatalf("page = %v, want review", m.page().kind)
+	}
+	press(m, keys(tui.KeyEsc)...)
+	if m.page().kind != pageModels {
+		t.Errorf("after esc page = %v, want models", m.page().kind)
+	}
+	press(m, keys(tui.KeyEnter)...)
+
+	// Choose "Don't save"
+	press(m, keys(tui.KeyDown, tui.KeyDown, tui.KeyDown)...)
+	press(m, keys(tui.KeyEnter)...)
+	if m.cancelled || m.savePath != "" {
+		t.Errorf("cancelled = %v, savePath = %q", m.cancelled, m.savePath)
+	}
+}
+
+func TestWizardModelOAuthSkipsAPIKey(t *testing.T) {
+	w := &Wizard{config: &collectedConfig{}}
+	m := newWizardModel(w, nil)
+
+	press(m, keys(tui.KeyDown, tui.KeyDown)...) // Anthropic
+	press(m, text(" "))
+	press(m, keys(tui.KeyEnter)...)
+	if m.page().kind != pageAuth {
+		t.Fatalf("page = %v, want auth", m.page().kind)
+	}
+	press(m, keys(tui.KeyDown)...)
+	if cmd := press(m, keys(tui.KeyEnter)...); cmd == nil {
+		t.Fatal("choosing OAuth did not start the login")
+	}
+
+	// Pretend the login succeeded
+	w.config.anthropicOAuth = &oauthTokenData{AccessToken: "token"}
+	m.Update(oauthDoneMsg{provider: "Anthropic Claude"})
+	if m.page().kind != pageModels {
+		t.Errorf("after OAuth page = %v, want models", m.page().kind)
+	}
+	press(m, keys(tui.KeyEsc)...)
+	if m.page().kind != pageAuth {
+		t.Errorf("esc from models went to %v, want auth (API key skipped)", m.page().kind)
+	}
+}
+
+var errTest = errors.New("mistral: no models")
@@ -13,7 +13,9 @@ import (
 	"github.com/cecil-the-coder/mcp-code-api/internal/api"
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/auth"
 	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/keyring"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/tui"
 	"gopkg.in/yaml.v3"
 )
 
@@ -56,6 +58,19 @@ type collectedConfig struct {
 	qwenAPIKey string
 	qwenModels []string
 	qwenOAuth  *oauthTokenData
+
+	// Mistral
+	mistralAPIKey string
+	mistralModels []string
+
+	// Synthetic (Hugging Face)
+	syntheticAPIKey string
+	syntheticModels []string
+
+	// Secrets go to the OS credential store when useKeyring is set;
+	// secretRefs maps each stored account to its config reference
+	useKeyring bool
+	secretRefs map[string]string
 }
 
 // oauthTokenData stores OAuth token information
@@ -77,28 +92,47 @@ func NewWizard() *Wizard {
 // Run runs the interactive configuration wizard
 func Run() error {
 	wizard := NewWizard()
+	if tui.IsTerminal(os.Stdin) && tui.IsTerminal(os.Stdout) {
+		return wizard.runTUI()
+	}
 	return wizard.run()
 }
 
+// RunPlain runs the wizard with line-based prompts, for terminals the
+// full-screen wizard doesn't work in and for scripted input
+func RunPlain() error {
+	return NewWizard().run()
+}
+
 // run executes the wizard flow
 func (w *Wizard) run() error {
 	fmt.Println("\n╔════════════════════════════════════════╗")
 	fmt.Println("║  MCP Code API Configuration Wizard    ║")
 	fmt.Println("╚════════════════════════════════════════╝")
 
+	// Step 0: Offer credentials found in other AI CLIs and the environment
+	imported := w.importCredentials()
+	if len(imported) > 0 {
+		fmt.Println("\nYou can add more providers below, or press Enter to continue with the imported ones.")
+	}
+
 	// Step 1: Select providers to configure
 	selectedProviders, err := w.selectProviders()
 	if err != nil {
 		return err
 	}
 
-	if len(selectedProviders) == 0 {
+	if len(selectedProviders) == 0 && len(imported) == 0 {
 		fmt.Println("\n⚠️  No providers selected. At least one provider is required.")
 		return fmt.Errorf("no providers configured")
 	}
 
 	// Step 2: Configure selected providers
 	for _, provider := range selectedProviders {
+		if imported[provider] {
+			fmt.Printf("\n↪ %s credentials were imported, skipping setup\n", provider)
+			continue
+		}
 		if err := w.configureProvider(provider); err != nil {
 			logger.Errorf("Failed to configure %s: %v", provider, err)
 		}
@@ -119,6 +153,12 @@ func (w *Wizard) run() error {
 		fmt.Printf("\n✅ Configuration saved to: %s\n", configPath)
 	}
 
+	printNextSteps(configPath)
+	return nil
+}
+
+// printNextSteps tells the user how to start using the configuration
+func printNextSteps(configPath string) {
 	fmt.Println("\n✅ Configuration complete!")
 	fmt.Println("\n📝 Next steps:")
 	if configPath != "" {
@@ -128,8 +168,6 @@ func (w *Wizard) run() error {
 	}
 	fmt.Println("   2. The server will automatically provide systemPrompt instructions to all MCP-compatible IDEs")
 	fmt.Println("   3. Use the 'write' tool in your IDE for all code operations")
-
-	return nil
 }
 
 // selectProviders presents a menu of providers and returns the user's selection
@@ -141,6 +179,8 @@ func (w *Wizard) selectProviders() ([]string, error) {
 	fmt.Println("   4. Google Gemini - Multimodal AI with API key or OAuth")
 	fmt.Println("   5. Alibaba Qwen - Chinese language models with API key or OAuth")
 	fmt.Println("   6. OpenAI - GPT models with API key")
+	fmt.Println("   7. Mistral - Codestral code models with fill-in-the-middle")
+	fmt.Println("   8. Synthetic (Hugging Face) - Open models on Hugging Face Inference Providers")
 	fmt.Println()
 	fmt.Println("Select providers to configure:")
 	fmt.Println("  • Enter numbers separated by commas (e.g., 1,3,4)")
@@ -155,7 +195,7 @@ func (w *Wizard) selectProviders() ([]string, error) {
 
 	// Handle 'all' selection
 	if strings.ToLower(strings.TrimSpace(input)) == "all" {
-		return []string{"cerebras", "openrouter", "anthropic", "gemini", "qwen", "openai"}, nil
+		return []string{"cerebras", "openrouter", "anthropic", "gemini", "qwen", "openai", "mistral", "synthetic"}, nil
 	}
 
 	// Parse comma-separated numbers
@@ -166,6 +206,8 @@ func (w *Wizard) selectProviders() ([]string, error) {
 		4: "gemini",
 		5: "qwen",
 		6: "openai",
+		7: "mistral",
+		8: "synthetic",
 	}
 
 	var selected []string
@@ -175,7 +217,7 @@ func (w *Wizard) selectProviders() ([]string, error) {
 	for _, numStr := range numbers {
 		numStr = strings.TrimSpace(numStr)
 		num, err := strconv.Atoi(numStr)
-		if err != nil || num < 1 || num > 6 {
+		if err != nil || num < 1 || num > len(providerMap) {
 			fmt.Printf("⚠️  Invalid selection: %s (skipping)\n", numStr)
 			continue
 		}
@@ -205,6 +247,10 @@ func (w *Wizard) configureProvider(provider string) error {
 		return w.configureQwenProvider()
 	case "openai":
 		return w.configureOpenAIProvider()
+	case "mistral":
+		return w.configureMistralProvider()
+	case "synthetic":
+		return w.configureSyntheticProvider()
 	default:
 		return fmt.Errorf("unknown provider: %s", provider)
 	}
@@ -517,6 +563,67 @@ func (w *Wizard) configureOpenAIProvider() error {
 	return nil
 }
 
+// configureMistralProvider configures the Mistral API key and models
+func (w *Wizard) configureMistralProvider() error {
+	fmt.Println("\n🌬️  Mistral Configuration")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("Get your API key at: https://console.mistral.ai/api-keys")
+	fmt.Println()
+
+	apiKey := w.prompt("Enter Mistral API key: ", false)
+	if apiKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+	w.config.mistralAPIKey = apiKey
+	os.Setenv("MISTRAL_API_KEY", apiKey)
+	fmt.Println("✅ Mistral API key configured")
+
+	// Ask about models
+	fmt.Println()
+	fmt.Println("Model Configuration:")
+	fmt.Println("  • Enter one or more models separated by commas")
+	fmt.Println("  • Example: codestral-latest,mistral-large-latest")
+	modelsInput := w.prompt("Models (default: codestral-latest, press Enter for default): ", true)
+	if modelsInput != "" {
+		w.config.mistralModels = parseModelList(modelsInput)
+	} else {
+		w.config.mistralModels = []string{"codestral-latest"}
+	}
+
+	return nil
+}
+
+// configureSyntheticProvider configures the Hugging Face token and models
+func (w *Wizard) configureSyntheticProvider() error {
+	fmt.Println("\n🤗 Synthetic (Hugging Face) Configuration")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("Create an access token at: https://huggingface.co/settings/tokens")
+	fmt.Println("  • It needs the \"Make calls to Inference Providers\" permission")
+	fmt.Println()
+
+	apiKey := w.prompt("Enter Hugging Face token: ", false)
+	if apiKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+	w.config.syntheticAPIKey = apiKey
+	os.Setenv("HF_TOKEN", apiKey)
+	fmt.Println("✅ Hugging Face token configured")
+
+	// Ask about models
+	fmt.Println()
+	fmt.Println("Model Configuration:")
+	fmt.Println("  • Enter one or more Hub model IDs separated by commas")
+	fmt.Println("  • Example: Qwen/Qwen2.5-Coder-32B-Instruct,deepseek-ai/DeepSeek-V3")
+	modelsInput := w.prompt("Models (default: Qwen/Qwen2.5-Coder-32B-Instruct, press Enter for default): ", true)
+	if modelsInput != "" {
+		w.config.syntheticModels = parseModelList(modelsInput)
+	} else {
+		w.config.syntheticModels = []string{"Qwen/Qwen2.5-Coder-32B-Instruct"}
+	}
+
+	return nil
+}
+
 // testConfiguration tests the API connections
 func (w *Wizard) testConfiguration() error {
 	fmt.Println("\n🧪 Testing Configuration")
@@ -531,7 +638,9 @@ func (w *Wizard) testConfiguration() error {
 		w.config.geminiAPIKey != "" ||
 		w.config.geminiOAuth != nil ||
 		w.config.qwenAPIKey != "" ||
-		w.config.qwenOAuth != nil
+		w.config.qwenOAuth != nil ||
+		w.config.mistralAPIKey != "" ||
+		w.config.syntheticAPIKey != ""
 
 	if !hasAnyProvider {
 		return fmt.Errorf("no providers configured")
@@ -566,6 +675,12 @@ func (w *Wizard) testConfiguration() error {
 	if w.config.qwenOAuth != nil {
 		fmt.Printf("✅ Qwen OAuth configured (expires: %s)\n", w.config.qwenOAuth.ExpiresAt)
 	}
+	if w.config.mistralAPIKey != "" {
+		fmt.Println("✅ Mistral API configured")
+	}
+	if w.config.syntheticAPIKey != "" {
+		fmt.Println("✅ Synthetic (Hugging Face) configured")
+	}
 
 	return nil
 }
@@ -605,6 +720,13 @@ func writeModelsYAML(sb *strings.Builder, models []string, indent string) {
 	}
 }
 
+// writeAPIKeyYAML writes provider's api_key, or its api_key_ref once the key
+// is in the credential store
+func writeAPIKeyYAML(sb *strings.Builder, c *collectedConfig, provider string) {
+	key, value := c.apiKeyField(provider)
+	sb.WriteString(fmt.Sprintf("    %s: \"%s\"\n", key, value))
+}
+
 // modelsToInterface converts model slice to interface{} for YAML marshal
 func modelsToInterface(models []string) interface{} {
 	if len(models) == 0 {
@@ -648,7 +770,7 @@ func (w *Wizard) saveConfiguration() (string, error) {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("Choose where to save your configuration:")
 	fmt.Println("  1. config.yaml (current directory)")
-	fmt.Println("  2. ~/.mcp-code-api/config.yaml (user config directory)")
+	fmt.Printf("  2. %s (user config directory)\n", config.ConfigFilePath())
 	fmt.Println("  3. Custom path")
 	fmt.Println("  4. Skip (don't save)")
 	fmt.Println()
@@ -660,28 +782,17 @@ func (w *Wizard) saveConfiguration() (string, error) {
 	case "1":
 		configPath = "config.yaml"
 	case "2":
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get home directory: %w", err)
-		}
-		configDir := filepath.Join(homeDir, ".mcp-code-api")
-		if err := os.MkdirAll(configDir, 0755); err != nil {
+		configPath = config.ConfigFilePath()
+		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 			return "", fmt.Errorf("failed to create config directory: %w", err)
 		}
-		configPath = filepath.Join(configDir, "config.yaml")
 	case "3":
 		configPath = w.prompt("Enter full path to config file: ", false)
 		if configPath == "" {
 			return "", fmt.Errorf("path is required")
 		}
 		// Expand ~ to home directory
-		if strings.HasPrefix(configPath, "~/") {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				return "", fmt.Errorf("failed to get home directory: %w", err)
-			}
-			configPath = filepath.Join(homeDir, configPath[2:])
-		}
+		configPath = config.ExpandPath(configPath)
 		// Create parent directory if it doesn't exist
 		configDir := filepath.Dir(configPath)
 		if err := os.MkdirAll(configDir, 0755); err != nil {
@@ -695,7 +806,7 @@ func (w *Wizard) saveConfiguration() (string, error) {
 	}
 
 	// Check if config file already exists
-	var yamlContent string
+	merge := false
 	if _, err := os.Stat(configPath); err == nil {
 		// File exists - ask if they want to merge or replace
 		fmt.Printf("\n⚠️  Configuration file already exists: %s\n", configPath)
@@ -708,16 +819,9 @@ func (w *Wizard) saveConfiguration() (string, error) {
 		mergeChoice := w.prompt("Select option (1-3): ", false)
 		switch mergeChoice {
 		case "1":
-			// Merge with existing config
-			merged, err := w.mergeWithExistingConfig(configPath)
-			if err != nil {
-				return "", fmt.Errorf("failed to merge with existing config: %w", err)
-			}
-			yamlContent = merged
+			merge = true
 			fmt.Println("✅ Configuration will be merged with existing file")
 		case "2":
-			// Replace - generate fresh YAML
-			yamlContent = w.generateYAML()
 			fmt.Println("✅ Configuration will replace existing file")
 		case "3":
 			fmt.Println("Configuration save cancelled.")
@@ -725,17 +829,53 @@ func (w *Wizard) saveConfiguration() (string, error) {
 		default:
 			return "", fmt.Errorf("invalid choice: %s", mergeChoice)
 		}
+	}
+
+	if keyring.Available() {
+		answer := strings.ToLower(w.prompt(fmt.Sprintf("Store API keys and tokens in %s instead of the config file? (Y/n): ", keyring.Name()), true))
+		w.config.useKeyring = answer == "" || answer == "y" || answer == "yes"
+	}
+
+	if err := w.writeConfiguration(configPath, merge); err != nil {
+		return "", err
+	}
+	return configPath, nil
+}
+
+// writeConfiguration writes the collected configuration to configPath,
+// merging it into the existing file if merge is set
+func (w *Wizard) writeConfiguration(configPath string, merge bool) error {
+	w.prepareSecrets()
+
+	var yamlContent string
+	if merge {
+		merged, err := w.mergeWithExistingConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to merge with existing config: %w", err)
+		}
+		yamlContent = merged
 	} else {
-		// File doesn't exist - generate fresh YAML
+		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+			return fmt.Errorf("failed to create config directory: %w", err)
+		}
 		yamlContent = w.generateYAML()
 	}
 
-	// Write to file
 	if err := os.WriteFile(configPath, []byte(yamlContent), 0600); err != nil {
-		return "", fmt.Errorf("failed to write config file: %w", err)
+		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	return nil
+}
 
-	return configPath, nil
+// prepareSecrets moves the secrets to the OS credential store if requested,
+// falling back to the config file if that fails
+func (w *Wizard) prepareSecrets() {
+	w.config.secretRefs = nil
+	if w.config.useKeyring {
+		if err := w.config.storeSecrets(); err != nil {
+			fmt.Printf("⚠️  Could not store secrets in %s (%v); writing them to the config file\n", keyring.Name(), err)
+		}
+	}
 }
 
 // mergeWithExistingConfig loads existing config and merges new provider configurations
@@ -787,9 +927,10 @@ func (w *Wizard) mergeWithExistingConfig(configPath string) (string, error) {
 	// Merge Cerebras configuration
 	if w.config.cerebrasAPIKey != "" {
 		cerebrasConfig := map[string]interface{}{
-			"api_key":  w.config.cerebrasAPIKey,
 			"base_url": "https://api.cerebras.ai",
 		}
+		key, value := w.config.apiKeyField("cerebras")
+		cerebrasConfig[key] = value
 		if len(w.config.cerebrasModels) > 0 {
 			if len(w.config.cerebrasModels) == 1 {
 				cerebrasConfig["model"] = w.config.cerebrasModels[0]
@@ -817,9 +958,10 @@ func (w *Wizard) mergeWithExistingConfig(configPath string) (string, error) {
 	// Merge OpenRouter configuration
 	if w.config.openrouterAPIKey != "" {
 		openrouterConfig := map[string]interface{}{
-			"api_key":  w.config.openrouterAPIKey,
 			"base_url": "https://openrouter.ai/api",
 		}
+		key, value := w.config.apiKeyField("openrouter")
+		openrouterConfig[key] = value
 		if len(w.config.openrouterModels) > 0 {
 			if len(w.config.openrouterModels) == 1 {
 				openrouterConfig["model"] = w.config.openrouterModels[0]
@@ -847,10 +989,11 @@ func (w *Wizard) mergeWithExistingConfig(configPath string) (string, error) {
 	// Merge OpenAI configuration
 	if w.config.openaiAPIKey != "" {
 		openaiConfig := map[string]interface{}{
-			"api_key":           w.config.openaiAPIKey,
 			"base_url":          "https://api.openai.com/v1",
 			"use_responses_api": false,
 		}
+		key, value := w.config.apiKeyField("openai")
+		openaiConfig[key] = value
 		if len(w.config.openaiModels) > 0 {
 			if len(w.config.openaiModels) == 1 {
 				openaiConfig["model"] = w.config.openaiModels[0]
@@ -871,9 +1014,12 @@ func (w *Wizard) mergeWithExistingConfig(configPath string) (string, error) {
 			"base_url": "https://api.anthropic.com",
 		}
 		if w.config.anthropicAPIKey != "" {
-			anthropicConfig["api_key"] = w.config.anthropicAPIKey
+			key, value := w.config.apiKeyField("anthropic")
+			anthropicConfig[key] = value
 		}
-		if w.config.anthropicOAuth != nil {
+		if ref := w.config.tokenRef("anthropic"); ref != "" {
+			anthropicConfig["oauth"] = map[string]interface{}{"token_ref": ref}
+		} else if w.config.anthropicOAuth != nil {
 			anthropicConfig["oauth"] = map[string]interface{}{
 				"access_token":  w.config.anthropicOAuth.AccessToken,
 				"refresh_token": w.config.anthropicOAuth.RefreshToken,
@@ -901,16 +1047,21 @@ func (w *Wizard) mergeWithExistingConfig(configPath string) (string, error) {
 			"base_url": "https://generativelanguage.googleapis.com",
 		}
 		if w.config.geminiAPIKey != "" {
-			geminiConfig["api_key"] = w.config.geminiAPIKey
+			key, value := w.config.apiKeyField("gemini")
+			geminiConfig[key] = value
 		}
 		if w.config.geminiOAuth != nil {
-			// Store OAuth tokens as flat fields, not nested
-			geminiConfig["access_token"] = w.config.geminiOAuth.AccessToken
-			geminiConfig["refresh_token"] = w.config.geminiOAuth.RefreshToken
-			// Store token_expiry as time.Time (will be serialized as RFC3339)
-			expiresAt, err := time.Parse(time.RFC3339, w.config.geminiOAuth.ExpiresAt)
-			if err == nil {
-				geminiConfig["token_expiry"] = expiresAt
+			if ref := w.config.tokenRef("gemini"); ref != "" {
+				geminiConfig["token_ref"] = ref
+			} else {
+				// Store OAuth tokens as flat fields, not nested
+				geminiConfig["access_token"] = w.config.geminiOAuth.AccessToken
+				geminiConfig["refresh_token"] = w.config.geminiOAuth.RefreshToken
+				// Store token_expiry as time.Time (will be serialized as RFC3339)
+				expiresAt, err := time.Parse(time.RFC3339, w.config.geminiOAuth.ExpiresAt)
+				if err == nil {
+					geminiConfig["token_expiry"] = expiresAt
+				}
 			}
 			// Add client credentials from OAuth defaults (official llxprt-code credentials)
 			geminiConfig["client_id"] = auth.GeminiOAuthClientID
@@ -937,12 +1088,15 @@ func (w *Wizard) mergeWithExistingConfig(configPath string) (string, error) {
 	// Merge Qwen configuration
 	if w.config.qwenAPIKey != "" || w.config.qwenOAuth != nil {
 		qwenConfig := map[string]interface{}{
-			"base_url": "https://dashscope.aliyuncs.com/api/v1",
+			"base_url": "https://dashscope.aliyuncs.com/compatible-mode",
 		}
 		if w.config.qwenAPIKey != "" {
-			qwenConfig["api_key"] = w.config.qwenAPIKey
+			key, value := w.config.apiKeyField("qwen")
+			qwenConfig[key] = value
 		}
-		if w.config.qwenOAuth != nil {
+		if ref := w.config.tokenRef("qwen"); ref != "" {
+			qwenConfig["oauth"] = map[string]interface{}{"token_ref": ref}
+		} else if w.config.qwenOAuth != nil {
 			qwenConfig["oauth"] = map[string]interface{}{
 				"access_token":  w.config.qwenOAuth.AccessToken,
 				"refresh_token": w.config.qwenOAuth.RefreshToken,
@@ -964,6 +1118,42 @@ func (w *Wizard) mergeWithExistingConfig(configPath string) (string, error) {
 		enabled = addToList(enabled, "qwen")
 	}
 
+	// Merge Mistral configuration
+	if w.config.mistralAPIKey != "" {
+		mistralConfig := map[string]interface{}{
+			"base_url": "https://api.mistral.ai/v1",
+		}
+		key, value := w.config.apiKeyField("mistral")
+		mistralConfig[key] = value
+		if len(w.config.mistralModels) > 0 {
+			mistralConfig["model"] = w.config.mistralModels[0]
+		} else {
+			mistralConfig["model"] = "codestral-latest"
+		}
+		updateProvider("mistral", mistralConfig)
+		preferredOrder = addToList(preferredOrder, "mistral")
+		enabled = addToList(enabled, "mistral")
+	}
+
+	// Merge Synthetic (Hugging Face) configuration
+	if w.config.syntheticAPIKey != "" {
+		syntheticConfig := map[string]interface{}{
+			"base_url": "https://router.huggingface.co/v1",
+		}
+		key, value := w.config.apiKeyField("synthetic")
+		syntheticConfig[key] = value
+		if len(w.config.syntheticModels) > 1 {
+			syntheticConfig["models"] = w.config.syntheticModels
+		} else if len(w.config.syntheticModels) == 1 {
+			syntheticConfig["model"] = w.config.syntheticModels[0]
+		} else {
+			syntheticConfig["model"] = "Qwen/Qwen2.5-Coder-32B-Instruct"
+		}
+		updateProvider("synthetic", syntheticConfig)
+		preferredOrder = addToList(preferredOrder, "synthetic")
+		enabled = addToList(enabled, "synthetic")
+	}
+
 	// Update lists
 	providers["preferred_order"] = preferredOrder
 	providers["enabled"] = enabled
@@ -995,7 +1185,7 @@ func (w *Wizard) generateYAML() string {
 	// Cerebras configuration
 	if w.config.cerebrasAPIKey != "" {
 		sb.WriteString("  cerebras:\n")
-		sb.WriteString(fmt.Sprintf("    api_key: \"%s\"\n", w.config.cerebrasAPIKey))
+		writeAPIKeyYAML(&sb, w.config, "cerebras")
 		if len(w.config.cerebrasModels) > 0 {
 			writeModelsYAML(&sb, w.config.cerebrasModels, "    ")
 		} else {
@@ -1015,7 +1205,7 @@ func (w *Wizard) generateYAML() string {
 	// OpenRouter configuration
 	if w.config.openrouterAPIKey != "" {
 		sb.WriteString("  openrouter:\n")
-		sb.WriteString(fmt.Sprintf("    api_key: \"%s\"\n", w.config.openrouterAPIKey))
+		writeAPIKeyYAML(&sb, w.config, "openrouter")
 		if len(w.config.openrouterModels) > 0 {
 			writeModelsYAML(&sb, w.config.openrouterModels, "    ")
 		} else {
@@ -1037,7 +1227,7 @@ func (w *Wizard) generateYAML() string {
 	// OpenAI configuration
 	if w.config.openaiAPIKey != "" {
 		sb.WriteString("  openai:\n")
-		sb.WriteString(fmt.Sprintf("    api_key: \"%s\"\n", w.config.openaiAPIKey))
+		writeAPIKeyYAML(&sb, w.config, "openai")
 		if len(w.config.openaiModels) > 0 {
 			writeModelsYAML(&sb, w.config.openaiModels, "    ")
 		} else {
@@ -1051,9 +1241,12 @@ func (w *Wizard) generateYAML() string {
 	if w.config.anthropicAPIKey != "" || w.config.anthropicOAuth != nil {
 		sb.WriteString("  anthropic:\n")
 		if w.config.anthropicAPIKey != "" {
-			sb.WriteString(fmt.Sprintf("    api_key: \"%s\"\n", w.config.anthropicAPIKey))
+			writeAPIKeyYAML(&sb, w.config, "anthropic")
 		}
-		if w.config.anthropicOAuth != nil {
+		if ref := w.config.tokenRef("anthropic"); ref != "" {
+			sb.WriteString("    oauth:\n")
+			sb.WriteString(fmt.Sprintf("      token_ref: \"%s\"\n", ref))
+		} else if w.config.anthropicOAuth != nil {
 			sb.WriteString("    oauth:\n")
 			sb.WriteString(fmt.Sprintf("      access_token: \"%s\"\n", w.config.anthropicOAuth.AccessToken))
 			sb.WriteString(fmt.Sprintf("      refresh_token: \"%s\"\n", w.config.anthropicOAuth.RefreshToken))
@@ -1072,16 +1265,20 @@ func (w *Wizard) generateYAML() string {
 	if w.config.geminiAPIKey != "" || w.config.geminiOAuth != nil {
 		sb.WriteString("  gemini:\n")
 		if w.config.geminiAPIKey != "" {
-			sb.WriteString(fmt.Sprintf("    api_key: \"%s\"\n", w.config.geminiAPIKey))
+			writeAPIKeyYAML(&sb, w.config, "gemini")
 		}
 		if w.config.geminiOAuth != nil {
-			// Write OAuth tokens as flat fields, not nested
-			sb.WriteString(fmt.Sprintf("    access_token: \"%s\"\n", w.config.geminiOAuth.AccessToken))
-			sb.WriteString(fmt.Sprintf("    refresh_token: \"%s\"\n", w.config.geminiOAuth.RefreshToken))
-			// Store token_expiry in RFC3339 format (matches llxprt-code)
-			expiresAt, err := time.Parse(time.RFC3339, w.config.geminiOAuth.ExpiresAt)
-			if err == nil {
-				sb.WriteString(fmt.Sprintf("    token_expiry: \"%s\"\n", expiresAt.Format(time.RFC3339)))
+			if ref := w.config.tokenRef("gemini"); ref != "" {
+				sb.WriteString(fmt.Sprintf("    token_ref: \"%s\"\n", ref))
+			} else {
+				// Write OAuth tokens as flat fields, not nested
+				sb.WriteString(fmt.Sprintf("    access_token: \"%s\"\n", w.config.geminiOAuth.AccessToken))
+				sb.WriteString(fmt.Sprintf("    refresh_token: \"%s\"\n", w.config.geminiOAuth.RefreshToken))
+				// Store token_expiry in RFC3339 format (matches llxprt-code)
+				expiresAt, err := time.Parse(time.RFC3339, w.config.geminiOAuth.ExpiresAt)
+				if err == nil {
+					sb.WriteString(fmt.Sprintf("    token_expiry: \"%s\"\n", expiresAt.Format(time.RFC3339)))
+				}
 			}
 			// Add client credentials from OAuth defaults (official llxprt-code credentials)
 			sb.WriteString(fmt.Sprintf("    client_id: \"%s\"\n", auth.GeminiOAuthClientID))
@@ -1103,9 +1300,12 @@ func (w *Wizard) generateYAML() string {
 	if w.config.qwenAPIKey != "" || w.config.qwenOAuth != nil {
 		sb.WriteString("  qwen:\n")
 		if w.config.qwenAPIKey != "" {
-			sb.WriteString(fmt.Sprintf("    api_key: \"%s\"\n", w.config.qwenAPIKey))
+			writeAPIKeyYAML(&sb, w.config, "qwen")
 		}
-		if w.config.qwenOAuth != nil {
+		if ref := w.config.tokenRef("qwen"); ref != "" {
+			sb.WriteString("    oauth:\n")
+			sb.WriteString(fmt.Sprintf("      token_ref: \"%s\"\n", ref))
+		} else if w.config.qwenOAuth != nil {
 			sb.WriteString("    oauth:\n")
 			sb.WriteString(fmt.Sprintf("      access_token: \"%s\"\n", w.config.qwenOAuth.AccessToken))
 			sb.WriteString(fmt.Sprintf("      refresh_token: \"%s\"\n", w.config.qwenOAuth.RefreshToken))
@@ -1117,7 +1317,36 @@ func (w *Wizard) generateYAML() string {
 		} else {
 			sb.WriteString("    model: \"qwen-max\"\n")
 		}
-		sb.WriteString("    base_url: \"https://dashscope.aliyuncs.com/api/v1\"\n\n")
+		sb.WriteString("    base_url: \"https://dashscope.aliyuncs.com/compatible-mode\"\n\n")
+	}
+
+	// Mistral configuration
+	if w.config.mistralAPIKey != "" {
+		sb.WriteString("  mistral:\n")
+		writeAPIKeyYAML(&sb, w.config, "mistral")
+		if len(w.config.mistralModels) > 0 {
+			sb.WriteString(fmt.Sprintf("    model: \"%s\"\n", w.config.mistralModels[0]))
+		} else {
+			sb.WriteString("    model: \"codestral-latest\"\n")
+		}
+		sb.WriteString("    base_url: \"https://api.mistral.ai/v1\"\n\n")
+	}
+
+	// Synthetic (Hugging Face) configuration
+	if w.config.syntheticAPIKey != "" {
+		sb.WriteString("  synthetic:\n")
+		writeAPIKeyYAML(&sb, w.config, "synthetic")
+		if len(w.config.syntheticModels) > 1 {
+			sb.WriteString("    models:\n")
+			for _, model := range w.config.syntheticModels {
+				sb.WriteString(fmt.Sprintf("      - \"%s\"\n", model))
+			}
+		} else if len(w.config.syntheticModels) == 1 {
+			sb.WriteString(fmt.Sprintf("    model: \"%s\"\n", w.config.syntheticModels[0]))
+		} else {
+			sb.WriteString("    model: \"Qwen/Qwen2.5-Coder-32B-Instruct\"\n")
+		}
+		sb.WriteString("    base_url: \"https://router.huggingface.co/v1\"\n\n")
 	}
 
 	// Provider ordering
@@ -1140,6 +1369,12 @@ func (w *Wizard) generateYAML() string {
 	if w.config.qwenAPIKey != "" || w.config.qwenOAuth != nil {
 		sb.WriteString("    - qwen\n")
 	}
+	if w.config.mistralAPIKey != "" {
+		sb.WriteString("    - mistral\n")
+	}
+	if w.config.syntheticAPIKey != "" {
+		sb.WriteString("    - synthetic\n")
+	}
 	sb.WriteString("\n")
 
 	// Enabled providers
@@ -1162,6 +1397,12 @@ func (w *Wizard) generateYAML() string {
 	if w.config.qwenAPIKey != "" || w.config.qwenOAuth != nil {
 		sb.WriteString("    - qwen\n")
 	}
+	if w.config.mistralAPIKey != "" {
+		sb.WriteString("    - mistral\n")
+	}
+	if w.config.syntheticAPIKey != "" {
+		sb.WriteString("    - synthetic\n")
+	}
 	sb.WriteString("\n")
 
 	// Logging configuration
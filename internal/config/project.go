@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ProjectFileName is the per-repository convention file, found by walking
+// up from the file being written
+const ProjectFileName = ".mcp-code-api.yaml"
+
+// ProjectConfig holds a repository's conventions. They override the global
+// configuration for requests that write files under Root.
+type ProjectConfig struct {
+	Root string `mapstructure:"-"` // Directory holding the convention file
+
+	Model        string                     `mapstructure:"model,omitempty"`           // provider/model tried first, e.g. cerebras/zai-glm-4.6
+	Order        []string                   `mapstructure:"preferred_order,omitempty"` // Replaces providers.preferred_order
+	Profiles     map[string]LanguageProfile `mapstructure:"profiles,omitempty"`        // Merged over the global profiles, keyed by language
+	ContextFiles []string                   `mapstructure:"context_files,omitempty"`   // Files, directories or globs relative to Root, sent with every request
+	Formatters   map[string][]string        `mapstructure:"formatters,omitempty"`      // Formatter commands by language, from the trusted ones; turns formatting on
+	Validation   ProjectValidation          `mapstructure:"validation,omitempty"`
+	Preamble     string                     `mapstructure:"preamble,omitempty"` // Instructions put before every prompt
+}
+
+// ProjectValidation sets how strictly a project's generated code is checked
+type ProjectValidation struct {
+	Always bool   `mapstructure:"always"`            // Validate writes even when the client doesn't ask to
+	GoMode string `mapstructure:"go_mode,omitempty"` // Replaces validation.go_mode
+}
+
+// projectFile is a parsed convention file and the modification time it was
+// parsed at
+type projectFile struct {
+	modTime time.Time
+	project *ProjectConfig
+}
+
+var projectFiles = struct {
+	sync.Mutex
+	parsed map[string]projectFile
+}{parsed: make(map[string]projectFile)}
+
+// FindProjectConfig returns the conventions of the project filePath is in:
+// the nearest ProjectFileName in its directory or one above. It returns nil
+// if there is none.
+func FindProjectConfig(filePath string) (*ProjectConfig, error) {
+	if filePath == "" {
+		return nil, nil
+	}
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	for dir := filepath.Dir(abs); ; {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return loadProjectConfig(path, info.ModTime())
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig parses the convention file at path, reusing the last
+// parse while the file is unchanged
+func loadProjectConfig(path string, modTime time.Time) (*ProjectConfig, error) {
+	projectFiles.Lock()
+	cached, ok := projectFiles.parsed[path]
+	projectFiles.Unlock()
+	if ok && cached.modTime.Equal(modTime) {
+		return cached.project, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	project := &ProjectConfig{}
+	if err := v.Unmarshal(project); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	project.Root = filepath.Dir(path)
+
+	projectFiles.Lock()
+	projectFiles.parsed[path] = projectFile{modTime: modTime, project: project}
+	projectFiles.Unlock()
+	return project, nil
+}
+
+// ContextPaths returns the project's context files with relative entries
+// resolved against Root. Entries may still point outside Root, through an
+// absolute path, ".." or a symlink; callers sending them must check.
+func (p *ProjectConfig) ContextPaths() []string {
+	if p == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(p.ContextFiles))
+	for _, entry := range p.ContextFiles {
+		if !filepath.IsAbs(entry) {
+			entry = filepath.Join(p.Root, entry)
+		}
+		paths = append(paths, filepath.Clean(entry))
+	}
+	return paths
+}
+
+// ApplyValidation returns cfg with the project's validation settings
+func (p *ProjectConfig) ApplyValidation(cfg ValidationConfig) ValidationConfig {
+	if p != nil && p.Validation.GoMode != "" {
+		cfg.GoMode = p.Validation.GoMode
+	}
+	return cfg
+}
@@ -4,10 +4,55 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/requestlog"
 	"github.com/cecil-the-coder/mcp-code-api/internal/types"
 	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
 )
 
+// Diff formats of a write response. Unified and side-by-side diffs are
+// plain text with hunk headers, so they can be piped into review tools.
+const (
+	DiffFormatEmoji      = "emoji"
+	DiffFormatUnified    = "unified"
+	DiffFormatSideBySide = "side-by-side"
+	DiffFormatStatsOnly  = "stats-only"
+)
+
+// ParseDiffFormat checks a diff_format argument; empty means emoji
+func ParseDiffFormat(value string) (string, error) {
+	switch value {
+	case "":
+		return DiffFormatEmoji, nil
+	case DiffFormatEmoji, DiffFormatUnified, DiffFormatSideBySide, DiffFormatStatsOnly:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown diff_format %q (expected unified, side-by-side, emoji or stats-only)", value)
+	}
+}
+
+// FormatDiffResponse formats a create or edit response as just the diff
+// from existingContent, which is empty for a new file, in the given format
+func FormatDiffResponse(format, existingContent, newContent, filePath string) *types.Content {
+	var text string
+	switch format {
+	case DiffFormatUnified:
+		text = requestlog.UnifiedDiff(filePath, existingContent, newContent)
+	case DiffFormatSideBySide:
+		text = requestlog.SideBySideDiff(filePath, existingContent, newContent)
+	case DiffFormatStatsOnly:
+		added, removed := requestlog.DiffStats(existingContent, newContent)
+		text = fmt.Sprintf("%s | +%d -%d\n", filePath, added, removed)
+	}
+	if text == "" {
+		text = fmt.Sprintf("No changes to %s\n", filePath)
+	}
+
+	return &types.Content{
+		Type: "text",
+		Text: text,
+	}
+}
+
 // FormatEditResponse formats an edit response with visual diff
 func FormatEditResponse(fileName, existingContent, newContent, filePath string) *types.Content {
 	// Generate diff between existing and new content
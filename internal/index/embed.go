@@ -0,0 +1,272 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// Embedding providers
+const (
+	EmbeddingLocal  = "local"
+	EmbeddingOpenAI = "openai"
+)
+
+const (
+	defaultDimensions       = 512
+	defaultEmbeddingURL     = "https://api.openai.com/v1"
+	defaultEmbeddingModel   = "text-embedding-3-small"
+	embeddingBatchSize      = 64
+	embeddingRequestTimeout = 60 * time.Second
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects how
+// related the texts are
+type Embedder interface {
+	// Name identifies the embedding model; vectors stored under another name
+	// are recomputed
+	Name() string
+	// Embed returns one unit-length vector per text
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder returns the embedder cfg selects
+func NewEmbedder(cfg config.EmbeddingConfig) (Embedder, error) {
+	switch cfg.Provider {
+	case "", EmbeddingLocal:
+		dimensions := cfg.Dimensions
+		if dimensions <= 0 {
+			dimensions = defaultDimensions
+		}
+		return &HashEmbedder{Dimensions: dimensions}, nil
+	case EmbeddingOpenAI:
+		e := &OpenAIEmbedder{BaseURL: cfg.BaseURL, APIKey: cfg.APIKey, Model: cfg.Model, client: &http.Client{Timeout: embeddingRequestTimeout}}
+		if e.BaseURL == "" {
+			e.BaseURL = defaultEmbeddingURL
+		}
+		if e.APIKey == "" {
+			e.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+		if e.Model == "" {
+			e.Model = defaultEmbeddingModel
+		}
+		return e, nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q (want %s or %s)", cfg.Provider, EmbeddingLocal, EmbeddingOpenAI)
+	}
+}
+
+// CheckPolicy refuses an embedding API when cfg restricts where project
+// files may go: chunks sent for embedding would bypass data-residency
+// policies and the redaction of outbound prompts. The local embedder sends
+// nothing, and an endpoint marked trusted is allowed.
+func CheckPolicy(cfg *config.Config) error {
+	embedding := cfg.Index.Embedding
+	if embedding.Provider == "" || embedding.Provider == EmbeddingLocal || embedding.Trusted {
+		return nil
+	}
+	var policies []string
+	if len(cfg.Residency.Policies) > 0 {
+		policies = append(policies, "data-residency policies")
+	}
+	if cfg.Redaction.Enabled {
+		policies = append(policies, "redaction")
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the %s embedding API would receive project files without %s; use the local embedder or set index.embedding.trusted", embedding.Provider, strings.Join(policies, " or "))
+}
+
+// HashEmbedder embeds text without a model: identifiers are split into
+// their words (camelCase, snake_case), and words and whole identifiers are
+// hashed into a fixed number of dimensions. Texts sharing names score high,
+// which is most of what relates code to a request about it.
+type HashEmbedder struct {
+	Dimensions int
+}
+
+// Name identifies the vectors by their size
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("local-hash-%d", e.Dimensions)
+}
+
+// Embed hashes each text's terms, weighting repeated terms logarithmically
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		counts := make(map[string]int)
+		for _, term := range terms(text) {
+			counts[term]++
+		}
+		vector := make([]float32, e.Dimensions)
+		for term, count := range counts {
+			h := fnv.New64a()
+			h.Write([]byte(term))
+			sum := h.Sum64()
+			weight := float32(1 + math.Log(float64(count)))
+			if sum&(1<<63) != 0 {
+				weight = -weight // A sign bit keeps colliding terms from adding up
+			}
+			vector[sum%uint64(e.Dimensions)] += weight
+		}
+		vectors[i] = normalize(vector)
+	}
+	return vectors, nil
+}
+
+// stopTerms are too common in code and prose to relate two texts
+var stopTerms = map[string]bool{
+	"the": true, "and": true, "for": true, "if": true, "else": true, "return": true, "func": true,
+	"function": true, "def": true, "var": true, "let": true, "const": true, "nil": true, "null": true,
+	"err": true, "to": true, "of": true, "in": true, "is": true, "a": true, "an": true, "it": true,
+	"this": true, "self": true, "true": true, "false": true, "import": true, "package": true,
+}
+
+// terms returns the lowercased identifiers and words of text, identifiers
+// followed by their parts
+func terms(text string) []string {
+	var out []string
+	add := func(term string) {
+		term = strings.ToLower(term)
+		if len(term) > 1 && !stopTerms[term] {
+			out = append(out, term)
+		}
+	}
+	for _, identifier := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		parts := splitIdentifier(identifier)
+		if len(parts) > 1 {
+			add(identifier)
+		}
+		for _, part := range parts {
+			add(part)
+		}
+	}
+	return out
+}
+
+// splitIdentifier splits snake_case and camelCase (keeping acronyms such as
+// HTTP together) into words
+func splitIdentifier(identifier string) []string {
+	var words []string
+	for _, field := range strings.Split(identifier, "_") {
+		runes := []rune(field)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			words = append(words, string(runes[start:]))
+		}
+	}
+	return words
+}
+
+// OpenAIEmbedder calls an OpenAI-compatible /embeddings endpoint
+type OpenAIEmbedder struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	client  *http.Client
+}
+
+// Name is the model the vectors come from
+func (e *OpenAIEmbedder) Name() string {
+	return "openai:" + e.Model
+}
+
+// Embed sends texts in batches
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		batch := texts[start:min(start+embeddingBatchSize, len(texts))]
+		embedded, err := e.embedBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]interface{}{"model": e.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(e.BaseURL, "/")+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding API error: %d - %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var parsed struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding response: %w", err)
+	}
+	if len(parsed.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding API returned %d vectors for %d texts", len(parsed.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, item := range parsed.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding API returned index %d for %d texts", item.Index, len(texts))
+		}
+		vectors[item.Index] = normalize(item.Embedding)
+	}
+	return vectors, nil
+}
+
+// normalize scales v to unit length, so a dot product is the cosine
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
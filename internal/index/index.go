@@ -0,0 +1,393 @@
+// Package index keeps a small on-disk vector store of a project's files, so
+// the snippets most relevant to a request can be sent as context with it.
+// Files are split into chunks of lines and embedded once; later updates
+// only embed the files that changed.
+package index
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+const (
+	defaultTopK       = 5
+	defaultChunkLines = 40
+	defaultMaxFiles   = 5000
+	maxIndexFileSize  = 256 << 10
+	storeVersion      = 1
+)
+
+// defaultExtensions are the source files indexed unless configured otherwise
+var defaultExtensions = []string{
+	".go", ".py", ".js", ".jsx", ".ts", ".tsx", ".rs", ".java", ".kt", ".rb", ".php", ".cs",
+	".c", ".h", ".cc", ".cpp", ".hpp", ".swift", ".scala", ".sh", ".sql", ".proto", ".md",
+}
+
+// skippedDirs hold dependencies and build output rather than the project's code
+var skippedDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "target": true, "dist": true, "build": true, "__pycache__": true,
+}
+
+// rootMarkers are the files marking a project root, nearest first
+var rootMarkers = []string{config.ProjectFileName, ".git", "go.mod", "package.json", "pyproject.toml", "Cargo.toml"}
+
+// Snippet is an indexed chunk of a file that matches a query
+type Snippet struct {
+	Path      string  // Absolute
+	StartLine int     // 1-based, inclusive
+	EndLine   int     // Inclusive
+	Score     float64 // Cosine similarity to the query
+	Text      string
+}
+
+// Stats describes an update
+type Stats struct {
+	Files    int // Files in the index
+	Chunks   int // Chunks in the index
+	Embedded int // Chunks embedded by the update
+	Removed  int // Files dropped because they are gone
+}
+
+// store is the on-disk form of an index
+type store struct {
+	Version int
+	Model   string // Embedder name; vectors from another one are dropped
+	Files   map[string]*fileEntry
+}
+
+// fileEntry holds the chunks of one file, keyed by path relative to the root
+type fileEntry struct {
+	ModTime time.Time
+	Size    int64
+	Chunks  []chunk
+}
+
+type chunk struct {
+	StartLine, EndLine int
+	Vector             []float32
+}
+
+// Index is the vector store of the project under Root
+type Index struct {
+	Root string
+	// Filter, if set, leaves out the files and directories it rejects
+	Filter func(path string) bool
+
+	path       string
+	embedder   Embedder
+	chunkLines int
+	maxFiles   int
+	extensions map[string]bool
+
+	mu     sync.Mutex
+	store  *store
+	loaded bool
+}
+
+// Open returns the index of the project at root, stored under dir. The
+// store is read on first use.
+func Open(cfg config.IndexConfig, embedder Embedder, root string) *Index {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(config.ConfigDir(), "index")
+	}
+	sum := sha256.Sum256([]byte(root))
+	idx := &Index{
+		Root:       root,
+		path:       filepath.Join(config.ExpandPath(dir), hex.EncodeToString(sum[:8])+".gob"),
+		embedder:   embedder,
+		chunkLines: cfg.ChunkLines,
+		maxFiles:   cfg.MaxFiles,
+		extensions: make(map[string]bool),
+	}
+	if idx.chunkLines <= 0 {
+		idx.chunkLines = defaultChunkLines
+	}
+	if idx.maxFiles <= 0 {
+		idx.maxFiles = defaultMaxFiles
+	}
+	extensions := cfg.Extensions
+	if len(extensions) == 0 {
+		extensions = defaultExtensions
+	}
+	for _, ext := range extensions {
+		idx.extensions[strings.ToLower(ext)] = true
+	}
+	return idx
+}
+
+// Update embeds the files added or changed since the last update and drops
+// the ones removed, then saves the store
+func (idx *Index) Update(ctx context.Context) (Stats, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.load()
+
+	var stats Stats
+	type pending struct {
+		rel   string
+		entry *fileEntry
+		texts []string
+	}
+	var changed []pending
+	seen := make(map[string]bool)
+
+	err := filepath.WalkDir(idx.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Unreadable entries are left out
+		}
+		if d.IsDir() {
+			if path != idx.Root && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()] || (idx.Filter != nil && !idx.Filter(path))) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !idx.extensions[strings.ToLower(filepath.Ext(path))] || !d.Type().IsRegular() {
+			return nil
+		}
+		if idx.Filter != nil && !idx.Filter(path) {
+			return nil
+		}
+		if len(seen) >= idx.maxFiles {
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxIndexFileSize {
+			return nil
+		}
+		rel, err := filepath.Rel(idx.Root, path)
+		if err != nil {
+			return nil
+		}
+		seen[rel] = true
+		if entry, ok := idx.store.Files[rel]; ok && entry.ModTime.Equal(info.ModTime()) && entry.Size == info.Size() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			return nil
+		}
+		entry := &fileEntry{ModTime: info.ModTime(), Size: info.Size()}
+		var texts []string
+		for _, c := range chunkLines(string(data), idx.chunkLines) {
+			entry.Chunks = append(entry.Chunks, chunk{StartLine: c.start, EndLine: c.end})
+			texts = append(texts, rel+"\n"+c.text) // The path is part of what a chunk is about
+		}
+		changed = append(changed, pending{rel: rel, entry: entry, texts: texts})
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to walk %s: %w", idx.Root, err)
+	}
+
+	for rel := range idx.store.Files {
+		if !seen[rel] {
+			delete(idx.store.Files, rel)
+			stats.Removed++
+		}
+	}
+
+	var texts []string
+	for _, p := range changed {
+		texts = append(texts, p.texts...)
+	}
+	if len(texts) > 0 {
+		vectors, err := idx.embedder.Embed(ctx, texts)
+		if err != nil {
+			return stats, fmt.Errorf("failed to embed %d chunk(s): %w", len(texts), err)
+		}
+		next := 0
+		for _, p := range changed {
+			for i := range p.entry.Chunks {
+				p.entry.Chunks[i].Vector = vectors[next]
+				next++
+			}
+			idx.store.Files[p.rel] = p.entry
+		}
+		stats.Embedded = len(texts)
+	}
+
+	for _, entry := range idx.store.Files {
+		stats.Chunks += len(entry.Chunks)
+	}
+	stats.Files = len(idx.store.Files)
+
+	if len(changed) > 0 || stats.Removed > 0 {
+		if err := idx.save(); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// Search returns the k chunks most similar to query, best first, leaving
+// out the files in exclude (absolute paths) and chunks scoring below
+// minScore. The text of each snippet is read from the file.
+func (idx *Index) Search(ctx context.Context, query string, k int, minScore float64, exclude []string) ([]Snippet, error) {
+	if k <= 0 {
+		k = defaultTopK
+	}
+	vectors, err := idx.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the query: %w", err)
+	}
+	queryVector := vectors[0]
+
+	excluded := make(map[string]bool)
+	for _, path := range exclude {
+		if abs, err := filepath.Abs(path); err == nil {
+			excluded[abs] = true
+		}
+	}
+
+	idx.mu.Lock()
+	idx.load()
+	var matches []Snippet
+	for rel, entry := range idx.store.Files {
+		path := filepath.Join(idx.Root, rel)
+		if excluded[path] {
+			continue
+		}
+		for _, c := range entry.Chunks {
+			score := dot(queryVector, c.Vector)
+			if score > 0 && score >= minScore {
+				matches = append(matches, Snippet{Path: path, StartLine: c.StartLine, EndLine: c.EndLine, Score: score})
+			}
+		}
+	}
+	idx.mu.Unlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Path < matches[j].Path
+	})
+	var snippets []Snippet
+	for _, match := range matches {
+		if len(snippets) == k {
+			break
+		}
+		data, err := os.ReadFile(match.Path)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		if match.StartLine > len(lines) {
+			continue
+		}
+		match.Text = strings.Join(lines[match.StartLine-1:min(match.EndLine, len(lines))], "\n")
+		snippets = append(snippets, match)
+	}
+	return snippets, nil
+}
+
+// load reads the store, starting empty if there is none or it was built by
+// another embedder or version
+func (idx *Index) load() {
+	if idx.loaded {
+		return
+	}
+	idx.loaded = true
+	idx.store = &store{Version: storeVersion, Model: idx.embedder.Name(), Files: make(map[string]*fileEntry)}
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		return
+	}
+	var stored store
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&stored); err != nil {
+		logger.Warnf("Index: rebuilding %s: %v", idx.path, err)
+		return
+	}
+	if stored.Version != storeVersion || stored.Model != idx.embedder.Name() || stored.Files == nil {
+		logger.Infof("Index: rebuilding the index of %s for %s", idx.Root, idx.embedder.Name())
+		return
+	}
+	idx.store = &stored
+}
+
+// save writes the store atomically
+func (idx *Index) save() error {
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0700); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(idx.store); err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return os.Rename(tmp, idx.path)
+}
+
+// lineChunk is a run of lines of a file
+type lineChunk struct {
+	start, end int
+	text       string
+}
+
+// chunkLines splits content into runs of size lines, leaving out runs that
+// are only whitespace
+func chunkLines(content string, size int) []lineChunk {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	var chunks []lineChunk
+	for start := 0; start < len(lines); start += size {
+		end := min(start+size, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		chunks = append(chunks, lineChunk{start: start + 1, end: end, text: text})
+	}
+	return chunks
+}
+
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// RootFor returns the root of the project filePath is in: the nearest
+// directory above it holding one of rootMarkers, or the file's directory
+func RootFor(filePath string) string {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return filepath.Dir(filePath)
+	}
+	start := filepath.Dir(abs)
+	for dir := start; ; {
+		for _, marker := range rootMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return start
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,168 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := map[string][]string{
+		"parseHTTPResponse": {"parse", "HTTP", "Response"},
+		"rate_limit_wait":   {"rate", "limit", "wait"},
+		"RetryPolicy":       {"Retry", "Policy"},
+		"url":               {"url"},
+	}
+	for identifier, want := range tests {
+		if got := splitIdentifier(identifier); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitIdentifier(%q) = %q, want %q", identifier, got, want)
+		}
+	}
+}
+
+// writeFiles creates files under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIndexUpdateAndSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"retry/backoff.go":          "package retry\n\n// Backoff returns the exponential backoff delay before a retry attempt\nfunc Backoff(attempt int) time.Duration {\n\treturn time.Second << attempt\n}\n",
+		"cache/cache.go":            "package cache\n\n// Get returns the cached response for key\nfunc (c *Cache) Get(key string) ([]byte, bool) {\n\treturn c.entries[key]\n}\n",
+		"node_modules/dep/index.js": "function backoff(attempt) { return attempt }\n",
+		"notes.txt":                 "exponential backoff retry attempt\n",
+	})
+	cfg := config.IndexConfig{Dir: t.TempDir()}
+	embedder, _ := NewEmbedder(config.EmbeddingConfig{})
+	idx := Open(cfg, embedder, root)
+
+	stats, err := idx.Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 2 || stats.Embedded != 2 {
+		t.Errorf("first update = %+v, want the 2 Go files embedded", stats)
+	}
+
+	snippets, err := idx.Search(context.Background(), "add jitter to the exponential backoff between retry attempts", 1, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets) != 1 || snippets[0].Path != filepath.Join(root, "retry/backoff.go") || !strings.Contains(snippets[0].Text, "func Backoff") {
+		t.Fatalf("Search() = %+v, want backoff.go", snippets)
+	}
+	excluded, _ := idx.Search(context.Background(), "exponential backoff retry", 1, 0, []string{filepath.Join(root, "retry/backoff.go")})
+	if len(excluded) == 1 && excluded[0].Path == filepath.Join(root, "retry/backoff.go") {
+		t.Error("Search() returned an excluded file")
+	}
+
+	// A fresh index of the same root reads the store and only embeds changes
+	writeFiles(t, root, map[string]string{"cache/evict.go": "package cache\n\nfunc (c *Cache) Evict(key string) {}\n"})
+	if err := os.Remove(filepath.Join(root, "retry/backoff.go")); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = Open(cfg, embedder, root).Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 2 || stats.Embedded != 1 || stats.Removed != 1 {
+		t.Errorf("second update = %+v, want evict.go embedded and backoff.go removed", stats)
+	}
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		// Answer out of order, as the API may
+		var data []string
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			vector := []float32{0, 0, 0}
+			vector[i] = 2
+			embedding, _ := json.Marshal(vector)
+			data = append(data, fmt.Sprintf(`{"index":%d,"embedding":%s}`, i, embedding))
+		}
+		fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(data, ","))
+	}))
+	defer server.Close()
+
+	embedder, err := NewEmbedder(config.EmbeddingConfig{Provider: EmbeddingOpenAI, BaseURL: server.URL + "/v1", APIKey: "secret", Model: "nomic-embed-text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embedder.Name() != "openai:nomic-embed-text" {
+		t.Errorf("Name() = %q", embedder.Name())
+	}
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vectors, [][]float32{{1, 0, 0}, {0, 1, 0}}) {
+		t.Errorf("Embed() = %v, want unit vectors in input order", vectors)
+	}
+}
+
+func TestIndexFilter(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"retry.go":          "package main\n\nfunc retryWithBackoff() {}\n",
+		"secrets/tokens.go": "package secrets\n\nconst retryToken = \"x\"\n",
+	})
+	embedder, _ := NewEmbedder(config.EmbeddingConfig{})
+	idx := Open(config.IndexConfig{Dir: t.TempDir()}, embedder, root)
+	idx.Filter = func(path string) bool { return filepath.Base(path) != "secrets" }
+	stats, err := idx.Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 1 {
+		t.Errorf("files = %d, want the denied directory left out", stats.Files)
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		wantFail bool
+	}{
+		{"local with policies", config.Config{Residency: config.ResidencyConfig{Policies: []config.ResidencyPolicyConfig{{Path: "/work"}}}}, false},
+		{"api without policies", config.Config{Index: config.IndexConfig{Embedding: config.EmbeddingConfig{Provider: EmbeddingOpenAI}}}, false},
+		{"api with residency", config.Config{Index: config.IndexConfig{Embedding: config.EmbeddingConfig{Provider: EmbeddingOpenAI}}, Residency: config.ResidencyConfig{Policies: []config.ResidencyPolicyConfig{{Path: "/work"}}}}, true},
+		{"api with redaction", config.Config{Index: config.IndexConfig{Embedding: config.EmbeddingConfig{Provider: EmbeddingOpenAI}}, Redaction: config.RedactionConfig{Enabled: true}}, true},
+		{"trusted api with redaction", config.Config{Index: config.IndexConfig{Embedding: config.EmbeddingConfig{Provider: EmbeddingOpenAI, Trusted: true}}, Redaction: config.RedactionConfig{Enabled: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckPolicy(&tt.cfg); (err != nil) != tt.wantFail {
+				t.Errorf("CheckPolicy() = %v, want failure: %v", err, tt.wantFail)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package index
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// Manager keeps the indexes of the projects requests write to
+type Manager struct {
+	cfg      config.IndexConfig
+	embedder Embedder
+	// Filter, if set, leaves out the files and directories it rejects, so
+	// they are neither embedded nor retrieved
+	Filter func(path string) bool
+
+	mu      sync.Mutex
+	indexes map[string]*Index // Keyed by project root
+}
+
+// NewManager returns the manager cfg configures, or nil if indexing is off
+func NewManager(cfg config.IndexConfig) (*Manager, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	embedder, err := NewEmbedder(cfg.Embedding)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{cfg: cfg, embedder: embedder, indexes: make(map[string]*Index)}, nil
+}
+
+// Embedder returns the embedder the indexes use
+func (m *Manager) Embedder() Embedder {
+	return m.embedder
+}
+
+// For returns the index of the project at root
+func (m *Manager) For(root string) *Index {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	idx, ok := m.indexes[root]
+	if !ok {
+		idx = Open(m.cfg, m.embedder, root)
+		idx.Filter = m.Filter
+		m.indexes[root] = idx
+	}
+	return idx
+}
+
+// Retrieve brings the index of filePath's project up to date and returns the
+// snippets most relevant to query, leaving out filePath and the files in
+// exclude, which the request already sends
+func (m *Manager) Retrieve(ctx context.Context, filePath, query string, exclude []string) ([]Snippet, error) {
+	idx := m.For(RootFor(filePath))
+	stats, err := idx.Update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Embedded > 0 || stats.Removed > 0 {
+		logger.Infof("Index: %s: embedded %d chunk(s), removed %d file(s); %d file(s) indexed", idx.Root, stats.Embedded, stats.Removed, stats.Files)
+	}
+	return idx.Search(ctx, query, m.cfg.TopK, m.cfg.MinScore, append([]string{filePath}, exclude...))
+}
+
+type autoContextKey struct{}
+
+// WithAutoContext returns a context whose requests do or don't get
+// retrieved snippets, overriding index.auto_context
+func WithAutoContext(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, autoContextKey{}, enabled)
+}
+
+// AutoContext reports whether the request in ctx gets retrieved snippets:
+// as set by WithAutoContext, or configured
+func AutoContext(ctx context.Context, configured bool) bool {
+	if enabled, ok := ctx.Value(autoContextKey{}).(bool); ok {
+		return enabled
+	}
+	return configured
+}
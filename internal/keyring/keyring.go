@@ -0,0 +1,148 @@
+// Package keyring keeps API keys and OAuth tokens in the operating system's
+// credential store instead of the config file. The config refers to a
+// stored secret as "keyring:<account>", for example
+//
+//	api_key_ref: keyring:cerebras
+package keyring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Service is the name secrets are stored under in the credential store
+const Service = "mcp-code-api"
+
+// refPrefix marks a config value that names a credential store entry
+const refPrefix = "keyring:"
+
+var (
+	// ErrNotFound is returned when the credential store has no such entry
+	ErrNotFound = errors.New("secret not found in credential store")
+
+	// ErrUnsupported is returned when no credential store is available
+	ErrUnsupported = errors.New("no OS credential store available")
+)
+
+// Backend is a credential store
+type Backend interface {
+	// Name describes the store, like "macOS Keychain"
+	Name() string
+	// Available reports whether the store can be used on this system
+	Available() bool
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// backend is the platform's credential store; tests replace it
+var backend Backend = platformBackend()
+
+// Name returns the credential store's name
+func Name() string {
+	return backend.Name()
+}
+
+// Available reports whether secrets can be stored on this system
+func Available() bool {
+	return backend.Available()
+}
+
+// Get returns the secret stored for account
+func Get(account string) (string, error) {
+	if !backend.Available() {
+		return "", ErrUnsupported
+	}
+	return backend.Get(Service, account)
+}
+
+// Set stores secret for account, replacing any previous value
+func Set(account, secret string) error {
+	if !backend.Available() {
+		return ErrUnsupported
+	}
+	return backend.Set(Service, account, secret)
+}
+
+// Delete removes the secret stored for account
+func Delete(account string) error {
+	if !backend.Available() {
+		return ErrUnsupported
+	}
+	return backend.Delete(Service, account)
+}
+
+// Ref returns the config value referring to account
+func Ref(account string) string {
+	return refPrefix + account
+}
+
+// IsRef reports whether value refers to a credential store entry
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, refPrefix)
+}
+
+// Account returns the account a "keyring:<account>" reference names
+func Account(ref string) (string, error) {
+	account, ok := strings.CutPrefix(ref, refPrefix)
+	if !ok || account == "" {
+		return "", fmt.Errorf("invalid credential reference %q: want %s<name>", ref, refPrefix)
+	}
+	return account, nil
+}
+
+// Resolve returns the secret a "keyring:<account>" reference points to
+func Resolve(ref string) (string, error) {
+	account, err := Account(ref)
+	if err != nil {
+		return "", err
+	}
+	secret, err := Get(account)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	return secret, nil
+}
+
+// Token is an OAuth login as kept in the credential store, JSON encoded
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// ResolveToken returns the OAuth token a "keyring:<account>" reference
+// points to
+func ResolveToken(ref string) (*Token, error) {
+	data, err := Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	var token Token
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, fmt.Errorf("%s: invalid token: %w", ref, err)
+	}
+	return &token, nil
+}
+
+// SetToken stores an OAuth token for account
+func SetToken(account string, token *Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return Set(account, string(data))
+}
+
+// unsupported is the backend for systems without a credential store
+type unsupported struct{}
+
+func (unsupported) Name() string                    { return "none" }
+func (unsupported) Available() bool                 { return false }
+func (unsupported) Get(_, _ string) (string, error) { return "", ErrUnsupported }
+func (unsupported) Set(_, _, _ string) error        { return ErrUnsupported }
+func (unsupported) Delete(_, _ string) error        { return ErrUnsupported }
@@ -0,0 +1,86 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// memoryBackend is an in-process credential store for tests
+type memoryBackend map[string]string
+
+func (memoryBackend) Name() string    { return "memory" }
+func (memoryBackend) Available() bool { return true }
+
+func (m memoryBackend) Get(service, account string) (string, error) {
+	secret, ok := m[service+"/"+account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (m memoryBackend) Set(service, account, secret string) error {
+	m[service+"/"+account] = secret
+	return nil
+}
+
+func (m memoryBackend) Delete(service, account string) error {
+	delete(m, service+"/"+account)
+	return nil
+}
+
+func useMemory(t *testing.T) {
+	old := backend
+	backend = memoryBackend{}
+	t.Cleanup(func() { backend = old })
+}
+
+func TestResolve(t *testing.T) {
+	useMemory(t)
+	if err := Set("cerebras", "csk-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := Ref("cerebras")
+	if ref != "keyring:cerebras" || !IsRef(ref) {
+		t.Fatalf("Ref() = %q", ref)
+	}
+	if secret, err := Resolve(ref); err != nil || secret != "csk-secret" {
+		t.Errorf("Resolve(%q) = %q, %v", ref, secret, err)
+	}
+	if _, err := Resolve("keyring:missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := Resolve("vault:cerebras"); err == nil {
+		t.Error("Resolve() accepted a reference without the keyring: prefix")
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	old := backend
+	backend = unsupported{}
+	t.Cleanup(func() { backend = old })
+
+	if Available() {
+		t.Error("Available() = true")
+	}
+	if _, err := Resolve("keyring:cerebras"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Resolve() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	useMemory(t)
+	want := &Token{AccessToken: "ya29", RefreshToken: "1//r", Expiry: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := SetToken("gemini-oauth", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ResolveToken("keyring:gemini-oauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken || !got.Expiry.Equal(want.Expiry) {
+		t.Errorf("ResolveToken() = %+v, want %+v", got, want)
+	}
+}
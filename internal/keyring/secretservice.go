@@ -0,0 +1,58 @@
+//go:build linux || dragonfly || freebsd || netbsd || openbsd
+
+package keyring
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// secretService stores secrets through the freedesktop Secret Service
+// (GNOME Keyring, KWallet) using libsecret's secret-tool
+type secretService struct{}
+
+func platformBackend() Backend {
+	return secretService{}
+}
+
+func (secretService) Name() string {
+	return "Secret Service"
+}
+
+func (secretService) Available() bool {
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
+
+func (secretService) Get(service, account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits 1 without a message when nothing matches
+		if stderr.Len() == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secret service lookup failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func (secretService) Set(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", service+": "+account, "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("secret service update failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (secretService) Delete(service, account string) error {
+	if out, err := exec.Command("secret-tool", "clear", "service", service, "account", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("secret service delete failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !(darwin || windows || linux || dragonfly || freebsd || netbsd || openbsd)
+
+package keyring
+
+func platformBackend() Backend {
+	return unsupported{}
+}
@@ -53,6 +53,38 @@ func SetLogFile(filename string) error {
 	return nil
 }
 
+// RotateLogFile moves the log file to <file>.1, replacing an earlier one, and
+// starts a new file once it has grown past maxSize bytes. It reports whether
+// the file was rotated.
+func RotateLogFile(maxSize int64) (bool, error) {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+
+	if logFile == nil || maxSize <= 0 {
+		return false, nil
+	}
+	info, err := logFile.Stat()
+	if err != nil || info.Size() <= maxSize {
+		return false, err
+	}
+
+	filename := logFile.Name()
+	logFile.Close()
+	logFile = nil
+	renameErr := os.Rename(filename, filename+".1")
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		onlyStderr = true
+		return false, fmt.Errorf("failed to reopen log file: %w", err)
+	}
+	logFile = file
+	if renameErr != nil {
+		return false, fmt.Errorf("failed to rotate log file: %w", renameErr)
+	}
+	return true, nil
+}
+
 // SetVerbose enables verbose logging
 func SetVerbose(v bool) {
 	verbose = v
@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"os"
+	"os/user"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/audit"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// recordAudit appends a file modification to the audit log, if enabled.
+// A failure to audit is logged but does not fail the write, which has
+// already happened.
+func (s *Server) recordAudit(operation, filePath, prompt, before, after string, info *router.GenerationInfo) {
+	if s.audit == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		Operation: operation,
+		FilePath:  filePath,
+		Checksum:  utils.ContentChecksum(after),
+		Diff:      audit.SummarizeDiff(before, after),
+		Caller:    s.caller(),
+	}
+	if prompt != "" {
+		entry.PromptSHA256 = utils.ContentChecksum(prompt)
+	}
+	if info != nil {
+		entry.Provider = info.Provider
+		entry.Model = info.Model
+		entry.Cached = info.Cached
+	}
+	if current, err := user.Current(); err == nil {
+		entry.User = current.Username
+	}
+
+	if err := s.audit.Record(entry); err != nil {
+		logger.Errorf("Failed to record audit entry for %s: %v", filePath, err)
+	}
+}
+
+// caller describes the MCP client and IDE that issued the request
+func (s *Server) caller() string {
+	caller := s.clientInfo
+	if ide := os.Getenv("CEREBRAS_MCP_IDE"); ide != "" {
+		if caller != "" {
+			caller += " "
+		}
+		caller += "(ide: " + ide + ")"
+	}
+	return caller
+}
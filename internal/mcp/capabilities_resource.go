@@ -0,0 +1,35 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// capabilitiesResourceURI is the MCP resource exposing the provider capability matrix
+const capabilitiesResourceURI = "capabilities://providers"
+
+// readCapabilitiesResource returns the live capability matrix as JSON so
+// clients can pick a provider/model for a task
+func (s *Server) readCapabilitiesResource(request *Request) (*Response, error) {
+	return jsonResource(request, capabilitiesResourceURI, s.router.GetCapabilities())
+}
+
+// jsonResource returns v as the JSON content of the resource uri
+func jsonResource(request *Request, uri string, v interface{}) (*Response, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", uri, err)
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"contents": []map[string]interface{}{{
+				"uri":      uri,
+				"mimeType": "application/json",
+				"text":     string(data),
+			}},
+		},
+	}, nil
+}
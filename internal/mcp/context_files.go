@@ -0,0 +1,194 @@
+package mcp
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+)
+
+// expandContextFiles expands directories and glob patterns in context_files
+// ("internal/api/**/*.go", where ** matches any number of directories) into
+// the files they contain. Plain file paths pass through unchanged. Matched
+// files are skipped if they are hidden, outside the workspace, denied, binary
+// or larger than context_files.max_file_size; more than
+// context_files.max_files in total is an error.
+func (s *Server) expandContextFiles(entries []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, entry := range entries {
+		var matches []string
+		var err error
+		if strings.ContainsAny(entry, "*?[") {
+			matches, err = globFiles(entry)
+		} else if info, statErr := os.Stat(entry); statErr == nil && info.IsDir() {
+			matches, err = globFiles(filepath.Join(entry, "**"))
+		} else {
+			add(entry)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid context_files entry %q: %w", entry, err)
+		}
+
+		kept := 0
+		for _, path := range matches {
+			if reason := s.skipContextFile(path); reason != "" {
+				logger.Debugf("Context files: skipping %s (%s)", path, reason)
+				continue
+			}
+			add(path)
+			kept++
+		}
+		if kept == 0 {
+			logger.Warnf("Context files: %q matched no usable files", entry)
+		}
+	}
+
+	if max := s.config.ContextFiles.MaxFiles; max > 0 && len(files) > max {
+		return nil, fmt.Errorf("context_files expands to %d files, more than the limit of %d; narrow the patterns or raise context_files.max_files", len(files), max)
+	}
+	return files, nil
+}
+
+// skipContextFile returns why a file matched by a pattern should not be sent
+// as context, or "" to keep it
+func (s *Server) skipContextFile(path string) string {
+	if allowed := s.config.Workspace.AllowedPaths; len(allowed) > 0 && !policy.IsPathWithin(path, allowed) {
+		return "outside the workspace"
+	}
+	if pattern, denied := policy.MatchDeniedPath(path, s.config.Workspace.DeniedPaths); denied && pattern == "" {
+		return "could not be resolved to check the denied paths"
+	} else if denied {
+		return fmt.Sprintf("denied by %q", pattern)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err.Error()
+	}
+	if max := s.config.ContextFiles.MaxFileSize; max > 0 && info.Size() > max {
+		return fmt.Sprintf("%d bytes, over the %d byte limit", info.Size(), max)
+	}
+
+	// Binary files would only waste the model's context
+	head := make([]byte, 8000)
+	f, err := os.Open(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer f.Close()
+	n, _ := f.Read(head)
+	if bytes.IndexByte(head[:n], 0) >= 0 {
+		return "binary"
+	}
+	return ""
+}
+
+// globFiles returns the regular files matching pattern in lexical order.
+// Hidden files and directories, and node_modules, are only matched when the
+// pattern names them explicitly.
+func globFiles(pattern string) ([]string, error) {
+	pattern = filepath.Clean(pattern)
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+
+	// Walk from the longest leading part of the pattern without wildcards
+	base := 0
+	for base < len(segments)-1 && !strings.ContainsAny(segments[base], "*?[") {
+		base++
+	}
+	root := strings.Join(segments[:base], "/")
+	switch {
+	case root == "" && strings.HasPrefix(pattern, "/"):
+		root = "/"
+	case root == "":
+		root = "."
+	}
+	segments = segments[base:]
+	for _, segment := range segments {
+		if _, err := filepath.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == filepath.FromSlash(root) {
+				return err
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		relSegments := strings.Split(filepath.ToSlash(rel), "/")
+
+		if d.IsDir() {
+			if (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") && !namedInPattern(d.Name(), segments) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && !namedInPattern(d.Name(), segments) {
+			return nil
+		}
+		if d.Type().IsRegular() && matchSegments(segments, relSegments) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// namedInPattern reports whether a pattern segment starts like name, so
+// ".github/**" still reaches .github
+func namedInPattern(name string, segments []string) bool {
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ".") {
+			if ok, _ := filepath.Match(segment, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, where "**"
+// matches zero or more segments
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
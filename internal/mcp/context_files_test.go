@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestExpandContextFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"internal/api/client.go":        "package api",
+		"internal/api/router/router.go": "package router",
+		"internal/api/router/notes.md":  "notes",
+		"internal/api/.hidden/x.go":     "package hidden",
+		"internal/api/blob.go":          "package api\x00",
+		"internal/api/big.go":           "package api // " + strings.Repeat("x", 100),
+		"internal/api/.env":             "SECRET=1",
+		"README.md":                     "readme",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &Server{config: &config.Config{
+		Workspace:    config.WorkspaceConfig{DeniedPaths: config.DefaultDeniedPaths},
+		ContextFiles: config.ContextFilesConfig{MaxFiles: 10, MaxFileSize: 64},
+	}}
+
+	got, err := s.expandContextFiles([]string{
+		filepath.Join(root, "internal", "api", "**", "*.go"),
+		filepath.Join(root, "README.md"),
+		filepath.Join(root, "internal", "api", "router"),
+	})
+	if err != nil {
+		t.Fatalf("expandContextFiles() error = %v", err)
+	}
+	want := []string{
+		filepath.Join(root, "internal", "api", "client.go"),
+		filepath.Join(root, "internal", "api", "router", "router.go"),
+		filepath.Join(root, "README.md"),
+		filepath.Join(root, "internal", "api", "router", "notes.md"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandContextFiles() = %v, want %v", got, want)
+	}
+
+	s.config.ContextFiles.MaxFiles = 1
+	if _, err := s.expandContextFiles([]string{filepath.Join(root, "internal")}); err == nil {
+		t.Error("expandContextFiles() exceeded max_files without an error")
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"**/*.go", "a.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"a/**/c.go", "a/c.go", true},
+		{"a/**/c.go", "a/b/d/c.go", true},
+		{"a/*/c.go", "a/b/d/c.go", false},
+		{"*.go", "a/b.go", false},
+	}
+	for _, tt := range tests {
+		if got := matchSegments(strings.Split(tt.pattern, "/"), strings.Split(tt.path, "/")); got != tt.want {
+			t.Errorf("matchSegments(%s, %s) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+)
+
+const (
+	// contextResourceScheme is the URI scheme used for context snapshots
+	contextResourceScheme = "context://"
+	// maxContextSnapshots bounds how many write requests keep their context in memory
+	maxContextSnapshots = 20
+	// defaultResourceChunkSize is the page size for resources/read when no length is given
+	defaultResourceChunkSize = 256 * 1024
+)
+
+// ContextSnapshot is a copy of one context file as it was sent to a provider,
+// after reduction, guarding and redaction
+type ContextSnapshot struct {
+	URI      string
+	Path     string
+	Content  string
+	Captured time.Time
+}
+
+// ContextSnapshotStore keeps the context files used by recent write requests so
+// clients can inspect them later through MCP resources
+type ContextSnapshotStore struct {
+	mutex     sync.RWMutex
+	sequence  int
+	order     []int
+	snapshots map[int][]*ContextSnapshot
+}
+
+// NewContextSnapshotStore creates an empty snapshot store
+func NewContextSnapshotStore() *ContextSnapshotStore {
+	return &ContextSnapshotStore{
+		snapshots: make(map[int][]*ContextSnapshot),
+	}
+}
+
+// sentContext keeps the context files of a request's latest provider attempt
+// as they were sent
+type sentContext struct {
+	mutex sync.Mutex
+	files []api.SentContextFile
+}
+
+// withSentContext returns a context recording the context files sent to
+// providers in the returned sentContext
+func withSentContext(ctx context.Context) (context.Context, *sentContext) {
+	sent := &sentContext{}
+	return api.WithContextSent(ctx, func(files []api.SentContextFile) {
+		sent.mutex.Lock()
+		defer sent.mutex.Unlock()
+		sent.files = files
+	}), sent
+}
+
+// Files returns the context files of the latest attempt
+func (s *sentContext) Files() []api.SentContextFile {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.files
+}
+
+// Capture snapshots the context files as they were sent and returns their
+// resource URIs
+func (c *ContextSnapshotStore) Capture(files []api.SentContextFile) []string {
+	if len(files) == 0 {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.sequence++
+	id := c.sequence
+	now := time.Now()
+
+	var uris []string
+	var snapshots []*ContextSnapshot
+	for i, file := range files {
+		uri := fmt.Sprintf("%s%d/%d/%s", contextResourceScheme, id, i, filepath.Base(file.Path))
+		snapshots = append(snapshots, &ContextSnapshot{
+			URI:      uri,
+			Path:     file.Path,
+			Content:  file.Text,
+			Captured: now,
+		})
+		uris = append(uris, uri)
+	}
+
+	c.snapshots[id] = snapshots
+	c.order = append(c.order, id)
+	for len(c.order) > maxContextSnapshots {
+		delete(c.snapshots, c.order[0])
+		c.order = c.order[1:]
+	}
+	return uris
+}
+
+// List returns all retained snapshots, newest request first
+func (c *ContextSnapshotStore) List() []*ContextSnapshot {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var result []*ContextSnapshot
+	for i := len(c.order) - 1; i >= 0; i-- {
+		result = append(result, c.snapshots[c.order[i]]...)
+	}
+	return result
+}
+
+// Get looks up a snapshot by URI
+func (c *ContextSnapshotStore) Get(uri string) (*ContextSnapshot, bool) {
+	var id, index int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(uri, contextResourceScheme), "%d/%d/", &id, &index); err != nil {
+		return nil, false
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for _, snapshot := range c.snapshots[id] {
+		if snapshot.URI == uri {
+			return snapshot, true
+		}
+	}
+	return nil, false
+}
+
+var globalContextStore *ContextSnapshotStore
+
+func init() {
+	globalContextStore = NewContextSnapshotStore()
+}
+
+// handleListResources handles the resources/list request
+func (s *Server) handleListResources(ctx context.Context, request *Request) (*Response, error) {
+	resources := []map[string]interface{}{{
+		"uri":         capabilitiesResourceURI,
+		"name":        "Provider capabilities",
+		"description": "Capability matrix of the configured providers and models",
+		"mimeType":    "application/json",
+	}}
+	resources = append(resources, statusResources()...)
+	for _, diff := range globalDiffStore.List() {
+		resources = append(resources, map[string]interface{}{
+			"uri":         diff.URI,
+			"name":        diff.Path,
+			"description": fmt.Sprintf("Diff of the write at %s, left out of the response", diff.Captured.Format(time.RFC3339)),
+			"mimeType":    "text/x-diff",
+			"size":        len(diff.Text),
+		})
+	}
+	for _, snapshot := range globalContextStore.List() {
+		resources = append(resources, map[string]interface{}{
+			"uri":         snapshot.URI,
+			"name":        snapshot.Path,
+			"description": fmt.Sprintf("Context file sent to the provider at %s", snapshot.Captured.Format(time.RFC3339)),
+			"mimeType":    "text/plain",
+			"size":        len(snapshot.Content),
+		})
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"resources": resources,
+		},
+	}, nil
+}
+
+// handleReadResource handles the resources/read request. In addition to the
+// standard uri parameter it accepts optional byte offset/length so large
+// snapshots and diffs can be fetched in chunks; the result carries nextOffset until the
+// end of the content is reached.
+func (s *Server) handleReadResource(ctx context.Context, request *Request) (*Response, error) {
+	var params struct {
+		URI    string `json:"uri"`
+		Offset int    `json:"offset"`
+		Length int    `json:"length"`
+	}
+	if err := s.unmarshalParams(request.Params, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse resource read parameters: %w", err)
+	}
+
+	switch params.URI {
+	case capabilitiesResourceURI:
+		return s.readCapabilitiesResource(request)
+	case providerStatusResourceURI:
+		return s.readProviderStatusResource(request)
+	case metricsSummaryResourceURI:
+		return s.readMetricsSummaryResource(request)
+	}
+
+	uri, mimeType := params.URI, "text/plain"
+	var content string
+	if diff, ok := globalDiffStore.Get(params.URI); ok {
+		content, mimeType = diff.Text, "text/x-diff"
+	} else if snapshot, ok := globalContextStore.Get(params.URI); ok {
+		content = snapshot.Content
+	} else {
+		return nil, fmt.Errorf("resource not found: %s", params.URI)
+	}
+
+	total := len(content)
+	if params.Offset < 0 || params.Offset > total {
+		return nil, fmt.Errorf("offset %d out of range (size %d)", params.Offset, total)
+	}
+
+	length := params.Length
+	if length <= 0 {
+		length = defaultResourceChunkSize
+	}
+
+	start := alignToRune(content, params.Offset)
+	end := start + length
+	if end >= total {
+		end = total
+	} else {
+		end = alignToRune(content, end)
+		if end <= start {
+			// Chunk smaller than one rune - return the whole rune
+			_, size := utf8.DecodeRuneInString(content[start:])
+			end = start + size
+		}
+	}
+
+	result := map[string]interface{}{
+		"contents": []map[string]interface{}{{
+			"uri":      uri,
+			"mimeType": mimeType,
+			"text":     content[start:end],
+		}},
+		"offset":    start,
+		"totalSize": total,
+	}
+	if end < total {
+		result["nextOffset"] = end
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  result,
+	}, nil
+}
+
+// alignToRune moves offset back to the start of the UTF-8 sequence it falls in
+func alignToRune(s string, offset int) int {
+	for offset > 0 && offset < len(s) && !utf8.RuneStart(s[offset]) {
+		offset--
+	}
+	return offset
+}
@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestAlignToRune(t *testing.T) {
+	s := "aé€b" // a, 2-byte é at 1, 3-byte € at 3, b at 6
+	for _, tt := range []struct {
+		offset, want int
+	}{
+		{0, 0}, {1, 1}, {2, 1}, {3, 3}, {4, 3}, {5, 3}, {6, 6}, {7, 7},
+	} {
+		if got := alignToRune(s, tt.offset); got != tt.want {
+			t.Errorf("alignToRune(%q, %d) = %d, want %d", s, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestReadResourceInChunks(t *testing.T) {
+	content := "héllo wörld €uro"
+	uri := globalContextStore.Capture([]api.SentContextFile{{Path: "/work/notes.txt", Text: content}})[0]
+	s := &Server{}
+
+	read := func(offset, length int) map[string]interface{} {
+		t.Helper()
+		params := map[string]interface{}{"uri": uri, "offset": offset, "length": length}
+		resp, err := s.handleReadResource(context.Background(), &Request{ID: 1, Params: params})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Result.(map[string]interface{})
+	}
+	text := func(result map[string]interface{}) string {
+		return result["contents"].([]map[string]interface{})[0]["text"].(string)
+	}
+
+	// Chunks end before a rune they would split, and the next starts there
+	var got string
+	offset := 0
+	for reads := 0; ; reads++ {
+		if reads > len(content) {
+			t.Fatal("reads never reached the end of the content")
+		}
+		result := read(offset, 3)
+		if result["totalSize"] != len(content) {
+			t.Fatalf("totalSize = %v, want %d", result["totalSize"], len(content))
+		}
+		got += text(result)
+		next, ok := result["nextOffset"]
+		if !ok {
+			break
+		}
+		offset = next.(int)
+	}
+	if got != content {
+		t.Errorf("chunks joined = %q, want %q", got, content)
+	}
+
+	// An offset inside a rune starts at the rune; a length shorter than the
+	// rune returns the whole rune
+	if result := read(2, 1); text(result) != "é" || result["offset"] != 1 || result["nextOffset"] != 3 {
+		t.Errorf("read(2, 1) = %+v, want é from offset 1", result)
+	}
+	// Without a length the rest fits in one chunk
+	if result := read(0, 0); text(result) != content || result["nextOffset"] != nil {
+		t.Errorf("read(0, 0) = %+v, want the whole content", result)
+	}
+
+	params := map[string]interface{}{"uri": uri, "offset": len(content) + 1}
+	if _, err := s.handleReadResource(context.Background(), &Request{ID: 1, Params: params}); err == nil {
+		t.Error("Expected an error for an offset past the end")
+	}
+}
+
+func TestContextSnapshotEviction(t *testing.T) {
+	store := NewContextSnapshotStore()
+	if uris := store.Capture(nil); uris != nil {
+		t.Errorf("Capture(nil) = %v, want no resources", uris)
+	}
+
+	var first []string
+	for i := 0; i <= maxContextSnapshots; i++ {
+		uris := store.Capture([]api.SentContextFile{{Path: fmt.Sprintf("/work/%d.go", i), Text: "package main"}})
+		if i == 0 {
+			first = uris
+		}
+	}
+	if _, ok := store.Get(first[0]); ok {
+		t.Errorf("%s kept past %d requests", first[0], maxContextSnapshots)
+	}
+	list := store.List()
+	if len(list) != maxContextSnapshots || list[0].Path != fmt.Sprintf("/work/%d.go", maxContextSnapshots) {
+		t.Errorf("List() has %d snapshots starting with %s, want %d newest first", len(list), list[0].Path, maxContextSnapshots)
+	}
+}
+
+func TestWriteToolSnapshotsContextAsSent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, "package main\n")
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	cfg.Redaction = config.RedactionConfig{Enabled: true, Emails: true}
+	cfg.ContextFiles.Guard.Enabled = true
+	factory := provider.NewProviderFactory()
+	provider.InitializeDefaultProviders(factory)
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, factory)}
+	if err := s.router.Initialize(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	contextFile := filepath.Join(dir, "owners.txt")
+	os.WriteFile(contextFile, []byte("maintainer: jane@example.com\n"), 0600)
+	args := map[string]interface{}{
+		"file_path":     filepath.Join(dir, "main.go"),
+		"prompt":        "write main",
+		"context_files": []interface{}{contextFile},
+	}
+	resp, err := s.handleWriteTool(context.Background(), &Request{ID: 1}, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uris, _ := resp.Result.(map[string]interface{})["contextResources"].([]string)
+	if len(uris) != 1 {
+		t.Fatalf("contextResources = %v, want the context file", resp.Result)
+	}
+	snapshot, ok := globalContextStore.Get(uris[0])
+	if !ok {
+		t.Fatalf("%s not found", uris[0])
+	}
+	if strings.Contains(snapshot.Content, "jane@example.com") || !strings.Contains(snapshot.Content, "<EMAIL_1>") {
+		t.Errorf("snapshot = %q, want the address redacted as sent", snapshot.Content)
+	}
+	if snapshot.Content == "maintainer: jane@example.com\n" || !strings.Contains(snapshot.Content, contextFile) {
+		t.Errorf("snapshot = %q, want the guarded block sent", snapshot.Content)
+	}
+}
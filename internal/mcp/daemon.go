@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// DaemonSocket returns the unix socket path of the daemon
+func DaemonSocket(cfg *config.Config) string {
+	if cfg.Server.Socket != "" {
+		return config.ExpandPath(cfg.Server.Socket)
+	}
+	return filepath.Join(config.ConfigDir(), "daemon.sock")
+}
+
+// ListenDaemon listens on the daemon socket. A socket left behind by a daemon
+// that exited is replaced; one that still accepts connections is an error.
+func ListenDaemon(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		conn, dialErr := net.DialTimeout("unix", path, time.Second)
+		if dialErr == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already listening on %s", path)
+		}
+		logger.Infof("Removing stale daemon socket %s", path)
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// Anyone who can connect can write files as this user. Windows ignores
+	// the mode; the socket's directory in the user's profile is private.
+	if err := os.Chmod(path, 0600); err != nil && runtime.GOOS != "windows" {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// ServeDaemon initializes the router once and runs an MCP session for every
+// connection accepted on listener until ctx is cancelled. Sessions share the
+// router, so provider connections, rate limits, the cache and metrics are
+// common to every attached editor.
+func (s *Server) ServeDaemon(ctx context.Context, listener net.Listener) error {
+	restore := guardStdout()
+	defer restore()
+
+	if err := s.router.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize router: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	logger.Infof("MCP daemon listening on %s", listener.Addr())
+	var sessions sync.WaitGroup
+	defer sessions.Wait()
+	for id := 1; ; id++ {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		sessions.Add(1)
+		go func(id int, conn net.Conn) {
+			defer sessions.Done()
+			s.serveConn(ctx, id, conn)
+		}(id, conn)
+	}
+}
+
+// serveConn runs one attached client's session
+func (s *Server) serveConn(ctx context.Context, id int, conn net.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer conn.Close()
+
+	// Unblock the read when the daemon shuts down
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	logger.Infof("Daemon session %d attached", id)
+	session := s.newSession(conn)
+	if err := session.messageLoop(ctx); err != nil && ctx.Err() == nil {
+		logger.Warnf("Daemon session %d (%s) ended: %v", id, session.clientInfo, err)
+		return
+	}
+	logger.Infof("Daemon session %d (%s) detached", id, session.clientInfo)
+}
+
+// newSession returns a server speaking MCP over conn. It shares the
+// router, configuration, audit and request logs with s; client info,
+// negotiated protocol version and frame statistics are its own.
+func (s *Server) newSession(conn net.Conn) *Server {
+	return &Server{
+		config: s.config,
+		router: s.router,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+		audit:  s.audit,
+
+		requests: s.requests,
+	}
+}
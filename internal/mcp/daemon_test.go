@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestListenDaemonReplacesStaleSocket(t *testing.T) {
+	// Unix socket paths are short; t.TempDir() can exceed the limit
+	dir, err := os.MkdirTemp("", "mcpd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "daemon.sock")
+
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := ListenDaemon(path)
+	if err != nil {
+		t.Fatalf("ListenDaemon() with stale socket error = %v", err)
+	}
+	defer listener.Close()
+
+	if _, err := ListenDaemon(path); err == nil {
+		t.Error("ListenDaemon() succeeded while another daemon is listening")
+	}
+}
+
+func TestDaemonSessionsAreIndependent(t *testing.T) {
+	s := &Server{config: &config.Config{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var clients []net.Conn
+	for i := 0; i < 2; i++ {
+		client, conn := net.Pipe()
+		defer client.Close()
+		go s.serveConn(ctx, i+1, conn)
+		clients = append(clients, client)
+	}
+
+	// Interleave requests to both sessions; each gets its own response
+	for i, client := range clients {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/list"}`+"\n", i+1)
+		if _, err := client.Write([]byte(request)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, client := range clients {
+		line, err := bufio.NewReader(client).ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("session %d: %v", i+1, err)
+		}
+		var response Response
+		if err := json.Unmarshal(line, &response); err != nil {
+			t.Fatalf("session %d: %v", i+1, err)
+		}
+		if id, _ := response.ID.(float64); int(id) != i+1 {
+			t.Errorf("session %d got response for id %v", i+1, response.ID)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// diffResourceScheme is the URI scheme of diffs left out of write responses
+	diffResourceScheme = "diff://"
+	// maxStoredDiffs bounds how many left-out diffs are kept in memory
+	maxStoredDiffs = 20
+)
+
+// StoredDiff is the diff of one write that was too long to return
+type StoredDiff struct {
+	URI      string
+	Path     string
+	Text     string
+	Captured time.Time
+}
+
+// DiffStore keeps the diffs left out of recent write responses so clients
+// can read them through MCP resources
+type DiffStore struct {
+	mutex    sync.RWMutex
+	sequence int
+	diffs    []*StoredDiff
+}
+
+// Put stores the diff of a write to path and returns its resource URI
+func (d *DiffStore) Put(path, text string) string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.sequence++
+	diff := &StoredDiff{
+		URI:      fmt.Sprintf("%s%d/%s", diffResourceScheme, d.sequence, filepath.Base(path)),
+		Path:     path,
+		Text:     text,
+		Captured: time.Now(),
+	}
+	d.diffs = append(d.diffs, diff)
+	if len(d.diffs) > maxStoredDiffs {
+		d.diffs = d.diffs[len(d.diffs)-maxStoredDiffs:]
+	}
+	return diff.URI
+}
+
+// List returns the retained diffs, newest first
+func (d *DiffStore) List() []*StoredDiff {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	result := make([]*StoredDiff, 0, len(d.diffs))
+	for i := len(d.diffs) - 1; i >= 0; i-- {
+		result = append(result, d.diffs[i])
+	}
+	return result
+}
+
+// Get looks up a diff by URI
+func (d *DiffStore) Get(uri string) (*StoredDiff, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	for _, diff := range d.diffs {
+		if diff.URI == uri {
+			return diff, true
+		}
+	}
+	return nil, false
+}
+
+var globalDiffStore = &DiffStore{}
+
+// diffThreshold returns the length above which a write response leaves out
+// its diff: the configured threshold, or maxLength if that is smaller
+func (s *Server) diffThreshold(maxLength int) int {
+	threshold := s.config.Responses.WriteOnlyDiffThreshold
+	if maxLength > 0 && (threshold <= 0 || maxLength < threshold) {
+		threshold = maxLength
+	}
+	return threshold
+}
+
+// truncateContent cuts the text of content to maxLength bytes in total,
+// noting where text was cut. Zero means no limit.
+func truncateContent(content []Content, maxLength int) []Content {
+	if maxLength <= 0 {
+		return content
+	}
+	remaining := maxLength
+	for i := range content {
+		text := content[i].Text
+		if len(text) <= remaining {
+			remaining -= len(text)
+			continue
+		}
+		cut := alignToRune(text, remaining)
+		content[i].Text = text[:cut] + fmt.Sprintf("\n... response truncated (%d bytes total)", len(text))
+		if rest := len(content) - i - 1; rest > 0 {
+			content[i].Text += fmt.Sprintf("; %d more item(s) left out", rest)
+		}
+		return content[:i+1]
+	}
+	return content
+}
@@ -0,0 +1,62 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/requestlog"
+)
+
+func TestWriteToolLeavesOutLongDiff(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	original := "package main\n\nfunc main() {}\n"
+	os.WriteFile(file, []byte(original), 0600)
+	generated := "package main\n\nfunc main() {\n" + strings.Repeat("\tprintln(1)\n", 50) + "}\n"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, generated)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"longdiff"}
+	cfg.Providers.Order = []string{"longdiff"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"longdiff": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	args := map[string]interface{}{"file_path": file, "prompt": "print", "diff_format": "unified", "max_response_length": float64(400)}
+	resp, err := s.handleWriteTool(context.Background(), &Request{ID: 1}, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := resp.Result.(map[string]interface{})["content"].([]Content)[0].Text
+	uri := regexp.MustCompile(`diff://[^\s)]+`).FindString(text)
+	if uri == "" || len(text) > 400 {
+		t.Fatalf("response = %q, want a short note with the diff's resource URI", text)
+	}
+
+	read, err := s.handleReadResource(context.Background(), &Request{ID: 2, Params: map[string]interface{}{"uri": uri}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := read.Result.(map[string]interface{})["contents"].([]map[string]interface{})[0]["text"]
+	if want := requestlog.UnifiedDiff(file, original, generated); got != want {
+		t.Errorf("resource %s = %q, want the full diff %q", uri, got, want)
+	}
+}
+
+func TestTruncateContent(t *testing.T) {
+	content := truncateContent([]Content{{Type: "text", Text: "warnings"}, {Type: "text", Text: "héllo world"}, {Type: "text", Text: "more"}}, 10)
+	if len(content) != 2 || !strings.HasPrefix(content[1].Text, "h\n... response truncated (12 bytes total); 1 more item(s) left out") {
+		t.Errorf("truncateContent() = %q", content)
+	}
+}
@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"errors"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+)
+
+// JSON-RPC error codes for failures agents can react to, in the range the
+// spec reserves for server errors. Anything else keeps errCodeGeneric.
+const (
+	errCodeGeneric          = -1
+	errCodeProvider         = -32000
+	errCodeAuth             = -32001
+	errCodeRateLimited      = -32002
+	errCodeContextTooLarge  = -32003
+	errCodeValidationFailed = -32004
+	errCodeSandboxViolation = -32005
+	errCodeLicenseViolation = -32006
+)
+
+// errorKindSandboxViolation is the kind of a request touching a path the
+// workspace policy doesn't allow
+const errorKindSandboxViolation router.ErrorKind = "sandbox_violation"
+
+var errorCodes = map[router.ErrorKind]int{
+	router.ErrorKindProvider:         errCodeProvider,
+	router.ErrorKindAuth:             errCodeAuth,
+	router.ErrorKindRateLimited:      errCodeRateLimited,
+	router.ErrorKindContextTooLarge:  errCodeContextTooLarge,
+	router.ErrorKindValidationFailed: errCodeValidationFailed,
+	errorKindSandboxViolation:        errCodeSandboxViolation,
+	router.ErrorKindLicenseViolation: errCodeLicenseViolation,
+}
+
+// sandboxViolationError rejects a file outside the workspace policy
+type sandboxViolationError struct {
+	message string
+}
+
+func (e *sandboxViolationError) Error() string {
+	return e.message
+}
+
+// errorData is the data member of a classified JSON-RPC error
+type errorData struct {
+	Kind     router.ErrorKind         `json:"kind"`
+	Attempts []router.ProviderAttempt `json:"attempts,omitempty"`
+}
+
+// classifyError returns the JSON-RPC code and data for err, or ok false if
+// it isn't one of the classified failures
+func classifyError(err error) (code int, data *errorData, ok bool) {
+	var sandboxErr *sandboxViolationError
+	if errors.As(err, &sandboxErr) {
+		return errCodeSandboxViolation, &errorData{Kind: errorKindSandboxViolation}, true
+	}
+	var genErr *router.GenerationError
+	if errors.As(err, &genErr) {
+		return errorCodes[genErr.Kind], &errorData{Kind: genErr.Kind, Attempts: genErr.Attempts}, true
+	}
+	return errCodeGeneric, nil, false
+}
+
+// newErrorResponse builds the error member of a response for err
+func newErrorResponse(err error) *ErrorResponse {
+	code, data, ok := classifyError(err)
+	if !ok {
+		return &ErrorResponse{Code: code, Message: err.Error()}
+	}
+	return &ErrorResponse{Code: code, Message: err.Error(), Data: data}
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+)
+
+func TestSendErrorResponseClassifiesFailures(t *testing.T) {
+	genErr := &router.GenerationError{
+		Kind: router.ErrorKindRateLimited,
+		Attempts: []router.ProviderAttempt{
+			{Provider: "cerebras", Model: "qwen-3-coder-480b", Kind: router.ErrorKindRateLimited, StatusCode: 429, RetryAfterSeconds: 30, Error: "cerebras API error: 429 - slow down"},
+		},
+		Err: errors.New("all providers failed or no API keys configured"),
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantKind string
+	}{
+		{"generation", fmt.Errorf("generation did not finish within the timeout: %w", genErr), errCodeRateLimited, "rate_limited"},
+		{"license", &router.GenerationError{Kind: router.ErrorKindLicenseViolation, Err: errors.New("all providers failed or no API keys configured")}, errCodeLicenseViolation, "license_violation"},
+		{"sandbox", &sandboxViolationError{"file_path /etc/passwd is outside the allowed workspace paths"}, errCodeSandboxViolation, "sandbox_violation"},
+		{"unclassified", errors.New("unknown method: foo"), errCodeGeneric, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s := &Server{writer: bufio.NewWriter(&out)}
+			s.sendErrorResponse(&Request{ID: float64(1)}, tt.err)
+
+			var resp struct {
+				Error struct {
+					Code    int    `json:"code"`
+					Message string `json:"message"`
+					Data    *struct {
+						Kind     string                   `json:"kind"`
+						Attempts []router.ProviderAttempt `json:"attempts"`
+					} `json:"data"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid frame %q: %v", out.String(), err)
+			}
+			if resp.Error.Code != tt.wantCode || resp.Error.Message != tt.err.Error() {
+				t.Errorf("error = %d %q, want %d %q", resp.Error.Code, resp.Error.Message, tt.wantCode, tt.err.Error())
+			}
+			if tt.wantKind == "" {
+				if resp.Error.Data != nil {
+					t.Errorf("data = %+v, want none", resp.Error.Data)
+				}
+				return
+			}
+			if resp.Error.Data == nil || resp.Error.Data.Kind != tt.wantKind {
+				t.Fatalf("data = %+v, want kind %s", resp.Error.Data, tt.wantKind)
+			}
+			if tt.name == "generation" && (len(resp.Error.Data.Attempts) != 1 || resp.Error.Data.Attempts[0] != genErr.Attempts[0]) {
+				t.Errorf("attempts = %+v, want %+v", resp.Error.Data.Attempts, genErr.Attempts)
+			}
+		})
+	}
+}
@@ -1,32 +1,45 @@
 package mcp
 
-import "sync"
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/retention"
+)
 
 type FileBackupStore struct {
 	mutex   sync.RWMutex
-	backups map[string]string
+	backups map[string]fileBackup
+}
+
+// fileBackup is a file's content before the last write, and when it was kept
+type fileBackup struct {
+	content string
+	stored  time.Time
 }
 
 func NewFileBackupStore() *FileBackupStore {
 	return &FileBackupStore{
-		backups: make(map[string]string),
+		backups: make(map[string]fileBackup),
 	}
 }
 
 func (f *FileBackupStore) StoreBackup(filePath, content string) {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
-	f.backups[filePath] = content
+	f.backups[filePath] = fileBackup{content: content, stored: time.Now()}
 }
 
 func (f *FileBackupStore) GetBackup(filePath string) (string, error) {
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
-	content, exists := f.backups[filePath]
+	backup, exists := f.backups[filePath]
 	if !exists {
 		return "", &BackupNotFoundError{Path: filePath}
 	}
-	return content, nil
+	return backup.content, nil
 }
 
 func (f *FileBackupStore) HasBackup(filePath string) bool {
@@ -42,6 +55,41 @@ func (f *FileBackupStore) ClearBackup(filePath string) {
 	delete(f.backups, filePath)
 }
 
+// Prune drops the backups stored before policy.MaxAge, then the oldest ones
+// until they total at most policy.MaxSize bytes
+func (f *FileBackupStore) Prune(policy config.RetentionPolicy, now time.Time, dryRun bool) retention.Result {
+	result := retention.Result{Path: "(memory)"}
+	if policy.MaxAge <= 0 && policy.MaxSize <= 0 {
+		return result
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	paths := make([]string, 0, len(f.backups))
+	var total int64
+	for path, backup := range f.backups {
+		paths = append(paths, path)
+		total += int64(len(backup.content))
+	}
+	sort.Slice(paths, func(a, b int) bool { return f.backups[paths[a]].stored.Before(f.backups[paths[b]].stored) })
+
+	for _, path := range paths {
+		backup := f.backups[path]
+		expired := policy.MaxAge > 0 && now.Sub(backup.stored) > policy.MaxAge
+		tooLarge := policy.MaxSize > 0 && total > policy.MaxSize
+		if !expired && !tooLarge {
+			break
+		}
+		if !dryRun {
+			delete(f.backups, path)
+		}
+		result.Removed++
+		result.Freed += int64(len(backup.content))
+		total -= int64(len(backup.content))
+	}
+	return result
+}
+
 type BackupNotFoundError struct {
 	Path string
 }
@@ -54,4 +102,4 @@ var globalBackupStore *FileBackupStore
 
 func init() {
 	globalBackupStore = NewFileBackupStore()
-}
\ No newline at end of file
+}
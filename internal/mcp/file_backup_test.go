@@ -0,0 +1,33 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestFileBackupStorePrune(t *testing.T) {
+	now := time.Now()
+	store := NewFileBackupStore()
+	store.backups["old.go"] = fileBackup{content: "0123456789", stored: now.Add(-48 * time.Hour)}
+	store.backups["older.go"] = fileBackup{content: "0123456789", stored: now.Add(-2 * time.Hour)}
+	store.backups["new.go"] = fileBackup{content: "0123456789", stored: now}
+
+	if result := store.Prune(config.RetentionPolicy{MaxAge: 24 * time.Hour}, now, true); result.Removed != 1 || !store.HasBackup("old.go") {
+		t.Errorf("dry run = %+v, want 1 backup counted and kept", result)
+	}
+	if result := store.Prune(config.RetentionPolicy{MaxAge: 24 * time.Hour}, now, false); result.Removed != 1 || result.Freed != 10 || store.HasBackup("old.go") {
+		t.Errorf("max age = %+v, want the day-old backup removed", result)
+	}
+
+	// Past the size limit the oldest backups go first
+	if result := store.Prune(config.RetentionPolicy{MaxSize: 15}, now, false); result.Removed != 1 || store.HasBackup("older.go") || !store.HasBackup("new.go") {
+		t.Errorf("max size = %+v, want only the newest backup kept", result)
+	}
+
+	// Without a policy everything is kept
+	if result := store.Prune(config.RetentionPolicy{}, now, false); result.Removed != 0 || !store.HasBackup("new.go") {
+		t.Errorf("no policy = %+v, want nothing removed", result)
+	}
+}
@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+	"github.com/cecil-the-coder/mcp-code-api/internal/prompts"
+)
+
+// withProject finds the conventions of the project filePath is in
+// (.mcp-code-api.yaml) and attaches them to ctx for the router, along with
+// the code style its .editorconfig and linter configs ask for. It returns a
+// nil project if there is no convention file.
+func withProject(ctx context.Context, filePath string) (context.Context, *config.ProjectConfig, error) {
+	ctx = prompts.WithStyle(ctx, prompts.DetectStyle(filePath))
+	project, err := config.FindProjectConfig(filePath)
+	if err != nil || project == nil {
+		return ctx, nil, err
+	}
+	logger.Debugf("Using project conventions from %s", project.Root)
+	return router.WithProject(ctx, project), project, nil
+}
+
+// projectContextPaths returns the project's context files that are inside
+// its root. A convention file comes with the repository, so entries reaching
+// outside it, to ~/.ssh say, are dropped rather than sent to the provider.
+func projectContextPaths(project *config.ProjectConfig) []string {
+	var paths []string
+	for _, path := range project.ContextPaths() {
+		if !policy.IsPathWithin(path, []string{project.Root}) {
+			logger.Warnf("Ignoring context file %s from %s: outside the project", path, filepath.Join(project.Root, config.ProjectFileName))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
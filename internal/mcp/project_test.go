@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestWriteToolAppliesProjectConventions(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "internal", "app"), 0755)
+	os.WriteFile(filepath.Join(root, "CONVENTIONS.md"), []byte("Errors wrap with %w.\n"), 0600)
+	os.WriteFile(filepath.Join(root, config.ProjectFileName), []byte(`
+model: "project/coder-large"
+context_files: ["CONVENTIONS.md"]
+preamble: "Use log/slog for logging."
+`), 0600)
+
+	var model, prompt string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		model, prompt = req.Model, req.Messages[len(req.Messages)-1].Content
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, "package app\n")
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"global", "project"}
+	cfg.Providers.Order = []string{"global", "project"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{
+		"global":  {BaseURL: "http://127.0.0.1:0", APIKey: "key", Model: "coder"},
+		"project": {BaseURL: srv.URL, APIKey: "key", Model: "coder"},
+	}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	file := filepath.Join(root, "internal", "app", "app.go")
+	args := map[string]interface{}{"file_path": file, "prompt": "an app package"}
+	if _, err := s.handleWriteTool(context.Background(), &Request{ID: 1}, &args); err != nil {
+		t.Fatal(err)
+	}
+	if model != "coder-large" {
+		t.Errorf("model = %q, want the project's", model)
+	}
+	if !strings.Contains(prompt, "Use log/slog for logging.\n\nan app package") || !strings.Contains(prompt, "Errors wrap with %w.") {
+		t.Errorf("prompt = %q, want the project's preamble and context file", prompt)
+	}
+}
+
+func TestProjectContextFilesStayInRoot(t *testing.T) {
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "id_rsa")
+	os.WriteFile(secret, []byte("PRIVATE KEY\n"), 0600)
+	parent := t.TempDir()
+	root := filepath.Join(parent, "repo")
+	os.MkdirAll(root, 0755)
+	os.WriteFile(filepath.Join(parent, "credentials"), []byte("aws secret\n"), 0600)
+	os.WriteFile(filepath.Join(root, "CONVENTIONS.md"), []byte("Errors wrap with %w.\n"), 0600)
+	if err := os.Symlink(outside, filepath.Join(root, "keys")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	os.WriteFile(filepath.Join(root, config.ProjectFileName), []byte(fmt.Sprintf(
+		"context_files: [%q, \"../credentials\", \"keys/id_rsa\", \"CONVENTIONS.md\"]\n", secret)), 0600)
+
+	project, err := config.FindProjectConfig(filepath.Join(root, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := projectContextPaths(project), []string{filepath.Join(root, "CONVENTIONS.md")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("projectContextPaths() = %v, want only %v", got, want)
+	}
+}
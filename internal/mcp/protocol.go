@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"slices"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// MCP protocol revisions this server speaks
+const (
+	protocol20241105 = "2024-11-05"
+	protocol20250326 = "2025-03-26"
+	protocol20250618 = "2025-06-18" // Adds structuredContent and tool outputSchema
+)
+
+// supportedProtocolVersions lists the revisions this server speaks, newest first
+var supportedProtocolVersions = []string{protocol20250618, protocol20250326, protocol20241105}
+
+// clientCapabilities records which optional features the client declared in
+// initialize
+type clientCapabilities struct {
+	Roots       bool
+	Sampling    bool
+	Elicitation bool
+}
+
+// negotiateProtocolVersion picks the revision to speak: the client's if this
+// server supports it, otherwise the newest one, which the client may reject
+func negotiateProtocolVersion(requested string) string {
+	if slices.Contains(supportedProtocolVersions, requested) {
+		return requested
+	}
+	if requested != "" {
+		logger.Warnf("MCP client requested unsupported protocol version %s; offering %s", requested, supportedProtocolVersions[0])
+	}
+	return supportedProtocolVersions[0]
+}
+
+// supportsStructuredContent reports whether the negotiated revision has tool
+// structuredContent and outputSchema. Before initialize, as in tests, the
+// newest revision is assumed.
+func (s *Server) supportsStructuredContent() bool {
+	return s.protocolVersion == "" || s.protocolVersion >= protocol20250618
+}
+
+// gateTools removes what the negotiated revision does not define from the
+// tool list
+func (s *Server) gateTools(tools []Tool) []Tool {
+	if s.supportsStructuredContent() {
+		return tools
+	}
+	for i := range tools {
+		tools[i].OutputSchema = nil
+	}
+	return tools
+}
+
+// gateToolResult removes what the negotiated revision does not define from a
+// tools/call result
+func (s *Server) gateToolResult(resp *Response) *Response {
+	if resp == nil || s.supportsStructuredContent() {
+		return resp
+	}
+	if result, ok := resp.Result.(map[string]interface{}); ok {
+		delete(result, "structuredContent")
+	}
+	return resp
+}
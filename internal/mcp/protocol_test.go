@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestProtocolNegotiationGatesStructuredContent(t *testing.T) {
+	for _, tt := range []struct {
+		requested, want string
+		outputSchema    bool
+	}{
+		{requested: protocol20241105, want: protocol20241105},
+		{requested: protocol20250326, want: protocol20250326},
+		{requested: protocol20250618, want: protocol20250618, outputSchema: true},
+		{requested: "2099-01-01", want: supportedProtocolVersions[0], outputSchema: true},
+	} {
+		cfg := &config.Config{}
+		s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+		params := map[string]interface{}{
+			"protocolVersion": tt.requested,
+			"capabilities":    map[string]interface{}{"roots": map[string]interface{}{}},
+			"clientInfo":      map[string]interface{}{"name": "test"},
+		}
+		resp, err := s.handleInitialize(context.Background(), &Request{ID: 1, Params: params})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Result.(map[string]interface{})["protocolVersion"]; got != tt.want {
+			t.Errorf("requested %s: negotiated %v, want %s", tt.requested, got, tt.want)
+		}
+		if !s.clientCaps.Roots || s.clientCaps.Sampling {
+			t.Errorf("client capabilities = %+v, want roots only", s.clientCaps)
+		}
+
+		list, _ := s.handleListTools(context.Background(), &Request{ID: 2})
+		tools := list.Result.(map[string]interface{})["tools"].([]Tool)
+		if got := tools[0].OutputSchema != nil; got != tt.outputSchema {
+			t.Errorf("protocol %s: write tool outputSchema = %v, want %v", tt.want, got, tt.outputSchema)
+		}
+
+		result := s.gateToolResult(&Response{Result: map[string]interface{}{"structuredContent": writeOutcome{}}})
+		if _, got := result.Result.(map[string]interface{})["structuredContent"]; got != tt.outputSchema {
+			t.Errorf("protocol %s: structuredContent kept = %v, want %v", tt.want, got, tt.outputSchema)
+		}
+	}
+}
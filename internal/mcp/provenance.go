@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// provenanceMarker identifies trailer lines so they can be replaced on later edits
+const provenanceMarker = "mcp-code-api provenance:"
+
+// appendProvenance appends a trailer comment recording how content was generated.
+// Any previous trailer (e.g. preserved by the model while editing) is replaced.
+// Files whose language has no comment syntax are returned unchanged.
+func (s *Server) appendProvenance(content, filePath, prompt string, info *router.GenerationInfo) string {
+	prefix, suffix, ok := utils.CommentDelimiters(utils.GetLanguageFromFile(filePath, nil))
+	if !ok {
+		return content
+	}
+
+	model := info.Model
+	if model == "" {
+		model = "unknown"
+	}
+	trailer := fmt.Sprintf("%s%s provider=%s model=%s generated=%s prompt_sha256=%s tool=%s/%s%s",
+		prefix, provenanceMarker, info.Provider, model,
+		time.Now().UTC().Format(time.RFC3339),
+		utils.ContentChecksum(prompt)[:16],
+		s.config.Server.Name, s.config.Server.Version,
+		suffix)
+
+	content = strings.TrimRight(stripProvenance(content), "\n")
+	return content + "\n\n" + trailer + "\n"
+}
+
+// stripProvenance removes existing provenance trailer lines
+func stripProvenance(content string) string {
+	if !strings.Contains(content, provenanceMarker) {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.Contains(line, provenanceMarker) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
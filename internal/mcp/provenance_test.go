@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestAppendProvenance(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.Name, cfg.Server.Version = "mcp-code-api", "1.2.3"
+	s := &Server{config: cfg}
+	info := &router.GenerationInfo{Provider: "cerebras", Model: "qwen-3-coder"}
+
+	tests := []struct {
+		file, content, prefix, suffix string
+	}{
+		{"main.go", "package main\n", "// ", ""},
+		{"app.py", "print(1)\n", "# ", ""},
+		{"index.html", "<p>hi</p>\n", "<!-- ", " -->"},
+		{"pom.xml", "<project/>\n", "<!-- ", " -->"},
+		{"site.css", "body {}\n", "/* ", " */"},
+	}
+	for _, tt := range tests {
+		got := s.appendProvenance(tt.content, tt.file, "write it", info)
+		body, trailer, found := strings.Cut(got, "\n\n")
+		if !found || body+"\n" != tt.content {
+			t.Errorf("%s: got %q, want the content followed by a trailer", tt.file, got)
+			continue
+		}
+		trailer = strings.TrimSuffix(trailer, "\n")
+		if !strings.HasPrefix(trailer, tt.prefix+provenanceMarker) || !strings.HasSuffix(trailer, tt.suffix) || strings.Contains(trailer, "\n") {
+			t.Errorf("%s: trailer %q, want one %q...%q comment line", tt.file, trailer, tt.prefix, tt.suffix)
+		}
+		for _, field := range []string{"provider=cerebras", "model=qwen-3-coder", "tool=mcp-code-api/1.2.3" + tt.suffix} {
+			if !strings.Contains(trailer, field) {
+				t.Errorf("%s: trailer %q is missing %s", tt.file, trailer, field)
+			}
+		}
+
+		// A later edit replaces the trailer instead of adding another
+		if again := s.appendProvenance(got, tt.file, "edit it", info); strings.Count(again, provenanceMarker) != 1 {
+			t.Errorf("%s: %d trailers after a second write, want 1", tt.file, strings.Count(again, provenanceMarker))
+		}
+	}
+
+	// Formats without comments are left alone
+	for _, file := range []string{"data.json", "notes.unknownext"} {
+		if got := s.appendProvenance("{}\n", file, "write it", info); got != "{}\n" {
+			t.Errorf("%s: got %q, want the content unchanged", file, got)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/audit"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/requestlog"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// refactorPlan is the model's plan for a refactor: which files change and how
+type refactorPlan struct {
+	Files []refactorStep `json:"files"`
+}
+
+// refactorStep is one file's part of a refactor
+type refactorStep struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+// refactorEdit is a generated file waiting to be written
+type refactorEdit struct {
+	path      string
+	operation string // audit.OperationCreate or audit.OperationUpdate
+	before    string
+	after     string
+	info      *router.GenerationInfo
+}
+
+// handleRefactorTool plans a refactor across several files, generates each
+// file's new content with the others as context, and writes them all only
+// once every file has generated (and validated) successfully
+func (s *Server) handleRefactorTool(ctx context.Context, request *Request, arguments *map[string]interface{}) (*Response, error) {
+	instruction, err := extractStringArg(arguments, "instruction")
+	if err != nil {
+		return nil, fmt.Errorf("instruction is required: %w", err)
+	}
+	files, err := extractStringSliceArg(arguments, "files")
+	if err != nil {
+		return nil, fmt.Errorf("files must be an array of strings: %w", err)
+	}
+	contextFiles, err := extractStringSliceArg(arguments, "context_files")
+	if err != nil {
+		return nil, fmt.Errorf("context_files must be an array of strings: %w", err)
+	}
+	var requestedProvider, requestedModel string
+	for key, value := range map[string]*string{"provider": &requestedProvider, "model": &requestedModel} {
+		if _, exists := (*arguments)[key]; !exists {
+			continue
+		}
+		if *value, err = extractStringArg(arguments, key); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+	if ctx, err = withReasoningEffort(ctx, arguments); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	if ctx, err = withValidationOptions(ctx, arguments); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	validate := true
+	if _, exists := (*arguments)["validate"]; exists {
+		validate = extractBoolArg(arguments, "validate")
+	}
+	writeOnly := extractBoolArg(arguments, "write_only")
+
+	if files, err = s.expandContextFiles(files); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	if len(files) == 0 {
+		return s.createErrorResponse(request, fmt.Errorf("files is required: list the files the refactor may change or create"))
+	}
+	for i, file := range files {
+		files[i] = filepath.Clean(file)
+	}
+	// The conventions of the project the first file is in apply to the whole refactor
+	ctx, project, err := withProject(ctx, files[0])
+	if err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	if project != nil && project.Validation.Always {
+		validate = true
+	}
+	if contextFiles, err = s.expandContextFiles(append(contextFiles, projectContextPaths(project)...)); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	for _, file := range files {
+		if err := s.checkWorkspacePaths(file, nil); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+	if err := s.checkWorkspacePaths(files[0], contextFiles); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+
+	var warnings []string
+	warningCallback := func(providerName, message string) {
+		warnings = append(warnings, message)
+		logger.Infof("[VALIDATION] %s", message)
+	}
+
+	plan, err := s.planRefactor(ctx, instruction, files, contextFiles, requestedProvider, requestedModel)
+	if err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	logger.Infof("Refactor: plan changes %d of %d file(s)", len(plan.Files), len(files))
+
+	// Generate every file before writing any, so a failure leaves the tree untouched
+	var edits []refactorEdit
+	for i, step := range plan.Files {
+		before, _ := utils.ReadFileContent(step.Path)
+		operation := audit.OperationUpdate
+		if before == "" {
+			operation = audit.OperationCreate
+		}
+		prompt := refactorPrompt(instruction, plan, i, edits)
+
+		// The other files as they are now; files already generated are in the prompt
+		var stepContext []string
+		for _, file := range append(slices.Clone(files), contextFiles...) {
+			done := slices.ContainsFunc(edits, func(e refactorEdit) bool { return e.path == file })
+			if file != step.Path && !done && !slices.Contains(stepContext, file) {
+				stepContext = append(stepContext, file)
+			}
+		}
+
+		stepCtx, info := router.WithGenerationInfo(ctx)
+		start := time.Now()
+		after, err := s.router.GenerateCodeWithValidation(stepCtx, prompt, step.Path, stepContext, requestedProvider, requestedModel, validate, warningCallback)
+		if err != nil {
+			s.recordRequest(operation, step.Path, before, "", validate, warnings, info, time.Since(start), err)
+			return s.createErrorResponse(request, fmt.Errorf("refactor of %s failed, no files were changed: %w", step.Path, err))
+		}
+		if s.config.Provenance.Enabled {
+			after = s.appendProvenance(after, step.Path, prompt, info)
+		}
+		after = utils.MatchTextFormat(before, after)
+		s.recordRequest(operation, step.Path, before, after, validate, warnings, info, time.Since(start), nil)
+		edits = append(edits, refactorEdit{path: step.Path, operation: operation, before: before, after: after, info: info})
+	}
+
+	var diff strings.Builder
+	for _, edit := range edits {
+		if edit.before != "" {
+			globalBackupStore.StoreBackup(edit.path, edit.before)
+		}
+		if err := utils.WriteFileContent(edit.path, edit.after); err != nil {
+			return s.createErrorResponse(request, fmt.Errorf("failed to write %s (files before it in the plan were written; use restore_previous to undo them): %w", edit.path, err))
+		}
+		s.recordAudit(edit.operation, edit.path, instruction, edit.before, edit.after, edit.info)
+		diff.WriteString(requestlog.UnifiedDiff(edit.path, edit.before, edit.after))
+	}
+
+	text := fmt.Sprintf("✅ Refactored %d file(s):\n", len(edits))
+	for _, step := range plan.Files {
+		text += fmt.Sprintf("- %s: %s\n", step.Path, step.Change)
+	}
+	if len(warnings) > 0 {
+		text += "\n⚠️ Validation warnings:\n" + strings.Join(warnings, "\n") + "\n"
+	}
+	if writeOnly {
+		text += "\n(Diff omitted to save context - use write_only: false to see changes)"
+	} else if diff.Len() > 0 {
+		text += "\n```diff\n" + diff.String() + "```"
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"content": []Content{{Type: "text", Text: text}},
+		},
+	}, nil
+}
+
+// planRefactor asks the model which of files the refactor changes, in what
+// order, and what each change is
+func (s *Server) planRefactor(ctx context.Context, instruction string, files, contextFiles []string, provider, model string) (*refactorPlan, error) {
+	prompt := fmt.Sprintf(`Plan a refactor that spans several files. Do not write any code yet.
+
+Refactor: %s
+
+Files the refactor may change or create:
+- %s
+
+Reply with only a JSON object of the form {"files": [{"path": "<one of the files above>", "change": "<what to change in that file>"}]}, listing only the files that need to change, in the order they should be changed.`,
+		instruction, strings.Join(files, "\n- "))
+
+	var existing []string
+	for _, file := range append(slices.Clone(files), contextFiles...) {
+		if _, err := utils.ReadFileContent(file); err == nil && !slices.Contains(existing, file) {
+			existing = append(existing, file)
+		}
+	}
+
+	response, err := s.router.GenerateCodeWithValidation(ctx, prompt, "", existing, provider, model, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan the refactor: %w", err)
+	}
+	return parseRefactorPlan(response, files)
+}
+
+// parseRefactorPlan reads the plan from the model's response, which may
+// wrap the JSON in prose, and checks it only names files from files
+func parseRefactorPlan(response string, files []string) (*refactorPlan, error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the refactor plan is not JSON: %q", response)
+	}
+	var plan refactorPlan
+	if err := json.Unmarshal([]byte(response[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("the refactor plan is not valid JSON: %w", err)
+	}
+
+	var steps []refactorStep
+	for _, step := range plan.Files {
+		step.Path = filepath.Clean(step.Path)
+		if !slices.Contains(files, step.Path) {
+			return nil, fmt.Errorf("the refactor plan changes %s, which is not in files", step.Path)
+		}
+		if slices.ContainsFunc(steps, func(s refactorStep) bool { return s.Path == step.Path }) {
+			continue
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("the refactor plan changes no files")
+	}
+	plan.Files = steps
+	return &plan, nil
+}
+
+// refactorPrompt builds the generation prompt for step i of plan, including
+// the whole plan and the new content of the files already generated
+func refactorPrompt(instruction string, plan *refactorPlan, i int, done []refactorEdit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\nThis file's part of the refactor: %s\n", instruction, plan.Files[i].Change)
+	if len(plan.Files) > 1 {
+		b.WriteString("\nThe whole refactor plan:\n")
+		for _, step := range plan.Files {
+			fmt.Fprintf(&b, "- %s: %s\n", step.Path, step.Change)
+		}
+	}
+	for _, edit := range done {
+		fmt.Fprintf(&b, "\nAlready refactored, new content of %s:\n```\n%s\n```\n", edit.path, edit.after)
+	}
+	return b.String()
+}
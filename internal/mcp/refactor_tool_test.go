@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestRefactorToolPlansAndWritesAllFiles(t *testing.T) {
+	dir := t.TempDir()
+	lib, caller := filepath.Join(dir, "lib.go"), filepath.Join(dir, "main.go")
+	os.WriteFile(lib, []byte("package main\n\nfunc Old() {}\n"), 0600)
+	os.WriteFile(caller, []byte("package main\n\nfunc main() { Old() }\n"), 0600)
+
+	var prompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[len(req.Messages)-1].Content
+		prompts = append(prompts, prompt)
+
+		var content string
+		switch {
+		case strings.Contains(prompt, "Plan a refactor"):
+			plan, _ := json.Marshal(refactorPlan{Files: []refactorStep{
+				{Path: lib, Change: "rename Old to New"},
+				{Path: caller, Change: "call New"},
+			}})
+			content = "Here is the plan:\n" + string(plan)
+		case strings.Contains(prompt, "part of the refactor: call New"):
+			content = "package main\n\nfunc main() { New() }\n"
+		default:
+			content = "package main\n\nfunc New() {}\n"
+		}
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, content)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	args := map[string]interface{}{
+		"instruction": "rename Old to New",
+		"files":       []interface{}{lib, caller},
+	}
+	resp, err := s.handleRefactorTool(context.Background(), &Request{ID: 1}, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := resp.Result.(map[string]interface{})["content"].([]Content)[0].Text
+	if !strings.Contains(text, "Refactored 2 file(s)") || !strings.Contains(text, "+func New() {}") {
+		t.Errorf("response = %s", text)
+	}
+
+	for path, want := range map[string]string{lib: "func New() {}", caller: "New()"} {
+		if data, _ := os.ReadFile(path); !strings.Contains(string(data), want) {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+		}
+	}
+	// The second file is generated knowing the first's new content
+	if len(prompts) != 3 || !strings.Contains(prompts[2], "func New() {}") {
+		t.Errorf("main.go prompt does not include lib.go's new content: %q", prompts)
+	}
+}
+
+func TestParseRefactorPlanRejectsUnlistedFiles(t *testing.T) {
+	_, err := parseRefactorPlan(`{"files":[{"path":"/etc/passwd","change":"x"}]}`, []string{"/src/a.go"})
+	if err == nil || !strings.Contains(err.Error(), "not in files") {
+		t.Errorf("error = %v, want not in files", err)
+	}
+}
@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/requestlog"
+)
+
+// recordRequest adds a write tool call to the dashboard's request log, if
+// enabled. A nil err means after was written to filePath.
+func (s *Server) recordRequest(operation, filePath, before, after string, validate bool, warnings []string, info *router.GenerationInfo, latency time.Duration, err error) {
+	if s.requests == nil {
+		return
+	}
+
+	entry := requestlog.Entry{
+		FilePath:   filePath,
+		Operation:  operation,
+		LatencyMs:  latency.Milliseconds(),
+		Validation: requestlog.ValidationSkipped,
+		Warnings:   append([]string(nil), warnings...),
+	}
+	if info != nil {
+		entry.Provider = info.Provider
+		entry.Model = info.Model
+		entry.Cached = info.Cached
+		if info.Usage != nil {
+			entry.PromptTokens = info.Usage.PromptTokens
+			entry.CompletionTokens = info.Usage.CompletionTokens
+			entry.TotalTokens = info.Usage.TotalTokens
+			entry.CachedTokens = info.Usage.CachedTokens
+		}
+	}
+
+	switch {
+	case err != nil:
+		entry.Validation = requestlog.ValidationFailed
+		entry.Error = err.Error()
+	case validate && len(warnings) > 0:
+		entry.Validation = requestlog.ValidationWarnings
+	case validate:
+		entry.Validation = requestlog.ValidationPassed
+	}
+	if err == nil {
+		entry.Diff = requestlog.UnifiedDiff(filePath, before, after)
+	}
+
+	s.requests.Add(entry)
+}
@@ -0,0 +1,216 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
+)
+
+// operationReview is the request log operation for the read-only review tool
+const operationReview = "review"
+
+// codeReview is the model's structured review of a file or diff
+type codeReview struct {
+	Summary string        `json:"summary"`
+	Risk    string        `json:"risk"` // low, medium or high
+	Issues  []reviewIssue `json:"issues"`
+}
+
+// reviewIssue is one problem found by a review
+type reviewIssue struct {
+	Line        int    `json:"line,omitempty"`
+	Severity    string `json:"severity"` // info, warning or error
+	Description string `json:"description"`
+	Fix         string `json:"fix,omitempty"`
+}
+
+// handleReviewTool sends a file or a diff to a provider for review and
+// returns its issues, risk rating and suggested fixes; nothing is written
+func (s *Server) handleReviewTool(ctx context.Context, request *Request, arguments *map[string]interface{}) (*Response, error) {
+	contextFiles, err := extractStringSliceArg(arguments, "context_files")
+	if err != nil {
+		return nil, fmt.Errorf("context_files must be an array of strings: %w", err)
+	}
+	var filePath, diff, focus, requestedProvider, requestedModel string
+	for key, value := range map[string]*string{"file_path": &filePath, "diff": &diff, "focus": &focus, "provider": &requestedProvider, "model": &requestedModel} {
+		if _, exists := (*arguments)[key]; !exists {
+			continue
+		}
+		if *value, err = extractStringArg(arguments, key); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+	if ctx, err = withReasoningEffort(ctx, arguments); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	if filePath == "" && strings.TrimSpace(diff) == "" {
+		return s.createErrorResponse(request, fmt.Errorf("file_path or diff is required"))
+	}
+	sarif := extractBoolArg(arguments, "sarif")
+	if sarif && filePath == "" {
+		return s.createErrorResponse(request, fmt.Errorf("sarif requires file_path, which the findings are reported against"))
+	}
+
+	if contextFiles, err = s.expandContextFiles(contextFiles); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	for _, file := range contextFiles {
+		if err := s.checkWorkspacePath("context file", file); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+	if filePath != "" {
+		if err := s.checkWorkspacePath("file_path", filePath); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+		contextFiles = append([]string{filePath}, contextFiles...)
+	}
+
+	prompt := reviewPrompt(filePath, diff, focus)
+	reviewCtx, info := router.WithGenerationInfo(ctx)
+	start := time.Now()
+	response, err := s.router.GenerateCodeWithValidation(reviewCtx, prompt, "", contextFiles, requestedProvider, requestedModel, false, nil)
+	s.recordRequest(operationReview, filePath, "", "", false, nil, info, time.Since(start), err)
+	if err != nil {
+		return s.createErrorResponse(request, fmt.Errorf("review failed: %w", err))
+	}
+
+	review, err := parseReview(response)
+	var text string
+	var sarifLog *validation.SARIFLog
+	if err != nil {
+		// Still useful to the caller, just not structured
+		text = fmt.Sprintf("⚠️ The review is not structured (%v):\n\n%s", err, response)
+	} else {
+		text = review.format()
+		if sarif {
+			sarifLog = validation.NewSARIFLog(s.config.Server.Name, s.config.Server.Version, "", []validation.FileFindings{review.findings(filePath)})
+		}
+	}
+	if info.Provider != "" {
+		text += fmt.Sprintf("\n\n🤖 Reviewed by %s", info.Provider)
+		if info.Model != "" {
+			text += "/" + info.Model
+		}
+	}
+
+	result := map[string]interface{}{
+		"content": []Content{{Type: "text", Text: text}},
+	}
+	if sarifLog != nil {
+		result["sarif"] = sarifLog
+	}
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  result,
+	}, nil
+}
+
+// ReviewChange reviews filePath through r the way the review tool does,
+// focusing on diff when given, and returns the issues found. It is the
+// review behind ci review.
+func ReviewChange(ctx context.Context, r *router.EnhancedRouter, filePath, diff string) (validation.FileFindings, error) {
+	response, err := r.GenerateCodeWithValidation(ctx, reviewPrompt(filePath, diff, ""), "", []string{filePath}, "", "", false, nil)
+	if err != nil {
+		return validation.FileFindings{}, fmt.Errorf("review failed: %w", err)
+	}
+	review, err := parseReview(response)
+	if err != nil {
+		return validation.FileFindings{}, fmt.Errorf("the review of %s is not structured: %w", filePath, err)
+	}
+	return review.findings(filePath), nil
+}
+
+// reviewPrompt asks for a JSON review of filePath, or of diff when given
+func reviewPrompt(filePath, diff, focus string) string {
+	var b strings.Builder
+	if diff != "" {
+		b.WriteString("Review this change")
+		if filePath != "" {
+			fmt.Fprintf(&b, " to %s (its full content is in the context)", filePath)
+		}
+		fmt.Fprintf(&b, ":\n```diff\n%s\n```\n", strings.Trim(diff, "\n"))
+	} else {
+		fmt.Fprintf(&b, "Review %s (its content is in the context).\n", filePath)
+	}
+	b.WriteString("Look for bugs, security problems, error handling gaps and unclear code. Do not rewrite the code.\n")
+	if focus != "" {
+		fmt.Fprintf(&b, "Focus on: %s\n", focus)
+	}
+	b.WriteString(`
+Reply with only a JSON object of the form {"summary": "<one or two sentences>", "risk": "low|medium|high", "issues": [{"line": <line number, or 0>, "severity": "info|warning|error", "description": "<the problem>", "fix": "<the suggested fix>"}]}. Use an empty issues list if there are none.`)
+	return b.String()
+}
+
+// parseReview reads the review from the model's response, which may wrap
+// the JSON in prose
+func parseReview(response string) (*codeReview, error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON in the response")
+	}
+	var review codeReview
+	if err := json.Unmarshal([]byte(response[start:end+1]), &review); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	switch review.Risk = strings.ToLower(review.Risk); review.Risk {
+	case "low", "medium", "high":
+	default:
+		return nil, fmt.Errorf("unknown risk %q", review.Risk)
+	}
+	return &review, nil
+}
+
+// format renders the review for the tool response
+func (r *codeReview) format() string {
+	icon := map[string]string{"low": "🟢", "medium": "🟡", "high": "🔴"}[r.Risk]
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s Risk: %s\n%s\n", icon, r.Risk, r.Summary)
+	if len(r.Issues) == 0 {
+		b.WriteString("\n✅ No issues found")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n🔍 %d issue(s):\n", len(r.Issues))
+	for _, issue := range r.Issues {
+		b.WriteString("- [" + issue.Severity + "]")
+		if issue.Line > 0 {
+			fmt.Fprintf(&b, " line %d:", issue.Line)
+		}
+		b.WriteString(" " + issue.Description + "\n")
+		if issue.Fix != "" {
+			b.WriteString("  Fix: " + issue.Fix + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// findings returns the issues of the review of filePath as SARIF-ready
+// findings, with their severity as the level
+func (r *codeReview) findings(filePath string) validation.FileFindings {
+	findings := validation.FileFindings{
+		FilePath: filePath,
+		Language: validation.DetectLanguage(filePath),
+		RuleID:   validation.ReviewIssueRuleID,
+	}
+	for _, issue := range r.Issues {
+		message := issue.Description
+		if issue.Fix != "" {
+			message += " Fix: " + issue.Fix
+		}
+		level := "note"
+		switch strings.ToLower(issue.Severity) {
+		case "error":
+			level = "error"
+		case "warning":
+			level = "warning"
+		}
+		findings.Errors = append(findings.Errors, validation.ValidationError{Line: issue.Line, Message: message, Level: level})
+	}
+	return findings
+}
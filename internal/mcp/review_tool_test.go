@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
+)
+
+func TestParseReview(t *testing.T) {
+	review, err := parseReview("Sure!\n```json\n{\"summary\": \"ok\", \"risk\": \"High\", \"issues\": [{\"line\": 3, \"severity\": \"error\", \"description\": \"nil map\"}]}\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.Risk != "high" || len(review.Issues) != 1 || review.Issues[0].Line != 3 {
+		t.Errorf("review = %+v", review)
+	}
+	if _, err := parseReview(`{"summary": "ok", "risk": "extreme"}`); err == nil {
+		t.Error("parseReview accepted an unknown risk")
+	}
+}
+
+func TestReviewToolDoesNotWrite(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	original := "package main\n\nvar m map[string]int\n\nfunc main() { m[\"a\"] = 1 }\n"
+	os.WriteFile(file, []byte(original), 0600)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `{"summary": "Writes to a nil map.", "risk": "high", "issues": [{"line": 5, "severity": "error", "description": "m is nil", "fix": "make the map"}]}`
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, content)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	args := map[string]interface{}{"file_path": file}
+	resp, err := s.handleReviewTool(context.Background(), &Request{ID: 1}, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := resp.Result.(map[string]interface{})["content"].([]Content)[0].Text
+	for _, want := range []string{"Risk: high", "line 5: m is nil", "Fix: make the map"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("response missing %q: %s", want, text)
+		}
+	}
+	if data, _ := os.ReadFile(file); string(data) != original {
+		t.Errorf("review changed the file: %q", data)
+	}
+	if _, ok := resp.Result.(map[string]interface{})["sarif"]; ok {
+		t.Error("response has a SARIF log that wasn't asked for")
+	}
+
+	args = map[string]interface{}{"file_path": file, "sarif": true}
+	if resp, err = s.handleReviewTool(context.Background(), &Request{ID: 2}, &args); err != nil {
+		t.Fatal(err)
+	}
+	sarif, ok := resp.Result.(map[string]interface{})["sarif"].(*validation.SARIFLog)
+	if !ok {
+		t.Fatalf("response has no SARIF log: %+v", resp.Result)
+	}
+	results := sarif.Runs[0].Results
+	if len(results) != 1 || results[0].RuleID != validation.ReviewIssueRuleID || results[0].Level != "error" || results[0].Locations[0].PhysicalLocation.Region.StartLine != 5 {
+		t.Errorf("SARIF results = %+v", results)
+	}
+
+	args = map[string]interface{}{"diff": "+x", "sarif": true}
+	if resp, _ = s.handleReviewTool(context.Background(), &Request{ID: 3}, &args); !strings.Contains(resp.Result.(map[string]interface{})["content"].([]Content)[0].Text, "sarif requires file_path") {
+		t.Errorf("sarif without file_path = %+v, want an error", resp.Result)
+	}
+}
+
+func TestReviewChangeFindings(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	os.WriteFile(file, []byte("package main\n\nvar m map[string]int\n\nfunc main() { m[\"a\"] = 1 }\n"), 0600)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `{"summary": "Writes to a nil map.", "risk": "high", "issues": [{"line": 5, "severity": "error", "description": "m is nil", "fix": "make the map"}, {"severity": "info", "description": "no doc comment"}]}`
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, content)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+
+	findings, err := ReviewChange(context.Background(), router.NewEnhancedRouter(cfg, nil), file, "+func main() { m[\"a\"] = 1 }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings.RuleID != validation.ReviewIssueRuleID || len(findings.Errors) != 2 {
+		t.Fatalf("findings = %+v", findings)
+	}
+	if e := findings.Errors[0]; e.Line != 5 || e.Level != "error" || e.Message != "m is nil Fix: make the map" {
+		t.Errorf("first finding = %+v", e)
+	}
+	if e := findings.Errors[1]; e.Level != "note" {
+		t.Errorf("info issue level = %q, want note", e.Level)
+	}
+
+	sarif := validation.NewSARIFLog("mcp-code-api", "test", dir, []validation.FileFindings{findings})
+	if results := sarif.Runs[0].Results; len(results) != 2 || results[0].RuleID != validation.ReviewIssueRuleID || results[1].Level != "note" {
+		t.Errorf("SARIF results = %+v", results)
+	}
+}
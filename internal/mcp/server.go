@@ -2,16 +2,21 @@ package mcp
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/audit"
 	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/requestlog"
+	"github.com/cecil-the-coder/mcp-code-api/internal/tracing"
 )
 
 // Request represents an MCP request
@@ -32,8 +37,9 @@ type Response struct {
 
 // ErrorResponse represents an MCP error
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"` // Kind and provider attempts of a classified failure
 }
 
 // Content type is imported from types package
@@ -44,6 +50,8 @@ type Tool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	// OutputSchema describes the tool's structuredContent, if it returns any
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
 }
 
 // Server represents an MCP server
@@ -52,6 +60,20 @@ type Server struct {
 	router *router.EnhancedRouter
 	reader *bufio.Reader
 	writer *bufio.Writer
+	stdout *os.File // Protocol stream; os.Stdout is redirected while serving
+	audit  *audit.Log
+
+	// requests keeps recent write tool calls for the dashboard
+	requests *requestlog.Log
+
+	// clientInfo is the name/version the MCP client sent in initialize
+	clientInfo string
+
+	// protocolVersion is the MCP revision negotiated in initialize
+	protocolVersion string
+	clientCaps      clientCapabilities
+
+	discardedFrames int
 }
 
 // NewServer creates a new MCP server instance
@@ -63,11 +85,20 @@ func NewServer(cfg *config.Config) *Server {
 	// Create enhanced router
 	enhancedRouter := router.NewEnhancedRouter(cfg, factory)
 
+	auditLog, err := audit.NewLog(cfg.Audit)
+	if err != nil {
+		logger.Warnf("Audit log disabled: %v", err)
+	}
+
 	s := &Server{
 		config: cfg,
 		router: enhancedRouter,
 		reader: bufio.NewReader(os.Stdin),
 		writer: bufio.NewWriter(os.Stdout),
+		stdout: os.Stdout,
+		audit:  auditLog,
+
+		requests: requestlog.New(cfg.Metrics.RequestLog),
 	}
 	return s
 }
@@ -77,8 +108,30 @@ func (s *Server) GetRouter() *router.EnhancedRouter {
 	return s.router
 }
 
+// GetRequestLog returns the log of recent write tool calls (nil if disabled)
+func (s *Server) GetRequestLog() *requestlog.Log {
+	return s.requests
+}
+
+// GetBackupStore returns the write tool's backups, kept for restore_previous
+func (s *Server) GetBackupStore() *FileBackupStore {
+	return globalBackupStore
+}
+
 // Start starts an MCP server
 func (s *Server) Start(ctx context.Context) error {
+	// Keep stray stdout writes (including during provider initialization)
+	// out of the JSON-RPC stream
+	restore := guardStdout()
+	defer restore()
+	if problems := checkStdoutPurity(s.stdout); len(problems) > 0 {
+		for _, problem := range problems {
+			logger.Warnf("Stdout self-check: %s", problem)
+		}
+	} else {
+		logger.Debugf("Stdout self-check passed")
+	}
+
 	// Initialize router with providers
 	if err := s.router.Initialize(ctx); err != nil {
 		return fmt.Errorf("failed to initialize router: %w", err)
@@ -92,20 +145,36 @@ func (s *Server) Start(ctx context.Context) error {
 // messageLoop handles the main message loop for MCP communication
 func (s *Server) messageLoop(ctx context.Context) error {
 	logger.Debugf("Message loop started, waiting for requests...")
-	decoder := json.NewDecoder(s.reader)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
+			// Frames are newline-delimited; reading by line lets a corrupted
+			// frame be skipped without losing the ones that follow it
+			line, readErr := s.reader.ReadBytes('\n')
+			if readErr != nil && readErr != io.EOF {
+				return fmt.Errorf("failed to read request: %w", readErr)
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				if readErr == io.EOF {
+					return nil
+				}
+				continue
+			}
+
 			var request Request
-			if err := decoder.Decode(&request); err != nil {
-				if err == io.EOF {
+			if err := decodeFrame(line, s.config.Server.FrameRecovery, &request); err != nil {
+				if !s.config.Server.FrameRecovery {
+					logger.Debugf("Failed to decode request: %v", err)
+					return fmt.Errorf("failed to decode request: %w", err)
+				}
+				s.discardFrame(line, err)
+				if readErr == io.EOF {
 					return nil
 				}
-				logger.Debugf("Failed to decode request: %v", err)
-				return fmt.Errorf("failed to decode request: %w", err)
+				continue
 			}
 
 			logger.Debugf("Received request: method=%s, id=%v", request.Method, request.ID)
@@ -151,6 +220,14 @@ func (s *Server) handleRequest(ctx context.Context, request *Request) (*Response
 		return s.handleListTools(ctx, request)
 	case "tools/call":
 		return s.handleCallTool(ctx, request)
+	case "resources/list":
+		return s.handleListResources(ctx, request)
+	case "resources/read":
+		return s.handleReadResource(ctx, request)
+	case "prompts/list":
+		return s.handleListPrompts(ctx, request)
+	case "prompts/get":
+		return s.handleGetPrompt(ctx, request)
 	default:
 		logger.Debugf("Unknown method received: %s", request.Method)
 		return nil, fmt.Errorf("unknown method: %s", request.Method)
@@ -159,27 +236,57 @@ func (s *Server) handleRequest(ctx context.Context, request *Request) (*Response
 
 // handleInitialize handles the initialize request
 func (s *Server) handleInitialize(ctx context.Context, request *Request) (*Response, error) {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Capabilities    struct {
+			Roots       *struct{} `json:"roots"`
+			Sampling    *struct{} `json:"sampling"`
+			Elicitation *struct{} `json:"elicitation"`
+		} `json:"capabilities"`
+		ClientInfo struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"clientInfo"`
+	}
+	if err := s.unmarshalParams(request.Params, &params); err == nil && params.ClientInfo.Name != "" {
+		s.clientInfo = params.ClientInfo.Name
+		if params.ClientInfo.Version != "" {
+			s.clientInfo += "/" + params.ClientInfo.Version
+		}
+		logger.Debugf("MCP client: %s", s.clientInfo)
+	}
+	s.protocolVersion = negotiateProtocolVersion(params.ProtocolVersion)
+	s.clientCaps = clientCapabilities{
+		Roots:       params.Capabilities.Roots != nil,
+		Sampling:    params.Capabilities.Sampling != nil,
+		Elicitation: params.Capabilities.Elicitation != nil,
+	}
+	logger.Debugf("MCP protocol %s, client capabilities %+v", s.protocolVersion, s.clientCaps)
+
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      request.ID,
 		Result: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
+			"protocolVersion": s.protocolVersion,
+			// Lists are fixed and resources can't be subscribed to
 			"capabilities": map[string]interface{}{
-				"tools": map[string]interface{}{},
+				"tools":     map[string]interface{}{"listChanged": false},
+				"resources": map[string]interface{}{"subscribe": false, "listChanged": false},
+				"prompts":   map[string]interface{}{"listChanged": false},
 			},
 			"serverInfo": map[string]interface{}{
 				"name":        s.config.Server.Name,
 				"version":     s.config.Server.Version,
 				"description": s.config.Server.Description,
 			},
-			"instructions": buildSystemInstructions(),
+			"instructions": s.router.GetPrompts().Instructions(buildSystemInstructions()),
 		},
 	}, nil
 }
 
 // handleListTools handles the tools/list request
 func (s *Server) handleListTools(ctx context.Context, request *Request) (*Response, error) {
-	tools := s.getTools()
+	tools := s.gateTools(s.getTools())
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      request.ID,
@@ -190,7 +297,7 @@ func (s *Server) handleListTools(ctx context.Context, request *Request) (*Respon
 }
 
 // handleCallTool handles the tools/call request
-func (s *Server) handleCallTool(ctx context.Context, request *Request) (*Response, error) {
+func (s *Server) handleCallTool(ctx context.Context, request *Request) (resp *Response, err error) {
 	var params struct {
 		Name      string                 `json:"name"`
 		Arguments map[string]interface{} `json:"arguments"`
@@ -200,12 +307,29 @@ func (s *Server) handleCallTool(ctx context.Context, request *Request) (*Respons
 		return nil, fmt.Errorf("failed to parse tool call parameters: %w", err)
 	}
 
+	ctx, span := tracing.StartServer(ctx, "mcp.tools/call "+params.Name,
+		tracing.String("rpc.system", "jsonrpc"),
+		tracing.String("rpc.method", request.Method),
+		tracing.String("mcp.tool.name", params.Name),
+	)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	switch params.Name {
 	case "write":
-		return s.handleWriteTool(ctx, request, &params.Arguments)
+		resp, err = s.handleWriteTool(ctx, request, &params.Arguments)
+	case "refactor":
+		resp, err = s.handleRefactorTool(ctx, request, &params.Arguments)
+	case "test_generate":
+		resp, err = s.handleTestGenerateTool(ctx, request, &params.Arguments)
+	case "review":
+		resp, err = s.handleReviewTool(ctx, request, &params.Arguments)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", params.Name)
 	}
+	return s.gateToolResult(resp), err
 }
 
 // getTools returns a list of available tools
@@ -235,9 +359,9 @@ This tool provides AI-powered code generation with:
 - Creates new files automatically
 - Modifies existing files with smart diffs
 - Shows visually enhanced git-style diffs with emoji indicators (✅ additions, ❌ removals, 🔍 changes)
-- Supports context_files for better code understanding
+- Supports context_files (paths, directories or globs like internal/api/**/*.go) for better code understanding
 - Handles all programming languages
-- Automatic syntax validation (Python, Go, JavaScript, TypeScript)
+- Automatic syntax validation (Python, Go, JavaScript, TypeScript, Rust, Java, C/C++, Ruby)
 - Auto-fix for Go code formatting
 - CONTEXT SAVER: Use write_only: true to get minimal response and save 80-95% context
 - UNDO SUPPORT: Automatically backs up files before modification - use restore_previous: true to undo
@@ -266,17 +390,72 @@ This tool provides AI-powered code generation with:
 					"type": "array",
 					"items": map[string]interface{}{
 						"type":        "string",
-						"description": "OPTIONAL: Array of file paths to include as context for the model. These files will be read and their content included to help understand the codebase structure and patterns.",
+						"description": "OPTIONAL: Array of file paths, directories or glob patterns (e.g. 'internal/api/**/*.go') to include as context for the model. These files will be read and their content included to help understand the codebase structure and patterns.",
 					},
 				},
+				"provider": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Send this request only to the named provider (e.g. 'cerebras', 'anthropic' or a custom provider name) instead of trying the configured providers in order. It must be enabled in the server configuration. Default: configured order with failover",
+				},
+				"model": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Model to use for this request instead of the provider's configured one, e.g. 'qwen-3-coder-480b'. Requires provider, or give both as 'provider/model' (e.g. 'openrouter/qwen/qwen3-coder'). Unknown models are rejected. Default: the provider's configured model",
+				},
+				"reasoning_effort": map[string]interface{}{
+					"type":        "string",
+					"enum":        api.ReasoningEfforts,
+					"description": "OPTIONAL: How hard reasoning models (OpenAI o-series and gpt-5, directly, on Azure or through OpenRouter) think before answering. Higher effort is slower and uses more tokens. Ignored by other models. Default: the provider's reasoning_effort setting",
+				},
+				"timeout": map[string]interface{}{
+					"type":        "number",
+					"description": "OPTIONAL: Seconds the whole generation may take, across retries and provider fallbacks. Default: no limit beyond each provider's timeout",
+				},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: 'overwrite' regenerates the whole file. 'append' generates only new code and adds it at the end of the file. 'insert_after:<anchor>' generates only new code and inserts it after the line containing <anchor>, which must appear exactly once in the file (e.g. 'insert_after:func main() {'). Validation checks the merged file. Default: overwrite",
+				},
 				"write_only": map[string]interface{}{
 					"type":        "boolean",
 					"description": "OPTIONAL: When true, returns a minimal success message instead of the full diff. This significantly reduces context usage in the conversation. Set to true when you don't need to see the changes. Default: false",
 				},
+				"diff_format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"unified", "side-by-side", "emoji", "stats-only"},
+					"description": "OPTIONAL: How the response shows the changes. 'unified' is a plain unified diff with hunk headers that can be piped into review tools (git apply, reviewdog), 'side-by-side' shows old and new lines in two columns, 'emoji' is the decorated summary, 'stats-only' is just the count of added and removed lines. Ignored with write_only. Default: emoji",
+				},
+				"max_response_length": map[string]interface{}{
+					"type":        "number",
+					"description": "OPTIONAL: Bytes of response text to return at most. A longer diff is left out as with write_only, with the resource URI it can be read from; other text beyond the limit is truncated. Default: the server's responses.max_length (no limit unless configured)",
+				},
 				"validate": map[string]interface{}{
 					"type":        "boolean",
 					"description": "OPTIONAL: When true, validates code syntax before writing using language-specific validators (gofmt, node, python, tsc). Automatically enabled when write_only is true. If validation fails and auto-fix is available (e.g., gofmt for Go), attempts to fix automatically. Otherwise returns error message for the AI to fix. Default: false (true if write_only is true)",
 				},
+				"validation_retries": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     0,
+					"description": "OPTIONAL: How many times a provider is asked to fix code that failed validation before it counts as failed. Default: the server's validation.retries (2)",
+				},
+				"validation_fallback": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: When false, code that still fails validation after the retries fails the request instead of trying the next provider. Default: the server's validation.fallback (true)",
+				},
+				"allow_unvalidated": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: When true and no provider produces code that passes validation, write the last attempt anyway with a warning; the result reports validated: false. Default: the server's validation.allow_unvalidated (false)",
+				},
+				"auto_context": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: Add the snippets of the repository index most relevant to the prompt as context; the result lists them in retrieved_context. Only when the server's index is enabled. Default: the server's index.auto_context (true)",
+				},
+				"expected_base_checksum": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: SHA-256 (hex) of the file content this edit is based on, typically the 'checksum' returned by a previous write. If the file on disk no longer matches, the write is rejected without calling a provider so you can detect external modifications between steps; it is checked again just before writing, so changes made during generation are not overwritten either. Use the SHA-256 of an empty string for a file that should not exist yet.",
+				},
+				"sarif": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: When true, the result includes a 'sarif' field with syntax validation findings for the written file in SARIF 2.1.0 format, for upload to code scanning or other tooling. Default: false",
+				},
 				"restore_previous": map[string]interface{}{
 					"type":        "boolean",
 					"description": "OPTIONAL: When true, restores the previous version of the file from the in-memory backup. The backup is created automatically each time a file is modified. This allows you to undo the last change made to a file. Note: Only works for files modified in the current session, and the backup is cleared after restore. When using this parameter, you only need to provide file_path (prompt is not required). Default: false",
@@ -284,9 +463,168 @@ This tool provides AI-powered code generation with:
 			},
 			"required": []string{"file_path"},
 		},
+		OutputSchema: writeOutputSchema,
 	}
 
-	return []Tool{writeTool}
+	refactorTool := Tool{
+		Name: "refactor",
+		Description: `Coordinated AI refactor across several files.
+
+Give a high-level instruction (e.g. "rename Config.Timeout to RequestTimeout and update all callers") and the files it may touch. The tool plans which files change and how, generates each file with the others as context (later files see the earlier files' new content), validates each one, and writes them only if every file succeeded. Returns the plan and a combined diff. Undo a file with the write tool's restore_previous.`,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"instruction": map[string]interface{}{
+					"type":        "string",
+					"description": "REQUIRED: The refactor to make, described at the level of the whole change",
+				},
+				"files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "REQUIRED: Absolute paths, directories or globs of the files the refactor may change. List a path that does not exist yet to let the refactor create it",
+				},
+				"context_files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "OPTIONAL: Further files (paths, directories or globs) to read for context but not change",
+				},
+				"provider": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Send every request of this refactor only to the named provider. Default: configured order with failover",
+				},
+				"model": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Model to use instead of the provider's configured one; requires provider, or use 'provider/model'",
+				},
+				"reasoning_effort": map[string]interface{}{
+					"type":        "string",
+					"enum":        api.ReasoningEfforts,
+					"description": "OPTIONAL: How hard reasoning models think before answering; ignored by other models. Default: the provider's reasoning_effort setting",
+				},
+				"validate": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: Validate each generated file and retry on syntax errors. Default: true",
+				},
+				"validation_retries": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     0,
+					"description": "OPTIONAL: Validation retries per provider for each file. Default: the server's validation.retries (2)",
+				},
+				"validation_fallback": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: When false, a file that still fails validation fails the refactor instead of trying the next provider. Default: the server's validation.fallback (true)",
+				},
+				"allow_unvalidated": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: Write a file's last attempt with a warning when no provider gets it to pass validation. Default: the server's validation.allow_unvalidated (false)",
+				},
+				"write_only": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: Return only the plan, not the combined diff. Default: false",
+				},
+			},
+			"required": []string{"instruction", "files"},
+		},
+	}
+
+	testGenerateTool := Tool{
+		Name: "test_generate",
+		Description: `Generate the test file for a source file.
+
+The source file is sent as context automatically and the test file is named by the language's convention (foo.go -> foo_test.go, foo.py -> test_foo.py, foo.ts -> foo.test.ts, src/main/.../Foo.java -> src/test/.../FooTest.java, foo.rb -> foo_test.rb). With run_tests, the tests are run (go test for Go, pytest for Python) and, if they fail, regenerated once with the failures.`,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "REQUIRED: Absolute path to the source file to test",
+				},
+				"test_path": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Absolute path of the test file. Default: the language's naming convention",
+				},
+				"prompt": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Further instructions, e.g. which cases to cover or which test helpers to use",
+				},
+				"context_files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "OPTIONAL: Further files (paths, directories or globs) to read for context, e.g. existing tests to match",
+				},
+				"run_tests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: Run the generated tests (Go and Python) and regenerate once if they fail. Default: false",
+				},
+				"provider": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Use only the named provider. Default: configured order with failover",
+				},
+				"model": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Model to use instead of the provider's configured one; requires provider, or use 'provider/model'",
+				},
+				"reasoning_effort": map[string]interface{}{
+					"type":        "string",
+					"enum":        api.ReasoningEfforts,
+					"description": "OPTIONAL: How hard reasoning models think before answering; ignored by other models. Default: the provider's reasoning_effort setting",
+				},
+				"write_only": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: Omit the diff from the response. Default: false",
+				},
+			},
+			"required": []string{"file_path"},
+		},
+	}
+
+	reviewTool := Tool{
+		Name: "review",
+		Description: `Read-only AI code review of a file or a diff.
+
+Sends the file (or the diff, with the file as context) to a provider and returns a summary, a risk rating (low, medium or high) and a list of issues with line numbers and suggested fixes. Nothing is written, so this is a cheap way to get a second opinion from a fast provider.`,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the file to review. Required unless diff is given",
+				},
+				"diff": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Unified diff to review instead of the whole file; file_path, if given, is sent as context",
+				},
+				"focus": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: What to concentrate on, e.g. 'concurrency' or 'input validation'",
+				},
+				"sarif": map[string]interface{}{
+					"type":        "boolean",
+					"description": "OPTIONAL: When true, the result includes a 'sarif' field with the issues found in file_path in SARIF 2.1.0 format, at their severity, for upload to code scanning or other tooling. Requires file_path. Default: false",
+				},
+				"context_files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "OPTIONAL: Further files (paths, directories or globs) to read for context",
+				},
+				"provider": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Use only the named provider. Default: configured order with failover",
+				},
+				"model": map[string]interface{}{
+					"type":        "string",
+					"description": "OPTIONAL: Model to use instead of the provider's configured one; requires provider, or use 'provider/model'",
+				},
+				"reasoning_effort": map[string]interface{}{
+					"type":        "string",
+					"enum":        api.ReasoningEfforts,
+					"description": "OPTIONAL: How hard reasoning models think before answering; ignored by other models. Default: the provider's reasoning_effort setting",
+				},
+			},
+		},
+	}
+
+	return []Tool{writeTool, refactorTool, testGenerateTool, reviewTool}
 }
 
 // sendResponse sends a response to the client
@@ -322,10 +660,7 @@ func (s *Server) sendErrorResponse(request *Request, err error) {
 	errorResponse := &Response{
 		JSONRPC: "2.0",
 		ID:      request.ID,
-		Error: &ErrorResponse{
-			Code:    -1,
-			Message: err.Error(),
-		},
+		Error:   newErrorResponse(err),
 	}
 
 	data, marshalErr := json.Marshal(errorResponse)
@@ -0,0 +1,131 @@
+package mcp
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+)
+
+// MCP resources with the same live data as the HTTP dashboard, so IDEs can
+// show provider health without it
+const (
+	providerStatusResourceURI = "mcp://providers/status"
+	metricsSummaryResourceURI = "mcp://metrics/summary"
+)
+
+// providerStatus is one provider's entry in mcp://providers/status
+type providerStatus struct {
+	Name           string    `json:"name"`
+	Healthy        *bool     `json:"healthy,omitempty"` // Unset until the provider has been health checked
+	LastChecked    time.Time `json:"last_checked,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	ResponseTimeMs int64     `json:"response_time_ms,omitempty"`
+	Requests       int64     `json:"requests"`
+	Successes      int64     `json:"successes"`
+	Failures       int64     `json:"failures"`
+	P50LatencyMs   int64     `json:"p50_latency_ms"`
+	P95LatencyMs   int64     `json:"p95_latency_ms"`
+	P50TTFTMs      int64     `json:"p50_ttft_ms,omitempty"` // Time to first token, for providers that stream
+	LastUsed       time.Time `json:"last_used,omitempty"`
+}
+
+// metricsSummary is the content of mcp://metrics/summary
+type metricsSummary struct {
+	Requests         int64              `json:"requests"`
+	Successes        int64              `json:"successes"`
+	Failures         int64              `json:"failures"`
+	FallbackAttempts int64              `json:"fallback_attempts"`
+	TotalTokens      int64              `json:"total_tokens"`
+	P50LatencyMs     int64              `json:"p50_latency_ms"`
+	P95LatencyMs     int64              `json:"p95_latency_ms"`
+	P99LatencyMs     int64              `json:"p99_latency_ms"`
+	Providers        []providerStatus   `json:"providers"`
+	Racing           []api.RacingReport `json:"racing,omitempty"`
+}
+
+// providerStatuses merges health checks and request metrics for each
+// provider; per-model entries ("provider:model") are left out
+func (s *Server) providerStatuses() []providerStatus {
+	health := s.router.GetHealthStatus()
+	var statuses []providerStatus
+	for name, m := range s.router.GetProviderMetrics() {
+		if m.IsModel || strings.Contains(name, ":") {
+			continue
+		}
+		status := providerStatus{
+			Name:         name,
+			Requests:     m.TotalRequests,
+			Successes:    m.SuccessfulRequests,
+			Failures:     m.FailedRequests,
+			P50LatencyMs: m.P50Latency.Milliseconds(),
+			P95LatencyMs: m.P95Latency.Milliseconds(),
+			P50TTFTMs:    m.P50TTFT.Milliseconds(),
+			LastUsed:     m.LastUsed,
+		}
+		if h, ok := health[name]; ok {
+			healthy := h.IsHealthy
+			status.Healthy = &healthy
+			status.LastChecked = h.LastChecked
+			status.Error = h.ErrorMessage
+			status.ResponseTimeMs = h.ResponseTime.Milliseconds()
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// readProviderStatusResource returns each provider's health and request counts
+func (s *Server) readProviderStatusResource(request *Request) (*Response, error) {
+	statuses := s.providerStatuses()
+	if statuses == nil {
+		statuses = []providerStatus{}
+	}
+	return jsonResource(request, providerStatusResourceURI, statuses)
+}
+
+// readMetricsSummaryResource returns router-wide totals and latency
+// percentiles, with the per-provider status and racing statistics
+func (s *Server) readMetricsSummaryResource(request *Request) (*Response, error) {
+	totals := s.router.GetMetrics()
+	latency := s.router.GetOverallLatencyMetrics()
+	summary := metricsSummary{
+		Requests:         totals.TotalRequests,
+		Successes:        totals.SuccessfulRequests,
+		Failures:         totals.FailedRequests,
+		FallbackAttempts: totals.FallbackAttempts,
+		P50LatencyMs:     latency.P50Latency.Milliseconds(),
+		P95LatencyMs:     latency.P95Latency.Milliseconds(),
+		P99LatencyMs:     latency.P99Latency.Milliseconds(),
+		Providers:        s.providerStatuses(),
+	}
+	for _, m := range s.router.GetProviderMetrics() {
+		if !m.IsModel {
+			summary.TotalTokens += m.TotalTokens
+		}
+	}
+	if summary.Providers == nil {
+		summary.Providers = []providerStatus{}
+	}
+	if reports := s.router.GetRacingStats(); len(reports) > 0 {
+		summary.Racing = reports
+	}
+	return jsonResource(request, metricsSummaryResourceURI, summary)
+}
+
+// statusResources lists the status resources for resources/list
+func statusResources() []map[string]interface{} {
+	return []map[string]interface{}{{
+		"uri":         providerStatusResourceURI,
+		"name":        "Provider status",
+		"description": "Health checks and request counts of the enabled providers",
+		"mimeType":    "application/json",
+	}, {
+		"uri":         metricsSummaryResourceURI,
+		"name":        "Metrics summary",
+		"description": "Request totals, latency percentiles, tokens and racing statistics",
+		"mimeType":    "application/json",
+	}}
+}
@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestStatusResources(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"mock"}
+	cfg.Providers.Mock = &config.MockConfig{}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	list, err := s.handleListResources(context.Background(), &Request{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := map[string]bool{}
+	for _, resource := range list.Result.(map[string]interface{})["resources"].([]map[string]interface{}) {
+		listed[resource["uri"].(string)] = true
+	}
+
+	for _, uri := range []string{providerStatusResourceURI, metricsSummaryResourceURI} {
+		if !listed[uri] {
+			t.Errorf("resources/list is missing %s", uri)
+		}
+		resp, err := s.handleReadResource(context.Background(), &Request{ID: 2, Params: map[string]interface{}{"uri": uri}})
+		if err != nil {
+			t.Fatalf("read %s: %v", uri, err)
+		}
+		text := resp.Result.(map[string]interface{})["contents"].([]map[string]interface{})[0]["text"].(string)
+
+		var providers []providerStatus
+		if uri == metricsSummaryResourceURI {
+			var summary metricsSummary
+			if err := json.Unmarshal([]byte(text), &summary); err != nil {
+				t.Fatalf("%s: %v", uri, err)
+			}
+			providers = summary.Providers
+		} else if err := json.Unmarshal([]byte(text), &providers); err != nil {
+			t.Fatalf("%s: %v", uri, err)
+		}
+		if len(providers) != 1 || providers[0].Name != "mock" {
+			t.Errorf("%s providers = %+v, want the mock provider", uri, providers)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// JSON-RPC parse error code, returned when an incoming frame is not valid JSON
+const errCodeParseError = -32700
+
+// maxLoggedFrame bounds how much of a discarded frame is written to the log
+const maxLoggedFrame = 200
+
+// guardStdout reserves the process stdout for JSON-RPC frames. os.Stdout and
+// the standard library logger are pointed at stderr so that stray prints from
+// dependencies cannot corrupt the stream; the server keeps writing through the
+// *os.File it captured at construction. The returned func undoes the redirect.
+func guardStdout() func() {
+	stdout := os.Stdout
+	logOutput := log.Writer()
+
+	os.Stdout = os.Stderr
+	log.SetOutput(os.Stderr)
+
+	return func() {
+		os.Stdout = stdout
+		log.SetOutput(logOutput)
+	}
+}
+
+// checkStdoutPurity verifies that nothing other than the protocol writer can
+// reach the stdout stream and returns a description of every problem found
+func checkStdoutPurity(protocol *os.File) []string {
+	var problems []string
+
+	if protocol == nil {
+		return []string{"protocol stream is not attached to a file"}
+	}
+	if _, err := protocol.Stat(); err != nil {
+		problems = append(problems, fmt.Sprintf("stdout is not usable: %v", err))
+	}
+	if os.Stdout != nil && os.Stdout.Fd() == protocol.Fd() {
+		problems = append(problems, "os.Stdout still writes to the protocol stream")
+	}
+	if f, ok := log.Writer().(*os.File); ok && f.Fd() == protocol.Fd() {
+		problems = append(problems, "standard library logger writes to the protocol stream")
+	}
+	if path := logger.GetLogFile(); path == "/dev/stdout" || path == "/dev/fd/1" {
+		problems = append(problems, fmt.Sprintf("log file %s is the protocol stream", path))
+	}
+
+	return problems
+}
+
+// decodeFrame parses one newline-delimited JSON-RPC frame. With recovery
+// enabled, a frame that has non-JSON noise in front of it (e.g. a partial
+// line printed by a dependency) is resynchronized at the first '{'.
+func decodeFrame(line []byte, recovery bool, request *Request) error {
+	err := json.Unmarshal(line, request)
+	if err == nil || !recovery {
+		return err
+	}
+
+	start := bytes.IndexByte(line, '{')
+	if start <= 0 {
+		return err
+	}
+	if retryErr := json.Unmarshal(line[start:], request); retryErr != nil {
+		return err
+	}
+	logger.Warnf("Resynchronized JSON-RPC stream after discarding %d byte(s): %q",
+		start, truncateFrame(line[:start]))
+	return nil
+}
+
+// discardFrame reports a frame that could not be decoded and tells the client
+// with a parse error so it does not wait for a response that will never come
+func (s *Server) discardFrame(line []byte, err error) {
+	s.discardedFrames++
+	logger.Warnf("Discarding malformed JSON-RPC frame (%d so far): %v: %q",
+		s.discardedFrames, err, truncateFrame(line))
+
+	response := &Response{
+		JSONRPC: "2.0",
+		ID:      nil,
+		Error: &ErrorResponse{
+			Code:    errCodeParseError,
+			Message: fmt.Sprintf("parse error: %v", err),
+		},
+	}
+	if sendErr := s.sendResponse(response); sendErr != nil {
+		logger.Debugf("Failed to send parse error response: %v", sendErr)
+	}
+}
+
+// truncateFrame shortens a frame for logging
+func truncateFrame(line []byte) string {
+	line = bytes.TrimSpace(line)
+	if len(line) > maxLoggedFrame {
+		return string(line[:maxLoggedFrame]) + "..."
+	}
+	return string(line)
+}
@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestMessageLoopRecoversFromCorruptedFrames(t *testing.T) {
+	input := strings.Join([]string{
+		`Loading plugins...`,
+		`noise{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	s := &Server{
+		config: &config.Config{Server: config.ServerConfig{FrameRecovery: true}},
+		reader: bufio.NewReader(strings.NewReader(input)),
+		writer: bufio.NewWriter(&out),
+	}
+	if err := s.messageLoop(context.Background()); err != nil {
+		t.Fatalf("messageLoop() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d response frames, want 3:\n%s", len(lines), out.String())
+	}
+
+	var parseErr Response
+	if err := json.Unmarshal([]byte(lines[0]), &parseErr); err != nil {
+		t.Fatalf("invalid frame %q: %v", lines[0], err)
+	}
+	if parseErr.ID != nil || parseErr.Error == nil || parseErr.Error.Code != errCodeParseError {
+		t.Errorf("first frame = %s, want parse error with null id", lines[0])
+	}
+	for i, want := range []float64{1, 2} {
+		var resp Response
+		if err := json.Unmarshal([]byte(lines[i+1]), &resp); err != nil {
+			t.Fatalf("invalid frame %q: %v", lines[i+1], err)
+		}
+		if resp.ID != want || resp.Error != nil {
+			t.Errorf("frame %d = %s, want result for id %v", i+1, lines[i+1], want)
+		}
+	}
+}
+
+func TestMessageLoopStrictModeFailsOnCorruptedFrame(t *testing.T) {
+	s := &Server{
+		config: &config.Config{},
+		reader: bufio.NewReader(strings.NewReader("not json\n")),
+		writer: bufio.NewWriter(&bytes.Buffer{}),
+	}
+	if err := s.messageLoop(context.Background()); err == nil {
+		t.Error("messageLoop() error = nil, want decode error")
+	}
+}
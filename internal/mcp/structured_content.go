@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/guard"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// Write outcomes reported in structuredContent
+const (
+	outcomeCreated  = "created"
+	outcomeModified = "modified"
+	outcomeRestored = "restored"
+)
+
+// writeOutcome is the machine-readable result of a write tool call, returned
+// as MCP structuredContent next to the text so agents need not parse it
+type writeOutcome struct {
+	Operation string                   `json:"operation"` // created, modified or restored
+	FilePath  string                   `json:"file_path"`
+	Provider  string                   `json:"provider,omitempty"`
+	Model     string                   `json:"model,omitempty"`
+	Cached    bool                     `json:"cached"`
+	Tokens    int                      `json:"tokens"`
+	LatencyMs int64                    `json:"latency_ms"`
+	Validated bool                     `json:"validated"`
+	Checksum  string                   `json:"checksum"`
+	BackupID  string                   `json:"backup_id,omitempty"` // Checksum of the backed-up content; undo with restore_previous
+	Warnings  int                      `json:"warnings"`
+	Attempts  []router.ProviderAttempt `json:"attempts,omitempty"` // Providers tried, failed ones first; more than one means a fallback
+
+	ContextFindings []guard.Finding         `json:"context_findings,omitempty"`  // Context file lines flagged by the prompt-injection guard
+	LicenseFindings []policy.LicenseFinding `json:"license_findings,omitempty"`  // Copies and citations flagged by the license policy
+	Retrieved       []string                `json:"retrieved_context,omitempty"` // Snippets the repository index added, as path:start-end
+}
+
+// newWriteOutcome describes a successful write of content over existing
+func newWriteOutcome(filePath, existing, content string, info *router.GenerationInfo, latency time.Duration, validated bool, warnings int) writeOutcome {
+	outcome := writeOutcome{
+		Operation: outcomeCreated,
+		FilePath:  filePath,
+		LatencyMs: latency.Milliseconds(),
+		Validated: validated,
+		Checksum:  utils.ContentChecksum(content),
+		Warnings:  warnings,
+	}
+	if existing != "" {
+		outcome.Operation = outcomeModified
+		outcome.BackupID = utils.ContentChecksum(existing)
+	}
+	if info != nil {
+		outcome.Provider, outcome.Model, outcome.Cached = info.Provider, info.Model, info.Cached
+		outcome.Attempts = info.Attempts
+		outcome.LicenseFindings = info.LicenseFindings
+		outcome.Retrieved = info.Retrieved
+		if info.Usage != nil {
+			outcome.Tokens = info.Usage.TotalTokens
+		}
+	}
+	return outcome
+}
+
+// writeOutputSchema is the JSON schema of writeOutcome, advertised as the
+// write tool's outputSchema
+var writeOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"operation":  map[string]interface{}{"type": "string", "enum": []string{outcomeCreated, outcomeModified, outcomeRestored}},
+		"file_path":  map[string]interface{}{"type": "string"},
+		"provider":   map[string]interface{}{"type": "string"},
+		"model":      map[string]interface{}{"type": "string"},
+		"cached":     map[string]interface{}{"type": "boolean"},
+		"tokens":     map[string]interface{}{"type": "integer", "description": "Total tokens of the winning attempt; 0 when cached"},
+		"latency_ms": map[string]interface{}{"type": "integer"},
+		"validated":  map[string]interface{}{"type": "boolean", "description": "The written content passed validation"},
+		"checksum":   map[string]interface{}{"type": "string", "description": "SHA-256 of the written content"},
+		"backup_id":  map[string]interface{}{"type": "string", "description": "SHA-256 of the previous content, kept as a backup for restore_previous"},
+		"warnings":   map[string]interface{}{"type": "integer", "description": "Number of validation warnings"},
+		"context_findings": map[string]interface{}{
+			"type":        "array",
+			"description": "Context file lines that look like prompt injections, when the context guard is on",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"file": map[string]interface{}{"type": "string"},
+					"line": map[string]interface{}{"type": "integer"},
+					"rule": map[string]interface{}{"type": "string"},
+					"risk": map[string]interface{}{"type": "string", "enum": []string{guard.RiskHigh, guard.RiskMedium}},
+					"text": map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+		"retrieved_context": map[string]interface{}{
+			"type":        "array",
+			"description": "Snippets the repository index added to the prompt, as path:start-end",
+			"items":       map[string]interface{}{"type": "string"},
+		},
+		"license_findings": map[string]interface{}{
+			"type":        "array",
+			"description": "Blocklisted code copied verbatim and sources the provider cited, when the license policy annotates",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"kind":        map[string]interface{}{"type": "string", "enum": []string{policy.LicenseFindingBlocklist, policy.LicenseFindingCitation}},
+					"source":      map[string]interface{}{"type": "string"},
+					"license":     map[string]interface{}{"type": "string"},
+					"line":        map[string]interface{}{"type": "integer"},
+					"lines":       map[string]interface{}{"type": "integer"},
+					"source_line": map[string]interface{}{"type": "integer"},
+				},
+			},
+		},
+		"attempts": map[string]interface{}{
+			"type":        "array",
+			"description": "Providers tried in order; failed ones carry the error kind, so a working fallback behind a broken primary is visible",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"provider":            map[string]interface{}{"type": "string"},
+					"model":               map[string]interface{}{"type": "string"},
+					"duration_ms":         map[string]interface{}{"type": "integer"},
+					"kind":                map[string]interface{}{"type": "string", "description": "Error class of a failed attempt: auth_error, rate_limited, context_too_large, validation_failed, license_violation or provider_error"},
+					"status_code":         map[string]interface{}{"type": "integer"},
+					"retry_after_seconds": map[string]interface{}{"type": "number"},
+					"error":               map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"provider", "duration_ms"},
+			},
+		},
+	},
+	"required": []string{"operation", "file_path", "checksum"},
+}
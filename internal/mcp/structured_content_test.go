@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+func TestWriteToolReturnsStructuredContent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	original := "package main\n\nfunc main() {}\n"
+	os.WriteFile(file, []byte(original), 0600)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+			"package main\n\nfunc main() { println(1) }\n")
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	args := map[string]interface{}{"file_path": file, "prompt": "print 1", "write_only": true}
+	resp, err := s.handleWriteTool(context.Background(), &Request{ID: 1}, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcome, ok := resp.Result.(map[string]interface{})["structuredContent"].(writeOutcome)
+	if !ok {
+		t.Fatalf("no structuredContent in %v", resp.Result)
+	}
+	if outcome.Operation != outcomeModified || outcome.Provider != "stub" || outcome.Tokens != 15 || !outcome.Validated {
+		t.Errorf("outcome = %+v", outcome)
+	}
+	if outcome.BackupID != utils.ContentChecksum(original) {
+		t.Errorf("backup_id = %q, want the checksum of the original content", outcome.BackupID)
+	}
+}
+
+func TestWriteToolDiffFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	os.WriteFile(file, []byte("package main\n\nfunc main() {}\n"), 0600)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, "package main\n\nfunc main() { println(1) }\n")
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"differ"}
+	cfg.Providers.Order = []string{"differ"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"differ": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	args := map[string]interface{}{"file_path": file, "prompt": "print 1", "diff_format": "unified"}
+	resp, err := s.handleWriteTool(context.Background(), &Request{ID: 1}, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := resp.Result.(map[string]interface{})["content"].([]Content)
+	want := "--- a" + file + "\n+++ b" + file + "\n@@ -1,3 +1,3 @@\n package main\n \n-func main() {}\n+func main() { println(1) }\n"
+	if len(content) != 1 || content[0].Text != want {
+		t.Errorf("content = %+v, want only the unified diff\n%s", content, want)
+	}
+
+	args = map[string]interface{}{"file_path": file, "prompt": "print 1", "diff_format": "html"}
+	resp, _ = s.handleWriteTool(context.Background(), &Request{ID: 2}, &args)
+	if text := resp.Result.(map[string]interface{})["content"].([]Content)[0].Text; !strings.Contains(text, "unknown diff_format") {
+		t.Errorf("diff_format html response = %q, want an error", text)
+	}
+}
@@ -0,0 +1,224 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/audit"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/requestlog"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+const (
+	// testRunTimeout bounds one run of the generated tests
+	testRunTimeout = 5 * time.Minute
+
+	// maxTestOutput is how much of a failing run's output is kept, from the end
+	maxTestOutput = 8 << 10
+)
+
+// testFilePath returns the conventional test file for a source file, or ""
+// if its language has no convention here
+func testFilePath(sourcePath string) string {
+	dir, base := filepath.Split(sourcePath)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	switch utils.GetLanguageFromFile(sourcePath, nil) {
+	case "go", "ruby":
+		return filepath.Join(dir, name+"_test"+ext)
+	case "python":
+		return filepath.Join(dir, "test_"+base)
+	case "javascript", "typescript":
+		return filepath.Join(dir, name+".test"+ext)
+	case "java":
+		// Maven and Gradle keep tests in a parallel src/test tree
+		if main := string(filepath.Separator) + filepath.Join("src", "main") + string(filepath.Separator); strings.Contains(dir, main) {
+			dir = strings.Replace(dir, main, string(filepath.Separator)+filepath.Join("src", "test")+string(filepath.Separator), 1)
+		}
+		return filepath.Join(dir, name+"Test"+ext)
+	}
+	return ""
+}
+
+// testCommand returns the command that runs the tests in testPath, or nil
+// if the language has no supported runner
+func testCommand(ctx context.Context, testPath string) *exec.Cmd {
+	var cmd *exec.Cmd
+	switch utils.GetLanguageFromFile(testPath, nil) {
+	case "go":
+		cmd = exec.CommandContext(ctx, "go", "test", ".")
+	case "python":
+		cmd = exec.CommandContext(ctx, "pytest", "-q", filepath.Base(testPath))
+	default:
+		return nil
+	}
+	cmd.Dir = filepath.Dir(testPath)
+	return cmd
+}
+
+// runTests runs the tests in testPath and returns whether they passed and
+// the tail of their output
+func runTests(ctx context.Context, testPath string) (passed bool, output string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, testRunTimeout)
+	defer cancel()
+	cmd := testCommand(ctx, testPath)
+	if cmd == nil {
+		return false, "", fmt.Errorf("run_tests supports Go and Python test files, not %s", filepath.Base(testPath))
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	runErr := cmd.Run()
+	output = out.String()
+	if len(output) > maxTestOutput {
+		output = "...\n" + output[len(output)-maxTestOutput:]
+	}
+	if runErr != nil {
+		if _, failed := runErr.(*exec.ExitError); !failed {
+			return false, output, fmt.Errorf("failed to run %s: %w", strings.Join(cmd.Args, " "), runErr)
+		}
+		return false, output, nil
+	}
+	return true, output, nil
+}
+
+// handleTestGenerateTool generates the test file for a source file, with
+// the source as context, and optionally runs the tests, regenerating once
+// with the failures if they don't pass
+func (s *Server) handleTestGenerateTool(ctx context.Context, request *Request, arguments *map[string]interface{}) (*Response, error) {
+	sourcePath, err := extractStringArg(arguments, "file_path")
+	if err != nil {
+		return nil, fmt.Errorf("file_path is required: %w", err)
+	}
+	contextFiles, err := extractStringSliceArg(arguments, "context_files")
+	if err != nil {
+		return nil, fmt.Errorf("context_files must be an array of strings: %w", err)
+	}
+	var testPath, instructions, requestedProvider, requestedModel string
+	for key, value := range map[string]*string{"test_path": &testPath, "prompt": &instructions, "provider": &requestedProvider, "model": &requestedModel} {
+		if _, exists := (*arguments)[key]; !exists {
+			continue
+		}
+		if *value, err = extractStringArg(arguments, key); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+	if ctx, err = withReasoningEffort(ctx, arguments); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	runAfter := extractBoolArg(arguments, "run_tests")
+	writeOnly := extractBoolArg(arguments, "write_only")
+
+	source, err := utils.ReadFileContent(sourcePath)
+	if err != nil || source == "" {
+		return s.createErrorResponse(request, fmt.Errorf("cannot read source file %s: %v", sourcePath, err))
+	}
+	if testPath == "" {
+		if testPath = testFilePath(sourcePath); testPath == "" {
+			return s.createErrorResponse(request, fmt.Errorf("no test file convention for %s; pass test_path", filepath.Base(sourcePath)))
+		}
+	}
+	ctx, project, err := withProject(ctx, testPath)
+	if err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	if contextFiles, err = s.expandContextFiles(append(contextFiles, projectContextPaths(project)...)); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	contextFiles = append([]string{sourcePath}, contextFiles...)
+	if err := s.checkWorkspacePaths(testPath, contextFiles); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+
+	existing, _ := utils.ReadFileContent(testPath)
+	operation := audit.OperationCreate
+	if existing != "" {
+		operation = audit.OperationUpdate
+		globalBackupStore.StoreBackup(testPath, existing)
+	}
+
+	var warnings []string
+	warningCallback := func(providerName, message string) {
+		warnings = append(warnings, message)
+		logger.Infof("[VALIDATION] %s", message)
+	}
+
+	prompt := fmt.Sprintf("Write thorough unit tests for %s, covering its exported behavior, edge cases and error paths. Use the language's standard test framework and conventions for %s.",
+		sourcePath, filepath.Base(testPath))
+	if instructions != "" {
+		prompt += "\n\n" + instructions
+	}
+
+	generate := func(prompt string) (string, *router.GenerationInfo, error) {
+		genCtx, info := router.WithGenerationInfo(ctx)
+		start := time.Now()
+		code, err := s.router.GenerateCodeWithValidation(genCtx, prompt, testPath, contextFiles, requestedProvider, requestedModel, true, warningCallback)
+		if err == nil && s.config.Provenance.Enabled {
+			code = s.appendProvenance(code, testPath, prompt, info)
+		}
+		if err == nil {
+			code = utils.MatchTextFormat(existing, code)
+			err = utils.WriteFileContent(testPath, code)
+		}
+		s.recordRequest(operation, testPath, existing, code, true, warnings, info, time.Since(start), err)
+		return code, info, err
+	}
+
+	code, info, err := generate(prompt)
+	if err != nil {
+		return s.createErrorResponse(request, err)
+	}
+
+	var status string
+	if runAfter {
+		passed, output, runErr := runTests(ctx, testPath)
+		if runErr == nil && !passed {
+			logger.Infof("Tests in %s failed, regenerating once with the failures", testPath)
+			retryPrompt := fmt.Sprintf("%s\n\n🚨 THE PREVIOUS TESTS FAILED:\n%s\n\nFix the tests. If the failure shows a real bug in %s, keep the test and say so in a comment.",
+				prompt, output, filepath.Base(sourcePath))
+			if code, info, err = generate(retryPrompt); err != nil {
+				return s.createErrorResponse(request, err)
+			}
+			passed, output, runErr = runTests(ctx, testPath)
+		}
+		switch {
+		case runErr != nil:
+			status = "⚠️ Tests not run: " + runErr.Error()
+		case passed:
+			status = "🧪 Tests pass"
+		default:
+			status = "❌ Tests still fail:\n```\n" + output + "\n```"
+		}
+	}
+	s.recordAudit(operation, testPath, prompt, existing, code, info)
+
+	text := fmt.Sprintf("✅ Generated tests for %s\n📝 File: %s\n🔒 SHA-256: %s", filepath.Base(sourcePath), testPath, utils.ContentChecksum(code))
+	if status != "" {
+		text += "\n" + status
+	}
+	if len(warnings) > 0 {
+		text += "\n\n⚠️ Validation warnings:\n" + strings.Join(warnings, "\n")
+	}
+	if writeOnly {
+		text += "\n\n(Full diff omitted to save context - use write_only: false to see changes)"
+	} else if diff := requestlog.UnifiedDiff(testPath, existing, code); diff != "" {
+		text += "\n\n```diff\n" + diff + "```"
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"content":  []Content{{Type: "text", Text: text}},
+			"checksum": utils.ContentChecksum(code),
+		},
+	}, nil
+}
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestTestFilePath(t *testing.T) {
+	for source, want := range map[string]string{
+		"/repo/pkg/foo.go":                   "/repo/pkg/foo_test.go",
+		"/repo/app/foo.py":                   "/repo/app/test_foo.py",
+		"/repo/src/foo.ts":                   "/repo/src/foo.test.ts",
+		"/repo/lib/foo.rb":                   "/repo/lib/foo_test.rb",
+		"/repo/src/main/java/com/x/Foo.java": "/repo/src/test/java/com/x/FooTest.java",
+		"/repo/notes.txt":                    "",
+	} {
+		if got := testFilePath(source); got != want {
+			t.Errorf("testFilePath(%s) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestTestGenerateToolRetriesFailingTests(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "add.go")
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/add\n\ngo 1.21\n"), 0600)
+	os.WriteFile(source, []byte("package add\n\nfunc Add(a, b int) int { return a + b }\n"), 0600)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		want := "3"
+		if calls > 1 {
+			want = "2"
+		}
+		content := "package add\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 1) != " + want + " {\n\t\tt.Fatal(\"wrong sum\")\n\t}\n}\n"
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, content)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	args := map[string]interface{}{"file_path": source, "run_tests": true}
+	resp, err := s.handleTestGenerateTool(context.Background(), &Request{ID: 1}, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := resp.Result.(map[string]interface{})["content"].([]Content)[0].Text
+	if calls != 2 || !strings.Contains(text, "Tests pass") {
+		t.Errorf("calls = %d, response = %s", calls, text)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "add_test.go")); !strings.Contains(string(data), "!= 2") {
+		t.Errorf("add_test.go = %q, want the regenerated tests", data)
+	}
+}
@@ -0,0 +1,192 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// promptArgument is one parameter of an MCP prompt
+type promptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// workflowPrompt is an MCP prompt that expands into a plan of tool calls for
+// a common code generation workflow
+type workflowPrompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Arguments   []promptArgument `json:"arguments"`
+
+	defaults map[string]string // Values of optional arguments the caller left out
+	text     *template.Template
+}
+
+// workflowPrompts are the prompts offered by prompts/list, in listing order
+var workflowPrompts = []*workflowPrompt{
+	{
+		Name:        "new_go_service",
+		Description: "Create a Go service: a type with a constructor and its methods, plus tests",
+		Arguments: []promptArgument{
+			{Name: "name", Description: "Service name, e.g. InvoiceService", Required: true},
+			{Name: "purpose", Description: "What the service does and which methods it needs", Required: true},
+			{Name: "dir", Description: "Absolute path of the package directory (default: the current package)"},
+		},
+		defaults: map[string]string{"dir": "."},
+		text: template.Must(template.New("new_go_service").Parse(`Create a Go service named {{.name}} in {{.dir}}.
+
+Purpose: {{.purpose}}
+
+Plan:
+1. Read the existing files in {{.dir}} to learn the package name, error handling and naming conventions.
+2. Call the write tool with file_path "{{.dir}}/{{.file}}.go", context_files ["{{.dir}}"], and a prompt asking for:
+   - a {{.name}} struct holding its dependencies
+   - a New{{.name}} constructor taking those dependencies
+   - one method per operation in the purpose, taking a context.Context first and returning errors wrapped with fmt.Errorf("...: %w", err)
+   - doc comments on the exported identifiers
+3. Call the test_generate tool with file_path "{{.dir}}/{{.file}}.go" and run_tests true.
+4. If test_generate reports failing tests that show a bug in the service, fix the service with the write tool and run test_generate again.`)),
+	},
+	{
+		Name:        "rest_handler",
+		Description: "Add a REST handler for a resource, with request validation and tests",
+		Arguments: []promptArgument{
+			{Name: "resource", Description: "Resource the handler serves, e.g. orders", Required: true},
+			{Name: "file_path", Description: "Absolute path of the handler file to create or extend", Required: true},
+			{Name: "operations", Description: "Operations to support (default: list, get, create, update, delete)"},
+			{Name: "framework", Description: "HTTP framework or router in use (default: whatever the project already uses)"},
+		},
+		defaults: map[string]string{
+			"operations": "list, get, create, update, delete",
+			"framework":  "whatever the project already uses",
+		},
+		text: template.Must(template.New("rest_handler").Parse(`Add a REST handler for {{.resource}} in {{.file_path}}.
+
+Operations: {{.operations}}
+Framework: {{.framework}}
+
+Plan:
+1. Find where the existing routes are registered and an existing handler to match, and pass both as context_files.
+2. Call the write tool with file_path "{{.file_path}}" and a prompt asking for one handler per operation that:
+   - decodes and validates the request, answering 400 with a JSON error on bad input
+   - answers 404 when the {{.resource}} does not exist
+   - encodes JSON responses with the right status code (201 on create, 204 on delete)
+3. Register the new routes with the write tool (mode "insert_after:<the last route registration>" on the routes file).
+4. Call the test_generate tool with file_path "{{.file_path}}" and run_tests true to cover each operation's success and error cases.`)),
+	},
+	{
+		Name:        "unit_tests",
+		Description: "Write unit tests for a file and check that they pass",
+		Arguments: []promptArgument{
+			{Name: "file_path", Description: "Absolute path of the file to test", Required: true},
+			{Name: "focus", Description: "Behavior to concentrate on (default: exported behavior, edge cases and error paths)"},
+		},
+		defaults: map[string]string{"focus": "exported behavior, edge cases and error paths"},
+		text: template.Must(template.New("unit_tests").Parse(`Write unit tests for {{.file_path}}.
+
+Focus: {{.focus}}
+
+Plan:
+1. Look for existing tests next to {{.file_path}} and pass one as a context file so the new tests match its helpers and style.
+2. Call the test_generate tool with file_path "{{.file_path}}", prompt "Focus on {{.focus}}", the context files from step 1, and run_tests true.
+3. If the tests still fail, read the failures: fix the tests with the write tool if they are wrong, or report the bug in {{.file_path}} if the code is.`)),
+	},
+}
+
+// findWorkflowPrompt returns the prompt named name, or nil
+func findWorkflowPrompt(name string) *workflowPrompt {
+	for _, p := range workflowPrompts {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// render expands the prompt with args, checking the required ones are set
+func (p *workflowPrompt) render(args map[string]string) (string, error) {
+	data := map[string]string{}
+	for key, value := range p.defaults {
+		data[key] = value
+	}
+	for _, arg := range p.Arguments {
+		value := strings.TrimSpace(args[arg.Name])
+		if value == "" {
+			if arg.Required {
+				return "", fmt.Errorf("prompt %s needs argument %s", p.Name, arg.Name)
+			}
+			continue
+		}
+		data[arg.Name] = value
+	}
+	if name, ok := data["name"]; ok {
+		data["file"] = snakeCase(name)
+	}
+
+	var b strings.Builder
+	if err := p.text.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", p.Name, err)
+	}
+	return b.String(), nil
+}
+
+// snakeCase turns a Go identifier such as InvoiceService into invoice_service
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// handleListPrompts handles the prompts/list request
+func (s *Server) handleListPrompts(ctx context.Context, request *Request) (*Response, error) {
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"prompts": workflowPrompts,
+		},
+	}, nil
+}
+
+// handleGetPrompt handles the prompts/get request
+func (s *Server) handleGetPrompt(ctx context.Context, request *Request) (*Response, error) {
+	var params struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := s.unmarshalParams(request.Params, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt parameters: %w", err)
+	}
+
+	prompt := findWorkflowPrompt(params.Name)
+	if prompt == nil {
+		return nil, fmt.Errorf("unknown prompt: %s", params.Name)
+	}
+	text, err := prompt.render(params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"description": prompt.Description,
+			"messages": []map[string]interface{}{{
+				"role":    "user",
+				"content": Content{Type: "text", Text: text},
+			}},
+		},
+	}, nil
+}
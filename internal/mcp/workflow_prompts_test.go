@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGetPromptRendersArguments(t *testing.T) {
+	s := &Server{}
+	params := map[string]interface{}{
+		"name":      "new_go_service",
+		"arguments": map[string]interface{}{"name": "InvoiceService", "purpose": "bill customers", "dir": "/repo/billing"},
+	}
+	resp, err := s.handleGetPrompt(context.Background(), &Request{ID: 1, Params: params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := resp.Result.(map[string]interface{})["messages"].([]map[string]interface{})
+	text := messages[0]["content"].(Content).Text
+	for _, want := range []string{"/repo/billing/invoice_service.go", "NewInvoiceService", "bill customers", "test_generate"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestGetPromptRequiresArguments(t *testing.T) {
+	s := &Server{}
+	params := map[string]interface{}{"name": "unit_tests", "arguments": map[string]interface{}{}}
+	if _, err := s.handleGetPrompt(context.Background(), &Request{ID: 1, Params: params}); err == nil {
+		t.Error("prompts/get accepted a missing required argument")
+	}
+	for _, p := range workflowPrompts {
+		args := map[string]string{}
+		for _, arg := range p.Arguments {
+			args[arg.Name] = "x"
+		}
+		if _, err := p.render(args); err != nil {
+			t.Errorf("%s: %v", p.Name, err)
+		}
+	}
+}
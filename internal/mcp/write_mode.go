@@ -0,0 +1,118 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
+)
+
+// Write tool modes: regenerate the whole file, or generate only code that is
+// added at the end or after an anchor line
+const (
+	writeModeOverwrite   = "overwrite"
+	writeModeAppend      = "append"
+	writeModeInsertAfter = "insert_after"
+)
+
+// writeMode is a parsed write tool mode argument
+type writeMode struct {
+	kind   string
+	anchor string // insert_after only
+}
+
+// parseWriteMode parses "overwrite", "append" or "insert_after:<anchor>";
+// empty means overwrite
+func parseWriteMode(value string) (writeMode, error) {
+	kind, anchor, _ := strings.Cut(value, ":")
+	switch kind {
+	case "", writeModeOverwrite, writeModeAppend:
+		if anchor != "" {
+			return writeMode{}, fmt.Errorf("mode %s does not take an anchor", kind)
+		}
+		if kind == "" {
+			kind = writeModeOverwrite
+		}
+		return writeMode{kind: kind}, nil
+	case writeModeInsertAfter:
+		if strings.TrimSpace(anchor) == "" {
+			return writeMode{}, fmt.Errorf("mode insert_after needs an anchor, e.g. insert_after:func main()")
+		}
+		return writeMode{kind: kind, anchor: anchor}, nil
+	default:
+		return writeMode{}, fmt.Errorf("unknown mode %q (expected overwrite, append or insert_after:<anchor>)", value)
+	}
+}
+
+// partial reports whether the model generates only the new code
+func (m writeMode) partial() bool {
+	return m.kind != writeModeOverwrite
+}
+
+// check verifies the mode can be applied to the file's existing content
+func (m writeMode) check(filePath, existing string) error {
+	if m.kind != writeModeInsertAfter {
+		return nil
+	}
+	if existing == "" {
+		return fmt.Errorf("insert_after needs an existing file; %s is missing or empty", filePath)
+	}
+	switch n := strings.Count(existing, m.anchor); n {
+	case 0:
+		return fmt.Errorf("anchor %q not found in %s", m.anchor, filePath)
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("anchor %q appears %d times in %s; use a longer anchor", m.anchor, n, filePath)
+	}
+}
+
+// prompt adds the instruction to return only the new code
+func (m writeMode) prompt(prompt string) string {
+	switch m.kind {
+	case writeModeAppend:
+		return prompt + "\n\nReturn ONLY the new code to add at the end of the existing file. Do not repeat any existing code."
+	case writeModeInsertAfter:
+		return fmt.Sprintf("%s\n\nReturn ONLY the new code to insert after the line containing %q in the existing file. Do not repeat any existing code.", prompt, m.anchor)
+	}
+	return prompt
+}
+
+// apply merges generated code into the file's existing content
+func (m writeMode) apply(existing, generated string) string {
+	generated = strings.Trim(generated, "\n") + "\n"
+	switch m.kind {
+	case writeModeAppend:
+		if existing == "" {
+			return generated
+		}
+		return strings.TrimRight(existing, "\n") + "\n\n" + generated
+	case writeModeInsertAfter:
+		at := strings.Index(existing, m.anchor) + len(m.anchor)
+		if end := strings.IndexByte(existing[at:], '\n'); end >= 0 {
+			at += end + 1
+		} else {
+			existing += "\n"
+			at = len(existing)
+		}
+		return existing[:at] + generated + existing[at:]
+	}
+	return generated
+}
+
+// validateMerged validates a file after generated code was merged into it;
+// the generated code alone is not a valid file
+func (s *Server) validateMerged(filePath, content string) error {
+	language := validation.DetectLanguage(filePath)
+	if language == validation.LanguageUnknown {
+		return nil
+	}
+	result, err := language.ValidatorFor(s.config.Validation).Validate(content, filePath)
+	if err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if !result.Valid {
+		return fmt.Errorf("the merged file fails validation:\n%s", validation.FormatValidationErrors(result.Errors, language))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteModes(t *testing.T) {
+	existing := "package main\n\nfunc main() {\n}\n"
+
+	for _, tt := range []struct {
+		mode, want string
+	}{
+		{"append", "package main\n\nfunc main() {\n}\n\nfunc helper() {}\n"},
+		{"insert_after:package main", "package main\nfunc helper() {}\n\nfunc main() {\n}\n"},
+		{"insert_after:}", existing + "func helper() {}\n"},
+	} {
+		mode, err := parseWriteMode(tt.mode)
+		if err != nil {
+			t.Fatalf("parseWriteMode(%q) error = %v", tt.mode, err)
+		}
+		if err := mode.check("main.go", existing); err != nil {
+			t.Errorf("%s: check() error = %v", tt.mode, err)
+		}
+		if got := mode.apply(existing, "\nfunc helper() {}\n"); got != tt.want {
+			t.Errorf("%s: apply() = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	// An anchor on the last line without a newline
+	mode, _ := parseWriteMode("insert_after:}")
+	if got := mode.apply("func main() {\n}", "func helper() {}"); got != "func main() {\n}\nfunc helper() {}\n" {
+		t.Errorf("apply() at end of file = %q", got)
+	}
+
+	for _, tt := range []struct {
+		mode, existing, want string
+	}{
+		{"prepend", existing, "unknown mode"},
+		{"append:x", existing, "does not take an anchor"},
+		{"insert_after:", existing, "needs an anchor"},
+		{"insert_after:func helper", existing, "not found"},
+		{"insert_after:main", existing, "appears 2 times"},
+		{"insert_after:main", "", "needs an existing file"},
+	} {
+		mode, err := parseWriteMode(tt.mode)
+		if err == nil {
+			err = mode.check("main.go", tt.existing)
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("mode %q: error = %v, want %q", tt.mode, err, tt.want)
+		}
+	}
+}
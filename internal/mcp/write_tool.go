@@ -8,12 +8,48 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/audit"
 	"github.com/cecil-the-coder/mcp-code-api/internal/formatting"
+	"github.com/cecil-the-coder/mcp-code-api/internal/guard"
+	"github.com/cecil-the-coder/mcp-code-api/internal/index"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/policy"
+	"github.com/cecil-the-coder/mcp-code-api/internal/tracing"
 	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+	"github.com/cecil-the-coder/mcp-code-api/internal/validation"
 )
 
+// checkWorkspacePaths rejects files outside the configured workspace paths
+// and files matching a denied pattern (secrets, credentials). Symlinks are
+// resolved first so a link cannot be used to escape the workspace.
+func (s *Server) checkWorkspacePaths(filePath string, contextFiles []string) error {
+	if err := s.checkWorkspacePath("file_path", filePath); err != nil {
+		return err
+	}
+	for _, file := range contextFiles {
+		if err := s.checkWorkspacePath("context file", file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) checkWorkspacePath(kind, path string) error {
+	if allowed := s.config.Workspace.AllowedPaths; len(allowed) > 0 && !policy.IsPathWithin(path, allowed) {
+		return &sandboxViolationError{fmt.Sprintf("%s %s is outside the allowed workspace paths", kind, path)}
+	}
+	if pattern, denied := policy.MatchDeniedPath(path, s.config.Workspace.DeniedPaths); denied && pattern == "" {
+		return &sandboxViolationError{fmt.Sprintf("%s %s could not be resolved to check the denied workspace paths", kind, path)}
+	} else if denied {
+		return &sandboxViolationError{fmt.Sprintf("%s %s is denied by workspace pattern %q", kind, path, pattern)}
+	}
+	return nil
+}
+
 // handleWriteTool handles the write tool request
 func (s *Server) handleWriteTool(ctx context.Context, request *Request, arguments *map[string]interface{}) (*Response, error) {
 	// Get IDE identification from environment variable
@@ -44,6 +80,95 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 		return nil, fmt.Errorf("context_files must be an array of strings: %w", err)
 	}
 
+	// Optional provider and model for this request only
+	var requestedProvider, requestedModel string
+	for key, value := range map[string]*string{"provider": &requestedProvider, "model": &requestedModel} {
+		if _, exists := (*arguments)[key]; !exists {
+			continue
+		}
+		if *value, err = extractStringArg(arguments, key); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+	if ctx, err = withReasoningEffort(ctx, arguments); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	if ctx, err = withValidationOptions(ctx, arguments); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	if value, exists := (*arguments)["auto_context"]; exists {
+		enabled, ok := value.(bool)
+		if !ok {
+			return s.createErrorResponse(request, fmt.Errorf("auto_context must be a boolean, got %T", value))
+		}
+		ctx = index.WithAutoContext(ctx, enabled)
+	}
+
+	var modeArg string
+	if _, exists := (*arguments)["mode"]; exists {
+		if modeArg, err = extractStringArg(arguments, "mode"); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+	mode, err := parseWriteMode(modeArg)
+	if err != nil {
+		return s.createErrorResponse(request, err)
+	}
+
+	var diffFormatArg string
+	if _, exists := (*arguments)["diff_format"]; exists {
+		if diffFormatArg, err = extractStringArg(arguments, "diff_format"); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+	diffFormat, err := formatting.ParseDiffFormat(diffFormatArg)
+	if err != nil {
+		return s.createErrorResponse(request, err)
+	}
+
+	// Optional bound on the response text, overriding responses.max_length
+	maxLength := s.config.Responses.MaxLength
+	if value, exists := (*arguments)["max_response_length"]; exists {
+		length, ok := value.(float64)
+		if !ok || length <= 0 {
+			return s.createErrorResponse(request, fmt.Errorf("max_response_length must be a positive number of bytes, got %v", value))
+		}
+		maxLength = int(length)
+	}
+
+	// Optional deadline for the whole generation, across retries and fallbacks
+	if value, exists := (*arguments)["timeout"]; exists {
+		seconds, ok := value.(float64)
+		if !ok || seconds <= 0 {
+			return s.createErrorResponse(request, fmt.Errorf("timeout must be a positive number of seconds, got %v", value))
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(seconds*float64(time.Second)))
+		defer cancel()
+	}
+
+	// The project's conventions file may add context and override settings
+	ctx, project, err := withProject(ctx, filePath)
+	if err != nil {
+		return s.createErrorResponse(request, err)
+	}
+	contextFiles = append(contextFiles, projectContextPaths(project)...)
+
+	contextFiles, err = s.expandContextFiles(contextFiles)
+	if err != nil {
+		return s.createErrorResponse(request, err)
+	}
+
+	if err := s.checkWorkspacePaths(filePath, contextFiles); err != nil {
+		return s.createErrorResponse(request, err)
+	}
+
+	// Flag context files that look like prompt injections
+	guardFindings := s.router.ContextGuard().ScanFiles(contextFiles)
+	for _, finding := range guardFindings {
+		logger.Warnf("Context guard: %s:%d looks like a prompt injection (%s, %s risk)", finding.File, finding.Line, finding.Rule, finding.Risk)
+	}
+
 	// Check for write_only flag to reduce context usage
 	writeOnly := extractBoolArg(arguments, "write_only")
 
@@ -55,6 +180,9 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 			validate = true
 		}
 	}
+	if project != nil && project.Validation.Always {
+		validate = true
+	}
 
 	// Check for restore_previous flag to undo last write
 	restorePrevious := extractBoolArg(arguments, "restore_previous")
@@ -62,14 +190,32 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 		return s.handleRestorePrevious(request, filePath)
 	}
 
-	// Check if file exists to determine operation type
-	existingContent, err := utils.ReadFileContent(filePath)
-	isEdit := err == nil && existingContent != ""
+	// Check if file exists to determine operation type. The content is worked
+	// on decoded (UTF-8, \n line ends) and encoded as the file was on write.
+	rawContent, err := utils.ReadFileContent(filePath)
+	isEdit := err == nil && rawContent != ""
+	existingContent, textFormat := utils.DecodeText(rawContent)
+
+	// Refuse to build on a base the caller didn't expect (file changed
+	// externally). This fails fast; the check is repeated before writing.
+	expectedBase, _ := extractStringArg(arguments, "expected_base_checksum")
+	if expectedBase != "" {
+		if err != nil {
+			return s.createErrorResponse(request, fmt.Errorf("failed to read file for checksum: %w", err))
+		}
+		if err := checkBaseChecksum(filePath, rawContent, expectedBase); err != nil {
+			return s.createErrorResponse(request, err)
+		}
+	}
+
+	if err := mode.check(filePath, existingContent); err != nil {
+		return s.createErrorResponse(request, err)
+	}
 
 	// Store backup of existing content before modification
-	if isEdit && existingContent != "" {
-		globalBackupStore.StoreBackup(filePath, existingContent)
-		logger.Debugf("Stored backup for file: %s (%d bytes)", filePath, len(existingContent))
+	if isEdit {
+		globalBackupStore.StoreBackup(filePath, rawContent)
+		logger.Debugf("Stored backup for file: %s (%d bytes)", filePath, len(rawContent))
 	}
 
 	logger.Debug("=== FILE OPERATION DEBUG ===")
@@ -77,6 +223,7 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 	logger.Debugf("File exists: %v", isEdit)
 	logger.Debugf("Existing content length: %d", len(existingContent))
 	logger.Debugf("Validation enabled: %v", validate)
+	logger.Debugf("Mode: %s", mode.kind)
 	logger.Debug("============================")
 
 	// Collect validation warnings
@@ -90,27 +237,133 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 		logger.Infof("[VALIDATION] %s", message)
 	}
 
+	auditOperation := audit.OperationCreate
+	if isEdit {
+		auditOperation = audit.OperationUpdate
+	}
+
 	// Route API call to appropriate provider with validation retry and failover
-	result, err := s.router.GenerateCodeWithValidation(ctx, prompt, filePath, contextFiles, validate, warningCallback)
+	ctx, genInfo := router.WithGenerationInfo(ctx)
+	ctx, sent := withSentContext(ctx)
+	start := time.Now()
+	// In append and insert modes the model returns only the new code, which
+	// is validated once merged into the file
+	result, err := s.router.GenerateCodeWithValidation(ctx, mode.prompt(prompt), filePath, contextFiles, requestedProvider, requestedModel, validate && !mode.partial(), warningCallback)
+	if err == nil && mode.partial() {
+		// The new code alone may not format, so the merged file is formatted
+		result = s.router.FormatCode(ctx, filePath, mode.apply(existingContent, result))
+		if validate {
+			if err = s.validateMerged(filePath, result); err != nil && s.router.ValidationConfig(ctx).AllowUnvalidated {
+				warningCallback("", fmt.Sprintf("⚠️ Writing the merged file unvalidated:\n%v", err))
+				genInfo.Unvalidated, err = true, nil
+			}
+		}
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("generation did not finish within the timeout: %w", err)
+	}
 	if err != nil {
+		tracing.SpanFromContext(ctx).RecordError(err)
+		s.recordRequest(auditOperation, filePath, existingContent, "", validate, warnings, genInfo, time.Since(start), err)
 		// Check if we have warnings to include
-		var errorMsg string
 		if len(warnings) > 0 {
-			errorMsg = fmt.Sprintf("%s\n\nValidation warnings:\n%s", err.Error(), strings.Join(warnings, "\n"))
-		} else {
-			errorMsg = err.Error()
+			err = fmt.Errorf("%w\n\nValidation warnings:\n%s", err, strings.Join(warnings, "\n"))
+		}
+		return s.createErrorResponse(request, err)
+	}
+
+	// Keep a copy of the context as sent for clients that want to show what
+	// was used
+	contextResources := globalContextStore.Capture(sent.Files())
+
+	if len(genInfo.LicenseFindings) > 0 {
+		warningCallback("", "⚠️ License policy: generated code "+policy.FormatLicenseFindings(genInfo.LicenseFindings))
+	}
+
+	if s.config.Provenance.Enabled {
+		result = s.appendProvenance(result, filePath, prompt, genInfo)
+	}
+	// Keep the line ends, final newline and encoding of the file being modified
+	written := result
+	if isEdit {
+		written = textFormat.Apply(result)
+	}
+
+	// The file may have changed while the code was generated
+	if expectedBase != "" {
+		current, err := utils.ReadFileContent(filePath)
+		if err == nil {
+			err = checkBaseChecksum(filePath, current, expectedBase)
+		}
+		if err != nil {
+			s.recordRequest(auditOperation, filePath, existingContent, result, validate, warnings, genInfo, time.Since(start), err)
+			return s.createErrorResponse(request, err)
 		}
-		return s.createErrorResponse(request, fmt.Errorf("%s", errorMsg))
 	}
 
 	// Write the result to the file
-	if err := utils.WriteFileContent(filePath, result); err != nil {
-		return s.createErrorResponse(request, fmt.Errorf("failed to write file: %w", err))
+	if err := utils.WriteFileContent(filePath, written); err != nil {
+		err = fmt.Errorf("failed to write file: %w", err)
+		s.recordRequest(auditOperation, filePath, existingContent, result, validate, warnings, genInfo, time.Since(start), err)
+		return s.createErrorResponse(request, err)
+	}
+	checksum := utils.ContentChecksum(written)
+	latency := time.Since(start)
+
+	s.recordAudit(auditOperation, filePath, prompt, existingContent, result, genInfo)
+	s.recordRequest(auditOperation, filePath, existingContent, result, validate, warnings, genInfo, latency, nil)
+	outcome := newWriteOutcome(filePath, rawContent, written, genInfo, latency, validate && !genInfo.Unvalidated, len(warnings))
+	outcome.ContextFindings = guardFindings
+
+	// Optional SARIF report of validation findings for the written content
+	var sarifLog *validation.SARIFLog
+	if extractBoolArg(arguments, "sarif") {
+		sarifLog = s.buildSARIF(filePath, result)
+	}
+
+	resultFields := func(content []Content) map[string]interface{} {
+		fields := map[string]interface{}{
+			"content":           content,
+			"checksum":          checksum,
+			"contextResources":  contextResources,
+			"structuredContent": outcome,
+		}
+		if sarifLog != nil {
+			fields["sarif"] = sarifLog
+		}
+		// Also outside structuredContent, which older clients don't get, when
+		// a fallback hides a failing provider
+		if len(outcome.Attempts) > 1 {
+			fields["attempts"] = outcome.Attempts
+		}
+		return fields
+	}
+
+	// Render the changes, leaving them out as with write_only if they are too
+	// long; the full diff stays readable as a resource
+	fileName := filepath.Base(filePath)
+	var changes *Content
+	omittedNote := "(Full diff omitted to save context - use write_only: false to see changes)"
+	if !writeOnly {
+		if diffFormat != formatting.DiffFormatEmoji {
+			changes = formatting.FormatDiffResponse(diffFormat, existingContent, result, filePath)
+		} else if isEdit && existingContent != "" {
+			// Clean the existing content too for consistent comparison
+			cleanExistingContent := utils.CleanCodeResponse(existingContent)
+			changes = formatting.FormatEditResponse(fileName, cleanExistingContent, result, filePath)
+		} else if !isEdit {
+			changes = formatting.FormatCreateResponse(fileName, result, filePath)
+		}
+		if threshold := s.diffThreshold(maxLength); changes != nil && threshold > 0 && len(changes.Text) > threshold {
+			uri := globalDiffStore.Put(filePath, changes.Text)
+			omittedNote = fmt.Sprintf("(Diff of %d bytes omitted to save context - read it from resource %s)", len(changes.Text), uri)
+			logger.Debugf("Write response: diff of %d bytes exceeds %d, stored as %s", len(changes.Text), threshold, uri)
+			writeOnly = true
+		}
 	}
 
 	// If write_only is enabled, return minimal response to save context
 	if writeOnly {
-		fileName := filepath.Base(filePath)
 		operation := "created"
 		if isEdit {
 			operation = "updated"
@@ -119,20 +372,23 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 		lineCount := strings.Count(result, "\n") + 1
 
 		// Build response text
-		responseText := fmt.Sprintf("✅ Successfully %s: %s\n📝 File: %s\n💾 Lines: %d",
-			operation, fileName, filePath, lineCount)
+		responseText := fmt.Sprintf("✅ Successfully %s: %s\n📝 File: %s\n💾 Lines: %d\n🔒 SHA-256: %s",
+			operation, fileName, filePath, lineCount, checksum)
 
 		// Add warnings if any
 		if len(warnings) > 0 {
 			responseText += "\n\n⚠️ Validation warnings:\n" + strings.Join(warnings, "\n")
 		}
+		if len(guardFindings) > 0 {
+			responseText += "\n\n" + formatGuardFindings(guardFindings)
+		}
 
-		responseText += "\n\n(Full diff omitted to save context - use write_only: false to see changes)"
+		responseText += "\n\n" + omittedNote
 
-		responseContent := []Content{{
+		responseContent := truncateContent([]Content{{
 			Type: "text",
 			Text: responseText,
-		}}
+		}}, maxLength)
 
 		logger.Debug("=== MCP RESPONSE DEBUG (WRITE_ONLY MODE) ===")
 		logger.Debugf("IDE Source: %s", ideSource)
@@ -144,15 +400,12 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 		return &Response{
 			JSONRPC: "2.0",
 			ID:      request.ID,
-			Result: map[string]interface{}{
-				"content": responseContent,
-			},
+			Result:  resultFields(responseContent),
 		}, nil
 	}
 
 	// Format the response based on operation type (normal mode with full diff)
 	var responseContent []Content
-	fileName := filepath.Base(filePath)
 
 	// Add warnings as first content item if any
 	if len(warnings) > 0 {
@@ -163,24 +416,22 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 		})
 	}
 
-	if isEdit && existingContent != "" {
-		// Clean the existing content too for consistent comparison
-		cleanExistingContent := utils.CleanCodeResponse(existingContent)
-		editResponse := formatting.FormatEditResponse(fileName, cleanExistingContent, result, filePath)
-		if editResponse != nil {
-			responseContent = append(responseContent, *editResponse)
-		}
-	} else if !isEdit {
-		createResponse := formatting.FormatCreateResponse(fileName, result, filePath)
-		responseContent = append(responseContent, *createResponse)
+	if len(guardFindings) > 0 {
+		responseContent = append(responseContent, Content{
+			Type: "text",
+			Text: formatGuardFindings(guardFindings),
+		})
 	}
 
+	if changes != nil {
+		responseContent = append(responseContent, *changes)
+	}
+	responseContent = truncateContent(responseContent, maxLength)
+
 	response := &Response{
 		JSONRPC: "2.0",
 		ID:      request.ID,
-		Result: map[string]interface{}{
-			"content": responseContent,
-		},
+		Result:  resultFields(responseContent),
 	}
 
 	// Log the full response for debugging
@@ -195,6 +446,70 @@ func (s *Server) handleWriteTool(ctx context.Context, request *Request, argument
 	return response, nil
 }
 
+// checkBaseChecksum errors unless content, as read from filePath, has the
+// checksum the caller expects
+func checkBaseChecksum(filePath, content, expected string) error {
+	if actual := utils.ContentChecksum(content); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("base checksum mismatch for %s: expected %s, got %s (file was modified since last read)", filePath, expected, actual)
+	}
+	return nil
+}
+
+// formatGuardFindings lists context file lines that look like prompt
+// injections, high risk first
+func formatGuardFindings(findings []guard.Finding) string {
+	var lines []string
+	for _, risk := range []string{guard.RiskHigh, guard.RiskMedium} {
+		for _, finding := range findings {
+			if finding.Risk == risk {
+				lines = append(lines, fmt.Sprintf("- %s:%d [%s, %s risk] %s", finding.File, finding.Line, finding.Rule, finding.Risk, finding.Text))
+			}
+		}
+	}
+	return "🛡️ Context files contain text that looks like instructions to the model; check the generated code:\n" + strings.Join(lines, "\n")
+}
+
+// withReasoningEffort applies the optional reasoning_effort argument to ctx
+func withReasoningEffort(ctx context.Context, arguments *map[string]interface{}) (context.Context, error) {
+	if _, exists := (*arguments)["reasoning_effort"]; !exists {
+		return ctx, nil
+	}
+	effort, err := extractStringArg(arguments, "reasoning_effort")
+	if err != nil {
+		return ctx, err
+	}
+	if err := api.ValidateReasoningEffort(effort); err != nil {
+		return ctx, err
+	}
+	return api.WithReasoningEffort(ctx, effort), nil
+}
+
+// withValidationOptions applies the optional validation_retries,
+// validation_fallback and allow_unvalidated arguments to ctx
+func withValidationOptions(ctx context.Context, arguments *map[string]interface{}) (context.Context, error) {
+	var opts router.ValidationOptions
+	if value, exists := (*arguments)["validation_retries"]; exists {
+		retries, ok := value.(float64)
+		if !ok || retries < 0 || retries != float64(int(retries)) {
+			return ctx, fmt.Errorf("validation_retries must be a whole number of retries, got %v", value)
+		}
+		n := int(retries)
+		opts.Retries = &n
+	}
+	for key, target := range map[string]**bool{"validation_fallback": &opts.Fallback, "allow_unvalidated": &opts.AllowUnvalidated} {
+		value, exists := (*arguments)[key]
+		if !exists {
+			continue
+		}
+		flag, ok := value.(bool)
+		if !ok {
+			return ctx, fmt.Errorf("%s must be a boolean, got %T", key, value)
+		}
+		*target = &flag
+	}
+	return router.WithValidationOptions(ctx, opts), nil
+}
+
 // extractStringArg extracts a string argument from the arguments map
 func extractStringArg(arguments *map[string]interface{}, key string) (string, error) {
 	if arguments == nil {
@@ -278,6 +593,12 @@ func (s *Server) createErrorResponse(request *Request, err error) (*Response, er
 	logger.Debugf("Error occurred: %v", err)
 	logger.Debug("=======================")
 
+	// Classified failures go out as JSON-RPC errors with a code and data
+	// agents can act on
+	if _, _, ok := classifyError(err); ok {
+		return nil, err
+	}
+
 	// Return a standard text error if something goes wrong
 	return &Response{
 		JSONRPC: "2.0",
@@ -291,6 +612,25 @@ func (s *Server) createErrorResponse(request *Request, err error) (*Response, er
 	}, nil
 }
 
+// buildSARIF validates content and reports any findings as a SARIF log
+func (s *Server) buildSARIF(filePath, content string) *validation.SARIFLog {
+	language := validation.DetectLanguage(filePath)
+	findings := []validation.FileFindings{}
+
+	validationResult, err := language.ValidatorFor(s.config.Validation).Validate(content, filePath)
+	if err != nil {
+		logger.Warnf("SARIF: validation failed to run for %s: %v", filePath, err)
+	} else if !validationResult.Valid {
+		findings = append(findings, validation.FileFindings{
+			FilePath: filePath,
+			Language: language,
+			Errors:   validationResult.Errors,
+		})
+	}
+
+	return validation.NewSARIFLog(s.config.Server.Name, s.config.Server.Version, "", findings)
+}
+
 // handleRestorePrevious restores the previous version of a file from backup
 func (s *Server) handleRestorePrevious(request *Request, filePath string) (*Response, error) {
 	logger.Debugf("Attempting to restore previous version of: %s", filePath)
@@ -306,10 +646,14 @@ func (s *Server) handleRestorePrevious(request *Request, filePath string) (*Resp
 		return s.createErrorResponse(request, fmt.Errorf("failed to get backup: %w", err))
 	}
 
+	currentContent, _ := utils.ReadFileContent(filePath)
+
 	// Write backup content to file
 	if err := utils.WriteFileContent(filePath, backupContent); err != nil {
 		return s.createErrorResponse(request, fmt.Errorf("failed to restore file: %w", err))
 	}
+	s.recordAudit(audit.OperationRestore, filePath, "", currentContent, backupContent, nil)
+	s.recordRequest(audit.OperationRestore, filePath, currentContent, backupContent, false, nil, nil, 0, nil)
 
 	// Clear the backup after successful restore
 	globalBackupStore.ClearBackup(filePath)
@@ -328,6 +672,12 @@ func (s *Server) handleRestorePrevious(request *Request, filePath string) (*Resp
 				Type: "text",
 				Text: responseText,
 			}},
+			"checksum": utils.ContentChecksum(backupContent),
+			"structuredContent": writeOutcome{
+				Operation: outcomeRestored,
+				FilePath:  filePath,
+				Checksum:  utils.ContentChecksum(backupContent),
+			},
 		},
 	}, nil
 }
@@ -0,0 +1,152 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+func TestWriteToolExpectedBaseChecksum(t *testing.T) {
+	const (
+		original  = "package main\n\nfunc main() {}\n"
+		generated = "package main\n\nfunc main() { println(1) }\n"
+	)
+	var calls atomic.Int32
+	var duringGeneration func() // Runs while the provider answers
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if duringGeneration != nil {
+			duringGeneration()
+		}
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, generated)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	write := func(file, checksum string) string {
+		t.Helper()
+		args := map[string]interface{}{"file_path": file, "prompt": "print 1", "expected_base_checksum": checksum}
+		resp, err := s.handleWriteTool(context.Background(), &Request{ID: 1}, &args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Result.(map[string]interface{})["content"].([]Content)[0].Text
+	}
+	dir := t.TempDir()
+
+	// A matching checksum, in any case, lets the write through
+	matching := filepath.Join(dir, "matching.go")
+	os.WriteFile(matching, []byte(original), 0600)
+	write(matching, strings.ToUpper(utils.ContentChecksum(original)))
+	if data, _ := os.ReadFile(matching); string(data) != generated {
+		t.Errorf("file after a matching checksum = %q, want the generated code", data)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("provider calls = %d, want 1", calls.Load())
+	}
+
+	// A file changed since it was read is left alone, without asking the provider
+	calls.Store(0)
+	changed := filepath.Join(dir, "changed.go")
+	os.WriteFile(changed, []byte(original), 0600)
+	stale := utils.ContentChecksum("package main\n")
+	if text := write(changed, stale); !strings.Contains(text, "base checksum mismatch") || !strings.Contains(text, stale) {
+		t.Errorf("response = %q, want a checksum mismatch", text)
+	}
+	if data, _ := os.ReadFile(changed); string(data) != original {
+		t.Errorf("file after a mismatch = %q, want it unchanged", data)
+	}
+
+	// A missing file reads as empty, so a checksum of other content refuses
+	// to create it
+	missing := filepath.Join(dir, "missing.go")
+	if text := write(missing, utils.ContentChecksum(original)); !strings.Contains(text, "base checksum mismatch") || !strings.Contains(text, utils.ContentChecksum("")) {
+		t.Errorf("response = %q, want a mismatch against empty content", text)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("missing file was written: %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("provider calls after refused writes = %d, want 0", calls.Load())
+	}
+
+	// A file changed while the code was generated is left alone too
+	racing := filepath.Join(dir, "racing.go")
+	os.WriteFile(racing, []byte(original), 0600)
+	const edited = "package main\n\nfunc main() { println(2) }\n"
+	duringGeneration = func() { os.WriteFile(racing, []byte(edited), 0600) }
+	if text := write(racing, utils.ContentChecksum(original)); !strings.Contains(text, "base checksum mismatch") || !strings.Contains(text, utils.ContentChecksum(edited)) {
+		t.Errorf("response = %q, want a mismatch against the edited file", text)
+	}
+	if data, _ := os.ReadFile(racing); string(data) != edited {
+		t.Errorf("file after a concurrent edit = %q, want the edit kept", data)
+	}
+}
+
+func TestWriteToolEditsUTF16Files(t *testing.T) {
+	var prompt string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Messages[len(req.Messages)-1].Content
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, "line 2\n")
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: srv.URL, APIKey: "key", Model: "coder"}}
+	s := &Server{config: cfg, router: router.NewEnhancedRouter(cfg, nil)}
+
+	// UTF-16LE with a byte order mark and CRLF line ends
+	utf16LE := func(text string) string {
+		raw := "\xff\xfe"
+		for _, unit := range utf16.Encode([]rune(text)) {
+			raw += string([]byte{byte(unit), byte(unit >> 8)})
+		}
+		return raw
+	}
+	for _, tt := range []struct {
+		mode, want string
+	}{
+		{"append", "line 1\r\nline 3\r\n\r\nline 2\r\n"},
+		{"insert_after:line 1", "line 1\r\nline 2\r\nline 3\r\n"},
+	} {
+		file := filepath.Join(t.TempDir(), "notes.txt")
+		os.WriteFile(file, []byte(utf16LE("line 1\r\nline 3\r\n")), 0600)
+
+		args := map[string]interface{}{"file_path": file, "prompt": "add line 2", "mode": tt.mode}
+		resp, err := s.handleWriteTool(context.Background(), &Request{ID: 1}, &args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(file); string(data) != utf16LE(tt.want) {
+			t.Errorf("%s: file = %q, want %q in UTF-16LE; response %+v", tt.mode, data, tt.want, resp.Result)
+		}
+		if !strings.Contains(prompt, "line 1\nline 3\n") {
+			t.Errorf("%s: prompt = %q, want the existing content decoded", tt.mode, prompt)
+		}
+	}
+}
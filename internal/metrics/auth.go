@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// tokenCookie holds a browser's proof of the bearer token, so the dashboard's
+// own requests and WebSocket are authorized after opening it with ?token=
+const tokenCookie = "mcp_metrics_session"
+
+// withAuth requires the configured credentials on every path except the
+// container probes. Browsers can pass the bearer token once as ?token=; it
+// is exchanged for a cookie and removed from the address.
+func (s *MetricsServer) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.auth.Enabled() || r.URL.Path == s.prefix+"/healthz" || r.URL.Path == s.prefix+"/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if token := r.URL.Query().Get("token"); token != "" && s.auth.Token != "" && equalSecret(token, s.auth.Token) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    sessionValue(s.auth.Token),
+				Path:     s.prefix + "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteStrictMode,
+			})
+			query := r.URL.Query()
+			query.Del("token")
+			location := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+			http.Redirect(w, r, location.String(), http.StatusSeeOther)
+			return
+		}
+
+		if !s.authorized(r) {
+			if s.auth.Username != "" && s.auth.Password != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="mcp-code-api metrics", charset="UTF-8"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-code-api metrics"`)
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized reports whether r carries the bearer token, the basic auth
+// credentials or the token's session cookie
+func (s *MetricsServer) authorized(r *http.Request) bool {
+	if s.auth.Token != "" {
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && equalSecret(bearer, s.auth.Token) {
+			return true
+		}
+		if cookie, err := r.Cookie(tokenCookie); err == nil && equalSecret(cookie.Value, sessionValue(s.auth.Token)) {
+			return true
+		}
+	}
+	if s.auth.Username != "" && s.auth.Password != "" {
+		if username, password, ok := r.BasicAuth(); ok {
+			// Both are compared so a wrong username takes as long as a wrong password
+			userOK := equalSecret(username, s.auth.Username)
+			passwordOK := equalSecret(password, s.auth.Password)
+			return userOK && passwordOK
+		}
+	}
+	return false
+}
+
+// equalSecret compares secrets in constant time
+func equalSecret(given, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(want)) == 1
+}
+
+// sessionValue derives the session cookie from the token, so the token
+// itself is not stored by the browser
+func sessionValue(token string) string {
+	sum := sha256.Sum256([]byte("mcp-code-api metrics session\x00" + token))
+	return hex.EncodeToString(sum[:])
+}
+
+// sameOrigin reports whether r has no Origin header or one naming the
+// requested host
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// isLoopback reports whether host only accepts local connections
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// securityWarnings describes the risks of serving on host with auth and tls
+func securityWarnings(host string, auth config.MetricsAuthConfig, tls config.TLSConfig) []string {
+	if isLoopback(host) {
+		return nil
+	}
+	if host == "" {
+		host = "all interfaces"
+	}
+	var warnings []string
+	if !auth.Enabled() {
+		warnings = append(warnings, "metrics server on "+host+" has no authentication; anyone who can reach it sees provider names, latencies and recent requests (set metrics.auth)")
+	} else if !tls.Enabled() {
+		warnings = append(warnings, "metrics server on "+host+" sends credentials without TLS (set metrics.tls)")
+	}
+	return warnings
+}
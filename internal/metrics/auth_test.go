@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestMetricsAuth(t *testing.T) {
+	s := &MetricsServer{}
+	s.SetSecurity(config.MetricsAuthConfig{Username: "ops", Password: "hunter2", Token: "s3cret"}, config.TLSConfig{})
+	handler := s.withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	if code := serve(httptest.NewRequest(http.MethodGet, "/api/metrics", nil)).Code; code != http.StatusUnauthorized {
+		t.Errorf("no credentials = %d, want 401", code)
+	}
+	if code := serve(httptest.NewRequest(http.MethodGet, "/healthz", nil)).Code; code != http.StatusOK {
+		t.Errorf("/healthz without credentials = %d, want 200", code)
+	}
+
+	bearer := httptest.NewRequest(http.MethodGet, "/api/metrics", nil)
+	bearer.Header.Set("Authorization", "Bearer s3cret")
+	if code := serve(bearer).Code; code != http.StatusOK {
+		t.Errorf("bearer token = %d, want 200", code)
+	}
+	wrong := httptest.NewRequest(http.MethodGet, "/api/metrics", nil)
+	wrong.SetBasicAuth("ops", "guess")
+	if code := serve(wrong).Code; code != http.StatusUnauthorized {
+		t.Errorf("wrong password = %d, want 401", code)
+	}
+	basic := httptest.NewRequest(http.MethodGet, "/api/metrics", nil)
+	basic.SetBasicAuth("ops", "hunter2")
+	if code := serve(basic).Code; code != http.StatusOK {
+		t.Errorf("basic auth = %d, want 200", code)
+	}
+
+	// ?token= is exchanged for a cookie the dashboard's requests carry
+	login := serve(httptest.NewRequest(http.MethodGet, "/?token=s3cret&tab=requests", nil))
+	if login.Code != http.StatusSeeOther || login.Header().Get("Location") != "/?tab=requests" {
+		t.Fatalf("?token= = %d to %q, want a redirect dropping the token", login.Code, login.Header().Get("Location"))
+	}
+	cookies := login.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value == "s3cret" {
+		t.Fatalf("session cookies = %v", cookies)
+	}
+	ws := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	ws.AddCookie(cookies[0])
+	ws.Header.Set("Origin", "http://"+ws.Host)
+	if code := serve(ws).Code; code != http.StatusOK {
+		t.Errorf("/ws with the session cookie = %d, want 200", code)
+	}
+}
+
+func TestSecurityWarnings(t *testing.T) {
+	token := config.MetricsAuthConfig{Token: "t"}
+	cert := config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}
+	tests := []struct {
+		host string
+		auth config.MetricsAuthConfig
+		tls  config.TLSConfig
+		want int
+	}{
+		{"localhost", config.MetricsAuthConfig{}, config.TLSConfig{}, 0},
+		{"127.0.0.1", config.MetricsAuthConfig{}, config.TLSConfig{}, 0},
+		{"0.0.0.0", config.MetricsAuthConfig{}, config.TLSConfig{}, 1},
+		{"0.0.0.0", token, config.TLSConfig{}, 1},
+		{"0.0.0.0", token, cert, 0},
+	}
+	for _, tt := range tests {
+		if got := securityWarnings(tt.host, tt.auth, tt.tls); len(got) != tt.want {
+			t.Errorf("securityWarnings(%s, %+v, %+v) = %v", tt.host, tt.auth, tt.tls, got)
+		}
+	}
+}
+
+func TestStartRejectsBadTLS(t *testing.T) {
+	s := NewMetricsServer(nil, "127.0.0.1", 0)
+	s.SetSecurity(config.MetricsAuthConfig{}, config.TLSConfig{CertFile: "cert.pem"})
+	if err := s.Start(); err == nil {
+		t.Error("Start() accepted a certificate without a key")
+	}
+	s.SetSecurity(config.MetricsAuthConfig{}, config.TLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"})
+	if err := s.Start(); err == nil {
+		t.Error("Start() accepted a missing certificate")
+	}
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"html/template"
+	"io/fs"
+	"net/http"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// The dashboard is built from embedded files: dashboard/templates holds the
+// page, one template per tab plus shared partials, and dashboard/static the
+// stylesheet and script served under /static/, below the server's route
+// prefix.
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+var dashboardTemplates = template.Must(template.ParseFS(dashboardFiles, "dashboard/templates/*.html"))
+
+// dashboardAssetVersion changes whenever a static file does, so browsers
+// fetch the new version instead of a cached one
+var dashboardAssetVersion = hashStaticFiles()
+
+// dashboardCard is a single-value metric card; ID is the element the
+// script fills in
+type dashboardCard struct {
+	ID    string
+	Title string
+	Label string
+}
+
+// dashboardPage is the data index.html is rendered with
+type dashboardPage struct {
+	Title        string
+	BasePath     string // Route prefix the server is mounted under
+	AssetVersion string
+	SummaryCards []dashboardCard
+	LatencyCards []dashboardCard
+}
+
+var dashboardData = dashboardPage{
+	Title: "Metrics Dashboard",
+	SummaryCards: []dashboardCard{
+		{"totalRequests", "Total Requests", "All incoming requests"},
+		{"successfulRequests", "Successful Requests", "Completed successfully"},
+		{"failedRequests", "Failed Requests", "Errors occurred"},
+		{"fallbackAttempts", "Fallback Attempts", "Provider fallbacks"},
+		{"queuedRequests", "Queued Requests", "Waited for a generation slot"},
+		{"avgQueueWait", "Avg Queue Wait", "milliseconds"},
+		{"successRate", "Success Rate", "Percentage successful"},
+		{"activeInstances", "Active Instances", "Running MCP servers"},
+	},
+	LatencyCards: []dashboardCard{
+		{"overallMin", "Min", "milliseconds"},
+		{"overallP50", "P50 (Median)", "milliseconds"},
+		{"overallP95", "P95", "milliseconds"},
+		{"overallP99", "P99", "milliseconds"},
+		{"overallMax", "Max", "milliseconds"},
+	},
+}
+
+func (s *MetricsServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := dashboardData
+	data.BasePath = s.prefix
+	data.AssetVersion = dashboardAssetVersion
+
+	var page bytes.Buffer
+	if err := dashboardTemplates.ExecuteTemplate(&page, "index.html", data); err != nil {
+		logger.Errorf("Failed to render dashboard: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page.Bytes())
+}
+
+// staticHandler serves the dashboard's stylesheet and script
+func staticHandler() http.Handler {
+	static, err := fs.Sub(dashboardFiles, "dashboard/static")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/static/", http.FileServer(http.FS(static)))
+}
+
+func hashStaticFiles() string {
+	h := sha256.New()
+	_ = fs.WalkDir(dashboardFiles, "dashboard/static", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := dashboardFiles.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		h.Write([]byte(path))
+		h.Write(data)
+		return nil
+	})
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,356 @@
+// Metrics arrive over /ws as a snapshot followed by deltas; state
+// holds the flattened fields ("TotalRequests",
+// "ProviderMetrics/<key>", "HealthStatus/<provider>")
+var state = {};
+var reconnectDelay = 1000;
+
+// basePath is the route prefix the server is mounted under, e.g. "/metrics"
+var basePath = document.body.dataset.basePath || '';
+
+function formatDuration(nanos) {
+    return (nanos / 1000000).toFixed(2);
+}
+
+// currentMetrics rebuilds the /api/metrics shape from state
+function currentMetrics() {
+    var data = { ProviderMetrics: {}, HealthStatus: {} };
+    for (var key in state) {
+        var slash = key.indexOf('/');
+        if (slash < 0) {
+            data[key] = state[key];
+        } else if (data[key.substring(0, slash)]) {
+            data[key.substring(0, slash)][key.substring(slash + 1)] = state[key];
+        }
+    }
+    return data;
+}
+
+function renderSummary(data) {
+    document.getElementById('totalRequests').innerHTML = data.TotalRequests || 0;
+    document.getElementById('successfulRequests').innerHTML = data.SuccessfulRequests || 0;
+    document.getElementById('failedRequests').innerHTML = data.FailedRequests || 0;
+    document.getElementById('fallbackAttempts').innerHTML = data.FallbackAttempts || 0;
+    document.getElementById('queuedRequests').innerHTML = data.QueuedRequests || 0;
+    document.getElementById('avgQueueWait').innerHTML = formatDuration(data.AvgQueueWait || 0);
+    document.getElementById('activeInstances').innerHTML = data.ActiveInstances || 0;
+
+    var successRate = 0;
+    if (data.TotalRequests > 0) {
+        successRate = ((data.SuccessfulRequests / data.TotalRequests) * 100).toFixed(1);
+    }
+    document.getElementById('successRate').innerHTML = successRate + '%';
+
+    // Update overall latency metrics
+    var latency = data.OverallLatency;
+    document.getElementById('overallMin').innerHTML = latency ? formatDuration(latency.MinLatency || 0) : '-';
+    document.getElementById('overallP50').innerHTML = latency ? formatDuration(latency.P50Latency || 0) : '-';
+    document.getElementById('overallP95').innerHTML = latency ? formatDuration(latency.P95Latency || 0) : '-';
+    document.getElementById('overallP99').innerHTML = latency ? formatDuration(latency.P99Latency || 0) : '-';
+    document.getElementById('overallMax').innerHTML = latency ? formatDuration(latency.MaxLatency || 0) : '-';
+}
+
+function metricCells(metric) {
+    var successRate = 0;
+    if (metric.TotalRequests > 0) {
+        successRate = ((metric.SuccessfulRequests / metric.TotalRequests) * 100).toFixed(1);
+    }
+    return '<td>' + (metric.TotalRequests || 0) + '</td>' +
+        '<td>' + successRate + '%</td>' +
+        '<td>' + (metric.AvgTokensPerSec ? metric.AvgTokensPerSec.toFixed(0) : '-') + '</td>' +
+        '<td>' + formatDuration(metric.MinLatency || 0) + '</td>' +
+        '<td>' + formatDuration(metric.P50Latency || 0) + '</td>' +
+        '<td>' + formatDuration(metric.P95Latency || 0) + '</td>' +
+        '<td>' + formatDuration(metric.P99Latency || 0) + '</td>' +
+        '<td>' + formatDuration(metric.MaxLatency || 0) + '</td>' +
+        '<td>' + formatDuration(metric.AvgLatency || 0) + '</td>';
+}
+
+// keyRows lists the usage of each API key of a multi-key provider, keys
+// shown by their masked suffix
+function keyRows(keys) {
+    var rows = '';
+    for (var i = 0; i < keys.length; i++) {
+        var key = keys[i];
+        var successRate = key.Requests > 0 ? ((1 - key.ErrorRate) * 100).toFixed(1) + '%' : '-';
+        var rateLimited = key.RateLimitHits > 0 ? '<span style="color: #f44336;">' + key.RateLimitHits + ' rate limited</span>' : '';
+        rows += '<tr>' +
+            '<td></td>' +
+            '<td style="padding-left: 30px; color: #9e9e9e;">🔑 ' + escapeHtml(key.Key) + '</td>' +
+            '<td>' + (key.Requests || 0) + '</td>' +
+            '<td>' + successRate + '</td>' +
+            '<td colspan="7">' + (key.Failures || 0) + ' failed ' + rateLimited + '</td>' +
+            '</tr>';
+    }
+    return rows;
+}
+
+function renderProviders(data) {
+    var metricsTable = document.getElementById('providerMetricsTable');
+    if (!data.ProviderMetrics || Object.keys(data.ProviderMetrics).length === 0) {
+        metricsTable.innerHTML = '<div class="loading">No provider metrics available</div>';
+        return;
+    }
+
+    var tableHtml = '<table><thead><tr><th>Health</th><th>Provider Name</th><th>Total Requests</th><th>Success Rate</th><th>Tokens/sec</th><th>Min (ms)</th><th>P50 (ms)</th><th>P95 (ms)</th><th>P99 (ms)</th><th>Max (ms)</th><th>Avg (ms)</th></tr></thead><tbody>';
+
+    // Separate providers and models
+    var providers = [];
+    var models = {};
+    for (var key in data.ProviderMetrics) {
+        var metric = data.ProviderMetrics[key];
+        if (metric.IsModel) {
+            // This is a model - group under its provider
+            if (!models[metric.Name]) {
+                models[metric.Name] = [];
+            }
+            models[metric.Name].push(metric);
+        } else {
+            providers.push(metric);
+        }
+    }
+
+    // Sort providers alphabetically
+    providers.sort(function(a, b) {
+        return a.Name.localeCompare(b.Name);
+    });
+
+    for (var i = 0; i < providers.length; i++) {
+        var provider = providers[i];
+        var health = data.HealthStatus[provider.Name];
+
+        var healthIcon;
+        if (provider.TotalRequests === 0 || !health || !health.LastChecked) {
+            // Provider not used yet - show ?
+            healthIcon = '<span style="color: #9e9e9e; font-size: 1.2em;">?</span>';
+        } else if (health.IsHealthy) {
+            healthIcon = '<span style="color: #4caf50; font-size: 1.2em;">✓</span>';
+        } else {
+            healthIcon = '<span style="color: #f44336; font-size: 1.2em;">✗</span>';
+        }
+
+        tableHtml += '<tr>' +
+            '<td style="text-align: center;">' + healthIcon + '</td>' +
+            '<td><strong>' + provider.Name + '</strong></td>' +
+            metricCells(provider) +
+            '</tr>';
+
+        if (provider.Keys) {
+            tableHtml += keyRows(provider.Keys);
+        }
+
+        if (models[provider.Name]) {
+            // Sort models by average latency (fastest first, unused last)
+            models[provider.Name].sort(function(a, b) {
+                if (a.AvgLatency === 0 && b.AvgLatency === 0) return 0;
+                if (a.AvgLatency === 0) return 1;
+                if (b.AvgLatency === 0) return -1;
+                return a.AvgLatency - b.AvgLatency;
+            });
+
+            for (var j = 0; j < models[provider.Name].length; j++) {
+                var model = models[provider.Name][j];
+                tableHtml += '<tr>' +
+                    '<td></td>' + // No health icon for models
+                    '<td style="padding-left: 30px; color: #9e9e9e;">↳ ' + model.Model + '</td>' +
+                    metricCells(model) +
+                    '</tr>';
+            }
+        }
+    }
+
+    tableHtml += '</tbody></table>';
+    metricsTable.innerHTML = tableHtml;
+}
+
+function render() {
+    var data = currentMetrics();
+    renderSummary(data);
+    renderProviders(data);
+    updateTimestamp();
+}
+
+function updateTimestamp() {
+    var now = new Date();
+    var timestamp = now.toLocaleTimeString() + '.' + now.getMilliseconds().toString().padStart(3, '0');
+    document.getElementById('lastUpdate').innerHTML = 'Last updated: ' + timestamp;
+}
+
+var currentTab = 'overview';
+var expandedRequests = {};
+
+function showTab(tab) {
+    currentTab = tab;
+    document.getElementById('overviewTab').style.display = tab === 'overview' ? '' : 'none';
+    document.getElementById('requestsTab').style.display = tab === 'requests' ? '' : 'none';
+    document.getElementById('racingTab').style.display = tab === 'racing' ? '' : 'none';
+    document.getElementById('overviewTabButton').className = tab === 'overview' ? 'tab active' : 'tab';
+    document.getElementById('requestsTabButton').className = tab === 'requests' ? 'tab active' : 'tab';
+    document.getElementById('racingTabButton').className = tab === 'racing' ? 'tab active' : 'tab';
+    if (tab === 'requests') {
+        updateRequests();
+    } else if (tab === 'racing') {
+        updateRacing();
+    }
+}
+
+function escapeHtml(text) {
+    return String(text).replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;');
+}
+
+function formatDiff(diff) {
+    return diff.split('\n').map(function(line) {
+        var cls = '';
+        if (line.indexOf('@@') === 0) {
+            cls = 'hunk';
+        } else if (line.indexOf('+') === 0 && line.indexOf('+++') !== 0) {
+            cls = 'add';
+        } else if (line.indexOf('-') === 0 && line.indexOf('---') !== 0) {
+            cls = 'del';
+        }
+        return '<span class="' + cls + '">' + escapeHtml(line) + '</span>';
+    }).join('\n');
+}
+
+function toggleRequest(id) {
+    expandedRequests[id] = !expandedRequests[id];
+    var details = document.getElementById('request-' + id);
+    if (details) {
+        details.style.display = expandedRequests[id] ? '' : 'none';
+    }
+}
+
+function updateRequests() {
+    fetch(basePath + '/api/requests')
+        .then(function(response) {
+            if (!response.ok) {
+                return response.text().then(function(text) { throw new Error(text); });
+            }
+            return response.json();
+        })
+        .then(function(entries) {
+            var requestsTable = document.getElementById('requestsTable');
+            if (entries.length === 0) {
+                requestsTable.innerHTML = '<div class="loading">No write requests yet</div>';
+                return;
+            }
+
+            var tableHtml = '<table><thead><tr><th>Time</th><th>File</th><th>Operation</th><th>Provider</th><th>Model</th><th>Tokens</th><th>Latency (ms)</th><th>Validation</th></tr></thead><tbody>';
+            for (var i = 0; i < entries.length; i++) {
+                var entry = entries[i];
+                var tokens = entry.cached ? 'cached' : (entry.total_tokens ? entry.total_tokens + ' (' + entry.prompt_tokens + ' / ' + entry.completion_tokens + ')' : '-');
+                tableHtml += '<tr class="request-row" onclick="toggleRequest(' + entry.id + ')">' +
+                    '<td>' + new Date(entry.time).toLocaleTimeString() + '</td>' +
+                    '<td>' + escapeHtml(entry.file_path) + '</td>' +
+                    '<td>' + entry.operation + '</td>' +
+                    '<td>' + escapeHtml(entry.provider || '-') + '</td>' +
+                    '<td>' + escapeHtml(entry.model || '-') + '</td>' +
+                    '<td>' + tokens + '</td>' +
+                    '<td>' + (entry.latency_ms || 0) + '</td>' +
+                    '<td class="validation-' + entry.validation + '">' + entry.validation + '</td>' +
+                    '</tr>';
+
+                var details = '';
+                if (entry.error) {
+                    details += '<div class="validation-failed">' + escapeHtml(entry.error) + '</div>';
+                }
+                if (entry.warnings) {
+                    details += '<div class="validation-warnings">' + entry.warnings.map(escapeHtml).join('<br>') + '</div>';
+                }
+                details += entry.diff ? '<div class="diff">' + formatDiff(entry.diff) + '</div>' : '<div class="loading">No changes</div>';
+                tableHtml += '<tr id="request-' + entry.id + '" style="display: ' + (expandedRequests[entry.id] ? '' : 'none') + ';">' +
+                    '<td colspan="8">' + details + '</td></tr>';
+            }
+            tableHtml += '</tbody></table>';
+            requestsTable.innerHTML = tableHtml;
+        })
+        .catch(function(error) {
+            document.getElementById('requestsTable').innerHTML = '<div class="error">' + escapeHtml(error.message) + '</div>';
+        });
+}
+
+function updateRacing() {
+    fetch(basePath + '/api/racing')
+        .then(function(response) {
+            if (!response.ok) {
+                return response.text().then(function(text) { throw new Error(text); });
+            }
+            return response.json();
+        })
+        .then(function(reports) {
+            var racingTable = document.getElementById('racingTable');
+            if (reports.length === 0) {
+                racingTable.innerHTML = '<div class="loading">No racing providers configured</div>';
+                return;
+            }
+
+            var html = '';
+            for (var i = 0; i < reports.length; i++) {
+                var report = reports[i];
+                html += '<h3>' + escapeHtml(report.provider) + '</h3>' +
+                    '<p>' + report.races + ' races, racing ' + (report.num_racers || 'all') + ' of the pool, ' +
+                    'slowness threshold ' + report.slowness_threshold + 'x. ' +
+                    'Pool: ' + (report.pool.length ? report.pool.map(escapeHtml).join(', ') : 'empty') + '</p>';
+                html += '<table><thead><tr><th>Model</th><th>Races</th><th>Wins</th><th>Win Rate</th><th>Avg TTFT (ms)</th><th>Failures</th><th>Abandoned</th><th>Status</th></tr></thead><tbody>';
+                for (var j = 0; j < report.models.length; j++) {
+                    var model = report.models[j];
+                    var status = model.excluded ? '<span class="validation-failed">excluded (slow)</span>' :
+                        (model.in_pool ? 'racing' : 'waiting');
+                    html += '<tr>' +
+                        '<td>' + escapeHtml(model.model) + '</td>' +
+                        '<td>' + model.races + '</td>' +
+                        '<td>' + model.wins + '</td>' +
+                        '<td>' + (model.win_rate * 100).toFixed(1) + '%</td>' +
+                        '<td>' + (model.avg_ttft_ms ? model.avg_ttft_ms.toFixed(0) : '-') + '</td>' +
+                        '<td>' + model.failures + '</td>' +
+                        '<td>' + model.abandoned + '</td>' +
+                        '<td>' + status + '</td>' +
+                        '</tr>';
+                }
+                html += '</tbody></table>';
+            }
+            racingTable.innerHTML = html;
+        })
+        .catch(function(error) {
+            document.getElementById('racingTable').innerHTML = '<div class="error">' + escapeHtml(error.message) + '</div>';
+        });
+}
+
+function connect() {
+    var scheme = location.protocol === 'https:' ? 'wss://' : 'ws://';
+    var socket = new WebSocket(scheme + location.host + basePath + '/ws');
+
+    socket.onopen = function() {
+        reconnectDelay = 1000;
+    };
+    socket.onmessage = function(event) {
+        var update = JSON.parse(event.data);
+        if (update.type === 'snapshot') {
+            state = {};
+        }
+        for (var key in (update.set || {})) {
+            state[key] = update.set[key];
+        }
+        (update.removed || []).forEach(function(key) {
+            delete state[key];
+        });
+        render();
+
+        // A finished write shows up as a change in the request counters
+        if (update.type === 'delta' && update.set &&
+            (update.set.SuccessfulRequests !== undefined || update.set.FailedRequests !== undefined)) {
+            if (currentTab === 'requests') {
+                updateRequests();
+            } else if (currentTab === 'racing') {
+                updateRacing();
+            }
+        }
+    };
+    socket.onclose = function() {
+        // Keep the last values on screen and retry with backoff
+        document.getElementById('lastUpdate').innerHTML = 'Disconnected, reconnecting...';
+        setTimeout(connect, reconnectDelay);
+        reconnectDelay = Math.min(reconnectDelay * 2, 30000);
+    };
+}
+
+connect();
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDashboardRenders(t *testing.T) {
+	s := &MetricsServer{}
+	recorder := httptest.NewRecorder()
+	s.handleIndex(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+	body := recorder.Body.String()
+	for _, want := range []string{
+		`id="totalRequests"`,
+		`id="overallP99"`,
+		`id="requestsTable"`,
+		`id="racingTable"`,
+		`/static/dashboard.js?v=` + dashboardAssetVersion,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("dashboard is missing %s", want)
+		}
+	}
+}
+
+func TestDashboardStaticFiles(t *testing.T) {
+	for _, path := range []string{"/static/dashboard.js", "/static/dashboard.css"} {
+		recorder := httptest.NewRecorder()
+		staticHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusOK || recorder.Body.Len() == 0 {
+			t.Errorf("GET %s: status %d, %d bytes", path, recorder.Code, recorder.Body.Len())
+		}
+	}
+}
+
+func TestPrefixedServers(t *testing.T) {
+	a := NewMetricsServer(nil, "localhost", 0)
+	a.SetPrefix("/a")
+	b := NewMetricsServer(nil, "localhost", 0)
+	b.SetPrefix("b/")
+
+	get := func(handler http.Handler, path string) (int, string) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder.Code, recorder.Body.String()
+	}
+	for prefix, handler := range map[string]http.Handler{"/a": a.Handler(), "/b": b.Handler()} {
+		if code, _ := get(handler, prefix+"/healthz"); code != http.StatusOK {
+			t.Errorf("GET %s/healthz = %d, want 200", prefix, code)
+		}
+		if code, _ := get(handler, prefix+"/static/dashboard.js"); code != http.StatusOK {
+			t.Errorf("GET %s/static/dashboard.js = %d, want 200", prefix, code)
+		}
+		code, body := get(handler, prefix+"/")
+		if code != http.StatusOK || !strings.Contains(body, `data-base-path="`+prefix+`"`) || !strings.Contains(body, prefix+"/static/dashboard.js") {
+			t.Errorf("GET %s/ = %d, dashboard does not use the prefix", prefix, code)
+		}
+		if code, _ := get(handler, "/healthz"); code != http.StatusNotFound {
+			t.Errorf("unprefixed /healthz = %d, want 404", code)
+		}
+	}
+	if got, want := a.URL(), "http://localhost:0/a/"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,260 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// liveInterval is how often metrics are checked for changes while at least
+// one dashboard is connected
+const liveInterval = time.Second
+
+// liveClientBuffer is how many updates a slow browser may fall behind
+// before it is disconnected
+const liveClientBuffer = 16
+
+// liveUpdate is a message pushed to dashboards over /ws. The first message
+// on a connection is a "snapshot" with every field; "delta" messages then
+// carry only fields that changed. Fields are keyed by their metric name,
+// with per-provider entries as "ProviderMetrics/<key>" and
+// "HealthStatus/<provider>".
+type liveUpdate struct {
+	Type    string                     `json:"type"`
+	Set     map[string]json.RawMessage `json:"set,omitempty"`
+	Removed []string                   `json:"removed,omitempty"`
+}
+
+// liveHub collects metrics once per interval and fans the changes out to
+// every connected dashboard
+type liveHub struct {
+	store  *SharedMetricsStore
+	router func() *router.EnhancedRouter
+
+	mutex   sync.Mutex
+	clients map[chan []byte]bool
+	last    map[string]json.RawMessage
+	running bool
+	stop    chan struct{}
+}
+
+func newLiveHub(store *SharedMetricsStore, r func() *router.EnhancedRouter) *liveHub {
+	return &liveHub{
+		store:   store,
+		router:  r,
+		clients: make(map[chan []byte]bool),
+		stop:    make(chan struct{}),
+	}
+}
+
+// subscribe registers a dashboard and returns its message channel, primed
+// with a snapshot
+func (h *liveHub) subscribe() chan []byte {
+	fields := h.collect()
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if fields != nil {
+		h.last = fields
+	}
+
+	ch := make(chan []byte, liveClientBuffer)
+	select {
+	case <-h.stop:
+		close(ch)
+		return ch
+	default:
+	}
+	if message, err := json.Marshal(liveUpdate{Type: "snapshot", Set: h.last}); err == nil {
+		ch <- message
+	}
+	h.clients[ch] = true
+
+	if !h.running {
+		h.running = true
+		go h.run()
+	}
+	return ch
+}
+
+// unsubscribe removes a dashboard
+func (h *liveHub) unsubscribe(ch chan []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.clients[ch] {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// close disconnects every dashboard and stops the hub
+func (h *liveHub) close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	select {
+	case <-h.stop:
+		return
+	default:
+	}
+	close(h.stop)
+	for ch := range h.clients {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// run pushes deltas until the last dashboard disconnects
+func (h *liveHub) run() {
+	ticker := time.NewTicker(liveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		}
+
+		fields := h.collect()
+
+		h.mutex.Lock()
+		if len(h.clients) == 0 {
+			h.running = false
+			h.mutex.Unlock()
+			return
+		}
+		if fields != nil {
+			h.broadcast(diffFields(h.last, fields))
+			h.last = fields
+		}
+		h.mutex.Unlock()
+	}
+}
+
+// broadcast sends an update to every dashboard, dropping any that are too
+// far behind. Callers must hold the mutex.
+func (h *liveHub) broadcast(update liveUpdate) {
+	if len(update.Set) == 0 && len(update.Removed) == 0 {
+		return
+	}
+	message, err := json.Marshal(update)
+	if err != nil {
+		logger.Errorf("Failed to encode live metrics update: %v", err)
+		return
+	}
+	for ch := range h.clients {
+		select {
+		case ch <- message:
+		default:
+			logger.Debugf("Dropping dashboard that fell behind on live updates")
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// collect returns the current metrics as flattened fields. Health from this
+// instance's router is used directly rather than waiting for the next
+// metrics file update, so the indicators change as soon as a check does.
+func (h *liveHub) collect() map[string]json.RawMessage {
+	aggregated, err := h.store.GetAggregatedMetrics()
+	if err != nil {
+		logger.Errorf("Failed to get aggregated metrics: %v", err)
+		return nil
+	}
+	if r := h.router(); r != nil {
+		for provider, health := range r.GetHealthStatus() {
+			if existing, ok := aggregated.HealthStatus[provider]; !ok || !health.LastChecked.Before(existing.LastChecked) {
+				aggregated.HealthStatus[provider] = health
+			}
+		}
+	}
+	return flattenMetrics(aggregated)
+}
+
+// flattenMetrics encodes aggregated metrics as fields keyed for liveUpdate
+func flattenMetrics(aggregated *AggregatedMetrics) map[string]json.RawMessage {
+	fields := make(map[string]json.RawMessage)
+	add := func(key string, value interface{}) {
+		if data, err := json.Marshal(value); err == nil {
+			fields[key] = data
+		}
+	}
+
+	add("TotalRequests", aggregated.TotalRequests)
+	add("SuccessfulRequests", aggregated.SuccessfulRequests)
+	add("FailedRequests", aggregated.FailedRequests)
+	add("FallbackAttempts", aggregated.FallbackAttempts)
+	add("QueuedRequests", aggregated.QueuedRequests)
+	add("AvgQueueWait", aggregated.AvgQueueWait)
+	add("MaxQueueWait", aggregated.MaxQueueWait)
+	add("ActiveInstances", aggregated.ActiveInstances)
+	add("OverallLatency", aggregated.OverallLatency)
+	for key, metrics := range aggregated.ProviderMetrics {
+		add("ProviderMetrics/"+key, metrics)
+	}
+	for provider, health := range aggregated.HealthStatus {
+		add("HealthStatus/"+provider, health)
+	}
+	return fields
+}
+
+// diffFields returns the update turning previous into current
+func diffFields(previous, current map[string]json.RawMessage) liveUpdate {
+	update := liveUpdate{Type: "delta", Set: make(map[string]json.RawMessage)}
+	for key, value := range current {
+		if old, ok := previous[key]; !ok || string(old) != string(value) {
+			update.Set[key] = value
+		}
+	}
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			update.Removed = append(update.Removed, key)
+		}
+	}
+	return update
+}
+
+// handleWebSocket streams metric updates to a dashboard
+func (s *MetricsServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		logger.Debugf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	updates := s.live.subscribe()
+	defer s.live.unsubscribe(updates)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		if err := conn.readLoop(); err != nil {
+			logger.Debugf("Dashboard connection closed: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case message, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err := conn.WriteText(message); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
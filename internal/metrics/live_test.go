@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWSAcceptKey(t *testing.T) {
+	// Example from RFC 6455 section 1.3
+	if got := wsAcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("wsAcceptKey() = %q", got)
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	previous := map[string]json.RawMessage{
+		"TotalRequests":         json.RawMessage(`1`),
+		"FailedRequests":        json.RawMessage(`0`),
+		"HealthStatus/cerebras": json.RawMessage(`{"IsHealthy":true}`),
+	}
+	current := map[string]json.RawMessage{
+		"TotalRequests":           json.RawMessage(`2`),
+		"FailedRequests":          json.RawMessage(`0`),
+		"HealthStatus/openrouter": json.RawMessage(`{"IsHealthy":true}`),
+	}
+
+	update := diffFields(previous, current)
+	if len(update.Set) != 2 || string(update.Set["TotalRequests"]) != "2" || update.Set["HealthStatus/openrouter"] == nil {
+		t.Errorf("Set = %v, want TotalRequests and HealthStatus/openrouter", update.Set)
+	}
+	if len(update.Removed) != 1 || update.Removed[0] != "HealthStatus/cerebras" {
+		t.Errorf("Removed = %v, want [HealthStatus/cerebras]", update.Removed)
+	}
+}
+
+func TestWebSocketSendsSnapshot(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := NewSharedMetricsStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMetricsServer(store, "127.0.0.1", 0)
+	defer s.live.close()
+
+	server := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	request := "GET /ws HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", response.StatusCode)
+	}
+
+	var head [2]byte
+	if _, err := io.ReadFull(reader, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	length := int(head[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		if _, err := io.ReadFull(reader, ext[:]); err != nil {
+			t.Fatal(err)
+		}
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var update liveUpdate
+	if err := json.Unmarshal(payload, &update); err != nil {
+		t.Fatalf("invalid message %q: %v", payload, err)
+	}
+	if update.Type != "snapshot" || update.Set["TotalRequests"] == nil {
+		t.Errorf("first message = %s, want a snapshot", payload)
+	}
+}
+
+func TestWebSocketRejectsCrossOrigin(t *testing.T) {
+	handshake := func(origin string) int {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		r.Header.Set("Connection", "Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		r.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		if _, err := upgradeWebSocket(w, r); err == nil {
+			t.Errorf("handshake from %q succeeded on a recorder", origin)
+		}
+		return w.Code
+	}
+
+	// With no auth configured the dashboard has no other protection
+	if code := handshake("https://evil.example"); code != http.StatusForbidden {
+		t.Errorf("cross-origin handshake = %d, want 403", code)
+	}
+	// The recorder can't be hijacked, so a same-origin handshake gets past the checks
+	if code := handshake("http://example.com"); code != http.StatusInternalServerError {
+		t.Errorf("same-origin handshake = %d, want it to reach the upgrade", code)
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// failureWindow is how long a provider's failed request counts against
+// readiness. Nothing probes the providers between requests, so once it has
+// passed the provider counts as ready again and the next request retries it;
+// otherwise a pod whose providers all failed once would never become ready.
+var failureWindow = time.Minute
+
+// probeResponse is the body of the /healthz and /readyz probes
+type probeResponse struct {
+	Status    string          `json:"status"`
+	Reason    string          `json:"reason,omitempty"`
+	Providers map[string]bool `json:"providers,omitempty"`
+}
+
+// handleHealthz is the liveness probe: the process is up and serving
+func (s *MetricsServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeProbe(w, http.StatusOK, probeResponse{Status: "ok"})
+}
+
+// handleReadyz is the readiness probe: 200 once the router has initialized
+// its providers and at least one of them is healthy, 503 until then or
+// while every provider has failed within failureWindow
+func (s *MetricsServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.router == nil {
+		writeProbe(w, http.StatusServiceUnavailable, probeResponse{Status: "not ready", Reason: "no router"})
+		return
+	}
+
+	health := s.router.GetHealthStatus()
+	if len(health) == 0 {
+		writeProbe(w, http.StatusServiceUnavailable, probeResponse{Status: "not ready", Reason: "no providers initialized"})
+		return
+	}
+	providers := make(map[string]bool, len(health))
+	healthy := 0
+	for name, status := range health {
+		providers[name] = status.IsHealthy
+		if status.IsHealthy || time.Since(status.LastChecked) > failureWindow {
+			healthy++
+		}
+	}
+	if s.auth.Enabled() && !s.authorized(r) {
+		// Provider names are only shown to authorized callers
+		providers = nil
+	}
+	if healthy == 0 {
+		writeProbe(w, http.StatusServiceUnavailable, probeResponse{Status: "not ready", Reason: "no healthy providers", Providers: providers})
+		return
+	}
+	writeProbe(w, http.StatusOK, probeResponse{Status: "ready", Providers: providers})
+}
+
+// writeProbe writes a probe's JSON response with the given status code
+func writeProbe(w http.ResponseWriter, code int, body probeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
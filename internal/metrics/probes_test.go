@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/provider"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestProbes(t *testing.T) {
+	probe := func(handler http.HandlerFunc, path string) (int, string) {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder.Code, recorder.Body.String()
+	}
+
+	s := &MetricsServer{}
+	if code, _ := probe(s.handleHealthz, "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz = %d, want 200", code)
+	}
+	if code, _ := probe(s.handleReadyz, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz without a router = %d, want 503", code)
+	}
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"mock"}
+	cfg.Providers.Mock = &config.MockConfig{}
+	factory := provider.NewProviderFactory()
+	provider.InitializeDefaultProviders(factory)
+	r := router.NewEnhancedRouter(cfg, factory)
+	s.SetRouter(r)
+	if code, body := probe(s.handleReadyz, "/readyz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "no providers initialized") {
+		t.Errorf("/readyz before initialization = %d %s, want 503", code, body)
+	}
+
+	if err := r.Initialize(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if code, body := probe(s.handleReadyz, "/readyz"); code != http.StatusOK || !strings.Contains(body, `"mock":true`) {
+		t.Errorf("/readyz after initialization = %d %s, want 200", code, body)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.handleReadyz(recorder, httptest.NewRequest(http.MethodPost, "/readyz", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /readyz = %d, want 405", recorder.Code)
+	}
+}
+
+func TestReadyzRecoversAfterFailures(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"stub"}
+	cfg.Providers.Order = []string{"stub"}
+	cfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: upstream.URL, APIKey: "key", Model: "coder"}}
+	r := router.NewEnhancedRouter(cfg, nil)
+	s := &MetricsServer{}
+	s.SetRouter(r)
+	readyz := func() int {
+		recorder := httptest.NewRecorder()
+		s.handleReadyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return recorder.Code
+	}
+
+	defer func(window time.Duration) { failureWindow = window }(failureWindow)
+	failureWindow = 50 * time.Millisecond
+
+	if _, err := r.GenerateCode(t.Context(), "print one", "", "main.py", "python", nil); err == nil {
+		t.Fatal("Expected the failing provider to fail the request")
+	}
+	if code := readyz(); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz right after a failure = %d, want 503", code)
+	}
+
+	// Once the failure is older than the window the pod is ready again, so
+	// traffic comes back and retries the provider
+	time.Sleep(2 * failureWindow)
+	if code := readyz(); code != http.StatusOK {
+		t.Errorf("/readyz after the failure window = %d, want 200", code)
+	}
+}
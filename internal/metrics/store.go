@@ -5,16 +5,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
 	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
 )
 
-// SharedMetricsStore manages shared metrics across multiple server instances
+// staleAfter is how long an instance's metrics count after its last update;
+// instances update every 2 seconds while running
+const staleAfter = 10 * time.Second
+
+// SharedMetricsStore manages shared metrics across multiple server instances.
+// Each instance only ever writes its own file in dir, replacing it whole, and
+// readers merge the files, so instances need no lock between them.
 type SharedMetricsStore struct {
-	filePath     string
+	dir          string
 	instanceID   string
 	mutex        sync.RWMutex
 	lastUpdate   time.Time
@@ -30,9 +39,15 @@ type InstanceMetrics struct {
 	SuccessfulRequests int64                          `json:"successful_requests"`
 	FailedRequests     int64                          `json:"failed_requests"`
 	FallbackAttempts   int64                          `json:"fallback_attempts"`
+	QueuedRequests     int64                          `json:"queued_requests,omitempty"`
+	TotalQueueWait     time.Duration                  `json:"total_queue_wait,omitempty"`
+	MaxQueueWait       time.Duration                  `json:"max_queue_wait,omitempty"`
 	HealthStatus       map[string]*router.HealthStatus `json:"health_status"`
 	ProviderMetrics    map[string]router.ProviderMetrics `json:"provider_metrics"`
 	OverallLatency     router.OverallLatencyMetrics   `json:"overall_latency"`
+	// Histograms let the percentiles be merged across instances; instances
+	// of older versions leave them out and have their percentiles averaged
+	Histograms         *router.LatencyHistograms      `json:"histograms,omitempty"`
 }
 
 // AggregatedMetrics represents combined metrics from all instances
@@ -41,49 +56,45 @@ type AggregatedMetrics struct {
 	SuccessfulRequests int64                          `json:"SuccessfulRequests"`
 	FailedRequests     int64                          `json:"FailedRequests"`
 	FallbackAttempts   int64                          `json:"FallbackAttempts"`
+	QueuedRequests     int64                          `json:"QueuedRequests"`
+	AvgQueueWait       time.Duration                  `json:"AvgQueueWait"`
+	MaxQueueWait       time.Duration                  `json:"MaxQueueWait"`
 	ActiveInstances    int                            `json:"ActiveInstances"`
 	HealthStatus       map[string]*router.HealthStatus `json:"HealthStatus"`
 	ProviderMetrics    map[string]router.ProviderMetrics `json:"ProviderMetrics"`
 	OverallLatency     router.OverallLatencyMetrics   `json:"OverallLatency"`
 }
 
-// StoredMetrics represents the entire metrics file structure
+// StoredMetrics holds the metrics of every active instance
 type StoredMetrics struct {
 	Instances map[string]*InstanceMetrics `json:"instances"`
 	Updated   time.Time                   `json:"updated"`
 }
 
-// NewSharedMetricsStore creates a new shared metrics store
-func NewSharedMetricsStore() (*SharedMetricsStore, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+// StoreDir returns the directory holding the instances' metrics files
+func StoreDir() string {
+	metricsDir := config.ConfigDir()
+	if config.NoPersist() {
+		// Still shared by the instances in this container
+		metricsDir = filepath.Join(os.TempDir(), "mcp-code-api")
 	}
+	return filepath.Join(metricsDir, "metrics")
+}
 
-	metricsDir := filepath.Join(homeDir, ".mcp-code-api")
-	if err := os.MkdirAll(metricsDir, 0755); err != nil {
+// NewSharedMetricsStore creates a new shared metrics store
+func NewSharedMetricsStore() (*SharedMetricsStore, error) {
+	dir := StoreDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create metrics directory: %w", err)
 	}
-
-	filePath := filepath.Join(metricsDir, "metrics.json")
-	instanceID := fmt.Sprintf("mcp-%d", os.Getpid())
+	// Older versions shared a single metrics.json between instances
+	_ = os.Remove(filepath.Join(filepath.Dir(dir), "metrics.json"))
 
 	store := &SharedMetricsStore{
-		filePath:   filePath,
-		instanceID: instanceID,
+		dir:        dir,
+		instanceID: fmt.Sprintf("mcp-%d", os.Getpid()),
 		stopChan:   make(chan bool),
 	}
-
-	// Initialize file if it doesn't exist
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		if err := store.writeMetrics(&StoredMetrics{
-			Instances: make(map[string]*InstanceMetrics),
-			Updated:   time.Now(),
-		}); err != nil {
-			return nil, fmt.Errorf("failed to initialize metrics file: %w", err)
-		}
-	}
-
 	return store, nil
 }
 
@@ -116,20 +127,11 @@ func (s *SharedMetricsStore) Stop() {
 	}
 	close(s.stopChan)
 
-	// Remove this instance from the metrics file
+	// Remove this instance's metrics file
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	stored, err := s.readMetrics()
-	if err != nil {
-		logger.Warnf("Failed to read metrics on shutdown: %v", err)
-		return
-	}
-
-	delete(stored.Instances, s.instanceID)
-	stored.Updated = time.Now()
-
-	if err := s.writeMetrics(stored); err != nil {
+	if err := os.Remove(s.instanceFile(s.instanceID)); err != nil && !os.IsNotExist(err) {
 		logger.Warnf("Failed to clean up instance metrics: %v", err)
 	}
 
@@ -141,47 +143,34 @@ func (s *SharedMetricsStore) UpdateMetrics(r *router.EnhancedRouter) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	// Read current metrics
-	stored, err := s.readMetrics()
-	if err != nil {
-		return fmt.Errorf("failed to read metrics: %w", err)
-	}
-
 	// Get current router metrics
 	routerMetrics := r.GetMetrics()
 	healthStatus := r.GetHealthStatus()
 	providerMetrics := r.GetProviderMetrics()
 	overallLatency := r.GetOverallLatencyMetrics()
+	histograms := r.GetLatencyHistograms()
 
-	// Update this instance's metrics
-	stored.Instances[s.instanceID] = &InstanceMetrics{
+	// Replace this instance's metrics
+	instance := &InstanceMetrics{
 		InstanceID:         s.instanceID,
 		LastUpdate:         time.Now(),
 		TotalRequests:      routerMetrics.TotalRequests,
 		SuccessfulRequests: routerMetrics.SuccessfulRequests,
 		FailedRequests:     routerMetrics.FailedRequests,
 		FallbackAttempts:   routerMetrics.FallbackAttempts,
+		QueuedRequests:     routerMetrics.QueuedRequests,
+		TotalQueueWait:     routerMetrics.TotalQueueWait,
+		MaxQueueWait:       routerMetrics.MaxQueueWait,
 		HealthStatus:       healthStatus,
 		ProviderMetrics:    providerMetrics,
 		OverallLatency:     overallLatency,
+		Histograms:         &histograms,
 	}
-
-	// Clean up stale instances (older than 10 seconds)
-	staleThreshold := time.Now().Add(-10 * time.Second)
-	for id, instance := range stored.Instances {
-		if instance.LastUpdate.Before(staleThreshold) {
-			logger.Debugf("Removing stale instance: %s (last update: %s)", id, instance.LastUpdate)
-			delete(stored.Instances, id)
-		}
-	}
-
-	stored.Updated = time.Now()
-
-	// Write back to file
-	if err := s.writeMetrics(stored); err != nil {
+	if err := s.writeInstance(instance); err != nil {
 		return fmt.Errorf("failed to write metrics: %w", err)
 	}
 
+	s.removeStale()
 	s.lastUpdate = time.Now()
 	return nil
 }
@@ -202,11 +191,15 @@ func (s *SharedMetricsStore) GetAggregatedMetrics() (*AggregatedMetrics, error)
 		ProviderMetrics: make(map[string]router.ProviderMetrics),
 	}
 
+	var totalQueueWait time.Duration
 	for _, instance := range stored.Instances {
 		aggregated.TotalRequests += instance.TotalRequests
 		aggregated.SuccessfulRequests += instance.SuccessfulRequests
 		aggregated.FailedRequests += instance.FailedRequests
 		aggregated.FallbackAttempts += instance.FallbackAttempts
+		aggregated.QueuedRequests += instance.QueuedRequests
+		totalQueueWait += instance.TotalQueueWait
+		aggregated.MaxQueueWait = max(aggregated.MaxQueueWait, instance.MaxQueueWait)
 		aggregated.ActiveInstances++
 
 		// Merge health status (use most recent)
@@ -238,6 +231,9 @@ func (s *SharedMetricsStore) GetAggregatedMetrics() (*AggregatedMetrics, error)
 				existing.P50Latency = (existing.P50Latency + metrics.P50Latency) / 2
 				existing.P95Latency = (existing.P95Latency + metrics.P95Latency) / 2
 				existing.P99Latency = (existing.P99Latency + metrics.P99Latency) / 2
+				existing.AvgTTFT = averageNonZero(existing.AvgTTFT, metrics.AvgTTFT)
+				existing.P50TTFT = averageNonZero(existing.P50TTFT, metrics.P50TTFT)
+				existing.P95TTFT = averageNonZero(existing.P95TTFT, metrics.P95TTFT)
 
 				// Update total latency for average calculation
 				existing.TotalLatency += metrics.TotalLatency
@@ -246,6 +242,7 @@ func (s *SharedMetricsStore) GetAggregatedMetrics() (*AggregatedMetrics, error)
 				if metrics.LastUsed.After(existing.LastUsed) {
 					existing.LastUsed = metrics.LastUsed
 				}
+				existing.Keys = mergeKeyUsage(existing.Keys, metrics.Keys)
 
 				aggregated.ProviderMetrics[providerName] = existing
 			} else {
@@ -254,6 +251,9 @@ func (s *SharedMetricsStore) GetAggregatedMetrics() (*AggregatedMetrics, error)
 			}
 		}
 	}
+	if aggregated.QueuedRequests > 0 {
+		aggregated.AvgQueueWait = totalQueueWait / time.Duration(aggregated.QueuedRequests)
+	}
 
 	// Recalculate average latencies for all providers
 	for providerName, metrics := range aggregated.ProviderMetrics {
@@ -263,6 +263,29 @@ func (s *SharedMetricsStore) GetAggregatedMetrics() (*AggregatedMetrics, error)
 		}
 	}
 
+	// Where every instance sent histograms, replace the averaged
+	// percentiles with ones read from the merged histograms
+	if merged, ok := mergeHistograms(stored.Instances); ok {
+		for providerName, metrics := range aggregated.ProviderMetrics {
+			if latency := merged.Providers[providerName]; latency != nil && latency.Count > 0 {
+				metrics.MinLatency = latency.Min
+				metrics.P50Latency = latency.Quantile(0.50)
+				metrics.P95Latency = latency.Quantile(0.95)
+				metrics.P99Latency = latency.Quantile(0.99)
+				metrics.MaxLatency = latency.Max
+				metrics.AvgLatency = latency.Average()
+			}
+			if ttft := merged.TTFT[providerName]; ttft != nil && ttft.Count > 0 {
+				metrics.AvgTTFT = ttft.Average()
+				metrics.P50TTFT = ttft.Quantile(0.50)
+				metrics.P95TTFT = ttft.Quantile(0.95)
+			}
+			aggregated.ProviderMetrics[providerName] = metrics
+		}
+		aggregated.OverallLatency = latencyPercentiles(merged.Overall)
+		return aggregated, nil
+	}
+
 	// Aggregate overall latency metrics across instances
 	var overallMinLatency, overallP50Latency, overallP95Latency, overallP99Latency, overallMaxLatency time.Duration
 	var instanceCount int
@@ -298,44 +321,153 @@ func (s *SharedMetricsStore) GetAggregatedMetrics() (*AggregatedMetrics, error)
 	return aggregated, nil
 }
 
-// readMetrics reads metrics from the file (caller must hold lock)
+// mergeKeyUsage adds the per-key counts of another instance, matching keys by
+// their masked suffix
+func mergeKeyUsage(into, from []api.KeyUsage) []api.KeyUsage {
+	merged := append([]api.KeyUsage(nil), into...)
+	for _, usage := range from {
+		i := slices.IndexFunc(merged, func(existing api.KeyUsage) bool { return existing.Key == usage.Key })
+		if i < 0 {
+			merged = append(merged, usage)
+			continue
+		}
+		merged[i].Requests += usage.Requests
+		merged[i].Failures += usage.Failures
+		merged[i].RateLimitHits += usage.RateLimitHits
+		if merged[i].Requests > 0 {
+			merged[i].ErrorRate = float64(merged[i].Failures) / float64(merged[i].Requests)
+		}
+	}
+	return merged
+}
+
+// mergeHistograms adds up the latency histograms of instances. It reports
+// false if an instance has none, as the percentiles can then only be averaged.
+func mergeHistograms(instances map[string]*InstanceMetrics) (router.LatencyHistograms, bool) {
+	merged := router.LatencyHistograms{
+		Overall:   router.NewLatencyHistogram(),
+		Providers: make(map[string]*router.LatencyHistogram),
+		TTFT:      make(map[string]*router.LatencyHistogram),
+	}
+	if len(instances) == 0 {
+		return merged, false
+	}
+	add := func(into map[string]*router.LatencyHistogram, from map[string]*router.LatencyHistogram) {
+		for key, histogram := range from {
+			if into[key] == nil {
+				into[key] = router.NewLatencyHistogram()
+			}
+			into[key].Merge(histogram)
+		}
+	}
+	for _, instance := range instances {
+		if instance.Histograms == nil {
+			return merged, false
+		}
+		merged.Overall.Merge(instance.Histograms.Overall)
+		add(merged.Providers, instance.Histograms.Providers)
+		add(merged.TTFT, instance.Histograms.TTFT)
+	}
+	return merged, true
+}
+
+// latencyPercentiles reads the overall latency percentiles from a histogram
+func latencyPercentiles(histogram *router.LatencyHistogram) router.OverallLatencyMetrics {
+	return router.OverallLatencyMetrics{
+		MinLatency: histogram.Min,
+		P50Latency: histogram.Quantile(0.50),
+		P95Latency: histogram.Quantile(0.95),
+		P99Latency: histogram.Quantile(0.99),
+		MaxLatency: histogram.Max,
+	}
+}
+
+// instanceFile returns the path of an instance's metrics file
+func (s *SharedMetricsStore) instanceFile(instanceID string) string {
+	return filepath.Join(s.dir, instanceID+".json")
+}
+
+// readMetrics reads the metrics of every instance updated within staleAfter
+// (caller must hold lock). Files that cannot be read or parsed, such as one
+// removed while listing, are skipped.
 func (s *SharedMetricsStore) readMetrics() (*StoredMetrics, error) {
-	data, err := os.ReadFile(s.filePath)
+	stored := &StoredMetrics{
+		Instances: make(map[string]*InstanceMetrics),
+		Updated:   time.Now(),
+	}
+
+	entries, err := os.ReadDir(s.dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &StoredMetrics{
-				Instances: make(map[string]*InstanceMetrics),
-				Updated:   time.Now(),
-			}, nil
+			return stored, nil
 		}
 		return nil, err
 	}
 
-	var stored StoredMetrics
-	if err := json.Unmarshal(data, &stored); err != nil {
-		return nil, err
-	}
-
-	if stored.Instances == nil {
-		stored.Instances = make(map[string]*InstanceMetrics)
+	staleThreshold := time.Now().Add(-staleAfter)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var instance InstanceMetrics
+		if err := json.Unmarshal(data, &instance); err != nil {
+			logger.Debugf("Skipping unreadable metrics file %s: %v", entry.Name(), err)
+			continue
+		}
+		if instance.InstanceID == "" || instance.LastUpdate.Before(staleThreshold) {
+			continue
+		}
+		stored.Instances[instance.InstanceID] = &instance
 	}
-
-	return &stored, nil
+	return stored, nil
 }
 
-// writeMetrics writes metrics to the file (caller must hold lock)
-func (s *SharedMetricsStore) writeMetrics(stored *StoredMetrics) error {
-	data, err := json.MarshalIndent(stored, "", "  ")
+// writeInstance replaces the instance's metrics file. The file is written
+// under a temporary name and renamed, so readers never see it half written.
+func (s *SharedMetricsStore) writeInstance(instance *InstanceMetrics) error {
+	data, err := json.MarshalIndent(instance, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	// Write to temporary file first
-	tmpFile := s.filePath + ".tmp"
+	path := s.instanceFile(instance.InstanceID)
+	tmpFile := path + ".tmp"
 	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
 		return err
 	}
+	return os.Rename(tmpFile, path)
+}
+
+// removeStale deletes the files of instances that stopped updating without
+// cleaning up, e.g. after a crash
+func (s *SharedMetricsStore) removeStale() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	staleThreshold := time.Now().Add(-staleAfter)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !info.ModTime().Before(staleThreshold) {
+			continue
+		}
+		logger.Debugf("Removing stale instance metrics: %s (last update: %s)", entry.Name(), info.ModTime())
+		_ = os.Remove(filepath.Join(s.dir, entry.Name()))
+	}
+}
 
-	// Atomic rename
-	return os.Rename(tmpFile, s.filePath)
-}
\ No newline at end of file
+// averageNonZero averages two durations, ignoring one that is unset, e.g.
+// the time to first token of an instance that never streamed
+func averageNonZero(a, b time.Duration) time.Duration {
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	}
+	return (a + b) / 2
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+)
+
+func TestAggregatedPercentilesMergeHistograms(t *testing.T) {
+	store := &SharedMetricsStore{dir: t.TempDir()}
+	instance := func(id string, count int, latency time.Duration) *InstanceMetrics {
+		histogram := router.NewLatencyHistogram()
+		for i := 0; i < count; i++ {
+			histogram.Add(latency)
+		}
+		return &InstanceMetrics{
+			InstanceID: id,
+			LastUpdate: time.Now(),
+			ProviderMetrics: map[string]router.ProviderMetrics{
+				"mock": {Name: "mock", TotalRequests: int64(count), SuccessfulRequests: int64(count), P50Latency: latency, P95Latency: latency, P99Latency: latency},
+			},
+			OverallLatency: router.OverallLatencyMetrics{P50Latency: latency, P95Latency: latency, P99Latency: latency},
+			Histograms: &router.LatencyHistograms{
+				Overall:   histogram,
+				Providers: map[string]*router.LatencyHistogram{"mock": histogram},
+			},
+		}
+	}
+	for _, instance := range []*InstanceMetrics{
+		instance("fast", 90, 100*time.Millisecond),
+		instance("slow", 10, 10*time.Second),
+	} {
+		if err := store.writeInstance(instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	aggregated, err := store.GetAggregatedMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := aggregated.ProviderMetrics["mock"]
+	if mock.P50Latency > 101*time.Millisecond || aggregated.OverallLatency.P50Latency > 101*time.Millisecond {
+		t.Errorf("P50 = %v, overall %v, want about 100ms", mock.P50Latency, aggregated.OverallLatency.P50Latency)
+	}
+	if mock.P95Latency < 9900*time.Millisecond {
+		t.Errorf("P95 = %v, want about 10s", mock.P95Latency)
+	}
+	if want := (90*100*time.Millisecond + 10*10*time.Second) / 100; mock.AvgLatency != want {
+		t.Errorf("AvgLatency = %v, want %v", mock.AvgLatency, want)
+	}
+
+	// An instance without histograms falls back to averaging
+	old := instance("old", 10, time.Second)
+	old.Histograms = nil
+	if err := store.writeInstance(old); err != nil {
+		t.Fatal(err)
+	}
+	if aggregated, err = store.GetAggregatedMetrics(); err != nil {
+		t.Fatal(err)
+	}
+	if aggregated.OverallLatency.P50Latency < time.Second {
+		t.Errorf("overall P50 = %v, want the averaged percentiles", aggregated.OverallLatency.P50Latency)
+	}
+}
+
+func TestInstancesWriteConcurrently(t *testing.T) {
+	dir := t.TempDir()
+
+	// Leftovers: a crashed instance's file, a half-written one and a
+	// temporary file
+	crashed, _ := json.Marshal(&InstanceMetrics{InstanceID: "mcp-crashed", LastUpdate: time.Now().Add(-time.Minute)})
+	for name, data := range map[string][]byte{
+		"mcp-crashed.json": crashed,
+		"mcp-torn.json":    []byte(`{"instance_id": "mcp-to`),
+		"mcp-1.json.tmp":   []byte(`{`),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-time.Minute)
+	_ = os.Chtimes(filepath.Join(dir, "mcp-crashed.json"), old, old)
+
+	// Stores in one process stand in for separate processes, which share
+	// nothing but the directory
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store := &SharedMetricsStore{dir: dir, instanceID: "mcp-" + string(rune('a'+i))}
+			for j := 1; j <= 20; j++ {
+				if err := store.writeInstance(&InstanceMetrics{InstanceID: store.instanceID, LastUpdate: time.Now(), TotalRequests: int64(j)}); err != nil {
+					t.Error(err)
+					return
+				}
+				store.removeStale()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	store := &SharedMetricsStore{dir: dir}
+	aggregated, err := store.GetAggregatedMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregated.ActiveInstances != 8 || aggregated.TotalRequests != 8*20 {
+		t.Errorf("instances, requests = %d, %d, want 8, 160", aggregated.ActiveInstances, aggregated.TotalRequests)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mcp-crashed.json")); !os.IsNotExist(err) {
+		t.Error("stale instance file was not removed")
+	}
+}
+
+func TestMergeKeyUsage(t *testing.T) {
+	merged := mergeKeyUsage(
+		[]api.KeyUsage{{Key: "...0001", Requests: 10, Failures: 1}},
+		[]api.KeyUsage{{Key: "...0001", Requests: 10, Failures: 3, RateLimitHits: 2}, {Key: "...0002", Requests: 5}},
+	)
+	if len(merged) != 2 {
+		t.Fatalf("merged = %+v, want 2 keys", merged)
+	}
+	if first := merged[0]; first.Requests != 20 || first.Failures != 4 || first.RateLimitHits != 2 || first.ErrorRate != 0.2 {
+		t.Errorf("first key = %+v", first)
+	}
+}
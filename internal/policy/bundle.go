@@ -0,0 +1,321 @@
+package policy
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v3"
+)
+
+// Managed policy bundle file names inside the managed policy directory
+const (
+	BundleFileName    = "policy.yaml"
+	SignatureFileName = "policy.yaml.sig"
+	PublicKeyFileName = "policy.pub"
+)
+
+// Bundle is an organization-managed policy that the local user cannot override.
+// It is layered on top of the personal configuration after it has been loaded.
+type Bundle struct {
+	Organization     string                  `mapstructure:"organization"`
+	AllowedProviders []string                `mapstructure:"allowed_providers"`
+	AllowedPaths     []string                `mapstructure:"allowed_paths"`
+	DeniedPaths      []string                `mapstructure:"denied_paths"`
+	Limits           config.LimitsConfig     `mapstructure:"limits"`
+	Redaction        *config.RedactionConfig `mapstructure:"redaction"`
+	Residency        *config.ResidencyConfig `mapstructure:"residency"`
+	Audit            *config.AuditConfig     `mapstructure:"audit"`
+	AuditRetention   *config.RetentionPolicy `mapstructure:"audit_retention"` // Replaces retention.audit when audit is required
+	License          *config.LicenseConfig   `mapstructure:"license"`
+}
+
+// ManagedPolicyDir returns the system-wide directory holding the managed bundle.
+// It is intentionally not configurable from user config or environment so a
+// local user cannot point the server at a different (or empty) bundle.
+func ManagedPolicyDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "mcp-code-api")
+	case "darwin":
+		return "/Library/Application Support/mcp-code-api"
+	default:
+		return "/etc/mcp-code-api"
+	}
+}
+
+// LoadManagedBundle loads and verifies the bundle from ManagedPolicyDir.
+// Returns (nil, nil) when no bundle is installed.
+func LoadManagedBundle() (*Bundle, error) {
+	return LoadBundle(ManagedPolicyDir())
+}
+
+// LoadBundle loads the bundle in dir and verifies its signature against the
+// public key installed alongside it. Any verification failure is an error:
+// a tampered bundle must stop the server, not be silently ignored.
+func LoadBundle(dir string) (*Bundle, error) {
+	bundlePath := filepath.Join(dir, BundleFileName)
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read policy bundle: %w", err)
+	}
+
+	keyData, err := os.ReadFile(filepath.Join(dir, PublicKeyFileName))
+	if err != nil {
+		return nil, fmt.Errorf("policy bundle present but public key missing: %w", err)
+	}
+	publicKey, err := ParsePublicKey(keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	sigData, err := os.ReadFile(filepath.Join(dir, SignatureFileName))
+	if err != nil {
+		return nil, fmt.Errorf("policy bundle present but signature missing: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigData)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy bundle signature encoding: %w", err)
+	}
+
+	if !ed25519.Verify(publicKey, data, signature) {
+		return nil, fmt.Errorf("policy bundle signature verification failed for %s", bundlePath)
+	}
+
+	bundle, err := ParseBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Loaded signed policy bundle from %s (organization: %s)", bundlePath, bundle.Organization)
+	return bundle, nil
+}
+
+// ParsePublicKey parses an Ed25519 public key in PEM (PKIX) or raw base64 form
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy public key: %w", err)
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("policy public key must be Ed25519, got %T", key)
+		}
+		return edKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode policy public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("policy public key has invalid length %d", len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ParseBundle decodes bundle YAML using the same field names as config.yaml
+func ParseBundle(data []byte) (*Bundle, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse policy bundle: %w", err)
+	}
+
+	var bundle Bundle
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:     &bundle,
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, fmt.Errorf("failed to decode policy bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// Apply layers the bundle over cfg. Personal settings may only narrow what the
+// bundle allows: provider and path lists are intersected, denied paths are
+// added, limits take the stricter value, redaction/residency rules are merged
+// with the bundle's and a required audit log is forced on.
+func (b *Bundle) Apply(cfg *config.Config) {
+	if b == nil || cfg == nil {
+		return
+	}
+
+	if len(b.AllowedProviders) > 0 {
+		allowed := make(map[string]bool)
+		for _, p := range b.AllowedProviders {
+			allowed[p] = true
+		}
+		cfg.Providers.Enabled = filterStrings(cfg.Providers.Enabled, allowed)
+		cfg.Providers.Order = filterOrder(cfg.Providers.Order, allowed)
+	}
+
+	if len(b.AllowedPaths) > 0 {
+		var narrowed []string
+		for _, userPath := range cfg.Workspace.AllowedPaths {
+			if IsPathWithin(userPath, b.AllowedPaths) {
+				narrowed = append(narrowed, userPath)
+			}
+		}
+		if len(narrowed) == 0 {
+			narrowed = b.AllowedPaths
+		}
+		cfg.Workspace.AllowedPaths = narrowed
+	}
+	cfg.Workspace.DeniedPaths = append(cfg.Workspace.DeniedPaths, b.DeniedPaths...)
+
+	cfg.Limits.MaxRequestsPerDay = stricterLimit(cfg.Limits.MaxRequestsPerDay, b.Limits.MaxRequestsPerDay)
+	cfg.Limits.MaxTokensPerDay = stricterLimit(cfg.Limits.MaxTokensPerDay, b.Limits.MaxTokensPerDay)
+	for name, managed := range b.Limits.Providers {
+		if cfg.Limits.Providers == nil {
+			cfg.Limits.Providers = make(map[string]config.ProviderRateLimit)
+		}
+		user := cfg.Limits.Providers[name]
+		user.RequestsPerMinute = stricterLimit(user.RequestsPerMinute, managed.RequestsPerMinute)
+		user.Burst = stricterLimit(user.Burst, managed.Burst)
+		if user.MaxWait == 0 {
+			user.MaxWait = managed.MaxWait
+		}
+		cfg.Limits.Providers[name] = user
+	}
+
+	if b.Redaction != nil && b.Redaction.Enabled {
+		user := cfg.Redaction
+		merged := *b.Redaction
+		if user.Enabled {
+			merged.Emails = merged.Emails || user.Emails
+			merged.Names = append(merged.Names, user.Names...)
+			merged.Hostnames = append(merged.Hostnames, user.Hostnames...)
+			merged.Patterns = append(merged.Patterns, user.Patterns...)
+			if merged.NamesFile == "" {
+				merged.NamesFile = user.NamesFile
+			}
+		}
+		cfg.Redaction = merged
+	}
+
+	if b.Residency != nil {
+		// With managed policies only the bundle's region tags count, or a
+		// local tag on any other provider would satisfy them
+		regions := cfg.Residency.Regions
+		if regions == nil || len(b.Residency.Policies) > 0 {
+			regions = make(map[string]string)
+		}
+		for provider, region := range b.Residency.Regions {
+			regions[provider] = region
+		}
+		cfg.Residency.Regions = regions
+		cfg.Residency.Policies = append(cfg.Residency.Policies, b.Residency.Policies...)
+	}
+
+	// A managed audit requirement cannot be switched off, redirected or
+	// cleaned up locally: only the bundle's retention applies to the log
+	if b.Audit != nil && b.Audit.Enabled {
+		cfg.Audit.Enabled = true
+		if b.Audit.Dir != "" {
+			cfg.Audit.Dir = b.Audit.Dir
+		}
+		cfg.Retention.Audit = config.RetentionPolicy{}
+		if b.AuditRetention != nil {
+			cfg.Retention.Audit = *b.AuditRetention
+		}
+	}
+
+	// A managed license policy adds to the local one and can only make it stricter
+	if b.License != nil && b.License.Enabled {
+		user := cfg.License
+		merged := *b.License
+		if user.Enabled {
+			merged.Blocklist = append(merged.Blocklist, user.Blocklist...)
+			merged.DeniedLicenses = append(merged.DeniedLicenses, user.DeniedLicenses...)
+			if user.Mode == config.LicenseModeReject {
+				merged.Mode = config.LicenseModeReject
+			}
+			if user.MinLines > 0 && (merged.MinLines <= 0 || user.MinLines < merged.MinLines) {
+				merged.MinLines = user.MinLines
+			}
+		}
+		cfg.License = merged
+	}
+}
+
+// AllowsProvider reports whether the bundle lets providerName be used; with
+// no bundle or no provider list every provider is allowed
+func (b *Bundle) AllowsProvider(providerName string) bool {
+	return b == nil || len(b.AllowedProviders) == 0 || slices.Contains(b.AllowedProviders, providerName)
+}
+
+// IsPathWithin reports whether path is equal to or below one of roots,
+// after resolving symlinks on both sides. Windows paths compare without
+// regard to case, as the file system does.
+func IsPathWithin(path string, roots []string) bool {
+	abs, err := ResolvePath(path)
+	if err != nil {
+		return false
+	}
+	abs = foldCase(abs)
+	for _, root := range roots {
+		rootAbs, err := ResolvePath(root)
+		if err != nil {
+			continue
+		}
+		rootAbs = foldCase(rootAbs)
+		if abs == rootAbs || strings.HasPrefix(abs, strings.TrimSuffix(rootAbs, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func filterStrings(values []string, allowed map[string]bool) []string {
+	var result []string
+	for _, v := range values {
+		if allowed[v] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// filterOrder keeps the steps of preferred_order, including those of
+// fallback chains, whose provider is allowed
+func filterOrder(order []string, allowed map[string]bool) []string {
+	var result []string
+	for _, step := range config.ExpandOrder(order) {
+		if providerName, _ := config.SplitOrderStep(step); allowed[providerName] {
+			result = append(result, step)
+		}
+	}
+	return result
+}
+
+// stricterLimit returns the smaller non-zero limit (0 means unlimited)
+func stricterLimit(user, managed int) int {
+	if managed <= 0 {
+		return user
+	}
+	if user <= 0 || managed < user {
+		return managed
+	}
+	return user
+}
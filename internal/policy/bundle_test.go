@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+const testBundle = `organization: "Example Corp"
+allowed_providers: ["anthropic"]
+allowed_paths: ["/work"]
+limits:
+  max_tokens_per_day: 1000
+`
+
+// writeSignedBundle writes a bundle, its signature and public key into dir
+func writeSignedBundle(t *testing.T, dir string, content string, signed []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	files := map[string]string{
+		BundleFileName:    content,
+		SignatureFileName: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, signed)),
+		PublicKeyFileName: base64.StdEncoding.EncodeToString(pub),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// TestLoadBundleApply tests that a valid bundle loads and narrows the config
+func TestLoadBundleApply(t *testing.T) {
+	dir := t.TempDir()
+	writeSignedBundle(t, dir, testBundle, []byte(testBundle))
+
+	bundle, err := LoadBundle(dir)
+	if err != nil {
+		t.Fatalf("Failed to load bundle: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Providers.Enabled = []string{"anthropic", "cerebras"}
+	cfg.Limits.MaxTokensPerDay = 5000
+	cfg.Workspace.AllowedPaths = []string{"/work/team", "/home/me"}
+	bundle.Apply(cfg)
+
+	if len(cfg.Providers.Enabled) != 1 || cfg.Providers.Enabled[0] != "anthropic" {
+		t.Errorf("Expected only anthropic enabled, got %v", cfg.Providers.Enabled)
+	}
+	if cfg.Limits.MaxTokensPerDay != 1000 {
+		t.Errorf("Expected managed token limit 1000, got %d", cfg.Limits.MaxTokensPerDay)
+	}
+	if len(cfg.Workspace.AllowedPaths) != 1 || cfg.Workspace.AllowedPaths[0] != "/work/team" {
+		t.Errorf("Expected user paths narrowed to /work/team, got %v", cfg.Workspace.AllowedPaths)
+	}
+}
+
+// TestLoadBundleTampered tests that a modified bundle fails verification
+func TestLoadBundleTampered(t *testing.T) {
+	dir := t.TempDir()
+	writeSignedBundle(t, dir, testBundle+"allowed_paths: [\"/\"]\n", []byte(testBundle))
+
+	if _, err := LoadBundle(dir); err == nil {
+		t.Error("Expected signature verification error for tampered bundle")
+	}
+}
+
+// TestLoadBundleMissing tests that an absent bundle is not an error
+func TestLoadBundleMissing(t *testing.T) {
+	bundle, err := LoadBundle(t.TempDir())
+	if err != nil || bundle != nil {
+		t.Errorf("Expected (nil, nil) for missing bundle, got (%v, %v)", bundle, err)
+	}
+}
+
+// TestBundleResidencyRegionsAreAuthoritative tests that local region tags
+// can't satisfy managed residency policies
+func TestBundleResidencyRegionsAreAuthoritative(t *testing.T) {
+	bundle := &Bundle{Residency: &config.ResidencyConfig{
+		Regions:  map[string]string{"mistral": "eu"},
+		Policies: []config.ResidencyPolicyConfig{{Path: "/work/client-x", AllowedRegions: []string{"eu"}}},
+	}}
+	cfg := &config.Config{}
+	cfg.Residency.Regions = map[string]string{"openrouter": "eu", "mistral": "us"}
+	bundle.Apply(cfg)
+
+	if len(cfg.Residency.Regions) != 1 || cfg.Residency.Regions["mistral"] != "eu" {
+		t.Errorf("Expected only the bundle's region tags, got %v", cfg.Residency.Regions)
+	}
+	if err := NewResidencyChecker(cfg.Residency).Check("openrouter", []string{"/work/client-x/main.go"}); err == nil {
+		t.Error("Expected a locally tagged provider to be rejected by the managed policy")
+	}
+
+	// Region tags alone add to the local ones
+	bundle = &Bundle{Residency: &config.ResidencyConfig{Regions: map[string]string{"mistral": "eu"}}}
+	cfg = &config.Config{}
+	cfg.Residency.Regions = map[string]string{"openrouter": "us"}
+	bundle.Apply(cfg)
+	if len(cfg.Residency.Regions) != 2 {
+		t.Errorf("Expected local and managed region tags, got %v", cfg.Residency.Regions)
+	}
+}
+
+// TestBundleAuditRetention tests that a required audit log is only pruned
+// by the bundle's retention
+func TestBundleAuditRetention(t *testing.T) {
+	local := config.RetentionPolicy{MaxAge: time.Hour, MaxSize: 1024}
+
+	cfg := &config.Config{}
+	cfg.Retention.Audit = local
+	(&Bundle{Audit: &config.AuditConfig{Enabled: true}}).Apply(cfg)
+	if cfg.Retention.Audit != (config.RetentionPolicy{}) {
+		t.Errorf("Expected a required audit log to be kept, got retention %+v", cfg.Retention.Audit)
+	}
+
+	managed := config.RetentionPolicy{MaxAge: 90 * 24 * time.Hour}
+	cfg = &config.Config{}
+	cfg.Retention.Audit = local
+	(&Bundle{Audit: &config.AuditConfig{Enabled: true}, AuditRetention: &managed}).Apply(cfg)
+	if cfg.Retention.Audit != managed {
+		t.Errorf("Expected the bundle's audit retention, got %+v", cfg.Retention.Audit)
+	}
+
+	// Without an audit requirement the local policy stands
+	cfg = &config.Config{}
+	cfg.Retention.Audit = local
+	(&Bundle{AuditRetention: &managed}).Apply(cfg)
+	if cfg.Retention.Audit != local {
+		t.Errorf("Expected the local audit retention, got %+v", cfg.Retention.Audit)
+	}
+}
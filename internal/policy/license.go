@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// maxBlocklistFileSize skips files in the blocklist too large to be source code
+const maxBlocklistFileSize = 1 << 20
+
+// Kinds of license findings
+const (
+	LicenseFindingBlocklist = "blocklist" // Verbatim copy of a blocklisted file
+	LicenseFindingCitation  = "citation"  // Source cited by the provider
+)
+
+// LicenseFinding is a part of generated code the license policy flags
+type LicenseFinding struct {
+	Kind       string `json:"kind"`
+	Source     string `json:"source"`                // Blocklisted file or cited URI
+	License    string `json:"license,omitempty"`     // As reported by the provider
+	Line       int    `json:"line,omitempty"`        // First copied line of the generated code
+	Lines      int    `json:"lines,omitempty"`       // Length of the copy in generated lines
+	SourceLine int    `json:"source_line,omitempty"` // Where the copy starts in Source
+}
+
+func (f LicenseFinding) String() string {
+	if f.Kind == LicenseFindingCitation {
+		if f.License != "" {
+			return fmt.Sprintf("recites %s (license: %s)", f.Source, f.License)
+		}
+		return fmt.Sprintf("recites %s", f.Source)
+	}
+	return fmt.Sprintf("lines %d-%d copy %s:%d", f.Line, f.Line+f.Lines-1, f.Source, f.SourceLine)
+}
+
+// LicenseViolationError is returned when generated code breaks a license
+// policy in reject mode
+type LicenseViolationError struct {
+	Findings []LicenseFinding
+}
+
+func (e *LicenseViolationError) Error() string {
+	return "license policy violation: generated code " + FormatLicenseFindings(e.Findings)
+}
+
+// FormatLicenseFindings describes findings on one line
+func FormatLicenseFindings(findings []LicenseFinding) string {
+	descriptions := make([]string, len(findings))
+	for i, f := range findings {
+		descriptions[i] = f.String()
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// sourceLine locates a window of lines in a blocklisted file
+type sourceLine struct {
+	path string
+	line int
+}
+
+// numberedLine is a normalized line with its 1-based line number
+type numberedLine struct {
+	number int
+	text   string
+}
+
+// LicenseChecker compares generated code with a blocklist of sources and
+// flags the citations providers report
+type LicenseChecker struct {
+	reject   bool
+	minLines int
+	denied   []string
+	windows  map[uint64]sourceLine // Hash of minLines consecutive lines -> first occurrence
+}
+
+// NewLicenseChecker indexes the blocklist of cfg. Returns nil if the policy
+// is off; a blocklist entry that can't be read is an error.
+func NewLicenseChecker(cfg config.LicenseConfig) (*LicenseChecker, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	checker := &LicenseChecker{
+		reject:   cfg.Mode == config.LicenseModeReject,
+		minLines: cfg.MinLines,
+		windows:  make(map[uint64]sourceLine),
+	}
+	if checker.minLines <= 0 {
+		checker.minLines = config.DefaultLicenseMinLines
+	}
+	for _, license := range cfg.DeniedLicenses {
+		checker.denied = append(checker.denied, strings.ToLower(strings.TrimSpace(license)))
+	}
+
+	for _, entry := range cfg.Blocklist {
+		root := config.ExpandPath(entry)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.Size() > maxBlocklistFileSize {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if bytes.IndexByte(data, 0) >= 0 {
+				return nil // Binary
+			}
+			checker.index(path, string(data))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("license blocklist %s: %w", entry, err)
+		}
+	}
+	return checker, nil
+}
+
+// Windows returns how many line windows the blocklist holds
+func (c *LicenseChecker) Windows() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.windows)
+}
+
+// Rejects reports whether findings fail the generation
+func (c *LicenseChecker) Rejects() bool {
+	return c != nil && c.reject
+}
+
+// index adds every window of content's lines
+func (c *LicenseChecker) index(path, content string) {
+	lines := significantLines(content)
+	for i := 0; i+c.minLines <= len(lines); i++ {
+		hash := hashWindow(lines[i : i+c.minLines])
+		if _, ok := c.windows[hash]; !ok {
+			c.windows[hash] = sourceLine{path: path, line: lines[i].number}
+		}
+	}
+}
+
+// Check returns the findings in code: runs of at least minLines lines
+// copied from a blocklisted file, and the cited sources under a denied
+// license (any cited source if none are denied)
+func (c *LicenseChecker) Check(code string, citations []types.Citation) []LicenseFinding {
+	if c == nil {
+		return nil
+	}
+	var findings []LicenseFinding
+
+	lines := significantLines(code)
+	for i := 0; i+c.minLines <= len(lines); {
+		source, ok := c.windows[hashWindow(lines[i:i+c.minLines])]
+		if !ok {
+			i++
+			continue
+		}
+		// Extend the copy while the following windows match too
+		end := i + c.minLines
+		for end < len(lines) {
+			if _, ok := c.windows[hashWindow(lines[end+1-c.minLines:end+1])]; !ok {
+				break
+			}
+			end++
+		}
+		findings = append(findings, LicenseFinding{
+			Kind:       LicenseFindingBlocklist,
+			Source:     source.path,
+			Line:       lines[i].number,
+			Lines:      lines[end-1].number - lines[i].number + 1,
+			SourceLine: source.line,
+		})
+		i = end
+	}
+
+	for _, citation := range citations {
+		if !c.deniedLicense(citation.License) {
+			continue
+		}
+		source := citation.URI
+		if source == "" {
+			source = citation.Title
+		}
+		findings = append(findings, LicenseFinding{Kind: LicenseFindingCitation, Source: source, License: citation.License})
+	}
+	return findings
+}
+
+// deniedLicense reports whether a source under license is a finding
+func (c *LicenseChecker) deniedLicense(license string) bool {
+	if len(c.denied) == 0 {
+		return true
+	}
+	license = strings.ToLower(license)
+	for _, denied := range c.denied {
+		if license != "" && strings.Contains(license, denied) {
+			return true
+		}
+	}
+	return false
+}
+
+// significantLines returns content's lines with whitespace collapsed,
+// leaving out lines without a letter or digit (blank lines, lone braces),
+// so reformatting doesn't hide a copy
+func significantLines(content string) []numberedLine {
+	var lines []numberedLine
+	for i, line := range strings.Split(content, "\n") {
+		text := strings.Join(strings.Fields(line), " ")
+		if strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		lines = append(lines, numberedLine{number: i + 1, text: text})
+	}
+	return lines
+}
+
+func hashWindow(lines []numberedLine) uint64 {
+	h := fnv.New64a()
+	for _, line := range lines {
+		h.Write([]byte(line.text))
+		h.Write([]byte{'\n'})
+	}
+	return h.Sum64()
+}
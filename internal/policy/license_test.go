@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/types"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+const proprietary = `// Copyright Example Corp. All rights reserved.
+package ratelimit
+
+func (b *bucket) take(now int64) bool {
+	elapsed := now - b.last
+	b.tokens = min(b.capacity, b.tokens+elapsed*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+`
+
+func TestLicenseCheckerBlocklist(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bucket.go"), []byte(proprietary), 0600); err != nil {
+		t.Fatal(err)
+	}
+	checker, err := NewLicenseChecker(config.LicenseConfig{Enabled: true, Blocklist: []string{dir}, MinLines: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reindented and surrounded by other code, the copy is still found
+	copied := "package limiter\n\nimport \"time\"\n\n" + strings.ReplaceAll(proprietary[strings.Index(proprietary, "func"):], "\t", "    ")
+	findings := checker.Check(copied, nil)
+	if len(findings) != 1 {
+		t.Fatalf("Check() = %+v, want one copy", findings)
+	}
+	if f := findings[0]; f.Kind != LicenseFindingBlocklist || f.Line != 5 || f.Lines != 9 || f.SourceLine != 4 || !strings.HasSuffix(f.Source, "bucket.go") {
+		t.Errorf("finding = %+v, want lines 5-13 copied from bucket.go:4", f)
+	}
+
+	if findings := checker.Check("package limiter\n\nfunc take() bool {\n\treturn true\n}\n", nil); len(findings) != 0 {
+		t.Errorf("Check() of original code = %+v, want none", findings)
+	}
+}
+
+func TestLicenseCheckerCitations(t *testing.T) {
+	citations := []types.Citation{
+		{URI: "https://github.com/example/gpl-lib", License: "GPL-3.0"},
+		{URI: "https://github.com/example/mit-lib", License: "MIT"},
+	}
+
+	checker, err := NewLicenseChecker(config.LicenseConfig{Enabled: true, Mode: config.LicenseModeReject, DeniedLicenses: []string{"gpl"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checker.Rejects() {
+		t.Error("Rejects() = false in reject mode")
+	}
+	findings := checker.Check("package main\n", citations)
+	if len(findings) != 1 || findings[0].Source != "https://github.com/example/gpl-lib" {
+		t.Errorf("Check() = %+v, want only the GPL citation", findings)
+	}
+
+	checker, _ = NewLicenseChecker(config.LicenseConfig{Enabled: true})
+	if findings := checker.Check("package main\n", citations); len(findings) != 2 {
+		t.Errorf("Check() without denied licenses = %+v, want every citation", findings)
+	}
+}
+
+func TestLicenseCheckerDisabled(t *testing.T) {
+	checker, err := NewLicenseChecker(config.LicenseConfig{Blocklist: []string{"/does/not/exist"}})
+	if checker != nil || err != nil {
+		t.Errorf("NewLicenseChecker() = %v, %v; want nil when disabled", checker, err)
+	}
+	if _, err := NewLicenseChecker(config.LicenseConfig{Enabled: true, Blocklist: []string{"/does/not/exist"}}); err == nil {
+		t.Error("NewLicenseChecker() with a missing blocklist entry succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// ResidencyChecker enforces data-residency constraints on provider selection
+type ResidencyChecker struct {
+	regions map[string]string
+	rules   []residencyRule
+}
+
+type residencyRule struct {
+	prefix  string
+	allowed map[string]bool
+	raw     []string
+}
+
+// ViolationError is returned when a provider may not receive data for a path
+type ViolationError struct {
+	Provider       string
+	Region         string
+	Path           string
+	AllowedRegions []string
+}
+
+func (e *ViolationError) Error() string {
+	region := e.Region
+	if region == "" {
+		region = "untagged"
+	}
+	return fmt.Sprintf("data-residency policy violation: provider %s (region: %s) may not receive %s (allowed regions: %s)",
+		e.Provider, region, e.Path, strings.Join(e.AllowedRegions, ", "))
+}
+
+// NewResidencyChecker creates a checker from configuration.
+// Returns nil if no policies are configured.
+func NewResidencyChecker(cfg config.ResidencyConfig) *ResidencyChecker {
+	if len(cfg.Policies) == 0 {
+		return nil
+	}
+
+	checker := &ResidencyChecker{
+		regions: make(map[string]string),
+	}
+	for provider, region := range cfg.Regions {
+		checker.regions[strings.ToLower(provider)] = strings.ToLower(strings.TrimSpace(region))
+	}
+
+	for _, p := range cfg.Policies {
+		if strings.TrimSpace(p.Path) == "" {
+			continue
+		}
+		prefix, err := ResolvePath(p.Path)
+		if err != nil {
+			prefix = filepath.Clean(config.ExpandPath(p.Path))
+		}
+		rule := residencyRule{
+			prefix:  foldCase(prefix),
+			allowed: make(map[string]bool),
+		}
+		for _, region := range p.AllowedRegions {
+			region = strings.ToLower(strings.TrimSpace(region))
+			rule.allowed[region] = true
+			rule.raw = append(rule.raw, region)
+		}
+		checker.rules = append(checker.rules, rule)
+	}
+
+	// Most specific paths first for clearer error messages
+	sort.SliceStable(checker.rules, func(i, j int) bool {
+		return len(checker.rules[i].prefix) > len(checker.rules[j].prefix)
+	})
+
+	return checker
+}
+
+// RegionFor returns the region tag configured for a provider ("" if untagged)
+func (c *ResidencyChecker) RegionFor(provider string) string {
+	if c == nil {
+		return ""
+	}
+	return c.regions[strings.ToLower(provider)]
+}
+
+// Check verifies that provider may receive the contents of all the given paths.
+// Returns a *ViolationError for the first path the provider is not allowed to see.
+func (c *ResidencyChecker) Check(provider string, paths []string) error {
+	if c == nil {
+		return nil
+	}
+
+	region := c.RegionFor(provider)
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		for _, rule := range c.rules {
+			if !rule.matches(path) {
+				continue
+			}
+			if region == "" || !rule.allowed[region] {
+				return &ViolationError{
+					Provider:       provider,
+					Region:         region,
+					Path:           path,
+					AllowedRegions: rule.raw,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matches reports whether path falls under the rule's directory prefix.
+// Symlinks are resolved first, so a link outside the prefix that points
+// into it is still covered.
+func (r residencyRule) matches(path string) bool {
+	abs, err := ResolvePath(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	abs = foldCase(abs)
+	if abs == r.prefix {
+		return true
+	}
+	return strings.HasPrefix(abs, strings.TrimSuffix(r.prefix, string(filepath.Separator))+string(filepath.Separator))
+}
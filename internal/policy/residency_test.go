@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestResidencyChecker(t *testing.T) {
+	work := t.TempDir()
+	clientX := filepath.Join(work, "client-x")
+	outside := t.TempDir()
+	if err := os.MkdirAll(clientX, 0755); err != nil {
+		t.Fatal(err)
+	}
+	checker := NewResidencyChecker(config.ResidencyConfig{
+		Regions:  map[string]string{"mistral": "EU", "cerebras": "us"},
+		Policies: []config.ResidencyPolicyConfig{{Path: clientX, AllowedRegions: []string{"eu"}}},
+	})
+
+	link := filepath.Join(outside, "linked")
+	symlinks := os.Symlink(clientX, link) == nil
+
+	tests := []struct {
+		name     string
+		provider string
+		path     string
+		wantErr  bool
+	}{
+		{"allowed region", "mistral", filepath.Join(clientX, "main.go"), false},
+		{"other region", "cerebras", filepath.Join(clientX, "main.go"), true},
+		{"untagged provider", "openrouter", filepath.Join(clientX, "main.go"), true},
+		{"the directory itself", "cerebras", clientX, true},
+		{"nested new file", "cerebras", filepath.Join(clientX, "pkg", "new.go"), true},
+		{"sibling with the prefix as name prefix", "cerebras", filepath.Join(work, "client-xy", "main.go"), false},
+		{"outside any policy", "openrouter", filepath.Join(outside, "main.go"), false},
+		{"symlink into the policy", "cerebras", filepath.Join(link, "main.go"), symlinks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checker.Check(tt.provider, []string{tt.path})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check(%s, %s) = %v, want error: %v", tt.provider, tt.path, err, tt.wantErr)
+			}
+			var violation *ViolationError
+			if err != nil && (!errors.As(err, &violation) || violation.Provider != tt.provider) {
+				t.Errorf("error = %v, want a violation naming %s", err, tt.provider)
+			}
+		})
+	}
+
+	if NewResidencyChecker(config.ResidencyConfig{Regions: map[string]string{"mistral": "eu"}}) != nil {
+		t.Error("NewResidencyChecker() returned a checker without policies")
+	}
+}
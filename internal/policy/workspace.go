@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// ResolvePath returns the absolute form of path with symlinks resolved, so a
+// link inside the workspace cannot be used to reach a file outside it. Path
+// components that do not exist yet (a file about to be created) are kept as
+// given below the deepest existing directory.
+func ResolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(config.ExpandPath(path))
+	if err != nil {
+		return "", err
+	}
+
+	existing, rest := abs, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+// foldCase lowercases path on Windows, whose file systems ignore case, so
+// ".ENV" is denied like ".env"
+func foldCase(path string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// MatchDeniedPath returns the first pattern in denied that matches path.
+// Patterns containing a path separator (or starting with ~) name a file or
+// directory tree, e.g. "~/.ssh"; other patterns are globs matched against
+// every component of the path, so ".git" denies anything inside a .git
+// directory and "*.pem" any PEM file. A path that can't be resolved, such
+// as one through a symlink loop or an unreadable directory, could lead
+// anywhere, so it is denied by any non-empty list with an empty pattern.
+func MatchDeniedPath(path string, denied []string) (string, bool) {
+	resolved, err := ResolvePath(path)
+	if err != nil {
+		return "", len(denied) > 0
+	}
+	components := strings.Split(filepath.ToSlash(foldCase(resolved)), "/")
+
+	for _, pattern := range denied {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if strings.HasPrefix(pattern, "~") || strings.ContainsAny(pattern, `/\`) {
+			if IsPathWithin(resolved, []string{pattern}) {
+				return pattern, true
+			}
+			if matched, _ := filepath.Match(foldCase(filepath.Clean(config.ExpandPath(pattern))), foldCase(resolved)); matched {
+				return pattern, true
+			}
+			continue
+		}
+		for _, component := range components {
+			if matched, _ := filepath.Match(foldCase(pattern), component); matched {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// WorkspaceAllows reports whether ws lets path be read: it is inside the
+// allowed paths, if any, and matches no denied pattern
+func WorkspaceAllows(ws config.WorkspaceConfig, path string) bool {
+	if len(ws.AllowedPaths) > 0 && !IsPathWithin(path, ws.AllowedPaths) {
+		return false
+	}
+	_, denied := MatchDeniedPath(path, ws.DeniedPaths)
+	return !denied
+}
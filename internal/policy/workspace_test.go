@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestIsPathWithinResolvesSymlinks(t *testing.T) {
+	workspace := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(workspace, "escape")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	if !IsPathWithin(filepath.Join(workspace, "src", "new.go"), []string{workspace}) {
+		t.Error("new file inside the workspace rejected")
+	}
+	if IsPathWithin(filepath.Join(workspace, "escape", "evil.go"), []string{workspace}) {
+		t.Error("file reached through a symlink out of the workspace accepted")
+	}
+	if IsPathWithin(filepath.Join(workspace, "..", filepath.Base(outside), "x.go"), []string{workspace}) {
+		t.Error("path with .. out of the workspace accepted")
+	}
+}
+
+func TestMatchDeniedPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // Windows
+	workspace := t.TempDir()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(workspace, ".env"), ".env"},
+		{filepath.Join(workspace, "config", ".env.production"), ".env.*"},
+		{filepath.Join(workspace, ".git", "hooks", "pre-commit"), ".git"},
+		{filepath.Join(workspace, "certs", "server.pem"), "*.pem"},
+		{filepath.Join(home, ".ssh", "authorized_keys"), "~/.ssh"},
+		{filepath.Join(workspace, ".github", "workflows", "ci.yml"), ""},
+		{filepath.Join(workspace, "main.go"), ""},
+	}
+	if runtime.GOOS == "windows" {
+		// The file system ignores case, so the deny list must too
+		tests = append(tests,
+			struct{ path, want string }{filepath.Join(workspace, ".ENV"), ".env"},
+			struct{ path, want string }{filepath.Join(home, ".SSH", "config"), "~/.ssh"},
+		)
+	}
+	for _, tt := range tests {
+		pattern, denied := MatchDeniedPath(tt.path, config.DefaultDeniedPaths)
+		if pattern != tt.want || denied != (tt.want != "") {
+			t.Errorf("MatchDeniedPath(%s) = (%q, %v), want %q", tt.path, pattern, denied, tt.want)
+		}
+	}
+
+	if _, denied := MatchDeniedPath(filepath.Join(workspace, ".env"), nil); denied {
+		t.Error("empty deny list denied a path")
+	}
+
+	// A path that can't be resolved is denied rather than let through
+	loop := filepath.Join(workspace, "loop")
+	if err := os.Symlink(loop, loop); err != nil {
+		t.Skipf("Cannot create symlinks: %v", err)
+	}
+	if pattern, denied := MatchDeniedPath(filepath.Join(loop, "main.go"), config.DefaultDeniedPaths); !denied || pattern != "" {
+		t.Errorf("MatchDeniedPath(symlink loop) = (%q, %v), want denied", pattern, denied)
+	}
+	if _, denied := MatchDeniedPath(filepath.Join(loop, "main.go"), nil); denied {
+		t.Error("empty deny list denied an unresolvable path")
+	}
+}
@@ -0,0 +1,187 @@
+// Package prompts renders the system prompt sent to providers and the
+// instructions returned to MCP clients, from built-in defaults overridden by
+// the templates section of the configuration and optional template files.
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// DefaultSystem is the built-in system prompt for code generation
+const DefaultSystem = "You are an expert programmer. Generate ONLY clean, functional code in {{.Language}} with no explanations, comments about the code generation process, or markdown formatting. Include necessary imports and ensure the code is ready to run. When modifying existing files, preserve the structure and style while implementing the requested changes. Output raw code only. Never use markdown code blocks."
+
+// Data is available to system prompt and language templates
+type Data struct {
+	Language string // Target language, e.g. "go" or "python"
+	Provider string // Provider name, e.g. "cerebras"
+}
+
+// Set holds the parsed templates. A nil *Set renders the built-in defaults.
+type Set struct {
+	system       *template.Template
+	providers    map[string]*template.Template
+	languages    map[string]*template.Template
+	instructions string
+}
+
+var defaultSystem = template.Must(template.New("system").Parse(DefaultSystem))
+
+// NewSet builds a template set from configuration. Inline templates take
+// precedence over files in templates.dir. Returns nil if nothing is customized.
+func NewSet(cfg config.TemplatesConfig) (*Set, error) {
+	sources, err := readTemplateDir(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.System != "" {
+		sources["system"] = cfg.System
+	}
+	for name, text := range cfg.Providers {
+		sources["system."+strings.ToLower(name)] = text
+	}
+	for name, text := range cfg.Languages {
+		sources["language."+strings.ToLower(name)] = text
+	}
+	if cfg.Instructions != "" {
+		sources["instructions"] = cfg.Instructions
+	}
+
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	set := &Set{
+		providers: make(map[string]*template.Template),
+		languages: make(map[string]*template.Template),
+	}
+	for name, text := range sources {
+		if name == "instructions" {
+			set.instructions = text
+			continue
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", name, err)
+		}
+		// Catch references to unknown fields now rather than on the first request
+		if err := tmpl.Execute(new(strings.Builder), Data{Language: "go", Provider: "test"}); err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", name, err)
+		}
+		switch {
+		case name == "system":
+			set.system = tmpl
+		case strings.HasPrefix(name, "system."):
+			set.providers[strings.TrimPrefix(name, "system.")] = tmpl
+		case strings.HasPrefix(name, "language."):
+			set.languages[strings.TrimPrefix(name, "language.")] = tmpl
+		}
+	}
+	return set, nil
+}
+
+// readTemplateDir reads the template files in dir, keyed by name without the
+// .tmpl extension: system.tmpl, system.<provider>.tmpl,
+// language.<language>.tmpl and instructions.tmpl. A missing directory is not
+// an error.
+func readTemplateDir(dir string) (map[string]string, error) {
+	sources := make(map[string]string)
+	if dir == "" {
+		return sources, nil
+	}
+	dir = config.ExpandPath(dir)
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := strings.ToLower(strings.TrimSuffix(filepath.Base(file), ".tmpl"))
+		if name != "system" && name != "instructions" &&
+			!strings.HasPrefix(name, "system.") && !strings.HasPrefix(name, "language.") {
+			logger.Warnf("Ignoring unknown template file %s", file)
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", file, err)
+		}
+		sources[name] = string(data)
+	}
+	return sources, nil
+}
+
+// System renders the system prompt for provider generating language: the
+// provider's template (or the shared one), followed by any instructions for
+// the language
+func (s *Set) System(provider, language string) string {
+	data := Data{Language: language, Provider: provider}
+
+	tmpl := defaultSystem
+	var languageTmpl *template.Template
+	if s != nil {
+		if t := s.providers[strings.ToLower(provider)]; t != nil {
+			tmpl = t
+		} else if s.system != nil {
+			tmpl = s.system
+		}
+		languageTmpl = s.languages[strings.ToLower(language)]
+	}
+
+	prompt, err := render(tmpl, data)
+	if err != nil {
+		// Templates are validated at load time, so this should not happen
+		logger.Warnf("Failed to render %s template, using default: %v", tmpl.Name(), err)
+		prompt, _ = render(defaultSystem, data)
+	}
+	if languageTmpl != nil {
+		extra, err := render(languageTmpl, data)
+		if err != nil {
+			logger.Warnf("Failed to render %s template: %v", languageTmpl.Name(), err)
+		} else if extra = strings.TrimSpace(extra); extra != "" {
+			prompt = strings.TrimSpace(prompt) + "\n\n" + extra
+		}
+	}
+	return prompt
+}
+
+// Instructions returns the configured MCP instructions, or fallback
+func (s *Set) Instructions(fallback string) string {
+	if s == nil || s.instructions == "" {
+		return fallback
+	}
+	return s.instructions
+}
+
+func render(tmpl *template.Template, data Data) (string, error) {
+	var b strings.Builder
+	err := tmpl.Execute(&b, data)
+	return b.String(), err
+}
+
+type setKey struct{}
+
+// WithSet attaches set to ctx so provider clients render the configured prompts
+func WithSet(ctx context.Context, set *Set) context.Context {
+	if set == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, setKey{}, set)
+}
+
+// System renders the system prompt using the set attached to ctx, or the
+// built-in default, followed by the style attached with WithStyle
+func System(ctx context.Context, provider, language string) string {
+	set, _ := ctx.Value(setKey{}).(*Set)
+	prompt := set.System(provider, language)
+	if style, _ := ctx.Value(styleKey{}).(string); style != "" {
+		prompt = strings.TrimSpace(prompt) + "\n\n" + style
+	}
+	return prompt
+}
@@ -0,0 +1,77 @@
+package prompts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestDefaultSystemPrompt(t *testing.T) {
+	got := System(context.Background(), "cerebras", "go")
+	if !strings.HasPrefix(got, "You are an expert programmer. Generate ONLY clean, functional code in go ") {
+		t.Errorf("System() without a set = %q", got)
+	}
+}
+
+func TestSetPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"system.tmpl":          "File system prompt for {{.Language}}",
+		"system.mistral.tmpl":  "Mistral prompt for {{.Language}}",
+		"language.python.tmpl": "Follow PEP 8.",
+		"instructions.tmpl":    "Use the write tool.",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set, err := NewSet(config.TemplatesConfig{
+		Dir:       dir,
+		Providers: map[string]string{"Mistral": "Inline {{.Provider}} prompt for {{.Language}}"},
+		Languages: map[string]string{"go": "Run gofmt."},
+	})
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+
+	tests := []struct {
+		provider, language, want string
+	}{
+		{"cerebras", "rust", "File system prompt for rust"},
+		{"mistral", "rust", "Inline mistral prompt for rust"},
+		{"cerebras", "python", "File system prompt for python\n\nFollow PEP 8."},
+		{"cerebras", "go", "File system prompt for go\n\nRun gofmt."},
+	}
+	for _, tt := range tests {
+		ctx := WithSet(context.Background(), set)
+		if got := System(ctx, tt.provider, tt.language); got != tt.want {
+			t.Errorf("System(%s, %s) = %q, want %q", tt.provider, tt.language, got, tt.want)
+		}
+	}
+
+	if got := set.Instructions("default"); got != "Use the write tool." {
+		t.Errorf("Instructions() = %q", got)
+	}
+	var none *Set
+	if got := none.Instructions("default"); got != "default" {
+		t.Errorf("nil Set Instructions() = %q, want fallback", got)
+	}
+}
+
+func TestNewSetRejectsBadTemplates(t *testing.T) {
+	if set, err := NewSet(config.TemplatesConfig{}); set != nil || err != nil {
+		t.Errorf("NewSet() with no templates = %v, %v; want nil, nil", set, err)
+	}
+	if _, err := NewSet(config.TemplatesConfig{System: "{{.Language"}); err == nil {
+		t.Error("NewSet() accepted a template with a syntax error")
+	}
+	if _, err := NewSet(config.TemplatesConfig{System: "{{.FilePath}}"}); err == nil {
+		t.Error("NewSet() accepted a template referencing an unknown field")
+	}
+}
@@ -0,0 +1,402 @@
+package prompts
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Style is the code style a project's tooling expects of a file, read from
+// .editorconfig and the linter and formatter configs that apply to it
+type Style struct {
+	IndentStyle   string // "tab" or "space", "" if unset
+	IndentSize    int
+	MaxLineLength int
+	Quotes        string // "single" or "double"
+	Semicolons    *bool
+	Formatter     string // Stricter formatter the project requires, e.g. gofumpt
+	Sources       []string
+}
+
+// Files read for each kind of config, nearest first within a directory
+var (
+	prettierFiles = []string{".prettierrc", ".prettierrc.json", ".prettierrc.yaml", ".prettierrc.yml"}
+	eslintFiles   = []string{".eslintrc.json", ".eslintrc", ".eslintrc.yaml", ".eslintrc.yml"}
+	golangciFiles = []string{".golangci.yml", ".golangci.yaml"}
+)
+
+// webExtensions are the files prettier and eslint settings apply to
+var webExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true, ".ts": true, ".tsx": true,
+	".vue": true, ".svelte": true, ".css": true, ".scss": true, ".less": true, ".json": true,
+}
+
+// DetectStyle returns the style the project containing filePath expects of
+// it. .editorconfig sets the baseline; prettier, eslint and golangci-lint
+// settings, being what a failed lint run would complain about, take
+// precedence. Unreadable or malformed configs are skipped.
+func DetectStyle(filePath string) Style {
+	var style Style
+	if filePath == "" {
+		return style
+	}
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return style
+	}
+	style.readEditorConfig(abs)
+
+	switch ext := strings.ToLower(filepath.Ext(abs)); {
+	case webExtensions[ext]:
+		if path, settings := findConfig(abs, eslintFiles); path != "" {
+			style.applyESLint(settings)
+			style.Sources = append(style.Sources, filepath.Base(path))
+		}
+		if path, settings := findConfig(abs, prettierFiles); path != "" {
+			style.applyPrettier(settings)
+			style.Sources = append(style.Sources, filepath.Base(path))
+		}
+	case ext == ".go":
+		if path, settings := findConfig(abs, golangciFiles); path != "" {
+			style.applyGolangci(settings)
+			style.Sources = append(style.Sources, filepath.Base(path))
+		}
+	}
+	return style
+}
+
+// Summary renders the style as instructions for the system prompt, or ""
+// if nothing is known about it
+func (s Style) Summary() string {
+	var rules []string
+	switch {
+	case s.IndentStyle == "tab":
+		rules = append(rules, "Indent with tabs")
+	case s.IndentStyle == "space" && s.IndentSize > 0:
+		rules = append(rules, fmt.Sprintf("Indent with %d spaces", s.IndentSize))
+	case s.IndentStyle == "space":
+		rules = append(rules, "Indent with spaces")
+	case s.IndentSize > 0:
+		rules = append(rules, fmt.Sprintf("Indent by %d columns", s.IndentSize))
+	}
+	if s.Quotes != "" {
+		rules = append(rules, fmt.Sprintf("Use %s quotes for strings", s.Quotes))
+	}
+	if s.Semicolons != nil {
+		if *s.Semicolons {
+			rules = append(rules, "End statements with semicolons")
+		} else {
+			rules = append(rules, "Omit semicolons at the end of statements")
+		}
+	}
+	if s.MaxLineLength > 0 {
+		rules = append(rules, fmt.Sprintf("Keep lines at most %d characters long", s.MaxLineLength))
+	}
+	if s.Formatter != "" {
+		rules = append(rules, fmt.Sprintf("Format as %s would", s.Formatter))
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Follow the project's code style (from %s): %s.",
+		strings.Join(s.Sources, ", "), strings.Join(rules, "; "))
+}
+
+// readEditorConfig applies the .editorconfig sections matching path, from
+// the outermost file in effect to the nearest
+func (s *Style) readEditorConfig(path string) {
+	var files []string
+	for dir := filepath.Dir(path); ; {
+		file := filepath.Join(dir, ".editorconfig")
+		if root, err := editorConfigIsRoot(file); err == nil {
+			files = append(files, file)
+			if root {
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	properties := make(map[string]string)
+	for i := len(files) - 1; i >= 0; i-- {
+		readEditorConfigFile(files[i], path, properties)
+	}
+	s.IndentStyle = properties["indent_style"]
+	if properties["indent_size"] == "tab" {
+		properties["indent_size"] = properties["tab_width"]
+	}
+	s.IndentSize, _ = strconv.Atoi(properties["indent_size"])
+	s.MaxLineLength, _ = strconv.Atoi(properties["max_line_length"])
+	if quotes := properties["quote_type"]; quotes == "single" || quotes == "double" {
+		s.Quotes = quotes
+	}
+	if s.IndentStyle != "" || s.IndentSize > 0 || s.MaxLineLength > 0 || s.Quotes != "" {
+		s.Sources = append(s.Sources, ".editorconfig")
+	}
+}
+
+// editorConfigIsRoot reports whether the .editorconfig at file declares
+// root = true, so that files further up are ignored
+func editorConfigIsRoot(file string) (bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			break
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && strings.EqualFold(strings.TrimSpace(key), "root") {
+			return strings.EqualFold(strings.TrimSpace(value), "true"), nil
+		}
+	}
+	return false, nil
+}
+
+// readEditorConfigFile sets properties from the sections of file that match
+// path; later sections override earlier ones
+func readEditorConfigFile(file, path string, properties map[string]string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(filepath.Dir(file), path)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+
+	matching := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && strings.HasSuffix(line, "]"):
+			matching = editorConfigMatch(line[1:len(line)-1], rel)
+		case matching:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				properties[strings.ToLower(strings.TrimSpace(key))] = strings.ToLower(strings.TrimSpace(value))
+			}
+		}
+	}
+}
+
+// editorConfigMatch reports whether the section glob matches rel, a path
+// relative to the .editorconfig. Globs without a slash match in any
+// directory.
+func editorConfigMatch(glob, rel string) bool {
+	pattern, err := regexp.Compile(editorConfigRegexp(glob))
+	return err == nil && pattern.MatchString(rel)
+}
+
+// editorConfigRegexp translates an EditorConfig glob to a regular expression:
+// * and ? stop at slashes, ** doesn't, {a,b} is either alternative and
+// [...] is a character class
+func editorConfigRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(glob, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	glob = strings.TrimPrefix(glob, "/")
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '{':
+			braces++
+			b.WriteString("(?:")
+		case '}':
+			braces--
+			b.WriteString(")")
+		case ',':
+			if braces > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteByte(c)
+			}
+		case '[', ']':
+			b.WriteByte(c)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// findConfig returns the path and parsed settings of the nearest of names
+// in path's directory or one above, or "" if there is none. JSON configs
+// are parsed as YAML, which they are a subset of.
+func findConfig(path string, names []string) (string, map[string]interface{}) {
+	for dir := filepath.Dir(path); ; {
+		for _, name := range names {
+			file := filepath.Join(dir, name)
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			var settings map[string]interface{}
+			if err := yaml.Unmarshal(data, &settings); err != nil || settings == nil {
+				return "", nil
+			}
+			return file, settings
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// applyPrettier applies the settings of a prettier config
+func (s *Style) applyPrettier(settings map[string]interface{}) {
+	if useTabs, ok := settings["useTabs"].(bool); ok {
+		s.IndentStyle = map[bool]string{true: "tab", false: "space"}[useTabs]
+	} else if s.IndentStyle == "" {
+		s.IndentStyle = "space" // prettier's default
+	}
+	if width, ok := settings["tabWidth"].(int); ok {
+		s.IndentSize = width
+	}
+	if width, ok := settings["printWidth"].(int); ok {
+		s.MaxLineLength = width
+	}
+	if single, ok := settings["singleQuote"].(bool); ok {
+		s.Quotes = map[bool]string{true: "single", false: "double"}[single]
+	}
+	if semi, ok := settings["semi"].(bool); ok {
+		s.Semicolons = &semi
+	}
+}
+
+// applyESLint applies the stylistic rules of an eslint config. A rule is a
+// severity, or a list of a severity and its options.
+func (s *Style) applyESLint(settings map[string]interface{}) {
+	rules, _ := settings["rules"].(map[string]interface{})
+	for name, rule := range rules {
+		options, ok := rule.([]interface{})
+		if !ok || len(options) < 2 || eslintRuleOff(options[0]) {
+			continue
+		}
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "@stylistic/"), "@typescript-eslint/")
+		switch name {
+		case "indent":
+			switch value := options[1].(type) {
+			case string:
+				if value == "tab" {
+					s.IndentStyle = "tab"
+				}
+			case int:
+				s.IndentStyle, s.IndentSize = "space", value
+			}
+		case "quotes":
+			if quotes, ok := options[1].(string); ok && (quotes == "single" || quotes == "double") {
+				s.Quotes = quotes
+			}
+		case "semi":
+			if value, ok := options[1].(string); ok {
+				semi := value == "always"
+				s.Semicolons = &semi
+			}
+		case "max-len":
+			switch value := options[1].(type) {
+			case int:
+				s.MaxLineLength = value
+			case map[string]interface{}:
+				if code, ok := value["code"].(int); ok {
+					s.MaxLineLength = code
+				}
+			}
+		}
+	}
+}
+
+// eslintRuleOff reports whether an eslint severity turns a rule off
+func eslintRuleOff(severity interface{}) bool {
+	return severity == "off" || severity == 0
+}
+
+// applyGolangci applies the settings of a golangci-lint config, in either
+// the v1 layout or the v2 one with formatters in their own section
+func (s *Style) applyGolangci(settings map[string]interface{}) {
+	linters, _ := settings["linters"].(map[string]interface{})
+	formatters, _ := settings["formatters"].(map[string]interface{})
+	enabled := make(map[string]bool)
+	for _, section := range []map[string]interface{}{linters, formatters} {
+		list, _ := section["enable"].([]interface{})
+		for _, name := range list {
+			if name, ok := name.(string); ok {
+				enabled[name] = true
+			}
+		}
+	}
+
+	if enabled["lll"] {
+		s.MaxLineLength = 120 // lll's default
+		lllSettings := nestedMap(settings, "linters-settings", "lll")
+		if lllSettings == nil {
+			lllSettings = nestedMap(linters, "settings", "lll")
+		}
+		if length, ok := lllSettings["line-length"].(int); ok {
+			s.MaxLineLength = length
+		}
+	}
+	for _, formatter := range []string{"gofumpt", "goimports"} {
+		if enabled[formatter] {
+			s.Formatter = formatter
+			break
+		}
+	}
+}
+
+// nestedMap follows keys through nested YAML mappings, returning nil if any
+// is missing
+func nestedMap(m map[string]interface{}, keys ...string) map[string]interface{} {
+	for _, key := range keys {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		m = next
+	}
+	return m
+}
+
+type styleKey struct{}
+
+// WithStyle attaches the style of the file a request writes to ctx, so
+// provider clients add it to the system prompt
+func WithStyle(ctx context.Context, style Style) context.Context {
+	summary := style.Summary()
+	if summary == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, styleKey{}, summary)
+}
@@ -0,0 +1,69 @@
+package prompts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectStyle(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".editorconfig":      "root = true\n\n[*]\nindent_style = space\nindent_size = 4\n\n[*.{go,mod}]\nindent_style = tab\n\n[web/**.ts]\nindent_size = 2\nmax_line_length = 80\n",
+		"web/.eslintrc.json": `{"rules": {"quotes": ["error", "single"], "semi": ["error", "never"], "max-len": ["warn", {"code": 100}], "indent": ["off", 8]}}`,
+		"web/.prettierrc":    "printWidth: 110\n",
+		".golangci.yml":      "linters:\n  enable: [lll, gofumpt]\nlinters-settings:\n  lll:\n    line-length: 140\n",
+	})
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"main.go", "Follow the project's code style (from .editorconfig, .golangci.yml): Indent with tabs; Keep lines at most 140 characters long; Format as gofumpt would."},
+		{"web/src/app.ts", "Follow the project's code style (from .editorconfig, .eslintrc.json, .prettierrc): Indent with 2 spaces; Use single quotes for strings; Omit semicolons at the end of statements; Keep lines at most 110 characters long."},
+		{"scripts/run.py", "Follow the project's code style (from .editorconfig): Indent with 4 spaces."},
+	}
+	for _, tt := range tests {
+		if got := DetectStyle(filepath.Join(root, tt.file)).Summary(); got != tt.want {
+			t.Errorf("DetectStyle(%s).Summary() =\n%q\nwant\n%q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestDetectStyleNestedEditorConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".editorconfig":     "root = true\n[*]\nindent_style = space\nindent_size = 4\nmax_line_length = 100\n",
+		"sub/.editorconfig": "[*.py]\nindent_size = 2\n",
+	})
+	style := DetectStyle(filepath.Join(root, "sub", "x.py"))
+	if style.IndentStyle != "space" || style.IndentSize != 2 || style.MaxLineLength != 100 {
+		t.Errorf("DetectStyle() = %+v, want the nearer indent_size over the root's settings", style)
+	}
+	if got := DetectStyle(filepath.Join(t.TempDir(), "x.py")).Summary(); got != "" {
+		t.Errorf("Summary() without configs = %q, want empty", got)
+	}
+}
+
+func TestSystemWithStyle(t *testing.T) {
+	ctx := WithStyle(context.Background(), Style{IndentStyle: "tab", Sources: []string{".editorconfig"}})
+	got := System(ctx, "cerebras", "go")
+	if !strings.HasSuffix(got, "\n\nFollow the project's code style (from .editorconfig): Indent with tabs.") {
+		t.Errorf("System() = %q, want the style appended", got)
+	}
+}
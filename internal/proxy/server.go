@@ -0,0 +1,280 @@
+package proxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// Server exposes the router through an OpenAI-compatible HTTP API so that
+// non-MCP tools can share the same provider configuration and fallback logic
+type Server struct {
+	router *router.EnhancedRouter
+	config config.ProxyConfig
+	server *http.Server
+}
+
+// chatMessage is an OpenAI chat message. Content may be a string or an array of parts.
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// chatCompletionRequest is the subset of the OpenAI request the proxy understands
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+// NewServer creates a proxy server for r
+func NewServer(r *router.EnhancedRouter, cfg config.ProxyConfig) *Server {
+	return &Server{router: r, config: cfg}
+}
+
+// Handler returns the proxy's HTTP handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", s.withAuth(s.handleChatCompletions))
+	mux.HandleFunc("/v1/models", s.withAuth(s.handleModels))
+	return mux
+}
+
+// Start binds the configured address, so an address in use fails startup,
+// and serves in the background
+func (s *Server) Start() error {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.server = &http.Server{
+		Addr:    addr,
+		Handler: s.Handler(),
+	}
+
+	logger.Infof("Starting OpenAI-compatible proxy on %s", addr)
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Proxy server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop shuts the proxy down
+func (s *Server) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	logger.Infof("Stopping proxy server...")
+	return s.server.Shutdown(ctx)
+}
+
+// withAuth enforces the optional bearer token
+func (s *Server) withAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.APIKey != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.APIKey)) != 1 {
+				writeError(w, http.StatusUnauthorized, "invalid_api_key", "Invalid API key")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "Method not allowed")
+		return
+	}
+
+	var req chatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+	prompt := flattenMessages(req.Messages)
+	if prompt == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "messages must contain text content")
+		return
+	}
+
+	requestedProvider, requestedModel := selection(req.Model)
+	if err := s.router.CheckSelection(requestedProvider, requestedModel); err != nil {
+		writeError(w, http.StatusNotFound, "invalid_request_error", err.Error())
+		return
+	}
+
+	ctx, info := router.WithGenerationInfo(r.Context())
+	result, err := s.router.GenerateCodeWithValidation(ctx, prompt, "", nil, requestedProvider, requestedModel, false, nil)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "provider_error", err.Error())
+		return
+	}
+
+	model := info.Model
+	if model == "" {
+		model = info.Provider
+	}
+	id := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+
+	if req.Stream {
+		s.writeStream(w, id, created, model, result)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": created,
+		"model":   model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": result},
+			"finish_reason": "stop",
+		}},
+	})
+}
+
+// selection maps a request's model to the provider and model to generate
+// with: a provider /v1/models lists, or provider/model. An empty model or
+// "auto" leaves the choice to the router.
+func selection(model string) (requestedProvider, requestedModel string) {
+	model = strings.TrimSpace(model)
+	switch {
+	case model == "" || model == "auto":
+		return "", ""
+	case strings.ContainsAny(model, "/:"):
+		return "", model
+	default:
+		return model, ""
+	}
+}
+
+// writeStream sends the completed result as server-sent events. The router does
+// not stream, so the whole response arrives in a single content chunk.
+func (s *Server) writeStream(w http.ResponseWriter, id string, created int64, model, content string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	chunk := func(delta map[string]string, finish interface{}) {
+		data, _ := json.Marshal(map[string]interface{}{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   model,
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"delta":         delta,
+				"finish_reason": finish,
+			}},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+
+	chunk(map[string]string{"role": "assistant", "content": content}, nil)
+	chunk(map[string]string{}, "stop")
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// handleModels lists the enabled providers as models
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "Method not allowed")
+		return
+	}
+
+	var names []string
+	for name := range s.router.GetHealthStatus() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	models := []map[string]interface{}{}
+	for _, name := range names {
+		models = append(models, map[string]interface{}{
+			"id":       name,
+			"object":   "model",
+			"owned_by": "mcp-code-api",
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"object": "list",
+		"data":   models,
+	})
+}
+
+// flattenMessages turns a chat transcript into a single prompt. A lone user
+// message is passed through unchanged; otherwise each turn is labelled.
+func flattenMessages(messages []chatMessage) string {
+	var parts []string
+	for _, m := range messages {
+		text := strings.TrimSpace(messageText(m.Content))
+		if text == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", m.Role, text))
+	}
+	if len(messages) == 1 && messages[0].Role == "user" {
+		return strings.TrimSpace(messageText(messages[0].Content))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// messageText extracts text from string or multi-part message content
+func messageText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// writeError writes an OpenAI-style error body
+func writeError(w http.ResponseWriter, status int, errType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{
+			"message": message,
+			"type":    errType,
+		},
+	})
+}
@@ -0,0 +1,223 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/api/router"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// newTestProxy returns a proxy over a router whose only provider answers
+// every request with code
+func newTestProxy(t *testing.T, code string, cfg config.ProxyConfig) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := json.Marshal(code)
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%s}}]}`, data)
+	}))
+	t.Cleanup(upstream.Close)
+
+	routerCfg := &config.Config{}
+	routerCfg.Providers.Enabled = []string{"stub"}
+	routerCfg.Providers.Order = []string{"stub"}
+	routerCfg.Providers.Custom = map[string]config.CustomProviderConfig{"stub": {BaseURL: upstream.URL, APIKey: "key", Model: "coder"}}
+	srv := httptest.NewServer(NewServer(router.NewEnhancedRouter(routerCfg, nil), cfg).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postChat(t *testing.T, url string, stream bool) *http.Response {
+	t.Helper()
+	return postChatModel(t, url, "auto", stream)
+}
+
+func postChatModel(t *testing.T, url, model string, stream bool) *http.Response {
+	t.Helper()
+	body := fmt.Sprintf(`{"model":%q,"stream":%t,"messages":[{"role":"user","content":"print one"}]}`, model, stream)
+	resp, err := http.Post(url+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletions(t *testing.T) {
+	srv := newTestProxy(t, "print(1)", config.ProxyConfig{})
+
+	resp := postChat(t, srv.URL, false)
+	var completion struct {
+		Object  string `json:"object"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message      chatMessage `json:"message"`
+			FinishReason string      `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || completion.Object != "chat.completion" || completion.Model != "coder" {
+		t.Fatalf("response = %d %+v, want a chat.completion from coder", resp.StatusCode, completion)
+	}
+	if len(completion.Choices) != 1 || messageText(completion.Choices[0].Message.Content) != "print(1)" || completion.Choices[0].FinishReason != "stop" {
+		t.Errorf("choices = %+v, want the generated code", completion.Choices)
+	}
+}
+
+func TestChatCompletionsRoutesModel(t *testing.T) {
+	// Two providers answering with their name and the model they were asked for
+	upstream := func(name string) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Model string `json:"model"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":"%s %s"}}]}`, name, req.Model)
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	routerCfg := &config.Config{}
+	routerCfg.Providers.Enabled = []string{"first", "second"}
+	routerCfg.Providers.Order = []string{"first", "second"}
+	routerCfg.Providers.Custom = map[string]config.CustomProviderConfig{
+		"first":  {BaseURL: upstream("first"), APIKey: "key", Model: "small"},
+		"second": {BaseURL: upstream("second"), APIKey: "key", Model: "small"},
+	}
+	srv := httptest.NewServer(NewServer(router.NewEnhancedRouter(routerCfg, nil), config.ProxyConfig{}).Handler())
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		model, want string
+	}{
+		{"", "first small"},
+		{"auto", "first small"},
+		{"second", "second small"},
+		{"second/large", "second large"},
+	} {
+		resp := postChatModel(t, srv.URL, tt.model, false)
+		var completion struct {
+			Choices []struct {
+				Message chatMessage `json:"message"`
+			} `json:"choices"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || len(completion.Choices) != 1 || messageText(completion.Choices[0].Message.Content) != tt.want {
+			t.Errorf("model %q = %d %+v, want %q", tt.model, resp.StatusCode, completion, tt.want)
+		}
+	}
+
+	// A model the router can't route to is refused rather than ignored
+	if resp := postChatModel(t, srv.URL, "gpt-4o", false); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown model status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestChatCompletionsStream(t *testing.T) {
+	srv := newTestProxy(t, "print(1)", config.ProxyConfig{})
+
+	resp := postChat(t, srv.URL, true)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := strings.Split(strings.TrimSpace(string(data)), "\n\n")
+	if len(events) != 3 || events[2] != "data: [DONE]" {
+		t.Fatalf("events = %q, want a content chunk, a stop chunk and [DONE]", events)
+	}
+	var chunks [2]struct {
+		Object  string `json:"object"`
+		Choices []struct {
+			Delta        map[string]string `json:"delta"`
+			FinishReason *string           `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	for i := range chunks {
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(events[i], "data: ")), &chunks[i]); err != nil {
+			t.Fatalf("event %d = %q: %v", i, events[i], err)
+		}
+		if chunks[i].Object != "chat.completion.chunk" || len(chunks[i].Choices) != 1 {
+			t.Fatalf("event %d = %q, want a chat.completion.chunk", i, events[i])
+		}
+	}
+	if first := chunks[0].Choices[0]; first.Delta["content"] != "print(1)" || first.FinishReason != nil {
+		t.Errorf("first chunk = %+v, want the content without a finish reason", first)
+	}
+	if last := chunks[1].Choices[0]; last.FinishReason == nil || *last.FinishReason != "stop" {
+		t.Errorf("last chunk = %+v, want finish_reason stop", last)
+	}
+}
+
+func TestModels(t *testing.T) {
+	srv := newTestProxy(t, "print(1)", config.ProxyConfig{})
+
+	// Providers are listed once the router has checked or used them
+	postChat(t, srv.URL, false)
+	resp, err := http.Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var list struct {
+		Object string `json:"object"`
+		Data   []struct {
+			ID     string `json:"id"`
+			Object string `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Object != "list" || len(list.Data) != 1 || list.Data[0].ID != "stub" || list.Data[0].Object != "model" {
+		t.Errorf("models = %+v, want the stub provider", list)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	srv := newTestProxy(t, "print(1)", config.ProxyConfig{APIKey: "secret"})
+
+	if resp := postChat(t, srv.URL, false); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status without a key = %d, want 401", resp.StatusCode)
+	}
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/models", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status with the key = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestStartReportsBindErrors(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	port := taken.Addr().(*net.TCPAddr).Port
+
+	s := NewServer(nil, config.ProxyConfig{Host: "127.0.0.1", Port: port})
+	if err := s.Start(); err == nil {
+		s.Stop()
+		t.Fatal("Start() succeeded on a port already in use")
+	}
+}
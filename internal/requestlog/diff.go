@@ -0,0 +1,289 @@
+package requestlog
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around changes
+	diffContext = 3
+
+	// maxDiffCells bounds the line-matching table; larger files are shown as
+	// a whole-file replacement rather than stalling the write
+	maxDiffCells = 1 << 20
+
+	// maxDiffBytes bounds how much of a diff is kept per entry
+	maxDiffBytes = 64 << 10
+
+	// sideBySideColumn is the width of the old column of a side-by-side diff
+	sideBySideColumn = 60
+)
+
+// diffOp is one line of an edit script
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns a unified diff turning before into after, or "" if
+// they are identical
+func UnifiedDiff(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	name := strings.TrimPrefix(filepath.ToSlash(path), "/")
+	oldName, newName := "a/"+name, "b/"+name
+	if before == "" {
+		oldName = "/dev/null"
+	}
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, h := range hunks(ops) {
+		oldStart, newStart, oldCount, newCount := h.lines(ops)
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[h.start:h.end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			b.WriteByte('\n')
+		}
+	}
+
+	return truncateDiff(b.String())
+}
+
+// SideBySideDiff returns the hunks of a diff turning before into after with
+// the old and new lines in two columns, as diff -y marks them: "|" for a
+// changed line, "<" for a removed one and ">" for an added one. It returns
+// "" if before and after are identical.
+func SideBySideDiff(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", strings.TrimPrefix(filepath.ToSlash(path), "/"))
+	for _, h := range hunks(ops) {
+		oldStart, newStart, oldCount, newCount := h.lines(ops)
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for i := h.start; i < h.end; {
+			if ops[i].kind == ' ' {
+				writeColumns(&b, ops[i].line, ' ', ops[i].line)
+				i++
+				continue
+			}
+			// Pair the removed lines of a change with the lines added for them
+			var removed, added []string
+			for ; i < h.end && ops[i].kind == '-'; i++ {
+				removed = append(removed, ops[i].line)
+			}
+			for ; i < h.end && ops[i].kind == '+'; i++ {
+				added = append(added, ops[i].line)
+			}
+			for j := 0; j < max(len(removed), len(added)); j++ {
+				switch {
+				case j >= len(added):
+					writeColumns(&b, removed[j], '<', "")
+				case j >= len(removed):
+					writeColumns(&b, "", '>', added[j])
+				default:
+					writeColumns(&b, removed[j], '|', added[j])
+				}
+			}
+		}
+	}
+	return truncateDiff(b.String())
+}
+
+// writeColumns writes one side-by-side line, fitting the old line into
+// sideBySideColumn characters
+func writeColumns(b *strings.Builder, oldLine string, mark byte, newLine string) {
+	oldLine = strings.ReplaceAll(oldLine, "\t", "    ")
+	if runes := []rune(oldLine); len(runes) > sideBySideColumn {
+		oldLine = string(runes[:sideBySideColumn-1]) + "…"
+	}
+	line := fmt.Sprintf("%-*s %c %s", sideBySideColumn, oldLine, mark, strings.ReplaceAll(newLine, "\t", "    "))
+	b.WriteString(strings.TrimRight(line, " "))
+	b.WriteByte('\n')
+}
+
+// DiffStats returns the number of lines added and removed turning before
+// into after
+func DiffStats(before, after string) (added, removed int) {
+	for _, op := range diffLines(splitLines(before), splitLines(after)) {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// hunk is a range of an edit script shown together: changes with up to
+// diffContext unchanged lines around them
+type hunk struct {
+	start, end int
+}
+
+// hunks groups the changes of ops into hunks, merging changes separated by
+// too few unchanged lines to be worth splitting
+func hunks(ops []diffOp) []hunk {
+	var out []hunk
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		hunkStart := max(start-diffContext, 0)
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// Close the hunk after a run of unchanged lines too long to bridge
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = run
+		}
+		out = append(out, hunk{start: hunkStart, end: end})
+		start = end
+	}
+	return out
+}
+
+// lines returns the old and new start lines and line counts of the hunk
+// for its header
+func (h hunk) lines(ops []diffOp) (oldStart, newStart, oldCount, newCount int) {
+	oldStart, newStart = 1, 1
+	for _, op := range ops[:h.start] {
+		if op.kind != '+' {
+			oldStart++
+		}
+		if op.kind != '-' {
+			newStart++
+		}
+	}
+	for _, op := range ops[h.start:h.end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+	return oldStart, newStart, oldCount, newCount
+}
+
+// truncateDiff cuts a diff longer than maxDiffBytes at a line boundary
+func truncateDiff(diff string) string {
+	if len(diff) > maxDiffBytes {
+		cut := strings.LastIndexByte(diff[:maxDiffBytes], '\n') + 1
+		diff = diff[:cut] + fmt.Sprintf("... diff truncated (%d bytes total)\n", len(diff))
+	}
+	return diff
+}
+
+// splitLines splits content into lines without the trailing empty line
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines computes a minimal edit script using the longest common
+// subsequence of lines
+func diffLines(a, b []string) []diffOp {
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(midA)+1)*(len(midB)+1) > maxDiffCells {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		ops = append(ops, lcsDiff(midA, midB)...)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// lcsDiff diffs a and b with a dynamic programming LCS table
+func lcsDiff(a, b []string) []diffOp {
+	width := len(b) + 1
+	lcs := make([]int, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,99 @@
+// Package requestlog keeps the most recent write tool calls in memory so the
+// metrics dashboard can show what was changed, by which provider and at what
+// cost.
+package requestlog
+
+import (
+	"sync"
+	"time"
+)
+
+// Validation outcomes
+const (
+	ValidationSkipped  = "skipped"  // Validation was not requested
+	ValidationPassed   = "passed"   // Validated without warnings
+	ValidationWarnings = "warnings" // Written, but validation reported problems
+	ValidationFailed   = "failed"   // The call failed; see Error
+)
+
+// Entry is one write tool call
+type Entry struct {
+	ID               int64     `json:"id"`
+	Time             time.Time `json:"time"`
+	FilePath         string    `json:"file_path"`
+	Operation        string    `json:"operation"` // create, update, restore or review
+	Provider         string    `json:"provider,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	Cached           bool      `json:"cached,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	CachedTokens     int       `json:"cached_tokens,omitempty"` // Prompt tokens read from the provider's prompt cache
+	LatencyMs        int64     `json:"latency_ms"`
+	Validation       string    `json:"validation"`
+	Warnings         []string  `json:"warnings,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	Diff             string    `json:"diff,omitempty"` // Unified diff of the file
+}
+
+// Log is a fixed-size ring of recent entries, safe for concurrent use
+type Log struct {
+	mutex   sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	lastID  int64
+}
+
+// New creates a log keeping the last size entries.
+// Returns nil if size is not positive.
+func New(size int) *Log {
+	if size <= 0 {
+		return nil
+	}
+	return &Log{entries: make([]Entry, size)}
+}
+
+// Add appends an entry, evicting the oldest once the log is full. Time and
+// ID are assigned here.
+func (l *Log) Add(entry Entry) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.lastID++
+	entry.ID = l.lastID
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Recent returns up to limit entries, newest first. A limit of 0 returns all.
+func (l *Log) Recent(limit int) []Entry {
+	if l == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+	if limit > 0 && limit < count {
+		count = limit
+	}
+
+	recent := make([]Entry, 0, count)
+	for i := 1; i <= count; i++ {
+		recent = append(recent, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return recent
+}
@@ -0,0 +1,93 @@
+package requestlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogRecentNewestFirst(t *testing.T) {
+	log := New(2)
+	for _, path := range []string{"a.go", "b.go", "c.go"} {
+		log.Add(Entry{FilePath: path})
+	}
+
+	recent := log.Recent(0)
+	if len(recent) != 2 || recent[0].FilePath != "c.go" || recent[1].FilePath != "b.go" {
+		t.Fatalf("Recent(0) = %+v, want c.go then b.go", recent)
+	}
+	if recent[0].ID != 3 {
+		t.Errorf("ID = %d, want 3", recent[0].ID)
+	}
+	if got := log.Recent(1); len(got) != 1 || got[0].FilePath != "c.go" {
+		t.Errorf("Recent(1) = %+v, want c.go", got)
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var log *Log
+	log.Add(Entry{FilePath: "a.go"})
+	if recent := log.Recent(0); recent != nil {
+		t.Errorf("Recent() on nil log = %v", recent)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "package main\n\nfunc a() {}\n\nfunc b() {}\n"
+	after := "package main\n\nfunc a() {}\n\nfunc b() int { return 1 }\n"
+
+	want := strings.Join([]string{
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -2,4 +2,4 @@",
+		" ",
+		" func a() {}",
+		" ",
+		"-func b() {}",
+		"+func b() int { return 1 }",
+		"",
+	}, "\n")
+	if got := UnifiedDiff("main.go", before, after); got != want {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffNewFile(t *testing.T) {
+	got := UnifiedDiff("main.go", "", "package main\n")
+	want := "--- /dev/null\n+++ b/main.go\n@@ -0,0 +1,1 @@\n+package main\n"
+	if got != want {
+		t.Errorf("UnifiedDiff() = %q, want %q", got, want)
+	}
+	if UnifiedDiff("main.go", "same\n", "same\n") != "" {
+		t.Error("UnifiedDiff() of identical content is not empty")
+	}
+}
+
+func TestSideBySideDiff(t *testing.T) {
+	before := "package main\n\nfunc a() {}\n"
+	after := "package main\n\nfunc a() int { return 1 }\nfunc b() {}\n"
+
+	got := SideBySideDiff("main.go", before, after)
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 6 || lines[0] != "main.go" || lines[1] != "@@ -1,3 +1,4 @@" {
+		t.Fatalf("SideBySideDiff() =\n%s", got)
+	}
+	column := strings.Repeat(" ", sideBySideColumn)
+	want := []string{
+		"package main" + column[len("package main"):] + "   package main",
+		"",
+		"func a() {}" + column[len("func a() {}"):] + " | func a() int { return 1 }",
+		column + " > func b() {}",
+	}
+	for i, line := range want {
+		if lines[i+2] != line {
+			t.Errorf("line %d = %q, want %q", i+3, lines[i+2], line)
+		}
+	}
+}
+
+func TestDiffStats(t *testing.T) {
+	added, removed := DiffStats("a\nb\nc\n", "a\nc\nd\ne\n")
+	if added != 2 || removed != 1 {
+		t.Errorf("DiffStats() = +%d -%d, want +2 -1", added, removed)
+	}
+}
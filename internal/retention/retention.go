@@ -0,0 +1,241 @@
+// Package retention removes old audit logs, benchmark history, metrics files
+// and backups and rotates the debug log, so that long-running installs stay
+// within the limits set in the retention configuration.
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/audit"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// Result is what cleaning one target removed
+type Result struct {
+	Target  string `json:"target"`
+	Path    string `json:"path"`
+	Removed int    `json:"removed"` // Files or entries
+	Freed   int64  `json:"freed"`   // Bytes
+}
+
+// Store is data kept in memory that the janitor prunes, such as the write
+// tool's backups
+type Store interface {
+	Prune(policy config.RetentionPolicy, now time.Time, dryRun bool) Result
+}
+
+// Janitor applies the retention policies to the audit log, the benchmark
+// history, the metrics files and the backups, and rotates the server's
+// debug log
+type Janitor struct {
+	// Backups, if set, are pruned with the backups policy; only the server
+	// holds them
+	Backups Store
+
+	cfg          config.RetentionConfig
+	auditDir     string
+	benchHistory string
+	metricsDir   string
+}
+
+// NewJanitor creates a janitor for cfg's audit log, the benchmark history at
+// benchHistory and the metrics files in metricsDir
+func NewJanitor(cfg *config.Config, benchHistory, metricsDir string) *Janitor {
+	return &Janitor{
+		cfg:          cfg.Retention,
+		auditDir:     audit.Dir(cfg.Audit),
+		benchHistory: benchHistory,
+		metricsDir:   metricsDir,
+	}
+}
+
+// Clean removes what the policies no longer keep, or with dryRun only
+// reports it. Every target is cleaned even if another fails; the first
+// error is returned.
+func (j *Janitor) Clean(now time.Time, dryRun bool) ([]Result, error) {
+	var firstErr error
+	keep := func(result Result, err error) Result {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return result
+	}
+
+	results := []Result{
+		keep(PruneDir(j.auditDir, "audit-*.jsonl", j.cfg.Audit, now, dryRun)),
+		keep(TrimJSONL(j.benchHistory, j.cfg.BenchHistory, now, dryRun)),
+		keep(PruneDir(j.metricsDir, "*.json", j.cfg.Metrics, now, dryRun)),
+	}
+	results[0].Target = "audit"
+	results[1].Target = "bench history"
+	results[2].Target = "metrics"
+	if j.Backups != nil {
+		backups := j.Backups.Prune(j.cfg.Backups, now, dryRun)
+		backups.Target = "backups"
+		results = append(results, backups)
+	}
+	return results, firstErr
+}
+
+// Run cleans up, and rotates the debug log, now and every interval until ctx
+// is done
+func (j *Janitor) Run(ctx context.Context) {
+	interval := j.cfg.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		j.runOnce()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *Janitor) runOnce() {
+	if rotated, err := logger.RotateLogFile(j.cfg.LogMaxSize); err != nil {
+		logger.Warnf("Retention: %v", err)
+	} else if rotated {
+		logger.Info("Retention: rotated the debug log")
+	}
+
+	results, err := j.Clean(time.Now(), false)
+	if err != nil {
+		logger.Warnf("Retention: %v", err)
+	}
+	for _, result := range results {
+		if result.Removed > 0 {
+			logger.Infof("Retention: removed %d from %s (%d bytes)", result.Removed, result.Target, result.Freed)
+		}
+	}
+}
+
+// PruneDir removes the files in dir matching pattern that were last written
+// before policy.MaxAge, then the oldest files until they total at most
+// policy.MaxSize. The newest file, which may still be appended to, is never
+// removed for size.
+func PruneDir(dir, pattern string, policy config.RetentionPolicy, now time.Time, dryRun bool) (Result, error) {
+	result := Result{Path: dir}
+	if policy.MaxAge <= 0 && policy.MaxSize <= 0 {
+		return result, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return result, err
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	var total int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(a, b int) bool { return files[a].modTime.Before(files[b].modTime) })
+
+	remove := func(f file) error {
+		if !dryRun {
+			if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		result.Removed++
+		result.Freed += f.size
+		total -= f.size
+		return nil
+	}
+	for i, f := range files {
+		expired := policy.MaxAge > 0 && now.Sub(f.modTime) > policy.MaxAge
+		tooLarge := policy.MaxSize > 0 && total > policy.MaxSize && i < len(files)-1
+		if !expired && !tooLarge {
+			break
+		}
+		if err := remove(f); err != nil {
+			return result, fmt.Errorf("failed to remove %s: %w", f.path, err)
+		}
+	}
+	return result, nil
+}
+
+// TrimJSONL removes the lines of the JSONL file at path whose "time" field
+// is older than policy.MaxAge, then the oldest lines until the file is at
+// most policy.MaxSize. Lines without a time are kept. The file is replaced
+// in one rename, so readers see either version.
+func TrimJSONL(path string, policy config.RetentionPolicy, now time.Time, dryRun bool) (Result, error) {
+	result := Result{Path: path}
+	if policy.MaxAge <= 0 && policy.MaxSize <= 0 {
+		return result, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, err
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		lines = append(lines, append(scanner.Bytes(), '\n'))
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var kept [][]byte
+	var size int64
+	for _, line := range lines {
+		var entry struct {
+			Time time.Time `json:"time"`
+		}
+		if policy.MaxAge > 0 && json.Unmarshal(line, &entry) == nil && !entry.Time.IsZero() && now.Sub(entry.Time) > policy.MaxAge {
+			continue
+		}
+		kept = append(kept, line)
+		size += int64(len(line))
+	}
+	for policy.MaxSize > 0 && size > policy.MaxSize && len(kept) > 0 {
+		size -= int64(len(kept[0]))
+		kept = kept[1:]
+	}
+
+	result.Removed = len(lines) - len(kept)
+	result.Freed = int64(len(data)) - size
+	if result.Removed == 0 || dryRun {
+		return result, nil
+	}
+
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, bytes.Join(kept, nil), 0644); err != nil {
+		return result, err
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package retention
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestPruneDir(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	write := func(name string, size int, age time.Duration) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("audit-2026-01-01.jsonl", 100, 40*24*time.Hour)
+	write("audit-2026-02-01.jsonl", 100, 10*24*time.Hour)
+	write("audit-2026-02-10.jsonl", 100, 24*time.Hour)
+	write("audit-2026-02-11.jsonl", 500, 0)
+	write("notes.txt", 100, 90*24*time.Hour)
+
+	policy := config.RetentionPolicy{MaxAge: 30 * 24 * time.Hour, MaxSize: 650}
+	result, err := PruneDir(dir, "audit-*.jsonl", policy, now, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 2 || result.Freed != 200 {
+		t.Errorf("dry run = %+v, want 2 files, 200 bytes", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "audit-2026-01-01.jsonl")); err != nil {
+		t.Error("dry run removed a file")
+	}
+
+	if _, err := PruneDir(dir, "audit-*.jsonl", policy, now, false); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	if got := strings.Join(left, " "); got != "audit-2026-02-10.jsonl audit-2026-02-11.jsonl notes.txt" {
+		t.Errorf("left %s", got)
+	}
+
+	// The newest file stays even when it alone is over the limit
+	if result, _ := PruneDir(dir, "audit-*.jsonl", config.RetentionPolicy{MaxSize: 1}, now, false); result.Removed != 1 {
+		t.Errorf("removed %d files, want 1", result.Removed)
+	}
+}
+
+func TestTrimJSONL(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	var lines []string
+	for _, age := range []time.Duration{100, 50, 20, 10, 0} {
+		line, _ := json.Marshal(map[string]interface{}{"time": now.Add(-age * 24 * time.Hour), "suite": "default"})
+		lines = append(lines, string(line))
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := TrimJSONL(path, config.RetentionPolicy{MaxAge: 60 * 24 * time.Hour}, now, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 1 {
+		t.Errorf("removed %d entries, want 1", result.Removed)
+	}
+
+	lineSize := int64(len(lines[4]) + 1)
+	if result, err = TrimJSONL(path, config.RetentionPolicy{MaxSize: 2 * lineSize}, now, false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if result.Removed != 2 || string(data) != lines[3]+"\n"+lines[4]+"\n" {
+		t.Errorf("removed %d, left %q", result.Removed, data)
+	}
+
+	if result, err := TrimJSONL(filepath.Join(t.TempDir(), "missing.jsonl"), config.RetentionPolicy{MaxSize: 1}, now, false); err != nil || result.Removed != 0 {
+		t.Errorf("missing file: %+v, %v", result, err)
+	}
+}
+
+// prunedStore records the policy it was pruned with
+type prunedStore struct{ policy config.RetentionPolicy }
+
+func (s *prunedStore) Prune(policy config.RetentionPolicy, now time.Time, dryRun bool) Result {
+	s.policy = policy
+	return Result{Path: "(memory)", Removed: 1}
+}
+
+func TestJanitorCleansMetricsAndBackups(t *testing.T) {
+	now := time.Now()
+	metricsDir := t.TempDir()
+	for name, age := range map[string]time.Duration{"old.json": 48 * time.Hour, "live.json": 0} {
+		path := filepath.Join(metricsDir, name)
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.Audit.Dir = t.TempDir()
+	cfg.Retention.Metrics = config.RetentionPolicy{MaxAge: 24 * time.Hour}
+	cfg.Retention.Backups = config.RetentionPolicy{MaxSize: 1024}
+	janitor := NewJanitor(cfg, filepath.Join(t.TempDir(), "history.jsonl"), metricsDir)
+	backups := &prunedStore{}
+	janitor.Backups = backups
+
+	results, err := janitor.Clean(now, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed := map[string]int{}
+	for _, result := range results {
+		removed[result.Target] = result.Removed
+	}
+	if removed["metrics"] != 1 || removed["backups"] != 1 {
+		t.Errorf("results = %+v, want a metrics file and a backup removed", results)
+	}
+	if _, err := os.Stat(filepath.Join(metricsDir, "live.json")); err != nil {
+		t.Errorf("live metrics file removed: %v", err)
+	}
+	if backups.policy != cfg.Retention.Backups {
+		t.Errorf("backups pruned with %+v, want %+v", backups.policy, cfg.Retention.Backups)
+	}
+}
@@ -0,0 +1,357 @@
+// Package symbols cuts Go context files down to the declarations a request
+// needs. Instead of whole files, a write to a Go file is sent the types,
+// functions and values its prompt or the edited file names, with function
+// bodies left out, and the names of the declarations that were dropped.
+package symbols
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+	"github.com/cecil-the-coder/mcp-code-api/internal/utils"
+)
+
+// Header opens a reduced file, so the model knows it is partial
+const Header = "// Declarations relevant to the request; function bodies and other declarations are left out\n"
+
+// maxOmittedNames bounds the list of dropped declarations in a reduced file
+const maxOmittedNames = 40
+
+var (
+	identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	majorVersion      = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// File is a context file and its content
+type File struct {
+	Path    string
+	Content string
+}
+
+// Extractor reduces Go context files to their relevant declarations
+type Extractor struct {
+	minSize int
+}
+
+// New returns the extractor cfg configures, or nil if it is off
+func New(cfg config.ContextSymbolsConfig) *Extractor {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &Extractor{minSize: cfg.MinSize}
+}
+
+// source is a parsed context file
+type source struct {
+	File
+	ast *ast.File
+}
+
+// decl is a top-level declaration of a context file. Type specs of a
+// grouped declaration are kept one by one; consts and vars by group, since
+// their values may depend on each other (iota).
+type decl struct {
+	src      *source
+	node     ast.Decl
+	spec     *ast.TypeSpec
+	names    []string
+	receiver string   // Receiver type of a method
+	refs     []string // Identifiers in the part that is sent
+}
+
+// Reduce returns the reduced content of the Go files among files, keyed by
+// path, when target is a Go file. A declaration is relevant if the prompt
+// names it (ignoring case) or the current content of target uses it; so are
+// the exported methods of a relevant type, and the types relevant
+// declarations refer to. Files that are smaller than the minimum size or
+// don't parse are left out and sent whole, as are all files if nothing is
+// relevant.
+func (e *Extractor) Reduce(prompt, target string, files []File) map[string]string {
+	if e == nil || !isGo(target) {
+		return nil
+	}
+	fset := token.NewFileSet()
+	var sources []*source
+	for _, f := range files {
+		if !isGo(f.Path) || len(f.Content) < e.minSize {
+			continue
+		}
+		file, err := parser.ParseFile(fset, f.Path, f.Content, parser.ParseComments)
+		if err != nil {
+			logger.Debugf("Symbols: sending %s whole: %v", f.Path, err)
+			continue
+		}
+		sources = append(sources, &source{File: f, ast: file})
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	decls, types := collect(sources)
+
+	named := make(map[string]bool)
+	for _, name := range identifierPattern.FindAllString(prompt, -1) {
+		named[strings.ToLower(name)] = true
+	}
+	used := make(map[string]bool)
+	if content, err := utils.ReadFileContentCached(target); err == nil {
+		for _, name := range identifierPattern.FindAllString(content, -1) {
+			used[name] = true
+		}
+	}
+	relevant := func(name string) bool {
+		return name != "_" && (used[name] || named[strings.ToLower(name)])
+	}
+
+	keep := make(map[*decl]bool)
+	var queue []*decl
+	add := func(d *decl) {
+		if !keep[d] {
+			keep[d] = true
+			queue = append(queue, d)
+		}
+	}
+	for _, d := range decls {
+		for _, name := range d.names {
+			if relevant(name) {
+				add(d)
+				break
+			}
+		}
+	}
+	for _, d := range decls {
+		if d.receiver != "" && ast.IsExported(d.names[0]) && relevant(d.receiver) {
+			add(d)
+		}
+	}
+	if len(keep) == 0 {
+		return nil
+	}
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		for _, name := range d.refs {
+			for _, t := range types[name] {
+				add(t)
+			}
+		}
+	}
+
+	reduced := make(map[string]string)
+	for _, src := range sources {
+		content := render(fset, src, decls, keep)
+		if len(content) >= len(src.Content) {
+			continue
+		}
+		logger.Debugf("Symbols: sending %d of %d bytes of %s", len(content), len(src.Content), src.Path)
+		reduced[src.Path] = content
+	}
+	return reduced
+}
+
+// collect returns the top-level declarations of sources in order, and the
+// type declarations by name
+func collect(sources []*source) ([]*decl, map[string][]*decl) {
+	var decls []*decl
+	types := make(map[string][]*decl)
+	for _, src := range sources {
+		for _, node := range src.ast.Decls {
+			switch node := node.(type) {
+			case *ast.FuncDecl:
+				d := &decl{src: src, node: node, names: []string{node.Name.Name}, refs: identifiers(&ast.FuncDecl{Recv: node.Recv, Name: node.Name, Type: node.Type})}
+				if node.Recv != nil && len(node.Recv.List) > 0 {
+					d.receiver = receiverType(node.Recv.List[0].Type)
+				}
+				decls = append(decls, d)
+			case *ast.GenDecl:
+				switch node.Tok {
+				case token.IMPORT:
+				case token.TYPE:
+					for _, spec := range node.Specs {
+						spec := spec.(*ast.TypeSpec)
+						d := &decl{src: src, node: node, spec: spec, names: []string{spec.Name.Name}, refs: identifiers(spec)}
+						decls = append(decls, d)
+						types[spec.Name.Name] = append(types[spec.Name.Name], d)
+					}
+				default:
+					d := &decl{src: src, node: node, refs: identifiers(node)}
+					for _, spec := range node.Specs {
+						for _, name := range spec.(*ast.ValueSpec).Names {
+							d.names = append(d.names, name.Name)
+						}
+					}
+					decls = append(decls, d)
+				}
+			}
+		}
+	}
+	return decls, types
+}
+
+// render returns the package clause of src, the imports and kept
+// declarations it uses, and the names of the others
+func render(fset *token.FileSet, src *source, decls []*decl, keep map[*decl]bool) string {
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	text := func(from, to token.Pos) string { return src.Content[offset(from):offset(to)] }
+
+	var body strings.Builder
+	refs := make(map[string]bool)
+	var omitted []string
+	for _, d := range decls {
+		if d.src != src {
+			continue
+		}
+		if !keep[d] {
+			for _, name := range d.names {
+				if d.receiver != "" {
+					name = d.receiver + "." + name
+				}
+				omitted = append(omitted, name)
+			}
+			continue
+		}
+		for _, name := range d.refs {
+			refs[name] = true
+		}
+		body.WriteString("\n")
+		switch node := d.node.(type) {
+		case *ast.FuncDecl:
+			end := node.End()
+			if node.Body != nil {
+				end = node.Body.Lbrace
+			}
+			body.WriteString(strings.TrimRight(text(start(node.Doc, node.Pos()), end), " \t"))
+		case *ast.GenDecl:
+			if d.spec == nil {
+				body.WriteString(text(start(node.Doc, node.Pos()), node.End()))
+				break
+			}
+			end := d.spec.End()
+			if d.spec.Comment != nil {
+				end = d.spec.Comment.End()
+			}
+			if node.Lparen.IsValid() {
+				// Taken out of its group, the spec loses a level of indentation
+				if d.spec.Doc != nil {
+					for _, comment := range d.spec.Doc.List {
+						body.WriteString(comment.Text + "\n")
+					}
+				}
+				body.WriteString("type " + strings.ReplaceAll(text(d.spec.Pos(), end), "\n\t", "\n"))
+			} else {
+				body.WriteString(text(start(node.Doc, node.Pos()), end))
+			}
+		}
+		body.WriteString("\n")
+	}
+
+	var out strings.Builder
+	out.WriteString(Header)
+	out.WriteString("package " + src.ast.Name.Name + "\n")
+	var imports []string
+	for _, spec := range src.ast.Imports {
+		if refs[importName(spec)] {
+			imports = append(imports, "\t"+text(spec.Pos(), spec.End()))
+		}
+	}
+	if len(imports) > 0 {
+		out.WriteString("\nimport (\n" + strings.Join(imports, "\n") + "\n)\n")
+	}
+	out.WriteString(body.String())
+	if len(omitted) > 0 {
+		list := strings.Join(omitted[:min(len(omitted), maxOmittedNames)], ", ")
+		if len(omitted) > maxOmittedNames {
+			list += fmt.Sprintf(" and %d more", len(omitted)-maxOmittedNames)
+		}
+		out.WriteString("\n// Omitted: " + list + "\n")
+	}
+	return out.String()
+}
+
+// start is where a declaration begins, including its doc comment
+func start(doc *ast.CommentGroup, pos token.Pos) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return pos
+}
+
+// identifiers returns the names in node
+func identifiers(node ast.Node) []string {
+	var names []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			names = append(names, id.Name)
+		}
+		return true
+	})
+	return names
+}
+
+// receiverType returns the name of a method's receiver type
+func receiverType(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// importName returns the name an import is referred to by: its alias, or
+// the last element of its path that isn't a major version
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	importPath, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(importPath)
+	if majorVersion.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	return strings.TrimPrefix(name, "go-")
+}
+
+func isGo(filePath string) bool {
+	return strings.EqualFold(filepath.Ext(filePath), ".go")
+}
+
+type extractorKey struct{}
+
+// WithExtractor returns a context whose requests reduce their Go context
+// files with e
+func WithExtractor(ctx context.Context, e *Extractor) context.Context {
+	if e == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, extractorKey{}, e)
+}
+
+// FromContext returns the extractor attached to ctx, or nil
+func FromContext(ctx context.Context) *Extractor {
+	e, _ := ctx.Value(extractorKey{}).(*Extractor)
+	return e
+}
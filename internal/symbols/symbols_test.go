@@ -0,0 +1,106 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+const managerSource = `package index
+
+import (
+	"context"
+	"strings"
+)
+
+// Config configures a Manager
+type Config struct {
+	Dir  string // Where indexes are stored
+	TopK int
+}
+
+type (
+	// Index is one project's store
+	Index struct {
+		Root string
+	}
+
+	// Unrelated is never referenced
+	Unrelated struct{}
+)
+
+const (
+	modeA = iota
+	modeB
+)
+
+// Manager keeps the indexes
+type Manager struct {
+	cfg     Config
+	indexes map[string]*Index
+}
+
+// NewManager returns a manager
+func NewManager(cfg Config) *Manager {
+	return &Manager{cfg: cfg, indexes: make(map[string]*Index)}
+}
+
+// Retrieve returns the snippets relevant to query
+func (m *Manager) Retrieve(ctx context.Context, query string) []string {
+	return strings.Fields(query)
+}
+
+func (m *Manager) helper() {}
+
+func unrelated() {}
+`
+
+func TestReduce(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "main.go")
+	files := []File{{Path: filepath.Join(dir, "manager.go"), Content: managerSource}}
+	e := New(config.ContextSymbolsConfig{Enabled: true})
+
+	reduced := e.Reduce("make NewManager check the dir", target, files)[files[0].Path]
+	for _, want := range []string{
+		Header + "package index\n",
+		"// NewManager returns a manager\nfunc NewManager(cfg Config) *Manager\n",
+		"// Config configures a Manager\ntype Config struct {\n\tDir  string // Where indexes are stored\n",
+		"// Manager keeps the indexes\ntype Manager struct {",
+		"// Index is one project's store\ntype Index struct {\n\tRoot string\n}\n",
+		"// Omitted: Unrelated, modeA, modeB, Manager.Retrieve, Manager.helper, unrelated\n",
+	} {
+		if !strings.Contains(reduced, want) {
+			t.Errorf("reduced = %q, want it to contain %q", reduced, want)
+		}
+	}
+	if strings.Contains(reduced, "return &Manager") || strings.Contains(reduced, "import") {
+		t.Errorf("reduced = %q, want no bodies or unused imports", reduced)
+	}
+
+	// Identifiers the target uses are relevant too, with the imports they need
+	if err := os.WriteFile(target, []byte("package main\n\nvar _ = m.Retrieve\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reduced = e.Reduce("add a flag", target, files)[files[0].Path]
+	if !strings.Contains(reduced, "import (\n\t\"context\"\n)\n") || !strings.Contains(reduced, "func (m *Manager) Retrieve(ctx context.Context, query string) []string\n") {
+		t.Errorf("reduced = %q, want Retrieve and the context import", reduced)
+	}
+
+	if got := e.Reduce("rename the flag", filepath.Join(dir, "other.go"), files); got != nil {
+		t.Errorf("Reduce() = %q, want nil when nothing is relevant", got)
+	}
+	if got := e.Reduce("update NewManager", filepath.Join(dir, "main.py"), files); got != nil {
+		t.Errorf("Reduce() = %q, want nil for a target that isn't Go", got)
+	}
+	small := New(config.ContextSymbolsConfig{Enabled: true, MinSize: len(managerSource) + 1})
+	if got := small.Reduce("update NewManager", target, files); got != nil {
+		t.Errorf("Reduce() = %q, want nil for files under the minimum size", got)
+	}
+	if New(config.ContextSymbolsConfig{}) != nil {
+		t.Error("New() returned an extractor with symbols disabled")
+	}
+}
@@ -0,0 +1,244 @@
+// Package tracing records OpenTelemetry-compatible spans for MCP tool calls,
+// router decisions and provider HTTP requests, and exports them to an OTLP
+// collector. Tracing is off until Init is called with an enabled config; until
+// then Start returns a nil span and every span method is a no-op.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// SpanKind follows the OTLP span kind enumeration
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Attribute is a span attribute. Value is a string, int64, float64 or bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Float returns a floating point attribute
+func Float(key string, value float64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Span is a single timed operation. A nil *Span is valid and ignores all calls.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	sampled  bool
+	name     string
+	kind     SpanKind
+	start    time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes []Attribute
+	statusErr  string
+	failed     bool
+	ended      bool
+}
+
+// tracer is the process-wide tracer installed by Init
+type tracer struct {
+	exporter    *exporter
+	sampleRatio float64
+}
+
+var (
+	globalMu sync.RWMutex
+	global   *tracer
+)
+
+type spanKey struct{}
+
+// Init starts exporting spans as configured. The returned shutdown function
+// flushes pending spans; it is a no-op when tracing is disabled. While
+// tracing is enabled, http.DefaultTransport is wrapped so provider clients
+// (which use the default transport) get a client span per request.
+func Init(cfg config.TracingConfig) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if !cfg.Enabled {
+		return noop, nil
+	}
+	if cfg.Endpoint == "" {
+		return noop, errors.New("tracing: no endpoint configured")
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	// The exporter talks to the collector on the untraced transport, so
+	// exporting never produces spans of its own
+	baseTransport := http.DefaultTransport
+	exp := newExporter(cfg, baseTransport)
+
+	globalMu.Lock()
+	global = &tracer{exporter: exp, sampleRatio: ratio}
+	globalMu.Unlock()
+	http.DefaultTransport = Transport(baseTransport)
+
+	logger.Infof("Tracing: exporting spans to %s (service: %s, sample ratio: %g)", exp.url, cfg.ServiceName, ratio)
+
+	return func(ctx context.Context) error {
+		globalMu.Lock()
+		global = nil
+		globalMu.Unlock()
+		http.DefaultTransport = baseTransport
+		return exp.shutdown(ctx)
+	}, nil
+}
+
+// Enabled reports whether spans are being recorded
+func Enabled() bool {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return global != nil
+}
+
+// Start begins an internal span as a child of the span in ctx, if any
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, KindInternal, name, attrs)
+}
+
+// StartServer begins a span for handling an incoming request
+func StartServer(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, KindServer, name, attrs)
+}
+
+func startSpan(ctx context.Context, kind SpanKind, name string, attrs []Attribute) (context.Context, *Span) {
+	globalMu.RLock()
+	t := global
+	globalMu.RUnlock()
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: append([]Attribute(nil), attrs...),
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+		span.sampled = parent.sampled
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+		span.sampled = t.sample(span.traceID)
+	}
+	_, _ = rand.Read(span.spanID[:])
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// sample decides whether a new trace is recorded, consistently for a trace ID
+func (t *tracer) sample(traceID [16]byte) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	var n uint64
+	for _, b := range traceID[8:] {
+		n = n<<8 | uint64(b)
+	}
+	return float64(n>>1) < t.sampleRatio*float64(math.MaxUint64>>1)
+}
+
+// SpanFromContext returns the current span, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SetAttributes adds or overwrites attributes on the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range attrs {
+		replaced := false
+		for i := range s.attributes {
+			if s.attributes[i].Key == attr.Key {
+				s.attributes[i] = attr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.attributes = append(s.attributes, attr)
+		}
+	}
+}
+
+// RecordError marks the span as failed. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.failed = true
+	s.statusErr = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if !s.sampled {
+		return
+	}
+	globalMu.RLock()
+	t := global
+	globalMu.RUnlock()
+	if t != nil {
+		t.exporter.enqueue(s)
+	}
+}
+
+// TraceID returns the hex trace ID, or "" for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+func TestSpansExportedAsOTLP(t *testing.T) {
+	var mu sync.Mutex
+	var received []otlpRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("X-Token") != "secret" {
+			t.Errorf("unexpected export request %s %v", r.URL.Path, r.Header)
+		}
+		var payload otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid OTLP JSON: %v", err)
+		}
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer provider.Close()
+
+	shutdown, err := Init(config.TracingConfig{
+		Enabled:     true,
+		Endpoint:    collector.URL,
+		ServiceName: "test-service",
+		Headers:     map[string]string{"X-Token": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	ctx, parent := Start(context.Background(), "router.generate")
+	childCtx, child := Start(ctx, "provider.generate", String("gen_ai.system", "cerebras"), Int("gen_ai.usage.input_tokens", 12))
+	req, _ := http.NewRequestWithContext(childCtx, "POST", provider.URL+"/v1/chat?key=abc", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	child.RecordError(errors.New("rate limited"))
+	child.End()
+	parent.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+	if Enabled() {
+		t.Error("tracing still enabled after shutdown")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	spans := map[string]otlpSpan{}
+	for _, payload := range received {
+		rs := payload.ResourceSpans[0]
+		if got := *rs.Resource.Attributes[0].Value.StringValue; got != "test-service" {
+			t.Errorf("service.name = %q, want test-service", got)
+		}
+		for _, span := range rs.ScopeSpans[0].Spans {
+			spans[span.Name] = span
+		}
+	}
+	if len(spans) != 3 {
+		t.Fatalf("exported %d spans, want 3: %v", len(spans), spans)
+	}
+
+	root, gen, httpSpan := spans["router.generate"], spans["provider.generate"], spans["HTTP POST"]
+	if root.ParentSpanID != "" || gen.ParentSpanID != root.SpanID || httpSpan.ParentSpanID != gen.SpanID {
+		t.Error("spans are not nested router -> provider -> HTTP")
+	}
+	if gen.TraceID != root.TraceID || httpSpan.TraceID != root.TraceID {
+		t.Error("spans do not share a trace ID")
+	}
+	if gen.Status.Code != 2 || gen.Status.Message != "rate limited" {
+		t.Errorf("provider span status = %+v, want error", gen.Status)
+	}
+	attrs := map[string]otlpAnyValue{}
+	for _, kv := range append(gen.Attributes, httpSpan.Attributes...) {
+		attrs[kv.Key] = kv.Value
+	}
+	if v := attrs["gen_ai.usage.input_tokens"].IntValue; v == nil || *v != "12" {
+		t.Errorf("input tokens attribute = %v, want 12", v)
+	}
+	if v := attrs["http.response.status_code"].IntValue; v == nil || *v != "429" {
+		t.Errorf("status code attribute = %v, want 429", v)
+	}
+	if v := attrs["url.full"].StringValue; v == nil || *v != provider.URL+"/v1/chat" {
+		t.Errorf("url.full = %v, want query string stripped", v)
+	}
+}
+
+func TestDisabledTracingIsNoop(t *testing.T) {
+	shutdown, err := Init(config.TracingConfig{})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	ctx, span := Start(context.Background(), "noop")
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Error("Start() returned a span with tracing disabled")
+	}
+	span.SetAttributes(String("k", "v"))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"net/http"
+)
+
+// tracingTransport wraps an http.RoundTripper with a client span per request
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// Transport returns base wrapped so every request is recorded as a client
+// span, nested under the span in the request context
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base}
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, span := startSpan(req.Context(), KindClient, "HTTP "+req.Method, []Attribute{
+		String("http.request.method", req.Method),
+		String("server.address", req.URL.Hostname()),
+		// Path only: query strings may carry API keys (e.g. Gemini's ?key=)
+		String("url.full", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+	})
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.RecordError(httpStatusError(resp.Status))
+	}
+	return resp, nil
+}
+
+type httpStatusError string
+
+func (e httpStatusError) Error() string { return string(e) }
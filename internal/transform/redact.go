@@ -0,0 +1,166 @@
+package transform
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// emailPattern matches most RFC 5322 addresses seen in source code and prose
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// RegexTransformer replaces every match of a regular expression with a placeholder
+type RegexTransformer struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// NewRegexTransformer creates a transformer that scrubs matches of pattern.
+// kind is used to build placeholder names (e.g. "email" -> <EMAIL_1>).
+func NewRegexTransformer(kind string, pattern *regexp.Regexp) *RegexTransformer {
+	return &RegexTransformer{kind: kind, pattern: pattern}
+}
+
+// Name returns the transformer name
+func (t *RegexTransformer) Name() string {
+	return "regex:" + t.kind
+}
+
+// Transform replaces all matches with session placeholders
+func (t *RegexTransformer) Transform(text string, session *Session) string {
+	return t.pattern.ReplaceAllStringFunc(text, func(match string) string {
+		return session.Placeholder(t.kind, match)
+	})
+}
+
+// DictionaryTransformer replaces whole-word, case-insensitive occurrences of
+// dictionary entries (e.g. customer or employee names) with placeholders
+type DictionaryTransformer struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// NewDictionaryTransformer creates a transformer for the given terms.
+// Returns nil if terms is empty.
+func NewDictionaryTransformer(kind string, terms []string) *DictionaryTransformer {
+	var quoted []string
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+
+	// Longest terms first so "Jane Doe" wins over "Jane"
+	sort.Slice(quoted, func(i, j int) bool {
+		return len(quoted[i]) > len(quoted[j])
+	})
+
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	return &DictionaryTransformer{kind: kind, pattern: pattern}
+}
+
+// Name returns the transformer name
+func (t *DictionaryTransformer) Name() string {
+	return "dictionary:" + t.kind
+}
+
+// Transform replaces all dictionary matches with session placeholders
+func (t *DictionaryTransformer) Transform(text string, session *Session) string {
+	return t.pattern.ReplaceAllStringFunc(text, func(match string) string {
+		return session.Placeholder(t.kind, match)
+	})
+}
+
+// hostnamePattern converts a hostname glob such as "*.corp.example.com" into a regexp
+func hostnamePattern(glob string) (*regexp.Regexp, error) {
+	glob = strings.ToLower(strings.TrimSpace(glob))
+	if glob == "" {
+		return nil, fmt.Errorf("empty hostname pattern")
+	}
+	expr := regexp.QuoteMeta(glob)
+	expr = strings.ReplaceAll(expr, `\*`, `[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*`)
+	return regexp.Compile(`(?i)\b` + expr + `\b`)
+}
+
+// NewPipelineFromConfig builds the redaction pipeline described by cfg.
+// Returns nil when redaction is disabled or no rules are configured.
+func NewPipelineFromConfig(cfg config.RedactionConfig) (*Pipeline, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	pipeline := NewPipeline(cfg.RestorePlaceholders)
+
+	// Custom patterns run first so users can override the built-in ones
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p.Name, err)
+		}
+		kind := p.Name
+		if kind == "" {
+			kind = "redacted"
+		}
+		pipeline.Add(NewRegexTransformer(kind, re))
+	}
+
+	// Emails run before names and hostnames so addresses are replaced whole
+	if cfg.Emails {
+		pipeline.Add(NewRegexTransformer("email", emailPattern))
+	}
+
+	names := append([]string{}, cfg.Names...)
+	if cfg.NamesFile != "" {
+		fileNames, err := readDictionary(cfg.NamesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read names file: %w", err)
+		}
+		names = append(names, fileNames...)
+	}
+	if t := NewDictionaryTransformer("name", names); t != nil {
+		pipeline.Add(t)
+	}
+
+	for _, glob := range cfg.Hostnames {
+		re, err := hostnamePattern(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hostname pattern %q: %w", glob, err)
+		}
+		pipeline.Add(NewRegexTransformer("host", re))
+	}
+
+	if pipeline.Len() == 0 {
+		return nil, nil
+	}
+	return pipeline, nil
+}
+
+// readDictionary reads one term per line, ignoring blank lines and # comments
+func readDictionary(path string) ([]string, error) {
+	file, err := os.Open(config.ExpandPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms, scanner.Err()
+}
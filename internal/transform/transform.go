@@ -0,0 +1,158 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Transformer rewrites outbound text (prompts and context) before it is sent to a provider
+type Transformer interface {
+	// Name returns a short identifier used in logs
+	Name() string
+
+	// Transform rewrites text, recording any substitutions in the session so
+	// they can be reversed on the way back
+	Transform(text string, session *Session) string
+}
+
+// Pipeline applies a sequence of transformers to outbound text
+type Pipeline struct {
+	transformers []Transformer
+	restore      bool
+}
+
+// NewPipeline creates a pipeline from the given transformers.
+// When restore is true, placeholders found in provider output are replaced
+// with the original values before the output is returned to the caller.
+func NewPipeline(restore bool, transformers ...Transformer) *Pipeline {
+	return &Pipeline{
+		transformers: transformers,
+		restore:      restore,
+	}
+}
+
+// Add appends a transformer to the pipeline
+func (p *Pipeline) Add(t Transformer) {
+	p.transformers = append(p.transformers, t)
+}
+
+// Len returns the number of transformers in the pipeline
+func (p *Pipeline) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.transformers)
+}
+
+// NewSession creates a new substitution session for a single request
+func (p *Pipeline) NewSession() *Session {
+	return &Session{
+		pipeline:     p,
+		placeholders: make(map[string]string),
+		originals:    make(map[string]string),
+		counters:     make(map[string]int),
+	}
+}
+
+// Session tracks the substitutions made while transforming one request.
+// The same original value always maps to the same placeholder within a session.
+type Session struct {
+	pipeline     *Pipeline
+	placeholders map[string]string // placeholder -> original
+	originals    map[string]string // kind + original -> placeholder
+	counters     map[string]int
+	mutex        sync.Mutex
+}
+
+// Placeholder returns the placeholder for an original value of the given kind,
+// allocating a new one if the value has not been seen in this session
+func (s *Session) Placeholder(kind, original string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := kind + "\x00" + original
+	if placeholder, ok := s.originals[key]; ok {
+		return placeholder
+	}
+
+	s.counters[kind]++
+	placeholder := fmt.Sprintf("<%s_%d>", strings.ToUpper(kind), s.counters[kind])
+	s.originals[key] = placeholder
+	s.placeholders[placeholder] = original
+	return placeholder
+}
+
+// Outbound runs text through every transformer in the pipeline
+func (s *Session) Outbound(text string) string {
+	if s == nil || s.pipeline == nil {
+		return text
+	}
+	for _, t := range s.pipeline.transformers {
+		text = t.Transform(text, s)
+	}
+	return text
+}
+
+// Inbound replaces placeholders in provider output with their original values.
+// It is a no-op if the pipeline was created without restore enabled.
+func (s *Session) Inbound(text string) string {
+	if s == nil || s.pipeline == nil || !s.pipeline.restore {
+		return text
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.placeholders) == 0 {
+		return text
+	}
+
+	pairs := make([]string, 0, len(s.placeholders)*2)
+	for placeholder, original := range s.placeholders {
+		pairs = append(pairs, placeholder, original)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Substitutions returns the number of distinct values replaced in this session
+func (s *Session) Substitutions() int {
+	if s == nil {
+		return 0
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.placeholders)
+}
+
+type sessionKey struct{}
+
+// WithSession returns a context carrying the given session
+func WithSession(ctx context.Context, session *Session) context.Context {
+	if session == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext returns the session stored in ctx, or nil
+func SessionFromContext(ctx context.Context) *Session {
+	if ctx == nil {
+		return nil
+	}
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+// Outbound transforms text using the session carried by ctx, if any.
+// Provider clients call this on the fully assembled prompt right before
+// building the request payload.
+func Outbound(ctx context.Context, text string) string {
+	return SessionFromContext(ctx).Outbound(text)
+}
+
+// Inbound restores placeholders in text using the session carried by ctx, if any
+func Inbound(ctx context.Context, text string) string {
+	return SessionFromContext(ctx).Inbound(text)
+}
@@ -0,0 +1,85 @@
+package transform
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// TestRedactionRoundTrip tests that scrubbed values are restored in the output
+func TestRedactionRoundTrip(t *testing.T) {
+	pipeline, err := NewPipelineFromConfig(config.RedactionConfig{
+		Enabled:             true,
+		RestorePlaceholders: true,
+		Emails:              true,
+		Names:               []string{"Jane Doe"},
+		Hostnames:           []string{"*.corp.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build pipeline: %v", err)
+	}
+
+	session := pipeline.NewSession()
+	input := "Contact Jane Doe <jane@example.org> about db1.corp.example.com; cc jane@example.org"
+	scrubbed := session.Outbound(input)
+
+	for _, secret := range []string{"Jane Doe", "jane@example.org", "db1.corp.example.com"} {
+		if strings.Contains(scrubbed, secret) {
+			t.Errorf("Expected %q to be scrubbed, got: %s", secret, scrubbed)
+		}
+	}
+
+	if strings.Count(scrubbed, "<EMAIL_1>") != 2 {
+		t.Errorf("Expected repeated email to reuse the same placeholder, got: %s", scrubbed)
+	}
+
+	if restored := session.Inbound(scrubbed); restored != input {
+		t.Errorf("Expected round trip to restore input\nwant: %s\ngot:  %s", input, restored)
+	}
+}
+
+// TestRedactionWithoutRestore tests that placeholders are left in place when restore is off
+func TestRedactionWithoutRestore(t *testing.T) {
+	pipeline, err := NewPipelineFromConfig(config.RedactionConfig{
+		Enabled: true,
+		Emails:  true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build pipeline: %v", err)
+	}
+
+	ctx := WithSession(context.Background(), pipeline.NewSession())
+	scrubbed := Outbound(ctx, "owner: ops@example.com")
+	if Inbound(ctx, scrubbed) != scrubbed {
+		t.Error("Expected output to keep placeholders when restore is disabled")
+	}
+}
+
+// TestRedactionDisabled tests that a disabled config produces no pipeline
+func TestRedactionDisabled(t *testing.T) {
+	pipeline, err := NewPipelineFromConfig(config.RedactionConfig{Emails: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pipeline != nil {
+		t.Error("Expected nil pipeline when redaction is disabled")
+	}
+
+	// Outbound without a session must be a no-op
+	if got := Outbound(context.Background(), "a@b.co"); got != "a@b.co" {
+		t.Errorf("Expected unchanged text, got %s", got)
+	}
+}
+
+// TestInvalidPattern tests that bad regular expressions are rejected
+func TestInvalidPattern(t *testing.T) {
+	_, err := NewPipelineFromConfig(config.RedactionConfig{
+		Enabled:  true,
+		Patterns: []config.RedactionPatternConfig{{Name: "ticket", Regex: "("}},
+	})
+	if err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
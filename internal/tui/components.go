@@ -0,0 +1,277 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Text styles
+func Bold(s string) string  { return "\x1b[1m" + s + "\x1b[0m" }
+func Faint(s string) string { return "\x1b[2m" + s + "\x1b[0m" }
+func Green(s string) string { return "\x1b[32m" + s + "\x1b[0m" }
+func Red(s string) string   { return "\x1b[31m" + s + "\x1b[0m" }
+func Cyan(s string) string  { return "\x1b[36m" + s + "\x1b[0m" }
+
+// TextInput is a single-line text field
+type TextInput struct {
+	Prompt      string
+	Placeholder string
+	// Mask hides the value, for secrets
+	Mask  bool
+	Value []rune
+	pos   int
+}
+
+// String returns the entered text
+func (t *TextInput) String() string {
+	return strings.TrimSpace(string(t.Value))
+}
+
+// SetValue replaces the text and moves the cursor to its end
+func (t *TextInput) SetValue(s string) {
+	t.Value = []rune(s)
+	t.pos = len(t.Value)
+}
+
+// Update edits the text for a key press; other keys are ignored
+func (t *TextInput) Update(key KeyMsg) {
+	switch key.Type {
+	case KeyRune:
+		value := append([]rune(nil), t.Value[:t.pos]...)
+		value = append(value, key.Runes...)
+		t.Value = append(value, t.Value[t.pos:]...)
+		t.pos += len(key.Runes)
+	case KeyBackspace:
+		if t.pos > 0 {
+			t.Value = append(t.Value[:t.pos-1], t.Value[t.pos:]...)
+			t.pos--
+		}
+	case KeyDelete:
+		if t.pos < len(t.Value) {
+			t.Value = append(t.Value[:t.pos], t.Value[t.pos+1:]...)
+		}
+	case KeyLeft:
+		t.pos = max(t.pos-1, 0)
+	case KeyRight:
+		t.pos = min(t.pos+1, len(t.Value))
+	case KeyHome, KeyCtrlA:
+		t.pos = 0
+	case KeyEnd, KeyCtrlE:
+		t.pos = len(t.Value)
+	case KeyCtrlU:
+		t.Value = t.Value[t.pos:]
+		t.pos = 0
+	case KeyCtrlW:
+		start := t.pos
+		for start > 0 && t.Value[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && t.Value[start-1] != ' ' {
+			start--
+		}
+		t.Value = append(t.Value[:start], t.Value[t.pos:]...)
+		t.pos = start
+	}
+}
+
+// View renders the field with a block cursor
+func (t *TextInput) View() string {
+	if len(t.Value) == 0 {
+		return t.Prompt + "\x1b[7m \x1b[0m" + Faint(t.Placeholder)
+	}
+	value := t.Value
+	if t.Mask {
+		value = []rune(strings.Repeat("•", len(t.Value)))
+	}
+	cursor := " "
+	if t.pos < len(value) {
+		cursor = string(value[t.pos])
+	}
+	after := ""
+	if t.pos+1 < len(value) {
+		after = string(value[t.pos+1:])
+	}
+	return t.Prompt + string(value[:t.pos]) + "\x1b[7m" + cursor + "\x1b[0m" + after
+}
+
+// ListItem is an entry of a List
+type ListItem struct {
+	Label   string
+	Detail  string
+	Checked bool
+}
+
+// List is a scrollable list of items. With Multi, space toggles items;
+// with Filter, typing narrows the items shown.
+type List struct {
+	Items  []ListItem
+	Multi  bool
+	Filter bool
+	// Height is the number of rows shown; 0 shows every item
+	Height int
+
+	query  []rune
+	cursor int
+	offset int
+}
+
+// Query returns the filter text
+func (l *List) Query() string {
+	return string(l.query)
+}
+
+// SetQuery replaces the filter text
+func (l *List) SetQuery(q string) {
+	l.query = []rune(q)
+	l.cursor, l.offset = 0, 0
+}
+
+// Visible returns the indexes into Items that match the filter
+func (l *List) Visible() []int {
+	var visible []int
+	query := strings.ToLower(string(l.query))
+	for i, item := range l.Items {
+		if query == "" || strings.Contains(strings.ToLower(item.Label), query) {
+			visible = append(visible, i)
+		}
+	}
+	return visible
+}
+
+// Selected returns the index into Items under the cursor, or -1
+func (l *List) Selected() int {
+	visible := l.Visible()
+	if l.cursor < len(visible) {
+		return visible[l.cursor]
+	}
+	return -1
+}
+
+// Checked returns the labels of the checked items, in list order
+func (l *List) Checked() []string {
+	var labels []string
+	for _, item := range l.Items {
+		if item.Checked {
+			labels = append(labels, item.Label)
+		}
+	}
+	return labels
+}
+
+// Update moves the cursor, toggles items and edits the filter
+func (l *List) Update(key KeyMsg) {
+	visible := l.Visible()
+	page := max(l.Height-1, 1)
+	switch {
+	case key.Type == KeyUp:
+		l.cursor--
+	case key.Type == KeyDown:
+		l.cursor++
+	case key.Type == KeyPgUp:
+		l.cursor -= page
+	case key.Type == KeyPgDown:
+		l.cursor += page
+	case key.Type == KeyHome:
+		l.cursor = 0
+	case key.Type == KeyEnd:
+		l.cursor = len(visible) - 1
+	case l.Multi && key.String() == "space":
+		if i := l.Selected(); i >= 0 {
+			l.Items[i].Checked = !l.Items[i].Checked
+		}
+	case l.Filter && key.Type == KeyRune:
+		l.query = append(l.query, key.Runes...)
+		l.cursor, l.offset = 0, 0
+	case l.Filter && key.Type == KeyBackspace && len(l.query) > 0:
+		l.query = l.query[:len(l.query)-1]
+		l.cursor, l.offset = 0, 0
+	case !l.Filter && (key.String() == "k" || key.String() == "j"):
+		if key.String() == "k" {
+			l.cursor--
+		} else {
+			l.cursor++
+		}
+	}
+
+	l.cursor = max(min(l.cursor, len(l.Visible())-1), 0)
+	if l.Height > 0 {
+		if l.cursor < l.offset {
+			l.offset = l.cursor
+		}
+		if l.cursor >= l.offset+l.Height {
+			l.offset = l.cursor - l.Height + 1
+		}
+	}
+}
+
+// View renders the visible items
+func (l *List) View() string {
+	var b strings.Builder
+	if l.Filter {
+		fmt.Fprintf(&b, "Filter: %s\x1b[7m \x1b[0m\n", string(l.query))
+	}
+
+	visible := l.Visible()
+	if len(visible) == 0 {
+		b.WriteString(Faint("  (no matches)"))
+		return b.String()
+	}
+	end := len(visible)
+	if l.Height > 0 {
+		end = min(l.offset+l.Height, end)
+	}
+	for row := l.offset; row < end; row++ {
+		item := l.Items[visible[row]]
+		pointer := "  "
+		label := item.Label
+		if row == l.cursor {
+			pointer = Cyan("> ")
+			label = Bold(label)
+		}
+		box := ""
+		if l.Multi {
+			box = "[ ] "
+			if item.Checked {
+				box = "[" + Green("x") + "] "
+			}
+		}
+		b.WriteString(pointer + box + label)
+		if item.Detail != "" {
+			b.WriteString("  " + Faint(item.Detail))
+		}
+		if row < end-1 {
+			b.WriteByte('\n')
+		}
+	}
+	if end < len(visible) || l.offset > 0 {
+		fmt.Fprintf(&b, "\n%s", Faint(fmt.Sprintf("  %d-%d of %d", l.offset+1, end, len(visible))))
+	}
+	return b.String()
+}
+
+// SpinnerTickMsg advances a Spinner
+type SpinnerTickMsg struct{}
+
+// Spinner shows that something is in progress
+type Spinner struct {
+	frame int
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Tick returns the command advancing the spinner
+func (s *Spinner) Tick() Cmd {
+	return Tick(100*time.Millisecond, func(time.Time) Msg { return SpinnerTickMsg{} })
+}
+
+// Update advances a frame and schedules the next
+func (s *Spinner) Update() Cmd {
+	s.frame = (s.frame + 1) % len(spinnerFrames)
+	return s.Tick()
+}
+
+// View renders the current frame
+func (s *Spinner) View() string {
+	return Cyan(spinnerFrames[s.frame])
+}
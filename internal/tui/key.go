@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// KeyType identifies a key press
+type KeyType int
+
+const (
+	KeyRune KeyType = iota
+	KeyEnter
+	KeyBackspace
+	KeyDelete
+	KeyTab
+	KeyShiftTab
+	KeyEsc
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPgUp
+	KeyPgDown
+	KeyCtrlA
+	KeyCtrlC
+	KeyCtrlE
+	KeyCtrlK
+	KeyCtrlU
+	KeyCtrlW
+)
+
+var keyNames = map[KeyType]string{
+	KeyEnter:     "enter",
+	KeyBackspace: "backspace",
+	KeyDelete:    "delete",
+	KeyTab:       "tab",
+	KeyShiftTab:  "shift+tab",
+	KeyEsc:       "esc",
+	KeyUp:        "up",
+	KeyDown:      "down",
+	KeyLeft:      "left",
+	KeyRight:     "right",
+	KeyHome:      "home",
+	KeyEnd:       "end",
+	KeyPgUp:      "pgup",
+	KeyPgDown:    "pgdown",
+	KeyCtrlA:     "ctrl+a",
+	KeyCtrlC:     "ctrl+c",
+	KeyCtrlE:     "ctrl+e",
+	KeyCtrlK:     "ctrl+k",
+	KeyCtrlU:     "ctrl+u",
+	KeyCtrlW:     "ctrl+w",
+}
+
+// KeyMsg is a key press. Printable input, including pasted text, arrives as
+// KeyRune messages.
+type KeyMsg struct {
+	Type  KeyType
+	Runes []rune
+}
+
+// String returns the key's name, like "enter" or "ctrl+c", or the typed text
+// (" " is "space")
+func (k KeyMsg) String() string {
+	if k.Type == KeyRune {
+		if len(k.Runes) == 1 && k.Runes[0] == ' ' {
+			return "space"
+		}
+		return string(k.Runes)
+	}
+	return keyNames[k.Type]
+}
+
+// escapeKeys maps the escape sequences of common terminals to keys
+var escapeKeys = map[string]KeyType{
+	"[A": KeyUp, "[B": KeyDown, "[C": KeyRight, "[D": KeyLeft,
+	"OA": KeyUp, "OB": KeyDown, "OC": KeyRight, "OD": KeyLeft,
+	"[H": KeyHome, "[F": KeyEnd, "OH": KeyHome, "OF": KeyEnd,
+	"[1~": KeyHome, "[4~": KeyEnd, "[7~": KeyHome, "[8~": KeyEnd,
+	"[3~": KeyDelete, "[5~": KeyPgUp, "[6~": KeyPgDown,
+	"[Z": KeyShiftTab,
+}
+
+var controlKeys = map[byte]KeyType{
+	0x01: KeyCtrlA,
+	0x03: KeyCtrlC,
+	0x05: KeyCtrlE,
+	0x08: KeyBackspace,
+	0x09: KeyTab,
+	0x0a: KeyEnter,
+	0x0b: KeyCtrlK,
+	0x0d: KeyEnter,
+	0x15: KeyCtrlU,
+	0x17: KeyCtrlW,
+	0x7f: KeyBackspace,
+}
+
+// parseKeys splits a chunk of terminal input into key presses
+func parseKeys(input []byte) []KeyMsg {
+	var keys []KeyMsg
+	var text []rune
+	flush := func() {
+		if len(text) > 0 {
+			keys = append(keys, KeyMsg{Type: KeyRune, Runes: text})
+			text = nil
+		}
+	}
+
+	for len(input) > 0 {
+		b := input[0]
+		switch {
+		case b == 0x1b:
+			flush()
+			if key, n := parseEscape(input[1:]); n > 0 {
+				keys = append(keys, KeyMsg{Type: key})
+				input = input[1+n:]
+				continue
+			}
+			keys = append(keys, KeyMsg{Type: KeyEsc})
+			input = input[1:]
+		case b < 0x20 || b == 0x7f:
+			flush()
+			if key, ok := controlKeys[b]; ok {
+				keys = append(keys, KeyMsg{Type: key})
+			}
+			input = input[1:]
+		default:
+			r, size := utf8.DecodeRune(input)
+			text = append(text, r)
+			input = input[size:]
+		}
+	}
+	flush()
+	return keys
+}
+
+// parseEscape matches the start of input against the known escape
+// sequences and returns the key and the number of bytes consumed
+func parseEscape(input []byte) (KeyType, int) {
+	for seq, key := range escapeKeys {
+		if strings.HasPrefix(string(input), seq) {
+			return key, len(seq)
+		}
+	}
+	return 0, 0
+}
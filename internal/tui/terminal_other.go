@@ -0,0 +1,32 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package tui
+
+import (
+	"errors"
+	"time"
+)
+
+// Raw terminal input is only implemented for unix terminals and the Windows
+// console; elsewhere Run reports ErrNotTerminal and callers fall back to
+// line-based prompts
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func enterRaw(fd int) (func(), error) {
+	return nil, errors.ErrUnsupported
+}
+
+func terminalSize(fd int) (width, height int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
+
+func readTimeout(fd int, buf []byte, timeout time.Duration) (int, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func watchResize(fn func()) func() {
+	return func() {}
+}
@@ -0,0 +1,151 @@
+//go:build windows
+
+package tui
+
+import (
+	"errors"
+	"os"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	keyEvent               = 0x0001 // INPUT_RECORD event type of a key press or release
+	maxInputRecordsPerRead = 64
+	resizePollInterval     = 250 * time.Millisecond
+)
+
+// Console input is read as records rather than with ReadFile, which blocks
+// on focus and mouse events until a key is pressed and so can't be stopped
+var procReadConsoleInputW = windows.NewLazySystemDLL("kernel32.dll").NewProc("ReadConsoleInputW")
+
+var errNoVirtualTerminal = errors.New("the console does not support virtual terminal sequences; use --plain")
+
+// inputRecord is an INPUT_RECORD; event holds a KEY_EVENT_RECORD for key
+// events
+type inputRecord struct {
+	eventType uint16
+	_         uint16
+	event     [16]byte
+}
+
+// keyEventRecord is a KEY_EVENT_RECORD
+type keyEventRecord struct {
+	keyDown         int32
+	repeatCount     uint16
+	virtualKeyCode  uint16
+	virtualScanCode uint16
+	unicodeChar     uint16
+	controlKeyState uint32
+}
+
+func isTerminal(fd int) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(fd), &mode) == nil
+}
+
+// enterRaw turns off line input and echo on the console and has it send
+// and interpret the same escape sequences as a unix terminal, so the
+// program can handle both alike. It returns a function restoring the
+// previous modes.
+func enterRaw(fd int) (func(), error) {
+	in := windows.Handle(fd)
+	var inMode uint32
+	if err := windows.GetConsoleMode(in, &inMode); err != nil {
+		return nil, err
+	}
+	out := windows.Handle(os.Stdout.Fd())
+	var outMode uint32
+	if err := windows.GetConsoleMode(out, &outMode); err != nil {
+		return nil, err
+	}
+
+	raw := inMode&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_LINE_INPUT|windows.ENABLE_PROCESSED_INPUT) | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(in, raw); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(out, outMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING|windows.DISABLE_NEWLINE_AUTO_RETURN); err != nil {
+		// Consoles before Windows 10 would print the sequences as text
+		windows.SetConsoleMode(in, inMode)
+		return nil, errNoVirtualTerminal
+	}
+	return func() {
+		windows.SetConsoleMode(in, inMode)
+		windows.SetConsoleMode(out, outMode)
+	}, nil
+}
+
+func terminalSize(fd int) (width, height int, err error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(fd), &info); err != nil {
+		return 0, 0, err
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1, nil
+}
+
+// readTimeout reads the keys typed within timeout; it returns no bytes if
+// there were none
+func readTimeout(fd int, buf []byte, timeout time.Duration) (int, error) {
+	in := windows.Handle(fd)
+	event, err := windows.WaitForSingleObject(in, uint32(timeout.Milliseconds()))
+	if err != nil {
+		return 0, err
+	}
+	if event != windows.WAIT_OBJECT_0 {
+		return 0, nil
+	}
+
+	// Each record is at most one UTF-16 unit, so up to 3 bytes
+	records := make([]inputRecord, min(maxInputRecordsPerRead, len(buf)/3))
+	var n uint32
+	ok, _, err := procReadConsoleInputW.Call(uintptr(in), uintptr(unsafe.Pointer(&records[0])), uintptr(len(records)), uintptr(unsafe.Pointer(&n)))
+	if ok == 0 {
+		return 0, err
+	}
+	return copy(buf, keyInput(records[:n])), nil
+}
+
+// keyInput returns the characters typed in records, as UTF-8. With virtual
+// terminal input on, special keys arrive as the characters of their escape
+// sequences; releases and other events carry none.
+func keyInput(records []inputRecord) []byte {
+	var units []uint16
+	for i := range records {
+		if records[i].eventType != keyEvent {
+			continue
+		}
+		key := (*keyEventRecord)(unsafe.Pointer(&records[i].event))
+		if key.keyDown != 0 && key.unicodeChar != 0 {
+			units = append(units, key.unicodeChar)
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// watchResize calls fn whenever the console window is resized. Windows has
+// no SIGWINCH, and resize records only arrive in the input stream, so the
+// size is polled.
+func watchResize(fn func()) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(resizePollInterval)
+		defer ticker.Stop()
+		width, height, _ := terminalSize(int(os.Stdout.Fd()))
+		for {
+			select {
+			case <-ticker.C:
+				w, h, err := terminalSize(int(os.Stdout.Fd()))
+				if err == nil && (w != width || h != height) {
+					width, height = w, h
+					fn()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
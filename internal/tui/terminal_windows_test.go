@@ -0,0 +1,37 @@
+//go:build windows
+
+package tui
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestKeyInput(t *testing.T) {
+	record := func(eventType uint16, down bool, char rune) inputRecord {
+		r := inputRecord{eventType: eventType}
+		key := keyEventRecord{repeatCount: 1, unicodeChar: uint16(char)}
+		if down {
+			key.keyDown = 1
+		}
+		*(*keyEventRecord)(unsafe.Pointer(&r.event)) = key
+		return r
+	}
+	records := []inputRecord{
+		record(keyEvent, true, 'a'),
+		record(keyEvent, false, 'a'),   // Release
+		record(0x0010, true, 'x'),      // Focus event
+		record(keyEvent, true, '\x1b'), // Up arrow, as its escape sequence
+		record(keyEvent, true, '['),
+		record(keyEvent, true, 'A'),
+		record(keyEvent, true, 'é'),
+		record(keyEvent, true, 0), // Shift on its own
+	}
+	var got []string
+	for _, key := range parseKeys(keyInput(records)) {
+		got = append(got, key.String())
+	}
+	if want := []string{"a", "up", "é"}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("keys = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+// Package tui is a small terminal UI runtime in the Elm style: a Model
+// handles messages in Update, renders itself in View, and describes any
+// slow or blocking work as a Cmd that the Program runs in the background.
+//
+// It follows bubbletea's design but covers only what the config wizard
+// needs (keys, lists, text inputs, a spinner), on unix terminals and the
+// Windows 10+ console, using golang.org/x/sys alone. That keeps the
+// charmbracelet module tree, about a dozen modules, out of a server
+// binary that only uses a terminal UI for setup.
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotTerminal is returned by Run when stdin or stdout is not a terminal
+var ErrNotTerminal = errors.New("not a terminal")
+
+// Msg is anything delivered to a model's Update
+type Msg interface{}
+
+// Cmd does work outside the update loop and returns the resulting message.
+// A nil Cmd does nothing.
+type Cmd func() Msg
+
+// Model is a screen driven by the Program
+type Model interface {
+	// Init returns the first command to run, if any
+	Init() Cmd
+	// Update handles a message and returns the new model
+	Update(Msg) (Model, Cmd)
+	// View renders the model; lines are separated by "\n"
+	View() string
+}
+
+// WindowSizeMsg reports the terminal size, on start and on every resize
+type WindowSizeMsg struct {
+	Width  int
+	Height int
+}
+
+type quitMsg struct{}
+
+// Quit is a Cmd that stops the program after the current update
+func Quit() Msg {
+	return quitMsg{}
+}
+
+type batchMsg []Cmd
+
+// Batch runs several commands concurrently
+func Batch(cmds ...Cmd) Cmd {
+	return func() Msg {
+		return batchMsg(cmds)
+	}
+}
+
+// Tick returns a command that sends fn's message after d
+func Tick(d time.Duration, fn func(time.Time) Msg) Cmd {
+	return func() Msg {
+		return fn(<-time.After(d))
+	}
+}
+
+type execMsg struct {
+	fn   func() error
+	done func(error) Msg
+}
+
+// Exec suspends the UI, restoring the terminal so fn can use stdin and
+// stdout normally, then resumes and delivers done's message
+func Exec(fn func() error, done func(error) Msg) Cmd {
+	return func() Msg {
+		return execMsg{fn: fn, done: done}
+	}
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	return isTerminal(int(f.Fd()))
+}
+
+// Program runs a Model on the terminal
+type Program struct {
+	model Model
+	in    *os.File
+	out   io.Writer
+	msgs  chan Msg
+
+	lastView string
+}
+
+// NewProgram returns a program running m on stdin and stdout
+func NewProgram(m Model) *Program {
+	return &Program{
+		model: m,
+		in:    os.Stdin,
+		out:   os.Stdout,
+		msgs:  make(chan Msg, 64),
+	}
+}
+
+// Run takes over the terminal until a Quit command and returns the final
+// model
+func (p *Program) Run() (Model, error) {
+	if !IsTerminal(p.in) || !IsTerminal(os.Stdout) {
+		return p.model, ErrNotTerminal
+	}
+
+	fd := int(p.in.Fd())
+	restore, err := enterRaw(fd)
+	if err != nil {
+		return p.model, fmt.Errorf("failed to configure terminal: %w", err)
+	}
+	defer func() { restore() }()
+	p.enterScreen()
+	defer p.leaveScreen()
+
+	stopInput := p.readInput(fd)
+	defer func() { stopInput() }()
+	stopResize := watchResize(func() { p.sendSize() })
+	defer stopResize()
+
+	p.sendSize()
+	p.run(p.model.Init())
+	p.render()
+
+	for msg := range p.msgs {
+		switch msg := msg.(type) {
+		case quitMsg:
+			return p.model, nil
+		case batchMsg:
+			for _, cmd := range msg {
+				p.run(cmd)
+			}
+			continue
+		case execMsg:
+			// Give the terminal back while fn runs
+			stopInput()
+			p.leaveScreen()
+			restore()
+			execErr := msg.fn()
+			if restore, err = enterRaw(fd); err != nil {
+				return p.model, fmt.Errorf("failed to configure terminal: %w", err)
+			}
+			p.enterScreen()
+			stopInput = p.readInput(fd)
+			p.lastView = ""
+			if msg.done != nil {
+				p.run(func() Msg { return msg.done(execErr) })
+			}
+			p.render()
+			continue
+		case WindowSizeMsg:
+			p.lastView = ""
+		}
+
+		var cmd Cmd
+		p.model, cmd = p.model.Update(msg)
+		p.run(cmd)
+		p.render()
+	}
+	return p.model, nil
+}
+
+// run executes cmd in the background and delivers its message
+func (p *Program) run(cmd Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		if msg := cmd(); msg != nil {
+			p.msgs <- msg
+		}
+	}()
+}
+
+func (p *Program) sendSize() {
+	if width, height, err := terminalSize(int(os.Stdout.Fd())); err == nil {
+		p.msgs <- WindowSizeMsg{Width: width, Height: height}
+	}
+}
+
+// readInput delivers key presses until the returned function is called
+func (p *Program) readInput(fd int) func() {
+	var (
+		once sync.Once
+		stop = make(chan struct{})
+		done = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		buf := make([]byte, 256)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			n, err := readTimeout(fd, buf, 100*time.Millisecond)
+			if err != nil {
+				return
+			}
+			for _, key := range parseKeys(buf[:n]) {
+				select {
+				case p.msgs <- key:
+				case <-stop:
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+		})
+	}
+}
+
+func (p *Program) enterScreen() {
+	// Alternate screen, hidden cursor
+	io.WriteString(p.out, "\x1b[?1049h\x1b[?25l")
+}
+
+func (p *Program) leaveScreen() {
+	io.WriteString(p.out, "\x1b[?25h\x1b[?1049l")
+}
+
+// render redraws the screen if the view changed
+func (p *Program) render() {
+	view := p.model.View()
+	if view == p.lastView {
+		return
+	}
+	p.lastView = view
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range strings.Split(view, "\n") {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+		b.WriteString("\x1b[K") // Clear what is left of the previous frame
+	}
+	b.WriteString("\x1b[J")
+	io.WriteString(p.out, b.String())
+}
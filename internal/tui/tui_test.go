@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	var got []string
+	for _, key := range parseKeys([]byte("ab\x1b[A\x1b[Z\r\x7f\x1b\x03é ")) {
+		got = append(got, key.String())
+	}
+	want := []string{"ab", "up", "shift+tab", "enter", "backspace", "esc", "ctrl+c", "é "}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys() = %q, want %q", got, want)
+	}
+	if key := parseKeys([]byte(" "))[0]; key.String() != "space" {
+		t.Errorf("space = %q", key.String())
+	}
+}
+
+func TestTextInput(t *testing.T) {
+	var input TextInput
+	for _, key := range parseKeys([]byte("helo\x1b[Dl\x1b[F!")) {
+		input.Update(key)
+	}
+	if got := input.String(); got != "hello!" {
+		t.Errorf("String() = %q, want hello!", got)
+	}
+	input.Update(KeyMsg{Type: KeyCtrlW})
+	if got := input.String(); got != "" {
+		t.Errorf("after ctrl+w String() = %q", got)
+	}
+}
+
+func TestListFilterAndToggle(t *testing.T) {
+	list := List{Multi: true, Filter: true, Items: []ListItem{{Label: "gpt-4o"}, {Label: "gpt-4o-mini"}, {Label: "o3"}}}
+	list.Update(KeyMsg{Type: KeyRune, Runes: []rune("mini")})
+	if visible := list.Visible(); !reflect.DeepEqual(visible, []int{1}) {
+		t.Fatalf("Visible() = %v, want [1]", visible)
+	}
+	list.Update(KeyMsg{Type: KeyRune, Runes: []rune{' '}})
+	if checked := list.Checked(); !reflect.DeepEqual(checked, []string{"gpt-4o-mini"}) {
+		t.Errorf("Checked() = %v", checked)
+	}
+
+	list.SetQuery("")
+	list.Update(KeyMsg{Type: KeyEnd})
+	if list.Selected() != 2 {
+		t.Errorf("Selected() after end = %d, want 2", list.Selected())
+	}
+}
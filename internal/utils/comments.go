@@ -0,0 +1,26 @@
+package utils
+
+// CommentDelimiters returns the line comment syntax for a language as returned
+// by GetLanguageFromFile. ok is false for formats without comments (e.g. JSON).
+func CommentDelimiters(language string) (prefix, suffix string, ok bool) {
+	switch language {
+	case "javascript", "typescript", "go", "java", "cpp", "c", "csharp", "php",
+		"swift", "kotlin", "rust", "scss", "less":
+		return "// ", "", true
+	case "python", "ruby", "bash", "zsh", "fish", "powershell", "yaml", "toml",
+		"dockerfile", "makefile", "config", "git", "env":
+		return "# ", "", true
+	case "sql":
+		return "-- ", "", true
+	case "ini":
+		return "; ", "", true
+	case "batch":
+		return "REM ", "", true
+	case "css":
+		return "/* ", " */", true
+	case "html", "xml", "markdown":
+		return "<!-- ", " -->", true
+	default:
+		return "", "", false
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// maxFileCacheBytes bounds the content kept by ReadFileContentCached
+const maxFileCacheBytes = 64 << 20
+
+// cachedFile is a file's content as of its modification time and size
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	content string
+}
+
+var fileCache = struct {
+	sync.Mutex
+	files map[string]*cachedFile
+	bytes int64
+}{files: make(map[string]*cachedFile)}
+
+// ReadFileContentCached is ReadFileContent for files read repeatedly while
+// serving one request, such as context files sent to every provider attempt:
+// a file whose modification time and size are unchanged is not read again
+func ReadFileContentCached(filePath string) (string, error) {
+	if filePath == "" {
+		return "", nil
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	fileCache.Lock()
+	cached, ok := fileCache.files[filePath]
+	fileCache.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.content, nil
+	}
+
+	content, err := ReadFileContent(filePath)
+	if err != nil {
+		return "", err
+	}
+	storeCachedFile(filePath, &cachedFile{modTime: info.ModTime(), size: info.Size(), content: content})
+	return content, nil
+}
+
+// PrefetchFiles reads files into the ReadFileContentCached cache
+// concurrently, so the reads that follow only check modification times
+func PrefetchFiles(filePaths []string) {
+	var wg sync.WaitGroup
+	for _, filePath := range filePaths {
+		if filePath == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ReadFileContentCached(filePath)
+		}()
+	}
+	wg.Wait()
+}
+
+// storeCachedFile caches file, dropping other files if the cache is full
+func storeCachedFile(filePath string, file *cachedFile) {
+	if int64(len(file.content)) > maxFileCacheBytes/4 {
+		return // Not worth evicting everything else for
+	}
+	fileCache.Lock()
+	defer fileCache.Unlock()
+	if old, ok := fileCache.files[filePath]; ok {
+		fileCache.bytes -= int64(len(old.content))
+	}
+	for path, other := range fileCache.files {
+		if fileCache.bytes+int64(len(file.content)) <= maxFileCacheBytes {
+			break
+		}
+		delete(fileCache.files, path)
+		fileCache.bytes -= int64(len(other.content))
+	}
+	fileCache.files[filePath] = file
+	fileCache.bytes += int64(len(file.content))
+}
+
+// forgetCachedFile drops filePath from the cache after this process writes it
+func forgetCachedFile(filePath string) {
+	fileCache.Lock()
+	defer fileCache.Unlock()
+	if old, ok := fileCache.files[filePath]; ok {
+		fileCache.bytes -= int64(len(old.content))
+		delete(fileCache.files, filePath)
+	}
+}
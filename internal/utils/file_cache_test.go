@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadFileContentCached(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	os.WriteFile(a, []byte("package a\n"), 0600)
+	os.WriteFile(b, []byte("package b\n"), 0600)
+	PrefetchFiles([]string{a, b, filepath.Join(dir, "missing.go")})
+
+	// Same modification time and size: served from the cache, not the disk
+	info, _ := os.Stat(a)
+	os.WriteFile(a, []byte("package x\n"), 0600)
+	os.Chtimes(a, info.ModTime(), info.ModTime())
+	if got, _ := ReadFileContentCached(a); got != "package a\n" {
+		t.Errorf("unchanged file re-read: %q", got)
+	}
+
+	// A newer modification time is read again
+	os.Chtimes(a, info.ModTime(), info.ModTime().Add(time.Second))
+	if got, _ := ReadFileContentCached(a); got != "package x\n" {
+		t.Errorf("changed file not re-read: %q", got)
+	}
+
+	// Writes through WriteFileContent are seen even within one mtime tick
+	info, _ = os.Stat(b)
+	WriteFileContent(b, "package y\n")
+	os.Chtimes(b, info.ModTime(), info.ModTime())
+	if got, _ := ReadFileContentCached(b); got != "package y\n" {
+		t.Errorf("written file not re-read: %q", got)
+	}
+
+	if got, err := ReadFileContentCached(filepath.Join(dir, "missing.go")); got != "" || err != nil {
+		t.Errorf("missing file = %q, %v; want empty", got, err)
+	}
+}
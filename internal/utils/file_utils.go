@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
 	"strings"
@@ -24,19 +28,112 @@ func ReadFileContent(filePath string) (string, error) {
 	return string(content), nil
 }
 
-// WriteFileContent writes content to a file
+// WriteFileContent writes content to a file atomically: it is written to a
+// temporary file in the same directory, synced and renamed over the target,
+// so a crash leaves either the old or the new content. An existing file
+// keeps its mode and owner; a new one gets 0666 less the umask. If the owner
+// can't be kept (only root may give files away), the file is rewritten in
+// place instead.
 func WriteFileContent(filePath, content string) error {
 	if filePath == "" {
 		return nil
 	}
+	defer forgetCachedFile(filePath)
+
+	// Replace the file a symlink points to, not the symlink
+	target := filePath
+	if resolved, err := filepath.EvalSymlinks(filePath); err == nil {
+		target = resolved
+	}
 
 	// Ensure the directory exists
-	dir := filepath.Dir(filePath)
+	dir := filepath.Dir(target)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	return os.WriteFile(filePath, []byte(content), 0644)
+	info, err := os.Stat(target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	tmp, err := createTempBeside(target)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if info != nil {
+		// An explicit chmod, as the umask applied when creating the file
+		// would clear bits the original has
+		if err := tmp.Chmod(info.Mode().Perm() | info.Mode()&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+			return fail(err)
+		}
+		if err := preserveOwner(tmp, info); err != nil {
+			fail(err)
+			return writeInPlace(target, content)
+		}
+	}
+	if _, err := tmp.WriteString(content); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	// The rename is durable once the directory is synced
+	syncDir(dir)
+	return nil
+}
+
+// createTempBeside creates a new hidden temporary file next to path. It is
+// created with mode 0666 so the umask applies, as it would to path.
+func createTempBeside(path string) (*os.File, error) {
+	dir, base := filepath.Split(path)
+	for range 100 {
+		name := filepath.Join(dir, fmt.Sprintf(".%s.%d.tmp", base, rand.Uint32()))
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+	return nil, fmt.Errorf("failed to create a temporary file for %s", path)
+}
+
+// writeInPlace overwrites path's content and syncs it, keeping the file
+// itself and so its owner
+func writeInPlace(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ContentChecksum returns the hex-encoded SHA-256 of content
+func ContentChecksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
 }
 
 // GetLanguageFromFile determines the programming language from a file path
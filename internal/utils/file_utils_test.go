@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileContentKeepsMode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.sh")
+	os.WriteFile(path, []byte("echo old\n"), 0600)
+	os.Chmod(path, 0750)
+
+	if err := WriteFileContent(path, "echo new\n"); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("mode = %v, want 0750 kept", info.Mode().Perm())
+	}
+	if got, _ := os.ReadFile(path); string(got) != "echo new\n" {
+		t.Errorf("content = %q", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want no temporary files left", len(entries))
+	}
+}
+
+func TestWriteFileContentNewFileUsesUmask(t *testing.T) {
+	dir := t.TempDir()
+	reference := filepath.Join(dir, "reference")
+	f, err := os.Create(reference) // 0666 less the umask
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	path := filepath.Join(dir, "sub", "new.go")
+	if err := WriteFileContent(path, "package sub\n"); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.Stat(reference)
+	got, _ := os.Stat(path)
+	if got.Mode() != want.Mode() {
+		t.Errorf("mode = %v, want %v", got.Mode(), want.Mode())
+	}
+}
+
+func TestWriteFileContentThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target, link := filepath.Join(dir, "target.go"), filepath.Join(dir, "link.go")
+	os.WriteFile(target, []byte("old\n"), 0600)
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	if err := WriteFileContent(link, "new\n"); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink replaced by a regular file")
+	}
+	if got, _ := os.ReadFile(target); string(got) != "new\n" {
+		t.Errorf("target content = %q, want new", got)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package utils
+
+import "os"
+
+// preserveOwner is a no-op where files have no unix owner
+func preserveOwner(f *os.File, info os.FileInfo) error {
+	return nil
+}
+
+// syncDir is a no-op where directories can't be synced
+func syncDir(dir string) error {
+	return nil
+}
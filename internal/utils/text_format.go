@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Byte order marks of the encodings a modified file keeps
+const (
+	bomUTF8    = "\xef\xbb\xbf"
+	bomUTF16LE = "\xff\xfe"
+	bomUTF16BE = "\xfe\xff"
+)
+
+// TextFormat is how a text file is laid out on disk, apart from its text
+type TextFormat struct {
+	BOM          string // Byte order mark, "" for none; a UTF-16 one means UTF-16
+	CRLF         bool   // Lines end in \r\n
+	FinalNewline bool   // The last line ends in a newline
+}
+
+// DetectTextFormat returns the format of a file's raw content. Lines end in
+// \r\n if most of them do; files with no line breaks are taken to end lines
+// in \n.
+func DetectTextFormat(content string) TextFormat {
+	format := TextFormat{BOM: byteOrderMark(content)}
+	text := decodeText(content, format.BOM)
+	crlf := strings.Count(text, "\r\n")
+	format.CRLF = crlf > 0 && crlf > strings.Count(text, "\n")-crlf
+	format.FinalNewline = strings.HasSuffix(text, "\n")
+	return format
+}
+
+// DecodeText returns a file's raw content as text to work on: UTF-8 without
+// a byte order mark, with \n line ends. Apply on the returned format lays
+// edited text out as the file was.
+func DecodeText(content string) (string, TextFormat) {
+	format := DetectTextFormat(content)
+	return strings.ReplaceAll(decodeText(content, format.BOM), "\r\n", "\n"), format
+}
+
+// Apply lays out text, as generated with \n line ends, in the format
+func (f TextFormat) Apply(text string) string {
+	// Generated text may already carry a byte order mark, e.g. when merged
+	// into the existing content
+	text = strings.TrimPrefix(text, bomUTF8)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if f.FinalNewline && text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	} else if !f.FinalNewline {
+		text = strings.TrimSuffix(text, "\n")
+	}
+	if f.CRLF {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+
+	switch f.BOM {
+	case bomUTF16LE, bomUTF16BE:
+		return f.BOM + encodeUTF16(text, f.BOM == bomUTF16BE)
+	default:
+		return f.BOM + text
+	}
+}
+
+// MatchTextFormat returns generated laid out like existing, the raw content
+// of the file it replaces: same line ends, final newline and byte order mark
+// (and UTF-16 encoding). Regenerated files would otherwise turn every line
+// of a CRLF file into a change. New files, where existing is "", are left as
+// generated.
+func MatchTextFormat(existing, generated string) string {
+	if existing == "" {
+		return generated
+	}
+	return DetectTextFormat(existing).Apply(generated)
+}
+
+// byteOrderMark returns the byte order mark content starts with, if any
+func byteOrderMark(content string) string {
+	for _, bom := range []string{bomUTF8, bomUTF16LE, bomUTF16BE} {
+		if strings.HasPrefix(content, bom) {
+			return bom
+		}
+	}
+	return ""
+}
+
+// decodeText returns content without its byte order mark, as UTF-8
+func decodeText(content, bom string) string {
+	content = strings.TrimPrefix(content, bom)
+	if bom != bomUTF16LE && bom != bomUTF16BE {
+		return content
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if bom == bomUTF16BE {
+		order = binary.BigEndian
+	}
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = order.Uint16([]byte(content[2*i : 2*i+2]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// encodeUTF16 encodes UTF-8 text as UTF-16 without a byte order mark
+func encodeUTF16(text string, bigEndian bool) string {
+	var order binary.AppendByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	buf := make([]byte, 0, 2*utf8.RuneCountInString(text))
+	for _, unit := range utf16.Encode([]rune(text)) {
+		buf = order.AppendUint16(buf, unit)
+	}
+	return string(buf)
+}
@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestMatchTextFormat(t *testing.T) {
+	generated := "package main\n\nfunc main() {}\n"
+	tests := []struct {
+		name, existing, want string
+	}{
+		{"new file", "", generated},
+		{"lf", "package main\n", generated},
+		{"crlf", "package main\r\n\r\nfunc old() {}\r\n", "package main\r\n\r\nfunc main() {}\r\n"},
+		{"no final newline", "package main\n\nfunc old() {}", "package main\n\nfunc main() {}"},
+		{"utf-8 bom", bomUTF8 + "package main\r\n", bomUTF8 + "package main\r\n\r\nfunc main() {}\r\n"},
+		{"mostly lf", "a\r\nb\nc\nd\n", generated},
+	}
+	for _, tt := range tests {
+		if got := MatchTextFormat(tt.existing, generated); got != tt.want {
+			t.Errorf("%s: MatchTextFormat() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTextFormatUTF16(t *testing.T) {
+	existing := bomUTF16LE + encodeUTF16("x = 1\r\n", false)
+	got := MatchTextFormat(existing, "x = 2\n")
+	if want := bomUTF16LE + "x\x00 \x00=\x00 \x002\x00\r\x00\n\x00"; got != want {
+		t.Errorf("MatchTextFormat() = %q, want %q", got, want)
+	}
+	if format := DetectTextFormat(got); !format.CRLF || format.BOM != bomUTF16LE || decodeText(got, format.BOM) != "x = 2\r\n" {
+		t.Errorf("DetectTextFormat() = %+v", format)
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	raw := bomUTF16BE + encodeUTF16("x = 1\r\ny = 2\r\n", true)
+	text, format := DecodeText(raw)
+	if text != "x = 1\ny = 2\n" || format.BOM != bomUTF16BE || !format.CRLF {
+		t.Fatalf("DecodeText() = %q, %+v", text, format)
+	}
+	if got := format.Apply(text); got != raw {
+		t.Errorf("Apply(DecodeText()) = %q, want the raw content %q", got, raw)
+	}
+}
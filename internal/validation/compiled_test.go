@@ -0,0 +1,48 @@
+package validation
+
+import "testing"
+
+func TestCompilerErrorsKeepOnlySyntax(t *testing.T) {
+	rust := (&RustValidator{}).parseErrors("a.rs:3:14: error: expected `;`, found `println`: help: add `;` here\n" +
+		"a.rs:1:5: error[E0432]: unresolved import `foo`\n" +
+		"a.rs:4:19: error: cannot find macro `anyhow` in this scope\n" +
+		"error: aborting due to 3 previous errors\n")
+	if len(rust) != 1 || rust[0].Line != 3 || rust[0].Column != 14 {
+		t.Errorf("rust errors = %+v", rust)
+	}
+
+	java := (&JavaValidator{}).parseErrors("/tmp/v/Foo.java:3: error: ';' expected\n" +
+		"        int x = 5\n" +
+		"                 ^\n" +
+		"/tmp/v/Foo.java:5: error: cannot find symbol\n" +
+		"        Bar b;\n" +
+		"        ^\n" +
+		"2 errors\n")
+	if len(java) != 1 || java[0].Line != 3 || java[0].Column != 18 || java[0].Message != "';' expected" {
+		t.Errorf("java errors = %+v", java)
+	}
+
+	cpp := (&CPPValidator{Language: LanguageCPP}).parseErrors("b.cpp:2:1: error: 'Foo' does not name a type\n" +
+		"b.cpp:5:3: error: expected ',' or ';' before 'return'\n" +
+		"b.cpp:9:10: fatal error: local.h: No such file or directory\n" +
+		"b.cpp:12:1: error: expected '}' at end of input\n")
+	if len(cpp) != 1 || cpp[0].Line != 5 {
+		t.Errorf("cpp errors = %+v", cpp)
+	}
+}
+
+func TestRubyParseErrors(t *testing.T) {
+	classic := (&RubyValidator{}).parseErrors("/tmp/validate-1.rb:4: syntax error, unexpected end-of-input, expecting `end'\n")
+	if len(classic) != 1 || classic[0].Line != 4 {
+		t.Errorf("classic ruby errors = %+v", classic)
+	}
+
+	prism := (&RubyValidator{}).parseErrors("/tmp/validate-1.rb:3: syntax errors found (SyntaxError)\n" +
+		"  1 | def f\n" +
+		"  2 |   x = 1\n" +
+		"> 3 | \n" +
+		"    | ^ expected an `end` to close the `def` statement\n")
+	if len(prism) != 1 || prism[0].Line != 3 || prism[0].Message != "expected an `end` to close the `def` statement" {
+		t.Errorf("prism ruby errors = %+v", prism)
+	}
+}
@@ -0,0 +1,145 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CPPValidator validates C and C++ code syntax
+type CPPValidator struct {
+	Language Language // LanguageC or LanguageCPP
+}
+
+// cppDiagnostic matches clang and gcc output: file:line:col: error: message
+var cppDiagnostic = regexp.MustCompile(`(?m)^(.+?):(\d+):(\d+): (fatal )?error: (.+)$`)
+
+// cppSyntaxErrors are fragments of clang and gcc parser messages. Without the
+// project's build flags, declarations from other files are often unknown, so
+// only these are treated as failures.
+var cppSyntaxErrors = []string{
+	"expected",
+	"unterminated",
+	"missing terminating",
+	"extraneous closing brace",
+	"stray '",
+	"unmatched",
+}
+
+// Validate checks C/C++ syntax using clang (or gcc) -fsyntax-only
+func (v *CPPValidator) Validate(code string, filePath string) (*ValidationResult, error) {
+	compiler := v.compiler()
+	if compiler == "" {
+		// No C/C++ compiler available, skip validation
+		return &ValidationResult{Valid: true, Errors: nil}, nil
+	}
+
+	fallback := "validate.cpp"
+	if v.Language == LanguageC {
+		fallback = "validate.c"
+	}
+	srcPath, tmpDir, err := writeTempSource(code, filePath, fallback)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	args := []string{"-fsyntax-only", "-fno-diagnostics-show-caret", "-w"}
+	// Resolve includes relative to the original file, as its build would
+	if filePath != "" {
+		if dir, err := filepath.Abs(filepath.Dir(filePath)); err == nil {
+			args = append(args, "-I", dir)
+		}
+	}
+	// Headers (.h) are checked as C++, which accepts nearly all C declarations
+	if v.Language == LanguageC && !strings.EqualFold(filepath.Ext(filePath), ".h") {
+		args = append(args, "-x", "c")
+	} else {
+		args = append(args, "-x", "c++")
+	}
+	args = append(args, srcPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), compileTimeout)
+	defer cancel()
+
+	// Run the compiler to check syntax with timeout
+	cmd := exec.CommandContext(ctx, compiler, args...)
+	output, err := cmd.CombinedOutput()
+
+	// Check for timeout
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s validation timeout exceeded (%s)", v.Language, compileTimeout)
+	}
+
+	if err == nil {
+		return &ValidationResult{Valid: true, Errors: nil}, nil
+	}
+
+	errors := v.parseErrors(string(output))
+	return &ValidationResult{Valid: len(errors) == 0, Errors: errors}, nil
+}
+
+// compiler returns the first available compiler for the language, or ""
+func (v *CPPValidator) compiler() string {
+	candidates := []string{"clang++", "g++"}
+	if v.Language == LanguageC {
+		candidates = []string{"clang", "gcc"}
+	}
+	toolCache := GetToolCache()
+	for _, candidate := range candidates {
+		if toolCache.IsAvailable(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// CanAutoFix returns false - formatting cannot fix C/C++ syntax errors
+func (v *CPPValidator) CanAutoFix() bool {
+	return false
+}
+
+// AutoFix is not implemented for C/C++
+func (v *CPPValidator) AutoFix(code string) (string, error) {
+	return "", fmt.Errorf("auto-fix not supported for %s", v.Language)
+}
+
+// parseErrors parses clang/gcc error messages, keeping only syntax errors. A
+// fatal error (usually a missing header) stops the compiler, so the rest of
+// the file is left unchecked.
+func (v *CPPValidator) parseErrors(output string) []ValidationError {
+	var errors []ValidationError
+
+	for _, match := range cppDiagnostic.FindAllStringSubmatch(output, -1) {
+		fatal, message := match[4] != "", match[5]
+		if fatal {
+			break
+		}
+		if !isCPPSyntaxError(message) {
+			continue
+		}
+		lineNum, _ := strconv.Atoi(match[2])
+		colNum, _ := strconv.Atoi(match[3])
+		errors = append(errors, ValidationError{
+			Line:    lineNum,
+			Column:  colNum,
+			Message: message,
+		})
+	}
+
+	return errors
+}
+
+func isCPPSyntaxError(message string) bool {
+	for _, fragment := range cppSyntaxErrors {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package validation
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// defaultFormatters are the formatters tried per language, in order of
+// preference; the first one installed is used
+var defaultFormatters = map[Language][][]string{
+	LanguageGo:         {{"goimports"}, {"gofmt"}},
+	LanguagePython:     {{"black", "--quiet", "-"}, {"ruff", "format", "-"}},
+	LanguageJavaScript: {{"prettier", "--stdin-filepath", "{file}"}},
+	LanguageTypeScript: {{"prettier", "--stdin-filepath", "{file}"}},
+	LanguageRust:       {{"rustfmt", "--edition", "2021"}},
+}
+
+// ProjectFormatting returns cfg with the project's formatters. A project file
+// comes with the repository, so it may only pick commands the user
+// configured or the built-in ones; any other command is refused rather than
+// run on the next write.
+func ProjectFormatting(cfg config.FormattingConfig, project *config.ProjectConfig) config.FormattingConfig {
+	if project == nil || len(project.Formatters) == 0 {
+		return cfg
+	}
+	commands := make(map[string][]string, len(cfg.Commands)+len(project.Formatters))
+	for language, command := range cfg.Commands {
+		commands[language] = command
+	}
+	applied := false
+	for language, command := range project.Formatters {
+		if !trustedFormatter(cfg, command) {
+			logger.Warnf("Ignoring %s formatter %q from %s: not a configured or built-in formatter", language, command, project.Root)
+			continue
+		}
+		commands[language] = command
+		applied = true
+	}
+	if applied {
+		cfg.Enabled = true
+		cfg.Commands = commands
+	}
+	return cfg
+}
+
+// trustedFormatter reports whether command is, argument for argument, one of
+// the user's formatting commands or a built-in formatter. An empty command,
+// turning formatting off for a language, runs nothing.
+func trustedFormatter(cfg config.FormattingConfig, command []string) bool {
+	if len(command) == 0 {
+		return true
+	}
+	for _, configured := range cfg.Commands {
+		if slices.Equal(configured, command) {
+			return true
+		}
+	}
+	for _, candidates := range defaultFormatters {
+		for _, builtin := range candidates {
+			if slices.Equal(builtin, command) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FormatterFor returns the formatter command for filePath's language with
+// {file} replaced, or nil if formatting is off, the language has none, or
+// it isn't installed
+func FormatterFor(cfg config.FormattingConfig, filePath string) []string {
+	if !cfg.Enabled {
+		return nil
+	}
+	language := DetectLanguage(filePath)
+
+	candidates := defaultFormatters[language]
+	if command, ok := cfg.Commands[language.String()]; ok {
+		candidates = [][]string{command}
+	}
+	for _, command := range candidates {
+		if len(command) == 0 || !GetToolCache().IsAvailable(command[0]) {
+			continue
+		}
+		args := make([]string, len(command))
+		for i, arg := range command {
+			args[i] = strings.ReplaceAll(arg, "{file}", filePath)
+		}
+		return args
+	}
+	return nil
+}
+
+// Format pipes code for filePath through its language's formatter. It
+// reports whether a formatter ran; a formatter that fails, typically on a
+// syntax error, is an error, and validation reports the underlying problem.
+func Format(cfg config.FormattingConfig, filePath, code string) (string, bool, error) {
+	command := FormatterFor(cfg, filePath)
+	if command == nil {
+		return code, false, nil
+	}
+	formatted, err := formatStdin(command[0], command[1:], code)
+	if err != nil {
+		return code, true, err
+	}
+	return formatted, true, nil
+}
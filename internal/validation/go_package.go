@@ -0,0 +1,176 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// GoModePackage selects GoPackageValidator in the validation.go_mode setting
+const GoModePackage = "package"
+
+// goPackageTimeout bounds go build and go vet together; a cold build cache
+// can take a while on large modules
+const goPackageTimeout = 60 * time.Second
+
+// goDiagnostic matches go build and go vet output: [vet: ]file.go:line:col: message
+var goDiagnostic = regexp.MustCompile(`(?m)^(?:vet: )?(\S+\.go):(\d+):(\d+): (.+)$`)
+
+// GoPackageValidator checks generated Go code in the context of its package:
+// after the syntax check, the code is overlaid on the file's path in its
+// module and the package is built and vetted, so references to undeclared
+// symbols and vet findings are fed back to the model. Only diagnostics in the
+// generated file are reported; files outside a module get the syntax check.
+type GoPackageValidator struct {
+	GoValidator
+}
+
+// Validate checks Go syntax, then builds and vets the file's package
+func (v *GoPackageValidator) Validate(code string, filePath string) (*ValidationResult, error) {
+	result, err := v.GoValidator.Validate(code, filePath)
+	if err != nil || !result.Valid {
+		return result, err
+	}
+
+	if !GetToolCache().IsAvailable("go") || filePath == "" {
+		return result, nil
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return result, nil
+	}
+	moduleDir := findGoModule(filepath.Dir(absPath))
+	if moduleDir == "" {
+		return result, nil
+	}
+	// Run from the module root: the package directory may not exist yet
+	pkgDir, err := filepath.Rel(moduleDir, filepath.Dir(absPath))
+	if err != nil {
+		return result, nil
+	}
+	pkg := "./" + filepath.ToSlash(pkgDir)
+
+	// Overlay the generated code on the target path, which need not exist yet
+	tmpFile, err := os.CreateTemp("", "validate-*.go")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if _, err := tmpFile.WriteString(code); err != nil {
+		return nil, fmt.Errorf("failed to write to temp file: %w", err)
+	}
+	tmpFile.Close()
+
+	overlay, err := writeGoOverlay(absPath, tmpFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(overlay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), goPackageTimeout)
+	defer cancel()
+
+	// go build skips _test.go files; go vet covers them
+	commands := [][]string{
+		{"build", "-overlay=" + overlay, "-o", os.DevNull, pkg},
+		{"vet", "-overlay=" + overlay, pkg},
+	}
+	if strings.HasSuffix(absPath, "_test.go") {
+		commands = commands[1:]
+	}
+
+	for _, args := range commands {
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Dir = moduleDir
+		output, err := cmd.CombinedOutput()
+
+		if ctx.Err() == context.DeadlineExceeded {
+			// Too slow to check here; don't hold up generation over it
+			logger.Warnf("go %s of %s timed out after %s, skipping package validation", args[0], filePath, goPackageTimeout)
+			return result, nil
+		}
+		if err == nil {
+			continue
+		}
+
+		errors := v.parseErrors(string(output), tmpFile.Name())
+		if len(errors) == 0 {
+			// Failures elsewhere in the package or module (missing
+			// dependencies, broken sibling files) are not the model's to fix
+			logger.Debugf("go %s failed outside %s: %s", args[0], filePath, strings.TrimSpace(string(output)))
+			return result, nil
+		}
+		return &ValidationResult{Valid: false, Errors: errors}, nil
+	}
+
+	return result, nil
+}
+
+// parseErrors parses go build/vet diagnostics reported against the overlay
+// source. The go command shortens paths, so match on the temp file's name.
+func (v *GoPackageValidator) parseErrors(output string, tmpPath string) []ValidationError {
+	var errors []ValidationError
+
+	name := filepath.Base(tmpPath)
+	for _, match := range goDiagnostic.FindAllStringSubmatch(output, -1) {
+		if !strings.HasSuffix(match[1], name) {
+			continue
+		}
+		lineNum, _ := strconv.Atoi(match[2])
+		colNum, _ := strconv.Atoi(match[3])
+		errors = append(errors, ValidationError{
+			Line:    lineNum,
+			Column:  colNum,
+			Message: match[4],
+		})
+	}
+
+	return errors
+}
+
+// findGoModule returns the directory of the go.mod governing dir, or ""
+func findGoModule(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// writeGoOverlay writes a go command -overlay file replacing target with source
+func writeGoOverlay(target, source string) (string, error) {
+	data, err := json.Marshal(map[string]map[string]string{
+		"Replace": {target: source},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	overlay, err := os.CreateTemp("", "validate-overlay-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to create overlay file: %w", err)
+	}
+	defer overlay.Close()
+
+	if _, err := overlay.Write(data); err != nil {
+		os.Remove(overlay.Name())
+		return "", fmt.Errorf("failed to write overlay file: %w", err)
+	}
+	return overlay.Name(), nil
+}
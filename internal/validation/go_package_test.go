@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoPackageValidatorCatchesUndeclaredSymbols(t *testing.T) {
+	if !GetToolCache().IsAvailable("go") {
+		t.Skip("go command not available")
+	}
+
+	module := t.TempDir()
+	files := map[string]string{
+		"go.mod":     "module example.com/m\n\ngo 1.21\n",
+		"pkg/old.go": "package pkg\n\nfunc Existing() int { return 1 }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(module, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v := &GoPackageValidator{}
+	target := filepath.Join(module, "pkg", "new.go")
+
+	result, err := v.Validate("package pkg\n\nfunc New() int { return Existing() }\n", target)
+	if err != nil || !result.Valid {
+		t.Fatalf("Validate(valid code) = %+v, %v", result, err)
+	}
+
+	result, err = v.Validate("package pkg\n\nfunc New() int { return Existing() + Missing() }\n", target)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Errorf("Validate(undeclared symbol) = %+v", result)
+	}
+
+	// Syntax errors are reported without building
+	result, err = v.Validate("package pkg\n\nfunc New() int {\n", target)
+	if err != nil || result.Valid {
+		t.Errorf("Validate(syntax error) = %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,126 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JavaValidator validates Java code syntax
+type JavaValidator struct{}
+
+// javaDiagnostic matches javac output: File.java:line: error: message
+var javaDiagnostic = regexp.MustCompile(`^(.+?\.java):(\d+): error: (.+)$`)
+
+// javaSyntaxErrors are fragments of javac's parser messages. Compiling a
+// single file reports every reference to the rest of the project as an error
+// ("cannot find symbol"), so only these are treated as failures.
+var javaSyntaxErrors = []string{
+	"expected",
+	"illegal start of",
+	"illegal character",
+	"reached end of file while parsing",
+	"not a statement",
+	"unclosed",
+	"else without if",
+	"without 'try'",
+	"without 'catch'",
+	"orphaned",
+	"malformed",
+}
+
+// Validate checks Java syntax using javac
+func (v *JavaValidator) Validate(code string, filePath string) (*ValidationResult, error) {
+	// Check if javac is available using tool cache
+	toolCache := GetToolCache()
+	if !toolCache.IsAvailable("javac") {
+		// No JDK available, skip validation
+		return &ValidationResult{Valid: true, Errors: nil}, nil
+	}
+
+	// javac requires a public class to be declared in a file of the same name
+	srcPath, tmpDir, err := writeTempSource(code, filePath, "Main.java")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), compileTimeout)
+	defer cancel()
+
+	// Run javac to check syntax with timeout
+	cmd := exec.CommandContext(ctx, "javac",
+		"-d", tmpDir,
+		"-proc:none",
+		"-implicit:none",
+		"-nowarn",
+		"-encoding", "UTF-8",
+		srcPath)
+	output, err := cmd.CombinedOutput()
+
+	// Check for timeout
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("java validation timeout exceeded (%s)", compileTimeout)
+	}
+
+	if err == nil {
+		return &ValidationResult{Valid: true, Errors: nil}, nil
+	}
+
+	errors := v.parseErrors(string(output))
+	return &ValidationResult{Valid: len(errors) == 0, Errors: errors}, nil
+}
+
+// CanAutoFix returns true if google-java-format is installed
+func (v *JavaValidator) CanAutoFix() bool {
+	return GetToolCache().IsAvailable("google-java-format")
+}
+
+// AutoFix uses google-java-format to format Java code
+func (v *JavaValidator) AutoFix(code string) (string, error) {
+	return formatStdin("google-java-format", []string{"-"}, code)
+}
+
+// parseErrors parses javac error messages, keeping only syntax errors
+func (v *JavaValidator) parseErrors(output string) []ValidationError {
+	var errors []ValidationError
+
+	// javac error format:
+	// File.java:line: error: message
+	//     source line
+	//         ^
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		match := javaDiagnostic.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil || !isJavaSyntaxError(match[3]) {
+			continue
+		}
+		lineNum, _ := strconv.Atoi(match[2])
+		colNum := 0
+		if i+2 < len(lines) {
+			if caret := strings.Index(lines[i+2], "^"); caret >= 0 && strings.TrimSpace(lines[i+2]) == "^" {
+				colNum = caret + 1
+			}
+		}
+		errors = append(errors, ValidationError{
+			Line:    lineNum,
+			Column:  colNum,
+			Message: match[3],
+		})
+	}
+
+	return errors
+}
+
+func isJavaSyntaxError(message string) bool {
+	for _, fragment := range javaSyntaxErrors {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
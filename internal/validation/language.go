@@ -3,6 +3,8 @@ package validation
 import (
 	"path/filepath"
 	"strings"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 )
 
 // Language represents a programming language
@@ -71,7 +73,31 @@ func (l Language) GetValidator() Validator {
 		return &TypeScriptValidator{}
 	case LanguageGo:
 		return &GoValidator{}
+	case LanguageRust:
+		return &RustValidator{}
+	case LanguageJava:
+		return &JavaValidator{}
+	case LanguageC, LanguageCPP:
+		return &CPPValidator{Language: l}
+	case LanguageRuby:
+		return &RubyValidator{}
 	default:
 		return &NoOpValidator{}
 	}
 }
+
+// ValidatorFor returns the validator for the language with the configured
+// validation settings applied
+func (l Language) ValidatorFor(cfg config.ValidationConfig) Validator {
+	validator := l.GetValidator()
+	if l == LanguageGo && cfg.GoMode == GoModePackage {
+		validator = &GoPackageValidator{}
+	}
+	if command := LanguageServerFor(cfg.LSP, l); command != nil {
+		validator = &LSPValidator{Validator: validator, Command: command, Timeout: cfg.LSP.Timeout}
+	}
+	if cfg.Security.Blocks() {
+		validator = &SecurityValidator{Validator: validator, Config: cfg.Security}
+	}
+	return validator
+}
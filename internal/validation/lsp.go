@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// defaultLSPTimeout bounds the wait for diagnostics when none is configured;
+// the first request to a server also waits for it to load the workspace
+const defaultLSPTimeout = 15 * time.Second
+
+// defaultLanguageServers are the language servers used per language
+var defaultLanguageServers = map[Language][]string{
+	LanguageGo:         {"gopls"},
+	LanguagePython:     {"pyright-langserver", "--stdio"},
+	LanguageJavaScript: {"typescript-language-server", "--stdio"},
+	LanguageTypeScript: {"typescript-language-server", "--stdio"},
+}
+
+// projectMarkers are the files marking the root a language server is started
+// in, nearest first
+var projectMarkers = []string{"go.mod", "package.json", "tsconfig.json", "pyproject.toml", "setup.py", ".git"}
+
+// LanguageServerFor returns the language server command for language, or nil
+// if the LSP check is off, the language has no server, or it isn't installed
+func LanguageServerFor(cfg config.LSPConfig, language Language) []string {
+	if !cfg.Enabled {
+		return nil
+	}
+	command := defaultLanguageServers[language]
+	if configured, ok := cfg.Servers[language.String()]; ok {
+		command = configured
+	}
+	if len(command) == 0 || !GetToolCache().IsAvailable(command[0]) {
+		return nil
+	}
+	return command
+}
+
+// LSPValidator checks code with Validator, then opens it in a language
+// server in place of the file at its path and reports the errors the server
+// finds: undeclared names, type errors, bad imports. A server that fails to
+// start or answer in time is skipped rather than failing the write.
+type LSPValidator struct {
+	Validator
+	Command []string
+	Timeout time.Duration
+}
+
+// Validate runs the wrapped validator, then the language server
+func (v *LSPValidator) Validate(code string, filePath string) (*ValidationResult, error) {
+	result, err := v.Validator.Validate(code, filePath)
+	if err != nil || !result.Valid || filePath == "" {
+		return result, err
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return result, nil
+	}
+
+	timeout := v.Timeout
+	if timeout <= 0 {
+		timeout = defaultLSPTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	server, err := languageServer(ctx, v.Command, projectRoot(filepath.Dir(absPath)))
+	if err != nil {
+		logger.Warnf("Skipping language server validation of %s: %v", filePath, err)
+		return result, nil
+	}
+	diagnostics, err := server.diagnose(ctx, absPath, languageID(absPath), code)
+	if err != nil {
+		logger.Warnf("Skipping language server validation of %s: %s: %v", filePath, v.Command[0], err)
+		return result, nil
+	}
+
+	var errors []ValidationError
+	for _, d := range diagnostics {
+		if d.Severity != 1 {
+			continue // Warnings and hints are not worth a retry
+		}
+		message := d.Message
+		if d.Source != "" {
+			message = fmt.Sprintf("%s (%s)", message, d.Source)
+		}
+		errors = append(errors, ValidationError{
+			Line:    d.Range.Start.Line + 1,
+			Column:  d.Range.Start.Character + 1,
+			Message: message,
+		})
+	}
+	if len(errors) > 0 {
+		return &ValidationResult{Valid: false, Errors: errors}, nil
+	}
+	return result, nil
+}
+
+// projectRoot returns the nearest directory at or above dir holding one of
+// projectMarkers, or the nearest existing one: the target file's directory
+// may not exist yet
+func projectRoot(dir string) string {
+	existing := ""
+	for current := dir; ; {
+		if info, err := os.Stat(current); err == nil && info.IsDir() {
+			if existing == "" {
+				existing = current
+			}
+			for _, marker := range projectMarkers {
+				if _, err := os.Stat(filepath.Join(current, marker)); err == nil {
+					return current
+				}
+			}
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			if existing == "" {
+				return dir
+			}
+			return existing
+		}
+		current = parent
+	}
+}
+
+// languageID returns the LSP language identifier of a file
+func languageID(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".tsx":
+		return "typescriptreact"
+	case ".jsx":
+		return "javascriptreact"
+	default:
+		return DetectLanguage(path).String()
+	}
+}
@@ -0,0 +1,442 @@
+package validation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+const (
+	// lspIdleTimeout shuts down a language server unused for this long
+	lspIdleTimeout = 10 * time.Minute
+	// lspSettle is how long to wait after diagnostics arrive for a newer set
+	// replacing them, as servers may publish in stages
+	lspSettle = 300 * time.Millisecond
+)
+
+// lspMessage is a JSON-RPC 2.0 request, notification or response
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// lspDiagnostic is a diagnostic from textDocument/publishDiagnostics
+type lspDiagnostic struct {
+	Range struct {
+		Start struct {
+			Line      int `json:"line"`
+			Character int `json:"character"`
+		} `json:"start"`
+	} `json:"range"`
+	Severity int    `json:"severity"` // 1 error, 2 warning, 3 information, 4 hint
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+type lspPublishDiagnostics struct {
+	URI         string          `json:"uri"`
+	Version     *int            `json:"version,omitempty"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+}
+
+// lspServer is a running language server, spoken to over stdio
+type lspServer struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	done  chan struct{}
+
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	nextID      int
+	pending     map[string]chan *lspMessage
+	subscribers map[string]chan lspPublishDiagnostics
+	idle        *time.Timer
+
+	// documentMu lets one document be open at a time, so diagnostics are
+	// attributed to the right version
+	documentMu sync.Mutex
+	version    int
+}
+
+// lspServers are the running language servers by command and project root
+var lspServers = struct {
+	sync.Mutex
+	running map[string]*lspServer
+}{running: make(map[string]*lspServer)}
+
+// languageServer returns the running server for command in root, starting
+// it if needed
+func languageServer(ctx context.Context, command []string, root string) (*lspServer, error) {
+	key := strings.Join(append(append([]string{}, command...), root), "\x00")
+
+	lspServers.Lock()
+	defer lspServers.Unlock()
+	if server, ok := lspServers.running[key]; ok {
+		select {
+		case <-server.done:
+			delete(lspServers.running, key)
+		default:
+			server.touch()
+			return server, nil
+		}
+	}
+
+	server, err := startLanguageServer(ctx, command, root)
+	if err != nil {
+		return nil, err
+	}
+	lspServers.running[key] = server
+	server.idle = time.AfterFunc(lspIdleTimeout, func() {
+		lspServers.Lock()
+		if lspServers.running[key] == server {
+			delete(lspServers.running, key)
+		}
+		lspServers.Unlock()
+		server.shutdown()
+	})
+	return server, nil
+}
+
+// ShutdownLanguageServers stops the language servers started for validation
+func ShutdownLanguageServers() {
+	lspServers.Lock()
+	servers := lspServers.running
+	lspServers.running = make(map[string]*lspServer)
+	lspServers.Unlock()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *lspServer) {
+			defer wg.Done()
+			server.shutdown()
+		}(server)
+	}
+	wg.Wait()
+}
+
+// startLanguageServer starts command and initializes it for the workspace at
+// root
+func startLanguageServer(ctx context.Context, command []string, root string) (*lspServer, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+
+	server := &lspServer{
+		cmd:         cmd,
+		stdin:       stdin,
+		done:        make(chan struct{}),
+		pending:     make(map[string]chan *lspMessage),
+		subscribers: make(map[string]chan lspPublishDiagnostics),
+	}
+	go server.readLoop(bufio.NewReader(stdout))
+
+	rootURI := fileURI(root)
+	_, err = server.call(ctx, "initialize", map[string]interface{}{
+		"processId": nil,
+		"rootUri":   rootURI,
+		"workspaceFolders": []map[string]string{
+			{"uri": rootURI, "name": filepath.Base(root)},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"publishDiagnostics": map[string]interface{}{"versionSupport": true},
+			},
+			"workspace": map[string]interface{}{"configuration": true, "workspaceFolders": true},
+		},
+	})
+	if err == nil {
+		err = server.notify("initialized", map[string]interface{}{})
+	}
+	if err != nil {
+		server.kill()
+		return nil, fmt.Errorf("failed to initialize %s: %w", command[0], err)
+	}
+	logger.Debugf("Started language server %s for %s", command[0], root)
+	return server, nil
+}
+
+// diagnose opens text as the document at path and returns the diagnostics
+// the server publishes for it. The document is closed again afterwards; it
+// never touches the disk.
+func (s *lspServer) diagnose(ctx context.Context, path, languageID, text string) ([]lspDiagnostic, error) {
+	s.documentMu.Lock()
+	defer s.documentMu.Unlock()
+	s.touch()
+
+	uri := fileURI(path)
+	updates := make(chan lspPublishDiagnostics, 16)
+	s.mu.Lock()
+	s.subscribers[uri] = updates
+	s.version++
+	version := s.version
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, uri)
+		s.mu.Unlock()
+	}()
+
+	err := s.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri, "languageId": languageID, "version": version, "text": text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer s.notify("textDocument/didClose", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+	})
+
+	var latest *lspPublishDiagnostics
+	var settle <-chan time.Time
+	for {
+		select {
+		case update := <-updates:
+			if update.Version != nil && *update.Version != version {
+				continue // Left over from an earlier validation
+			}
+			latest = &update
+			settle = time.After(lspSettle)
+		case <-settle:
+			return latest.Diagnostics, nil
+		case <-s.done:
+			return nil, errors.New("language server exited")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// call sends a request and waits for its response
+func (s *lspServer) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	s.mu.Lock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	response := make(chan *lspMessage, 1)
+	s.pending[id] = response
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	msg, err := newLSPMessage(method, params)
+	if err != nil {
+		return nil, err
+	}
+	msg.ID = json.RawMessage(id)
+	if err := s.write(msg); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-response:
+		if msg.Error != nil {
+			return nil, fmt.Errorf("%s: %s (code %d)", method, msg.Error.Message, msg.Error.Code)
+		}
+		return msg.Result, nil
+	case <-s.done:
+		return nil, errors.New("language server exited")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// notify sends a notification
+func (s *lspServer) notify(method string, params interface{}) error {
+	msg, err := newLSPMessage(method, params)
+	if err != nil {
+		return err
+	}
+	return s.write(msg)
+}
+
+// newLSPMessage returns a message calling method, leaving out nil params
+func newLSPMessage(method string, params interface{}) (*lspMessage, error) {
+	msg := &lspMessage{Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		msg.Params = data
+	}
+	return msg, nil
+}
+
+func (s *lspServer) write(msg *lspMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return writeLSPMessage(s.stdin, msg)
+}
+
+// readLoop dispatches the server's messages until it exits
+func (s *lspServer) readLoop(r *bufio.Reader) {
+	defer close(s.done)
+	for {
+		msg, err := readLSPMessage(r)
+		if err != nil {
+			if err != io.EOF {
+				logger.Debugf("Language server read failed: %v", err)
+			}
+			return
+		}
+
+		switch {
+		case msg.Method == "textDocument/publishDiagnostics":
+			var params lspPublishDiagnostics
+			if json.Unmarshal(msg.Params, &params) != nil {
+				continue
+			}
+			s.mu.Lock()
+			updates := s.subscribers[params.URI]
+			s.mu.Unlock()
+			if updates != nil {
+				select {
+				case updates <- params:
+				default:
+				}
+			}
+		case msg.Method != "" && msg.ID != nil:
+			// Requests from the server: settings and capability registration
+			// get empty answers
+			s.write(&lspMessage{ID: msg.ID, Result: lspEmptyResult(msg)})
+		case msg.Method == "" && msg.ID != nil:
+			s.mu.Lock()
+			response := s.pending[strings.Trim(string(msg.ID), `"`)]
+			s.mu.Unlock()
+			if response != nil {
+				response <- msg
+			}
+		}
+	}
+}
+
+// lspEmptyResult answers a server request: one null setting per item asked
+// for by workspace/configuration, null otherwise
+func lspEmptyResult(msg *lspMessage) json.RawMessage {
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(msg.Params, &params)
+		result, _ := json.Marshal(make([]interface{}, len(params.Items)))
+		return result
+	}
+	return json.RawMessage("null")
+}
+
+// touch postpones the idle shutdown
+func (s *lspServer) touch() {
+	if s.idle != nil {
+		s.idle.Reset(lspIdleTimeout)
+	}
+}
+
+// shutdown asks the server to exit, killing it if it doesn't
+func (s *lspServer) shutdown() {
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := s.call(ctx, "shutdown", nil); err == nil {
+		s.notify("exit", nil)
+	}
+	s.stdin.Close()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+	s.kill()
+}
+
+func (s *lspServer) kill() {
+	s.stdin.Close()
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+	// Wait closes stdout, so let readLoop finish with it first
+	select {
+	case <-s.done:
+	case <-time.After(time.Second):
+	}
+	s.cmd.Wait()
+}
+
+// readLSPMessage reads one Content-Length framed message
+func readLSPMessage(r *bufio.Reader) (*lspMessage, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	var msg lspMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return &msg, nil
+}
+
+// writeLSPMessage writes msg with its Content-Length header
+func writeLSPMessage(w io.Writer, msg *lspMessage) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// fileURI returns the file:// URI of an absolute path
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive letters
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
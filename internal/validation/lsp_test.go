@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+)
+
+// TestLanguageServerHelper is not a test: run as a subprocess with
+// MCP_LSP_HELPER set, it is a language server reporting an error on every
+// line mentioning "undefined" and a warning on every TODO
+func TestLanguageServerHelper(t *testing.T) {
+	if os.Getenv("MCP_LSP_HELPER") != "1" {
+		return
+	}
+	r := bufio.NewReader(os.Stdin)
+	for {
+		msg, err := readLSPMessage(r)
+		if err != nil {
+			os.Exit(0)
+		}
+		switch msg.Method {
+		case "initialize":
+			// Ask for settings first, as gopls does
+			writeLSPMessage(os.Stdout, &lspMessage{ID: json.RawMessage(`"cfg"`), Method: "workspace/configuration", Params: json.RawMessage(`{"items":[{}]}`)})
+			writeLSPMessage(os.Stdout, &lspMessage{ID: msg.ID, Result: json.RawMessage(`{"capabilities":{}}`)})
+		case "shutdown":
+			writeLSPMessage(os.Stdout, &lspMessage{ID: msg.ID, Result: json.RawMessage("null")})
+		case "exit":
+			os.Exit(0)
+		case "textDocument/didOpen":
+			var params struct {
+				TextDocument struct {
+					URI     string `json:"uri"`
+					Version int    `json:"version"`
+					Text    string `json:"text"`
+				} `json:"textDocument"`
+			}
+			json.Unmarshal(msg.Params, &params)
+			publish := lspPublishDiagnostics{URI: params.TextDocument.URI, Version: &params.TextDocument.Version, Diagnostics: []lspDiagnostic{}}
+			for i, line := range strings.Split(params.TextDocument.Text, "\n") {
+				var d lspDiagnostic
+				d.Range.Start.Line = i
+				switch {
+				case strings.Contains(line, "undefined"):
+					d.Severity, d.Source, d.Message = 1, "compiler", "undefined: name"
+					d.Range.Start.Character = strings.Index(line, "undefined")
+				case strings.Contains(line, "TODO"):
+					d.Severity, d.Message = 2, "unfinished"
+				default:
+					continue
+				}
+				publish.Diagnostics = append(publish.Diagnostics, d)
+			}
+			params2, _ := json.Marshal(publish)
+			writeLSPMessage(os.Stdout, &lspMessage{Method: "textDocument/publishDiagnostics", Params: params2})
+		}
+	}
+}
+
+func TestLSPValidator(t *testing.T) {
+	t.Setenv("MCP_LSP_HELPER", "1")
+	t.Cleanup(ShutdownLanguageServers)
+
+	cfg := config.ValidationConfig{LSP: config.LSPConfig{
+		Enabled: true,
+		Servers: map[string][]string{"ruby": {os.Args[0], "-test.run=^TestLanguageServerHelper$"}},
+	}}
+	validator := LanguageRuby.ValidatorFor(cfg)
+	if _, ok := validator.(*LSPValidator); !ok {
+		t.Fatalf("ValidatorFor() = %T, want *LSPValidator", validator)
+	}
+	target := filepath.Join(t.TempDir(), "new", "app.rb")
+
+	result, err := validator.Validate("x = 1 # TODO\nputs x\n", target)
+	if err != nil || !result.Valid {
+		t.Errorf("Validate(clean code) = %+v, %v; warnings should not fail validation", result, err)
+	}
+
+	// The same server checks the next version of the document
+	result, err = validator.Validate("x = 1\nputs undefined\n", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ValidationError{Line: 2, Column: 6, Message: "undefined: name (compiler)"}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0] != want {
+		t.Errorf("Validate(undefined name) = %+v, want %+v", result, want)
+	}
+	if len(lspServers.running) != 1 {
+		t.Errorf("%d language servers running, want 1", len(lspServers.running))
+	}
+}
+
+func TestLanguageServerFor(t *testing.T) {
+	if command := LanguageServerFor(config.LSPConfig{}, LanguageGo); command != nil {
+		t.Errorf("LanguageServerFor(disabled) = %v", command)
+	}
+	cfg := config.LSPConfig{Enabled: true, Servers: map[string][]string{"go": {}}}
+	if command := LanguageServerFor(cfg, LanguageGo); command != nil {
+		t.Errorf("LanguageServerFor(empty command) = %v", command)
+	}
+}
@@ -0,0 +1,130 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RubyValidator validates Ruby code syntax
+type RubyValidator struct{}
+
+var (
+	// rubyDiagnostic matches the classic parser: file.rb:line: message
+	rubyDiagnostic = regexp.MustCompile(`^.+?\.rb:(\d+): (.+)$`)
+	// rubySourceLine and rubyCaret match the Prism parser's annotated source:
+	// > 3 | code
+	//     |  ^ message
+	rubySourceLine = regexp.MustCompile(`^>\s*(\d+)\s*\|`)
+	rubyCaret      = regexp.MustCompile(`^\s*\|(\s*)\^+~*\s+(.+)$`)
+)
+
+// Validate checks Ruby syntax using ruby -c
+func (v *RubyValidator) Validate(code string, filePath string) (*ValidationResult, error) {
+	// Check if ruby is available using tool cache
+	toolCache := GetToolCache()
+	if !toolCache.IsAvailable("ruby") {
+		// No Ruby available, skip validation
+		return &ValidationResult{Valid: true, Errors: nil}, nil
+	}
+
+	// Create a temporary file with the code
+	tmpFile, err := os.CreateTemp("", "validate-*.rb")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if _, err := tmpFile.WriteString(code); err != nil {
+		return nil, fmt.Errorf("failed to write to temp file: %w", err)
+	}
+
+	tmpFile.Close()
+
+	// Create context with timeout (5s should be plenty for single file validation)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Run ruby syntax check with timeout
+	cmd := exec.CommandContext(ctx, "ruby", "-W0", "-c", tmpFile.Name())
+	output, err := cmd.CombinedOutput()
+
+	// Check for timeout
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("ruby validation timeout exceeded (5s)")
+	}
+
+	if err == nil {
+		return &ValidationResult{Valid: true, Errors: nil}, nil
+	}
+
+	// Parse errors from output
+	errors := v.parseErrors(string(output))
+	return &ValidationResult{Valid: false, Errors: errors}, nil
+}
+
+// CanAutoFix returns false - we don't auto-fix Ruby
+func (v *RubyValidator) CanAutoFix() bool {
+	return false
+}
+
+// AutoFix is not implemented for Ruby
+func (v *RubyValidator) AutoFix(code string) (string, error) {
+	return "", fmt.Errorf("auto-fix not supported for Ruby")
+}
+
+// parseErrors parses ruby -c error messages, from either the Prism parser
+// (Ruby 3.4+) or the classic one
+func (v *RubyValidator) parseErrors(output string) []ValidationError {
+	var errors []ValidationError
+
+	lines := strings.Split(output, "\n")
+
+	// Prism: "file.rb:3: syntax errors found", then annotated source lines
+	sourceLine := 0
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if match := rubySourceLine.FindStringSubmatch(line); match != nil {
+			sourceLine, _ = strconv.Atoi(match[1])
+			continue
+		}
+		if match := rubyCaret.FindStringSubmatch(line); match != nil && sourceLine > 0 {
+			errors = append(errors, ValidationError{
+				Line:    sourceLine,
+				Column:  len(match[1]),
+				Message: match[2],
+			})
+		}
+	}
+
+	// Classic: "file.rb:3: syntax error, unexpected end-of-input"
+	if len(errors) == 0 {
+		for _, line := range lines {
+			match := rubyDiagnostic.FindStringSubmatch(strings.TrimRight(line, "\r"))
+			if match == nil || strings.HasPrefix(match[2], "warning:") {
+				continue
+			}
+			lineNum, _ := strconv.Atoi(match[1])
+			errors = append(errors, ValidationError{
+				Line:    lineNum,
+				Message: match[2],
+			})
+		}
+	}
+
+	if len(errors) == 0 {
+		// Generic error with no line number
+		errors = append(errors, ValidationError{
+			Line:    0,
+			Message: strings.TrimSpace(output),
+		})
+	}
+
+	return errors
+}
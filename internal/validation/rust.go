@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RustValidator validates Rust code syntax
+type RustValidator struct{}
+
+// rustDiagnostic matches rustc --error-format=short output:
+// file.rs:line:col: error[E0432]: message
+var rustDiagnostic = regexp.MustCompile(`(?m)^(.+?):(\d+):(\d+): error(\[E\d+\])?: (.+)$`)
+
+// Validate checks Rust syntax using rustc. The file is compiled on its own, as
+// a library and only to metadata, so errors about crates, modules and macros
+// defined elsewhere in the project are ignored and only parse errors reported.
+func (v *RustValidator) Validate(code string, filePath string) (*ValidationResult, error) {
+	// Check if rustc is available using tool cache
+	toolCache := GetToolCache()
+	if !toolCache.IsAvailable("rustc") {
+		// No Rust toolchain available, skip validation
+		return &ValidationResult{Valid: true, Errors: nil}, nil
+	}
+
+	srcPath, tmpDir, err := writeTempSource(code, filePath, "lib.rs")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), compileTimeout)
+	defer cancel()
+
+	// Run rustc to check syntax with timeout
+	cmd := exec.CommandContext(ctx, "rustc",
+		"--edition", "2021",
+		"--crate-type", "lib",
+		"--emit=metadata",
+		"--error-format=short",
+		"--cap-lints", "allow",
+		"--out-dir", tmpDir,
+		srcPath)
+	output, err := cmd.CombinedOutput()
+
+	// Check for timeout
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("rust validation timeout exceeded (%s)", compileTimeout)
+	}
+
+	if err == nil {
+		return &ValidationResult{Valid: true, Errors: nil}, nil
+	}
+
+	errors := v.parseErrors(string(output))
+	return &ValidationResult{Valid: len(errors) == 0, Errors: errors}, nil
+}
+
+// CanAutoFix returns true if rustfmt is installed
+func (v *RustValidator) CanAutoFix() bool {
+	return GetToolCache().IsAvailable("rustfmt")
+}
+
+// AutoFix uses rustfmt to format Rust code
+func (v *RustValidator) AutoFix(code string) (string, error) {
+	return formatStdin("rustfmt", []string{"--edition", "2021"}, code)
+}
+
+// parseErrors parses rustc error messages, keeping only syntax errors.
+// Parse errors carry no error code; resolution and type errors do
+// (error[E0432]), as do a few uncoded ones about unknown macros and attributes.
+func (v *RustValidator) parseErrors(output string) []ValidationError {
+	var errors []ValidationError
+
+	for _, match := range rustDiagnostic.FindAllStringSubmatch(output, -1) {
+		code, message := match[4], match[5]
+		if code != "" || strings.HasPrefix(message, "cannot find ") || strings.HasPrefix(message, "malformed `") {
+			continue
+		}
+		lineNum, _ := strconv.Atoi(match[2])
+		colNum, _ := strconv.Atoi(match[3])
+		errors = append(errors, ValidationError{
+			Line:    lineNum,
+			Column:  colNum,
+			Message: message,
+		})
+	}
+
+	return errors
+}
@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"encoding/json"
+	"path/filepath"
+)
+
+// SARIF 2.1.0 constants
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// SyntaxErrorRuleID is the SARIF rule reported for validator syntax errors
+	SyntaxErrorRuleID = "syntax-error"
+
+	// ReviewIssueRuleID is the SARIF rule reported for issues a review found
+	ReviewIssueRuleID = "review-issue"
+)
+
+// ruleDescriptions describes the rules findings are reported under
+var ruleDescriptions = map[string]string{
+	SyntaxErrorRuleID: "Code fails language syntax validation",
+	ReviewIssueRuleID: "Issue found by a model review of the code",
+}
+
+// FileFindings groups validation errors found in one file
+type FileFindings struct {
+	FilePath string
+	Language Language
+	Errors   []ValidationError
+	RuleID   string // SyntaxErrorRuleID if empty
+}
+
+// SARIFLog is the root of a SARIF document
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is a single analysis run
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the analysis tool
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver describes the tool component that produced the results
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes a reporting rule
+type SARIFRule struct {
+	ID               string       `json:"id"`
+	ShortDescription SARIFMessage `json:"shortDescription"`
+}
+
+// SARIFMessage is a plain-text message
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult is a single finding
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations"`
+}
+
+// SARIFLocation points a result at a file region
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation is a file and optional region
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation identifies a file by URI
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is a line/column range (1-based)
+type SARIFRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// NewSARIFLog converts validation findings into a SARIF log. File paths are made
+// relative to baseDir when possible so GitHub code scanning can resolve them.
+func NewSARIFLog(toolName, toolVersion, baseDir string, findings []FileFindings) *SARIFLog {
+	results := []SARIFResult{}
+	rules := []SARIFRule{{ID: SyntaxErrorRuleID, ShortDescription: SARIFMessage{Text: ruleDescriptions[SyntaxErrorRuleID]}}}
+	for _, file := range findings {
+		uri := filepath.ToSlash(file.FilePath)
+		if baseDir != "" {
+			if rel, err := filepath.Rel(baseDir, file.FilePath); err == nil {
+				uri = filepath.ToSlash(rel)
+			}
+		}
+		ruleID := file.RuleID
+		if ruleID == "" {
+			ruleID = SyntaxErrorRuleID
+		}
+		if !hasRule(rules, ruleID) {
+			rules = append(rules, SARIFRule{ID: ruleID, ShortDescription: SARIFMessage{Text: ruleDescriptions[ruleID]}})
+		}
+
+		for _, e := range file.Errors {
+			location := SARIFLocation{
+				PhysicalLocation: SARIFPhysicalLocation{
+					ArtifactLocation: SARIFArtifactLocation{URI: uri},
+				},
+			}
+			if e.Line > 0 {
+				location.PhysicalLocation.Region = &SARIFRegion{StartLine: e.Line, StartColumn: e.Column}
+			}
+			level := e.Level
+			if level == "" {
+				level = "error"
+			}
+			results = append(results, SARIFResult{
+				RuleID:    ruleID,
+				Level:     level,
+				Message:   SARIFMessage{Text: e.Message},
+				Locations: []SARIFLocation{location},
+			})
+		}
+	}
+
+	return &SARIFLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []SARIFRun{{
+			Tool: SARIFTool{Driver: SARIFDriver{
+				Name:           toolName,
+				Version:        toolVersion,
+				InformationURI: "https://github.com/cecil-the-coder/mcp-code-api",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+func hasRule(rules []SARIFRule, id string) bool {
+	for _, rule := range rules {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// JSON renders the SARIF log as indented JSON
+func (l *SARIFLog) JSON() ([]byte, error) {
+	return json.MarshalIndent(l, "", "  ")
+}
@@ -0,0 +1,314 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
+	"github.com/cecil-the-coder/mcp-code-api/internal/logger"
+)
+
+// defaultSecurityTimeout bounds each external scanner when none is configured
+const defaultSecurityTimeout = 30 * time.Second
+
+// Sources of security findings
+const (
+	SecuritySourceBuiltin = "builtin"
+	SecuritySourceGosec   = "gosec"
+	SecuritySourceBandit  = "bandit"
+	SecuritySourceSemgrep = "semgrep"
+)
+
+// SecurityFinding is an insecure construct found in generated code
+type SecurityFinding struct {
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`   // Built-in rule name or the scanner's rule id
+	Source  string `json:"source"` // builtin, gosec, bandit or semgrep
+	Message string `json:"message"`
+}
+
+func (f SecurityFinding) String() string {
+	if f.Source == SecuritySourceBuiltin {
+		return fmt.Sprintf("security: %s (%s)", f.Message, f.Rule)
+	}
+	return fmt.Sprintf("security: %s (%s %s)", f.Message, f.Source, f.Rule)
+}
+
+// securityRule flags lines matching its pattern
+type securityRule struct {
+	name    string
+	message string
+	pattern *regexp.Regexp
+}
+
+// securityRules are checked on every language; they look for the shapes
+// of hardcoded secrets, shell commands built from input and disabled
+// certificate checks
+var securityRules = []securityRule{
+	{"hardcoded_secret", "hardcoded credential", regexp.MustCompile(`(?i)\b\w*(password|passwd|secret|api_?key|access_?key|auth_?token|private_?key)\w*["']?\s*(:=|=|:)\s*["']([^"'\s]{8,})["']`)},
+	{"hardcoded_secret", "hardcoded cloud or service token", regexp.MustCompile(`\b(AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36}|xox[baprs]-[A-Za-z0-9-]{10,}|sk_live_[A-Za-z0-9]{16,})\b`)},
+	{"hardcoded_secret", "embedded private key", regexp.MustCompile(`-----BEGIN ((RSA|EC|DSA|OPENSSH|PGP) )?PRIVATE KEY`)},
+	{"command_injection", "shell command built from variables", regexp.MustCompile(`exec\.Command(Context)?\(\s*(\w+,\s*)?"(/bin/)?(ba|z)?sh"\s*,\s*"-c"\s*,\s*([^"\s)]|"[^"]*"\s*\+)`)},
+	{"command_injection", "subprocess call with shell=True", regexp.MustCompile(`\bsubprocess\.\w+\(.*\bshell\s*=\s*True`)},
+	{"command_injection", "shell command built from variables", regexp.MustCompile(`\bos\.(system|popen)\((\s*f["']|[^)]*(\+|\.format\(|%\s*[\w(]))`)},
+	{"command_injection", "shell command built from variables", regexp.MustCompile("\\bexec(Sync)?\\(\\s*(`[^`]*\\$\\{|[\"'][^\"']*[\"']\\s*\\+)")},
+	{"command_injection", "shell command built from variables", regexp.MustCompile(`\b(system|exec|spawn)\(?\s*"[^"]*#\{`)},
+	{"insecure_tls", "TLS certificate verification disabled", regexp.MustCompile(`\bInsecureSkipVerify\s*:\s*true|\bverify\s*=\s*False\b|\brejectUnauthorized\s*:\s*false|\bssl\._create_unverified_context\b|\bCERT_NONE\b|NODE_TLS_REJECT_UNAUTHORIZED\W+0|\bVERIFY_NONE\b|danger_accept_invalid_certs\(\s*true`)},
+}
+
+// placeholderSecret matches values that only stand in for a secret
+var placeholderSecret = regexp.MustCompile(`(?i)(example|changeme|placeholder|dummy|your[_-]|xxx|<|\$\{|\{\{|%[sdv]|\*\*\*)`)
+
+// ScanSecurity returns the security findings in code written to filePath:
+// the built-in rules, then gosec for Go, bandit for Python and semgrep with
+// the configured rulesets when they are installed. A scanner that fails is
+// skipped.
+func ScanSecurity(cfg config.SecurityScanConfig, code, filePath string) []SecurityFinding {
+	if !cfg.Enabled {
+		return nil
+	}
+	findings := scanBuiltin(code)
+
+	var scanners []func(context.Context, string, string) ([]SecurityFinding, error)
+	switch DetectLanguage(filePath) {
+	case LanguageGo:
+		if GetToolCache().IsAvailable("gosec") {
+			scanners = append(scanners, runGosec)
+		}
+	case LanguagePython:
+		if GetToolCache().IsAvailable("bandit") {
+			scanners = append(scanners, runBandit)
+		}
+	}
+	if len(cfg.Semgrep) > 0 && GetToolCache().IsAvailable("semgrep") {
+		scanners = append(scanners, func(ctx context.Context, dir, file string) ([]SecurityFinding, error) {
+			return runSemgrep(ctx, cfg.Semgrep, dir, file)
+		})
+	}
+	if len(scanners) == 0 || filePath == "" {
+		return findings
+	}
+
+	// Scanners read files, so the code is written under its own name to a
+	// scratch directory
+	dir, err := os.MkdirTemp("", "security-scan-*")
+	if err != nil {
+		logger.Warnf("Skipping security scanners for %s: %v", filePath, err)
+		return findings
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, filepath.Base(filePath))
+	if err := os.WriteFile(file, []byte(code), 0600); err != nil {
+		logger.Warnf("Skipping security scanners for %s: %v", filePath, err)
+		return findings
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSecurityTimeout
+	}
+	for _, scan := range scanners {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		found, err := scan(ctx, dir, file)
+		cancel()
+		if err != nil {
+			logger.Warnf("Skipping security scanner for %s: %v", filePath, err)
+			continue
+		}
+		findings = append(findings, found...)
+	}
+	return findings
+}
+
+// scanBuiltin checks code line by line with securityRules, reporting the
+// first rule that flags each line
+func scanBuiltin(code string) []SecurityFinding {
+	var findings []SecurityFinding
+	for i, line := range strings.Split(code, "\n") {
+		for _, rule := range securityRules {
+			match := rule.pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			if rule.name == "hardcoded_secret" && len(match) > 3 && placeholderSecret.MatchString(match[3]) {
+				continue
+			}
+			findings = append(findings, SecurityFinding{Line: i + 1, Rule: rule.name, Source: SecuritySourceBuiltin, Message: rule.message})
+			break
+		}
+	}
+	return findings
+}
+
+// runScanner runs a scanner in dir and returns its standard output. Most
+// scanners exit non-zero when they find something, so a failure only counts
+// when there is no output to parse.
+func runScanner(ctx context.Context, dir string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", name, ctx.Err())
+	}
+	if stdout.Len() == 0 && err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// lowSeverity reports whether a scanner's severity is below the threshold
+// worth reporting
+func lowSeverity(severity string) bool {
+	switch strings.ToUpper(severity) {
+	case "LOW", "INFO", "UNDEFINED":
+		return true
+	}
+	return false
+}
+
+// runGosec scans the Go file in dir, given a module of its own
+func runGosec(ctx context.Context, dir, file string) ([]SecurityFinding, error) {
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module scan\n"), 0600); err != nil {
+		return nil, err
+	}
+	output, err := runScanner(ctx, dir, "gosec", "-fmt=json", "-quiet", "-no-fail", "./...")
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		Issues []struct {
+			Severity string `json:"severity"`
+			RuleID   string `json:"rule_id"`
+			Details  string `json:"details"`
+			Line     string `json:"line"` // "12" or a range "12-14"
+		} `json:"Issues"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("gosec: parsing output: %w", err)
+	}
+	var findings []SecurityFinding
+	for _, issue := range report.Issues {
+		if lowSeverity(issue.Severity) {
+			continue
+		}
+		line, _ := strconv.Atoi(strings.SplitN(issue.Line, "-", 2)[0])
+		findings = append(findings, SecurityFinding{Line: line, Rule: issue.RuleID, Source: SecuritySourceGosec, Message: issue.Details})
+	}
+	return findings, nil
+}
+
+// runBandit scans the Python file
+func runBandit(ctx context.Context, dir, file string) ([]SecurityFinding, error) {
+	output, err := runScanner(ctx, dir, "bandit", "-f", "json", "-q", file)
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		Results []struct {
+			TestID   string `json:"test_id"`
+			Text     string `json:"issue_text"`
+			Severity string `json:"issue_severity"`
+			Line     int    `json:"line_number"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("bandit: parsing output: %w", err)
+	}
+	var findings []SecurityFinding
+	for _, result := range report.Results {
+		if lowSeverity(result.Severity) {
+			continue
+		}
+		findings = append(findings, SecurityFinding{Line: result.Line, Rule: result.TestID, Source: SecuritySourceBandit, Message: result.Text})
+	}
+	return findings, nil
+}
+
+// runSemgrep scans the file with the rulesets
+func runSemgrep(ctx context.Context, rulesets []string, dir, file string) ([]SecurityFinding, error) {
+	args := []string{"scan", "--json", "--quiet", "--metrics=off"}
+	for _, ruleset := range rulesets {
+		args = append(args, "--config", ruleset)
+	}
+	output, err := runScanner(ctx, dir, "semgrep", append(args, file)...)
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		Results []struct {
+			CheckID string `json:"check_id"`
+			Start   struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			Extra struct {
+				Message  string `json:"message"`
+				Severity string `json:"severity"`
+			} `json:"extra"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("semgrep: parsing output: %w", err)
+	}
+	var findings []SecurityFinding
+	for _, result := range report.Results {
+		if lowSeverity(result.Extra.Severity) {
+			continue
+		}
+		findings = append(findings, SecurityFinding{Line: result.Start.Line, Rule: result.CheckID, Source: SecuritySourceSemgrep, Message: strings.TrimSpace(result.Extra.Message)})
+	}
+	return findings, nil
+}
+
+// FormatSecurityFindings describes findings for a warning
+func FormatSecurityFindings(findings []SecurityFinding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⚠️ Security scan flagged %d issue(s) in the generated code:\n", len(findings))
+	for i, f := range findings {
+		if i >= 10 {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(findings)-10)
+			break
+		}
+		if f.Line > 0 {
+			fmt.Fprintf(&b, "  Line %d: %s\n", f.Line, f)
+		} else {
+			fmt.Fprintf(&b, "  %s\n", f)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// SecurityValidator checks code with Validator, then scans it; findings fail
+// validation so the model is asked to fix them. It is used in block mode.
+type SecurityValidator struct {
+	Validator
+	Config config.SecurityScanConfig
+}
+
+// Validate runs the wrapped validator, then the security scan
+func (v *SecurityValidator) Validate(code string, filePath string) (*ValidationResult, error) {
+	result, err := v.Validator.Validate(code, filePath)
+	if err != nil || !result.Valid {
+		return result, err
+	}
+	findings := ScanSecurity(v.Config, code, filePath)
+	if len(findings) == 0 {
+		return result, nil
+	}
+	errors := make([]ValidationError, len(findings))
+	for i, f := range findings {
+		errors[i] = ValidationError{Line: f.Line, Message: f.String()}
+	}
+	return &ValidationResult{Valid: false, Errors: errors, Security: findings}, nil
+}
@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// compileTimeout bounds compiler-based checks, which start slower than the
+// interpreters and formatters used for the other languages
+const compileTimeout = 15 * time.Second
+
+// writeTempSource writes code to a new temporary directory under the base
+// name of filePath (or fallback), since some compilers require the file name
+// to match its contents (javac). The caller must remove the returned directory.
+func writeTempSource(code, filePath, fallback string) (path string, dir string, err error) {
+	dir, err = os.MkdirTemp("", "validate-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	name := filepath.Base(filePath)
+	if filePath == "" || name == "." || name == string(filepath.Separator) {
+		name = fallback
+	}
+	path = filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(code), 0600); err != nil {
+		os.RemoveAll(dir)
+		return "", "", fmt.Errorf("failed to write to temp file: %w", err)
+	}
+	return path, dir, nil
+}
+
+// formatStdin pipes code through a formatter that reads stdin and writes the
+// formatted code to stdout
+func formatStdin(tool string, args []string, code string) (string, error) {
+	// Create context with timeout (5s should be plenty for single file formatting)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Stdin = bytes.NewBufferString(code)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	// Check for timeout
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("%s timeout exceeded (5s)", tool)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", tool, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return stdout.String(), nil
+}
@@ -78,16 +78,28 @@ func (c *ToolCache) PrewarmCache() {
 		"tsc",
 
 		// Go
+		"go",
 		"gofmt",
 		"golangci-lint",
 		"staticcheck",
 
-		// Other
+		// Rust
 		"rustc",
+		"rustfmt",
 		"cargo",
+
+		// Java
 		"javac",
+		"google-java-format",
+
+		// C/C++
 		"clang",
+		"clang++",
 		"gcc",
+		"g++",
+
+		// Ruby
+		"ruby",
 	}
 
 	// Check all tools in parallel
@@ -8,6 +8,9 @@ import (
 type ValidationResult struct {
 	Valid  bool
 	Errors []ValidationError
+	// Security holds the findings of a blocking security scan that failed
+	// the code
+	Security []SecurityFinding
 }
 
 // ValidationError represents a syntax error
@@ -15,6 +18,7 @@ type ValidationError struct {
 	Line    int
 	Column  int
 	Message string
+	Level   string // SARIF level: error if empty, warning or note
 }
 
 // Validator defines the interface for language validators
@@ -6,11 +6,9 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
-	"os/user"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -18,6 +16,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/cecil-the-coder/mcp-code-api/internal/api"
+	"github.com/cecil-the-coder/mcp-code-api/internal/config"
 	"github.com/fatih/color"
 	"gopkg.in/yaml.v2"
 )
@@ -129,6 +129,8 @@ var (
 	configFile    = flag.String("config", "~/.mcp-code-api/config.yaml", "Configuration file path")
 	verboseOutput = flag.Bool("verbose", false, "Show verbose test output")
 	showHelp      = flag.Bool("help", false, "Show usage information")
+	recordDir     = flag.String("record", "", "Record provider responses to this directory")
+	replayDir     = flag.String("replay", "", "Replay recorded responses from this directory instead of calling providers")
 )
 
 // Global configuration
@@ -191,12 +193,7 @@ func loadConfig(configPath string) (*Config, error) {
 }
 
 func expandPath(path string) string {
-	if strings.HasPrefix(path, "~/") {
-		if usr, err := user.Current(); err == nil {
-			return filepath.Join(usr.HomeDir, path[2:])
-		}
-	}
-	return path
+	return config.ExpandPath(path)
 }
 
 // =============================================
@@ -224,7 +221,7 @@ type MCPClient struct {
 }
 
 func NewMCPClient() (*MCPClient, error) {
-	cmd := exec.Command("go", "run", "./main.go", "server", "--config", *configFile)
+	cmd := exec.Command("go", "run", "./main.go", "server", "--config", expandPath(*configFile))
 
 	// Build environment variables from config
 	var envVars []string
@@ -243,6 +240,13 @@ func NewMCPClient() (*MCPClient, error) {
 		}
 	}
 
+	// Record or replay provider responses (see recording in config.example.yaml)
+	if *recordDir != "" {
+		envVars = append(envVars, "MCP_RECORDING_MODE=record", "MCP_RECORDING_DIR="+expandPath(*recordDir))
+	} else if *replayDir != "" {
+		envVars = append(envVars, "MCP_RECORDING_MODE=replay", "MCP_RECORDING_DIR="+expandPath(*replayDir))
+	}
+
 	cmd.Env = append(os.Environ(), envVars...)
 
 	stdinPipe, err := cmd.StdinPipe()
@@ -425,7 +429,7 @@ func (pt *ProviderTester) TestProvider(ctx context.Context) []*ModelTestResult {
 		}
 	}
 
-	if pt.config.IsLocal {
+	if pt.config.IsLocal && *replayDir == "" {
 		fmt.Printf("🔍 DEBUG: IsLocal provider, checking service...\n")
 		if !pt.isLocalServiceRunning() {
 			fmt.Printf("⚪ %s: %s\n", gray(displayName), skipReason)
@@ -474,7 +478,8 @@ func (pt *ProviderTester) TestProvider(ctx context.Context) []*ModelTestResult {
 }
 
 func (pt *ProviderTester) isConfigured() bool {
-	if pt.config.IsLocal {
+	// Replayed responses need neither API keys nor running services
+	if pt.config.IsLocal || *replayDir != "" {
 		return true
 	}
 	// Check both single APIKey, multiple APIKeys array, and OAuth
@@ -494,6 +499,9 @@ func (pt *ProviderTester) isConfigured() bool {
 }
 
 func (pt *ProviderTester) getSkipReason() string {
+	if *replayDir != "" {
+		return "Replaying recorded responses"
+	}
 	if pt.config.IsLocal {
 		if !pt.isLocalServiceRunning() {
 			switch pt.config.Name {
@@ -522,32 +530,11 @@ func (pt *ProviderTester) getSkipReason() string {
 }
 
 func (pt *ProviderTester) isLocalServiceRunning() bool {
-	if !pt.config.IsLocal || pt.config.BaseURL == "" {
-		return false
-	}
-
-	client := &http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(pt.config.BaseURL + "/health")
-	if err != nil {
+	if !pt.config.IsLocal {
 		return false
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		endpoints := []string{"/v1", "/models", "/"}
-		for _, endpoint := range endpoints {
-			resp, err := client.Get(pt.config.BaseURL + endpoint)
-			if err == nil && resp.StatusCode == 200 {
-				return true
-			}
-			if resp != nil {
-				resp.Body.Close()
-			}
-		}
-		return false
-	}
-
-	return true
+	// Same probe the server uses before routing to a local provider
+	return api.ProbeLocalService(context.Background(), pt.config.BaseURL)
 }
 
 func (pt *ProviderTester) testModel(ctx context.Context, model string) *ModelTestResult {
@@ -711,7 +698,7 @@ func (pt *ProviderTester) testWriteFileWithCapture(ctx context.Context, model st
 	// Sanitize model name for filename
 	sanitizedModel := strings.ReplaceAll(model, "/", "_")
 	sanitizedModel = strings.ReplaceAll(sanitizedModel, ":", "_")
-	outputFile := fmt.Sprintf("/tmp/test_%s_%s_%d.txt", displayName, sanitizedModel, timestamp)
+	outputFile := filepath.Join(os.TempDir(), fmt.Sprintf("test_%s_%s_%d.txt", displayName, sanitizedModel, timestamp))
 
 	request := MCPRequest{
 		JSONRPC: "2.0",
@@ -912,6 +899,8 @@ func printUsage() {
 	fmt.Println("Options:")
 	fmt.Println("  --config string    Configuration file path (default: test-config.yaml)")
 	fmt.Println("  --verbose         Show verbose test output")
+	fmt.Println("  --record dir      Record provider responses to dir")
+	fmt.Println("  --replay dir      Replay responses recorded with --record (no API keys or network)")
 	fmt.Println("  --help, -h       Show this help message")
 	fmt.Println()
 	fmt.Println("Configuration:")